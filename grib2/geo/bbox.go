@@ -0,0 +1,143 @@
+// Package geo provides geographic types shared by the APIs that select or describe areas
+// of the globe.
+package geo
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/scorix/grib/grib2/units"
+)
+
+// BBox is an area bounded by two parallels and two meridians, in degrees. The box spans
+// eastwards from its western edge West to its eastern edge East, so that a box crossing the
+// antimeridian has East < West. A normalized box has West in [-180, 180), East in
+// (-180, 180] and South <= North within [-90, 90]; a box spanning all longitudes has West
+// -180 and East 180.
+//
+// Boxes reaching a pole cover every longitude there, since the pole is a single point.
+type BBox struct {
+	South, North float64 // Latitudes of the southern and northern edges
+	West, East   float64 // Longitudes of the western and eastern edges
+}
+
+// World is the box covering the whole globe
+var World = BBox{South: -90, North: 90, West: -180, East: 180}
+
+// NewBBox returns the normalized box from its south-west corner eastwards to its
+// north-east corner. Longitudes may be given in either [0, 360) or [-180, 180), so that
+// NewBBox(-10, 170, 10, -170) and NewBBox(-10, 170, 10, 190) are the same box crossing
+// the antimeridian.
+func NewBBox(south, west, north, east float64) BBox {
+	return BBox{South: south, North: north, West: west, East: east}.Normalized()
+}
+
+// BBoxAround returns the normalized box centred on a point with the given extent in
+// degrees of latitude and longitude. A box reaching beyond a pole is cut at the pole and
+// spans all longitudes, as does a box at least 360 degrees wide.
+func BBoxAround(lat, lon, height, width float64) BBox {
+	b := BBox{South: lat - height/2, North: lat + height/2, West: lon - width/2, East: lon + width/2}
+	if b.North >= 90 || b.South <= -90 {
+		b.West, b.East = World.West, World.East
+	}
+	return b.Normalized()
+}
+
+// Normalized returns the box with its latitudes ordered and clamped to the globe and its
+// longitudes wrapped into the ranges documented on BBox. The span from West eastwards to
+// East is kept: a box at least 360 degrees wide spans all longitudes, and a box whose East
+// is less than its West, in any range, crosses the antimeridian.
+func (b BBox) Normalized() BBox {
+	south, north := min(b.South, b.North), max(b.South, b.North)
+	out := BBox{South: max(south, -90), North: min(north, 90)}
+
+	if b.East-b.West >= 360 {
+		out.West, out.East = World.West, World.East
+		return out
+	}
+	out.West = wrapLongitude(b.West)
+	out.East = wrapLongitude(b.East)
+	if out.East == -180 && out.West != -180 {
+		out.East = 180
+	}
+	return out
+}
+
+// Width returns the span of the box in degrees of longitude, from 0 to 360
+func (b BBox) Width() float64 {
+	b = b.Normalized()
+	width := b.East - b.West
+	if width < 0 {
+		width += 360
+	}
+	return width
+}
+
+// CrossesAntimeridian reports whether the box spans the 180 degree meridian between its
+// edges
+func (b BBox) CrossesAntimeridian() bool {
+	b = b.Normalized()
+	return b.East < b.West
+}
+
+// Contains reports whether a point lies in the box, edges included. The longitude may be
+// given in either [0, 360) or [-180, 180).
+func (b BBox) Contains(lat, lon float64) bool {
+	b = b.Normalized()
+	if lat < b.South || lat > b.North {
+		return false
+	}
+	if math.Abs(lat) == 90 {
+		return true
+	}
+	return eastOf(b.West, lon) <= b.Width()
+}
+
+// Intersects reports whether the box shares at least one point with another, edges
+// included
+func (b BBox) Intersects(other BBox) bool {
+	b, other = b.Normalized(), other.Normalized()
+	south, north := max(b.South, other.South), min(b.North, other.North)
+	if south > north {
+		return false
+	}
+	if south == 90 || north == -90 {
+		// The boxes only meet at a pole
+		return true
+	}
+	return eastOf(b.West, other.West) <= b.Width() || eastOf(other.West, b.West) <= other.Width()
+}
+
+// String returns the box as its south-west and north-east corners
+func (b BBox) String() string {
+	return fmt.Sprintf("[%g, %g] to [%g, %g]", b.South, b.West, b.North, b.East)
+}
+
+// BBoxFromMicro returns the normalized box from the corners coded in templates: signed
+// latitudes and longitudes in [0, 360) in 10^-6 degree units, e.g. the cluster domain of
+// product templates 4.3 and 4.4
+func BBoxFromMicro(north, south int32, east, west uint32) BBox {
+	return NewBBox(units.Microdegrees(south), float64(west)/1e6, units.Microdegrees(north), float64(east)/1e6)
+}
+
+// Micro returns the corners of the normalized box as coded in templates, the inverse of
+// BBoxFromMicro. A box spanning all longitudes has its western edge at 0 and its eastern
+// edge at 360 degrees.
+func (b BBox) Micro() (north, south int32, east, west uint32) {
+	b = b.Normalized()
+	north, south = units.DegreesToMicro(b.North), units.DegreesToMicro(b.South)
+	if b.Width() == 360 {
+		return north, south, 360e6, 0
+	}
+	return north, south, units.LongitudeToMicro(b.East), units.LongitudeToMicro(b.West)
+}
+
+// eastOf returns how far east of from the longitude lon lies, in [0, 360)
+func eastOf(from, lon float64) float64 {
+	return units.NormalizeLongitude(lon - from)
+}
+
+// wrapLongitude maps a longitude in degrees into [-180, 180)
+func wrapLongitude(lon float64) float64 {
+	return units.NormalizeLongitude(lon+180) - 180
+}
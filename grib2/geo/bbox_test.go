@@ -0,0 +1,133 @@
+package geo_test
+
+import (
+	"math/rand/v2"
+	"testing"
+
+	"github.com/scorix/grib/grib2/geo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// randomBox returns a box on a whole degree grid, often crossing the antimeridian or
+// reaching a pole
+func randomBox(rng *rand.Rand) geo.BBox {
+	south := float64(rng.IntN(181) - 90)
+	north := south + float64(rng.IntN(int(91-south)))
+	west := float64(rng.IntN(360) - 180)
+	return geo.NewBBox(south, west, north, west+float64(rng.IntN(361)))
+}
+
+func TestNewBBox(t *testing.T) {
+	tests := []struct {
+		name                     string
+		south, west, north, east float64
+		want                     geo.BBox
+		crosses                  bool
+		width                    float64
+	}{
+		{"europe", 35, -10, 70, 40, geo.BBox{South: 35, North: 70, West: -10, East: 40}, false, 50},
+		{"0-360 longitudes", 35, 350, 70, 40, geo.BBox{South: 35, North: 70, West: -10, East: 40}, false, 50},
+		{"pacific", -10, 170, 10, -170, geo.BBox{South: -10, North: 10, West: 170, East: -170}, true, 20},
+		{"pacific in 0-360", -10, 170, 10, 190, geo.BBox{South: -10, North: 10, West: 170, East: -170}, true, 20},
+		{"east edge on antimeridian", 0, 170, 10, 180, geo.BBox{South: 0, North: 10, West: 170, East: 180}, false, 10},
+		{"west edge on antimeridian", 0, 180, 10, 190, geo.BBox{South: 0, North: 10, West: -180, East: -170}, false, 10},
+		{"global in 0-360", -90, 0, 90, 360, geo.World, false, 360},
+		{"reversed latitudes", 10, 0, -10, 5, geo.BBox{South: -10, North: 10, West: 0, East: 5}, false, 5},
+		{"beyond the poles", -100, 0, 100, 5, geo.BBox{South: -90, North: 90, West: 0, East: 5}, false, 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := geo.NewBBox(tt.south, tt.west, tt.north, tt.east)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.crosses, got.CrossesAntimeridian())
+			assert.Equal(t, tt.width, got.Width())
+		})
+	}
+}
+
+func TestBBoxAround(t *testing.T) {
+	assert.Equal(t, geo.BBox{South: -5, North: 5, West: 175, East: -175}, geo.BBoxAround(0, 180, 10, 10))
+	assert.Equal(t, geo.BBox{South: 80, North: 90, West: -180, East: 180}, geo.BBoxAround(88, 30, 16, 10))
+	assert.Equal(t, geo.BBox{South: -10, North: 10, West: -180, East: 180}, geo.BBoxAround(0, 30, 20, 400))
+}
+
+func TestBBoxContains(t *testing.T) {
+	pacific := geo.NewBBox(-10, 170, 10, -170)
+	assert.True(t, pacific.Contains(0, 180))
+	assert.True(t, pacific.Contains(0, -180))
+	assert.True(t, pacific.Contains(10, 170))
+	assert.True(t, pacific.Contains(-10, 190))
+	assert.False(t, pacific.Contains(0, 0))
+	assert.False(t, pacific.Contains(11, 180))
+
+	// Every longitude meets at the poles
+	arctic := geo.NewBBox(80, 0, 90, 10)
+	assert.True(t, arctic.Contains(90, 200))
+	assert.False(t, arctic.Contains(85, 200))
+	assert.True(t, arctic.Intersects(geo.NewBBox(90, 100, 90, 120)))
+	assert.False(t, arctic.Intersects(geo.NewBBox(80, 100, 89, 120)))
+}
+
+func TestBBoxProperties(t *testing.T) {
+	rng := rand.New(rand.NewPCG(3, 4))
+	for range 2000 {
+		b, other := randomBox(rng), randomBox(rng)
+		require.Equal(t, b, b.Normalized())
+		require.GreaterOrEqual(t, b.West, -180.0)
+		require.Less(t, b.West, 180.0)
+		require.Greater(t, b.East, -180.0)
+		require.LessOrEqual(t, b.East, 180.0)
+
+		lat, lon := float64(rng.IntN(181)-90), float64(rng.IntN(360)-180)
+		contains := b.Contains(lat, lon)
+
+		// Longitudes are accepted in any range
+		require.Equal(t, contains, b.Contains(lat, lon+360), "%v at %g, %g", b, lat, lon)
+		require.Equal(t, contains, b.Contains(lat, lon-360), "%v at %g, %g", b, lat, lon)
+
+		// Turning the globe moves the box across the antimeridian without changing what it holds
+		shift := float64(rng.IntN(360))
+		shifted := geo.NewBBox(b.South, b.West+shift, b.North, b.West+shift+b.Width())
+		require.Equal(t, b.Width(), shifted.Width())
+		require.Equal(t, contains, shifted.Contains(lat, lon+shift), "%v shifted by %g at %g, %g", b, shift, lat, lon)
+
+		// The box holds its corners and the centre of its edges
+		require.True(t, b.Contains(b.South, b.West))
+		require.True(t, b.Contains(b.North, b.East))
+		require.True(t, b.Contains((b.South+b.North)/2, b.West+b.Width()/2), "%v", b)
+
+		// Boxes holding a common point intersect, whichever comes first
+		require.Equal(t, b.Intersects(other), other.Intersects(b))
+		require.True(t, b.Intersects(b))
+		if contains && other.Contains(lat, lon) {
+			require.True(t, b.Intersects(other), "%v and %v at %g, %g", b, other, lat, lon)
+		}
+
+		// Boxes reaching a pole hold it at every longitude
+		if b.North == 90 {
+			require.True(t, b.Contains(90, lon))
+		}
+		if b.South == -90 {
+			require.True(t, b.Contains(-90, lon))
+		}
+	}
+}
+
+func TestBBoxMicro(t *testing.T) {
+	rng := rand.New(rand.NewPCG(5, 6))
+	for range 500 {
+		b := randomBox(rng)
+		north, south, east, west := b.Micro()
+		assert.Less(t, west, uint32(360e6))
+		assert.LessOrEqual(t, east, uint32(360e6))
+		assert.Equal(t, b, geo.BBoxFromMicro(north, south, east, west), "%v", b)
+	}
+
+	north, south, east, west := geo.NewBBox(-10.5, 170.25, 10, -170).Micro()
+	assert.Equal(t, []any{int32(10000000), int32(-10500000), uint32(190000000), uint32(170250000)}, []any{north, south, east, west})
+
+	north, south, east, west = geo.World.Micro()
+	assert.Equal(t, []any{int32(90000000), int32(-90000000), uint32(360000000), uint32(0)}, []any{north, south, east, west})
+	assert.Equal(t, geo.World, geo.BBoxFromMicro(north, south, east, west))
+}
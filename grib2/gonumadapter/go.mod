@@ -0,0 +1,18 @@
+module github.com/scorix/grib/grib2/gonumadapter
+
+go 1.24.4
+
+require (
+	github.com/scorix/grib/grib2 v0.0.0
+	github.com/stretchr/testify v1.10.0
+	gonum.org/v1/gonum v0.16.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+// The adapter is developed alongside the grib2 module it adapts
+replace github.com/scorix/grib/grib2 => ../
@@ -0,0 +1,42 @@
+// Package gonumadapter converts GRIB2 fields to and from gonum matrices. It is a separate
+// module so that the gonum dependency stays out of the core grib2 module.
+package gonumadapter
+
+import (
+	"fmt"
+
+	"github.com/scorix/grib/grib2/grid"
+	"gonum.org/v1/gonum/mat"
+)
+
+// ToDense returns the values of a field on def as a matrix of nj rows and ni columns, with
+// the northernmost row first and columns in the direction of increasing x (west to east
+// for latitude/longitude grids), whatever the scanning mode of the grid. values holds one
+// value per grid point in scanning order, with missing points included, e.g. as NaN.
+func ToDense(def grid.Definition, values []float64) (*mat.Dense, error) {
+	rows, err := grid.NorthUp(def, values)
+	if err != nil {
+		return nil, fmt.Errorf("gonumadapter: %w", err)
+	}
+	ni, nj := def.Dims()
+	return mat.NewDense(nj, ni, rows), nil
+}
+
+// FromDense is the inverse of ToDense: it returns the elements of m, laid out as by
+// ToDense, in the scanning order of def, ready for encoding
+func FromDense(def grid.Definition, m mat.Matrix) ([]float64, error) {
+	ni, nj := def.Dims()
+	if r, c := m.Dims(); r != nj || c != ni {
+		return nil, fmt.Errorf("gonumadapter: %dx%d matrix for a grid of %d rows and %d columns", r, c, nj, ni)
+	}
+
+	rows := make([]float64, ni*nj)
+	for r := range nj {
+		mat.Row(rows[r*ni:(r+1)*ni], r, m)
+	}
+	values, err := grid.FromNorthUp(def, rows)
+	if err != nil {
+		return nil, fmt.Errorf("gonumadapter: %w", err)
+	}
+	return values, nil
+}
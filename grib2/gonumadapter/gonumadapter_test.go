@@ -0,0 +1,59 @@
+package gonumadapter_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/scorix/grib/grib2/gonumadapter"
+	"github.com/scorix/grib/grib2/grid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestToDense_Orientation(t *testing.T) {
+	// A 1 degree grid over 30N-39N and 100E-119E; the marker sits at 37N 104E, which is
+	// the third row from the north and the fifth column from the west
+	earth := grid.Sphere(6371229)
+	for name, def := range map[string]*grid.RegularLatLon{
+		"north to south": {Ni: 20, Nj: 10, LatFirst: 39, LonFirst: 100, DLat: -1, DLon: 1, Shape: earth},
+		"south to north": {Ni: 20, Nj: 10, LatFirst: 30, LonFirst: 100, DLat: 1, DLon: 1, Scan: grid.ScanPositiveJ, Shape: earth},
+		"east to west, columns": {Ni: 20, Nj: 10, LatFirst: 30, LonFirst: 119, DLat: 1, DLon: -1,
+			Scan: grid.ScanNegativeI | grid.ScanPositiveJ | grid.ScanConsecutiveJ, Shape: earth},
+	} {
+		t.Run(name, func(t *testing.T) {
+			index, ok := grid.LatLonToIndex(def, 37, 104)
+			require.True(t, ok)
+			values := make([]float64, grid.NumberOfPoints(def))
+			values[index] = 1
+
+			m, err := gonumadapter.ToDense(def, values)
+			require.NoError(t, err)
+			r, c := m.Dims()
+			assert.Equal(t, 10, r)
+			assert.Equal(t, 20, c)
+			assert.Equal(t, 1.0, m.At(2, 4))
+			assert.Equal(t, 1.0, mat.Sum(m))
+
+			back, err := gonumadapter.FromDense(def, m)
+			require.NoError(t, err)
+			assert.Equal(t, values, back)
+		})
+	}
+}
+
+func TestDense_Errors(t *testing.T) {
+	def := &grid.RegularLatLon{Ni: 3, Nj: 2, DLat: -1, DLon: 1}
+
+	_, err := gonumadapter.ToDense(def, []float64{1, 2})
+	assert.ErrorContains(t, err, "2 values for a grid of 6 points")
+
+	_, err = gonumadapter.FromDense(def, mat.NewDense(3, 2, nil))
+	assert.ErrorContains(t, err, "3x2 matrix for a grid of 2 rows and 3 columns")
+
+	// Missing points travel as NaN
+	values := []float64{1, math.NaN(), 3, 4, 5, 6}
+	m, err := gonumadapter.ToDense(def, values)
+	require.NoError(t, err)
+	assert.True(t, math.IsNaN(m.At(0, 1)))
+}
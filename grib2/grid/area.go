@@ -0,0 +1,96 @@
+package grid
+
+import (
+	"fmt"
+	"math"
+)
+
+// CellAreas returns the area in square metres of the cell around each grid point, in scanning order.
+//
+// Regular lat/lon cells are spherical quadrilaterals bounded by the parallels half a step
+// from the point, clamped at the poles. Gaussian cells span the sine-of-latitude band given
+// by the row's quadrature weight. Projected cells are Dx·Dy divided by the square of the
+// map factor at the point, which follows the shape of the Earth. Lat/lon and Gaussian
+// areas use the mean radius of the Earth.
+func CellAreas(def Definition) ([]float64, error) {
+	ni, nj := def.Dims()
+	areas := make([]float64, ni*nj)
+	r := def.Earth().Radius()
+
+	switch g := def.(type) {
+	case *RegularLatLon:
+		dLon := radians(math.Abs(g.DLon))
+		for j := 0; j < nj; j++ {
+			lat, _ := g.PointLatLon(0, j)
+			top := clampLatitude(lat + math.Abs(g.DLat)/2)
+			bottom := clampLatitude(lat - math.Abs(g.DLat)/2)
+			area := r * r * dLon * (math.Sin(radians(top)) - math.Sin(radians(bottom)))
+			fillRow(def, areas, j, area)
+		}
+	case *Gaussian:
+		dLon := radians(math.Abs(g.DLon))
+		for j := 0; j < nj; j++ {
+			area := r * r * dLon * g.Weights[j]
+			fillRow(def, areas, j, area)
+		}
+	case *LambertConformal:
+		fillProjected(def, areas, g.Dx*g.Dy, g.MapFactor)
+	case *PolarStereographic:
+		fillProjected(def, areas, g.Dx*g.Dy, g.MapFactor)
+	default:
+		return nil, fmt.Errorf("grid: cell areas are not supported for %T", def)
+	}
+
+	return areas, nil
+}
+
+// AreaMean returns the area weighted mean of values given in scanning order,
+// ignoring NaN values. The mean of a field without valid values is NaN.
+func AreaMean(def Definition, values []float64) (float64, error) {
+	if n := NumberOfPoints(def); len(values) != n {
+		return 0, fmt.Errorf("grid: %d values for a grid of %d points", len(values), n)
+	}
+
+	areas, err := CellAreas(def)
+	if err != nil {
+		return 0, err
+	}
+
+	var sum, total float64
+	for i, v := range values {
+		if math.IsNaN(v) {
+			continue
+		}
+		sum += v * areas[i]
+		total += areas[i]
+	}
+	if total == 0 {
+		return math.NaN(), nil
+	}
+	return sum / total, nil
+}
+
+// fillRow sets the areas of all points of row j
+func fillRow(def Definition, areas []float64, j int, area float64) {
+	ni, _ := def.Dims()
+	for i := 0; i < ni; i++ {
+		areas[IJToIndex(def, i, j)] = area
+	}
+}
+
+// fillProjected sets the areas of a projected grid from the nominal cell area and the map factor
+func fillProjected(def Definition, areas []float64, nominal float64, mapFactor func(lat float64) float64) {
+	ni, nj := def.Dims()
+	for j := 0; j < nj; j++ {
+		for i := 0; i < ni; i++ {
+			lat, _ := def.PointLatLon(i, j)
+			k := mapFactor(lat)
+			areas[IJToIndex(def, i, j)] = nominal / (k * k)
+		}
+	}
+}
+
+// clampLatitude limits a latitude in degrees to [-90, 90]
+func clampLatitude(lat float64) float64 {
+	return math.Max(-90, math.Min(90, lat))
+}
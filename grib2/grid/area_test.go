@@ -0,0 +1,152 @@
+package grid_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/scorix/grib/grib2/grid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sum adds up the values
+func sum(values []float64) float64 {
+	var total float64
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
+
+func TestCellAreas_GlobalLatLon(t *testing.T) {
+	// 0.25 degree global grid from 90N to 90S, as used by GFS
+	g := &grid.RegularLatLon{
+		Ni: 1440, Nj: 721,
+		LatFirst: 90, LonFirst: 0,
+		DLat: -0.25, DLon: 0.25,
+		Shape: grid.Sphere(6371229),
+	}
+
+	areas, err := grid.CellAreas(g)
+	require.NoError(t, err)
+	require.Len(t, areas, 1440*721)
+
+	sphere := 4 * math.Pi * 6371229 * 6371229
+	assert.InEpsilon(t, sphere, sum(areas), 1e-3)
+
+	// Cells shrink towards the poles and are symmetric about the equator
+	assert.Greater(t, areas[360*1440], areas[0])
+	assert.InEpsilon(t, areas[0], areas[720*1440], 1e-9)
+	assert.InEpsilon(t, areas[100*1440], areas[620*1440], 1e-9)
+}
+
+func TestCellAreas_Gaussian(t *testing.T) {
+	lats, weights := grid.GaussianLatitudes(48)
+	g := &grid.Gaussian{
+		Ni: 192, Nj: 96, N: 48,
+		DLon:    360.0 / 192,
+		Lats:    lats,
+		Weights: weights,
+		Shape:   grid.Sphere(6371229),
+	}
+
+	areas, err := grid.CellAreas(g)
+	require.NoError(t, err)
+
+	sphere := 4 * math.Pi * 6371229 * 6371229
+	assert.InEpsilon(t, sphere, sum(areas), 1e-9)
+}
+
+func TestCellAreas_Projected(t *testing.T) {
+	tests := []struct {
+		name string
+		def  grid.Definition
+		lat  float64 // Latitude of true scale
+	}{
+		{
+			name: "lambert conformal",
+			def: &grid.LambertConformal{
+				Nx: 11, Ny: 11,
+				La1: 20, Lo1: 250, LoV: 265,
+				Latin1: 25, Latin2: 25,
+				Dx: 3000, Dy: 3000,
+				Scan:  grid.ScanPositiveJ,
+				Shape: grid.Sphere(6371229),
+			},
+			lat: 25,
+		},
+		{
+			name: "polar stereographic",
+			def: &grid.PolarStereographic{
+				Nx: 11, Ny: 11,
+				La1: 60, Lo1: 250, LoV: 255, LaD: 60,
+				Dx: 5000, Dy: 5000,
+				Scan:  grid.ScanPositiveJ,
+				Shape: grid.Sphere(6371229),
+			},
+			lat: 60,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			areas, err := grid.CellAreas(tt.def)
+			require.NoError(t, err)
+
+			// The first point lies close to the latitude of true scale, where cells are Dx·Dy
+			lat, _ := tt.def.PointLatLon(0, 0)
+			require.InDelta(t, tt.lat, lat, 5)
+			for i, area := range areas {
+				assert.Greater(t, area, 0.0, "point %d", i)
+			}
+
+			switch g := tt.def.(type) {
+			case *grid.LambertConformal:
+				assert.InEpsilon(t, g.Dx*g.Dy, areas[0]*g.MapFactor(lat)*g.MapFactor(lat), 1e-9)
+				assert.InDelta(t, 1, g.MapFactor(tt.lat), 1e-12)
+			case *grid.PolarStereographic:
+				assert.InEpsilon(t, g.Dx*g.Dy, areas[0]*g.MapFactor(lat)*g.MapFactor(lat), 1e-9)
+				assert.InDelta(t, 1, g.MapFactor(tt.lat), 1e-12)
+			}
+		})
+	}
+}
+
+func TestAreaMean(t *testing.T) {
+	g := &grid.RegularLatLon{
+		Ni: 4, Nj: 3,
+		LatFirst: 60, DLat: -60, DLon: 90,
+		Shape: grid.Sphere(6371229),
+	}
+
+	// A constant field has its own value as mean, whatever is missing
+	values := []float64{
+		2, 2, 2, 2,
+		2, math.NaN(), 2, 2,
+		2, 2, 2, math.NaN(),
+	}
+	mean, err := grid.AreaMean(g, values)
+	require.NoError(t, err)
+	assert.InDelta(t, 2, mean, 1e-12)
+
+	// The equatorial row spans 30S to 30N, which is half of the sphere
+	values = []float64{
+		0, 0, 0, 0,
+		1, 1, 1, 1,
+		0, 0, 0, 0,
+	}
+	mean, err = grid.AreaMean(g, values)
+	require.NoError(t, err)
+	assert.InDelta(t, math.Sin(math.Pi/6), mean, 1e-12)
+
+	_, err = grid.AreaMean(g, values[:5])
+	require.Error(t, err)
+
+	mean, err = grid.AreaMean(g, []float64{
+		math.NaN(), math.NaN(), math.NaN(), math.NaN(),
+		math.NaN(), math.NaN(), math.NaN(), math.NaN(),
+		math.NaN(), math.NaN(), math.NaN(), math.NaN(),
+	})
+	require.NoError(t, err)
+	assert.True(t, math.IsNaN(mean))
+}
@@ -0,0 +1,168 @@
+package grid
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/scorix/grib/grib2/template"
+	"github.com/scorix/grib/grib2/units"
+)
+
+// Fields set on the templates built by NewLatLonGrid and NewGaussianGrid
+const (
+	builtShapeOfEarth = 6    // Spherical Earth of radius 6,371,229 m (Code Table 3.2)
+	flagIIncrement    = 0x20 // i direction increments given (Flag Table 3.3)
+	flagJIncrement    = 0x10 // j direction increments given (Flag Table 3.3)
+)
+
+// NewLatLonGrid returns the fields of template 3.0 for the regular latitude/longitude grid
+// from the first grid point (firstLat, firstLon) to the last (lastLat, lastLon), every
+// dLat and dLon degrees. Longitudes may be given in either LonConvention.
+//
+// The increments are positive: rows run from the first latitude towards the last, and
+// columns eastwards from the first longitude to the last, across the antimeridian or the
+// prime meridian when the last longitude is the smaller. Ni and Nj, the scanning mode and
+// the increments in 10^-6 degrees are set to match, on a spherical Earth. The spans between
+// the first and last points must be whole numbers of increments, to within the rounding of
+// the increments to 10^-6 degrees, and the columns must not overlap around the globe.
+func NewLatLonGrid(firstLat, firstLon, lastLat, lastLon, dLat, dLon float64) (*template.LatLonGrid, error) {
+	nj, scan, err := latitudeRows(firstLat, lastLat, dLat)
+	if err != nil {
+		return nil, err
+	}
+	ni, err := longitudeColumns(firstLon, lastLon, dLon)
+	if err != nil {
+		return nil, err
+	}
+	if uint64(ni)*uint64(nj) > math.MaxUint32 {
+		return nil, fmt.Errorf("grid: %dx%d points exceed the number of data points of Section 3", ni, nj)
+	}
+
+	return &template.LatLonGrid{
+		ShapeOfEarth:               builtShapeOfEarth,
+		NumberOfGridPointsAlongX:   uint32(ni),
+		NumberOfGridPointsAlongY:   uint32(nj),
+		SubdivisionOfBasicAngle:    0xffffffff,
+		LatitudeOfFirstGridPoint:   units.DegreesToMicro(firstLat),
+		LongitudeOfFirstGridPoint:  units.LongitudeToMicro(firstLon),
+		ResolutionAndComponentFlag: flagIIncrement | flagJIncrement,
+		LatitudeOfLastGridPoint:    units.DegreesToMicro(lastLat),
+		LongitudeOfLastGridPoint:   units.LongitudeToMicro(lastLon),
+		XDirectionIncrement:        uint32(units.DegreesToMicro(dLon)),
+		YDirectionIncrement:        uint32(units.DegreesToMicro(dLat)),
+		ScanningMode:               scan,
+	}, nil
+}
+
+// NewGaussianGrid returns the fields of template 3.40 for the global regular Gaussian grid
+// with n parallels between a pole and the equator, with rows from north to south and
+// columns eastwards from firstLon to lastLon every dLon degrees, as for NewLatLonGrid
+func NewGaussianGrid(n int, firstLon, lastLon, dLon float64) (*template.GaussianGrid, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("grid: invalid number of parallels %d between a pole and the equator", n)
+	}
+	ni, err := longitudeColumns(firstLon, lastLon, dLon)
+	if err != nil {
+		return nil, err
+	}
+	nj := 2 * n
+	if uint64(ni)*uint64(nj) > math.MaxUint32 {
+		return nil, fmt.Errorf("grid: %dx%d points exceed the number of data points of Section 3", ni, nj)
+	}
+
+	lats, _ := GaussianLatitudes(n)
+	return &template.GaussianGrid{
+		LatLonGrid: template.LatLonGrid{
+			ShapeOfEarth:               builtShapeOfEarth,
+			NumberOfGridPointsAlongX:   uint32(ni),
+			NumberOfGridPointsAlongY:   uint32(nj),
+			SubdivisionOfBasicAngle:    0xffffffff,
+			LatitudeOfFirstGridPoint:   units.DegreesToMicro(lats[0]),
+			LongitudeOfFirstGridPoint:  units.LongitudeToMicro(firstLon),
+			ResolutionAndComponentFlag: flagIIncrement,
+			LatitudeOfLastGridPoint:    units.DegreesToMicro(lats[nj-1]),
+			LongitudeOfLastGridPoint:   units.LongitudeToMicro(lastLon),
+			XDirectionIncrement:        uint32(units.DegreesToMicro(dLon)),
+			YDirectionIncrement:        0xffffffff,
+		},
+		NumberOfParallels: uint32(n),
+	}, nil
+}
+
+// latitudeRows returns the number of rows from firstLat to lastLat every dLat degrees, with
+// the scanning mode giving their direction
+func latitudeRows(firstLat, lastLat, dLat float64) (nj int, scan uint8, err error) {
+	for _, lat := range []float64{firstLat, lastLat} {
+		if !(lat >= -90 && lat <= 90) {
+			return 0, 0, fmt.Errorf("grid: latitude %g is outside [-90, 90]", lat)
+		}
+	}
+	if err := checkIncrement("latitude", dLat); err != nil {
+		return 0, 0, err
+	}
+	direction := -1.0
+	if lastLat > firstLat {
+		scan, direction = ScanPositiveJ, 1
+	}
+	steps, err := wholeSteps("latitude", firstLat, lastLat, math.Abs(lastLat-firstLat), dLat*direction)
+	if err != nil {
+		return 0, 0, err
+	}
+	return steps + 1, scan, nil
+}
+
+// longitudeColumns returns the number of columns eastwards from firstLon to lastLon every
+// dLon degrees
+func longitudeColumns(firstLon, lastLon, dLon float64) (int, error) {
+	if math.IsNaN(firstLon) || math.IsNaN(lastLon) || math.IsInf(firstLon, 0) || math.IsInf(lastLon, 0) {
+		return 0, fmt.Errorf("grid: invalid longitudes %g and %g", firstLon, lastLon)
+	}
+	if err := checkIncrement("longitude", dLon); err != nil {
+		return 0, err
+	}
+	span := NormalizeLongitude(lastLon - firstLon)
+	if span == 0 && lastLon != firstLon {
+		return 0, fmt.Errorf("grid: last longitude %g repeats the first %g around the globe; end at longitude %g for a global grid",
+			lastLon, firstLon, NormalizeLongitude(firstLon-dLon))
+	}
+	steps, err := wholeSteps("longitude", firstLon, lastLon, span, dLon)
+	if err != nil {
+		return 0, err
+	}
+	if float64(steps+1)*dLon > 360+stepTolerance(steps) {
+		return 0, fmt.Errorf("grid: %d columns every %g° overlap around the globe; end at longitude %g for a global grid",
+			steps+1, dLon, NormalizeLongitude(firstLon+360-dLon))
+	}
+	return steps + 1, nil
+}
+
+// checkIncrement checks that an increment in degrees is positive and representable in
+// templates
+func checkIncrement(name string, d float64) error {
+	if !(d > 0) || math.IsInf(d, 0) {
+		return fmt.Errorf("grid: invalid %s increment %g; increments are positive, the scanning direction follows from the first and last points", name, d)
+	}
+	if d < 1e-6 {
+		return fmt.Errorf("grid: %s increment %g is below the resolution of 10^-6 degrees", name, d)
+	}
+	return nil
+}
+
+// wholeSteps returns the number of increments in the span between first and last, where
+// step is the increment signed in the direction from first to last
+func wholeSteps(name string, first, last, span, step float64) (int, error) {
+	d := math.Abs(step)
+	steps := math.Round(span / d)
+	if math.Abs(span-steps*d) > stepTolerance(int(steps)) {
+		below := math.Floor(span / d)
+		return 0, fmt.Errorf("grid: the %g° from %s %g to %g is not a whole number of %g° increments; end at %s %g or %g",
+			span, name, first, last, d, name, first+below*step, first+(below+1)*step)
+	}
+	return int(steps), nil
+}
+
+// stepTolerance returns the error allowed on a span of n increments: half of 10^-6 degrees
+// at each end and per increment, for increments rounded to the resolution of the template
+func stepTolerance(n int) float64 {
+	return 0.5e-6 * float64(n+2)
+}
@@ -0,0 +1,106 @@
+package grid_test
+
+import (
+	"testing"
+
+	"github.com/scorix/grib/grib2/grid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLatLonGrid(t *testing.T) {
+	t.Run("global quarter degree", func(t *testing.T) {
+		g, err := grid.NewLatLonGrid(90, 0, -90, 359.75, 0.25, 0.25)
+		require.NoError(t, err)
+		assert.Equal(t, uint32(1440), g.NumberOfGridPointsAlongX)
+		assert.Equal(t, uint32(721), g.NumberOfGridPointsAlongY)
+		assert.Equal(t, uint32(250000), g.XDirectionIncrement)
+		assert.Equal(t, uint32(250000), g.YDirectionIncrement)
+		assert.Equal(t, uint8(0), g.ScanningMode)
+
+		tmpl := g.GridTemplate()
+		assert.Equal(t, 1440*721, tmpl.NumberOfDataPoints)
+		def, err := grid.FromTemplate(&tmpl)
+		require.NoError(t, err)
+		assert.Equal(t, 1440*721, grid.NumberOfPoints(def))
+		lat, lon := grid.LatLonAt(def, 0)
+		assert.Equal(t, []float64{90, 0}, []float64{lat, lon})
+		lat, lon = grid.LatLonAt(def, 1440*721-1)
+		assert.InDelta(t, -90, lat, 1e-9)
+		assert.InDelta(t, 359.75, lon, 1e-9)
+	})
+
+	t.Run("regional across the antimeridian", func(t *testing.T) {
+		// South to north over the North Pacific, given in -180..180
+		g, err := grid.NewLatLonGrid(10, 150, 60, -120, 0.5, 2)
+		require.NoError(t, err)
+		assert.Equal(t, uint32(46), g.NumberOfGridPointsAlongX)
+		assert.Equal(t, uint32(101), g.NumberOfGridPointsAlongY)
+		assert.Equal(t, grid.ScanPositiveJ, g.ScanningMode)
+		assert.Equal(t, uint32(240000000), g.LongitudeOfLastGridPoint)
+
+		tmpl := g.GridTemplate()
+		def, err := grid.FromTemplate(&tmpl)
+		require.NoError(t, err)
+		lat, lon := grid.LatLonAt(def, grid.NumberOfPoints(def)-1)
+		assert.InDelta(t, 60, lat, 1e-9)
+		assert.InDelta(t, 240, lon, 1e-9)
+	})
+
+	t.Run("increments rounded to microdegrees", func(t *testing.T) {
+		g, err := grid.NewLatLonGrid(0, 0, 1, 359.916667, 1.0/12, 0.083333)
+		require.NoError(t, err)
+		assert.Equal(t, uint32(4320), g.NumberOfGridPointsAlongX)
+		assert.Equal(t, uint32(13), g.NumberOfGridPointsAlongY)
+	})
+
+	inconsistent := []struct {
+		name                                             string
+		firstLat, firstLon, lastLat, lastLon, dLat, dLon float64
+		want                                             string
+	}{
+		{"longitude span", 90, 0, -90, 359.8, 0.25, 0.25, "the 359.8° from longitude 0 to 359.8 is not a whole number of 0.25° increments; end at longitude 359.75 or 360"},
+		{"latitude span", 90, 0, -89.9, 359.75, 0.25, 0.25, "end at latitude -89.75 or -90"},
+		{"overlapping columns", 90, 0, -90, 359.8, 0.25, 0.7, "515 columns every 0.7° overlap around the globe"},
+		{"repeated first column", 90, 0, -90, 360, 0.25, 0.25, "last longitude 360 repeats the first 0 around the globe; end at longitude 359.75 for a global grid"},
+		{"negative increment", 90, 0, -90, 359.75, -0.25, 0.25, "invalid latitude increment -0.25; increments are positive"},
+		{"zero increment", 90, 0, -90, 359.75, 0.25, 0, "invalid longitude increment 0"},
+		{"latitude beyond a pole", 91, 0, -90, 359.75, 0.25, 0.25, "latitude 91 is outside [-90, 90]"},
+	}
+	for _, tt := range inconsistent {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := grid.NewLatLonGrid(tt.firstLat, tt.firstLon, tt.lastLat, tt.lastLon, tt.dLat, tt.dLon)
+			assert.ErrorContains(t, err, tt.want)
+		})
+	}
+
+	// A single point is a grid
+	g, err := grid.NewLatLonGrid(45, 10, 45, 10, 1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, g.GridTemplate().NumberOfDataPoints)
+}
+
+func TestNewGaussianGrid(t *testing.T) {
+	g, err := grid.NewGaussianGrid(8, 0, 337.5, 22.5)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(16), g.NumberOfGridPointsAlongX)
+	assert.Equal(t, uint32(16), g.NumberOfGridPointsAlongY)
+	assert.Equal(t, uint32(8), g.NumberOfParallels)
+
+	tmpl := g.GridTemplate()
+	assert.Equal(t, 40, tmpl.TemplateNumber)
+	assert.Equal(t, 256, tmpl.NumberOfDataPoints)
+	def, err := grid.FromTemplate(&tmpl)
+	require.NoError(t, err)
+
+	lats, _ := grid.GaussianLatitudes(8)
+	gaussian, ok := def.(*grid.Gaussian)
+	require.True(t, ok)
+	assert.InDeltaSlice(t, lats, gaussian.Lats, 1e-9)
+	assert.Equal(t, 22.5, gaussian.DLon)
+
+	_, err = grid.NewGaussianGrid(0, 0, 337.5, 22.5)
+	assert.ErrorContains(t, err, "invalid number of parallels 0")
+	_, err = grid.NewGaussianGrid(8, 0, 340, 22.5)
+	assert.ErrorContains(t, err, "not a whole number of 22.5° increments")
+}
@@ -0,0 +1,59 @@
+package grid
+
+import (
+	"strconv"
+	"strings"
+)
+
+// CFGridMapping returns the attributes of the CF grid mapping variable describing the
+// coordinate reference system of a grid (CF Conventions, Appendix F), and false for grids
+// without a CF grid mapping. The Earth is given by earth_radius for a sphere, and by
+// semi_major_axis and semi_minor_axis for a spheroid. Angles are in degrees.
+//
+// The attributes describe the projection only: coordinate variables are left to the
+// exporter, so no false easting or northing is given.
+func CFGridMapping(def Definition) (map[string]string, bool) {
+	var attrs map[string]string
+	switch g := def.(type) {
+	case *RegularLatLon, *Gaussian:
+		attrs = map[string]string{"grid_mapping_name": "latitude_longitude"}
+	case *LambertConformal:
+		parallels := []float64{g.Latin1}
+		if g.Latin2 != g.Latin1 {
+			parallels = append(parallels, g.Latin2)
+		}
+		attrs = map[string]string{
+			"grid_mapping_name":             "lambert_conformal_conic",
+			"standard_parallel":             formatCF(parallels...),
+			"longitude_of_central_meridian": formatCF(g.LoV),
+			"latitude_of_projection_origin": formatCF(g.LaD),
+		}
+	case *PolarStereographic:
+		attrs = map[string]string{
+			"grid_mapping_name":                     "polar_stereographic",
+			"straight_vertical_longitude_from_pole": formatCF(g.LoV),
+			"latitude_of_projection_origin":         formatCF(90 * g.hemisphere()),
+			"standard_parallel":                     formatCF(g.LaD),
+		}
+	default:
+		return nil, false
+	}
+
+	earth := def.Earth()
+	if earth.IsSphere() {
+		attrs["earth_radius"] = formatCF(earth.SemiMajorAxis)
+	} else {
+		attrs["semi_major_axis"] = formatCF(earth.SemiMajorAxis)
+		attrs["semi_minor_axis"] = formatCF(earth.SemiMinorAxis)
+	}
+	return attrs, true
+}
+
+// formatCF formats numeric attribute values as CDL does, separated by spaces
+func formatCF(values ...float64) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,31 @@
+package grid_test
+
+import (
+	"testing"
+
+	"github.com/scorix/grib/grib2/grid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCFGridMapping(t *testing.T) {
+	polar := &grid.PolarStereographic{
+		Nx: 10, Ny: 10, LoV: 315, LaD: -71, SouthPole: true,
+		Shape: grid.Earth{SemiMajorAxis: 6378137, SemiMinorAxis: 6356752.3142},
+	}
+	attrs, ok := grid.CFGridMapping(polar)
+	assert.True(t, ok)
+	assert.Equal(t, map[string]string{
+		"grid_mapping_name":                     "polar_stereographic",
+		"straight_vertical_longitude_from_pole": "315",
+		"latitude_of_projection_origin":         "-90",
+		"standard_parallel":                     "-71",
+		"semi_major_axis":                       "6378137",
+		"semi_minor_axis":                       "6356752.3142",
+	}, attrs)
+
+	lambert := &grid.LambertConformal{LoV: 265, LaD: 25, Latin1: 25, Latin2: 45, Shape: grid.Sphere(6371229)}
+	attrs, ok = grid.CFGridMapping(lambert)
+	assert.True(t, ok)
+	assert.Equal(t, "25 45", attrs["standard_parallel"])
+	assert.Equal(t, "6371229", attrs["earth_radius"])
+}
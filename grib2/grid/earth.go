@@ -0,0 +1,125 @@
+package grid
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/scorix/grib/grib2/units"
+)
+
+// Earth describes the shape of the Earth as a sphere or an oblate spheroid
+type Earth struct {
+	SemiMajorAxis float64 // Equatorial radius in metres
+	SemiMinorAxis float64 // Polar radius in metres, equal to SemiMajorAxis for a sphere
+}
+
+// Sphere returns a spherical Earth of the given radius in metres
+func Sphere(radius float64) Earth {
+	return Earth{SemiMajorAxis: radius, SemiMinorAxis: radius}
+}
+
+// IsSphere reports whether the Earth is modelled as a sphere
+func (e Earth) IsSphere() bool {
+	return e.SemiMajorAxis == e.SemiMinorAxis
+}
+
+// Radius returns the radius used for spherical computations. For a spheroid this is
+// the mean radius (2a + b) / 3, which keeps areas and distances within about 0.2% of
+// their ellipsoidal values.
+func (e Earth) Radius() float64 {
+	if e.IsSphere() {
+		return e.SemiMajorAxis
+	}
+	return (2*e.SemiMajorAxis + e.SemiMinorAxis) / 3
+}
+
+// Eccentricity returns the first eccentricity of the Earth, which is zero for a sphere
+func (e Earth) Eccentricity() float64 {
+	if e.IsSphere() {
+		return 0
+	}
+	a, b := e.SemiMajorAxis, e.SemiMinorAxis
+	return math.Sqrt(1 - b*b/(a*a))
+}
+
+// conformalM returns the ratio of the parallel radius to the semi-major axis at latitude phi
+// in radians on a spheroid of eccentricity e (Snyder's m)
+func conformalM(phi, e float64) float64 {
+	s := e * math.Sin(phi)
+	return math.Cos(phi) / math.Sqrt(1-s*s)
+}
+
+// conformalT returns Snyder's t for latitude phi in radians on a spheroid of eccentricity e;
+// on a sphere it is tan(π/4 - phi/2)
+func conformalT(phi, e float64) float64 {
+	s := e * math.Sin(phi)
+	return math.Tan(math.Pi/4-phi/2) / math.Pow((1-s)/(1+s), e/2)
+}
+
+// conformalLatitude inverts conformalT, returning the latitude in radians for Snyder's t
+func conformalLatitude(t, e float64) float64 {
+	phi := math.Pi/2 - 2*math.Atan(t)
+	for range 15 {
+		s := e * math.Sin(phi)
+		next := math.Pi/2 - 2*math.Atan(t*math.Pow((1-s)/(1+s), e/2))
+		if math.Abs(next-phi) < 1e-12 {
+			return next
+		}
+		phi = next
+	}
+	return phi
+}
+
+// EarthShape holds the shape of the Earth fields common to grid definition templates
+type EarthShape struct {
+	ShapeOfEarth           uint8  // Shape of the Earth (Code Table 3.2)
+	ScaleFactorRadiusEarth uint8  // Scale factor of radius of spherical Earth
+	ScaledValueRadiusEarth uint32 // Scaled value of radius of spherical Earth
+	ScaleFactorMajorAxis   uint8  // Scale factor of major axis of oblate spheroid Earth
+	ScaledValueMajorAxis   uint32 // Scaled value of major axis of oblate spheroid Earth
+	ScaleFactorMinorAxis   uint8  // Scale factor of minor axis of oblate spheroid Earth
+	ScaledValueMinorAxis   uint32 // Scaled value of minor axis of oblate spheroid Earth
+}
+
+// earthShapes contains the Code Table 3.2 shapes with predefined axes
+var earthShapes = map[uint8]Earth{
+	0:  Sphere(6367470),
+	2:  {SemiMajorAxis: 6378160, SemiMinorAxis: 6356775},      // IAU 1965
+	4:  {SemiMajorAxis: 6378137, SemiMinorAxis: 6356752.314},  // IAG-GRS80
+	5:  {SemiMajorAxis: 6378137, SemiMinorAxis: 6356752.3142}, // WGS84
+	6:  Sphere(6371229),
+	8:  Sphere(6371200),
+	9:  {SemiMajorAxis: 6377563.396, SemiMinorAxis: 6356256.909}, // OSGB 1936 Airy 1830
+	10: {SemiMajorAxis: 6378137, SemiMinorAxis: 6356752.3142},    // WGS84 with corrected geomagnetic coordinates
+}
+
+// Earth returns the shape of the Earth described by Code Table 3.2
+func (s EarthShape) Earth() (Earth, error) {
+	if earth, ok := earthShapes[s.ShapeOfEarth]; ok {
+		return earth, nil
+	}
+
+	switch s.ShapeOfEarth {
+	case 1:
+		return Sphere(scaled(s.ScaleFactorRadiusEarth, s.ScaledValueRadiusEarth)), nil
+	case 3:
+		// Axes specified in km
+		return Earth{
+			SemiMajorAxis: 1000 * scaled(s.ScaleFactorMajorAxis, s.ScaledValueMajorAxis),
+			SemiMinorAxis: 1000 * scaled(s.ScaleFactorMinorAxis, s.ScaledValueMinorAxis),
+		}, nil
+	case 7:
+		// Axes specified in m
+		return Earth{
+			SemiMajorAxis: scaled(s.ScaleFactorMajorAxis, s.ScaledValueMajorAxis),
+			SemiMinorAxis: scaled(s.ScaleFactorMinorAxis, s.ScaledValueMinorAxis),
+		}, nil
+	default:
+		return Earth{}, fmt.Errorf("grid: unsupported shape of the earth %d", s.ShapeOfEarth)
+	}
+}
+
+// scaled computes value × 10^-scaleFactor; the Earth shape scale factors are unsigned
+func scaled(scaleFactor uint8, value uint32) float64 {
+	return units.ScaledValue(int8(min(scaleFactor, 127)), value)
+}
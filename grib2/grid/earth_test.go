@@ -0,0 +1,49 @@
+package grid_test
+
+import (
+	"testing"
+
+	"github.com/scorix/grib/grib2/grid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEarthShape_Earth(t *testing.T) {
+	tests := []struct {
+		name    string
+		shape   grid.EarthShape
+		want    grid.Earth
+		wantErr bool
+	}{
+		{name: "predefined sphere", shape: grid.EarthShape{ShapeOfEarth: 6}, want: grid.Sphere(6371229)},
+		{name: "WGS84", shape: grid.EarthShape{ShapeOfEarth: 5}, want: grid.Earth{SemiMajorAxis: 6378137, SemiMinorAxis: 6356752.3142}},
+		{
+			name:  "specified radius",
+			shape: grid.EarthShape{ShapeOfEarth: 1, ScaleFactorRadiusEarth: 1, ScaledValueRadiusEarth: 63712290},
+			want:  grid.Sphere(6371229),
+		},
+		{
+			name: "axes in km",
+			shape: grid.EarthShape{
+				ShapeOfEarth:         3,
+				ScaleFactorMajorAxis: 0, ScaledValueMajorAxis: 6378,
+				ScaleFactorMinorAxis: 0, ScaledValueMinorAxis: 6357,
+			},
+			want: grid.Earth{SemiMajorAxis: 6378000, SemiMinorAxis: 6357000},
+		},
+		{name: "unsupported", shape: grid.EarthShape{ShapeOfEarth: 200}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.shape.Earth()
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.InDelta(t, tt.want.SemiMajorAxis, got.SemiMajorAxis, 1e-6)
+			assert.InDelta(t, tt.want.SemiMinorAxis, got.SemiMinorAxis, 1e-6)
+		})
+	}
+}
@@ -0,0 +1,258 @@
+package grid
+
+import (
+	"fmt"
+	"math"
+)
+
+// statOp selects the statistic computed by FocalStat
+type statOp uint8
+
+const (
+	statMax statOp = iota
+	statMin
+	statMean
+	statFractionAbove
+)
+
+// StatKind is a statistic computed over the neighbourhood of each grid point
+type StatKind struct {
+	op        statOp
+	threshold float64
+}
+
+// Neighbourhood statistics
+var (
+	StatMax  = StatKind{op: statMax}  // Maximum value
+	StatMin  = StatKind{op: statMin}  // Minimum value
+	StatMean = StatKind{op: statMean} // Unweighted mean value
+)
+
+// StatFractionAbove returns the statistic giving the fraction of values strictly above
+// threshold, e.g. a neighbourhood probability when applied to ensemble members
+func StatFractionAbove(threshold float64) StatKind {
+	return StatKind{op: statFractionAbove, threshold: threshold}
+}
+
+// String returns the name of the statistic
+func (s StatKind) String() string {
+	switch s.op {
+	case statMax:
+		return "max"
+	case statMin:
+		return "min"
+	case statMean:
+		return "mean"
+	case statFractionAbove:
+		return fmt.Sprintf("fraction above %g", s.threshold)
+	default:
+		return fmt.Sprintf("stat(%d)", s.op)
+	}
+}
+
+// FocalStat computes a statistic over the circular neighbourhood of every grid point.
+//
+// The neighbourhood of a point holds the points, itself included, whose great circle
+// distance on the sphere of the Earth's mean radius is at most radiusMeters. NaN values
+// are ignored; a point whose whole neighbourhood is NaN gets NaN. Values and the result
+// are in scanning order.
+//
+// Regular lat/lon and Gaussian grids convert the radius into a window of columns per row,
+// wrapping around global grids; projected grids use a window derived from the grid lengths.
+//
+// The statistic combines the values of a single field over space, so it applies whatever
+// the statistical processing of the field over time: the neighbourhood mean of 6-hour
+// maxima is the mean of those maxima over space. Combining fields over time or across
+// ensemble members is another matter, see template.StatisticalProcess.IsExtreme.
+func FocalStat(def Definition, values []float64, radiusMeters float64, stat StatKind) ([]float64, error) {
+	if n := NumberOfPoints(def); len(values) != n {
+		return nil, fmt.Errorf("grid: %d values for a grid of %d points", len(values), n)
+	}
+	if radiusMeters < 0 || math.IsNaN(radiusMeters) {
+		return nil, fmt.Errorf("grid: invalid neighbourhood radius %g", radiusMeters)
+	}
+	if stat.op > statFractionAbove {
+		return nil, fmt.Errorf("grid: unsupported statistic %s", stat)
+	}
+
+	ni, nj := def.Dims()
+	lats := make([]float64, ni*nj)
+	lons := make([]float64, ni*nj)
+	for j := 0; j < nj; j++ {
+		for i := 0; i < ni; i++ {
+			lats[j*ni+i], lons[j*ni+i] = def.PointLatLon(i, j)
+		}
+	}
+
+	windows, err := neighbourhoodWindows(def, lats, radiusMeters)
+	if err != nil {
+		return nil, err
+	}
+
+	r := def.Earth().Radius()
+	result := make([]float64, len(values))
+	for j := 0; j < nj; j++ {
+		for i := 0; i < ni; i++ {
+			acc := accumulator{stat: stat}
+			lat, lon := lats[j*ni+i], lons[j*ni+i]
+			windows(i, j, func(i2, j2 int) {
+				if greatCircleDistance(r, lat, lon, lats[j2*ni+i2], lons[j2*ni+i2]) <= radiusMeters {
+					acc.add(values[IJToIndex(def, i2, j2)])
+				}
+			})
+			result[IJToIndex(def, i, j)] = acc.result()
+		}
+	}
+
+	return result, nil
+}
+
+// window calls visit for the grid coordinates of every candidate neighbour of (i, j);
+// candidates farther than the radius are filtered out by the caller
+type window func(i, j int, visit func(i2, j2 int))
+
+// neighbourhoodWindows returns the candidate neighbours function of a grid
+// for a radius in metres, given the latitudes of its points in (j, i) order
+func neighbourhoodWindows(def Definition, lats []float64, radius float64) (window, error) {
+	ni, nj := def.Dims()
+	r := def.Earth().Radius()
+
+	switch g := def.(type) {
+	case *RegularLatLon:
+		return rowWindows(lats, ni, nj, math.Abs(g.DLon), r, radius), nil
+	case *Gaussian:
+		return rowWindows(lats, ni, nj, math.Abs(g.DLon), r, radius), nil
+	case *LambertConformal:
+		return projectedWindows(lats, ni, nj, g.Dx, g.Dy, g.MapFactor, radius), nil
+	case *PolarStereographic:
+		return projectedWindows(lats, ni, nj, g.Dx, g.Dy, g.MapFactor, radius), nil
+	default:
+		return nil, fmt.Errorf("grid: neighbourhoods are not supported for %T", def)
+	}
+}
+
+// rowSpan is the half-width in columns of the window of a row around a point of another row
+type rowSpan struct {
+	row       int
+	halfWidth int
+}
+
+// rowWindows builds the candidate neighbours of grids whose rows lie on parallels.
+// For each pair of rows, the largest longitude difference within the radius gives
+// the half-width of the column window.
+func rowWindows(lats []float64, ni, nj int, dLon, r, radius float64) window {
+	global := math.Abs(float64(ni)*dLon-360) < 1e-6
+	angle := radius / r
+	if angle > math.Pi {
+		angle = math.Pi
+	}
+
+	spans := make([][]rowSpan, nj)
+	for j := 0; j < nj; j++ {
+		phi1 := radians(lats[j*ni])
+		for j2 := 0; j2 < nj; j2++ {
+			phi2 := radians(lats[j2*ni])
+			if math.Abs(phi1-phi2) > angle+1e-12 {
+				continue
+			}
+
+			halfWidth := ni
+			if denominator := math.Cos(phi1) * math.Cos(phi2); denominator > 1e-12 && dLon > 0 {
+				c := (math.Cos(angle) - math.Sin(phi1)*math.Sin(phi2)) / denominator
+				if c > -1 {
+					// One extra column absorbs rounding; the distance check filters it out
+					halfWidth = int(degrees(math.Acos(math.Min(c, 1)))/dLon) + 1
+				}
+			}
+			spans[j] = append(spans[j], rowSpan{row: j2, halfWidth: halfWidth})
+		}
+	}
+
+	return func(i, j int, visit func(i2, j2 int)) {
+		for _, span := range spans[j] {
+			if global && 2*span.halfWidth+1 >= ni {
+				for i2 := 0; i2 < ni; i2++ {
+					visit(i2, span.row)
+				}
+				continue
+			}
+			for i2 := i - span.halfWidth; i2 <= i+span.halfWidth; i2++ {
+				switch {
+				case global:
+					visit((i2%ni+ni)%ni, span.row)
+				case i2 >= 0 && i2 < ni:
+					visit(i2, span.row)
+				}
+			}
+		}
+	}
+}
+
+// projectedWindows builds the candidate neighbours of projected grids from the grid
+// lengths on the ground at each point, with a margin for the variation of the map factor
+func projectedWindows(lats []float64, ni, nj int, dx, dy float64, mapFactor func(lat float64) float64, radius float64) window {
+	return func(i, j int, visit func(i2, j2 int)) {
+		k := mapFactor(lats[j*ni+i])
+		di := int(math.Ceil(radius*k/dx)) + 1
+		dj := int(math.Ceil(radius*k/dy)) + 1
+		for j2 := max(j-dj, 0); j2 <= min(j+dj, nj-1); j2++ {
+			for i2 := max(i-di, 0); i2 <= min(i+di, ni-1); i2++ {
+				visit(i2, j2)
+			}
+		}
+	}
+}
+
+// accumulator computes a statistic over the non-NaN values added to it
+type accumulator struct {
+	stat  StatKind
+	count int
+	value float64 // Running maximum, minimum, sum or count above the threshold
+}
+
+func (a *accumulator) add(v float64) {
+	if math.IsNaN(v) {
+		return
+	}
+
+	switch a.stat.op {
+	case statMax:
+		if a.count == 0 || v > a.value {
+			a.value = v
+		}
+	case statMin:
+		if a.count == 0 || v < a.value {
+			a.value = v
+		}
+	case statMean:
+		a.value += v
+	case statFractionAbove:
+		if v > a.stat.threshold {
+			a.value++
+		}
+	}
+	a.count++
+}
+
+func (a *accumulator) result() float64 {
+	if a.count == 0 {
+		return math.NaN()
+	}
+
+	switch a.stat.op {
+	case statMean, statFractionAbove:
+		return a.value / float64(a.count)
+	default:
+		return a.value
+	}
+}
+
+// greatCircleDistance returns the distance in metres between two points in degrees
+// on a sphere of radius r, using the haversine formula
+func greatCircleDistance(r, lat1, lon1, lat2, lon2 float64) float64 {
+	phi1, phi2 := radians(lat1), radians(lat2)
+	sinLat := math.Sin((phi2 - phi1) / 2)
+	sinLon := math.Sin(radians(lon2-lon1) / 2)
+	h := sinLat*sinLat + math.Cos(phi1)*math.Cos(phi2)*sinLon*sinLon
+	return 2 * r * math.Asin(math.Sqrt(math.Min(h, 1)))
+}
@@ -0,0 +1,152 @@
+package grid_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/scorix/grib/grib2/grid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// bruteForceFocalStat compares every pair of points
+func bruteForceFocalStat(def grid.Definition, values []float64, radius float64, stat string, threshold float64) []float64 {
+	r := def.Earth().Radius()
+	result := make([]float64, len(values))
+	for a := range values {
+		lat1, lon1 := grid.LatLonAt(def, a)
+
+		var selected []float64
+		for b, v := range values {
+			lat2, lon2 := grid.LatLonAt(def, b)
+			if haversine(r, lat1, lon1, lat2, lon2) <= radius && !math.IsNaN(v) {
+				selected = append(selected, v)
+			}
+		}
+
+		if len(selected) == 0 {
+			result[a] = math.NaN()
+			continue
+		}
+		switch stat {
+		case "max":
+			result[a] = math.Inf(-1)
+			for _, v := range selected {
+				result[a] = math.Max(result[a], v)
+			}
+		case "min":
+			result[a] = math.Inf(1)
+			for _, v := range selected {
+				result[a] = math.Min(result[a], v)
+			}
+		case "mean":
+			result[a] = sum(selected) / float64(len(selected))
+		case "fraction":
+			above := 0
+			for _, v := range selected {
+				if v > threshold {
+					above++
+				}
+			}
+			result[a] = float64(above) / float64(len(selected))
+		}
+	}
+	return result
+}
+
+func haversine(r, lat1, lon1, lat2, lon2 float64) float64 {
+	rad := math.Pi / 180
+	sinLat := math.Sin((lat2 - lat1) * rad / 2)
+	sinLon := math.Sin((lon2 - lon1) * rad / 2)
+	h := sinLat*sinLat + math.Cos(lat1*rad)*math.Cos(lat2*rad)*sinLon*sinLon
+	return 2 * r * math.Asin(math.Sqrt(math.Min(h, 1)))
+}
+
+func TestFocalStat(t *testing.T) {
+	lats, weights := grid.GaussianLatitudes(8)
+	grids := []struct {
+		name   string
+		def    grid.Definition
+		radius float64
+	}{
+		{
+			name:   "global lat/lon",
+			def:    &grid.RegularLatLon{Ni: 36, Nj: 19, LatFirst: 90, DLat: -10, DLon: 10, Shape: grid.Sphere(6371229)},
+			radius: 1500e3,
+		},
+		{
+			name:   "regional lat/lon scanning northwards",
+			def:    &grid.RegularLatLon{Ni: 20, Nj: 15, LatFirst: 20, LonFirst: 350, DLat: 2, DLon: 2, Scan: grid.ScanPositiveJ, Shape: grid.Sphere(6371229)},
+			radius: 500e3,
+		},
+		{
+			name:   "gaussian j consecutive",
+			def:    &grid.Gaussian{Ni: 32, Nj: 16, N: 8, DLon: 11.25, Lats: lats, Weights: weights, Scan: grid.ScanConsecutiveJ, Shape: grid.Sphere(6371229)},
+			radius: 2000e3,
+		},
+		{
+			name: "lambert conformal",
+			def: &grid.LambertConformal{
+				Nx: 25, Ny: 20, La1: 20, Lo1: 240, LoV: 265, Latin1: 25, Latin2: 25,
+				Dx: 40000, Dy: 40000, Scan: grid.ScanPositiveJ, Shape: grid.Sphere(6371229),
+			},
+			radius: 130e3,
+		},
+	}
+
+	stats := []struct {
+		name string
+		kind grid.StatKind
+	}{
+		{"max", grid.StatMax},
+		{"min", grid.StatMin},
+		{"mean", grid.StatMean},
+		{"fraction", grid.StatFractionAbove(0.7)},
+	}
+
+	for _, g := range grids {
+		rng := rand.New(rand.NewSource(1))
+		values := make([]float64, grid.NumberOfPoints(g.def))
+		for i := range values {
+			values[i] = rng.Float64()
+			if rng.Intn(10) == 0 {
+				values[i] = math.NaN()
+			}
+		}
+
+		for _, s := range stats {
+			t.Run(g.name+"/"+s.name, func(t *testing.T) {
+				got, err := grid.FocalStat(g.def, values, g.radius, s.kind)
+				require.NoError(t, err)
+
+				want := bruteForceFocalStat(g.def, values, g.radius, s.name, 0.7)
+				require.Len(t, got, len(want))
+				for i := range want {
+					if math.IsNaN(want[i]) {
+						assert.True(t, math.IsNaN(got[i]), "point %d", i)
+						continue
+					}
+					assert.InDelta(t, want[i], got[i], 1e-12, "point %d", i)
+				}
+			})
+		}
+	}
+}
+
+func TestFocalStat_Errors(t *testing.T) {
+	g := &grid.RegularLatLon{Ni: 4, Nj: 4, DLat: 1, DLon: 1, Shape: grid.Sphere(6371229)}
+
+	_, err := grid.FocalStat(g, make([]float64, 15), 1000, grid.StatMax)
+	require.Error(t, err)
+
+	_, err = grid.FocalStat(g, make([]float64, 16), -1, grid.StatMax)
+	require.Error(t, err)
+
+	// A zero radius leaves each point alone
+	values := []float64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, math.NaN()}
+	got, err := grid.FocalStat(g, values, 0, grid.StatMean)
+	require.NoError(t, err)
+	assert.Equal(t, values[:15], got[:15])
+	assert.True(t, math.IsNaN(got[15]))
+}
@@ -0,0 +1,196 @@
+package grid
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/scorix/grib/grib2/template"
+)
+
+// Gaussian is a regular Gaussian latitude/longitude grid (template 3.40).
+// Rows lie on the Gaussian latitudes of a grid with N parallels between a pole and the equator.
+type Gaussian struct {
+	Ni, Nj   int       // Number of points along a parallel and along a meridian
+	N        int       // Number of parallels between a pole and the equator
+	LonFirst float64   // Longitude of the first grid point in degrees
+	DLon     float64   // Longitude step between columns in degrees, negative when scanning westwards
+	Lats     []float64 // Latitude of each row in degrees, in j order
+	Weights  []float64 // Gaussian quadrature weight of each row, in j order
+	Scan     uint8     // Scanning mode flags (Flag Table 3.4)
+	Shape    Earth     // Shape of the Earth
+}
+
+var _ Definition = (*Gaussian)(nil)
+
+// Dims returns the number of points along a parallel and along a meridian
+func (g *Gaussian) Dims() (ni, nj int) {
+	return g.Ni, g.Nj
+}
+
+// ScanningMode returns the scanning mode flags
+func (g *Gaussian) ScanningMode() uint8 {
+	return g.Scan
+}
+
+// Earth returns the shape of the Earth
+func (g *Gaussian) Earth() Earth {
+	return g.Shape
+}
+
+// PointLatLon returns the latitude and longitude of the point at (i, j)
+func (g *Gaussian) PointLatLon(i, j int) (lat, lon float64) {
+	return g.Lats[j], NormalizeLongitude(g.LonFirst + float64(i)*g.DLon)
+}
+
+// nearestIJ returns the grid coordinates of the point nearest to a location
+func (g *Gaussian) nearestIJ(lat, lon float64) (i, j int, ok bool) {
+	if len(g.Lats) == 0 {
+		return 0, 0, false
+	}
+	best := math.Inf(1)
+	for k, rowLat := range g.Lats {
+		if d := math.Abs(lat - rowLat); d < best {
+			j, best = k, d
+		}
+	}
+	if last := len(g.Lats) - 1; last > 0 && len(g.Lats) != 2*g.N && (j == 0 || j == last) {
+		// Beyond the outermost rows of a regional grid, accept half the spacing to the
+		// adjacent row; rows of a global grid extend to the poles
+		neighbour := g.Lats[1]
+		if j == last {
+			neighbour = g.Lats[last-1]
+		}
+		if best > math.Abs(g.Lats[j]-neighbour)/2 {
+			return 0, 0, false
+		}
+	}
+
+	i, ok = nearestLongitudeStep(lon, g.LonFirst, g.DLon, g.Ni)
+	return i, j, ok
+}
+
+// LatLonToIJ returns the fractional grid coordinates of a latitude and longitude in degrees,
+// interpolating linearly in latitude between rows. Columns of a global grid are in [0, Ni),
+// and locations poleward of the outermost rows of a global grid are put on those rows; the
+// coordinates of other locations outside the grid lie outside [0, Ni-1] × [0, Nj-1].
+func (g *Gaussian) LatLonToIJ(lat, lon float64) (i, j float64) {
+	i = fractionalLongitudeStep(lon, g.LonFirst, g.DLon, g.Ni)
+	switch n := len(g.Lats); {
+	case n == 0:
+		return i, math.NaN()
+	case n == 1:
+		if lat == g.Lats[0] {
+			return i, 0
+		}
+		return i, math.NaN()
+	}
+
+	// Rows are monotonic in j; find the pair surrounding the latitude, or the outermost pair
+	k := 0
+	for k < len(g.Lats)-2 && (lat-g.Lats[k+1])*(g.Lats[k+1]-g.Lats[k]) > 0 {
+		k++
+	}
+	j = float64(k) + (lat-g.Lats[k])/(g.Lats[k+1]-g.Lats[k])
+	if len(g.Lats) == 2*g.N {
+		j = min(max(j, 0), float64(len(g.Lats)-1))
+	}
+	return i, j
+}
+
+// GaussianLatitudes returns the 2N Gaussian latitudes in degrees from north to south,
+// with their quadrature weights, which sum to 2
+func GaussianLatitudes(n int) (lats, weights []float64) {
+	size := 2 * n
+	lats = make([]float64, size)
+	weights = make([]float64, size)
+
+	for k := 0; k < n; k++ {
+		// Newton iteration on the roots of the Legendre polynomial of degree 2N
+		x := math.Cos(math.Pi * (float64(k) + 0.75) / (float64(size) + 0.5))
+		var dp float64
+		for iteration := 0; iteration < 100; iteration++ {
+			p, pPrev := legendre(size, x)
+			dp = float64(size) * (x*p - pPrev) / (x*x - 1)
+			dx := p / dp
+			x -= dx
+			if math.Abs(dx) < 1e-15 {
+				break
+			}
+		}
+		_, pPrev := legendre(size, x)
+		dp = float64(size) * pPrev / (1 - x*x)
+
+		lat := math.Asin(x) * 180 / math.Pi
+		weight := 2 / ((1 - x*x) * dp * dp)
+		lats[k], lats[size-1-k] = lat, -lat
+		weights[k], weights[size-1-k] = weight, weight
+	}
+
+	return lats, weights
+}
+
+// legendre evaluates the Legendre polynomials of degree n and n-1 at x
+func legendre(n int, x float64) (p, pPrev float64) {
+	p, pPrev = x, 1
+	for degree := 2; degree <= n; degree++ {
+		p, pPrev = ((2*float64(degree)-1)*x*p-(float64(degree)-1)*pPrev)/float64(degree), p
+	}
+	return p, pPrev
+}
+
+// newGaussianFromTemplate builds the geometry of template 3.40
+func newGaussianFromTemplate(g *template.GridTemplate) (Definition, error) {
+	if g.Gaussian == nil {
+		return nil, fmt.Errorf("grid: template 3.40 fields are not available")
+	}
+	t := g.Gaussian
+
+	if template.IsMissing(t.NumberOfGridPointsAlongX) {
+		return nil, fmt.Errorf("grid: reduced Gaussian grids are not supported")
+	}
+	n := int(t.NumberOfParallels)
+	if n <= 0 {
+		return nil, fmt.Errorf("grid: invalid number of parallels %d", n)
+	}
+
+	regular, err := regularLatLon(&t.LatLonGrid)
+	if err != nil {
+		return nil, err
+	}
+
+	allLats, allWeights := GaussianLatitudes(n)
+
+	// Locate the first row among the Gaussian latitudes; the template carries it to 10^-6 degrees
+	first := 0
+	for k, lat := range allLats {
+		if math.Abs(lat-regular.LatFirst) < math.Abs(allLats[first]-regular.LatFirst) {
+			first = k
+		}
+	}
+
+	step := 1 // North to south
+	if regular.Scan&ScanPositiveJ != 0 {
+		step = -1
+	}
+	if last := first + step*(regular.Nj-1); last < 0 || last >= len(allLats) {
+		return nil, fmt.Errorf("grid: %d rows from latitude %g exceed the %d Gaussian latitudes", regular.Nj, regular.LatFirst, len(allLats))
+	}
+
+	gaussian := &Gaussian{
+		Ni:       regular.Ni,
+		Nj:       regular.Nj,
+		N:        n,
+		LonFirst: regular.LonFirst,
+		DLon:     regular.DLon,
+		Lats:     make([]float64, regular.Nj),
+		Weights:  make([]float64, regular.Nj),
+		Scan:     regular.Scan,
+		Shape:    regular.Shape,
+	}
+	for j := range gaussian.Lats {
+		gaussian.Lats[j] = allLats[first+step*j]
+		gaussian.Weights[j] = allWeights[first+step*j]
+	}
+
+	return gaussian, nil
+}
@@ -0,0 +1,139 @@
+// Package grid computes the geometry of GRIB2 grids: the location of every data
+// point, cell areas and other quantities derived from a grid definition template.
+package grid
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/scorix/grib/grib2/template"
+)
+
+// Definition describes the geometry of a grid of Ni x Nj points.
+//
+// Grid coordinates (i, j) count points from the first grid point in the scanning
+// directions given by the scanning mode, so (0, 0) is always the first grid point.
+type Definition interface {
+	// Dims returns the number of points along the i (x) and j (y) axes
+	Dims() (ni, nj int)
+	// ScanningMode returns the scanning mode flags (Flag Table 3.4)
+	ScanningMode() uint8
+	// PointLatLon returns the latitude and longitude in degrees of the point at (i, j),
+	// with longitudes in [0, 360)
+	PointLatLon(i, j int) (lat, lon float64)
+	// Earth returns the shape of the Earth the grid is defined on
+	Earth() Earth
+}
+
+// Scanning mode flags (Flag Table 3.4)
+const (
+	ScanNegativeI     uint8 = 0x80 // Points of the first row scan in the -i (x) direction
+	ScanPositiveJ     uint8 = 0x40 // Points of the first column scan in the +j (y) direction
+	ScanConsecutiveJ  uint8 = 0x20 // Adjacent points in the j direction are consecutive
+	ScanAlternateRows uint8 = 0x10 // Adjacent rows (columns when j is consecutive) scan in opposite directions
+)
+
+// definitionBuilders build a Definition from a parsed grid template, keyed by template number
+var definitionBuilders = map[int]func(g *template.GridTemplate) (Definition, error){
+	0:  newRegularLatLonFromTemplate,
+	20: newPolarStereographicFromTemplate,
+	30: newLambertConformalFromTemplate,
+	40: newGaussianFromTemplate,
+}
+
+// FromTemplate builds the geometry of a parsed grid definition template
+func FromTemplate(g *template.GridTemplate) (Definition, error) {
+	switch {
+	case g.Absent():
+		return nil, errors.New("grid: no grid definition applies to the product")
+	case g.CentreDefined():
+		return nil, fmt.Errorf("grid: grid definition %d is predetermined by the originating centre", g.TemplateNumber)
+	case !g.HasTemplate():
+		return nil, fmt.Errorf("grid: unsupported source of grid definition %d", g.SourceOfGridDefinition)
+	}
+
+	build, ok := definitionBuilders[g.TemplateNumber]
+	if !ok {
+		return nil, fmt.Errorf("grid: unsupported grid definition template %d", g.TemplateNumber)
+	}
+	return build(g)
+}
+
+// CanGeolocate reports whether the geometry of the grid definition template can be computed
+func CanGeolocate(templateNumber int) bool {
+	_, ok := definitionBuilders[templateNumber]
+	return ok
+}
+
+// TemplateNumbers returns the grid definition templates with geometry support, in ascending order
+func TemplateNumbers() []int {
+	numbers := make([]int, 0, len(definitionBuilders))
+	for number := range definitionBuilders {
+		numbers = append(numbers, number)
+	}
+	slices.Sort(numbers)
+	return numbers
+}
+
+// NumberOfPoints returns the number of points of the grid
+func NumberOfPoints(def Definition) int {
+	ni, nj := def.Dims()
+	return ni * nj
+}
+
+// IndexToIJ converts the index of a data value into grid coordinates. The directions
+// of i and j are those of the first row and column; with alternating row scanning,
+// every second row (or column) is stored in the opposite direction.
+func IndexToIJ(def Definition, index int) (i, j int) {
+	ni, nj := def.Dims()
+	scan := def.ScanningMode()
+	if scan&ScanConsecutiveJ != 0 {
+		i, j = index/nj, index%nj
+		if scan&ScanAlternateRows != 0 && i%2 == 1 {
+			j = nj - 1 - j
+		}
+		return i, j
+	}
+
+	i, j = index%ni, index/ni
+	if scan&ScanAlternateRows != 0 && j%2 == 1 {
+		i = ni - 1 - i
+	}
+	return i, j
+}
+
+// IJToIndex converts grid coordinates into the index of the data value; it is the
+// inverse of IndexToIJ
+func IJToIndex(def Definition, i, j int) int {
+	ni, nj := def.Dims()
+	scan := def.ScanningMode()
+	if scan&ScanConsecutiveJ != 0 {
+		if scan&ScanAlternateRows != 0 && i%2 == 1 {
+			j = nj - 1 - j
+		}
+		return i*nj + j
+	}
+
+	if scan&ScanAlternateRows != 0 && j%2 == 1 {
+		i = ni - 1 - i
+	}
+	return j*ni + i
+}
+
+// LatLonAt returns the latitude and longitude in degrees of the data value at index
+func LatLonAt(def Definition, index int) (lat, lon float64) {
+	i, j := IndexToIJ(def, index)
+	return def.PointLatLon(i, j)
+}
+
+// Coordinates returns the latitudes and longitudes in degrees of all the data values, in
+// the order of the data values, with longitudes in [0, 360)
+func Coordinates(def Definition) (lats, lons []float64) {
+	n := NumberOfPoints(def)
+	lats, lons = make([]float64, n), make([]float64, n)
+	for index := range n {
+		lats[index], lons[index] = LatLonAt(def, index)
+	}
+	return lats, lons
+}
@@ -0,0 +1,234 @@
+package grid_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/scorix/grib/grib2/grid"
+	"github.com/scorix/grib/grib2/template"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndexToIJ(t *testing.T) {
+	tests := []struct {
+		name  string
+		scan  uint8
+		index int
+		i, j  int
+	}{
+		{name: "i consecutive", scan: 0x00, index: 5, i: 1, j: 1},
+		{name: "i consecutive, last", scan: 0x00, index: 11, i: 3, j: 2},
+		{name: "j consecutive", scan: grid.ScanConsecutiveJ, index: 5, i: 1, j: 2},
+		{name: "j consecutive, last", scan: grid.ScanConsecutiveJ, index: 11, i: 3, j: 2},
+		{name: "alternating, first of odd row", scan: grid.ScanAlternateRows, index: 4, i: 3, j: 1},
+		{name: "alternating, odd row", scan: grid.ScanAlternateRows, index: 5, i: 2, j: 1},
+		{name: "alternating, even row", scan: grid.ScanAlternateRows, index: 11, i: 3, j: 2},
+		{name: "alternating, j consecutive", scan: grid.ScanAlternateRows | grid.ScanConsecutiveJ, index: 5, i: 1, j: 0},
+		{name: "alternating, j consecutive, last", scan: grid.ScanAlternateRows | grid.ScanConsecutiveJ, index: 11, i: 3, j: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &grid.RegularLatLon{Ni: 4, Nj: 3, Scan: tt.scan}
+
+			i, j := grid.IndexToIJ(g, tt.index)
+			assert.Equal(t, tt.i, i)
+			assert.Equal(t, tt.j, j)
+			assert.Equal(t, tt.index, grid.IJToIndex(g, i, j))
+		})
+	}
+}
+
+func TestFromTemplate_LatLon(t *testing.T) {
+	def, err := grid.FromTemplate(&template.GridTemplate{
+		TemplateNumber: 0,
+		LatLon: &template.LatLonGrid{
+			ShapeOfEarth:               6,
+			NumberOfGridPointsAlongX:   1440,
+			NumberOfGridPointsAlongY:   721,
+			SubdivisionOfBasicAngle:    0xffffffff,
+			LatitudeOfFirstGridPoint:   90000000,
+			LongitudeOfFirstGridPoint:  0,
+			ResolutionAndComponentFlag: 0x30,
+			LatitudeOfLastGridPoint:    -90000000,
+			LongitudeOfLastGridPoint:   359750000,
+			XDirectionIncrement:        250000,
+			YDirectionIncrement:        250000,
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 6371229.0, def.Earth().Radius())
+
+	lat, lon := grid.LatLonAt(def, 0)
+	assert.InDelta(t, 90, lat, 1e-9)
+	assert.InDelta(t, 0, lon, 1e-9)
+
+	lat, lon = grid.LatLonAt(def, 1440*721-1)
+	assert.InDelta(t, -90, lat, 1e-9)
+	assert.InDelta(t, 359.75, lon, 1e-9)
+
+	_, err = grid.FromTemplate(&template.GridTemplate{TemplateNumber: 90})
+	require.Error(t, err)
+	assert.False(t, grid.CanGeolocate(90))
+	assert.Equal(t, []int{0, 20, 30, 40}, grid.TemplateNumbers())
+}
+
+func TestCoordinates_LatLon(t *testing.T) {
+	// A regional subset scanning northwards across the prime meridian, in units of
+	// 1/1000 degree with the basic angle and its subdivisions
+	regional := &template.GridTemplate{
+		TemplateNumber:     0,
+		NumberOfDataPoints: 5 * 3,
+		LatLon: &template.LatLonGrid{
+			ShapeOfEarth:               6,
+			NumberOfGridPointsAlongX:   5,
+			NumberOfGridPointsAlongY:   3,
+			BasicAngleOfInitialDomain:  1,
+			SubdivisionOfBasicAngle:    1000,
+			LatitudeOfFirstGridPoint:   40000,
+			LongitudeOfFirstGridPoint:  359000,
+			ResolutionAndComponentFlag: 0x30,
+			LatitudeOfLastGridPoint:    41000,
+			LongitudeOfLastGridPoint:   1000,
+			XDirectionIncrement:        500,
+			YDirectionIncrement:        500,
+			ScanningMode:               grid.ScanPositiveJ,
+		},
+	}
+	def, err := grid.FromTemplate(regional)
+	require.NoError(t, err)
+
+	lats, lons := grid.Coordinates(def)
+	assert.InDeltaSlice(t, []float64{40, 40, 40, 40, 40, 40.5, 40.5, 40.5, 40.5, 40.5, 41, 41, 41, 41, 41}, lats, 1e-9)
+	assert.InDeltaSlice(t, []float64{359, 359.5, 0, 0.5, 1, 359, 359.5, 0, 0.5, 1, 359, 359.5, 0, 0.5, 1}, lons, 1e-9)
+
+	_, lons = grid.LonMinus180To180.Coordinates(def)
+	assert.InDeltaSlice(t, []float64{-1, -0.5, 0, 0.5, 1}, lons[:5], 1e-9)
+
+	// Columns consecutive, scanning westwards
+	columns := *regional.LatLon
+	columns.LongitudeOfFirstGridPoint, columns.LongitudeOfLastGridPoint = 1000, 359000
+	columns.ScanningMode = grid.ScanNegativeI | grid.ScanPositiveJ | grid.ScanConsecutiveJ
+	def, err = grid.FromTemplate(&template.GridTemplate{TemplateNumber: 0, NumberOfDataPoints: 15, LatLon: &columns})
+	require.NoError(t, err)
+	lats, lons = grid.Coordinates(def)
+	assert.InDeltaSlice(t, []float64{40, 40.5, 41, 40, 40.5}, lats[:5], 1e-9)
+	assert.InDeltaSlice(t, []float64{1, 1, 1, 0.5, 0.5}, lons[:5], 1e-9)
+	assert.InDelta(t, 359, lons[14], 1e-9)
+
+	// Inconsistent definitions
+	wrongCount := *regional
+	wrongCount.NumberOfDataPoints = 16
+	_, err = grid.FromTemplate(&wrongCount)
+	assert.ErrorContains(t, err, "5x3 points for 16 data points")
+
+	wrongLat := *regional.LatLon
+	wrongLat.LatitudeOfLastGridPoint = 42000
+	_, err = grid.FromTemplate(&template.GridTemplate{TemplateNumber: 0, LatLon: &wrongLat})
+	assert.ErrorContains(t, err, "last latitude 42")
+
+	wrongLon := *regional.LatLon
+	wrongLon.LongitudeOfLastGridPoint = 2000
+	_, err = grid.FromTemplate(&template.GridTemplate{TemplateNumber: 0, LatLon: &wrongLon})
+	assert.ErrorContains(t, err, "last longitude 2")
+}
+
+func TestGaussianLatitudes(t *testing.T) {
+	lats, weights := grid.GaussianLatitudes(48)
+	require.Len(t, lats, 96)
+	require.Len(t, weights, 96)
+
+	assert.InDelta(t, 2, sum(weights), 1e-12)
+	// First latitude of the N48 (T62) Gaussian grid
+	assert.InDelta(t, 88.572, lats[0], 1e-3)
+	assert.InDelta(t, -lats[0], lats[95], 1e-12)
+	for k := 1; k < len(lats); k++ {
+		assert.Less(t, lats[k], lats[k-1])
+	}
+}
+
+func TestProjectionRoundTrip(t *testing.T) {
+	wgs84 := grid.Earth{SemiMajorAxis: 6378137, SemiMinorAxis: 6356752.3142}
+	lambert := &grid.LambertConformal{LoV: 265, Latin1: 25, Latin2: 50, Shape: grid.Sphere(6371229)}
+	polar := &grid.PolarStereographic{LoV: 255, LaD: 60, Shape: grid.Sphere(6371229)}
+	south := &grid.PolarStereographic{LoV: 0, LaD: -71, SouthPole: true, Shape: grid.Sphere(6371229)}
+	lambertWGS84 := &grid.LambertConformal{LoV: 265, Latin1: 25, Latin2: 50, Shape: wgs84}
+	polarWGS84 := &grid.PolarStereographic{LoV: 255, LaD: 90, Shape: wgs84}
+
+	for _, p := range []struct {
+		name      string
+		lat, lon  float64
+		project   func(lat, lon float64) (float64, float64)
+		unproject func(x, y float64) (float64, float64)
+	}{
+		{"lambert", 38.5, 262.5, lambert.Project, lambert.Unproject},
+		{"polar", 70, 300, polar.Project, polar.Unproject},
+		{"south polar", -75, 120, south.Project, south.Unproject},
+		{"lambert spheroid", 38.5, 262.5, lambertWGS84.Project, lambertWGS84.Unproject},
+		{"polar spheroid", 70, 300, polarWGS84.Project, polarWGS84.Unproject},
+	} {
+		t.Run(p.name, func(t *testing.T) {
+			x, y := p.project(p.lat, p.lon)
+			lat, lon := p.unproject(x, y)
+			assert.InDelta(t, p.lat, lat, 1e-9)
+			assert.InDelta(t, p.lon, lon, 1e-9)
+			assert.False(t, math.IsNaN(x) || math.IsNaN(y))
+		})
+	}
+}
+
+func TestProjection_Spheroid(t *testing.T) {
+	// Numerical examples from Snyder, Map Projections: A Working Manual (1987)
+	t.Run("lambert", func(t *testing.T) {
+		clarke1866 := grid.Earth{SemiMajorAxis: 6378206.4, SemiMinorAxis: 6378206.4 * math.Sqrt(1-0.00676866)}
+		g := &grid.LambertConformal{LoV: -96, Latin1: 33, Latin2: 45, Shape: clarke1866}
+
+		_, y0 := g.Project(23, -96)
+		x, y := g.Project(35, -75)
+		assert.InDelta(t, 1894410.9, x, 0.1)
+		assert.InDelta(t, 1564649.5, y-y0, 0.1)
+		assert.InDelta(t, 0.9970171, g.MapFactor(35), 1e-7)
+		assert.InDelta(t, 1, g.MapFactor(33), 1e-12)
+
+		lat, lon := g.Unproject(x, y)
+		assert.InDelta(t, 35, lat, 1e-9)
+		assert.InDelta(t, 285, lon, 1e-9)
+	})
+
+	t.Run("south polar", func(t *testing.T) {
+		international := grid.Earth{SemiMajorAxis: 6378388, SemiMinorAxis: 6378388 * math.Sqrt(1-0.00672267)}
+		g := &grid.PolarStereographic{LoV: -100, LaD: -71, SouthPole: true, Shape: international}
+
+		x, y := g.Project(-75, 150)
+		assert.InDelta(t, -1540033.6, x, 0.1)
+		assert.InDelta(t, -560526.4, y, 0.1)
+		assert.InDelta(t, 0.9896256, g.MapFactor(-75), 1e-7)
+
+		lat, lon := g.Unproject(x, y)
+		assert.InDelta(t, -75, lat, 1e-9)
+		assert.InDelta(t, 150, lon, 1e-9)
+	})
+}
+
+func TestLatLonToIJ(t *testing.T) {
+	earth := grid.Sphere(6371229)
+	lambert := &grid.LambertConformal{Nx: 11, Ny: 8, La1: 30, Lo1: 250, LoV: 265, Latin1: 25, Latin2: 50, Dx: 50000, Dy: 50000, Scan: grid.ScanPositiveJ, Shape: earth}
+	polar := &grid.PolarStereographic{Nx: 7, Ny: 9, La1: 50, Lo1: 240, LoV: 255, LaD: 60, Dx: 100000, Dy: 100000, Scan: grid.ScanNegativeI, Shape: earth}
+
+	for _, g := range []interface {
+		grid.Definition
+		LatLonToIJ(lat, lon float64) (i, j float64)
+	}{lambert, polar} {
+		lat, lon := g.PointLatLon(3, 5)
+		i, j := g.LatLonToIJ(lat, lon)
+		assert.InDelta(t, 3, i, 1e-6)
+		assert.InDelta(t, 5, j, 1e-6)
+
+		// The first grid point is at the origin
+		lat, lon = g.PointLatLon(0, 0)
+		i, j = g.LatLonToIJ(lat, lon)
+		assert.InDelta(t, 0, i, 1e-6)
+		assert.InDelta(t, 0, j, 1e-6)
+	}
+}
@@ -0,0 +1,168 @@
+package grid
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/scorix/grib/grib2/template"
+	"github.com/scorix/grib/grib2/units"
+)
+
+// LambertConformal is a Lambert conformal conic grid (template 3.30).
+// Projection math follows the shape of the Earth, a sphere or an oblate spheroid.
+type LambertConformal struct {
+	Nx, Ny    int     // Number of points along the x and y axes
+	La1, Lo1  float64 // Latitude and longitude of the first grid point in degrees
+	LoV       float64 // Longitude of the meridian parallel to the y axis in degrees
+	LaD       float64 // Latitude where Dx and Dy are specified in degrees
+	Latin1    float64 // First latitude from the pole at which the secant cone cuts the sphere, in degrees
+	Latin2    float64 // Second latitude at which the secant cone cuts the sphere, in degrees
+	Dx, Dy    float64 // Grid lengths in metres
+	SouthPole bool    // Projection centre is the south pole
+	Scan      uint8   // Scanning mode flags (Flag Table 3.4)
+	Shape     Earth   // Shape of the Earth
+}
+
+var _ Definition = (*LambertConformal)(nil)
+
+// Dims returns the number of points along the x and y axes
+func (g *LambertConformal) Dims() (ni, nj int) {
+	return g.Nx, g.Ny
+}
+
+// ScanningMode returns the scanning mode flags
+func (g *LambertConformal) ScanningMode() uint8 {
+	return g.Scan
+}
+
+// Earth returns the shape of the Earth
+func (g *LambertConformal) Earth() Earth {
+	return g.Shape
+}
+
+// PointLatLon returns the latitude and longitude of the point at (i, j)
+func (g *LambertConformal) PointLatLon(i, j int) (lat, lon float64) {
+	x0, y0 := g.Project(g.La1, g.Lo1)
+	dx, dy := stepDirections(g.Scan, g.Dx, g.Dy)
+	return g.Unproject(x0+float64(i)*dx, y0+float64(j)*dy)
+}
+
+// LatLonToIJ returns the fractional grid coordinates of a latitude and longitude in degrees.
+// The coordinates of a location outside the grid lie outside [0, Nx-1] × [0, Ny-1].
+func (g *LambertConformal) LatLonToIJ(lat, lon float64) (i, j float64) {
+	x, y := g.Project(lat, lon)
+	x0, y0 := g.Project(g.La1, g.Lo1)
+	dx, dy := stepDirections(g.Scan, g.Dx, g.Dy)
+	return (x - x0) / dx, (y - y0) / dy
+}
+
+// nearestIJ returns the grid coordinates of the point nearest to a location
+func (g *LambertConformal) nearestIJ(lat, lon float64) (i, j int, ok bool) {
+	fi, fj := g.LatLonToIJ(lat, lon)
+	return nearestGridIJ(fi, fj, g.Nx, g.Ny)
+}
+
+// cone returns the cone constant n and the scaling constant F of the projection on a
+// spheroid of eccentricity e
+func (g *LambertConformal) cone(e float64) (n, f float64) {
+	phi1, phi2 := radians(g.Latin1), radians(g.Latin2)
+	m1, t1 := conformalM(phi1, e), conformalT(phi1, e)
+	if math.Abs(phi1-phi2) < 1e-10 {
+		n = math.Sin(phi1)
+	} else {
+		n = math.Log(m1/conformalM(phi2, e)) / math.Log(t1/conformalT(phi2, e))
+	}
+	f = m1 / (n * math.Pow(t1, n))
+	return n, f
+}
+
+// Project converts a latitude and longitude in degrees into projection coordinates in metres,
+// with the origin at the pole of the cone
+func (g *LambertConformal) Project(lat, lon float64) (x, y float64) {
+	e := g.Shape.Eccentricity()
+	n, f := g.cone(e)
+	rho := g.Shape.SemiMajorAxis * f * math.Pow(conformalT(radians(lat), e), n)
+	theta := n * radians(wrapLongitude(lon-g.LoV))
+	return rho * math.Sin(theta), -rho * math.Cos(theta)
+}
+
+// Unproject converts projection coordinates in metres into a latitude and longitude in degrees
+func (g *LambertConformal) Unproject(x, y float64) (lat, lon float64) {
+	e := g.Shape.Eccentricity()
+	n, f := g.cone(e)
+	sign := math.Copysign(1, n)
+	rho := sign * math.Hypot(x, y)
+	theta := math.Atan2(sign*x, -sign*y)
+
+	t := math.Pow(rho/(g.Shape.SemiMajorAxis*f), 1/n)
+	return degrees(conformalLatitude(t, e)), NormalizeLongitude(g.LoV + degrees(theta/n))
+}
+
+// MapFactor returns the map scale factor k at a latitude in degrees
+func (g *LambertConformal) MapFactor(lat float64) float64 {
+	e := g.Shape.Eccentricity()
+	n, f := g.cone(e)
+	phi := radians(lat)
+	return n * f * math.Pow(conformalT(phi, e), n) / conformalM(phi, e)
+}
+
+// newLambertConformalFromTemplate builds the geometry of template 3.30
+func newLambertConformalFromTemplate(g *template.GridTemplate) (Definition, error) {
+	if g.Lambert == nil {
+		return nil, fmt.Errorf("grid: template 3.30 fields are not available")
+	}
+	t := g.Lambert
+
+	earth, err := EarthShape{
+		ShapeOfEarth:           t.ShapeOfEarth,
+		ScaleFactorRadiusEarth: t.ScaleFactorRadiusEarth,
+		ScaledValueRadiusEarth: t.ScaledValueRadiusEarth,
+		ScaleFactorMajorAxis:   t.ScaleFactorMajorAxis,
+		ScaledValueMajorAxis:   t.ScaledValueMajorAxis,
+		ScaleFactorMinorAxis:   t.ScaleFactorMinorAxis,
+		ScaledValueMinorAxis:   t.ScaledValueMinorAxis,
+	}.Earth()
+	if err != nil {
+		return nil, err
+	}
+
+	return &LambertConformal{
+		Nx:        int(t.NumberOfGridPointsAlongX),
+		Ny:        int(t.NumberOfGridPointsAlongY),
+		La1:       units.Microdegrees(t.LatitudeOfFirstGridPoint),
+		Lo1:       units.Microdegrees(int32(t.LongitudeOfFirstGridPoint)),
+		LoV:       units.Microdegrees(int32(t.OrientationOfGrid)),
+		LaD:       units.Microdegrees(t.LatitudeWhereDxDySpecified),
+		Latin1:    units.Microdegrees(t.LatitudeOfIntersection1),
+		Latin2:    units.Microdegrees(t.LatitudeOfIntersection2),
+		Dx:        float64(t.XDirectionIncrement) * 1e-3,
+		Dy:        float64(t.YDirectionIncrement) * 1e-3,
+		SouthPole: t.ProjectionCenterFlag&0x80 != 0,
+		Scan:      t.ScanningMode,
+		Shape:     earth,
+	}, nil
+}
+
+// stepDirections returns the signed x and y steps between grid points for a scanning mode
+func stepDirections(scan uint8, dx, dy float64) (float64, float64) {
+	if scan&ScanNegativeI != 0 {
+		dx = -dx
+	}
+	if scan&ScanPositiveJ == 0 {
+		dy = -dy
+	}
+	return dx, dy
+}
+
+// wrapLongitude maps a longitude difference in degrees into [-180, 180)
+func wrapLongitude(lon float64) float64 {
+	return NormalizeLongitude(lon+180) - 180
+}
+
+func radians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+func degrees(rad float64) float64 {
+	return rad * 180 / math.Pi
+}
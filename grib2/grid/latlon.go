@@ -0,0 +1,173 @@
+package grid
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/scorix/grib/grib2/template"
+	"github.com/scorix/grib/grib2/units"
+)
+
+// RegularLatLon is a regular latitude/longitude grid (template 3.0)
+type RegularLatLon struct {
+	Ni, Nj   int     // Number of points along a parallel and along a meridian
+	LatFirst float64 // Latitude of the first grid point in degrees
+	LonFirst float64 // Longitude of the first grid point in degrees
+	DLat     float64 // Latitude step between rows in degrees, negative when scanning southwards
+	DLon     float64 // Longitude step between columns in degrees, negative when scanning westwards
+	Scan     uint8   // Scanning mode flags (Flag Table 3.4)
+	Shape    Earth   // Shape of the Earth
+}
+
+var _ Definition = (*RegularLatLon)(nil)
+
+// Dims returns the number of points along a parallel and along a meridian
+func (g *RegularLatLon) Dims() (ni, nj int) {
+	return g.Ni, g.Nj
+}
+
+// ScanningMode returns the scanning mode flags
+func (g *RegularLatLon) ScanningMode() uint8 {
+	return g.Scan
+}
+
+// Earth returns the shape of the Earth
+func (g *RegularLatLon) Earth() Earth {
+	return g.Shape
+}
+
+// PointLatLon returns the latitude and longitude of the point at (i, j)
+func (g *RegularLatLon) PointLatLon(i, j int) (lat, lon float64) {
+	return g.LatFirst + float64(j)*g.DLat, NormalizeLongitude(g.LonFirst + float64(i)*g.DLon)
+}
+
+// nearestIJ returns the grid coordinates of the point nearest to a location
+func (g *RegularLatLon) nearestIJ(lat, lon float64) (i, j int, ok bool) {
+	pos := 0.0
+	if g.DLat != 0 {
+		pos = (lat - g.LatFirst) / g.DLat
+	}
+	j, jok := nearestStep(pos, g.Nj)
+	i, iok := nearestLongitudeStep(lon, g.LonFirst, g.DLon, g.Ni)
+	return i, j, iok && jok
+}
+
+// LatLonToIJ returns the fractional grid coordinates of a latitude and longitude in degrees.
+// Columns of a global grid are in [0, Ni); the coordinates of a location outside the grid
+// lie outside [0, Ni-1] × [0, Nj-1].
+func (g *RegularLatLon) LatLonToIJ(lat, lon float64) (i, j float64) {
+	if g.DLat != 0 {
+		j = (lat - g.LatFirst) / g.DLat
+	}
+	return fractionalLongitudeStep(lon, g.LonFirst, g.DLon, g.Ni), j
+}
+
+// NormalizeLongitude maps a longitude in degrees into [0, 360), see
+// units.NormalizeLongitude
+func NormalizeLongitude(lon float64) float64 {
+	return units.NormalizeLongitude(lon)
+}
+
+// newRegularLatLonFromTemplate builds the geometry of template 3.0
+func newRegularLatLonFromTemplate(g *template.GridTemplate) (Definition, error) {
+	if g.LatLon == nil {
+		return nil, fmt.Errorf("grid: template 3.0 fields are not available")
+	}
+	def, err := regularLatLon(g.LatLon)
+	if err != nil {
+		return nil, err
+	}
+	if err := def.checkLastPoint(g.LatLon); err != nil {
+		return nil, err
+	}
+	if n := NumberOfPoints(def); g.NumberOfDataPoints != 0 && g.NumberOfDataPoints != n {
+		return nil, fmt.Errorf("grid: %dx%d points for %d data points", def.Ni, def.Nj, g.NumberOfDataPoints)
+	}
+	return def, nil
+}
+
+// regularLatLon builds the geometry of the lat/lon fields shared by templates 3.0 and 3.40
+func regularLatLon(t *template.LatLonGrid) (*RegularLatLon, error) {
+	earth, err := latLonEarthShape(t).Earth()
+	if err != nil {
+		return nil, err
+	}
+
+	ni, nj := int(t.NumberOfGridPointsAlongX), int(t.NumberOfGridPointsAlongY)
+	if ni <= 0 || nj <= 0 || template.IsMissing(t.NumberOfGridPointsAlongX) {
+		return nil, fmt.Errorf("grid: unsupported grid dimensions %dx%d", ni, nj)
+	}
+
+	unit := angleUnit(t.BasicAngleOfInitialDomain, t.SubdivisionOfBasicAngle)
+	g := &RegularLatLon{
+		Ni:       ni,
+		Nj:       nj,
+		LatFirst: float64(t.LatitudeOfFirstGridPoint) * unit,
+		LonFirst: float64(t.LongitudeOfFirstGridPoint) * unit,
+		Scan:     t.ScanningMode,
+		Shape:    earth,
+	}
+
+	// Increments are only given when the resolution flags say so; otherwise derive them
+	// from the last grid point
+	if t.ResolutionAndComponentFlag&0x20 != 0 && !template.IsMissing(t.XDirectionIncrement) {
+		g.DLon = float64(t.XDirectionIncrement) * unit
+		if g.Scan&ScanNegativeI != 0 {
+			g.DLon = -g.DLon
+		}
+	} else if ni > 1 {
+		span := NormalizeLongitude(float64(t.LongitudeOfLastGridPoint)*unit - g.LonFirst)
+		if g.Scan&ScanNegativeI != 0 {
+			span -= 360
+		}
+		g.DLon = span / float64(ni-1)
+	}
+
+	if t.ResolutionAndComponentFlag&0x10 != 0 && !template.IsMissing(t.YDirectionIncrement) {
+		g.DLat = float64(t.YDirectionIncrement) * unit
+		if g.Scan&ScanPositiveJ == 0 {
+			g.DLat = -g.DLat
+		}
+	} else if nj > 1 {
+		g.DLat = (float64(t.LatitudeOfLastGridPoint)*unit - g.LatFirst) / float64(nj-1)
+	}
+
+	return g, nil
+}
+
+// checkLastPoint verifies that the increments lead from the first grid point to the last,
+// to within half an increment, which absorbs the rounding of the coded angles
+func (g *RegularLatLon) checkLastPoint(t *template.LatLonGrid) error {
+	unit := angleUnit(t.BasicAngleOfInitialDomain, t.SubdivisionOfBasicAngle)
+	lastLat, lastLon := float64(t.LatitudeOfLastGridPoint)*unit, float64(t.LongitudeOfLastGridPoint)*unit
+	lat, lon := g.PointLatLon(g.Ni-1, g.Nj-1)
+	if math.Abs(lat-lastLat) > math.Abs(g.DLat)/2+1e-6 {
+		return fmt.Errorf("grid: last latitude %g does not follow from the first %g and %d increments of %g", lastLat, g.LatFirst, g.Nj-1, g.DLat)
+	}
+	if d := math.Abs(NormalizeLongitude(lon-lastLon+180) - 180); d > math.Abs(g.DLon)/2+1e-6 {
+		return fmt.Errorf("grid: last longitude %g does not follow from the first %g and %d increments of %g", lastLon, g.LonFirst, g.Ni-1, g.DLon)
+	}
+	return nil
+}
+
+// angleUnit returns the size in degrees of the unit of angles in lat/lon templates:
+// 10^-6 degrees unless a basic angle and its subdivisions are given
+func angleUnit(basicAngle, subdivisions uint32) float64 {
+	if basicAngle == 0 || template.IsMissing(basicAngle) || subdivisions == 0 || template.IsMissing(subdivisions) {
+		return 1e-6
+	}
+	return float64(basicAngle) / float64(subdivisions)
+}
+
+// latLonEarthShape returns the shape of the Earth fields of a lat/lon template
+func latLonEarthShape(t *template.LatLonGrid) EarthShape {
+	return EarthShape{
+		ShapeOfEarth:           t.ShapeOfEarth,
+		ScaleFactorRadiusEarth: t.ScaleFactorRadiusEarth,
+		ScaledValueRadiusEarth: t.ScaledValueRadiusEarth,
+		ScaleFactorMajorAxis:   t.ScaleFactorMajorAxis,
+		ScaledValueMajorAxis:   t.ScaledValueMajorAxis,
+		ScaleFactorMinorAxis:   t.ScaleFactorMinorAxis,
+		ScaledValueMinorAxis:   t.ScaledValueMinorAxis,
+	}
+}
@@ -0,0 +1,110 @@
+package grid
+
+import "math"
+
+// locator is implemented by the grids that can find the point nearest to a location
+// without visiting every point
+type locator interface {
+	// nearestIJ returns the grid coordinates of the point nearest to a location in degrees,
+	// and false when the location lies outside the grid
+	nearestIJ(lat, lon float64) (i, j int, ok bool)
+}
+
+// LatLonToIndex returns the index of the data value at the grid point nearest to a latitude
+// and longitude in degrees, taking the scanning mode into account. The longitude may follow
+// either LonConvention. It returns false when the location lies more than half a grid step
+// outside the grid.
+func LatLonToIndex(def Definition, lat, lon float64) (int, bool) {
+	if l, ok := def.(locator); ok {
+		i, j, ok := l.nearestIJ(lat, lon)
+		if !ok {
+			return 0, false
+		}
+		return IJToIndex(def, i, j), true
+	}
+
+	// Other grids are searched point by point for the shortest great-circle distance
+	n := NumberOfPoints(def)
+	nearest, best := 0, math.Inf(1)
+	for index := 0; index < n; index++ {
+		pointLat, pointLon := LatLonAt(def, index)
+		if d := greatCircleDistance(1, lat, lon, pointLat, pointLon); d < best {
+			nearest, best = index, d
+		}
+	}
+	return nearest, n > 0
+}
+
+// ValueAt returns the value of the grid point nearest to a latitude and longitude in degrees,
+// in either LonConvention, from values in the order of the data section. It returns false when the location lies
+// outside the grid or values does not cover the grid.
+func ValueAt(def Definition, values []float64, lat, lon float64) (float64, bool) {
+	if len(values) != NumberOfPoints(def) {
+		return 0, false
+	}
+	index, ok := LatLonToIndex(def, lat, lon)
+	if !ok {
+		return 0, false
+	}
+	return values[index], true
+}
+
+// nearestStep rounds a position counted in grid steps to the nearest of n points
+func nearestStep(pos float64, n int) (int, bool) {
+	if !(pos >= -0.5 && pos < float64(n)-0.5) {
+		return 0, false
+	}
+	return max(int(math.Round(pos)), 0), true
+}
+
+// nearestLongitudeStep returns the column nearest to a longitude on a row of n points from
+// first with a signed step in degrees, wrapping around the globe when the row is global
+func nearestLongitudeStep(lon, first, step float64, n int) (int, bool) {
+	offset := NormalizeLongitude(lon - first)
+	if step < 0 {
+		offset, step = NormalizeLongitude(first-lon), -step
+	}
+	if step == 0 {
+		return nearestStep(0, n)
+	}
+
+	pos := offset / step
+	if float64(n)*step >= 360-1e-9 {
+		return int(math.Round(pos)) % n, true
+	}
+	if i, ok := nearestStep(pos, n); ok {
+		return i, true
+	}
+	// Locations just before the first column have an offset close to 360 degrees
+	return nearestStep(pos-360/step, n)
+}
+
+// fractionalLongitudeStep returns the fractional column of a longitude on a row of n points
+// from first with a signed step in degrees. Columns of a global row are in [0, n); on other
+// rows the longitude is taken on the turn of the globe nearest the row.
+func fractionalLongitudeStep(lon, first, step float64, n int) float64 {
+	offset := NormalizeLongitude(lon - first)
+	if step < 0 {
+		offset, step = NormalizeLongitude(first-lon), -step
+	}
+	if step == 0 {
+		return 0
+	}
+
+	pos := offset / step
+	if float64(n)*step >= 360-1e-9 {
+		return pos
+	}
+	// Locations just before the first column have an offset close to 360 degrees
+	if before := pos - 360/step; pos-float64(n-1) > -before {
+		return before
+	}
+	return pos
+}
+
+// nearestGridIJ rounds fractional grid coordinates to the nearest point of an ni × nj grid
+func nearestGridIJ(fi, fj float64, ni, nj int) (i, j int, ok bool) {
+	i, iok := nearestStep(fi, ni)
+	j, jok := nearestStep(fj, nj)
+	return i, j, iok && jok
+}
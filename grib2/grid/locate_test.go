@@ -0,0 +1,160 @@
+package grid_test
+
+import (
+	"math/rand/v2"
+	"testing"
+
+	"github.com/scorix/grib/grib2/grid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatLonToIndex_RoundTrip(t *testing.T) {
+	earth := grid.Sphere(6371229)
+	lats, _ := grid.GaussianLatitudes(8)
+
+	// Every combination of the direction, consecutive-j and alternating-row flags
+	flags := []uint8{grid.ScanNegativeI, grid.ScanPositiveJ, grid.ScanConsecutiveJ, grid.ScanAlternateRows}
+	rng := rand.New(rand.NewPCG(1, 2))
+
+	for _, build := range []struct {
+		name string
+		def  func(scan uint8) grid.Definition
+	}{
+		{"regular global", func(scan uint8) grid.Definition {
+			dLat, dLon := -10.0, 15.0
+			if scan&grid.ScanPositiveJ != 0 {
+				dLat = 10
+			}
+			if scan&grid.ScanNegativeI != 0 {
+				dLon = -15
+			}
+			return &grid.RegularLatLon{Ni: 24, Nj: 7, LatFirst: 30 * -dLat / 10, LonFirst: 180, DLat: dLat, DLon: dLon, Scan: scan, Shape: earth}
+		}},
+		{"regular regional", func(scan uint8) grid.Definition {
+			dLat, dLon := -0.5, 0.25
+			if scan&grid.ScanPositiveJ != 0 {
+				dLat = 0.5
+			}
+			if scan&grid.ScanNegativeI != 0 {
+				dLon = -0.25
+			}
+			return &grid.RegularLatLon{Ni: 9, Nj: 5, LatFirst: 45, LonFirst: 359.5, DLat: dLat, DLon: dLon, Scan: scan, Shape: earth}
+		}},
+		{"gaussian", func(scan uint8) grid.Definition {
+			dLon := 22.5
+			if scan&grid.ScanNegativeI != 0 {
+				dLon = -22.5
+			}
+			return &grid.Gaussian{Ni: 16, Nj: len(lats), N: 8, DLon: dLon, Lats: lats, Scan: scan, Shape: earth}
+		}},
+		{"lambert", func(scan uint8) grid.Definition {
+			return &grid.LambertConformal{Nx: 11, Ny: 8, La1: 30, Lo1: 250, LoV: 265, Latin1: 25, Latin2: 50, Dx: 50000, Dy: 50000, Scan: scan, Shape: earth}
+		}},
+		{"polar", func(scan uint8) grid.Definition {
+			return &grid.PolarStereographic{Nx: 7, Ny: 9, La1: 50, Lo1: 240, LoV: 255, LaD: 60, Dx: 100000, Dy: 100000, Scan: scan, Shape: earth}
+		}},
+	} {
+		t.Run(build.name, func(t *testing.T) {
+			for range 64 {
+				var scan uint8
+				for _, flag := range flags {
+					if rng.IntN(2) == 1 {
+						scan |= flag
+					}
+				}
+				def := build.def(scan)
+				index := rng.IntN(grid.NumberOfPoints(def))
+
+				lat, lon := grid.LatLonAt(def, index)
+				got, ok := grid.LatLonToIndex(def, lat, lon)
+				require.True(t, ok, "scan %#02x index %d", scan, index)
+				require.Equal(t, index, got, "scan %#02x", scan)
+
+				gotLat, gotLon := grid.LatLonAt(def, got)
+				assert.Equal(t, lat, gotLat)
+				assert.Equal(t, lon, gotLon)
+			}
+		})
+	}
+}
+
+func TestValueAt(t *testing.T) {
+	// Rows of a 3x2 grid scanned in alternate directions: the second row is stored east to west
+	def := &grid.RegularLatLon{Ni: 3, Nj: 2, LatFirst: 10, LonFirst: 0, DLat: -1, DLon: 1, Scan: grid.ScanAlternateRows}
+	values := []float64{1, 2, 3, 6, 5, 4}
+
+	for _, tt := range []struct {
+		lat, lon float64
+		want     float64
+	}{
+		{10, 0, 1},
+		{10, 2, 3},
+		{9, 0, 4},
+		{9.2, 1.9, 6},
+		{9, 359.7, 4},
+	} {
+		got, ok := grid.ValueAt(def, values, tt.lat, tt.lon)
+		require.True(t, ok, "lat %v lon %v", tt.lat, tt.lon)
+		assert.Equal(t, tt.want, got, "lat %v lon %v", tt.lat, tt.lon)
+	}
+
+	_, ok := grid.ValueAt(def, values, 11, 0)
+	assert.False(t, ok)
+	_, ok = grid.ValueAt(def, values, 10, 3)
+	assert.False(t, ok)
+	_, ok = grid.ValueAt(def, values[:5], 10, 0)
+	assert.False(t, ok)
+}
+
+func TestLonConvention(t *testing.T) {
+	for _, tt := range []struct {
+		lon                  float64
+		positive, signedWant float64
+	}{
+		{-0.125, 359.875, -0.125},
+		{359.875, 359.875, -0.125},
+		{180, 180, -180},
+		{-180, 180, -180},
+		{0, 0, 0},
+	} {
+		assert.InDelta(t, tt.positive, grid.Lon0To360.Longitude(tt.lon), 1e-12, "lon %v", tt.lon)
+		assert.InDelta(t, tt.signedWant, grid.LonMinus180To180.Longitude(tt.lon), 1e-12, "lon %v", tt.lon)
+	}
+}
+
+func TestLatLonToIndex_LonConventions(t *testing.T) {
+	// Cell centres of a global 0.25 degree grid, and a regional grid across the antimeridian
+	centres := &grid.RegularLatLon{Ni: 1440, Nj: 720, LatFirst: 89.875, LonFirst: 0.125, DLat: -0.25, DLon: 0.25}
+	pacific := &grid.RegularLatLon{Ni: 81, Nj: 41, LatFirst: 10, LonFirst: 170, DLat: -0.5, DLon: 0.25}
+
+	for _, tt := range []struct {
+		name     string
+		def      grid.Definition
+		lat, lon float64
+		i        int
+		signed   float64 // Longitude of the point under LonMinus180To180
+	}{
+		{name: "west of Greenwich", def: centres, lat: 89.875, lon: -0.125, i: 1439, signed: -0.125},
+		{name: "west of Greenwich, 0..360", def: centres, lat: 89.875, lon: 359.875, i: 1439, signed: -0.125},
+		{name: "east of Greenwich", def: centres, lat: 89.875, lon: 0.125, i: 0, signed: 0.125},
+		{name: "antimeridian", def: pacific, lat: 10, lon: 180, i: 40, signed: -180},
+		{name: "antimeridian, -180", def: pacific, lat: 10, lon: -180, i: 40, signed: -180},
+		{name: "west of the antimeridian", def: pacific, lat: 10, lon: -175, i: 60, signed: -175},
+		{name: "west of the antimeridian, 0..360", def: pacific, lat: 10, lon: 185, i: 60, signed: -175},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			index, ok := grid.LatLonToIndex(tt.def, tt.lat, tt.lon)
+			require.True(t, ok)
+			assert.Equal(t, tt.i, index)
+
+			_, lon := grid.Lon0To360.LatLonAt(tt.def, index)
+			assert.InDelta(t, grid.NormalizeLongitude(tt.signed), lon, 1e-9)
+			_, lon = grid.LonMinus180To180.LatLonAt(tt.def, index)
+			assert.InDelta(t, tt.signed, lon, 1e-9)
+		})
+	}
+
+	_, ok := grid.LatLonToIndex(pacific, 10, -169)
+	assert.False(t, ok)
+}
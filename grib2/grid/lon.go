@@ -0,0 +1,51 @@
+package grid
+
+import "fmt"
+
+// LonConvention selects the range of the longitudes returned by the API. Longitudes are
+// coded in [0, 360) by GRIB2 and all computations use that range; a convention is only
+// applied to the longitudes handed back to the caller. Longitudes given by the caller,
+// e.g. to LatLonToIndex, are accepted in either range.
+type LonConvention uint8
+
+const (
+	Lon0To360        LonConvention = iota // Longitudes in [0, 360), as coded in GRIB2
+	LonMinus180To180                      // Longitudes in [-180, 180)
+)
+
+// String returns the name of the convention
+func (c LonConvention) String() string {
+	switch c {
+	case Lon0To360:
+		return "0..360"
+	case LonMinus180To180:
+		return "-180..180"
+	default:
+		return fmt.Sprintf("LonConvention(%d)", c)
+	}
+}
+
+// Longitude maps a longitude in degrees into the range of the convention
+func (c LonConvention) Longitude(lon float64) float64 {
+	if c == LonMinus180To180 {
+		return wrapLongitude(lon)
+	}
+	return NormalizeLongitude(lon)
+}
+
+// LatLonAt returns the latitude and longitude in degrees of the data value at index, with
+// the longitude in the range of the convention
+func (c LonConvention) LatLonAt(def Definition, index int) (lat, lon float64) {
+	lat, lon = LatLonAt(def, index)
+	return lat, c.Longitude(lon)
+}
+
+// Coordinates returns the latitudes and longitudes in degrees of all the data values, in
+// the order of the data values, with longitudes in the range of the convention
+func (c LonConvention) Coordinates(def Definition) (lats, lons []float64) {
+	lats, lons = Coordinates(def)
+	for k, lon := range lons {
+		lons[k] = c.Longitude(lon)
+	}
+	return lats, lons
+}
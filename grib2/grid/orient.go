@@ -0,0 +1,50 @@
+package grid
+
+import "fmt"
+
+// NorthUp reorders values from the scanning order of the grid into rows running from
+// north to south, each running from west to east in the direction of increasing x, as
+// expected by image and matrix libraries. The result has nj rows of ni values.
+func NorthUp(def Definition, values []float64) ([]float64, error) {
+	n := NumberOfPoints(def)
+	if len(values) != n {
+		return nil, fmt.Errorf("grid: %d values for a grid of %d points", len(values), n)
+	}
+
+	out := make([]float64, n)
+	for index, v := range values {
+		out[NorthUpIndex(def, index)] = v
+	}
+	return out, nil
+}
+
+// FromNorthUp is the inverse of NorthUp: it reorders north-up rows of values into the
+// scanning order of the grid, e.g. to encode them
+func FromNorthUp(def Definition, rows []float64) ([]float64, error) {
+	n := NumberOfPoints(def)
+	if len(rows) != n {
+		return nil, fmt.Errorf("grid: %d values for a grid of %d points", len(rows), n)
+	}
+
+	out := make([]float64, n)
+	for index := range out {
+		out[index] = rows[NorthUpIndex(def, index)]
+	}
+	return out, nil
+}
+
+// NorthUpIndex returns the position in the order of NorthUp of the value at index
+func NorthUpIndex(def Definition, index int) int {
+	ni, nj := def.Dims()
+	scan := def.ScanningMode()
+
+	i, j := IndexToIJ(def, index)
+	column, row := i, j
+	if scan&ScanNegativeI != 0 {
+		column = ni - 1 - i
+	}
+	if scan&ScanPositiveJ != 0 {
+		row = nj - 1 - j
+	}
+	return row*ni + column
+}
@@ -0,0 +1,45 @@
+package grid_test
+
+import (
+	"testing"
+
+	"github.com/scorix/grib/grib2/grid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNorthUp(t *testing.T) {
+	// A 4x3 grid from 10N to 12N and 0E to 3E, in every scanning order
+	for scan := range uint8(16) {
+		scan <<= 4
+		dLat, dLon, latFirst, lonFirst := -1.0, 1.0, 12.0, 0.0
+		if scan&grid.ScanPositiveJ != 0 {
+			dLat, latFirst = 1, 10
+		}
+		if scan&grid.ScanNegativeI != 0 {
+			dLon, lonFirst = -1, 3
+		}
+		def := &grid.RegularLatLon{Ni: 4, Nj: 3, LatFirst: latFirst, LonFirst: lonFirst, DLat: dLat, DLon: dLon, Scan: scan, Shape: grid.Sphere(6371229)}
+
+		// Each value holds its index in the scanning order
+		values := make([]float64, grid.NumberOfPoints(def))
+		for index := range values {
+			values[index] = float64(index)
+		}
+
+		rows, err := grid.NorthUp(def, values)
+		require.NoError(t, err)
+		for k, v := range rows {
+			lat, lon := grid.LatLonAt(def, int(v))
+			assert.InDelta(t, 12-float64(k/4), lat, 1e-9, "scan %08b at %d", scan, k)
+			assert.InDelta(t, float64(k%4), lon, 1e-9, "scan %08b at %d", scan, k)
+		}
+
+		back, err := grid.FromNorthUp(def, rows)
+		require.NoError(t, err)
+		assert.Equal(t, values, back)
+	}
+
+	_, err := grid.NorthUp(&grid.RegularLatLon{Ni: 2, Nj: 2}, []float64{1})
+	assert.ErrorContains(t, err, "1 values for a grid of 4 points")
+}
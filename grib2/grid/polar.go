@@ -0,0 +1,140 @@
+package grid
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/scorix/grib/grib2/template"
+	"github.com/scorix/grib/grib2/units"
+)
+
+// PolarStereographic is a polar stereographic grid (template 3.20).
+// Projection math follows the shape of the Earth, a sphere or an oblate spheroid.
+type PolarStereographic struct {
+	Nx, Ny    int     // Number of points along the x and y axes
+	La1, Lo1  float64 // Latitude and longitude of the first grid point in degrees
+	LoV       float64 // Longitude of the meridian parallel to the y axis in degrees
+	LaD       float64 // Latitude where Dx and Dy are specified (true scale) in degrees
+	Dx, Dy    float64 // Grid lengths in metres
+	SouthPole bool    // Projection centre is the south pole
+	Scan      uint8   // Scanning mode flags (Flag Table 3.4)
+	Shape     Earth   // Shape of the Earth
+}
+
+var _ Definition = (*PolarStereographic)(nil)
+
+// Dims returns the number of points along the x and y axes
+func (g *PolarStereographic) Dims() (ni, nj int) {
+	return g.Nx, g.Ny
+}
+
+// ScanningMode returns the scanning mode flags
+func (g *PolarStereographic) ScanningMode() uint8 {
+	return g.Scan
+}
+
+// Earth returns the shape of the Earth
+func (g *PolarStereographic) Earth() Earth {
+	return g.Shape
+}
+
+// PointLatLon returns the latitude and longitude of the point at (i, j)
+func (g *PolarStereographic) PointLatLon(i, j int) (lat, lon float64) {
+	x0, y0 := g.Project(g.La1, g.Lo1)
+	dx, dy := stepDirections(g.Scan, g.Dx, g.Dy)
+	return g.Unproject(x0+float64(i)*dx, y0+float64(j)*dy)
+}
+
+// LatLonToIJ returns the fractional grid coordinates of a latitude and longitude in degrees.
+// The coordinates of a location outside the grid lie outside [0, Nx-1] × [0, Ny-1].
+func (g *PolarStereographic) LatLonToIJ(lat, lon float64) (i, j float64) {
+	x, y := g.Project(lat, lon)
+	x0, y0 := g.Project(g.La1, g.Lo1)
+	dx, dy := stepDirections(g.Scan, g.Dx, g.Dy)
+	return (x - x0) / dx, (y - y0) / dy
+}
+
+// nearestIJ returns the grid coordinates of the point nearest to a location
+func (g *PolarStereographic) nearestIJ(lat, lon float64) (i, j int, ok bool) {
+	fi, fj := g.LatLonToIJ(lat, lon)
+	return nearestGridIJ(fi, fj, g.Nx, g.Ny)
+}
+
+// hemisphere returns 1 for a north polar projection and -1 for a south polar one
+func (g *PolarStereographic) hemisphere() float64 {
+	if g.SouthPole {
+		return -1
+	}
+	return 1
+}
+
+// scale returns the distance from the pole in metres per unit of Snyder's t, which fixes
+// the true scale at LaD
+func (g *PolarStereographic) scale(e float64) float64 {
+	a := g.Shape.SemiMajorAxis
+	phiC := g.hemisphere() * radians(g.LaD)
+	if math.Abs(phiC-math.Pi/2) < 1e-10 {
+		return 2 * a / math.Sqrt(math.Pow(1+e, 1+e)*math.Pow(1-e, 1-e))
+	}
+	return a * conformalM(phiC, e) / conformalT(phiC, e)
+}
+
+// Project converts a latitude and longitude in degrees into projection coordinates in metres,
+// with the origin at the pole
+func (g *PolarStereographic) Project(lat, lon float64) (x, y float64) {
+	h := g.hemisphere()
+	e := g.Shape.Eccentricity()
+	rho := g.scale(e) * conformalT(h*radians(lat), e)
+	theta := radians(lon - g.LoV)
+	return rho * math.Sin(theta), -h * rho * math.Cos(theta)
+}
+
+// Unproject converts projection coordinates in metres into a latitude and longitude in degrees
+func (g *PolarStereographic) Unproject(x, y float64) (lat, lon float64) {
+	h := g.hemisphere()
+	e := g.Shape.Eccentricity()
+	phi := conformalLatitude(math.Hypot(x, y)/g.scale(e), e)
+	return h * degrees(phi), NormalizeLongitude(g.LoV + degrees(math.Atan2(x, -h*y)))
+}
+
+// MapFactor returns the map scale factor k at a latitude in degrees
+func (g *PolarStereographic) MapFactor(lat float64) float64 {
+	e := g.Shape.Eccentricity()
+	phi := g.hemisphere() * radians(lat)
+	return g.scale(e) * conformalT(phi, e) / (g.Shape.SemiMajorAxis * conformalM(phi, e))
+}
+
+// newPolarStereographicFromTemplate builds the geometry of template 3.20
+func newPolarStereographicFromTemplate(g *template.GridTemplate) (Definition, error) {
+	if g.PolarStereo == nil {
+		return nil, fmt.Errorf("grid: template 3.20 fields are not available")
+	}
+	t := g.PolarStereo
+
+	earth, err := EarthShape{
+		ShapeOfEarth:           t.ShapeOfEarth,
+		ScaleFactorRadiusEarth: t.ScaleFactorRadiusEarth,
+		ScaledValueRadiusEarth: t.ScaledValueRadiusEarth,
+		ScaleFactorMajorAxis:   t.ScaleFactorMajorAxis,
+		ScaledValueMajorAxis:   t.ScaledValueMajorAxis,
+		ScaleFactorMinorAxis:   t.ScaleFactorMinorAxis,
+		ScaledValueMinorAxis:   t.ScaledValueMinorAxis,
+	}.Earth()
+	if err != nil {
+		return nil, err
+	}
+
+	return &PolarStereographic{
+		Nx:        int(t.NumberOfGridPointsAlongX),
+		Ny:        int(t.NumberOfGridPointsAlongY),
+		La1:       units.Microdegrees(t.LatitudeOfFirstGridPoint),
+		Lo1:       units.Microdegrees(int32(t.LongitudeOfFirstGridPoint)),
+		LoV:       units.Microdegrees(int32(t.OrientationOfGrid)),
+		LaD:       units.Microdegrees(t.LatitudeWhereDxDySpecified),
+		Dx:        float64(t.XDirectionIncrement) * 1e-3,
+		Dy:        float64(t.YDirectionIncrement) * 1e-3,
+		SouthPole: t.ProjectionCenterFlag&0x80 != 0,
+		Scan:      t.ScanningMode,
+		Shape:     earth,
+	}, nil
+}
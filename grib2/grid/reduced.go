@@ -0,0 +1,94 @@
+package grid
+
+import (
+	"fmt"
+	"math"
+	"slices"
+)
+
+// ReducedRows splits the values of a reduced (quasi-regular) grid into its rows, given the
+// number of points of each row from the optional list of Section 3. The rows share the
+// backing array of values.
+func ReducedRows(values []float64, pointsPerRow []uint32) ([][]float64, error) {
+	if n := reducedPoints(pointsPerRow); len(values) != n {
+		return nil, fmt.Errorf("grid: %d values for a reduced grid of %d points", len(values), n)
+	}
+
+	rows := make([][]float64, len(pointsPerRow))
+	offset := 0
+	for k, n := range pointsPerRow {
+		rows[k] = values[offset : offset+int(n) : offset+int(n)]
+		offset += int(n)
+	}
+	return rows, nil
+}
+
+// ExpandReducedGrid interpolates each row of a reduced (quasi-regular) grid linearly in
+// longitude to targetNi points, returning a rectangular grid of len(pointsPerRow) rows
+// in the order of the rows. A targetNi of zero selects the number of points of the
+// longest row.
+//
+// Each row of n points covers a full circle of latitude with its points 360/n degrees
+// apart from a common first longitude, as given by the optional list interpretation 1
+// (Code Table 3.11); interpolation wraps from the last point of a row back to the first.
+// A NaN value only leaves its neighbour in the interpolation, and a row without points
+// is all NaN.
+func ExpandReducedGrid(values []float64, pointsPerRow []uint32, targetNi int) ([]float64, error) {
+	rows, err := ReducedRows(values, pointsPerRow)
+	if err != nil {
+		return nil, err
+	}
+	if targetNi == 0 && len(pointsPerRow) > 0 {
+		targetNi = int(slices.Max(pointsPerRow))
+	}
+	if targetNi < 0 {
+		return nil, fmt.Errorf("grid: invalid number of points %d along the rows", targetNi)
+	}
+
+	expanded := make([]float64, 0, len(rows)*targetNi)
+	for _, row := range rows {
+		expanded = append(expanded, expandRow(row, targetNi)...)
+	}
+	return expanded, nil
+}
+
+// expandRow interpolates the n points of a full circle to ni points
+func expandRow(row []float64, ni int) []float64 {
+	out := make([]float64, ni)
+	n := len(row)
+	if n == 0 {
+		for i := range out {
+			out[i] = math.NaN()
+		}
+		return out
+	}
+	if n == ni {
+		copy(out, row)
+		return out
+	}
+
+	for i := range out {
+		pos := float64(i) * float64(n) / float64(ni)
+		k := int(pos)
+		w := pos - float64(k)
+		v0, v1 := row[k%n], row[(k+1)%n]
+		switch {
+		case w == 0 || math.IsNaN(v1):
+			out[i] = v0
+		case math.IsNaN(v0):
+			out[i] = v1
+		default:
+			out[i] = (1-w)*v0 + w*v1
+		}
+	}
+	return out
+}
+
+// reducedPoints returns the number of points of a reduced grid
+func reducedPoints(pointsPerRow []uint32) int {
+	n := 0
+	for _, points := range pointsPerRow {
+		n += int(points)
+	}
+	return n
+}
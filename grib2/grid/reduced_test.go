@@ -0,0 +1,49 @@
+package grid_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/scorix/grib/grib2/grid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandReducedGrid(t *testing.T) {
+	nan := math.NaN()
+	pointsPerRow := []uint32{4, 8, 0}
+	values := []float64{
+		0, 10, 20, 30,
+		1, 2, 3, 4, 5, 6, 7, 8,
+	}
+
+	rows, err := grid.ReducedRows(values, pointsPerRow)
+	require.NoError(t, err)
+	assert.Equal(t, [][]float64{{0, 10, 20, 30}, {1, 2, 3, 4, 5, 6, 7, 8}, {}}, rows)
+
+	// Rows are interpolated to the longest row, wrapping around the circle of latitude
+	expanded, err := grid.ExpandReducedGrid(values, pointsPerRow, 0)
+	require.NoError(t, err)
+	require.Len(t, expanded, 24)
+	assert.Equal(t, []float64{0, 5, 10, 15, 20, 25, 30, 15}, expanded[:8])
+	assert.Equal(t, values[4:], expanded[8:16])
+	for _, v := range expanded[16:] {
+		assert.True(t, math.IsNaN(v))
+	}
+
+	expanded, err = grid.ExpandReducedGrid(values, pointsPerRow, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []float64{0, 20, 1, 5}, expanded[:4])
+
+	// A NaN point leaves its neighbour in the interpolation
+	expanded, err = grid.ExpandReducedGrid([]float64{0, nan, 20, 30}, []uint32{4}, 8)
+	require.NoError(t, err)
+	assert.Equal(t, []float64{0, 0}, expanded[:2])
+	assert.True(t, math.IsNaN(expanded[2]))
+	assert.Equal(t, []float64{20, 20, 25, 30, 15}, expanded[3:])
+
+	_, err = grid.ExpandReducedGrid(values[:11], pointsPerRow, 8)
+	assert.ErrorContains(t, err, "11 values for a reduced grid of 12 points")
+	_, err = grid.ExpandReducedGrid(values, pointsPerRow, -1)
+	assert.ErrorContains(t, err, "invalid number of points -1")
+}
@@ -0,0 +1,276 @@
+package grid
+
+import (
+	"fmt"
+	"math"
+	"sync"
+)
+
+// RegridMethod selects how Regrid interpolates values onto a destination grid
+type RegridMethod uint8
+
+const (
+	RegridNearest  RegridMethod = iota // Value of the nearest source point
+	RegridBilinear                     // Bilinear interpolation between the four surrounding source points
+)
+
+// String returns the name of the method
+func (m RegridMethod) String() string {
+	switch m {
+	case RegridNearest:
+		return "nearest"
+	case RegridBilinear:
+		return "bilinear"
+	default:
+		return fmt.Sprintf("method(%d)", m)
+	}
+}
+
+// Regridder maps values from a source grid onto a destination grid. The source points
+// and weights of every destination point are computed once by NewRegridder, so that
+// the fields of a file sharing the same grids are regridded without redoing the geometry.
+// A Regridder is safe for concurrent use.
+type Regridder struct {
+	method  RegridMethod
+	nSrc    int
+	stencil int       // Number of source points per destination point
+	indices []int     // Source indices, stencil per destination point; -1 when unused
+	weights []float64 // Weight of each source index
+	nDst    int
+	outside int // Number of destination points outside the source grid
+}
+
+// NewRegridder computes the mapping from the points of src to the points of dst.
+//
+// Nearest neighbour takes the value of the source point found by LatLonToIndex.
+// Bilinear interpolation needs fractional grid coordinates on the source grid and is
+// supported for regular lat/lon, Gaussian, Lambert conformal and polar stereographic
+// source grids; it wraps around global rows. Destination points outside the source grid
+// get NaN.
+func NewRegridder(src, dst Definition, method RegridMethod) (*Regridder, error) {
+	r := &Regridder{
+		method: method,
+		nSrc:   NumberOfPoints(src),
+		nDst:   NumberOfPoints(dst),
+	}
+
+	switch method {
+	case RegridNearest:
+		r.stencil = 1
+	case RegridBilinear:
+		if _, ok := src.(fractionalLocator); !ok {
+			return nil, fmt.Errorf("grid: bilinear regridding is not supported from %T", src)
+		}
+		r.stencil = 4
+	default:
+		return nil, fmt.Errorf("grid: unsupported regridding method %s", method)
+	}
+
+	r.indices = make([]int, r.nDst*r.stencil)
+	r.weights = make([]float64, r.nDst*r.stencil)
+	for index := range r.nDst {
+		lat, lon := LatLonAt(dst, index)
+		indices := r.indices[index*r.stencil : (index+1)*r.stencil]
+		weights := r.weights[index*r.stencil : (index+1)*r.stencil]
+
+		var ok bool
+		if method == RegridNearest {
+			indices[0], ok = LatLonToIndex(src, lat, lon)
+			weights[0] = 1
+		} else {
+			ok = bilinearStencil(src, lat, lon, indices, weights)
+		}
+		if !ok {
+			r.outside++
+			for k := range indices {
+				indices[k], weights[k] = -1, 0
+			}
+		}
+	}
+
+	return r, nil
+}
+
+// Method returns the interpolation method of the regridder
+func (r *Regridder) Method() RegridMethod {
+	return r.method
+}
+
+// Outside returns the number of destination points lying outside the source grid
+func (r *Regridder) Outside() int {
+	return r.outside
+}
+
+// Regrid interpolates values of the source grid, in scanning order, onto the destination
+// grid, returning values in the scanning order of the destination grid. NaN source values
+// are left out of the interpolation and the remaining weights renormalised; a destination
+// point whose source points are all NaN, or that lies outside the source grid, is NaN.
+func (r *Regridder) Regrid(values []float64) ([]float64, error) {
+	if len(values) != r.nSrc {
+		return nil, fmt.Errorf("grid: %d values for a grid of %d points", len(values), r.nSrc)
+	}
+
+	result := make([]float64, r.nDst)
+	for index := range result {
+		var sum, total float64
+		for k := index * r.stencil; k < (index+1)*r.stencil; k++ {
+			w := r.weights[k]
+			if w == 0 {
+				continue
+			}
+			if v := values[r.indices[k]]; !math.IsNaN(v) {
+				sum += w * v
+				total += w
+			}
+		}
+		if total == 0 {
+			result[index] = math.NaN()
+		} else {
+			result[index] = sum / total
+		}
+	}
+	return result, nil
+}
+
+// maxCachedRegridders bounds the number of regridders kept by Regrid
+const maxCachedRegridders = 16
+
+// regridKey identifies a pair of grids and a method in the cache of Regrid
+type regridKey struct {
+	src, dst string
+	method   RegridMethod
+}
+
+var (
+	regridMu    sync.Mutex
+	regridCache = map[regridKey]*Regridder{}
+)
+
+// Regrid interpolates values of the src grid, in scanning order, onto the dst grid with
+// the given method; see NewRegridder and Regridder.Regrid.
+//
+// The regridders of the built-in grid types are cached by the geometry of both grids,
+// so that regridding several fields between the same grids computes the mapping once.
+// Use NewRegridder directly to control the lifetime of the mapping.
+func Regrid(src Definition, values []float64, dst Definition, method RegridMethod) ([]float64, error) {
+	if n := NumberOfPoints(src); len(values) != n {
+		return nil, fmt.Errorf("grid: %d values for a grid of %d points", len(values), n)
+	}
+
+	srcKey, srcOK := definitionKey(src)
+	dstKey, dstOK := definitionKey(dst)
+	if !srcOK || !dstOK {
+		r, err := NewRegridder(src, dst, method)
+		if err != nil {
+			return nil, err
+		}
+		return r.Regrid(values)
+	}
+
+	key := regridKey{src: srcKey, dst: dstKey, method: method}
+	regridMu.Lock()
+	r, ok := regridCache[key]
+	regridMu.Unlock()
+	if !ok {
+		var err error
+		if r, err = NewRegridder(src, dst, method); err != nil {
+			return nil, err
+		}
+		regridMu.Lock()
+		if len(regridCache) >= maxCachedRegridders {
+			clear(regridCache)
+		}
+		regridCache[key] = r
+		regridMu.Unlock()
+	}
+	return r.Regrid(values)
+}
+
+// definitionKey returns a key identifying the geometry of a built-in grid type, and false
+// for other implementations of Definition
+func definitionKey(def Definition) (string, bool) {
+	switch g := def.(type) {
+	case *RegularLatLon:
+		return fmt.Sprintf("%T%+v", g, *g), true
+	case *LambertConformal:
+		return fmt.Sprintf("%T%+v", g, *g), true
+	case *PolarStereographic:
+		return fmt.Sprintf("%T%+v", g, *g), true
+	case *Gaussian:
+		// The latitudes and weights follow from N and the first row
+		key := *g
+		key.Lats, key.Weights = nil, nil
+		first := math.NaN()
+		if len(g.Lats) > 0 {
+			first = g.Lats[0]
+		}
+		return fmt.Sprintf("%T%+v%v", g, key, first), true
+	default:
+		return "", false
+	}
+}
+
+// fractionalLocator is implemented by the grids that give the fractional grid coordinates
+// of a location, as needed by bilinear interpolation
+type fractionalLocator interface {
+	LatLonToIJ(lat, lon float64) (i, j float64)
+}
+
+// bilinearStencil fills the four source indices and weights surrounding a location, and
+// returns false when the location lies outside the source grid
+func bilinearStencil(src Definition, lat, lon float64, indices []int, weights []float64) bool {
+	fi, fj := src.(fractionalLocator).LatLonToIJ(lat, lon)
+	ni, nj := src.Dims()
+
+	i0, i1, wi, iok := bilinearAxis(fi, ni, globalRows(src))
+	j0, j1, wj, jok := bilinearAxis(fj, nj, false)
+	if !iok || !jok {
+		return false
+	}
+
+	indices[0], weights[0] = IJToIndex(src, i0, j0), (1-wi)*(1-wj)
+	indices[1], weights[1] = IJToIndex(src, i1, j0), wi*(1-wj)
+	indices[2], weights[2] = IJToIndex(src, i0, j1), (1-wi)*wj
+	indices[3], weights[3] = IJToIndex(src, i1, j1), wi*wj
+	return true
+}
+
+// bilinearAxis returns the two points of an axis of n points surrounding the fractional
+// position pos, and the weight of the second. A periodic axis wraps from the last point
+// back to the first.
+func bilinearAxis(pos float64, n int, periodic bool) (p0, p1 int, w float64, ok bool) {
+	const eps = 1e-9
+	upper := float64(n - 1)
+	if periodic {
+		upper = float64(n)
+	}
+	if !(pos >= -eps && pos <= upper+eps) || n == 0 {
+		return 0, 0, 0, false
+	}
+	if n == 1 {
+		return 0, 0, 0, true
+	}
+
+	pos = min(max(pos, 0), upper)
+	p0 = min(int(pos), n-1)
+	if !periodic && p0 == n-1 {
+		p0--
+	}
+	p1 = p0 + 1
+	if p1 == n {
+		p1 = 0
+	}
+	return p0, p1, pos - float64(p0), true
+}
+
+// globalRows reports whether the rows of a grid go all the way around the globe
+func globalRows(def Definition) bool {
+	switch g := def.(type) {
+	case *RegularLatLon:
+		return float64(g.Ni)*math.Abs(g.DLon) >= 360-1e-9
+	case *Gaussian:
+		return float64(g.Ni)*math.Abs(g.DLon) >= 360-1e-9
+	default:
+		return false
+	}
+}
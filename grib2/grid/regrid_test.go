@@ -0,0 +1,126 @@
+package grid_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/scorix/grib/grib2/grid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fieldOf evaluates f at every point of a grid, in scanning order
+func fieldOf(def grid.Definition, f func(lat, lon float64) float64) []float64 {
+	lats, lons := grid.Coordinates(def)
+	values := make([]float64, len(lats))
+	for k := range values {
+		values[k] = f(lats[k], lons[k])
+	}
+	return values
+}
+
+func TestRegrid_Downsample(t *testing.T) {
+	earth := grid.Sphere(6371229)
+	quarter := &grid.RegularLatLon{Ni: 1440, Nj: 721, LatFirst: 90, DLat: -0.25, DLon: 0.25, Shape: earth}
+	oneDegree := &grid.RegularLatLon{Ni: 360, Nj: 181, LatFirst: -90, DLat: 1, DLon: 1, Scan: grid.ScanPositiveJ, Shape: earth}
+
+	f := func(lat, lon float64) float64 { return lat + 10*math.Cos(radians(lon)) }
+	values := fieldOf(quarter, f)
+	want := fieldOf(oneDegree, f)
+
+	for _, method := range []grid.RegridMethod{grid.RegridNearest, grid.RegridBilinear} {
+		t.Run(method.String(), func(t *testing.T) {
+			got, err := grid.Regrid(quarter, values, oneDegree, method)
+			require.NoError(t, err)
+			assert.InDeltaSlice(t, want, got, 1e-9)
+
+			// A second call with an equal grid pair uses the cached mapping
+			again, err := grid.Regrid(quarter, values, &grid.RegularLatLon{Ni: 360, Nj: 181, LatFirst: -90, DLat: 1, DLon: 1, Scan: grid.ScanPositiveJ, Shape: earth}, method)
+			require.NoError(t, err)
+			assert.Equal(t, got, again)
+		})
+	}
+}
+
+func TestRegrid_Bilinear(t *testing.T) {
+	earth := grid.Sphere(6371229)
+	global := &grid.RegularLatLon{Ni: 360, Nj: 181, LatFirst: 90, DLat: -1, DLon: 1, Shape: earth}
+	offset := &grid.RegularLatLon{Ni: 4, Nj: 3, LatFirst: 10.5, LonFirst: 358.5, DLat: -0.5, DLon: 0.5, Shape: earth}
+
+	// Bilinear interpolation reproduces a field linear in latitude, across the wrap of the
+	// longitude at 0°
+	values := fieldOf(global, func(lat, lon float64) float64 { return 2 * lat })
+	got, err := grid.Regrid(global, values, offset, grid.RegridBilinear)
+	require.NoError(t, err)
+	assert.InDeltaSlice(t, fieldOf(offset, func(lat, lon float64) float64 { return 2 * lat }), got, 1e-9)
+
+	lons := fieldOf(global, func(lat, lon float64) float64 { return lon })
+	got, err = grid.Regrid(global, lons, offset, grid.RegridBilinear)
+	require.NoError(t, err)
+	assert.InDeltaSlice(t, []float64{358.5, 359, 179.5, 0}, got[:4], 1e-9)
+
+	// A Lambert conformal field onto a lat/lon grid inside its domain
+	lambert := &grid.LambertConformal{Nx: 21, Ny: 16, La1: 30, Lo1: 250, LoV: 265, Latin1: 25, Latin2: 50, Dx: 25000, Dy: 25000, Scan: grid.ScanPositiveJ, Shape: earth}
+	regional := &grid.RegularLatLon{Ni: 5, Nj: 4, LatFirst: 31, LonFirst: 251, DLat: 0.5, DLon: 0.5, Scan: grid.ScanPositiveJ, Shape: earth}
+	lats := fieldOf(lambert, func(lat, lon float64) float64 { return lat })
+	got, err = grid.Regrid(lambert, lats, regional, grid.RegridBilinear)
+	require.NoError(t, err)
+	assert.InDeltaSlice(t, fieldOf(regional, func(lat, lon float64) float64 { return lat }), got, 1e-3)
+
+	// A global Gaussian grid covers the poles with its outermost rows
+	lats4, _ := grid.GaussianLatitudes(4)
+	gaussian := &grid.Gaussian{Ni: 16, Nj: 8, N: 4, DLon: 22.5, Lats: lats4, Shape: earth}
+	poles := &grid.RegularLatLon{Ni: 1, Nj: 2, LatFirst: 90, DLat: -180, DLon: 1, Shape: earth}
+	got, err = grid.Regrid(gaussian, fieldOf(gaussian, func(lat, lon float64) float64 { return lat }), poles, grid.RegridBilinear)
+	require.NoError(t, err)
+	assert.InDeltaSlice(t, []float64{lats4[0], lats4[7]}, got, 1e-9)
+}
+
+func TestRegrid_MissingAndOutside(t *testing.T) {
+	earth := grid.Sphere(6371229)
+	src := &grid.RegularLatLon{Ni: 3, Nj: 3, LatFirst: 10, LonFirst: 20, DLat: -1, DLon: 1, Shape: earth}
+	dst := &grid.RegularLatLon{Ni: 4, Nj: 1, LatFirst: 9.5, LonFirst: 19, DLat: -1, DLon: 0.5, Shape: earth}
+
+	nan := math.NaN()
+	values := []float64{
+		1, 2, 3,
+		4, nan, 6,
+		7, 8, 9,
+	}
+
+	r, err := grid.NewRegridder(src, dst, grid.RegridBilinear)
+	require.NoError(t, err)
+	assert.Equal(t, grid.RegridBilinear, r.Method())
+	assert.Equal(t, 2, r.Outside())
+
+	got, err := r.Regrid(values)
+	require.NoError(t, err)
+	// 19° and 19.5° lie outside the grid; at 20° the NaN at 21° gets no weight, and at
+	// 20.5° the weights of the other three points are renormalised
+	assert.True(t, math.IsNaN(got[0]))
+	assert.True(t, math.IsNaN(got[1]))
+	assert.InDelta(t, 2.5, got[2], 1e-12)
+	assert.InDelta(t, (1+2+4)/3.0, got[3], 1e-12)
+
+	// All the surrounding points are NaN
+	got, err = r.Regrid([]float64{nan, nan, 3, nan, nan, 6, 7, 8, 9})
+	require.NoError(t, err)
+	assert.True(t, math.IsNaN(got[2]))
+	assert.True(t, math.IsNaN(got[3]))
+
+	// Nearest neighbour leaves points beyond half a step outside the grid as NaN
+	nearest := &grid.RegularLatLon{Ni: 4, Nj: 1, LatFirst: 9.75, LonFirst: 19.25, DLat: -1, DLon: 0.75, Shape: earth}
+	got, err = grid.Regrid(src, values, nearest, grid.RegridNearest)
+	require.NoError(t, err)
+	assert.True(t, math.IsNaN(got[0]))
+	assert.Equal(t, []float64{1, 2, 3}, got[1:])
+
+	_, err = grid.Regrid(src, values[:8], dst, grid.RegridNearest)
+	assert.ErrorContains(t, err, "8 values for a grid of 9 points")
+	_, err = grid.NewRegridder(src, dst, grid.RegridMethod(9))
+	assert.ErrorContains(t, err, "unsupported regridding method method(9)")
+}
+
+func radians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
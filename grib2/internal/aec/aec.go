@@ -0,0 +1,294 @@
+// Package aec decodes and encodes the Adaptive Entropy Coding of CCSDS 121.0-B, the
+// lossless compression of GRIB2 data representation template 5.42, as laid out by libaec:
+// blocks of samples coded with the option that suits them best, grouped into reference
+// sample intervals that each restart the optional unit delay predictor.
+package aec
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/scorix/grib/grib2/internal/bitio"
+)
+
+// Flags of libaec, which template 5.42 stores as its CCSDS compression options mask.
+// FlagMSB and Flag3Byte set how libaec lays samples out in memory, which does not affect
+// the coded data.
+const (
+	FlagSigned     = 1 << iota // Samples are signed, in two's complement
+	Flag3Byte                  // Samples of 17 to 24 bits take 3 octets in memory
+	FlagMSB                    // Samples are stored most significant octet first in memory
+	FlagPreprocess             // Samples are coded as the mapped residuals of the unit delay predictor
+	FlagRestricted             // Samples of at most 4 bits use the restricted set of code options
+	FlagPadRSI                 // Each reference sample interval is padded to an octet boundary
+	FlagNotEnforce             // Block sizes other than 8, 16, 32 and 64 are allowed
+)
+
+// Params are the coding parameters of a stream
+type Params struct {
+	BitsPerSample int   // Bits per sample, 1 to 32
+	BlockSize     int   // Samples per block, 8, 16, 32 or 64
+	RSI           int   // Blocks per reference sample interval, at most 4096
+	Flags         uint8 // Flag* bits
+}
+
+// Code options: the identifiers of the no compression option are all ones and those in
+// between select the sample splitting with k = id - 1
+const (
+	idLowEntropy = 0 // Zero block or second extension, told apart by the next bit
+	rosBlocks    = 5 // Number of zero blocks coding the remainder of the segment
+	segmentSize  = 64
+	maxSE        = 90 // Largest second extension code, for pairs adding up to 12
+)
+
+// ErrTruncated is returned when the data ends before the samples requested
+var ErrTruncated = errors.New("aec: truncated data")
+
+// check validates the parameters and returns the length of the option identifiers
+func (p Params) check() (idLen uint, err error) {
+	switch {
+	case p.BitsPerSample < 1 || p.BitsPerSample > 32:
+		return 0, fmt.Errorf("aec: invalid bits per sample %d", p.BitsPerSample)
+	case p.RSI < 1 || p.RSI > 4096:
+		return 0, fmt.Errorf("aec: invalid reference sample interval %d", p.RSI)
+	case p.Flags&FlagNotEnforce == 0 && p.BlockSize != 8 && p.BlockSize != 16 && p.BlockSize != 32 && p.BlockSize != 64,
+		p.BlockSize < 2 || p.BlockSize%2 != 0:
+		return 0, fmt.Errorf("aec: invalid block size %d", p.BlockSize)
+	}
+	switch n := p.BitsPerSample; {
+	case n > 16:
+		return 5, nil
+	case n > 8:
+		return 4, nil
+	case p.Flags&FlagRestricted == 0:
+		return 3, nil
+	case n <= 2:
+		return 1, nil
+	case n <= 4:
+		return 2, nil
+	}
+	return 0, fmt.Errorf("aec: restricted code options for %d bits per sample", p.BitsPerSample)
+}
+
+// limits returns the range of the samples
+func (p Params) limits() (xmin, xmax int64) {
+	if p.Flags&FlagSigned != 0 {
+		return -1 << (p.BitsPerSample - 1), 1<<(p.BitsPerSample-1) - 1
+	}
+	return 0, 1<<p.BitsPerSample - 1
+}
+
+// signed returns the sample of raw bits x, sign extended for signed samples
+func (p Params) signed(x int64) int64 {
+	if p.Flags&FlagSigned != 0 && x>>(p.BitsPerSample-1) != 0 {
+		x -= 1 << p.BitsPerSample
+	}
+	return x
+}
+
+// Decode decodes n samples from data
+func Decode(data []byte, n int, p Params) ([]int64, error) {
+	idLen, err := p.check()
+	if err != nil {
+		return nil, err
+	}
+	if n < 0 {
+		return nil, fmt.Errorf("aec: invalid number of samples %d", n)
+	}
+
+	d := &decoder{r: bitio.NewReader(data), p: p, idLen: idLen}
+	out := make([]int64, 0, n)
+	rsi := make([]int64, 0, p.RSI*p.BlockSize)
+	for len(out) < n {
+		rsi, err = d.interval(rsi[:0], min(p.RSI*p.BlockSize, n-len(out)))
+		if err != nil {
+			if errors.Is(err, bitio.ErrOverrun) {
+				err = ErrTruncated
+			}
+			return nil, fmt.Errorf("%w after %d of %d samples", err, len(out), n)
+		}
+		out = d.postprocess(out, rsi[:min(len(rsi), n-len(out))])
+		if p.Flags&FlagPadRSI != 0 {
+			d.r.Align()
+		}
+	}
+	return out, nil
+}
+
+// decoder reads the coded blocks of a stream
+type decoder struct {
+	r     *bitio.Reader
+	p     Params
+	idLen uint
+}
+
+func (d *decoder) bits(n int) (int64, error) {
+	x, err := d.r.ReadBits(uint(n))
+	return int64(x), err
+}
+
+// fs reads a fundamental sequence, a count of zeros ended by a one
+func (d *decoder) fs() (int64, error) {
+	var m int64
+	for {
+		bit, err := d.r.ReadBits(1)
+		if err != nil {
+			return 0, err
+		}
+		if bit == 1 {
+			return m, nil
+		}
+		m++
+	}
+}
+
+// interval appends to buf the coded samples of a reference sample interval, at least need
+// of them: the reference sample and the mapped residuals with preprocessing, else the
+// samples themselves
+func (d *decoder) interval(buf []int64, need int) ([]int64, error) {
+	J, n := d.p.BlockSize, d.p.BitsPerSample
+	for block := 0; block < d.p.RSI && len(buf) < need; block++ {
+		id, err := d.bits(int(d.idLen))
+		if err != nil {
+			return nil, err
+		}
+		// The reference sample takes the place of the first sample of the interval
+		ref := d.p.Flags&FlagPreprocess != 0 && block == 0
+		skip := 0
+		if ref {
+			skip = 1
+		}
+		switch {
+		case id == 1<<d.idLen-1: // No compression, the reference sample being the first
+			for range J {
+				x, err := d.bits(n)
+				if err != nil {
+					return nil, err
+				}
+				buf = append(buf, x)
+			}
+
+		case id == idLowEntropy:
+			secondExtension, err := d.bits(1)
+			if err != nil {
+				return nil, err
+			}
+			if buf, err = d.reference(buf, ref); err != nil {
+				return nil, err
+			}
+			if secondExtension == 1 {
+				if buf, err = d.secondExtension(buf, ref); err != nil {
+					return nil, err
+				}
+				break
+			}
+
+			blocks, err := d.fs()
+			if err != nil {
+				return nil, err
+			}
+			switch blocks++; {
+			case blocks == rosBlocks: // Remainder of the segment
+				blocks = int64(min(d.p.RSI-block, segmentSize-block%segmentSize))
+			case blocks > rosBlocks:
+				blocks--
+			}
+			if block+int(blocks) > d.p.RSI {
+				return nil, fmt.Errorf("aec: %d zero blocks from block %d of a reference sample interval of %d", blocks, block, d.p.RSI)
+			}
+			buf = append(buf, make([]int64, int(blocks)*J-skip)...)
+			block += int(blocks) - 1
+
+		default: // Sample splitting
+			k := int(id - 1)
+			if buf, err = d.reference(buf, ref); err != nil {
+				return nil, err
+			}
+			start := len(buf)
+			for range J - skip {
+				m, err := d.fs()
+				if err != nil {
+					return nil, err
+				}
+				buf = append(buf, m<<k)
+			}
+			for i := start; i < len(buf); i++ {
+				lsb, err := d.bits(k)
+				if err != nil {
+					return nil, err
+				}
+				buf[i] |= lsb
+			}
+		}
+	}
+	return buf, nil
+}
+
+// reference appends the reference sample that starts the first block of an interval
+func (d *decoder) reference(buf []int64, ref bool) ([]int64, error) {
+	if !ref {
+		return buf, nil
+	}
+	x, err := d.bits(d.p.BitsPerSample)
+	return append(buf, x), err
+}
+
+// secondExtension appends the samples of a block coded with the second extension option:
+// each pair a, b coded as the fundamental sequence of (a+b)(a+b+1)/2 + b. The reference
+// sample takes the place of the first sample of the first pair.
+func (d *decoder) secondExtension(buf []int64, ref bool) ([]int64, error) {
+	J := d.p.BlockSize
+	for i := 0; i < J; i += 2 {
+		m, err := d.fs()
+		if err != nil {
+			return nil, err
+		}
+		if m > maxSE {
+			return nil, fmt.Errorf("aec: invalid second extension code %d", m)
+		}
+		// Largest sum of a pair whose codes do not exceed m
+		sum := int64(0)
+		for (sum+1)*(sum+2)/2 <= m {
+			sum++
+		}
+		b := m - sum*(sum+1)/2
+		if !(ref && i == 0) {
+			buf = append(buf, sum-b)
+		}
+		buf = append(buf, b)
+	}
+	return buf, nil
+}
+
+// postprocess appends the samples of an interval to out: those decoded directly or,
+// with preprocessing, those predicted from the reference sample and the mapped residuals
+func (d *decoder) postprocess(out, rsi []int64) []int64 {
+	if d.p.Flags&FlagPreprocess == 0 {
+		for _, x := range rsi {
+			out = append(out, d.p.signed(x))
+		}
+		return out
+	}
+	if len(rsi) == 0 {
+		return out
+	}
+
+	xmin, xmax := d.p.limits()
+	last := d.p.signed(rsi[0])
+	out = append(out, last)
+	for _, delta := range rsi[1:] {
+		theta := min(last-xmin, xmax-last)
+		switch {
+		case delta <= 2*theta && delta%2 == 0:
+			last += delta / 2
+		case delta <= 2*theta:
+			last -= (delta + 1) / 2
+		case theta == last-xmin:
+			last = xmin + delta
+		default:
+			last = xmax - delta
+		}
+		out = append(out, last)
+	}
+	return out
+}
@@ -0,0 +1,196 @@
+package aec_test
+
+import (
+	"math"
+	"math/rand/v2"
+	"strings"
+	"testing"
+
+	"github.com/scorix/grib/grib2/internal/aec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// bits packs a string of '0' and '1', ignoring spaces, into octets padded with zeros
+func bits(s string) []byte {
+	s = strings.ReplaceAll(s, " ", "")
+	out := make([]byte, (len(s)+7)/8)
+	for i, c := range s {
+		if c == '1' {
+			out[i/8] |= 0x80 >> (i % 8)
+		}
+	}
+	return out
+}
+
+func TestDecode_Options(t *testing.T) {
+	tests := []struct {
+		name   string
+		params aec.Params
+		data   []byte
+		want   []int64
+	}{
+		{
+			name:   "no compression",
+			params: aec.Params{BitsPerSample: 4, BlockSize: 8, RSI: 1},
+			// ID 111, then 8 samples of 4 bits
+			data: bits("111 0001 0010 0011 0100 1111 1110 0000 1000"),
+			want: []int64{1, 2, 3, 4, 15, 14, 0, 8},
+		},
+		{
+			name:   "sample splitting",
+			params: aec.Params{BitsPerSample: 8, BlockSize: 8, RSI: 1},
+			// ID 010 for k = 1, the fundamental sequences of x >> 1, then the low bits
+			data: bits("010 1 01 01 001 001 0001 0001 00001 10101010"),
+			want: []int64{1, 2, 3, 4, 5, 6, 7, 8},
+		},
+		{
+			name:   "second extension",
+			params: aec.Params{BitsPerSample: 8, BlockSize: 8, RSI: 1},
+			// ID 000 and 1, then the pairs (0, 1), (1, 0), (0, 0), (2, 1) coded as 2, 1, 0, 7
+			data: bits("000 1 001 01 1 00000001"),
+			want: []int64{0, 1, 1, 0, 0, 0, 2, 1},
+		},
+		{
+			name:   "zero blocks with preprocessing",
+			params: aec.Params{BitsPerSample: 8, BlockSize: 8, RSI: 2, Flags: aec.FlagPreprocess},
+			// ID 000 and 0, the reference sample 100, then 2 zero blocks
+			data: bits("000 0 01100100 01"),
+			want: []int64{100, 100, 100, 100, 100, 100, 100, 100, 100, 100, 100, 100, 100, 100, 100, 100},
+		},
+		{
+			name:   "remainder of segment",
+			params: aec.Params{BitsPerSample: 8, BlockSize: 8, RSI: 3},
+			// A block with no compression, then zero blocks to the end of the interval
+			data: bits("111 00000001 00000010 00000011 00000100 00000101 00000110 00000111 00001000 000 0 00001"),
+			want: append([]int64{1, 2, 3, 4, 5, 6, 7, 8}, make([]int64, 16)...),
+		},
+		{
+			name:   "preprocessing",
+			params: aec.Params{BitsPerSample: 4, BlockSize: 8, RSI: 1, Flags: aec.FlagPreprocess},
+			// Reference 3 and mapped residuals 2 (+1), 1 (-1), 0, 7 (+4, beyond theta 3 so
+			// xmin + 7), 15 (+8, beyond theta 7 so xmin + 15), 0 and 0
+			data: bits("111 0011 0010 0001 0000 0111 1111 0000 0000"),
+			want: []int64{3, 4, 3, 3, 7, 15, 15, 15},
+		},
+		{
+			name:   "signed samples",
+			params: aec.Params{BitsPerSample: 4, BlockSize: 8, RSI: 1, Flags: aec.FlagSigned},
+			data:   bits("111 1111 1000 0111 0000 0001 1110 1001 0110"),
+			want:   []int64{-1, -8, 7, 0, 1, -2, -7, 6},
+		},
+		{
+			name:   "restricted options",
+			params: aec.Params{BitsPerSample: 2, BlockSize: 8, RSI: 1, Flags: aec.FlagRestricted},
+			// A 1 bit ID, 1 for no compression
+			data: bits("1 00 01 10 11 11 10 01 00"),
+			want: []int64{0, 1, 2, 3, 3, 2, 1, 0},
+		},
+		{
+			name:   "padded intervals",
+			params: aec.Params{BitsPerSample: 4, BlockSize: 8, RSI: 1, Flags: aec.FlagPadRSI},
+			data:   append(bits("111 0001 0010 0011 0100 0101 0110 0111 1000"), bits("111 1000 0111 0110 0101 0100 0011 0010 0001")...),
+			want:   []int64{1, 2, 3, 4, 5, 6, 7, 8, 8, 7, 6, 5, 4, 3, 2, 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := aec.Decode(tt.data, len(tt.want), tt.params)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestEncodeDecode(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	fields := map[string]func(i, n int) int64{
+		"smooth": func(i, n int) int64 {
+			return int64(float64(int64(1)<<n-1) * (0.5 + 0.4*math.Sin(float64(i)/40)))
+		},
+		"noise": func(_, n int) int64 { return rng.Int64N(int64(1) << n) },
+		"sparse": func(i, n int) int64 {
+			if i%97 < 90 {
+				return 0
+			}
+			return int64(i % (1 << n))
+		},
+		"constant": func(_, n int) int64 { return int64(1)<<n - 1 },
+	}
+
+	for _, params := range []aec.Params{
+		{BitsPerSample: 1, BlockSize: 8, RSI: 4},
+		{BitsPerSample: 3, BlockSize: 8, RSI: 4, Flags: aec.FlagRestricted | aec.FlagPreprocess},
+		{BitsPerSample: 8, BlockSize: 16, RSI: 64, Flags: aec.FlagPreprocess},
+		{BitsPerSample: 12, BlockSize: 32, RSI: 128, Flags: aec.FlagPreprocess | aec.FlagMSB},
+		{BitsPerSample: 16, BlockSize: 64, RSI: 3, Flags: aec.FlagPadRSI},
+		{BitsPerSample: 24, BlockSize: 32, RSI: 128, Flags: aec.FlagPreprocess | aec.Flag3Byte | aec.FlagMSB},
+		{BitsPerSample: 32, BlockSize: 8, RSI: 70, Flags: aec.FlagPreprocess | aec.FlagPadRSI},
+		{BitsPerSample: 10, BlockSize: 10, RSI: 5, Flags: aec.FlagPreprocess | aec.FlagNotEnforce},
+	} {
+		for name, field := range fields {
+			for _, n := range []int{1, 7, 1000, 10007} {
+				samples := make([]int64, n)
+				for i := range samples {
+					samples[i] = field(i, params.BitsPerSample)
+				}
+				data, err := aec.Encode(samples, params)
+				require.NoError(t, err)
+				got, err := aec.Decode(data, n, params)
+				require.NoError(t, err, "%+v %s %d", params, name, n)
+				require.Equal(t, samples, got, "%+v %s %d", params, name, n)
+			}
+		}
+	}
+
+	// Signed samples
+	params := aec.Params{BitsPerSample: 9, BlockSize: 16, RSI: 8, Flags: aec.FlagSigned | aec.FlagPreprocess}
+	samples := make([]int64, 500)
+	for i := range samples {
+		samples[i] = rng.Int64N(512) - 256
+	}
+	data, err := aec.Encode(samples, params)
+	require.NoError(t, err)
+	got, err := aec.Decode(data, len(samples), params)
+	require.NoError(t, err)
+	assert.Equal(t, samples, got)
+}
+
+func TestEncode_Compresses(t *testing.T) {
+	// A smooth 16 bit field takes less than half its size with preprocessing
+	samples := make([]int64, 4096)
+	for i := range samples {
+		samples[i] = 30000 + int64(2000*math.Sin(float64(i)/100))
+	}
+	data, err := aec.Encode(samples, aec.Params{BitsPerSample: 16, BlockSize: 32, RSI: 128, Flags: aec.FlagPreprocess})
+	require.NoError(t, err)
+	assert.Less(t, len(data), len(samples))
+}
+
+func TestDecode_Errors(t *testing.T) {
+	params := aec.Params{BitsPerSample: 8, BlockSize: 8, RSI: 1}
+	_, err := aec.Decode(bits("111 00000001"), 8, params)
+	assert.ErrorIs(t, err, aec.ErrTruncated)
+	assert.ErrorContains(t, err, "after 0 of 8 samples")
+
+	// 7 zero blocks in an interval of 4
+	_, err = aec.Decode(bits("000 0 00000001"), 8, aec.Params{BitsPerSample: 8, BlockSize: 8, RSI: 4})
+	assert.ErrorContains(t, err, "7 zero blocks from block 0")
+
+	// A second extension code beyond the pairs adding up to 12
+	_, err = aec.Decode(bits("000 1"+strings.Repeat("0", 91)+"1"), 8, params)
+	assert.ErrorContains(t, err, "invalid second extension code")
+
+	for _, invalid := range []aec.Params{
+		{BitsPerSample: 0, BlockSize: 8, RSI: 1},
+		{BitsPerSample: 33, BlockSize: 8, RSI: 1},
+		{BitsPerSample: 8, BlockSize: 12, RSI: 1},
+		{BitsPerSample: 8, BlockSize: 7, RSI: 1, Flags: aec.FlagNotEnforce},
+		{BitsPerSample: 8, BlockSize: 8, RSI: 0},
+		{BitsPerSample: 8, BlockSize: 8, RSI: 1, Flags: aec.FlagRestricted},
+	} {
+		_, err := aec.Decode(nil, 1, invalid)
+		assert.Error(t, err, "%+v", invalid)
+	}
+}
@@ -0,0 +1,201 @@
+package aec
+
+import (
+	"fmt"
+
+	"github.com/scorix/grib/grib2/internal/bitio"
+)
+
+// Encode encodes the samples, each block with the code option giving the fewest bits. A
+// last incomplete block is padded with the last sample.
+func Encode(samples []int64, p Params) ([]byte, error) {
+	idLen, err := p.check()
+	if err != nil {
+		return nil, err
+	}
+	xmin, xmax := p.limits()
+	for i, x := range samples {
+		if x < xmin || x > xmax {
+			return nil, fmt.Errorf("aec: sample %d of %d out of range [%d, %d]", i, x, xmin, xmax)
+		}
+	}
+
+	e := &encoder{w: &bitio.Writer{}, p: p, idLen: idLen}
+	J := p.BlockSize
+	for start := 0; start < len(samples); start += p.RSI * J {
+		interval := samples[start:min(start+p.RSI*J, len(samples))]
+		coded := e.preprocess(interval)
+		for len(coded)%J != 0 {
+			coded = append(coded, coded[len(coded)-1])
+		}
+		e.interval(coded)
+		if p.Flags&FlagPadRSI != 0 {
+			e.w.Align()
+		}
+	}
+	return e.w.Bytes(), nil
+}
+
+// encoder writes the coded blocks of a stream
+type encoder struct {
+	w     *bitio.Writer
+	p     Params
+	idLen uint
+}
+
+// preprocess returns the samples of an interval to code: with preprocessing, the
+// reference sample followed by the mapped residuals of the unit delay predictor, the
+// inverse of decoder.postprocess, else the raw bits of the samples
+func (e *encoder) preprocess(interval []int64) []int64 {
+	mask := int64(1)<<e.p.BitsPerSample - 1
+	coded := make([]int64, len(interval))
+	if e.p.Flags&FlagPreprocess == 0 {
+		for i, x := range interval {
+			coded[i] = x & mask
+		}
+		return coded
+	}
+
+	xmin, xmax := e.p.limits()
+	coded[0] = interval[0] & mask
+	for i := 1; i < len(interval); i++ {
+		last, x := interval[i-1], interval[i]
+		theta := min(last-xmin, xmax-last)
+		switch delta := x - last; {
+		case delta >= 0 && delta <= theta:
+			coded[i] = 2 * delta
+		case delta < 0 && -delta <= theta:
+			coded[i] = -2*delta - 1
+		default:
+			coded[i] = theta + max(delta, -delta)
+		}
+	}
+	return coded
+}
+
+// interval writes the blocks of an interval, merging the runs of zero blocks
+func (e *encoder) interval(coded []int64) {
+	J := e.p.BlockSize
+	blocks := len(coded) / J
+	zero := func(block int) bool {
+		for i := block * J; i < (block+1)*J; i++ {
+			if coded[i] != 0 && !(i == 0 && e.p.Flags&FlagPreprocess != 0) {
+				return false
+			}
+		}
+		return true
+	}
+
+	for block := 0; block < blocks; block++ {
+		ref := e.p.Flags&FlagPreprocess != 0 && block == 0
+		samples := coded[block*J : (block+1)*J]
+		if !zero(block) {
+			e.block(samples, ref)
+			continue
+		}
+
+		// Zero blocks up to the end of the segment, which the remainder of segment code
+		// covers as well at the end of the interval
+		end := block + 1
+		segmentEnd := min(blocks, (block/segmentSize+1)*segmentSize)
+		for end < segmentEnd && zero(end) {
+			end++
+		}
+		e.w.WriteBits(idLowEntropy, e.idLen)
+		e.w.WriteBits(0, 1)
+		if ref {
+			e.w.WriteBits(uint64(samples[0]), uint(e.p.BitsPerSample))
+		}
+		switch n := end - block; {
+		case n < rosBlocks:
+			e.fs(int64(n - 1))
+		case end == segmentEnd:
+			e.fs(rosBlocks - 1)
+		default:
+			e.fs(int64(n))
+		}
+		block = end - 1
+	}
+}
+
+// block writes a block that is not all zeros with the cheapest of the second extension,
+// sample splitting and no compression options
+func (e *encoder) block(samples []int64, ref bool) {
+	n := e.p.BitsPerSample
+	coded, refBits := samples, 0
+	if ref {
+		coded, refBits = samples[1:], n
+	}
+
+	best, bestBits := -1, n*len(samples) // No compression
+	maxK := 1<<e.idLen - 3
+	for k := 0; k <= maxK && k < n; k++ {
+		bits := refBits
+		for _, x := range coded {
+			bits += int(x>>k) + 1 + k
+		}
+		if bits < bestBits {
+			best, bestBits = k, bits
+		}
+	}
+	pairs, seBits := e.secondExtension(samples, ref)
+	if pairs != nil && refBits+seBits+1 < bestBits {
+		e.w.WriteBits(idLowEntropy, e.idLen)
+		e.w.WriteBits(1, 1)
+		if ref {
+			e.w.WriteBits(uint64(samples[0]), uint(n))
+		}
+		for _, m := range pairs {
+			e.fs(m)
+		}
+		return
+	}
+
+	if best < 0 {
+		e.w.WriteBits(1<<e.idLen-1, e.idLen)
+		for _, x := range samples {
+			e.w.WriteBits(uint64(x), uint(n))
+		}
+		return
+	}
+	e.w.WriteBits(uint64(best+1), e.idLen)
+	if ref {
+		e.w.WriteBits(uint64(samples[0]), uint(n))
+	}
+	for _, x := range coded {
+		e.fs(x >> best)
+	}
+	for _, x := range coded {
+		e.w.WriteBits(uint64(x), uint(best))
+	}
+}
+
+// secondExtension returns the codes of the pairs of samples and their length in bits, or
+// nil if a pair adds up to more than 12. The first sample of the first pair counts as 0
+// when it is the reference sample.
+func (e *encoder) secondExtension(samples []int64, ref bool) ([]int64, int) {
+	pairs := make([]int64, 0, len(samples)/2)
+	bits := 0
+	for i := 0; i < len(samples); i += 2 {
+		a, b := samples[i], samples[i+1]
+		if ref && i == 0 {
+			a = 0
+		}
+		sum := a + b
+		if sum > 12 {
+			return nil, 0
+		}
+		m := sum*(sum+1)/2 + b
+		pairs = append(pairs, m)
+		bits += int(m) + 1
+	}
+	return pairs, bits
+}
+
+// fs writes the fundamental sequence of m, m zeros followed by a one
+func (e *encoder) fs(m int64) {
+	for ; m >= 32; m -= 32 {
+		e.w.WriteBits(0, 32)
+	}
+	e.w.WriteBits(1, uint(m)+1)
+}
@@ -0,0 +1,186 @@
+// Package bitio reads and writes unsigned integers of arbitrary width packed MSB first,
+// as used by the GRIB2 data representation methods and bit-maps.
+package bitio
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrOverrun is returned by reads past the end of the data
+var ErrOverrun = errors.New("bitio: read past end of data")
+
+// Reader reads MSB-first unsigned integers of arbitrary width from a byte slice
+type Reader struct {
+	data   []byte
+	offset uint64 // Offset in bits from the start of data
+}
+
+// NewReader returns a Reader positioned at the first bit of data
+func NewReader(data []byte) *Reader {
+	return &Reader{data: data}
+}
+
+// ReadBits reads the next n bits (n <= 64) as an unsigned integer. A read that would
+// pass the end of the data fails with ErrOverrun and leaves the position unchanged.
+func (r *Reader) ReadBits(n uint) (uint64, error) {
+	if n > 64 {
+		return 0, fmt.Errorf("bitio: invalid width %d", n)
+	}
+	if n == 0 {
+		return 0, nil
+	}
+	if uint64(n) > r.Remaining() {
+		return 0, fmt.Errorf("%w: %d bits at bit offset %d of %d bytes", ErrOverrun, n, r.offset, len(r.data))
+	}
+
+	// Fast path: the bits lie within 8 whole octets of the data
+	byteIndex, bitIndex := r.offset/8, uint(r.offset%8)
+	if n+bitIndex <= 64 && byteIndex+8 <= uint64(len(r.data)) {
+		window := binary.BigEndian.Uint64(r.data[byteIndex:])
+		r.offset += uint64(n)
+		return window << bitIndex >> (64 - n), nil
+	}
+
+	var value uint64
+	for n > 0 {
+		bitIndex := uint(r.offset % 8)
+		available := 8 - bitIndex
+		take := min(available, n)
+
+		bits := uint64(r.data[r.offset/8]>>(available-take)) & (1<<take - 1)
+		value = value<<take | bits
+
+		r.offset += uint64(take)
+		n -= take
+	}
+
+	return value, nil
+}
+
+// Skip advances the position by n bits
+func (r *Reader) Skip(n uint64) error {
+	if n > r.Remaining() {
+		return fmt.Errorf("%w: skip of %d bits at bit offset %d of %d bytes", ErrOverrun, n, r.offset, len(r.data))
+	}
+	r.offset += n
+	return nil
+}
+
+// Align advances the position to the next octet boundary, unless already on one
+func (r *Reader) Align() {
+	r.offset = (r.offset + 7) / 8 * 8
+	r.offset = min(r.offset, uint64(len(r.data))*8)
+}
+
+// Offset returns the position in bits from the start of the data
+func (r *Reader) Offset() uint64 {
+	return r.offset
+}
+
+// Remaining returns the number of bits left to read
+func (r *Reader) Remaining() uint64 {
+	return uint64(len(r.data))*8 - r.offset
+}
+
+// StreamReader reads MSB-first unsigned integers of arbitrary width from an io.Reader,
+// reading no further ahead than the current octet when the source is an io.ByteReader
+type StreamReader struct {
+	source  io.ByteReader
+	current byte // Octet being read
+	left    uint // Bits of current not read yet
+	offset  uint64
+}
+
+// NewStreamReader returns a StreamReader over r, buffering r unless it is an io.ByteReader
+func NewStreamReader(r io.Reader) *StreamReader {
+	source, ok := r.(io.ByteReader)
+	if !ok {
+		source = bufio.NewReader(r)
+	}
+	return &StreamReader{source: source}
+}
+
+// ReadBits reads the next n bits (n <= 64) as an unsigned integer. The end of the
+// stream within the read is reported as ErrOverrun.
+func (r *StreamReader) ReadBits(n uint) (uint64, error) {
+	if n > 64 {
+		return 0, fmt.Errorf("bitio: invalid width %d", n)
+	}
+
+	var value uint64
+	for n > 0 {
+		if r.left == 0 {
+			b, err := r.source.ReadByte()
+			if err == io.EOF {
+				return 0, fmt.Errorf("%w: %d bits at bit offset %d", ErrOverrun, n, r.offset)
+			}
+			if err != nil {
+				return 0, err
+			}
+			r.current, r.left = b, 8
+		}
+
+		take := min(r.left, n)
+		bits := uint64(r.current>>(r.left-take)) & (1<<take - 1)
+		value = value<<take | bits
+
+		r.left -= take
+		r.offset += uint64(take)
+		n -= take
+	}
+
+	return value, nil
+}
+
+// Align discards the bits left in the current octet
+func (r *StreamReader) Align() {
+	r.offset += uint64(r.left)
+	r.left = 0
+}
+
+// Offset returns the number of bits read, including those discarded by Align
+func (r *StreamReader) Offset() uint64 {
+	return r.offset
+}
+
+// Writer packs MSB-first unsigned integers of arbitrary width into a byte slice
+type Writer struct {
+	data  []byte
+	nbits uint64 // Number of bits written
+}
+
+// WriteBits appends the n low bits (n <= 64) of v
+func (w *Writer) WriteBits(v uint64, n uint) {
+	for n > 0 {
+		used := uint(w.nbits % 8)
+		if used == 0 {
+			w.data = append(w.data, 0)
+		}
+		take := min(8-used, n)
+
+		bits := byte(v>>(n-take)) & (1<<take - 1)
+		w.data[len(w.data)-1] |= bits << (8 - used - take)
+
+		w.nbits += uint64(take)
+		n -= take
+	}
+}
+
+// Align pads the last octet with zero bits
+func (w *Writer) Align() {
+	w.nbits = uint64(len(w.data)) * 8
+}
+
+// Len returns the number of bits written, including the padding of Align
+func (w *Writer) Len() uint64 {
+	return w.nbits
+}
+
+// Bytes returns the packed data, padded with zero bits to a whole octet
+func (w *Writer) Bytes() []byte {
+	return w.data
+}
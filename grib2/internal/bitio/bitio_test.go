@@ -0,0 +1,173 @@
+package bitio_test
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand/v2"
+	"testing"
+	"testing/iotest"
+
+	"github.com/scorix/grib/grib2/internal/bitio"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriter(t *testing.T) {
+	w := &bitio.Writer{}
+	w.WriteBits(0xabc, 12)
+	w.WriteBits(0x123, 12)
+	w.WriteBits(1, 1)
+	assert.Equal(t, uint64(25), w.Len())
+	w.Align()
+	assert.Equal(t, uint64(32), w.Len())
+	w.WriteBits(0xff, 4)
+	assert.Equal(t, []byte{0xab, 0xc1, 0x23, 0x80, 0xf0}, w.Bytes())
+}
+
+func TestReader(t *testing.T) {
+	data := []byte{0xab, 0xc1, 0x23, 0x80, 0xf0}
+	readers := map[string]interface {
+		ReadBits(n uint) (uint64, error)
+		Align()
+		Offset() uint64
+	}{
+		"slice":  bitio.NewReader(data),
+		"stream": bitio.NewStreamReader(iotest.OneByteReader(bytes.NewReader(data))),
+	}
+
+	for name, r := range readers {
+		t.Run(name, func(t *testing.T) {
+			for _, want := range []struct {
+				n     uint
+				value uint64
+			}{{12, 0xabc}, {0, 0}, {12, 0x123}, {1, 1}} {
+				v, err := r.ReadBits(want.n)
+				require.NoError(t, err)
+				assert.Equal(t, want.value, v)
+			}
+			assert.Equal(t, uint64(25), r.Offset())
+
+			r.Align()
+			assert.Equal(t, uint64(32), r.Offset())
+			r.Align()
+			assert.Equal(t, uint64(32), r.Offset())
+
+			v, err := r.ReadBits(4)
+			require.NoError(t, err)
+			assert.Equal(t, uint64(0xf), v)
+
+			_, err = r.ReadBits(5)
+			assert.ErrorIs(t, err, bitio.ErrOverrun)
+			_, err = r.ReadBits(65)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestReader_Skip(t *testing.T) {
+	r := bitio.NewReader([]byte{0x0f, 0xf0})
+	require.NoError(t, r.Skip(4))
+	v, err := r.ReadBits(8)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0xff), v)
+	assert.Equal(t, uint64(4), r.Remaining())
+
+	assert.ErrorIs(t, r.Skip(5), bitio.ErrOverrun)
+	assert.Equal(t, uint64(12), r.Offset(), "a failed skip does not move")
+	r.Align()
+	assert.Zero(t, r.Remaining())
+}
+
+func TestRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	for width := uint(1); width <= 64; width++ {
+		values := make([]uint64, 100)
+		w := &bitio.Writer{}
+		for i := range values {
+			values[i] = rng.Uint64() >> (64 - width)
+			w.WriteBits(values[i], width)
+		}
+
+		r := bitio.NewReader(w.Bytes())
+		s := bitio.NewStreamReader(bytes.NewReader(w.Bytes()))
+		for i, want := range values {
+			got, err := r.ReadBits(width)
+			require.NoError(t, err)
+			require.Equal(t, want, got, "width %d value %d", width, i)
+
+			got, err = s.ReadBits(width)
+			require.NoError(t, err)
+			require.Equal(t, want, got, "width %d value %d from stream", width, i)
+		}
+		assert.Less(t, r.Remaining(), uint64(8))
+	}
+}
+
+func FuzzReadBits(f *testing.F) {
+	f.Add([]byte{0xab, 0xc1, 0x23}, []byte{12, 12})
+	f.Add([]byte{}, []byte{1})
+	f.Add([]byte{0xff}, []byte{3, 64, 5})
+	f.Add(bytes.Repeat([]byte{0x5a, 0xc3}, 10), []byte{7, 13, 31, 32, 1, 20, 9})
+
+	f.Fuzz(func(t *testing.T, data, widths []byte) {
+		r := bitio.NewReader(data)
+		w := &bitio.Writer{}
+		for _, b := range widths {
+			width := uint(b%64) + 1
+			before := r.Offset()
+			v, err := r.ReadBits(width)
+			if err != nil {
+				// Reads never pass the end of the data
+				require.ErrorIs(t, err, bitio.ErrOverrun)
+				require.Greater(t, before+uint64(width), uint64(len(data))*8)
+				require.Equal(t, before, r.Offset())
+				break
+			}
+			require.Zero(t, v>>width)
+			w.WriteBits(v, width)
+		}
+
+		// What was read is rewritten bit for bit
+		written := w.Bytes()
+		require.LessOrEqual(t, len(written), len(data))
+		if n := w.Len(); n > 0 {
+			full := n / 8
+			require.Equal(t, data[:full], written[:full])
+			if rest := n % 8; rest > 0 {
+				mask := byte(0xff) << (8 - rest)
+				require.Equal(t, data[full]&mask, written[full])
+			}
+		}
+	})
+}
+
+func BenchmarkReader(b *testing.B) {
+	data := make([]byte, 1<<20)
+	for i := range data {
+		data[i] = byte(i * 31)
+	}
+
+	for _, width := range []uint{1, 8, 12, 16, 24, 32} {
+		b.Run(fmt.Sprintf("width%d", width), func(b *testing.B) {
+			n := uint64(len(data)) * 8 / uint64(width)
+			b.SetBytes(int64(len(data)))
+			for b.Loop() {
+				r := bitio.NewReader(data)
+				for range n {
+					if _, err := r.ReadBits(width); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkWriter(b *testing.B) {
+	for b.Loop() {
+		w := &bitio.Writer{}
+		for i := range 1 << 16 {
+			w.WriteBits(uint64(i), 12)
+		}
+	}
+}
@@ -0,0 +1,393 @@
+package jpeg2000
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Markers of the codestream (Annex A)
+const (
+	markerSOC = 0xff4f // Start of codestream
+	markerSIZ = 0xff51 // Image and tile size
+	markerCOD = 0xff52 // Coding style default
+	markerCOC = 0xff53 // Coding style component
+	markerTLM = 0xff55 // Tile-part lengths
+	markerPLM = 0xff57 // Packet length, main header
+	markerPLT = 0xff58 // Packet length, tile-part header
+	markerQCD = 0xff5c // Quantization default
+	markerQCC = 0xff5d // Quantization component
+	markerRGN = 0xff5e // Region of interest
+	markerPOC = 0xff5f // Progression order change
+	markerPPM = 0xff60 // Packed packet headers, main header
+	markerPPT = 0xff61 // Packed packet headers, tile-part header
+	markerCRG = 0xff63 // Component registration
+	markerCOM = 0xff64 // Comment
+	markerSOT = 0xff90 // Start of tile-part
+	markerSOP = 0xff91 // Start of packet
+	markerEPH = 0xff92 // End of packet header
+	markerSOD = 0xff93 // Start of data
+	markerEOC = 0xffd9 // End of codestream
+)
+
+// Progression orders (Table A.16)
+const (
+	LRCP Progression = iota // Layer, resolution, component, position
+	RLCP                    // Resolution, layer, component, position
+	RPCL                    // Resolution, position, component, layer
+	PCRL                    // Position, component, resolution, layer
+	CPRL                    // Component, position, resolution, layer
+)
+
+// Progression is the order of the packets in the codestream
+type Progression uint8
+
+// Code-block styles (Table A.19)
+const (
+	StyleBypass      = 0x01 // Selective arithmetic coding bypass
+	StyleReset       = 0x02 // Reset of the context probabilities on each coding pass
+	StyleTermAll     = 0x04 // Termination on each coding pass
+	StyleVCausal     = 0x08 // Vertically causal context
+	StylePredictable = 0x10 // Predictable termination
+	StyleSegSym      = 0x20 // Segmentation symbols
+	styleHT          = 0x40 // High throughput block coding of Part 15
+)
+
+// codingStyle holds the coding style of a component, from COD or COC
+type codingStyle struct {
+	levels     int     // Number of decomposition levels
+	xcb, ycb   int     // Code-block width and height exponents
+	cbStyle    uint8   // Code-block style
+	reversible bool    // 5-3 reversible filter, else 9-7 irreversible
+	precincts  []uint8 // Precinct size exponents of each resolution, PPx in the low nibble
+}
+
+// codingDefaults holds the coding style of a tile or of the main header, from COD
+type codingDefaults struct {
+	sop, eph    bool
+	progression Progression
+	layers      int
+	mct         bool // Whether the first three components are transformed (Annex G.2)
+	codingStyle
+}
+
+// quantization holds the quantization of a component, from QCD or QCC
+type quantization struct {
+	style uint8 // 0 none, 1 scalar derived, 2 scalar expounded
+	guard int   // Number of guard bits
+	steps []stepSize
+}
+
+// stepSize is the exponent and mantissa of a quantization step size
+type stepSize struct {
+	exponent int
+	mantissa int
+}
+
+// header holds the coding parameters of the main header or of a tile, the COC and QCC
+// marker segments of a component overriding the COD and QCD ones
+type header struct {
+	cod *codingDefaults
+	coc map[int]*codingStyle
+	qcd *quantization
+	qcc map[int]*quantization
+}
+
+// siz holds the image and tile sizes of the SIZ marker segment, and the sample format and
+// size of one of its components, the first unless selected with component
+type siz struct {
+	x1, y1         int // Xsiz, Ysiz
+	x0, y0         int // XOsiz, YOsiz
+	tw, th         int // XTsiz, YTsiz
+	tx0, ty0       int // XTOsiz, YTOsiz
+	precision      int
+	signed         bool
+	dx, dy         int // XRsiz, YRsiz
+	numXT, numYT   int // Number of tiles
+	width, height  int // Size of the component
+	compX0, compY0 int // Origin of the component
+	components     []componentSiz
+}
+
+// componentSiz is the sample format and subsampling of a component, from Ssiz, XRsiz and
+// YRsiz
+type componentSiz struct {
+	precision int
+	signed    bool
+	dx, dy    int
+}
+
+// component returns the sizes with the sample format and size of component c
+func (s siz) component(c int) siz {
+	cs := s.components[c]
+	s.precision, s.signed, s.dx, s.dy = cs.precision, cs.signed, cs.dx, cs.dy
+	s.compX0, s.compY0 = ceilDiv(s.x0, s.dx), ceilDiv(s.y0, s.dy)
+	s.width = ceilDiv(s.x1, s.dx) - s.compX0
+	s.height = ceilDiv(s.y1, s.dy) - s.compY0
+	return s
+}
+
+// segmentReader reads the fields of a marker segment
+type segmentReader struct {
+	marker uint16
+	data   []byte
+	err    error
+}
+
+func (r *segmentReader) take(n int) []byte {
+	if r.err != nil {
+		return make([]byte, n)
+	}
+	if len(r.data) < n {
+		r.err = fmt.Errorf("jpeg2000: marker segment %#04x too short", r.marker)
+		return make([]byte, n)
+	}
+	b := r.data[:n]
+	r.data = r.data[n:]
+	return b
+}
+
+func (r *segmentReader) u8() int  { return int(r.take(1)[0]) }
+func (r *segmentReader) u16() int { return int(binary.BigEndian.Uint16(r.take(2))) }
+func (r *segmentReader) u32() int { return int(binary.BigEndian.Uint32(r.take(4))) }
+
+// readSegment returns the marker at offset and the contents of its marker segment
+func readSegment(data []byte, offset int) (uint16, []byte, error) {
+	if offset+4 > len(data) {
+		return 0, nil, fmt.Errorf("jpeg2000: codestream truncated at offset %d", offset)
+	}
+	marker := binary.BigEndian.Uint16(data[offset:])
+	length := int(binary.BigEndian.Uint16(data[offset+2:]))
+	if marker>>8 != 0xff {
+		return 0, nil, fmt.Errorf("jpeg2000: expected a marker at offset %d, got %#04x", offset, marker)
+	}
+	if length < 2 || offset+2+length > len(data) {
+		return 0, nil, fmt.Errorf("jpeg2000: marker segment %#04x at offset %d has invalid length %d", marker, offset, length)
+	}
+	return marker, data[offset+4 : offset+2+length], nil
+}
+
+// parseSIZ parses the SIZ marker segment
+func parseSIZ(data []byte) (siz, error) {
+	r := &segmentReader{marker: markerSIZ, data: data}
+	var s siz
+	r.u16() // Rsiz
+	s.x1, s.y1, s.x0, s.y0 = r.u32(), r.u32(), r.u32(), r.u32()
+	s.tw, s.th, s.tx0, s.ty0 = r.u32(), r.u32(), r.u32(), r.u32()
+	s.components = make([]componentSiz, r.u16())
+	for c := range s.components {
+		ssiz := r.u8()
+		s.components[c] = componentSiz{precision: ssiz&0x7f + 1, signed: ssiz&0x80 != 0, dx: r.u8(), dy: r.u8()}
+	}
+	if r.err != nil {
+		return s, r.err
+	}
+	switch {
+	case len(s.components) == 0:
+		return s, fmt.Errorf("jpeg2000: image without components")
+	case s.x1 <= s.x0 || s.y1 <= s.y0:
+		return s, fmt.Errorf("jpeg2000: empty image area")
+	case s.tw == 0 || s.th == 0 || s.tx0 > s.x0 || s.ty0 > s.y0 || s.tx0+s.tw <= s.x0 || s.ty0+s.th <= s.y0:
+		return s, fmt.Errorf("jpeg2000: invalid tiling")
+	}
+	s.numXT = ceilDiv(s.x1-s.tx0, s.tw)
+	s.numYT = ceilDiv(s.y1-s.ty0, s.th)
+	if s.numXT*s.numYT > 65535 {
+		return s, fmt.Errorf("jpeg2000: %dx%d tiles", s.numXT, s.numYT)
+	}
+
+	samples := 0
+	for _, cs := range s.components {
+		switch {
+		case cs.precision > 38:
+			return s, fmt.Errorf("jpeg2000: invalid precision %d", cs.precision)
+		case cs.dx == 0 || cs.dy == 0:
+			return s, fmt.Errorf("jpeg2000: invalid component subsampling %dx%d", cs.dx, cs.dy)
+		}
+		samples += (ceilDiv(s.x1, cs.dx) - ceilDiv(s.x0, cs.dx)) * (ceilDiv(s.y1, cs.dy) - ceilDiv(s.y0, cs.dy))
+	}
+	if samples > maxSamples {
+		return s, fmt.Errorf("jpeg2000: image of %dx%d samples in %d components too large", s.x1-s.x0, s.y1-s.y0, len(s.components))
+	}
+	return s.component(0), nil
+}
+
+// parseCodingStyle parses the coding style parameters (SPcod or SPcoc) of COD and COC
+func parseCodingStyle(r *segmentReader, precincts bool) (codingStyle, error) {
+	cs := codingStyle{
+		levels:     r.u8(),
+		xcb:        r.u8() + 2,
+		ycb:        r.u8() + 2,
+		cbStyle:    uint8(r.u8()),
+		reversible: r.u8() == 1,
+	}
+	if r.err != nil {
+		return cs, r.err
+	}
+	switch {
+	case cs.levels > 32:
+		return cs, fmt.Errorf("jpeg2000: %d decomposition levels", cs.levels)
+	case cs.xcb > 10 || cs.ycb > 10 || cs.xcb+cs.ycb > 12:
+		return cs, fmt.Errorf("jpeg2000: invalid code-block size 2^%dx2^%d", cs.xcb, cs.ycb)
+	case cs.cbStyle&styleHT != 0:
+		return cs, fmt.Errorf("jpeg2000: high throughput code-blocks are not supported")
+	}
+	cs.precincts = make([]uint8, cs.levels+1)
+	for i := range cs.precincts {
+		cs.precincts[i] = 0xff
+		if precincts {
+			cs.precincts[i] = uint8(r.u8())
+		}
+	}
+	return cs, r.err
+}
+
+// parseCOD parses the COD marker segment
+func parseCOD(data []byte) (*codingDefaults, error) {
+	r := &segmentReader{marker: markerCOD, data: data}
+	scod := r.u8()
+	cod := &codingDefaults{
+		sop:         scod&0x02 != 0,
+		eph:         scod&0x04 != 0,
+		progression: Progression(r.u8()),
+		layers:      r.u16(),
+	}
+	cod.mct = r.u8() == 1
+	var err error
+	if cod.codingStyle, err = parseCodingStyle(r, scod&0x01 != 0); err != nil {
+		return nil, err
+	}
+	if cod.progression > CPRL || cod.layers == 0 {
+		return nil, fmt.Errorf("jpeg2000: invalid progression order %d or number of layers %d", cod.progression, cod.layers)
+	}
+	return cod, nil
+}
+
+// parseCOC parses the COC marker segment, returning the index of its component
+func parseCOC(data []byte, components int) (int, *codingStyle, error) {
+	r := &segmentReader{marker: markerCOC, data: data}
+	c := componentIndex(r, components)
+	scoc := r.u8()
+	cs, err := parseCodingStyle(r, scoc&0x01 != 0)
+	if err != nil {
+		return 0, nil, err
+	}
+	return c, &cs, nil
+}
+
+// componentIndex reads the component index of a COC, QCC or RGN marker segment, of one
+// octet in images of fewer than 257 components and two otherwise
+func componentIndex(r *segmentReader, components int) int {
+	if components < 257 {
+		return r.u8()
+	}
+	return r.u16()
+}
+
+// parseQuantization parses the QCD or QCC marker segment, the component index of which
+// has been skipped
+func parseQuantization(r *segmentReader) (*quantization, error) {
+	sq := r.u8()
+	q := &quantization{style: uint8(sq & 0x1f), guard: sq >> 5}
+	switch q.style {
+	case 0:
+		for len(r.data) > 0 {
+			q.steps = append(q.steps, stepSize{exponent: r.u8() >> 3})
+		}
+	case 1, 2:
+		for len(r.data) > 1 {
+			v := r.u16()
+			q.steps = append(q.steps, stepSize{exponent: v >> 11, mantissa: v & 0x7ff})
+		}
+	default:
+		return nil, fmt.Errorf("jpeg2000: invalid quantization style %d", q.style)
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	if len(q.steps) == 0 {
+		return nil, fmt.Errorf("jpeg2000: quantization without step sizes")
+	}
+	return q, nil
+}
+
+// parseHeaderSegment parses a marker segment of the main header or of a tile-part header
+// of an image of the given number of components into h, returning an error for features
+// this package does not support
+func parseHeaderSegment(h *header, marker uint16, data []byte, components int) error {
+	var err error
+	switch marker {
+	case markerCOD:
+		h.cod, err = parseCOD(data)
+	case markerCOC:
+		var c int
+		var coc *codingStyle
+		if c, coc, err = parseCOC(data, components); err == nil {
+			if h.coc == nil {
+				h.coc = make(map[int]*codingStyle)
+			}
+			h.coc[c] = coc
+		}
+	case markerQCD:
+		h.qcd, err = parseQuantization(&segmentReader{marker: marker, data: data})
+	case markerQCC:
+		r := &segmentReader{marker: marker, data: data}
+		c := componentIndex(r, components)
+		var qcc *quantization
+		if qcc, err = parseQuantization(r); err == nil {
+			if h.qcc == nil {
+				h.qcc = make(map[int]*quantization)
+			}
+			h.qcc[c] = qcc
+		}
+	case markerRGN:
+		err = fmt.Errorf("jpeg2000: regions of interest are not supported")
+	case markerPOC:
+		err = fmt.Errorf("jpeg2000: progression order changes are not supported")
+	case markerPPM, markerPPT:
+		err = fmt.Errorf("jpeg2000: packed packet headers are not supported")
+	}
+	// TLM, PLM, PLT, CRG, COM and unknown marker segments are skipped
+	return err
+}
+
+// tileParams returns the coding parameters of component c of a tile: a tile-part COC
+// prevails over a tile-part COD, which prevails over a main COC, itself over the main COD,
+// and likewise for the quantization
+func tileParams(main, tile header, c int) (codingDefaults, quantization, error) {
+	var cod codingDefaults
+	switch {
+	case tile.cod != nil:
+		cod = *tile.cod
+	case main.cod != nil:
+		cod = *main.cod
+	default:
+		return cod, quantization{}, fmt.Errorf("jpeg2000: missing COD marker segment")
+	}
+	switch {
+	case tile.coc[c] != nil:
+		cod.codingStyle = *tile.coc[c]
+	case tile.cod != nil:
+	case main.coc[c] != nil:
+		cod.codingStyle = *main.coc[c]
+	}
+
+	var q *quantization
+	for _, candidate := range []*quantization{tile.qcc[c], tile.qcd, main.qcc[c], main.qcd} {
+		if candidate != nil {
+			q = candidate
+			break
+		}
+	}
+	if q == nil {
+		return cod, quantization{}, fmt.Errorf("jpeg2000: missing QCD marker segment")
+	}
+	if q.style != 1 && len(q.steps) < 1+3*cod.levels {
+		return cod, *q, fmt.Errorf("jpeg2000: %d quantization step sizes for %d decomposition levels", len(q.steps), cod.levels)
+	}
+	return cod, *q, nil
+}
+
+// ceilDiv returns the ceiling of a/b for a non-negative and b positive
+func ceilDiv(a, b int) int {
+	return (a + b - 1) / b
+}
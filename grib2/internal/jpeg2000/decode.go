@@ -0,0 +1,409 @@
+// Package jpeg2000 decodes and encodes the JPEG 2000 codestreams (ITU-T T.800, ISO/IEC
+// 15444-1) carried by GRIB2 data representation template 5.40: single component
+// images, tiled or not, with either wavelet filter, any number of quality layers, the five
+// progression orders, precincts and all code-block styles but high throughput. Regions
+// of interest, progression order changes and packed packet headers are not supported.
+// Images of several components, as other encoders write them, decode with
+// DecodeComponents in the progression orders that do not interleave positions.
+package jpeg2000
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// maxSamples bounds the size of the images decoded
+const maxSamples = 1 << 30
+
+// Image is the single component of a JPEG 2000 image
+type Image struct {
+	Width, Height int
+	Precision     int  // Bits per sample
+	Signed        bool // Whether samples are signed, in two's complement
+	Samples       []int64
+}
+
+// jp2Signature starts the signature box of the JP2 file format
+var jp2Signature = []byte{0, 0, 0, 12, 'j', 'P', ' ', ' '}
+
+// Config is the size and sample format of the first component of a JPEG 2000 image
+type Config struct {
+	Width, Height int
+	Precision     int  // Bits per sample
+	Signed        bool // Whether samples are signed, in two's complement
+	Components    int  // Number of components
+	Samples       int  // Number of samples of all components, which decoding allocates
+}
+
+// DecodeConfig returns the size and sample format of a JPEG 2000 codestream, bare or
+// wrapped in the JP2 file format, from its SIZ marker segment without decoding the image.
+// A codestream can describe a far larger image than its own size, so callers that know
+// how many samples to expect check them here before decoding.
+func DecodeConfig(data []byte) (Config, error) {
+	_, s, err := readSIZ(data)
+	if err != nil {
+		return Config{}, err
+	}
+	cfg := Config{Width: s.width, Height: s.height, Precision: s.precision, Signed: s.signed, Components: len(s.components)}
+	for c := range s.components {
+		cs := s.component(c)
+		cfg.Samples += cs.width * cs.height
+	}
+	return cfg, nil
+}
+
+// Decode decodes a JPEG 2000 codestream of a single component image, bare or wrapped in
+// the JP2 file format
+func Decode(data []byte) (*Image, error) {
+	images, err := decode(data, true)
+	if err != nil {
+		return nil, err
+	}
+	return images[0], nil
+}
+
+// DecodeComponents decodes each component of a JPEG 2000 codestream, bare or wrapped in
+// the JP2 file format, at its own size. The first three components are transformed back
+// to RGB when the coding style applies the multiple component transformation (Annex G).
+// The components of a tile must be coded in the LRCP, RLCP or CPRL progression order.
+func DecodeComponents(data []byte) ([]*Image, error) {
+	return decode(data, false)
+}
+
+// readSIZ returns the codestream of data, unwrapped from the JP2 file format, and its SIZ
+// marker segment
+func readSIZ(data []byte) ([]byte, siz, error) {
+	if bytes.HasPrefix(data, jp2Signature) {
+		var err error
+		if data, err = jp2Codestream(data); err != nil {
+			return nil, siz{}, err
+		}
+	}
+	if len(data) < 2 || binary.BigEndian.Uint16(data) != markerSOC {
+		return nil, siz{}, fmt.Errorf("jpeg2000: missing SOC marker")
+	}
+	marker, segment, err := readSegment(data, 2)
+	if err != nil {
+		return nil, siz{}, err
+	}
+	if marker != markerSIZ {
+		return nil, siz{}, fmt.Errorf("jpeg2000: expected SIZ marker, got %#04x", marker)
+	}
+	s, err := parseSIZ(segment)
+	if err != nil {
+		return nil, siz{}, err
+	}
+	return data, s, nil
+}
+
+// decode decodes the components of a codestream, failing on images of several components
+// when single is set
+func decode(data []byte, single bool) ([]*Image, error) {
+	data, s, err := readSIZ(data)
+	if err != nil {
+		return nil, err
+	}
+	components := len(s.components)
+	if single && components != 1 {
+		return nil, fmt.Errorf("jpeg2000: %d components, only single component images are supported", components)
+	}
+	// Every tile has a tile-part of 14 octets at least, SOT and SOD
+	if tiles := s.numXT * s.numYT; tiles > len(data)/14 {
+		return nil, fmt.Errorf("jpeg2000: %d tiles in a codestream of %d octets", tiles, len(data))
+	}
+
+	// Main header, up to the first tile-part
+	offset := 4 + int(binary.BigEndian.Uint16(data[4:])) // SOC and the SIZ marker segment
+	var main header
+	for {
+		if offset+2 <= len(data) && binary.BigEndian.Uint16(data[offset:]) == markerSOT {
+			break
+		}
+		marker, segment, err := readSegment(data, offset)
+		if err != nil {
+			return nil, err
+		}
+		if err := parseHeaderSegment(&main, marker, segment, components); err != nil {
+			return nil, err
+		}
+		offset += 4 + len(segment)
+	}
+
+	// Tile-parts, the data of which is gathered by tile
+	type tileData struct {
+		header header
+		data   []byte
+		parts  int
+	}
+	tiles := make([]tileData, s.numXT*s.numYT)
+	for offset+2 <= len(data) && binary.BigEndian.Uint16(data[offset:]) == markerSOT {
+		_, segment, err := readSegment(data, offset)
+		if err != nil {
+			return nil, err
+		}
+		r := &segmentReader{marker: markerSOT, data: segment}
+		index, length := r.u16(), r.u32()
+		if r.err != nil {
+			return nil, r.err
+		}
+		if index >= len(tiles) {
+			return nil, fmt.Errorf("jpeg2000: tile-part of tile %d out of %d", index, len(tiles))
+		}
+		end := offset + length
+		if length == 0 {
+			end = len(data)
+			if bytes.HasSuffix(data, []byte{0xff, 0xd9}) {
+				end -= 2
+			}
+		}
+		if end > len(data) || end < offset+14 {
+			return nil, fmt.Errorf("jpeg2000: tile-part of tile %d has invalid length %d", index, length)
+		}
+
+		td := &tiles[index]
+		offset += 4 + len(segment)
+		for {
+			if offset+2 > end {
+				return nil, fmt.Errorf("jpeg2000: missing SOD marker in a tile-part of tile %d", index)
+			}
+			if binary.BigEndian.Uint16(data[offset:]) == markerSOD {
+				break
+			}
+			marker, segment, err := readSegment(data[:end], offset)
+			if err != nil {
+				return nil, err
+			}
+			// Coding parameters may only be set in the first tile-part of a tile
+			if td.parts == 0 {
+				if err := parseHeaderSegment(&td.header, marker, segment, components); err != nil {
+					return nil, err
+				}
+			}
+			offset += 4 + len(segment)
+		}
+		td.data = append(td.data, data[offset+2:end]...)
+		td.parts++
+		offset = end
+	}
+	// The samples are only allocated once every tile SIZ announces has been found
+	for i, td := range tiles {
+		if td.parts == 0 {
+			return nil, fmt.Errorf("jpeg2000: missing tile %d of %d", i, len(tiles))
+		}
+	}
+
+	images := make([]*Image, components)
+	for c := range images {
+		cs := s.component(c)
+		images[c] = &Image{
+			Width:     cs.width,
+			Height:    cs.height,
+			Precision: cs.precision,
+			Signed:    cs.signed,
+			Samples:   make([]int64, cs.width*cs.height),
+		}
+	}
+	for i, td := range tiles {
+		tcs := make([]*tile, components)
+		for c := range tcs {
+			cod, q, err := tileParams(main, td.header, c)
+			if err != nil {
+				return nil, err
+			}
+			if tcs[c], err = newTile(s.component(c), i, cod, q); err != nil {
+				return nil, err
+			}
+		}
+		if err := decodeTile(s, tcs, td.data); err != nil {
+			return nil, fmt.Errorf("tile %d: %w", i, err)
+		}
+		for c, tl := range tcs {
+			tl.store(s.component(c), images[c])
+		}
+	}
+	return images, nil
+}
+
+// jp2Codestream returns the contiguous codestream box of a JP2 file
+func jp2Codestream(data []byte) ([]byte, error) {
+	for len(data) >= 8 {
+		length := uint64(binary.BigEndian.Uint32(data))
+		kind := string(data[4:8])
+		header := uint64(8)
+		switch length {
+		case 0:
+			length = uint64(len(data))
+		case 1:
+			if len(data) < 16 {
+				return nil, fmt.Errorf("jpeg2000: truncated JP2 box %q", kind)
+			}
+			length, header = binary.BigEndian.Uint64(data[8:]), 16
+		}
+		if length < header || length > uint64(len(data)) {
+			return nil, fmt.Errorf("jpeg2000: JP2 box %q has invalid length %d", kind, length)
+		}
+		if kind == "jp2c" {
+			return data[header:length], nil
+		}
+		data = data[length:]
+	}
+	return nil, fmt.Errorf("jpeg2000: JP2 file without a codestream box")
+}
+
+// decodeTile reads the packets of the components of a tile from data, then decodes their
+// code-blocks, reconstructs their samples and reverses the component transformation
+func decodeTile(s siz, tcs []*tile, data []byte) error {
+	packets, err := tilePackets(s, tcs)
+	if err != nil {
+		return err
+	}
+	offset := 0
+	for i, p := range packets {
+		if offset >= len(data) {
+			break // Truncated codestreams decode the packets received
+		}
+		n, err := tcs[p.component].decodePacket(p.packet, data[offset:], i)
+		if err != nil {
+			return err
+		}
+		offset += n
+	}
+
+	for _, tl := range tcs {
+		if err := tl.reconstruct(); err != nil {
+			return err
+		}
+	}
+	if tcs[0].cod.mct {
+		return inverseComponentTransform(tcs)
+	}
+	return nil
+}
+
+// reconstruct decodes the code-blocks of the tile received and reconstructs its samples
+func (tl *tile) reconstruct() error {
+	for _, res := range tl.resolutions {
+		for _, pr := range res.precincts {
+			for bi := range pr {
+				for j := range pr[bi].blocks {
+					if err := tl.decodeBlock(res.bands[bi], &pr[bi].blocks[j]); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+
+	coeffs := tl.resolutions[0].bands[0].coeffs
+	for r := 1; r < len(tl.resolutions); r++ {
+		coeffs = tl.inverse2D(r, coeffs)
+	}
+	tl.samples = coeffs
+	return nil
+}
+
+// decodeBlock decodes the coding passes of a code-block into the coefficients of its
+// subband (Annex D and E)
+func (tl *tile) decodeBlock(b *band, cb *codeBlock) error {
+	if cb.passes == 0 {
+		return nil
+	}
+	cbStyle := tl.cod.cbStyle
+	w, h := cb.x1-cb.x0, cb.y1-cb.y0
+	c := newCodeBlockCoder(w, h, b.orient, cbStyle)
+	c.decode = true
+	planes := b.mb - cb.zeroPlanes
+
+	var mq mqDecoder
+	var raw rawDecoder
+	pass := 0
+	for _, seg := range cb.segments {
+		var coder binaryCoder = &mq
+		isRaw := rawPass(pass, cbStyle)
+		if isRaw {
+			raw.init(seg.data)
+			coder = &raw
+		} else {
+			mq.init(seg.data)
+		}
+		for range seg.passes {
+			p := planes - 1 - (pass+2)/3
+			if p < 0 {
+				return fmt.Errorf("jpeg2000: %d coding passes for %d bit-planes", cb.passes, planes)
+			}
+			switch passType(pass) {
+			case passSignificance:
+				c.significancePass(coder, isRaw, p)
+			case passRefinement:
+				c.refinementPass(coder, p)
+			default:
+				c.cleanupPass(coder, p)
+			}
+			if cbStyle&StyleReset != 0 {
+				c.cx.reset()
+			}
+			pass++
+		}
+	}
+
+	stride := b.x1 - b.x0
+	for y := range h {
+		row := b.coeffs[(cb.y0-b.y0+y)*stride+cb.x0-b.x0:]
+		for x := range w {
+			m := c.mags[y*w+x]
+			var v float64
+			if tl.cod.reversible {
+				v = float64(m >> 1)
+			} else {
+				v = float64(m) / 2 * b.step
+			}
+			if c.flags[c.index(x, y)]&flagNegative != 0 {
+				v = -v
+			}
+			row[x] = v
+		}
+	}
+	return nil
+}
+
+// inverseComponentTransform transforms the first three components of a tile back to RGB,
+// with the reversible (RCT) or irreversible (ICT) transformation of their filter (G.2, G.3)
+func inverseComponentTransform(tcs []*tile) error {
+	if len(tcs) < 3 {
+		return fmt.Errorf("jpeg2000: component transformation of %d components", len(tcs))
+	}
+	y0, y1, y2 := tcs[0].samples, tcs[1].samples, tcs[2].samples
+	if len(y1) != len(y0) || len(y2) != len(y0) || tcs[1].cod.reversible != tcs[0].cod.reversible || tcs[2].cod.reversible != tcs[0].cod.reversible {
+		return fmt.Errorf("jpeg2000: component transformation of components of different sizes or filters")
+	}
+	for i := range y0 {
+		if tcs[0].cod.reversible {
+			g := y0[i] - math.Floor((y1[i]+y2[i])/4)
+			y0[i], y1[i], y2[i] = y2[i]+g, g, y1[i]+g
+		} else {
+			y0[i], y1[i], y2[i] = y0[i]+1.402*y2[i], y0[i]-0.34413*y1[i]-0.71414*y2[i], y0[i]+1.772*y1[i]
+		}
+	}
+	return nil
+}
+
+// store level shifts, rounds and clamps the reconstructed samples of the tile into the
+// image (G.1)
+func (tl *tile) store(s siz, img *Image) {
+	lo, hi := int64(0), int64(1)<<s.precision-1
+	var shift int64
+	if s.signed {
+		lo, hi = -1<<(s.precision-1), 1<<(s.precision-1)-1
+	} else {
+		shift = 1 << (s.precision - 1)
+	}
+	w := tl.x1 - tl.x0
+	for y := tl.y0; y < tl.y1; y++ {
+		for x := tl.x0; x < tl.x1; x++ {
+			v := int64(math.Round(tl.samples[(y-tl.y0)*w+x-tl.x0])) + shift
+			img.Samples[(y-s.compY0)*img.Width+x-s.compX0] = max(lo, min(v, hi))
+		}
+	}
+}
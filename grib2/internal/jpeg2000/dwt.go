@@ -0,0 +1,187 @@
+package jpeg2000
+
+import "math"
+
+// Lifting parameters of the 9-7 irreversible filter (Table F.4)
+const (
+	alpha = -1.586134342059924
+	beta  = -0.052980118572961
+	gamma = 0.882911075530934
+	delta = 0.443506852043971
+	kappa = 1.230174104914001
+)
+
+// reflect maps index i of a signal of n samples into it by periodic symmetric extension
+// (F.3.7)
+func reflect(i, n int) int {
+	if n == 1 {
+		return 0
+	}
+	period := 2 * (n - 1)
+	i %= period
+	if i < 0 {
+		i += period
+	}
+	if i >= n {
+		i = period - i
+	}
+	return i
+}
+
+// lift adds the weighted sum of the neighbours of every other sample, starting at start
+func lift(x []float64, start int, weight float64) {
+	n := len(x)
+	for i := start; i < n; i += 2 {
+		x[i] += weight * (x[reflect(i-1, n)] + x[reflect(i+1, n)])
+	}
+}
+
+// scale multiplies every other sample, starting at start
+func scale(x []float64, start int, factor float64) {
+	for i := start; i < len(x); i += 2 {
+		x[i] *= factor
+	}
+}
+
+// inverse1D reconstructs a signal from its interleaved low-pass and high-pass
+// coefficients (1D_SR, F.3.6). odd tells whether the first sample is at an odd position,
+// and so a high-pass coefficient.
+func inverse1D(x []float64, odd, reversible bool) {
+	low, high := 0, 1
+	if odd {
+		low, high = 1, 0
+	}
+	n := len(x)
+	if n == 1 {
+		if odd {
+			x[0] /= 2
+		}
+		return
+	}
+	if reversible {
+		for i := low; i < n; i += 2 {
+			x[i] -= math.Floor((x[reflect(i-1, n)] + x[reflect(i+1, n)] + 2) / 4)
+		}
+		for i := high; i < n; i += 2 {
+			x[i] += math.Floor((x[reflect(i-1, n)] + x[reflect(i+1, n)]) / 2)
+		}
+		return
+	}
+	scale(x, low, kappa)
+	scale(x, high, 1/kappa)
+	lift(x, low, -delta)
+	lift(x, high, -gamma)
+	lift(x, low, -beta)
+	lift(x, high, -alpha)
+}
+
+// forward1D decomposes a signal into interleaved low-pass and high-pass coefficients
+// (1D_SD, F.4.6), the inverse of inverse1D
+func forward1D(x []float64, odd, reversible bool) {
+	low, high := 0, 1
+	if odd {
+		low, high = 1, 0
+	}
+	n := len(x)
+	if n == 1 {
+		if odd {
+			x[0] *= 2
+		}
+		return
+	}
+	if reversible {
+		for i := high; i < n; i += 2 {
+			x[i] -= math.Floor((x[reflect(i-1, n)] + x[reflect(i+1, n)]) / 2)
+		}
+		for i := low; i < n; i += 2 {
+			x[i] += math.Floor((x[reflect(i-1, n)] + x[reflect(i+1, n)] + 2) / 4)
+		}
+		return
+	}
+	lift(x, high, alpha)
+	lift(x, low, beta)
+	lift(x, high, gamma)
+	lift(x, low, delta)
+	scale(x, high, kappa)
+	scale(x, low, 1/kappa)
+}
+
+// inverse2D reconstructs resolution r of the tile from resolution r-1, held in ll, and
+// the coefficients of the subbands of resolution r (2D_SR, F.3.2)
+func (tl *tile) inverse2D(r int, ll []float64) []float64 {
+	res := &tl.resolutions[r]
+	low := &tl.resolutions[r-1]
+	w, h := res.x1-res.x0, res.y1-res.y0
+	out := make([]float64, w*h)
+
+	// 2D_INTERLEAVE
+	for y := range h {
+		for x := range w {
+			u, v := res.x0+x, res.y0+y
+			var c float64
+			switch o := u&1 | v&1<<1; o {
+			case bandLL:
+				c = ll[(v>>1-low.y0)*(low.x1-low.x0)+u>>1-low.x0]
+			default:
+				b := res.bands[o-1]
+				c = b.coeffs[(v>>1-b.y0)*(b.x1-b.x0)+u>>1-b.x0]
+			}
+			out[y*w+x] = c
+		}
+	}
+
+	reversible := tl.cod.reversible
+	for y := range h {
+		inverse1D(out[y*w:(y+1)*w], res.x0&1 == 1, reversible)
+	}
+	col := make([]float64, h)
+	for x := range w {
+		for y := range h {
+			col[y] = out[y*w+x]
+		}
+		inverse1D(col, res.y0&1 == 1, reversible)
+		for y := range h {
+			out[y*w+x] = col[y]
+		}
+	}
+	return out
+}
+
+// forward2D decomposes resolution r of the tile, held in in, into the coefficients of its
+// subbands and returns resolution r-1 (2D_SD, F.4.2)
+func (tl *tile) forward2D(r int, in []float64) []float64 {
+	res := &tl.resolutions[r]
+	low := &tl.resolutions[r-1]
+	w, h := res.x1-res.x0, res.y1-res.y0
+
+	reversible := tl.cod.reversible
+	col := make([]float64, h)
+	for x := range w {
+		for y := range h {
+			col[y] = in[y*w+x]
+		}
+		forward1D(col, res.y0&1 == 1, reversible)
+		for y := range h {
+			in[y*w+x] = col[y]
+		}
+	}
+	for y := range h {
+		forward1D(in[y*w:(y+1)*w], res.x0&1 == 1, reversible)
+	}
+
+	// 2D_DEINTERLEAVE
+	ll := make([]float64, (low.x1-low.x0)*(low.y1-low.y0))
+	for y := range h {
+		for x := range w {
+			u, v := res.x0+x, res.y0+y
+			switch o := u&1 | v&1<<1; o {
+			case bandLL:
+				ll[(v>>1-low.y0)*(low.x1-low.x0)+u>>1-low.x0] = in[y*w+x]
+			default:
+				b := res.bands[o-1]
+				b.coeffs[(v>>1-b.y0)*(b.x1-b.x0)+u>>1-b.x0] = in[y*w+x]
+			}
+		}
+	}
+	return ll
+}
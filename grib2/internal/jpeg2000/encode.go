@@ -0,0 +1,431 @@
+package jpeg2000
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/bits"
+)
+
+// EncodeOptions are the coding parameters of Encode
+type EncodeOptions struct {
+	Levels          int         // Number of decomposition levels
+	Irreversible    bool        // 9-7 irreversible filter with a step size of 1/8, else 5-3 reversible
+	Layers          int         // Number of quality layers, 1 if zero
+	Progression     Progression // Order of the packets
+	CodeBlockWidth  int         // Code-block width exponent, 6 if zero
+	CodeBlockHeight int         // Code-block height exponent, 6 if zero
+	CodeBlockStyle  uint8       // Style* flags
+	SOP, EPH        bool        // Whether to mark packets with SOP and EPH markers
+	TileWidth       int         // Tile width, the image width if zero
+	TileHeight      int         // Tile height, the image height if zero
+
+	// Precinct size exponents of each resolution from the lowest, PPx in the low nibble
+	// and PPy in the high one, or maximal precincts if empty
+	Precincts []uint8
+}
+
+// irreversibleStep is the exponent added to the nominal dynamic range of the subbands for
+// their quantization with the irreversible filter, which sets a step size of 1/8
+const irreversibleStep = 3
+
+// Encode encodes the image into a JPEG 2000 codestream
+func Encode(img *Image, opts EncodeOptions) ([]byte, error) {
+	switch {
+	case img.Width <= 0 || img.Height <= 0 || len(img.Samples) != img.Width*img.Height:
+		return nil, fmt.Errorf("jpeg2000: %d samples for %dx%d", len(img.Samples), img.Width, img.Height)
+	case img.Precision < 1 || img.Precision > 38:
+		return nil, fmt.Errorf("jpeg2000: invalid precision %d", img.Precision)
+	case opts.Levels < 0 || opts.Levels > 32:
+		return nil, fmt.Errorf("jpeg2000: invalid number of decomposition levels %d", opts.Levels)
+	case len(opts.Precincts) != 0 && len(opts.Precincts) != opts.Levels+1:
+		return nil, fmt.Errorf("jpeg2000: %d precinct sizes for %d resolutions", len(opts.Precincts), opts.Levels+1)
+	}
+
+	s := siz{
+		x1: img.Width, y1: img.Height,
+		tw: orDefault(opts.TileWidth, img.Width), th: orDefault(opts.TileHeight, img.Height),
+		precision: img.Precision, signed: img.Signed,
+		dx: 1, dy: 1,
+		width: img.Width, height: img.Height,
+	}
+	s.numXT, s.numYT = ceilDiv(s.x1, s.tw), ceilDiv(s.y1, s.th)
+	cod := codingDefaults{
+		sop:         opts.SOP,
+		eph:         opts.EPH,
+		progression: opts.Progression,
+		layers:      orDefault(opts.Layers, 1),
+		codingStyle: codingStyle{
+			levels:     opts.Levels,
+			xcb:        orDefault(opts.CodeBlockWidth, 6),
+			ycb:        orDefault(opts.CodeBlockHeight, 6),
+			cbStyle:    opts.CodeBlockStyle,
+			reversible: !opts.Irreversible,
+			precincts:  opts.Precincts,
+		},
+	}
+	if len(cod.precincts) == 0 {
+		cod.precincts = make([]uint8, opts.Levels+1)
+		for i := range cod.precincts {
+			cod.precincts[i] = 0xff
+		}
+	}
+
+	// Step sizes of the subbands in order, with as many guard bits as the coefficients need
+	q := quantization{guard: 1, steps: make([]stepSize, 1+3*opts.Levels)}
+	if opts.Irreversible {
+		q.style = 2
+	}
+	for i := range q.steps {
+		gain := 0
+		if i > 0 {
+			gain = [...]int{1, 1, 2}[(i-1)%3]
+		}
+		q.steps[i].exponent = img.Precision + gain
+		if opts.Irreversible {
+			q.steps[i].exponent += irreversibleStep
+		}
+		if q.steps[i].exponent > 31 {
+			return nil, fmt.Errorf("jpeg2000: precision %d too large to quantize", img.Precision)
+		}
+	}
+
+	tiles := make([]*tile, s.numXT*s.numYT)
+	for i := range tiles {
+		tl, err := newTile(s, i, cod, q)
+		if err != nil {
+			return nil, err
+		}
+		q.guard = max(q.guard, tl.analyze(img))
+		tiles[i] = tl
+	}
+	if q.guard > 7 {
+		return nil, fmt.Errorf("jpeg2000: %d guard bits needed", q.guard)
+	}
+
+	out := []byte{0xff, 0x4f}
+	out = appendSIZ(out, s)
+	out = appendCOD(out, cod, len(opts.Precincts) != 0)
+	out = appendQCD(out, q)
+	for i, tl := range tiles {
+		for r := range tl.resolutions {
+			for _, b := range tl.resolutions[r].bands {
+				b.mb = q.guard + q.steps[bandIndex(r, b.orient)].exponent - 1
+			}
+		}
+		data := tl.encode(s)
+		out = binary.BigEndian.AppendUint16(out, markerSOT)
+		out = binary.BigEndian.AppendUint16(out, 10)
+		out = binary.BigEndian.AppendUint16(out, uint16(i))
+		out = binary.BigEndian.AppendUint32(out, uint32(12+2+len(data)))
+		out = append(out, 0, 1) // Tile-part 0 of 1
+		out = binary.BigEndian.AppendUint16(out, markerSOD)
+		out = append(out, data...)
+	}
+	return binary.BigEndian.AppendUint16(out, markerEOC), nil
+}
+
+// orDefault returns v, or def if v is zero
+func orDefault(v, def int) int {
+	if v == 0 {
+		return def
+	}
+	return v
+}
+
+// bandIndex returns the index of the step size of a subband of resolution r
+func bandIndex(r, orient int) int {
+	if r == 0 {
+		return 0
+	}
+	return 3*(r-1) + orient
+}
+
+// analyze level shifts and transforms the samples of the tile, and quantizes the
+// coefficients of its subbands. It returns the number of guard bits the coefficients
+// need.
+func (tl *tile) analyze(img *Image) int {
+	var shift float64
+	if !img.Signed {
+		shift = math.Ldexp(1, img.Precision-1)
+	}
+	w := tl.x1 - tl.x0
+	samples := make([]float64, w*(tl.y1-tl.y0))
+	for y := tl.y0; y < tl.y1; y++ {
+		for x := tl.x0; x < tl.x1; x++ {
+			samples[(y-tl.y0)*w+x-tl.x0] = float64(img.Samples[y*img.Width+x]) - shift
+		}
+	}
+	for r := len(tl.resolutions) - 1; r > 0; r-- {
+		samples = tl.forward2D(r, samples)
+	}
+	tl.resolutions[0].bands[0].coeffs = samples
+
+	guard := 1
+	for r := range tl.resolutions {
+		for _, b := range tl.resolutions[r].bands {
+			var most float64
+			for i, c := range b.coeffs {
+				if !tl.cod.reversible {
+					c = math.Copysign(math.Floor(math.Abs(c)/b.step), c)
+					b.coeffs[i] = c
+				}
+				most = max(most, math.Abs(c))
+			}
+			// Built with a single guard bit, the subband has as many bit-planes as its
+			// exponent, while G + exponent - 1 must hold the magnitudes
+			guard = max(guard, bits.Len64(uint64(most))-b.mb+1)
+		}
+	}
+	return guard
+}
+
+// encode codes the code-blocks of the tile and returns its packets
+func (tl *tile) encode(s siz) []byte {
+	layers := tl.cod.layers
+	for r := range tl.resolutions {
+		res := &tl.resolutions[r]
+		for _, pr := range res.precincts {
+			for bi := range pr {
+				pb := &pr[bi]
+				b := res.bands[bi]
+				for j := range pb.blocks {
+					cb := &pb.blocks[j]
+					planes := tl.encodeBlock(b, cb)
+					first := layers
+					for l := range layers {
+						if start, end := layerPasses(cb.passes, l, layers); end > start {
+							first = l
+							break
+						}
+					}
+					pb.inclusion.setValue(j, first)
+					pb.zeroPlanes.setValue(j, b.mb-planes)
+				}
+			}
+		}
+	}
+
+	var out []byte
+	for i, p := range tl.packets(s) {
+		out = tl.encodePacket(out, p, i)
+	}
+	return out
+}
+
+// layerPasses returns the range of the passes of a code-block in layer l, the passes being
+// shared evenly between the layers
+func layerPasses(passes, l, layers int) (int, int) {
+	return passes * l / layers, passes * (l + 1) / layers
+}
+
+// encodeBlock codes the coefficients of a code-block into its codeword segments, and
+// returns the number of bit-planes of their magnitudes
+func (tl *tile) encodeBlock(b *band, cb *codeBlock) int {
+	cbStyle := tl.cod.cbStyle
+	w, h := cb.x1-cb.x0, cb.y1-cb.y0
+	c := newCodeBlockCoder(w, h, b.orient, cbStyle)
+	var most int64
+	stride := b.x1 - b.x0
+	for y := range h {
+		for x := range w {
+			v := int64(b.coeffs[(cb.y0-b.y0+y)*stride+cb.x0-b.x0+x])
+			if v < 0 {
+				v = -v
+				c.flags[c.index(x, y)] |= flagNegative
+			}
+			c.mags[y*w+x] = v
+			most = max(most, v)
+		}
+	}
+	planes := bits.Len64(uint64(most))
+	cb.passes = max(0, 3*planes-2)
+
+	var mq mqEncoder
+	var raw rawEncoder
+	var coder binaryCoder
+	var seg segment
+	isRaw := false
+	for pass := range cb.passes {
+		if seg.passes == 0 {
+			seg.maxPasses = maxPasses(pass, cbStyle)
+			if isRaw = rawPass(pass, cbStyle); isRaw {
+				raw.init()
+				coder = &raw
+			} else {
+				mq.init()
+				coder = &mq
+			}
+		}
+		p := planes - 1 - (pass+2)/3
+		switch passType(pass) {
+		case passSignificance:
+			c.significancePass(coder, isRaw, p)
+		case passRefinement:
+			c.refinementPass(coder, p)
+		default:
+			c.cleanupPass(coder, p)
+		}
+		if cbStyle&StyleReset != 0 {
+			c.cx.reset()
+		}
+		if seg.passes++; seg.passes == seg.maxPasses || pass == cb.passes-1 {
+			if isRaw {
+				seg.data = raw.flush()
+			} else {
+				seg.data = mq.flush()
+			}
+			cb.segments = append(cb.segments, seg)
+			seg = segment{}
+		}
+	}
+	return planes
+}
+
+// encodePacket appends packet p, the index-th of the tile, to out (B.9 and B.10)
+func (tl *tile) encodePacket(out []byte, p packet, index int) []byte {
+	if tl.cod.sop {
+		out = binary.BigEndian.AppendUint16(out, markerSOP)
+		out = binary.BigEndian.AppendUint16(out, 4)
+		out = binary.BigEndian.AppendUint16(out, uint16(index))
+	}
+
+	pr := tl.resolutions[p.res].precincts[p.precinct]
+	layers := tl.cod.layers
+	empty := true
+	for _, pb := range pr {
+		for _, cb := range pb.blocks {
+			if start, end := layerPasses(cb.passes, p.layer, layers); end > start {
+				empty = false
+			}
+		}
+	}
+
+	w := &headerWriter{}
+	var body []byte
+	if empty {
+		w.bit(0)
+	} else {
+		w.bit(1)
+		for bi := range pr {
+			pb := &pr[bi]
+			for j := range pb.blocks {
+				cb := &pb.blocks[j]
+				start, end := layerPasses(cb.passes, p.layer, layers)
+				if !cb.included {
+					pb.inclusion.encode(w, j, p.layer+1)
+				} else {
+					w.bit(uint8(min(1, end-start)))
+				}
+				if end == start {
+					continue
+				}
+				if !cb.included {
+					pb.zeroPlanes.encode(w, j, pb.zeroPlanes.nodes[j].value+1)
+					cb.included = true
+				}
+				encodePasses(w, end-start)
+
+				// Contributions to the codeword segments, each taking the share of the
+				// bytes of its segment in proportion to its passes
+				type piece struct{ passes, length int }
+				var pieces []piece
+				segStart := 0
+				for _, seg := range cb.segments {
+					a, b := max(start, segStart), min(end, segStart+seg.passes)
+					if a < b {
+						from := (a - segStart) * len(seg.data) / seg.passes
+						to := (b - segStart) * len(seg.data) / seg.passes
+						pieces = append(pieces, piece{b - a, to - from})
+						body = append(body, seg.data[from:to]...)
+					}
+					segStart += seg.passes
+				}
+
+				increment := 0
+				for _, pc := range pieces {
+					increment = max(increment, bits.Len(uint(pc.length))-cb.lblock-bits.Len(uint(pc.passes))+1)
+				}
+				for range increment {
+					w.bit(1)
+				}
+				w.bit(0)
+				cb.lblock += increment
+				for _, pc := range pieces {
+					w.bits(pc.length, cb.lblock+bits.Len(uint(pc.passes))-1)
+				}
+			}
+		}
+	}
+	out = append(out, w.flush()...)
+	if tl.cod.eph {
+		out = binary.BigEndian.AppendUint16(out, markerEPH)
+	}
+	return append(out, body...)
+}
+
+// appendSIZ appends the SIZ marker segment of the single component image
+func appendSIZ(out []byte, s siz) []byte {
+	out = binary.BigEndian.AppendUint16(out, markerSIZ)
+	out = binary.BigEndian.AppendUint16(out, 38+3)
+	out = binary.BigEndian.AppendUint16(out, 0) // Rsiz
+	for _, v := range []int{s.x1, s.y1, s.x0, s.y0, s.tw, s.th, s.tx0, s.ty0} {
+		out = binary.BigEndian.AppendUint32(out, uint32(v))
+	}
+	out = binary.BigEndian.AppendUint16(out, 1)
+	ssiz := byte(s.precision - 1)
+	if s.signed {
+		ssiz |= 0x80
+	}
+	return append(out, ssiz, byte(s.dx), byte(s.dy))
+}
+
+// appendCOD appends the COD marker segment
+func appendCOD(out []byte, cod codingDefaults, precincts bool) []byte {
+	var scod byte
+	if precincts {
+		scod |= 0x01
+	}
+	if cod.sop {
+		scod |= 0x02
+	}
+	if cod.eph {
+		scod |= 0x04
+	}
+	length := 12
+	if precincts {
+		length += len(cod.precincts)
+	}
+	var transform byte
+	if cod.reversible {
+		transform = 1
+	}
+	out = binary.BigEndian.AppendUint16(out, markerCOD)
+	out = binary.BigEndian.AppendUint16(out, uint16(length))
+	out = append(out, scod, byte(cod.progression))
+	out = binary.BigEndian.AppendUint16(out, uint16(cod.layers))
+	out = append(out, 0, byte(cod.levels), byte(cod.xcb-2), byte(cod.ycb-2), cod.cbStyle, transform)
+	if precincts {
+		out = append(out, cod.precincts...)
+	}
+	return out
+}
+
+// appendQCD appends the QCD marker segment
+func appendQCD(out []byte, q quantization) []byte {
+	length := 3 + len(q.steps)
+	if q.style != 0 {
+		length += len(q.steps)
+	}
+	out = binary.BigEndian.AppendUint16(out, markerQCD)
+	out = binary.BigEndian.AppendUint16(out, uint16(length))
+	out = append(out, byte(q.guard<<5)|q.style)
+	for _, st := range q.steps {
+		if q.style == 0 {
+			out = append(out, byte(st.exponent<<3))
+		} else {
+			out = binary.BigEndian.AppendUint16(out, uint16(st.exponent<<11|st.mantissa))
+		}
+	}
+	return out
+}
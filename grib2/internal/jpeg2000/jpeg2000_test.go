@@ -0,0 +1,315 @@
+package jpeg2000_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"os"
+	"testing"
+
+	"github.com/scorix/grib/grib2/internal/jpeg2000"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// field returns a smooth field with noise, as meteorological fields look
+func field(width, height, precision int, signed bool) *jpeg2000.Image {
+	rng := rand.New(rand.NewPCG(uint64(width), uint64(height)))
+	img := &jpeg2000.Image{Width: width, Height: height, Precision: precision, Signed: signed}
+	amplitude := math.Ldexp(1, precision-2)
+	for y := range height {
+		for x := range width {
+			v := amplitude * (1 + math.Sin(float64(x)/7)*math.Cos(float64(y)/5))
+			v += float64(rng.IntN(1 + int(amplitude)/16))
+			if signed {
+				v -= amplitude
+			}
+			img.Samples = append(img.Samples, int64(v))
+		}
+	}
+	return img
+}
+
+func TestEncodeDecode_Lossless(t *testing.T) {
+	images := []*jpeg2000.Image{
+		field(37, 23, 12, false),
+		field(1, 1, 8, false),
+		field(1, 9, 1, false),
+		field(130, 5, 24, false),
+		field(33, 17, 16, true),
+	}
+	precincts := []uint8{0x22, 0x33, 0x44, 0x55}
+	for _, opts := range []jpeg2000.EncodeOptions{
+		{},
+		{Levels: 3},
+		{Levels: 3, CodeBlockWidth: 2, CodeBlockHeight: 4},
+		{Levels: 3, Layers: 4},
+		{Levels: 3, Layers: 2, Progression: jpeg2000.RLCP},
+		{Levels: 3, Layers: 2, Progression: jpeg2000.RPCL, Precincts: precincts},
+		{Levels: 3, Layers: 2, Progression: jpeg2000.PCRL, Precincts: precincts},
+		{Levels: 3, Layers: 2, Progression: jpeg2000.CPRL, Precincts: precincts, CodeBlockWidth: 5, CodeBlockHeight: 2},
+		{Levels: 2, CodeBlockStyle: jpeg2000.StyleBypass, Layers: 3},
+		{Levels: 2, CodeBlockStyle: jpeg2000.StyleReset | jpeg2000.StyleTermAll, Layers: 3},
+		{Levels: 2, CodeBlockStyle: jpeg2000.StyleBypass | jpeg2000.StyleTermAll},
+		{Levels: 2, CodeBlockStyle: jpeg2000.StyleVCausal | jpeg2000.StyleSegSym | jpeg2000.StylePredictable},
+		{Levels: 2, SOP: true, EPH: true, Layers: 2},
+		{Levels: 2, TileWidth: 16, TileHeight: 7, Progression: jpeg2000.PCRL},
+		{Levels: 8},
+	} {
+		for _, img := range images {
+			name := fmt.Sprintf("%dx%d/%+v", img.Width, img.Height, opts)
+			t.Run(name, func(t *testing.T) {
+				data, err := jpeg2000.Encode(img, opts)
+				require.NoError(t, err)
+				got, err := jpeg2000.Decode(data)
+				require.NoError(t, err)
+				assert.Equal(t, img, got)
+			})
+		}
+	}
+}
+
+func TestEncodeDecode_Irreversible(t *testing.T) {
+	img := field(90, 45, 16, false)
+	for _, levels := range []int{0, 1, 5} {
+		data, err := jpeg2000.Encode(img, jpeg2000.EncodeOptions{Levels: levels, Irreversible: true, Layers: 2})
+		require.NoError(t, err)
+		got, err := jpeg2000.Decode(data)
+		require.NoError(t, err)
+		require.Len(t, got.Samples, len(img.Samples))
+		for i, v := range got.Samples {
+			require.InDelta(t, img.Samples[i], v, 1, "levels %d, sample %d", levels, i)
+		}
+	}
+}
+
+func TestDecode_JP2(t *testing.T) {
+	img := field(10, 10, 8, false)
+	codestream, err := jpeg2000.Encode(img, jpeg2000.EncodeOptions{Levels: 1})
+	require.NoError(t, err)
+
+	box := func(kind string, content []byte) []byte {
+		b := binary.BigEndian.AppendUint32(nil, uint32(8+len(content)))
+		return append(append(b, kind...), content...)
+	}
+	var file []byte
+	file = append(file, box("jP  ", []byte{0x0d, 0x0a, 0x87, 0x0a})...)
+	file = append(file, box("ftyp", []byte("jp2 \x00\x00\x00\x00jp2 "))...)
+	file = append(file, box("jp2h", nil)...)
+	file = append(file, box("jp2c", codestream)...)
+
+	got, err := jpeg2000.Decode(file)
+	require.NoError(t, err)
+	assert.Equal(t, img, got)
+	cfg, err := jpeg2000.DecodeConfig(file)
+	require.NoError(t, err)
+	assert.Equal(t, jpeg2000.Config{Width: 10, Height: 10, Precision: 8, Components: 1, Samples: 100}, cfg)
+
+	_, err = jpeg2000.Decode(file[:len(file)-len(codestream)-8])
+	assert.ErrorContains(t, err, "without a codestream box")
+}
+
+// TestDecodeComponents_Kakadu decodes testdata/kakadu.jp2, a 400x300 RGB photograph
+// written by Kakadu with the reversible filter, the component transformation and 12
+// quality layers, from the testdata of github.com/gabriel-vasile/mimetype (MIT, see
+// testdata/kakadu.jp2.LICENSE). Without the samples of a reference decoder, a wrong
+// decoding shows as noise: the samples of a photograph differ little from their neighbours.
+func TestDecodeComponents_Kakadu(t *testing.T) {
+	data, err := os.ReadFile("testdata/kakadu.jp2")
+	require.NoError(t, err)
+
+	_, err = jpeg2000.Decode(data)
+	assert.ErrorContains(t, err, "3 components, only single component images are supported")
+
+	images, err := jpeg2000.DecodeComponents(data)
+	require.NoError(t, err)
+	require.Len(t, images, 3)
+	for c, img := range images {
+		assert.Equal(t, 400, img.Width, "component %d", c)
+		assert.Equal(t, 300, img.Height, "component %d", c)
+		assert.Equal(t, 8, img.Precision, "component %d", c)
+		assert.False(t, img.Signed, "component %d", c)
+		require.Len(t, img.Samples, 400*300)
+
+		var sum, diff float64
+		for i, v := range img.Samples {
+			require.True(t, v >= 0 && v <= 255, "component %d sample %d: %d", c, i, v)
+			sum += float64(v)
+			if i%img.Width > 0 {
+				diff += math.Abs(float64(v - img.Samples[i-1]))
+			}
+		}
+		mean := sum / float64(len(img.Samples))
+		assert.True(t, mean > 32 && mean < 128, "component %d mean %g", c, mean)
+		assert.Less(t, diff/float64(len(img.Samples)), 16.0, "component %d", c)
+	}
+
+	// Red, green and blue of a photograph correlate, unlike the luminance and the colour
+	// differences of the component transformation
+	for c := range 2 {
+		assert.Greater(t, correlation(images[c].Samples, images[c+1].Samples), 0.8, "components %d and %d", c, c+1)
+	}
+
+	// The codestream out of its JP2 boxes decodes the same
+	start := bytes.Index(data, []byte{0xff, 0x4f, 0xff, 0x51})
+	require.Positive(t, start)
+	bare, err := jpeg2000.DecodeComponents(data[start:])
+	require.NoError(t, err)
+	assert.Equal(t, images, bare)
+}
+
+// correlation returns the Pearson correlation coefficient of two series of samples
+func correlation(a, b []int64) float64 {
+	var sa, sb, saa, sbb, sab float64
+	for i := range a {
+		x, y := float64(a[i]), float64(b[i])
+		sa, sb, saa, sbb, sab = sa+x, sb+y, saa+x*x, sbb+y*y, sab+x*y
+	}
+	n := float64(len(a))
+	return (n*sab - sa*sb) / math.Sqrt((n*saa-sa*sa)*(n*sbb-sb*sb))
+}
+
+func TestDecode_Truncated(t *testing.T) {
+	img := field(40, 30, 12, false)
+	data, err := jpeg2000.Encode(img, jpeg2000.EncodeOptions{Levels: 2, Layers: 3, SOP: true})
+	require.NoError(t, err)
+
+	// A tile-part running to the end of the codestream carries the packets received, here
+	// up to the start of a packet of the last layer
+	sot := bytes.Index(data, []byte{0xff, 0x90})
+	end := bytes.LastIndex(data, []byte{0xff, 0x91})
+	truncated := append([]byte(nil), data[:end]...)
+	binary.BigEndian.PutUint32(truncated[sot+6:], 0)
+	got, err := jpeg2000.Decode(truncated)
+	require.NoError(t, err)
+	assert.Len(t, got.Samples, img.Width*img.Height)
+	assert.NotEqual(t, img.Samples, got.Samples)
+
+	_, err = jpeg2000.Decode(truncated[:len(truncated)-3])
+	assert.ErrorContains(t, err, "truncated")
+}
+
+func TestDecode_Errors(t *testing.T) {
+	valid, err := jpeg2000.Encode(field(8, 8, 8, false), jpeg2000.EncodeOptions{Levels: 1})
+	require.NoError(t, err)
+	withSegment := func(marker uint16, content ...byte) []byte {
+		// Insert a marker segment after SIZ, which is 43 bytes from offset 2
+		segment := binary.BigEndian.AppendUint16(nil, marker)
+		segment = binary.BigEndian.AppendUint16(segment, uint16(2+len(content)))
+		segment = append(segment, content...)
+		return append(append(append([]byte(nil), valid[:45]...), segment...), valid[45:]...)
+	}
+	// A second component like the first, after Ssiz, XRsiz and YRsiz of the first at 42
+	twoComponents := append(append(append([]byte(nil), valid[:45]...), valid[42:45]...), valid[45:]...)
+	binary.BigEndian.PutUint16(twoComponents[4:], binary.BigEndian.Uint16(valid[4:])+3)
+	binary.BigEndian.PutUint16(twoComponents[40:], 2)
+
+	for name, tc := range map[string]struct {
+		data []byte
+		want string
+	}{
+		"empty":            {nil, "missing SOC"},
+		"not a codestream": {[]byte("GRIB"), "missing SOC"},
+		"truncated header": {valid[:30], "invalid length"},
+		"two components":   {twoComponents, "2 components"},
+		"progression order change": {
+			withSegment(0xff5f, 0, 0, 0, 1, 1, 0), "progression order changes are not supported",
+		},
+		"region of interest": {withSegment(0xff5e, 0, 0, 3), "regions of interest are not supported"},
+		"packed headers":     {withSegment(0xff60, 0), "packed packet headers are not supported"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := jpeg2000.Decode(tc.data)
+			assert.ErrorContains(t, err, tc.want)
+		})
+	}
+
+	// SIZ announcing more tiles than the codestream can hold, here 1x1 tiles
+	manyTiles := append([]byte(nil), valid...)
+	binary.BigEndian.PutUint32(manyTiles[24:], 1)
+	binary.BigEndian.PutUint32(manyTiles[28:], 1)
+	_, err = jpeg2000.Decode(manyTiles)
+	assert.ErrorContains(t, err, "64 tiles in a codestream of")
+
+	// SOT marker segment running up to the last octet of a tile-part that ends with the
+	// codestream
+	longSOT := append([]byte(nil), valid[:len(valid)-2]...)
+	sot := bytes.Index(longSOT, []byte{0xff, 0x90})
+	binary.BigEndian.PutUint32(longSOT[sot+6:], 0)
+	binary.BigEndian.PutUint16(longSOT[sot+2:], uint16(len(longSOT)-1-sot-2))
+	_, err = jpeg2000.Decode(longSOT)
+	assert.ErrorContains(t, err, "missing SOD marker in a tile-part of tile 0")
+
+	// A tile without tile-parts, the last one cut off before its SOT marker
+	tiled, err := jpeg2000.Encode(field(16, 16, 8, false), jpeg2000.EncodeOptions{TileWidth: 8, TileHeight: 8})
+	require.NoError(t, err)
+	lastTile := bytes.LastIndex(tiled, []byte{0xff, 0x90})
+	_, err = jpeg2000.Decode(append(tiled[:lastTile:lastTile], 0xff, 0xd9))
+	assert.ErrorContains(t, err, "missing tile 3 of 4")
+
+	// Comments are skipped
+	got, err := jpeg2000.Decode(withSegment(0xff64, 0, 1, 'h', 'i'))
+	require.NoError(t, err)
+	assert.Len(t, got.Samples, 64)
+}
+
+func TestDecodeConfig(t *testing.T) {
+	data, err := jpeg2000.Encode(field(37, 23, 12, true), jpeg2000.EncodeOptions{Levels: 2})
+	require.NoError(t, err)
+	cfg, err := jpeg2000.DecodeConfig(data)
+	require.NoError(t, err)
+	assert.Equal(t, jpeg2000.Config{Width: 37, Height: 23, Precision: 12, Signed: true, Components: 1, Samples: 37 * 23}, cfg)
+
+	// Only the main header up to SIZ is read
+	cfg, err = jpeg2000.DecodeConfig(data[:2+2+int(binary.BigEndian.Uint16(data[4:]))])
+	require.NoError(t, err)
+	assert.Equal(t, 37, cfg.Width)
+
+	kakadu, err := os.ReadFile("testdata/kakadu.jp2")
+	require.NoError(t, err)
+	cfg, err = jpeg2000.DecodeConfig(kakadu)
+	require.NoError(t, err)
+	assert.Equal(t, jpeg2000.Config{Width: 400, Height: 300, Precision: 8, Components: 3, Samples: 3 * 400 * 300}, cfg)
+
+	_, err = jpeg2000.DecodeConfig(data[:30])
+	assert.ErrorContains(t, err, "invalid length")
+}
+
+func FuzzDecode(f *testing.F) {
+	img := field(13, 9, 10, false)
+	for _, opts := range []jpeg2000.EncodeOptions{
+		{},
+		{Levels: 2, Layers: 2, SOP: true, EPH: true},
+		{Levels: 1, TileWidth: 5, TileHeight: 4, Progression: jpeg2000.RPCL},
+		{Levels: 2, CodeBlockStyle: jpeg2000.StyleBypass | jpeg2000.StyleTermAll, Irreversible: true},
+	} {
+		data, err := jpeg2000.Encode(img, opts)
+		require.NoError(f, err)
+		f.Add(data)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		cfg, err := jpeg2000.DecodeConfig(data)
+		if err != nil {
+			_, err := jpeg2000.DecodeComponents(data)
+			require.Error(t, err)
+			return
+		}
+		// As the fuzz tests of the standard library's image decoders do, leave out images
+		// too large to decode quickly; callers bound them with DecodeConfig the same way
+		if cfg.Samples > 1<<16 {
+			return
+		}
+
+		images, err := jpeg2000.DecodeComponents(data)
+		if err != nil {
+			return
+		}
+		require.Len(t, images, cfg.Components)
+		assert.Equal(t, cfg.Width, images[0].Width)
+		assert.Equal(t, cfg.Height, images[0].Height)
+		assert.Len(t, images[0].Samples, cfg.Width*cfg.Height)
+	})
+}
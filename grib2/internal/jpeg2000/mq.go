@@ -0,0 +1,325 @@
+package jpeg2000
+
+// mqState is a state of the probability estimation of the MQ coder (Table C.2)
+type mqState struct {
+	qe         uint32 // Probability of the less probable symbol
+	nmps, nlps uint8  // Next state after a more or less probable symbol
+	switchMPS  bool   // Whether a less probable symbol swaps the more probable symbol
+}
+
+var mqStates = [47]mqState{
+	{0x5601, 1, 1, true}, {0x3401, 2, 6, false}, {0x1801, 3, 9, false}, {0x0ac1, 4, 12, false},
+	{0x0521, 5, 29, false}, {0x0221, 38, 33, false}, {0x5601, 7, 6, true}, {0x5401, 8, 14, false},
+	{0x4801, 9, 14, false}, {0x3801, 10, 14, false}, {0x3001, 11, 17, false}, {0x2401, 12, 18, false},
+	{0x1c01, 13, 20, false}, {0x1601, 29, 21, false}, {0x5601, 15, 14, true}, {0x5401, 16, 14, false},
+	{0x5101, 17, 15, false}, {0x4801, 18, 16, false}, {0x3801, 19, 17, false}, {0x3401, 20, 18, false},
+	{0x3001, 21, 19, false}, {0x2801, 22, 19, false}, {0x2401, 23, 20, false}, {0x2201, 24, 21, false},
+	{0x1c01, 25, 22, false}, {0x1801, 26, 23, false}, {0x1601, 27, 24, false}, {0x1401, 28, 25, false},
+	{0x1201, 29, 26, false}, {0x1101, 30, 27, false}, {0x0ac1, 31, 28, false}, {0x09c1, 32, 29, false},
+	{0x08a1, 33, 30, false}, {0x0521, 34, 31, false}, {0x0441, 35, 32, false}, {0x02a1, 36, 33, false},
+	{0x0221, 37, 34, false}, {0x0141, 38, 35, false}, {0x0111, 39, 36, false}, {0x0085, 40, 37, false},
+	{0x0049, 41, 38, false}, {0x0025, 42, 39, false}, {0x0015, 43, 40, false}, {0x0009, 44, 41, false},
+	{0x0005, 45, 42, false}, {0x0001, 45, 43, false}, {0x5601, 46, 46, false},
+}
+
+// Contexts of the coefficient bit modelling (Annex D)
+const (
+	ctxZeroCoding = 0  // Contexts 0-8 of the significance coding
+	ctxSign       = 9  // Contexts 9-13 of the sign coding
+	ctxRefinement = 14 // Contexts 14-16 of the magnitude refinement
+	ctxRunLength  = 17
+	ctxUniform    = 18
+	numContexts   = 19
+)
+
+// mqContext is the adaptive state of a context: its state index and more probable symbol
+type mqContext struct {
+	state uint8
+	mps   uint8
+}
+
+// contexts holds the states of the contexts of a code-block
+type contexts [numContexts]mqContext
+
+// reset sets the contexts to their initial states (Table D.7)
+func (c *contexts) reset() {
+	*c = contexts{}
+	c[ctxZeroCoding].state = 4
+	c[ctxRunLength].state = 3
+	c[ctxUniform].state = 46
+}
+
+// mqDecoder is the MQ arithmetic decoder of Annex C.3. Reading past the end of the data
+// feeds 1 bits, as after a marker.
+type mqDecoder struct {
+	data []byte
+	pos  int // Position of the current byte B
+	a, c uint32
+	ct   int
+}
+
+// byteAt returns the byte at i, or 0xff past the end of the data
+func (d *mqDecoder) byteAt(i int) uint32 {
+	if i < len(d.data) {
+		return uint32(d.data[i])
+	}
+	return 0xff
+}
+
+// init starts decoding a new codeword segment (INITDEC)
+func (d *mqDecoder) init(data []byte) {
+	d.data, d.pos = data, 0
+	d.c = d.byteAt(0) << 16
+	d.byteIn()
+	d.c <<= 7
+	d.ct -= 7
+	d.a = 0x8000
+}
+
+// byteIn reads the next byte into the C register (BYTEIN), with the bit stuffed after 0xff
+func (d *mqDecoder) byteIn() {
+	if d.byteAt(d.pos) == 0xff {
+		if next := d.byteAt(d.pos + 1); next > 0x8f {
+			d.c += 0xff00
+			d.ct = 8
+		} else {
+			d.pos++
+			d.c += next << 9
+			d.ct = 7
+		}
+		return
+	}
+	d.pos++
+	d.c += d.byteAt(d.pos) << 8
+	d.ct = 8
+}
+
+// decode decodes a binary decision in the context cx (DECODE)
+func (d *mqDecoder) decode(cx *mqContext) uint8 {
+	s := &mqStates[cx.state]
+	d.a -= s.qe
+	var bit uint8
+	if d.c>>16 < s.qe {
+		// LPS_EXCHANGE
+		if d.a < s.qe {
+			bit = cx.mps
+			cx.state = s.nmps
+		} else {
+			bit = 1 - cx.mps
+			if s.switchMPS {
+				cx.mps = 1 - cx.mps
+			}
+			cx.state = s.nlps
+		}
+		d.a = s.qe
+	} else {
+		d.c -= s.qe << 16
+		if d.a&0x8000 != 0 {
+			return cx.mps
+		}
+		// MPS_EXCHANGE
+		if d.a < s.qe {
+			bit = 1 - cx.mps
+			if s.switchMPS {
+				cx.mps = 1 - cx.mps
+			}
+			cx.state = s.nlps
+		} else {
+			bit = cx.mps
+			cx.state = s.nmps
+		}
+	}
+
+	// RENORMD
+	for {
+		if d.ct == 0 {
+			d.byteIn()
+		}
+		d.a <<= 1
+		d.c <<= 1
+		d.ct--
+		if d.a&0x8000 != 0 {
+			break
+		}
+	}
+	return bit
+}
+
+// rawDecoder reads the raw bits of the passes coded without the arithmetic coder in the
+// selective arithmetic coding bypass mode, with the bit stuffed after each 0xff
+type rawDecoder struct {
+	data []byte
+	pos  int
+	c    uint32
+	ct   int
+}
+
+// init starts decoding a new raw codeword segment
+func (d *rawDecoder) init(data []byte) {
+	*d = rawDecoder{data: data}
+}
+
+// decode reads the next bit
+func (d *rawDecoder) decode() uint8 {
+	if d.ct == 0 {
+		next := uint32(0xff)
+		if d.pos < len(d.data) {
+			next = uint32(d.data[d.pos])
+		}
+		switch {
+		case d.c == 0xff && next > 0x8f:
+			d.ct = 8
+		case d.c == 0xff:
+			d.c, d.ct = next, 7
+			d.pos++
+		default:
+			d.c, d.ct = next, 8
+			d.pos++
+		}
+	}
+	d.ct--
+	return uint8(d.c>>d.ct) & 1
+}
+
+// mqEncoder is the MQ arithmetic encoder of Annex C.2
+type mqEncoder struct {
+	out  []byte // Coded bytes, the first being the byte preceding the segment
+	a, c uint32
+	ct   int
+}
+
+// init starts a new codeword segment (INITENC)
+func (e *mqEncoder) init() {
+	e.out = []byte{0}
+	e.a, e.c, e.ct = 0x8000, 0, 12
+}
+
+// encode codes the binary decision bit in the context cx (ENCODE)
+func (e *mqEncoder) encode(cx *mqContext, bit uint8) {
+	s := &mqStates[cx.state]
+	e.a -= s.qe
+	if bit == cx.mps {
+		// CODEMPS
+		if e.a&0x8000 != 0 {
+			e.c += s.qe
+			return
+		}
+		if e.a < s.qe {
+			e.a = s.qe
+		} else {
+			e.c += s.qe
+		}
+		cx.state = s.nmps
+	} else {
+		// CODELPS
+		if e.a < s.qe {
+			e.c += s.qe
+		} else {
+			e.a = s.qe
+		}
+		if s.switchMPS {
+			cx.mps = 1 - cx.mps
+		}
+		cx.state = s.nlps
+	}
+
+	// RENORME
+	for {
+		e.a <<= 1
+		e.c <<= 1
+		e.ct--
+		if e.ct == 0 {
+			e.byteOut()
+		}
+		if e.a&0x8000 != 0 {
+			break
+		}
+	}
+}
+
+// byteOut moves a byte out of the C register (BYTEOUT), propagating a carry into the
+// previous byte and stuffing a bit after 0xff
+func (e *mqEncoder) byteOut() {
+	last := len(e.out) - 1
+	if e.out[last] == 0xff {
+		e.out = append(e.out, byte(e.c>>20))
+		e.c &= 0xfffff
+		e.ct = 7
+		return
+	}
+	if e.c >= 0x8000000 {
+		e.out[last]++
+		if e.out[last] == 0xff {
+			e.c &= 0x7ffffff
+			e.out = append(e.out, byte(e.c>>20))
+			e.c &= 0xfffff
+			e.ct = 7
+			return
+		}
+	}
+	e.out = append(e.out, byte(e.c>>19))
+	e.c &= 0x7ffff
+	e.ct = 8
+}
+
+// flush terminates the codeword segment (FLUSH) and returns its bytes
+func (e *mqEncoder) flush() []byte {
+	// SETBITS
+	temp := e.c + e.a
+	e.c |= 0xffff
+	if e.c >= temp {
+		e.c -= 0x8000
+	}
+
+	e.c <<= uint(e.ct)
+	e.byteOut()
+	e.c <<= uint(e.ct)
+	e.byteOut()
+
+	out := e.out[1:]
+	if len(out) > 0 && out[len(out)-1] == 0xff {
+		out = out[:len(out)-1]
+	}
+	return out
+}
+
+// rawEncoder writes raw bits, stuffing a bit after each 0xff
+type rawEncoder struct {
+	out []byte
+	c   uint8
+	ct  int
+}
+
+// init starts a new raw codeword segment
+func (e *rawEncoder) init() {
+	*e = rawEncoder{ct: 8}
+}
+
+// encode writes a bit
+func (e *rawEncoder) encode(bit uint8) {
+	e.ct--
+	e.c |= bit << uint(e.ct)
+	if e.ct == 0 {
+		e.out = append(e.out, e.c)
+		e.ct = 8
+		if e.c == 0xff {
+			e.ct = 7
+		}
+		e.c = 0
+	}
+}
+
+// flush terminates the segment, padding the last byte with alternating bits. A last
+// 0xff byte is dropped, the decoder reading 1 bits past the end of the segment.
+func (e *rawEncoder) flush() []byte {
+	last := len(e.out) - 1
+	switch {
+	case e.ct == 7 && last >= 0 && e.out[last] == 0xff:
+		e.out = e.out[:last]
+	case e.ct < 8:
+		for bit, n := uint8(0), len(e.out); len(e.out) == n; bit ^= 1 {
+			e.encode(bit)
+		}
+	}
+	return e.out
+}
@@ -0,0 +1,75 @@
+package jpeg2000
+
+import (
+	"math/rand/v2"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMQCoder_RoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	for _, n := range []int{0, 1, 7, 100, 5000} {
+		bits := make([]uint8, n)
+		ctxs := make([]int, n)
+		for i := range bits {
+			// Skewed decisions reach the states of low probabilities
+			if rng.IntN(10) == 0 {
+				bits[i] = 1
+			}
+			ctxs[i] = rng.IntN(numContexts)
+		}
+
+		var enc mqEncoder
+		var cx contexts
+		cx.reset()
+		enc.init()
+		for i, bit := range bits {
+			enc.encode(&cx[ctxs[i]], bit)
+		}
+		data := enc.flush()
+
+		var dec mqDecoder
+		cx.reset()
+		dec.init(data)
+		got := make([]uint8, n)
+		for i := range got {
+			got[i] = dec.decode(&cx[ctxs[i]])
+		}
+		assert.Equal(t, bits, got, "%d decisions", n)
+	}
+}
+
+func TestRawCoder_RoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewPCG(3, 4))
+	for _, tc := range []struct {
+		name string
+		bit  func(i int) uint8
+		n    int
+	}{
+		{"random", func(int) uint8 { return uint8(rng.IntN(2)) }, 1001},
+		{"ones", func(int) uint8 { return 1 }, 8 + 7 + 7},
+		{"ones ending a byte", func(int) uint8 { return 1 }, 8},
+		{"ones ending with a partial byte", func(int) uint8 { return 1 }, 8 + 3},
+	} {
+		var enc rawEncoder
+		enc.init()
+		want := make([]uint8, tc.n)
+		for i := range want {
+			want[i] = tc.bit(i)
+			enc.encode(want[i])
+		}
+		data := enc.flush()
+		for i := 0; i+1 < len(data); i++ {
+			assert.False(t, data[i] == 0xff && data[i+1] > 0x8f, "%s: marker code in the segment", tc.name)
+		}
+
+		var dec rawDecoder
+		dec.init(data)
+		got := make([]uint8, tc.n)
+		for i := range got {
+			got[i] = dec.decode()
+		}
+		assert.Equal(t, want, got, tc.name)
+	}
+}
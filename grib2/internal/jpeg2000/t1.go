@@ -0,0 +1,299 @@
+package jpeg2000
+
+// Coding passes (D.3)
+const (
+	passSignificance = iota
+	passRefinement
+	passCleanup
+)
+
+// passType returns the type of coding pass i of a code-block, the first being a cleanup pass
+func passType(i int) int {
+	return (i + 2) % 3
+}
+
+// Coefficient states
+const (
+	flagSignificant = 1 << iota
+	flagNegative
+	flagVisited // Coded in the significance propagation pass of the current bit-plane
+	flagRefined // Refined at least once
+)
+
+// binaryCoder codes the decisions of a coding pass: an encoder codes bit and returns it,
+// a decoder ignores it and returns the decoded decision
+type binaryCoder interface {
+	code(cx *mqContext, bit uint8) uint8
+}
+
+func (d *mqDecoder) code(cx *mqContext, _ uint8) uint8   { return d.decode(cx) }
+func (d *rawDecoder) code(_ *mqContext, _ uint8) uint8   { return d.decode() }
+func (e *rawEncoder) code(_ *mqContext, bit uint8) uint8 { e.encode(bit); return bit }
+
+func (e *mqEncoder) code(cx *mqContext, bit uint8) uint8 {
+	e.encode(cx, bit)
+	return bit
+}
+
+// codeBlockCoder runs the coding passes over the coefficients of a code-block (Annex D)
+type codeBlockCoder struct {
+	w, h    int
+	orient  int
+	cbStyle uint8
+	flags   []uint8 // States of the coefficients, with a border of one coefficient
+	cx      contexts
+
+	// The magnitudes to encode or, when decoding, the decoded ones doubled with the
+	// reconstruction point halfway in the interval of the bit-planes not decoded
+	mags   []int64
+	decode bool
+}
+
+func newCodeBlockCoder(w, h, orient int, cbStyle uint8) *codeBlockCoder {
+	c := &codeBlockCoder{
+		w:       w,
+		h:       h,
+		orient:  orient,
+		cbStyle: cbStyle,
+		flags:   make([]uint8, (w+2)*(h+2)),
+		mags:    make([]int64, w*h),
+	}
+	c.cx.reset()
+	return c
+}
+
+// index returns the index in flags of the coefficient at x, y
+func (c *codeBlockCoder) index(x, y int) int {
+	return (y+1)*(c.w+2) + x + 1
+}
+
+// significant returns 1 if the coefficient at flag index i is significant
+func (c *codeBlockCoder) significant(i int) int {
+	return int(c.flags[i] & flagSignificant)
+}
+
+// neighbours counts the significant horizontal, vertical and diagonal neighbours of the
+// coefficient at x, y. In the vertically causal mode, the coefficients of the next stripe
+// are not considered.
+func (c *codeBlockCoder) neighbours(x, y int) (h, v, d int) {
+	i, stride := c.index(x, y), c.w+2
+	h = c.significant(i-1) + c.significant(i+1)
+	v = c.significant(i - stride)
+	d = c.significant(i-stride-1) + c.significant(i-stride+1)
+	if c.cbStyle&StyleVCausal == 0 || y%4 != 3 {
+		v += c.significant(i + stride)
+		d += c.significant(i+stride-1) + c.significant(i+stride+1)
+	}
+	return h, v, d
+}
+
+// zeroCodingContext returns the significance coding context of the coefficient (Table D.1)
+func (c *codeBlockCoder) zeroCodingContext(x, y int) int {
+	h, v, d := c.neighbours(x, y)
+	switch c.orient {
+	case bandHL:
+		h, v = v, h
+	case bandHH:
+		hv := h + v
+		switch {
+		case d >= 3:
+			return 8
+		case d == 2 && hv >= 1:
+			return 7
+		case d == 2:
+			return 6
+		case d == 1 && hv >= 2:
+			return 5
+		case d == 1 && hv == 1:
+			return 4
+		case d == 1:
+			return 3
+		default:
+			return min(hv, 2)
+		}
+	}
+	switch {
+	case h == 2:
+		return 8
+	case h == 1 && v >= 1:
+		return 7
+	case h == 1 && d >= 1:
+		return 6
+	case h == 1:
+		return 5
+	case v == 2:
+		return 4
+	case v == 1:
+		return 3
+	default:
+		return min(d, 2)
+	}
+}
+
+// signContribution returns the contribution of the neighbour at flag index i to the sign
+// context: 1 if significant and positive, -1 if significant and negative
+func (c *codeBlockCoder) signContribution(i int) int {
+	switch c.flags[i] & (flagSignificant | flagNegative) {
+	case flagSignificant:
+		return 1
+	case flagSignificant | flagNegative:
+		return -1
+	}
+	return 0
+}
+
+// signContext returns the sign coding context of the coefficient and the bit the decision
+// is XORed with (Table D.3)
+func (c *codeBlockCoder) signContext(x, y int) (int, uint8) {
+	i, stride := c.index(x, y), c.w+2
+	h := c.signContribution(i-1) + c.signContribution(i+1)
+	v := c.signContribution(i - stride)
+	if c.cbStyle&StyleVCausal == 0 || y%4 != 3 {
+		v += c.signContribution(i + stride)
+	}
+	h, v = max(-1, min(h, 1)), max(-1, min(v, 1))
+
+	// The contexts are symmetric, the negated contributions flipping the sign
+	var xor uint8
+	if h < 0 || h == 0 && v < 0 {
+		h, v, xor = -h, -v, 1
+	}
+	return ctxSign + 3*h + v, xor
+}
+
+// refinementContext returns the magnitude refinement context of the coefficient (Table D.4)
+func (c *codeBlockCoder) refinementContext(x, y int) int {
+	if c.flags[c.index(x, y)]&flagRefined != 0 {
+		return ctxRefinement + 2
+	}
+	if h, v, d := c.neighbours(x, y); h+v+d > 0 {
+		return ctxRefinement + 1
+	}
+	return ctxRefinement
+}
+
+// bit returns bit-plane p of the magnitude to encode at x, y
+func (c *codeBlockCoder) bit(x, y, p int) uint8 {
+	return uint8(c.mags[y*c.w+x] >> p & 1)
+}
+
+// codeSignificance codes whether the coefficient at x, y becomes significant in bit-plane
+// p, in the context cx or raw, and if so its sign
+func (c *codeBlockCoder) codeSignificance(coder binaryCoder, cx *mqContext, x, y, p int) {
+	if coder.code(cx, c.bit(x, y, p)) == 1 {
+		c.codeSign(coder, cx == nil, x, y, p)
+	}
+}
+
+// codeSign codes the sign of the coefficient at x, y becoming significant in bit-plane p.
+// The encoder sets the negative flags of the coefficients before the coding passes.
+func (c *codeBlockCoder) codeSign(coder binaryCoder, raw bool, x, y, p int) {
+	i := c.index(x, y)
+	s := c.flags[i] >> 1 & 1 // flagNegative
+	if raw {
+		s = coder.code(nil, s)
+	} else {
+		ctx, xor := c.signContext(x, y)
+		s = coder.code(&c.cx[ctx], s^xor) ^ xor
+	}
+	c.flags[i] |= flagSignificant | s*flagNegative
+	if c.decode {
+		c.mags[y*c.w+x] = 3 << p
+	}
+}
+
+// significancePass runs the significance propagation pass of bit-plane p (D.3.1)
+func (c *codeBlockCoder) significancePass(coder binaryCoder, raw bool, p int) {
+	for y0 := 0; y0 < c.h; y0 += 4 {
+		for x := 0; x < c.w; x++ {
+			for y := y0; y < min(y0+4, c.h); y++ {
+				i := c.index(x, y)
+				if c.flags[i]&flagSignificant != 0 {
+					continue
+				}
+				ctx := c.zeroCodingContext(x, y)
+				if ctx == 0 {
+					continue
+				}
+				if raw {
+					c.codeSignificance(coder, nil, x, y, p)
+				} else {
+					c.codeSignificance(coder, &c.cx[ctx], x, y, p)
+				}
+				c.flags[i] |= flagVisited
+			}
+		}
+	}
+}
+
+// refinementPass runs the magnitude refinement pass of bit-plane p (D.3.3)
+func (c *codeBlockCoder) refinementPass(coder binaryCoder, p int) {
+	for y0 := 0; y0 < c.h; y0 += 4 {
+		for x := 0; x < c.w; x++ {
+			for y := y0; y < min(y0+4, c.h); y++ {
+				i := c.index(x, y)
+				if c.flags[i]&(flagSignificant|flagVisited) != flagSignificant {
+					continue
+				}
+				bit := coder.code(&c.cx[c.refinementContext(x, y)], c.bit(x, y, p))
+				if c.decode {
+					c.mags[y*c.w+x] += (2*int64(bit) - 1) << p
+				}
+				c.flags[i] |= flagRefined
+			}
+		}
+	}
+}
+
+// cleanupPass runs the cleanup pass of bit-plane p (D.3.4)
+func (c *codeBlockCoder) cleanupPass(coder binaryCoder, p int) {
+	uniform := &c.cx[ctxUniform]
+	for y0 := 0; y0 < c.h; y0 += 4 {
+		for x := 0; x < c.w; x++ {
+			y := y0
+			if y0+4 <= c.h && c.runLength(x, y0) {
+				// Position of the first coefficient becoming significant, 4 for none
+				var first uint8
+				for first < 4 && c.bit(x, y0+int(first), p) == 0 {
+					first++
+				}
+				var some uint8
+				if first < 4 {
+					some = 1
+				}
+				if coder.code(&c.cx[ctxRunLength], some) == 0 {
+					continue
+				}
+				first = coder.code(uniform, first>>1&1)<<1 | coder.code(uniform, first&1)
+				y = y0 + int(first)
+				c.codeSign(coder, false, x, y, p)
+				y++
+			}
+			for ; y < min(y0+4, c.h); y++ {
+				if c.flags[c.index(x, y)]&(flagSignificant|flagVisited) != 0 {
+					continue
+				}
+				c.codeSignificance(coder, &c.cx[c.zeroCodingContext(x, y)], x, y, p)
+			}
+		}
+	}
+	for i := range c.flags {
+		c.flags[i] &^= flagVisited
+	}
+	if c.cbStyle&StyleSegSym != 0 {
+		for _, bit := range []uint8{1, 0, 1, 0} {
+			coder.code(uniform, bit)
+		}
+	}
+}
+
+// runLength reports whether the column of four coefficients from x, y is coded in the
+// run-length mode: none significant or visited, and all with insignificant neighbours
+func (c *codeBlockCoder) runLength(x, y int) bool {
+	for k := range 4 {
+		if c.flags[c.index(x, y+k)]&(flagSignificant|flagVisited) != 0 || c.zeroCodingContext(x, y+k) != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,449 @@
+package jpeg2000
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+)
+
+// tagTree is a tag tree over a grid of code-blocks (B.10.2)
+type tagTree struct {
+	nodes  []tagNode
+	leaves int
+}
+
+type tagNode struct {
+	parent int // Index of the parent node, -1 for the root
+	value  int
+	low    int
+	known  bool // Whether the value has been coded
+}
+
+// newTagTree returns a tag tree over w x h leaves, with the values unknown
+func newTagTree(w, h int) *tagTree {
+	t := &tagTree{leaves: w * h}
+	type level struct{ start, w, h int }
+	levels := []level{{0, w, h}}
+	n := w * h
+	for w > 1 || h > 1 {
+		w, h = (w+1)/2, (h+1)/2
+		levels = append(levels, level{n, w, h})
+		n += w * h
+	}
+	t.nodes = make([]tagNode, n)
+	for i := range t.nodes {
+		t.nodes[i] = tagNode{parent: -1, value: 1 << 30}
+	}
+	for l := 0; l+1 < len(levels); l++ {
+		cur, next := levels[l], levels[l+1]
+		for y := range cur.h {
+			for x := range cur.w {
+				t.nodes[cur.start+y*cur.w+x].parent = next.start + y/2*next.w + x/2
+			}
+		}
+	}
+	return t
+}
+
+// setValue sets the value of a leaf for encoding, the parents holding the minimum of
+// their children
+func (t *tagTree) setValue(leaf, value int) {
+	for n := leaf; n >= 0 && t.nodes[n].value > value; n = t.nodes[n].parent {
+		t.nodes[n].value = value
+	}
+}
+
+// path returns the nodes from the root to the leaf
+func (t *tagTree) path(leaf int) []int {
+	var path []int
+	for n := leaf; n >= 0; n = t.nodes[n].parent {
+		path = append(path, n)
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+// decode reads the bits of the leaf up to threshold and reports whether its value is
+// below threshold
+func (t *tagTree) decode(r *headerReader, leaf, threshold int) bool {
+	low := 0
+	var node *tagNode
+	for _, n := range t.path(leaf) {
+		node = &t.nodes[n]
+		if low > node.low {
+			node.low = low
+		} else {
+			low = node.low
+		}
+		for low < threshold && low < node.value {
+			if r.bit() == 1 {
+				node.value = low
+			} else {
+				low++
+			}
+		}
+		node.low = low
+	}
+	return node.value < threshold
+}
+
+// encode writes the bits of the leaf up to threshold
+func (t *tagTree) encode(w *headerWriter, leaf, threshold int) {
+	low := 0
+	for _, n := range t.path(leaf) {
+		node := &t.nodes[n]
+		if low > node.low {
+			node.low = low
+		} else {
+			low = node.low
+		}
+		for low < threshold {
+			if low >= node.value {
+				if !node.known {
+					w.bit(1)
+					node.known = true
+				}
+				break
+			}
+			w.bit(0)
+			low++
+		}
+		node.low = low
+	}
+}
+
+// headerReader reads the bits of a packet header, with a bit stuffed after each 0xff
+type headerReader struct {
+	data []byte
+	pos  int
+	c    byte
+	ct   int
+}
+
+func (r *headerReader) bit() uint8 {
+	if r.ct == 0 {
+		r.ct = 8
+		if r.c == 0xff {
+			r.ct = 7
+		}
+		r.c = 0
+		if r.pos < len(r.data) {
+			r.c = r.data[r.pos]
+		}
+		r.pos++
+	}
+	r.ct--
+	return r.c >> r.ct & 1
+}
+
+func (r *headerReader) bits(n int) int {
+	var v int
+	for range n {
+		v = v<<1 | int(r.bit())
+	}
+	return v
+}
+
+// align ends the header, skipping the byte following a last 0xff
+func (r *headerReader) align() {
+	if r.c == 0xff {
+		r.pos++
+	}
+	r.c, r.ct = 0, 0
+}
+
+// headerWriter writes the bits of a packet header, stuffing a bit after each 0xff
+type headerWriter struct {
+	out []byte
+	c   byte
+	ct  int
+}
+
+func (w *headerWriter) bit(b uint8) {
+	if w.ct == 0 {
+		w.ct = 8
+		if len(w.out) > 0 && w.out[len(w.out)-1] == 0xff {
+			w.ct = 7
+		}
+	}
+	w.ct--
+	w.c |= b << w.ct
+	if w.ct == 0 {
+		w.out = append(w.out, w.c)
+		w.c = 0
+	}
+}
+
+func (w *headerWriter) bits(v, n int) {
+	for i := n - 1; i >= 0; i-- {
+		w.bit(uint8(v >> i & 1))
+	}
+}
+
+// flush ends the header, completing the last byte and following a last 0xff by a zero byte
+func (w *headerWriter) flush() []byte {
+	if w.ct > 0 {
+		w.out = append(w.out, w.c)
+		w.c, w.ct = 0, 0
+	}
+	if len(w.out) > 0 && w.out[len(w.out)-1] == 0xff {
+		w.out = append(w.out, 0)
+	}
+	return w.out
+}
+
+// packet identifies a packet of a tile
+type packet struct {
+	layer, res, precinct int
+}
+
+// componentPacket identifies a packet of a component of a tile
+type componentPacket struct {
+	component int
+	packet
+}
+
+// tilePackets returns the packets of the components of a tile in the progression order of
+// its coding style (B.12). Images of several components are supported in the LRCP, RLCP
+// and CPRL orders, whose positions do not interleave components.
+func tilePackets(s siz, tcs []*tile) ([]componentPacket, error) {
+	var out []componentPacket
+	if len(tcs) == 1 {
+		for _, p := range tcs[0].packets(s.component(0)) {
+			out = append(out, componentPacket{0, p})
+		}
+		return out, nil
+	}
+
+	resolutions := 0
+	for _, tl := range tcs {
+		resolutions = max(resolutions, len(tl.resolutions))
+	}
+	precincts := func(l, r int) {
+		for c, tl := range tcs {
+			if r < len(tl.resolutions) {
+				for k := range tl.resolutions[r].precincts {
+					out = append(out, componentPacket{c, packet{l, r, k}})
+				}
+			}
+		}
+	}
+	cod := tcs[0].cod
+	switch cod.progression {
+	case LRCP:
+		for l := range cod.layers {
+			for r := range resolutions {
+				precincts(l, r)
+			}
+		}
+	case RLCP:
+		for r := range resolutions {
+			for l := range cod.layers {
+				precincts(l, r)
+			}
+		}
+	case CPRL:
+		for c, tl := range tcs {
+			for _, p := range tl.positionPackets(s.component(c)) {
+				out = append(out, componentPacket{c, p})
+			}
+		}
+	default:
+		return nil, fmt.Errorf("jpeg2000: progression order %d of %d components is not supported", cod.progression, len(tcs))
+	}
+	return out, nil
+}
+
+// packets returns the packets of the tile in the progression order of its coding style
+// (B.12). Only the component and position driven orders depend on the tile position.
+func (tl *tile) packets(s siz) []packet {
+	var out []packet
+	layers := tl.cod.layers
+	switch tl.cod.progression {
+	case LRCP:
+		for l := range layers {
+			for r, res := range tl.resolutions {
+				for k := range res.precincts {
+					out = append(out, packet{l, r, k})
+				}
+			}
+		}
+	case RLCP:
+		for r, res := range tl.resolutions {
+			for l := range layers {
+				for k := range res.precincts {
+					out = append(out, packet{l, r, k})
+				}
+			}
+		}
+	case RPCL:
+		// With a single component, the positions visit the precincts of a resolution in
+		// raster order
+		for r, res := range tl.resolutions {
+			for k := range res.precincts {
+				for l := range layers {
+					out = append(out, packet{l, r, k})
+				}
+			}
+		}
+	case PCRL, CPRL:
+		out = tl.positionPackets(s)
+	}
+	return out
+}
+
+// positionPackets returns the packets in the position-component-resolution-layer order,
+// which with a single component is also the component-position-resolution-layer order
+func (tl *tile) positionPackets(s siz) []packet {
+	tx0, ty0, tx1, ty1 := tl.area[0], tl.area[1], tl.area[2], tl.area[3]
+	nl := len(tl.resolutions) - 1
+	dx, dy := 1<<62, 1<<62
+	for r, res := range tl.resolutions {
+		dx = min(dx, s.dx<<(res.ppx+nl-r))
+		dy = min(dy, s.dy<<(res.ppy+nl-r))
+	}
+
+	var out []packet
+	for y := ty0; y < ty1; y += dy - y%dy {
+		for x := tx0; x < tx1; x += dx - x%dx {
+			for r, res := range tl.resolutions {
+				if res.pw == 0 || res.ph == 0 {
+					continue
+				}
+				level := nl - r
+				if !(y%(s.dy<<(res.ppy+level)) == 0 || y == ty0 && (res.y0<<level)%(1<<(res.ppy+level)) != 0) {
+					continue
+				}
+				if !(x%(s.dx<<(res.ppx+level)) == 0 || x == tx0 && (res.x0<<level)%(1<<(res.ppx+level)) != 0) {
+					continue
+				}
+				px := ceilDiv(x, s.dx<<level)>>res.ppx - res.x0>>res.ppx
+				py := ceilDiv(y, s.dy<<level)>>res.ppy - res.y0>>res.ppy
+				for l := range tl.cod.layers {
+					out = append(out, packet{l, r, px + py*res.pw})
+				}
+			}
+		}
+	}
+	return out
+}
+
+// packetLength is the length of the contribution of a code-block to a codeword segment
+type packetLength struct {
+	block  *codeBlock
+	seg    int // Index of the segment
+	length int
+}
+
+// decodePacket reads packet p of the tile from data, returning the number of bytes read
+func (tl *tile) decodePacket(p packet, data []byte, index int) (int, error) {
+	pos := 0
+	if tl.cod.sop && len(data) >= 6 && binary.BigEndian.Uint16(data) == markerSOP {
+		pos = 6
+	}
+
+	r := &headerReader{data: data[pos:]}
+	var lengths []packetLength
+	if r.bit() == 1 {
+		pr := tl.resolutions[p.res].precincts[p.precinct]
+		for bi := range pr {
+			pb := &pr[bi]
+			b := tl.resolutions[p.res].bands[bi]
+			for j := range pb.blocks {
+				cb := &pb.blocks[j]
+				var included bool
+				if !cb.included {
+					included = pb.inclusion.decode(r, j, p.layer+1)
+				} else {
+					included = r.bit() == 1
+				}
+				if !included {
+					continue
+				}
+				if !cb.included {
+					threshold := 1
+					for !pb.zeroPlanes.decode(r, j, threshold) {
+						if threshold++; threshold > b.mb+1 {
+							return 0, fmt.Errorf("jpeg2000: packet %d: more missing bit-planes than the %d of the subband", index, b.mb)
+						}
+					}
+					cb.zeroPlanes = threshold - 1
+					cb.included = true
+				}
+				n := decodePasses(r)
+				for r.bit() == 1 {
+					if cb.lblock++; cb.lblock > 32 {
+						return 0, fmt.Errorf("jpeg2000: packet %d: invalid codeword segment length", index)
+					}
+				}
+				for n > 0 {
+					last := len(cb.segments) - 1
+					if last < 0 || cb.segments[last].passes == cb.segments[last].maxPasses {
+						cb.segments = append(cb.segments, segment{maxPasses: maxPasses(cb.passes, tl.cod.cbStyle)})
+						last++
+					}
+					seg := &cb.segments[last]
+					k := min(n, seg.maxPasses-seg.passes)
+					length := r.bits(cb.lblock + bits.Len(uint(k)) - 1)
+					lengths = append(lengths, packetLength{cb, last, length})
+					seg.passes += k
+					cb.passes += k
+					n -= k
+				}
+			}
+		}
+		if r.pos > len(r.data) {
+			return 0, fmt.Errorf("jpeg2000: packet %d header truncated", index)
+		}
+	}
+	r.align()
+	pos += r.pos
+	if tl.cod.eph && pos+2 <= len(data) && binary.BigEndian.Uint16(data[pos:]) == markerEPH {
+		pos += 2
+	}
+
+	for _, l := range lengths {
+		if pos+l.length > len(data) {
+			return 0, fmt.Errorf("jpeg2000: packet %d body truncated", index)
+		}
+		seg := &l.block.segments[l.seg]
+		seg.data = append(seg.data, data[pos:pos+l.length]...)
+		pos += l.length
+	}
+	return pos, nil
+}
+
+// decodePasses reads the number of new coding passes (Table B.4)
+func decodePasses(r *headerReader) int {
+	switch {
+	case r.bit() == 0:
+		return 1
+	case r.bit() == 0:
+		return 2
+	}
+	if n := r.bits(2); n != 3 {
+		return 3 + n
+	}
+	if n := r.bits(5); n != 31 {
+		return 6 + n
+	}
+	return 37 + r.bits(7)
+}
+
+// encodePasses writes the number of new coding passes, at most 164
+func encodePasses(w *headerWriter, n int) {
+	switch {
+	case n == 1:
+		w.bits(0, 1)
+	case n == 2:
+		w.bits(2, 2)
+	case n <= 5:
+		w.bits(0xc|(n-3), 4)
+	case n <= 36:
+		w.bits(0x1e0|(n-6), 9)
+	default:
+		w.bits(0xff80|(n-37), 16)
+	}
+}
@@ -0,0 +1,234 @@
+package jpeg2000
+
+import (
+	"fmt"
+	"math"
+)
+
+// Orientations of the subbands
+const (
+	bandLL = iota
+	bandHL
+	bandLH
+	bandHH
+)
+
+// tile is a tile of the single component, divided into resolutions, subbands, precincts
+// and code-blocks (Annex B)
+type tile struct {
+	x0, y0, x1, y1 int    // Tile-component area, in component coordinates
+	area           [4]int // Tile area on the reference grid
+	cod            codingDefaults
+	resolutions    []resolution
+	samples        []float64 // Reconstructed samples, before the DC level shift
+}
+
+// resolution is a resolution level of a tile, resolution 0 being the lowest
+type resolution struct {
+	x0, y0, x1, y1 int
+	ppx, ppy       int // Precinct size exponents
+	pw, ph         int // Number of precincts
+	bands          []*band
+	precincts      []precinct
+}
+
+// band is a subband of a resolution
+type band struct {
+	orient         int
+	x0, y0, x1, y1 int
+	mb             int       // Maximum number of magnitude bit-planes Mb
+	step           float64   // Quantization step size, 1 for the reversible filter
+	coeffs         []float64 // Coefficients, row-major
+}
+
+// precinct holds, for each subband of its resolution, the code-blocks of a precinct
+type precinct []precinctBand
+
+// precinctBand is the part of a subband in a precinct
+type precinctBand struct {
+	cbw, cbh   int // Code-blocks across and down
+	blocks     []codeBlock
+	inclusion  *tagTree
+	zeroPlanes *tagTree
+}
+
+// codeBlock is a code-block and the codeword segments collected from the packets
+type codeBlock struct {
+	x0, y0, x1, y1 int
+	included       bool
+	zeroPlanes     int
+	lblock         int
+	passes         int // Coding passes received
+	segments       []segment
+}
+
+// segment is a codeword segment: the coding passes coded by one run of the MQ or raw coder
+type segment struct {
+	data      []byte
+	passes    int
+	maxPasses int
+}
+
+// newTile builds the structure of the tile at index t
+func newTile(s siz, t int, cod codingDefaults, q quantization) (*tile, error) {
+	tileX, tileY := t%s.numXT, t/s.numXT
+	tx0 := max(s.tx0+tileX*s.tw, s.x0)
+	ty0 := max(s.ty0+tileY*s.th, s.y0)
+	tx1 := min(s.tx0+(tileX+1)*s.tw, s.x1)
+	ty1 := min(s.ty0+(tileY+1)*s.th, s.y1)
+	tl := &tile{
+		x0:   ceilDiv(tx0, s.dx),
+		y0:   ceilDiv(ty0, s.dy),
+		x1:   ceilDiv(tx1, s.dx),
+		y1:   ceilDiv(ty1, s.dy),
+		area: [4]int{tx0, ty0, tx1, ty1},
+		cod:  cod,
+	}
+
+	nl := cod.levels
+	for r := 0; r <= nl; r++ {
+		shift := nl - r
+		res := resolution{
+			x0:  ceilShift(tl.x0, shift),
+			y0:  ceilShift(tl.y0, shift),
+			x1:  ceilShift(tl.x1, shift),
+			y1:  ceilShift(tl.y1, shift),
+			ppx: int(cod.precincts[r] & 0x0f),
+			ppy: int(cod.precincts[r] >> 4),
+		}
+		if r > 0 && (res.ppx == 0 || res.ppy == 0) {
+			return nil, fmt.Errorf("jpeg2000: invalid precinct size 2^%dx2^%d at resolution %d", res.ppx, res.ppy, r)
+		}
+		if res.x1 > res.x0 && res.y1 > res.y0 {
+			res.pw = ceilShift(res.x1, res.ppx) - res.x0>>res.ppx
+			res.ph = ceilShift(res.y1, res.ppy) - res.y0>>res.ppy
+		}
+
+		// Subbands, with their decomposition level nb
+		orients := []int{bandLL}
+		nb := nl
+		if r > 0 {
+			orients = []int{bandHL, bandLH, bandHH}
+			nb = nl - r + 1
+		}
+		for _, o := range orients {
+			var xo, yo int // Offsets of the high-pass subbands (B-15)
+			if nb > 0 {
+				xo, yo = o&1<<(nb-1), o>>1<<(nb-1)
+			}
+			b := &band{
+				orient: o,
+				x0:     ceilShift(tl.x0-xo, nb),
+				y0:     ceilShift(tl.y0-yo, nb),
+				x1:     ceilShift(tl.x1-xo, nb),
+				y1:     ceilShift(tl.y1-yo, nb),
+			}
+			i := 0
+			if r > 0 {
+				i = 3*(r-1) + o
+			}
+			if err := b.quantize(q, i, nb, nl, s.precision, cod.reversible); err != nil {
+				return nil, err
+			}
+			b.coeffs = make([]float64, (b.x1-b.x0)*(b.y1-b.y0))
+			res.bands = append(res.bands, b)
+		}
+
+		// Code-block size, bounded by the precinct size in the subbands
+		xcb, ycb := min(cod.xcb, res.ppx), min(cod.ycb, res.ppy)
+		if r > 0 {
+			xcb, ycb = min(cod.xcb, res.ppx-1), min(cod.ycb, res.ppy-1)
+		}
+		res.precincts = make([]precinct, res.pw*res.ph)
+		for k := range res.precincts {
+			res.precincts[k] = res.newPrecinct(k, r > 0, xcb, ycb)
+		}
+		tl.resolutions = append(tl.resolutions, res)
+	}
+	return tl, nil
+}
+
+// quantize sets the number of magnitude bit-planes and the step size of the subband from
+// its quantization parameters (Annex E)
+func (b *band) quantize(q quantization, i, nb, nl, precision int, reversible bool) error {
+	var st stepSize
+	if q.style == 1 {
+		st = q.steps[0]
+		st.exponent -= nl - nb
+	} else {
+		st = q.steps[i]
+	}
+	if st.exponent < 0 || q.guard+st.exponent-1 > 62 {
+		return fmt.Errorf("jpeg2000: invalid quantization exponent %d", st.exponent)
+	}
+	b.mb = q.guard + st.exponent - 1
+	b.step = 1
+	if !reversible {
+		gain := [...]int{bandLL: 0, bandHL: 1, bandLH: 1, bandHH: 2}[b.orient]
+		b.step = math.Ldexp(1+float64(st.mantissa)/2048, precision+gain-st.exponent)
+	}
+	return nil
+}
+
+// newPrecinct builds precinct k of the resolution, with code-blocks of 2^xcb x 2^ycb
+func (res *resolution) newPrecinct(k int, high bool, xcb, ycb int) precinct {
+	// Precinct area in the subbands, halved above the lowest resolution
+	px, py := res.x0>>res.ppx+k%res.pw, res.y0>>res.ppy+k/res.pw
+	ppx, ppy := res.ppx, res.ppy
+	if high {
+		ppx, ppy = ppx-1, ppy-1
+	}
+
+	pr := make(precinct, len(res.bands))
+	for i, b := range res.bands {
+		x0, y0 := max(px<<ppx, b.x0), max(py<<ppy, b.y0)
+		x1, y1 := min((px+1)<<ppx, b.x1), min((py+1)<<ppy, b.y1)
+		if x1 <= x0 || y1 <= y0 {
+			continue
+		}
+		cx0, cy0 := x0>>xcb, y0>>ycb
+		pb := precinctBand{cbw: ceilShift(x1, xcb) - cx0, cbh: ceilShift(y1, ycb) - cy0}
+		pb.blocks = make([]codeBlock, pb.cbw*pb.cbh)
+		for j := range pb.blocks {
+			cx, cy := cx0+j%pb.cbw, cy0+j/pb.cbw
+			pb.blocks[j] = codeBlock{
+				x0:     max(cx<<xcb, x0),
+				y0:     max(cy<<ycb, y0),
+				x1:     min((cx+1)<<xcb, x1),
+				y1:     min((cy+1)<<ycb, y1),
+				lblock: 3,
+			}
+		}
+		pb.inclusion = newTagTree(pb.cbw, pb.cbh)
+		pb.zeroPlanes = newTagTree(pb.cbw, pb.cbh)
+		pr[i] = pb
+	}
+	return pr
+}
+
+// maxPasses returns the number of coding passes of the codeword segment starting with
+// pass i, with the code-block style cbStyle (Table D.9)
+func maxPasses(i int, cbStyle uint8) int {
+	switch {
+	case cbStyle&StyleTermAll != 0:
+		return 1
+	case cbStyle&StyleBypass == 0:
+		return math.MaxInt32
+	case i < 10:
+		return 10 - i
+	case passType(i) == passSignificance:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// rawPass reports whether pass i is coded by the raw coder in the bypass mode
+func rawPass(i int, cbStyle uint8) bool {
+	return cbStyle&StyleBypass != 0 && i >= 10 && passType(i) != passCleanup
+}
+
+// ceilShift returns the ceiling of a/2^s
+func ceilShift(a, s int) int {
+	return (a + 1<<s - 1) >> s
+}
@@ -0,0 +1,46 @@
+package testgrib
+
+import (
+	"encoding/binary"
+
+	"github.com/scorix/grib/grib2/units"
+)
+
+// uint16be encodes v as 2 big-endian octets
+func uint16be(v uint16) []byte {
+	return binary.BigEndian.AppendUint16(nil, v)
+}
+
+// uint32be encodes v as 4 big-endian octets
+func uint32be(v uint32) []byte {
+	return binary.BigEndian.AppendUint32(nil, v)
+}
+
+// signMagnitude8 encodes v as a 1 octet GRIB2 sign-and-magnitude integer
+func signMagnitude8(v int8) []byte {
+	return []byte{units.EncodeSignMagnitudeInt8(v)}
+}
+
+// signMagnitude16 encodes v as a 2 octet GRIB2 sign-and-magnitude integer
+func signMagnitude16(v int16) []byte {
+	return uint16be(units.EncodeSignMagnitudeInt16(v))
+}
+
+// signMagnitude32 encodes v as a 4 octet GRIB2 sign-and-magnitude integer
+func signMagnitude32(v int32) []byte {
+	return uint32be(units.EncodeSignMagnitudeInt32(v))
+}
+
+// concat joins octet slices
+func concat(parts ...[]byte) []byte {
+	var n int
+	for _, part := range parts {
+		n += len(part)
+	}
+
+	out := make([]byte, 0, n)
+	for _, part := range parts {
+		out = append(out, part...)
+	}
+	return out
+}
@@ -0,0 +1,15 @@
+package testgrib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignMagnitude(t *testing.T) {
+	assert.Equal(t, []byte{0x81}, signMagnitude8(-1))
+	assert.Equal(t, []byte{0x7f}, signMagnitude8(127))
+	assert.Equal(t, []byte{0x80, 0x02}, signMagnitude16(-2))
+	assert.Equal(t, []byte{0x00, 0x02}, signMagnitude16(2))
+	assert.Equal(t, []byte{0x80, 0x98, 0x96, 0x80}, signMagnitude32(-10000000))
+}
@@ -0,0 +1,388 @@
+package testgrib
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/png"
+	"math"
+	"math/bits"
+
+	"github.com/scorix/grib/grib2/internal/aec"
+	"github.com/scorix/grib/grib2/internal/bitio"
+	"github.com/scorix/grib/grib2/internal/jpeg2000"
+)
+
+// packSimple packs values with simple packing and builds data representation template 5.0
+func packSimple(s *Spec, values []float64) ([]byte, []byte, error) {
+	q, err := quantize(s, values)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	w := &bitio.Writer{}
+	if q.bits > 0 {
+		for _, x := range q.packed {
+			w.WriteBits(x, q.bits)
+		}
+	}
+	return q.template(s), w.Bytes(), nil
+}
+
+// quantized holds values turned into the packed integers of simple packing
+type quantized struct {
+	ref         float32
+	binaryScale int
+	bits        uint
+	packed      []uint64
+}
+
+// quantize turns values into the packed integers of simple packing, which JPEG 2000
+// packing shares. The reference value is rounded down to float32 so that no packed
+// integer is negative.
+func quantize(s *Spec, values []float64) (quantized, error) {
+	decimalScale := math.Pow(10, float64(s.DecimalScale))
+
+	minimum, maximum := 0.0, 0.0
+	for i, v := range values {
+		v *= decimalScale
+		if i == 0 || v < minimum {
+			minimum = v
+		}
+		if i == 0 || v > maximum {
+			maximum = v
+		}
+	}
+
+	ref := float32(minimum)
+	if float64(ref) > minimum {
+		ref = math.Nextafter32(ref, float32(math.Inf(-1)))
+	}
+	span := maximum - float64(ref)
+
+	nbits := uint(s.BitsPerValue)
+	binaryScale := 0
+	switch {
+	case nbits == 0:
+		nbits = uint(bits.Len64(uint64(math.Round(span))))
+	case span > 0:
+		maxPacked := math.Exp2(float64(nbits)) - 1
+		binaryScale = int(math.Ceil(math.Log2(span / maxPacked)))
+		for math.Round(span/math.Exp2(float64(binaryScale))) > maxPacked {
+			binaryScale++
+		}
+	}
+	if nbits > 64 {
+		return quantized{}, fmt.Errorf("testgrib: %d bits per value exceeds simple packing limit", nbits)
+	}
+
+	q := quantized{ref: ref, binaryScale: binaryScale, bits: nbits, packed: make([]uint64, len(values))}
+	if nbits > 0 {
+		// At 64 bits the largest packed integer wraps around to all ones
+		maxPacked := uint64(1)<<nbits - 1
+		for i, v := range values {
+			x := math.Round((v*decimalScale - float64(ref)) / math.Exp2(float64(binaryScale)))
+			q.packed[i] = maxPacked
+			if x < math.Exp2(float64(nbits)) {
+				q.packed[i] = uint64(max(x, 0))
+			}
+		}
+	}
+	return q, nil
+}
+
+// template builds the octets of data representation template 5.0, which start the
+// templates of the other grid point packings
+func (q quantized) template(s *Spec) []byte {
+	return concat(
+		uint32be(math.Float32bits(q.ref)),
+		signMagnitude16(int16(q.binaryScale)),
+		signMagnitude16(s.DecimalScale),
+		[]byte{
+			uint8(q.bits),
+			0x00, // type of original field values: floating point
+		},
+	)
+}
+
+// packIEEE stores values as IEEE floating point numbers and builds data representation
+// template 5.4: float64 when BitsPerValue is 64, float32 otherwise. The decimal scale is
+// not applied.
+func packIEEE(s *Spec, values []float64) ([]byte, []byte, error) {
+	if s.BitsPerValue == 64 {
+		data := make([]byte, 0, 8*len(values))
+		for _, v := range values {
+			data = binary.BigEndian.AppendUint64(data, math.Float64bits(v))
+		}
+		return []byte{0x02}, data, nil // precision: 64-bit
+	}
+	data := make([]byte, 0, 4*len(values))
+	for _, v := range values {
+		data = binary.BigEndian.AppendUint32(data, math.Float32bits(float32(v)))
+	}
+	return []byte{0x01}, data, nil // precision: 32-bit
+}
+
+// packJPEG2000 packs values with JPEG 2000 packing and builds data representation
+// template 5.40. The packed integers of simple packing are the samples of a lossless
+// codestream, the image of the grid or, with a bit-map, a single row of the values
+// present. A constant field has no codestream.
+func packJPEG2000(s *Spec, values []float64) ([]byte, []byte, error) {
+	q, err := quantize(s, values)
+	if err != nil {
+		return nil, nil, err
+	}
+	template := append(q.template(s),
+		0x00, // type of compression: lossless
+		0xff, // target compression ratio: missing
+	)
+	if q.bits == 0 || len(values) == 0 {
+		return template, nil, nil
+	}
+
+	img := &jpeg2000.Image{Width: int(s.Ni), Height: int(s.Nj), Precision: int(q.bits), Samples: make([]int64, len(values))}
+	if s.Bitmap != nil {
+		img.Width, img.Height = len(values), 1
+	}
+	for i, x := range q.packed {
+		img.Samples[i] = int64(x)
+	}
+	data, err := jpeg2000.Encode(img, jpeg2000.EncodeOptions{Levels: min(5, bits.Len(uint(min(img.Width, img.Height)))-1)})
+	if err != nil {
+		return nil, nil, fmt.Errorf("testgrib: %w", err)
+	}
+	return template, data, nil
+}
+
+// packPNG packs values with PNG packing and builds data representation template 5.41. The
+// packed integers of simple packing, widened to whole octets, are the pixels of a grey,
+// RGB or RGBA image of the grid or, with a bit-map, a single row of the values present. A
+// constant field has no image.
+func packPNG(s *Spec, values []float64) ([]byte, []byte, error) {
+	q, err := quantize(s, values)
+	if err != nil {
+		return nil, nil, err
+	}
+	if q.bits > 32 {
+		return nil, nil, fmt.Errorf("testgrib: %d bits per value exceeds PNG packing limit", q.bits)
+	}
+	if q.bits == 0 || len(values) == 0 {
+		return q.template(s), nil, nil
+	}
+	q.bits = (q.bits + 7) / 8 * 8
+
+	width, height := int(s.Ni), int(s.Nj)
+	if s.Bitmap != nil {
+		width, height = len(values), 1
+	}
+	rect := image.Rect(0, 0, width, height)
+	var img image.Image
+	switch q.bits {
+	case 8:
+		gray := image.NewGray(rect)
+		for i, x := range q.packed {
+			gray.Pix[i] = uint8(x)
+		}
+		img = gray
+	case 16:
+		gray := image.NewGray16(rect)
+		for i, x := range q.packed {
+			binary.BigEndian.PutUint16(gray.Pix[2*i:], uint16(x))
+		}
+		img = gray
+	default:
+		// RGB images are written from opaque RGBA ones
+		rgba := image.NewNRGBA(rect)
+		for i, x := range q.packed {
+			if q.bits == 24 {
+				x = x<<8 | 0xff
+			}
+			binary.BigEndian.PutUint32(rgba.Pix[4*i:], uint32(x))
+		}
+		img = rgba
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, nil, fmt.Errorf("testgrib: %w", err)
+	}
+	return q.template(s), buf.Bytes(), nil
+}
+
+// CCSDS parameters of template 5.42, the defaults of ecCodes
+const (
+	ccsdsFlags     = aec.Flag3Byte | aec.FlagMSB | aec.FlagPreprocess
+	ccsdsBlockSize = 32
+	ccsdsRSI       = 128
+)
+
+// packCCSDS packs values with CCSDS recommended lossless compression and builds data
+// representation template 5.42: the packed integers of simple packing compressed with
+// the Adaptive Entropy Coding. A constant field has no data.
+func packCCSDS(s *Spec, values []float64) ([]byte, []byte, error) {
+	q, err := quantize(s, values)
+	if err != nil {
+		return nil, nil, err
+	}
+	if q.bits > 32 {
+		return nil, nil, fmt.Errorf("testgrib: %d bits per value exceeds CCSDS packing limit", q.bits)
+	}
+	template := append(q.template(s), ccsdsFlags, ccsdsBlockSize)
+	template = append(template, uint16be(ccsdsRSI)...)
+	if q.bits == 0 || len(values) == 0 {
+		return template, nil, nil
+	}
+
+	samples := make([]int64, len(q.packed))
+	for i, x := range q.packed {
+		samples[i] = int64(x)
+	}
+	data, err := aec.Encode(samples, aec.Params{BitsPerSample: int(q.bits), BlockSize: ccsdsBlockSize, RSI: ccsdsRSI, Flags: ccsdsFlags})
+	if err != nil {
+		return nil, nil, fmt.Errorf("testgrib: %w", err)
+	}
+	return template, data, nil
+}
+
+// missingSubstitute is the primary and secondary missing value substitute written to
+// template 5.2, as used by NCEP
+const missingSubstitute = 9.999e20
+
+// packComplex packs values with complex packing and builds data representation template
+// 5.2. Values are rounded to integers after the decimal scaling, with E at 0, and split
+// into groups of GroupSize values with lengths given by the reference alone. With missing
+// value management, the all ones integers of each group width are left for missing values
+// and a group of missing values only has the all ones reference.
+func packComplex(s *Spec, values []float64) ([]byte, []byte, error) {
+	decimalScale := math.Pow(10, float64(s.DecimalScale))
+	groupSize := s.GroupSize
+	if groupSize <= 0 {
+		groupSize = 4
+	}
+
+	management := s.MissingManagement
+	minimum, found := 0.0, false
+	for _, v := range values {
+		if math.IsNaN(v) {
+			management = max(management, 1)
+			continue
+		}
+		if !found || v*decimalScale < minimum {
+			minimum, found = v*decimalScale, true
+		}
+	}
+	if management > 2 {
+		return nil, nil, fmt.Errorf("testgrib: unsupported missing value management %d", management)
+	}
+	ref := float32(minimum)
+	if float64(ref) > minimum {
+		ref = math.Nextafter32(ref, float32(math.Inf(-1)))
+	}
+	reserved := uint64(management) // All ones integers left for missing values
+
+	// Packed integers of each group, missing values being -1
+	type group struct {
+		values        []int64
+		ref, width    uint64
+		allMissing    bool
+		hasMissing    bool
+		maxDifference uint64
+	}
+	var groups []group
+	maxRef := uint64(0)
+	for start := 0; start < len(values); start += groupSize {
+		g := group{allMissing: true}
+		for _, v := range values[start:min(start+groupSize, len(values))] {
+			x := int64(-1)
+			if !math.IsNaN(v) {
+				x = int64(math.Round(v*decimalScale - float64(ref)))
+				if g.allMissing || uint64(x) < g.ref {
+					g.ref = uint64(x)
+				}
+				g.allMissing = false
+			} else {
+				g.hasMissing = true
+			}
+			g.values = append(g.values, x)
+		}
+		for _, x := range g.values {
+			if x >= 0 {
+				g.maxDifference = max(g.maxDifference, uint64(x)-g.ref)
+			}
+		}
+		if !g.allMissing {
+			maxRef = max(maxRef, g.ref)
+		}
+		groups = append(groups, g)
+	}
+
+	refBits := uint(bits.Len64(maxRef + reserved))
+	minWidth, maxWidth := uint64(math.MaxUint64), uint64(0)
+	for i := range groups {
+		g := &groups[i]
+		switch {
+		case g.allMissing:
+			g.ref = 1<<refBits - 1
+		case g.maxDifference > 0 || g.hasMissing:
+			g.width = uint64(bits.Len64(g.maxDifference + reserved))
+		}
+		minWidth, maxWidth = min(minWidth, g.width), max(maxWidth, g.width)
+	}
+	if len(groups) == 0 {
+		minWidth = 0
+	}
+	if refBits > 64 || maxWidth > 64 {
+		return nil, nil, fmt.Errorf("testgrib: packed integers exceed 64 bits")
+	}
+	widthBits := uint(bits.Len64(maxWidth - minWidth))
+
+	w := &bitio.Writer{}
+	for _, g := range groups {
+		w.WriteBits(g.ref, refBits)
+	}
+	w.Align()
+	for _, g := range groups {
+		w.WriteBits(g.width-minWidth, widthBits)
+	}
+	w.Align()
+	// The scaled group lengths take no bits: all but the last group have the reference length
+	for _, g := range groups {
+		if g.allMissing || g.width == 0 {
+			continue
+		}
+		missing := uint64(1)<<g.width - 1
+		for _, x := range g.values {
+			packed := missing
+			if x >= 0 {
+				packed = uint64(x) - g.ref
+			}
+			w.WriteBits(packed, uint(g.width))
+		}
+	}
+
+	lastLength := 0
+	if len(groups) > 0 {
+		lastLength = len(groups[len(groups)-1].values)
+	}
+	template := concat(
+		uint32be(math.Float32bits(ref)),
+		signMagnitude16(0),
+		signMagnitude16(s.DecimalScale),
+		[]byte{
+			uint8(refBits),
+			0x00, // type of original field values: floating point
+			0x01, // group splitting method: general
+			management,
+		},
+		uint32be(math.Float32bits(missingSubstitute)),
+		uint32be(math.Float32bits(missingSubstitute)),
+		uint32be(uint32(len(groups))),
+		[]byte{uint8(minWidth), uint8(widthBits)},
+		uint32be(uint32(groupSize)),
+		[]byte{0x01}, // length increment
+		uint32be(uint32(lastLength)),
+		[]byte{0x00}, // bits used for the scaled group lengths
+	)
+	return template, w.Bytes(), nil
+}
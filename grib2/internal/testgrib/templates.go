@@ -0,0 +1,243 @@
+package testgrib
+
+import (
+	"math"
+
+	"github.com/scorix/grib/grib2/grid"
+	"github.com/scorix/grib/grib2/units"
+)
+
+// encodeLatLonGrid builds grid definition template 3.0 (latitude/longitude)
+func encodeLatLonGrid(s *Spec) []byte {
+	latLast := s.LatFirst - float64(s.Nj-1)*s.Dy
+	if s.ScanningMode&0x40 != 0 {
+		latLast = s.LatFirst + float64(s.Nj-1)*s.Dy
+	}
+	lonLast := s.LonFirst + float64(s.Ni-1)*s.Dx
+	if s.ScanningMode&0x80 != 0 {
+		lonLast = s.LonFirst - float64(s.Ni-1)*s.Dx
+	}
+
+	return concat(
+		encodeEarthShape(s),
+		uint32be(s.Ni),
+		uint32be(s.Nj),
+		uint32be(0),          // basic angle of the initial production domain
+		uint32be(0xffffffff), // subdivisions of basic angle: missing
+		signMagnitude32(units.DegreesToMicro(s.LatFirst)),
+		uint32be(units.LongitudeToMicro(s.LonFirst)),
+		[]byte{0x30}, // resolution and component flags: increments given
+		signMagnitude32(units.DegreesToMicro(latLast)),
+		uint32be(units.LongitudeToMicro(lonLast)),
+		uint32be(uint32(units.DegreesToMicro(s.Dx))),
+		uint32be(uint32(units.DegreesToMicro(s.Dy))),
+		[]byte{s.ScanningMode},
+	)
+}
+
+// encodeRotatedLatLonGrid builds grid definition template 3.1 (rotated latitude/longitude),
+// with the grid points given in rotated coordinates
+func encodeRotatedLatLonGrid(s *Spec) []byte {
+	return concat(
+		encodeLatLonGrid(s),
+		signMagnitude32(units.DegreesToMicro(s.SouthPoleLat)),
+		uint32be(units.LongitudeToMicro(s.SouthPoleLon)),
+		uint32be(math.Float32bits(float32(s.RotationAngle))), // IEEE single precision degrees
+	)
+}
+
+// encodePolarStereoGrid builds grid definition template 3.20 (polar stereographic
+// projection), with grid lengths in metres
+func encodePolarStereoGrid(s *Spec) []byte {
+	return concat(
+		encodeEarthShape(s),
+		uint32be(s.Ni),
+		uint32be(s.Nj),
+		signMagnitude32(units.DegreesToMicro(s.LatFirst)),
+		uint32be(units.LongitudeToMicro(s.LonFirst)),
+		[]byte{0x08}, // resolution and component flags: vector components relative to the grid
+		signMagnitude32(units.DegreesToMicro(s.LaD)),
+		uint32be(units.LongitudeToMicro(s.LoV)),
+		uint32be(uint32(math.Round(s.Dx*1e3))),
+		uint32be(uint32(math.Round(s.Dy*1e3))),
+		[]byte{s.ProjectionCentre, s.ScanningMode},
+	)
+}
+
+// encodeLambertGrid builds grid definition template 3.30 (Lambert conformal), which
+// extends the octets of template 3.20 with the secant latitudes and the southern pole
+func encodeLambertGrid(s *Spec) []byte {
+	return concat(
+		encodePolarStereoGrid(s),
+		signMagnitude32(units.DegreesToMicro(s.Latin1)),
+		signMagnitude32(units.DegreesToMicro(s.Latin2)),
+		signMagnitude32(units.DegreesToMicro(s.SouthPoleLat)),
+		uint32be(units.LongitudeToMicro(s.SouthPoleLon)),
+	)
+}
+
+// encodeGaussianGrid builds grid definition template 3.40 (Gaussian latitude/longitude)
+// for a global grid of 2N parallels from the north, its increment along the parallels
+// missing on reduced grids
+func encodeGaussianGrid(s *Spec) []byte {
+	lats, _ := grid.GaussianLatitudes(int(s.Parallels))
+
+	// The last longitude of a reduced grid is that of its longest parallels
+	di, lonLast := uint32(0xffffffff), s.LonFirst
+	flags := uint8(0x00)
+	if s.PointsPerParallel == nil {
+		dx := 360 / float64(s.Ni)
+		di, lonLast = uint32(units.DegreesToMicro(dx)), s.LonFirst+360-dx
+		flags = 0x20 // i direction increments given
+	}
+	for _, count := range s.PointsPerParallel {
+		lonLast = max(lonLast, s.LonFirst+360-360/float64(count))
+	}
+
+	return concat(
+		encodeEarthShape(s),
+		uint32be(s.Ni),
+		uint32be(s.Nj),
+		uint32be(0),          // basic angle of the initial production domain
+		uint32be(0xffffffff), // subdivisions of basic angle: missing
+		signMagnitude32(units.DegreesToMicro(lats[0])),
+		uint32be(units.LongitudeToMicro(s.LonFirst)),
+		[]byte{flags},
+		signMagnitude32(units.DegreesToMicro(lats[len(lats)-1])),
+		uint32be(units.LongitudeToMicro(lonLast)),
+		uint32be(di),
+		uint32be(s.Parallels),
+		[]byte{s.ScanningMode},
+	)
+}
+
+// encodeSpaceViewGrid builds grid definition template 3.90 (space view perspective or
+// orthographic)
+func encodeSpaceViewGrid(s *Spec) []byte {
+	return concat(
+		encodeEarthShape(s),
+		uint32be(s.Ni),
+		uint32be(s.Nj),
+		signMagnitude32(units.DegreesToMicro(s.SubSatelliteLat)),
+		uint32be(units.LongitudeToMicro(s.SubSatelliteLon)),
+		[]byte{0x00}, // resolution and component flags
+		uint32be(uint32(s.Dx)),
+		uint32be(uint32(s.Dy)),
+		uint32be(s.Ni*500), // x coordinate of the sub-satellite point in grid lengths × 10^3
+		uint32be(s.Nj*500), // y coordinate of the sub-satellite point
+		[]byte{s.ScanningMode},
+		signMagnitude32(units.DegreesToMicro(s.Orientation)),
+		uint32be(s.Altitude),
+		uint32be(s.SectorX),
+		uint32be(s.SectorY),
+	)
+}
+
+// encodeEarthShape builds the shape of the earth octets starting the grid definition
+// templates: a sphere, or an oblate spheroid with axes in metres
+func encodeEarthShape(s *Spec) []byte {
+	if s.MajorAxis != 0 {
+		return concat(
+			[]byte{0x07},                         // shape of the earth: oblate spheroid, axes given
+			[]byte{0x00, 0x00, 0x00, 0x00, 0x00}, // radius
+			[]byte{0x00}, uint32be(s.MajorAxis),  // major axis
+			[]byte{0x00}, uint32be(s.MinorAxis), // minor axis
+		)
+	}
+	return []byte{
+		0x06,                         // shape of the earth: spherical, radius 6,371,229 m
+		0x00, 0x00, 0x00, 0x00, 0x00, // radius
+		0x00, 0x00, 0x00, 0x00, 0x00, // major axis
+		0x00, 0x00, 0x00, 0x00, 0x00, // minor axis
+	}
+}
+
+// encodeAnalysisProduct builds product definition template 4.0 (analysis or forecast at a horizontal level)
+func encodeAnalysisProduct(s *Spec) []byte {
+	return concat(
+		[]byte{
+			s.Category,
+			s.Parameter,
+			0x02, // type of generating process: forecast
+			0x00, // background generating process identifier
+			0x60, // analysis or forecast generating process identifier
+		},
+		uint16be(0),  // hours after reference time of data cutoff
+		[]byte{0x00}, // minutes after reference time of data cutoff
+		[]byte{s.Forecast.Unit},
+		uint32be(s.Forecast.Length),
+		[]byte{s.SurfaceType},
+		signMagnitude8(s.SurfaceScale),
+		uint32be(s.SurfaceValue),
+		encodeSecondSurface(s),
+	)
+}
+
+// encodeSecondSurface builds the type, scale factor and scaled value of the second fixed
+// surface, all missing without a type
+func encodeSecondSurface(s *Spec) []byte {
+	if s.SecondSurfaceType == 0 {
+		return []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+	}
+	return concat([]byte{s.SecondSurfaceType}, signMagnitude8(s.SecondSurfaceScale), uint32be(s.SecondSurfaceValue))
+}
+
+// encodeEnsembleProduct builds product definition template 4.1 (individual ensemble forecast)
+func encodeEnsembleProduct(s *Spec) []byte {
+	return append(encodeAnalysisProduct(s), s.EnsembleType, s.PerturbationNumber, s.EnsembleSize)
+}
+
+// encodeBlockProduct builds product definition templates 4.2-4.6, the octets of template
+// 4.0 followed by the template specific block
+func encodeBlockProduct(s *Spec) []byte {
+	return append(encodeAnalysisProduct(s), s.ProductBlock...)
+}
+
+// encodeBlockStatisticalProduct builds product definition templates 4.9, 4.10 and 4.12-4.14,
+// the octets of template 4.0 and the template specific block, statistically processed
+func encodeBlockStatisticalProduct(s *Spec) []byte {
+	return concat(encodeAnalysisProduct(s), s.ProductBlock, encodeTimeRange(s))
+}
+
+// encodeStatisticalProduct builds product definition template 4.8 (statistically processed over
+// one time range starting at the forecast time)
+func encodeStatisticalProduct(s *Spec) []byte {
+	return append(encodeAnalysisProduct(s), encodeTimeRange(s)...)
+}
+
+// encodeEnsembleStatisticalProduct builds product definition template 4.11 (individual
+// ensemble forecast statistically processed over one time range)
+func encodeEnsembleStatisticalProduct(s *Spec) []byte {
+	return append(encodeEnsembleProduct(s), encodeTimeRange(s)...)
+}
+
+// encodeTimeRange builds the overall time interval and the time range specifications of
+// the statistically processed templates, all with successive forecasts as time increment
+func encodeTimeRange(s *Spec) []byte {
+	end := s.EndTime.UTC()
+	octets := concat(
+		uint16be(uint16(end.Year())),
+		[]byte{uint8(end.Month()), uint8(end.Day()), uint8(end.Hour()), uint8(end.Minute()), uint8(end.Second())},
+		[]byte{uint8(len(s.TimeRanges))},
+		uint32be(0), // number of missing values in the statistical process
+	)
+	for _, r := range s.TimeRanges {
+		octets = append(octets, concat(
+			[]byte{
+				r.Process,
+				0x02, // type of time increment: successive forecasts
+				r.Unit,
+			},
+			uint32be(r.Length),
+			[]byte{0xff}, // unit of time increment: missing
+			uint32be(0),  // time increment: continuous processing
+		)...)
+	}
+	return octets
+}
+
+// encodeSpatialProduct builds product definition template 4.15 (statistically processed over
+// a spatial area)
+func encodeSpatialProduct(s *Spec) []byte {
+	return append(encodeAnalysisProduct(s), s.StatisticalProcess, s.SpatialProcessType, s.SpatialPoints)
+}
@@ -0,0 +1,424 @@
+// Package testgrib fabricates small, valid GRIB2 messages for tests.
+//
+// A Spec selects the grid, product and data representation templates and the
+// field values; Encode turns it into the bytes of a complete message, computing
+// section lengths, sign-and-magnitude fields and packed data along the way.
+package testgrib
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/scorix/grib/grib2/internal/bitio"
+	"github.com/scorix/grib/grib2/template"
+)
+
+// Spec describes a single field GRIB2 message. The zero value encodes a 4x4 regular
+// lat/lon grid starting at 0N 0E with 1 degree increments, holding an analysis of
+// temperature at the surface packed with simple packing.
+type Spec struct {
+	Discipline    uint8     // Discipline (Code Table 0.0)
+	Centre        uint16    // Originating centre, defaults to NCEP (7) when zero
+	ReferenceTime time.Time // Reference time, defaults to 2024-01-01 00:00 UTC when zero
+	LocalUse      []byte    // Local use data, written in a Section 2 when not nil
+
+	// Grid definition
+	GridSource   uint8   // Source of grid definition (Code Table 3.0); with 255, Section 3 has no points nor template
+	MajorAxis    uint32  // Major axis of an oblate spheroid earth in metres (shape 7); zero for a spherical earth
+	MinorAxis    uint32  // Minor axis of an oblate spheroid earth in metres
+	GridTemplate uint16  // Grid definition template number
+	Ni, Nj       uint32  // Number of points along a parallel and a meridian, default 4
+	LatFirst     float64 // Latitude of the first grid point in degrees
+	LonFirst     float64 // Longitude of the first grid point in degrees
+	Dx, Dy       float64 // Grid increments in degrees, or in metres on projected grids, default 1
+	ScanningMode uint8   // Scanning mode flags (Flag Table 3.4)
+
+	// Rotated grids, for template 3.1, and Lambert conformal grids, for template 3.30
+	SouthPoleLat, SouthPoleLon float64 // Latitude and longitude of the southern pole of projection in degrees
+	RotationAngle              float64 // Angle of rotation of projection in degrees
+
+	// Projected grids, for templates 3.20 and 3.30
+	LaD              float64 // Latitude where Dx and Dy are specified, in degrees
+	LoV              float64 // Orientation of the grid: the longitude parallel to the y axis, in degrees
+	ProjectionCentre uint8   // Projection centre flag (Flag Table 3.5)
+	Latin1, Latin2   float64 // Latitudes where the secant cone cuts the sphere, in degrees, for template 3.30
+
+	// Space view grids, for template 3.90, seen from above the centre of the grid; Dx and Dy
+	// are the apparent diameter of the earth in grid lengths
+	SubSatelliteLat, SubSatelliteLon float64 // Latitude and longitude of the sub-satellite point in degrees
+	Orientation                      float64 // Orientation of the grid in degrees
+	Altitude                         uint32  // Altitude of the camera from the earth's centre in equatorial radii × 10^6 (Nr)
+	SectorX, SectorY                 uint32  // Origin of the sector image (Xo, Yo)
+
+	// Global Gaussian grids from LonFirst, for template 3.40
+	Parallels         uint32   // Number of parallels between a pole and the equator; Nj defaults to twice it
+	PointsPerParallel []uint32 // Points along each parallel of a reduced grid, listed after the template; Ni is then missing
+	ListWidth         uint8    // Octets per entry of PointsPerParallel, default 4
+
+	// Product definition
+	ProductTemplate    uint16    // Product definition template number
+	Category           uint8     // Parameter category
+	Parameter          uint8     // Parameter number
+	ForecastHours      uint32    // Forecast time in hours
+	Forecast           *Duration // Forecast time in any unit, in place of ForecastHours
+	SurfaceType        uint8     // Type of first fixed surface, defaults to the ground or water surface (1)
+	SurfaceScale       int8      // Scale factor of first fixed surface
+	SurfaceValue       uint32    // Scaled value of first fixed surface
+	SecondSurfaceType  uint8     // Type of second fixed surface; zero, a reserved type, leaves it missing
+	SecondSurfaceScale int8      // Scale factor of second fixed surface
+	SecondSurfaceValue uint32    // Scaled value of second fixed surface
+	EnsembleType       uint8     // Type of ensemble forecast, for templates 4.1 and 4.11
+	PerturbationNumber uint8     // Perturbation number, for templates 4.1 and 4.11
+	EnsembleSize       uint8     // Number of forecasts in ensemble, for templates 4.1 and 4.11
+	StatisticalProcess uint8     // Type of statistical processing, for templates 4.8, 4.11 and 4.15
+	SpatialProcessType uint8     // Type of spatial processing (Code Table 4.15), for template 4.15
+	SpatialPoints      uint8     // Number of data points used in the spatial processing, for template 4.15
+	RangeHours         uint32    // Length of the statistical time range in hours, for templates 4.8 and 4.11
+
+	// Time ranges of templates 4.8-4.14, in place of the one of StatisticalProcess and RangeHours
+	TimeRanges []TimeRange
+	// End of the overall time interval of templates 4.8-4.14; defaults to the forecast time
+	// plus the first time range
+	EndTime time.Time
+	// Octets following the fixed surfaces in templates 4.2-4.6, 4.9, 4.10 and 4.12-4.14: the
+	// derived forecast, cluster, probability or percentile definition, laid out by the caller
+	ProductBlock []byte
+	// Product definition template octets used as given, in place of those encoded from the
+	// fields above, for templates testgrib does not lay out such as 4.20 (radar product)
+	ProductData []byte
+	Coordinates []float32 // Coordinate values following the product definition template
+
+	// Data representation
+	Packing      uint16 // Data representation template number
+	DecimalScale int16  // Decimal scale factor D applied before packing
+	BitsPerValue uint8  // Bits per packed value; zero selects the fewest bits that keep E at 0
+
+	// Complex packing, for template 5.2; NaN values are packed as primary missing values
+	GroupSize         int   // Values per group, default 4
+	MissingManagement uint8 // Missing value management (Code Table 5.5), 1 when zero and values are NaN
+
+	// Values holds one value per grid point in scanning order; defaults to 0, 1, 2, ...
+	// Values at points masked out by Bitmap are ignored.
+	Values []float64
+	Bitmap []bool // Optional bit-map, one entry per grid point
+}
+
+// Duration is a length of time in a unit of Code Table 4.4
+type Duration struct {
+	Unit   uint8  // Indicator of unit of time range (Code Table 4.4)
+	Length uint32 // Number of units
+}
+
+// TimeRange is a time range specification of the statistically processed product templates
+type TimeRange struct {
+	Process uint8  // Type of statistical processing (Code Table 4.10)
+	Unit    uint8  // Indicator of unit of time range (Code Table 4.4)
+	Length  uint32 // Length of the time range in units
+}
+
+// Sections holds the encoded Sections 1-7 of a message by section number, for tests that
+// assemble messages of their own; Section 2 is nil without local use data
+type Sections [8][]byte
+
+// gridEncoders builds the grid definition template octets (from octet 15 of Section 3)
+var gridEncoders = map[uint16]func(s *Spec) []byte{
+	0:  encodeLatLonGrid,
+	1:  encodeRotatedLatLonGrid,
+	20: encodePolarStereoGrid,
+	30: encodeLambertGrid,
+	40: encodeGaussianGrid,
+	90: encodeSpaceViewGrid,
+}
+
+// productEncoders builds the product definition template octets (from octet 10 of Section 4)
+var productEncoders = map[uint16]func(s *Spec) []byte{
+	0:  encodeAnalysisProduct,
+	1:  encodeEnsembleProduct,
+	2:  encodeBlockProduct,
+	3:  encodeBlockProduct,
+	4:  encodeBlockProduct,
+	5:  encodeBlockProduct,
+	6:  encodeBlockProduct,
+	7:  encodeAnalysisProduct,
+	8:  encodeStatisticalProduct,
+	9:  encodeBlockStatisticalProduct,
+	10: encodeBlockStatisticalProduct,
+	11: encodeEnsembleStatisticalProduct,
+	12: encodeBlockStatisticalProduct,
+	13: encodeBlockStatisticalProduct,
+	14: encodeBlockStatisticalProduct,
+	15: encodeSpatialProduct,
+}
+
+// packers packs the values present in Section 7 and builds the data representation
+// template octets (from octet 12 of Section 5)
+var packers = map[uint16]func(s *Spec, values []float64) (template []byte, data []byte, err error){
+	0:  packSimple,
+	2:  packComplex,
+	4:  packIEEE,
+	40: packJPEG2000,
+	41: packPNG,
+	42: packCCSDS,
+}
+
+// Encode builds the complete GRIB2 message described by spec
+func Encode(spec Spec) ([]byte, error) {
+	sections, err := EncodeSections(spec)
+	if err != nil {
+		return nil, err
+	}
+	return Assemble(spec.Discipline, sections[1:]...), nil
+}
+
+// EncodeSections builds the sections of the message described by spec, which Encode
+// assembles
+func EncodeSections(spec Spec) (Sections, error) {
+	s := spec.withDefaults()
+
+	points := int(s.points())
+	if len(s.Values) != points {
+		return Sections{}, fmt.Errorf("testgrib: %d values for %d grid points", len(s.Values), points)
+	}
+	if s.Bitmap != nil && len(s.Bitmap) != points {
+		return Sections{}, fmt.Errorf("testgrib: bit-map has %d entries for %d grid points", len(s.Bitmap), points)
+	}
+
+	encodeGrid, ok := gridEncoders[s.GridTemplate]
+	if !ok {
+		return Sections{}, fmt.Errorf("testgrib: unsupported grid definition template %d", s.GridTemplate)
+	}
+	productTemplate := s.ProductData
+	if productTemplate == nil {
+		encodeProduct, ok := productEncoders[s.ProductTemplate]
+		if !ok {
+			return Sections{}, fmt.Errorf("testgrib: unsupported product definition template %d", s.ProductTemplate)
+		}
+		productTemplate = encodeProduct(&s)
+	}
+	pack, ok := packers[s.Packing]
+	if !ok {
+		return Sections{}, fmt.Errorf("testgrib: unsupported data representation template %d", s.Packing)
+	}
+
+	present := s.presentValues()
+	dataRepTemplate, data, err := pack(&s, present)
+	if err != nil {
+		return Sections{}, err
+	}
+
+	var sections Sections
+	sections[1] = s.section1()
+	if s.LocalUse != nil {
+		sections[2] = Section(2, s.LocalUse)
+	}
+	sections[3] = s.section3(encodeGrid(&s))
+	sections[4] = s.section4(productTemplate)
+	sections[5] = Section(5, uint32be(uint32(len(present))), uint16be(s.Packing), dataRepTemplate)
+	sections[6] = s.section6()
+	sections[7] = Section(7, data)
+	return sections, nil
+}
+
+// Assemble builds a message of the given discipline from Sections 1-7 in the given order,
+// preceded by Section 0 with the total length and followed by Section 8; nil sections
+// are left out
+func Assemble(discipline uint8, sections ...[]byte) []byte {
+	totalLength := 16 + 4
+	for _, sec := range sections {
+		totalLength += len(sec)
+	}
+
+	msg := make([]byte, 0, totalLength)
+	msg = append(msg, 'G', 'R', 'I', 'B', 0x00, 0x00, discipline, 0x02)
+	msg = binary.BigEndian.AppendUint64(msg, uint64(totalLength))
+	for _, sec := range sections {
+		msg = append(msg, sec...)
+	}
+	return append(msg, '7', '7', '7', '7')
+}
+
+// MustEncode is like Encode but panics on error, for use in test tables
+func MustEncode(spec Spec) []byte {
+	msg, err := Encode(spec)
+	if err != nil {
+		panic(err)
+	}
+	return msg
+}
+
+// MustEncodeSections is like EncodeSections but panics on error
+func MustEncodeSections(spec Spec) Sections {
+	sections, err := EncodeSections(spec)
+	if err != nil {
+		panic(err)
+	}
+	return sections
+}
+
+// withDefaults returns a copy of the spec with zero values replaced by their defaults
+func (s Spec) withDefaults() Spec {
+	if s.Centre == 0 {
+		s.Centre = 7
+	}
+	if s.ReferenceTime.IsZero() {
+		s.ReferenceTime = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	if s.PointsPerParallel != nil {
+		s.Ni = 0xffffffff
+		if s.ListWidth == 0 {
+			s.ListWidth = 4
+		}
+	}
+	if s.Nj == 0 && s.Parallels != 0 {
+		s.Nj = 2 * s.Parallels
+	}
+	if s.Ni == 0 {
+		s.Ni = 4
+	}
+	if s.Nj == 0 {
+		s.Nj = 4
+	}
+	if s.Dx == 0 {
+		s.Dx = 1
+	}
+	if s.Dy == 0 {
+		s.Dy = 1
+	}
+	if s.SurfaceType == 0 {
+		s.SurfaceType = 1
+	}
+	if s.Forecast == nil {
+		s.Forecast = &Duration{Unit: 1, Length: s.ForecastHours}
+	}
+	if s.TimeRanges == nil {
+		s.TimeRanges = []TimeRange{{Process: s.StatisticalProcess, Unit: 1, Length: s.RangeHours}}
+	}
+	if s.EndTime.IsZero() {
+		s.EndTime = s.ReferenceTime
+		if start, ok := template.AddTimeUnits(s.ReferenceTime, s.Forecast.Unit, s.Forecast.Length); ok {
+			if end, ok := template.AddTimeUnits(start, s.TimeRanges[0].Unit, s.TimeRanges[0].Length); ok {
+				s.EndTime = end
+			}
+		}
+	}
+	if s.Values == nil {
+		s.Values = make([]float64, s.points())
+		for i := range s.Values {
+			s.Values[i] = float64(i)
+		}
+	}
+	return s
+}
+
+// points returns the number of grid points: the sum of the points along each parallel on
+// reduced grids, Ni × Nj otherwise
+func (s *Spec) points() uint32 {
+	if s.PointsPerParallel == nil {
+		return s.Ni * s.Nj
+	}
+	var points uint32
+	for _, count := range s.PointsPerParallel {
+		points += count
+	}
+	return points
+}
+
+// presentValues returns the values not masked out by the bit-map
+func (s *Spec) presentValues() []float64 {
+	if s.Bitmap == nil {
+		return s.Values
+	}
+
+	present := make([]float64, 0, len(s.Values))
+	for i, v := range s.Values {
+		if s.Bitmap[i] {
+			present = append(present, v)
+		}
+	}
+	return present
+}
+
+// section3 builds the grid definition section around the grid definition template octets,
+// followed by the list of points along each parallel of reduced grids. Products without a
+// grid have no points and the missing template number.
+func (s *Spec) section3(gridTemplate []byte) []byte {
+	if s.GridSource == 255 {
+		return Section(3, []byte{s.GridSource}, uint32be(0), []byte{0x00, 0x00}, uint16be(0xffff))
+	}
+
+	var list []byte
+	interpretation := uint8(0) // no list
+	if s.PointsPerParallel != nil {
+		interpretation = 1 // points along the full parallels of a global grid
+		for _, count := range s.PointsPerParallel {
+			list = append(list, uint32be(count)[4-s.ListWidth:]...)
+		}
+	}
+	return Section(3, []byte{s.GridSource}, uint32be(s.points()), []byte{s.ListWidth, interpretation}, uint16be(s.GridTemplate), gridTemplate, list)
+}
+
+// section4 builds the product definition section around the product definition template
+// octets, followed by the coordinate values
+func (s *Spec) section4(productTemplate []byte) []byte {
+	coordinates := make([]byte, 0, 4*len(s.Coordinates))
+	for _, v := range s.Coordinates {
+		coordinates = binary.BigEndian.AppendUint32(coordinates, math.Float32bits(v))
+	}
+	return Section(4, uint16be(uint16(len(s.Coordinates))), uint16be(s.ProductTemplate), productTemplate, coordinates)
+}
+
+// section1 builds the 21 octet identification section
+func (s *Spec) section1() []byte {
+	t := s.ReferenceTime.UTC()
+	return Section(1,
+		uint16be(s.Centre),
+		uint16be(0), // sub-centre
+		[]byte{
+			0x02, // master tables version
+			0x01, // local tables version
+			0x01, // significance of reference time: start of forecast
+		},
+		uint16be(uint16(t.Year())),
+		[]byte{
+			uint8(t.Month()), uint8(t.Day()), uint8(t.Hour()), uint8(t.Minute()), uint8(t.Second()),
+			0x00, // production status: operational
+			0x01, // type of data: forecast
+		},
+	)
+}
+
+// section6 builds the bit-map section, with indicator 255 when no bit-map applies
+func (s *Spec) section6() []byte {
+	if s.Bitmap == nil {
+		return Section(6, []byte{0xff})
+	}
+
+	w := &bitio.Writer{}
+	for _, present := range s.Bitmap {
+		bit := uint64(0)
+		if present {
+			bit = 1
+		}
+		w.WriteBits(bit, 1)
+	}
+	return Section(6, []byte{0x00}, w.Bytes())
+}
+
+// Section builds a section of the given number from the concatenated parts of its
+// payload, prefixing them with the section length and number
+func Section(number uint8, parts ...[]byte) []byte {
+	length := 5
+	for _, part := range parts {
+		length += len(part)
+	}
+
+	sec := make([]byte, 0, length)
+	sec = binary.BigEndian.AppendUint32(sec, uint32(length))
+	sec = append(sec, number)
+	for _, part := range parts {
+		sec = append(sec, part...)
+	}
+	return sec
+}
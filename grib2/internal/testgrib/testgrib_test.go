@@ -0,0 +1,289 @@
+package testgrib_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/scorix/grib/grib2/internal/testgrib"
+	"github.com/scorix/grib/grib2/reader"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readMessages reads every message of data and returns them with their flattened fields
+func readMessages(t *testing.T, data []byte) ([]*reader.Message, []reader.FlatMessage) {
+	r := reader.NewReaderAt(bytes.NewReader(data))
+
+	var messages []*reader.Message
+	err := r.EachMessage(func(_ int, info reader.MessageInfo) bool {
+		msg, err := r.ReadMessage(info)
+		require.NoError(t, err)
+		messages = append(messages, msg)
+		return true
+	})
+	require.NoError(t, err)
+
+	var fields []reader.FlatMessage
+	require.NoError(t, r.EachFlatMessage(func(_ int, msg reader.FlatMessage) bool {
+		fields = append(fields, msg)
+		return true
+	}))
+	return messages, fields
+}
+
+func TestEncode(t *testing.T) {
+	nan := math.NaN()
+	tests := []struct {
+		name string
+		spec testgrib.Spec
+		want []float64 // Decoded values, nil to skip decoding
+	}{
+		{
+			name: "defaults",
+			spec: testgrib.Spec{},
+			want: []float64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15},
+		},
+		{
+			name: "ensemble member",
+			spec: testgrib.Spec{ProductTemplate: 1, EnsembleType: 3, PerturbationNumber: 5, EnsembleSize: 30},
+		},
+		{
+			name: "accumulation",
+			spec: testgrib.Spec{ProductTemplate: 8, Category: 1, Parameter: 8, ForecastHours: 6, RangeHours: 6, StatisticalProcess: 1},
+		},
+		{
+			name: "ensemble accumulation",
+			spec: testgrib.Spec{ProductTemplate: 11, EnsembleType: 3, PerturbationNumber: 5, EnsembleSize: 30, RangeHours: 6, StatisticalProcess: 1},
+		},
+		{
+			name: "spatial maximum",
+			spec: testgrib.Spec{ProductTemplate: 15, StatisticalProcess: 2, SpatialPoints: 25},
+		},
+		{
+			name: "percentile",
+			spec: testgrib.Spec{ProductTemplate: 6, ProductBlock: []byte{90}},
+		},
+		{
+			name: "probability over two time ranges",
+			spec: testgrib.Spec{
+				ProductTemplate: 9, ProductBlock: make([]byte, 13), ForecastHours: 6,
+				TimeRanges: []testgrib.TimeRange{{Process: 1, Unit: 1, Length: 18}, {Process: 0, Unit: 0, Length: 60}},
+			},
+		},
+		{
+			name: "minute forecast of a layer",
+			spec: testgrib.Spec{Forecast: &testgrib.Duration{Unit: 0, Length: 90}, SurfaceType: 106, SecondSurfaceType: 106, SecondSurfaceScale: 1, SecondSurfaceValue: 1},
+		},
+		{
+			name: "hybrid level coordinates",
+			spec: testgrib.Spec{SurfaceType: 105, SurfaceValue: 1, Coordinates: []float32{0, 0.5, 1, 0.25}},
+		},
+		{
+			name: "local use section",
+			spec: testgrib.Spec{LocalUse: []byte{1, 2, 3}},
+		},
+		{
+			name: "rotated grid",
+			spec: testgrib.Spec{GridTemplate: 1, Ni: 3, Nj: 2, LatFirst: -6.3, LonFirst: 352.5, Dx: 0.02, Dy: 0.02, SouthPoleLat: -40, SouthPoleLon: 10, RotationAngle: -12.5},
+			want: []float64{0, 1, 2, 3, 4, 5},
+		},
+		{
+			name: "polar stereographic grid",
+			spec: testgrib.Spec{GridTemplate: 20, Ni: 3, Nj: 2, LatFirst: 30, LonFirst: 187, LaD: 60, LoV: 225, Dx: 11250, Dy: 11250, ScanningMode: 0x40},
+			want: []float64{0, 1, 2, 3, 4, 5},
+		},
+		{
+			name: "Lambert conformal grid",
+			spec: testgrib.Spec{GridTemplate: 30, Ni: 3, Nj: 2, LatFirst: 21.138123, LonFirst: 237.280472, LaD: 38.5, LoV: 262.5, Dx: 3000, Dy: 3000, ScanningMode: 0x40, Latin1: 38.5, Latin2: 38.5},
+			want: []float64{0, 1, 2, 3, 4, 5},
+		},
+		{
+			name: "Gaussian grid",
+			spec: testgrib.Spec{GridTemplate: 40, Parallels: 1, Ni: 3},
+			want: []float64{0, 1, 2, 3, 4, 5},
+		},
+		{
+			name: "reduced Gaussian grid",
+			spec: testgrib.Spec{GridTemplate: 40, Parallels: 1, PointsPerParallel: []uint32{3, 4}, ListWidth: 2},
+			want: []float64{0, 1, 2, 3, 4, 5, 6},
+		},
+		{
+			name: "space view grid",
+			spec: testgrib.Spec{GridTemplate: 90, Ni: 3, Nj: 2, MajorAxis: 6378169, MinorAxis: 6356584, Dx: 3622, Dy: 3622, Altitude: 6610700},
+			want: []float64{0, 1, 2, 3, 4, 5},
+		},
+		{
+			name: "southern hemisphere grid",
+			spec: testgrib.Spec{Ni: 3, Nj: 2, LatFirst: -10, LonFirst: -20, Dx: 0.5, Dy: 0.5, ScanningMode: 0x40},
+			want: []float64{0, 1, 2, 3, 4, 5},
+		},
+		{
+			name: "decimal scale",
+			spec: testgrib.Spec{Ni: 2, Nj: 2, DecimalScale: 1, Values: []float64{273.1, 273.2, 280.5, 290}},
+			want: []float64{273.1, 273.2, 280.5, 290},
+		},
+		{
+			name: "fixed bit width",
+			spec: testgrib.Spec{Ni: 2, Nj: 2, BitsPerValue: 4, Values: []float64{0, 96, 192, 288}}, // E = 5
+			want: []float64{0, 96, 192, 288},
+		},
+		{
+			name: "constant field",
+			spec: testgrib.Spec{Ni: 2, Nj: 2, Values: []float64{5, 5, 5, 5}},
+			want: []float64{5, 5, 5, 5},
+		},
+		{
+			name: "bit-map",
+			spec: testgrib.Spec{Ni: 3, Nj: 3, Bitmap: []bool{true, false, true, false, true, false, true, false, true}},
+			want: []float64{0, 2, 4, 6, 8},
+		},
+		{
+			name: "complex packing",
+			spec: testgrib.Spec{Packing: 2, Ni: 5, Nj: 2, DecimalScale: 1, Values: []float64{-3.2, -3.1, 0, 12.7, 12.7, 12.7, 12.7, 12.7, 1e3, 1e3}},
+			want: []float64{-3.2, -3.1, 0, 12.7, 12.7, 12.7, 12.7, 12.7, 1e3, 1e3},
+		},
+		{
+			name: "complex packing with missing values",
+			spec: testgrib.Spec{Packing: 2, GroupSize: 3, Ni: 3, Nj: 3, Values: []float64{1, nan, 3, nan, nan, nan, 7, 7, 7}},
+			want: []float64{1, nan, 3, nan, nan, nan, 7, 7, 7},
+		},
+		{
+			name: "complex packing with secondary missing values",
+			spec: testgrib.Spec{Packing: 2, MissingManagement: 2, Ni: 2, Nj: 2, Values: []float64{0, 1, 2, nan}},
+			want: []float64{0, 1, 2, nan},
+		},
+		{
+			name: "IEEE floating point",
+			spec: testgrib.Spec{Packing: 4, Ni: 2, Nj: 2, Values: []float64{-1.5, 0, 0x1p100, nan}},
+			want: []float64{-1.5, 0, 0x1p100, nan},
+		},
+		{
+			name: "JPEG 2000 packing",
+			spec: testgrib.Spec{Packing: 40, Ni: 7, Nj: 5, DecimalScale: 2},
+			want: []float64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31, 32, 33, 34},
+		},
+		{
+			name: "JPEG 2000 packing with a bit-map",
+			spec: testgrib.Spec{Packing: 40, Ni: 3, Nj: 2, Values: []float64{5, 0, 6, 0, 9, 1}, Bitmap: []bool{true, false, true, false, true, true}},
+			want: []float64{5, 6, 9, 1},
+		},
+		{
+			name: "JPEG 2000 packed constant field",
+			spec: testgrib.Spec{Packing: 40, Ni: 2, Nj: 2, Values: []float64{3, 3, 3, 3}},
+			want: []float64{3, 3, 3, 3},
+		},
+		{
+			name: "PNG packing",
+			spec: testgrib.Spec{Packing: 41, Ni: 3, Nj: 2, DecimalScale: 1, Values: []float64{-3.2, 0, 12.7, 99.9, 1e3, 1e3}},
+			want: []float64{-3.2, 0, 12.7, 99.9, 1e3, 1e3},
+		},
+		{
+			name: "PNG packing with a bit-map",
+			spec: testgrib.Spec{Packing: 41, Ni: 2, Nj: 2, BitsPerValue: 20, Values: []float64{0, 0, 7, 7e5}, Bitmap: []bool{false, true, true, true}},
+			want: []float64{0, 7, 7e5},
+		},
+		{
+			name: "CCSDS packing",
+			spec: testgrib.Spec{Packing: 42, Ni: 3, Nj: 2, DecimalScale: 1, Values: []float64{-3.2, 0, 12.7, 99.9, 1e3, 1e3}},
+			want: []float64{-3.2, 0, 12.7, 99.9, 1e3, 1e3},
+		},
+		{
+			name: "CCSDS packed constant field",
+			spec: testgrib.Spec{Packing: 42, Ni: 2, Nj: 2, Values: []float64{-1, -1, -1, -1}},
+			want: []float64{-1, -1, -1, -1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := testgrib.Encode(tt.spec)
+			require.NoError(t, err)
+			assert.Equal(t, uint64(len(data)), binary.BigEndian.Uint64(data[8:16]))
+
+			messages, fields := readMessages(t, data)
+			require.Len(t, messages, 1)
+			require.NoError(t, messages[0].Validate())
+
+			require.Len(t, fields, 1)
+			assert.Equal(t, uint16(tt.spec.ProductTemplate), fields[0].Product.TemplateNumber)
+
+			if tt.want != nil {
+				values, err := fields[0].DecodeData()
+				require.NoError(t, err)
+				assert.InDeltaSlice(t, tt.want, values, 1e-4)
+			}
+		})
+	}
+}
+
+func TestEncodeConcatenated(t *testing.T) {
+	var data []byte
+	for parameter := range uint8(3) {
+		data = append(data, testgrib.MustEncode(testgrib.Spec{Parameter: parameter})...)
+	}
+
+	messages, fields := readMessages(t, data)
+	require.Len(t, messages, 3)
+	for i, field := range fields {
+		assert.Equal(t, uint8(i), field.Product.Parameter)
+	}
+}
+
+func TestEncodeSections(t *testing.T) {
+	spec := testgrib.Spec{ProductTemplate: 8, LocalUse: []byte{1, 2}, Bitmap: []bool{true, false, true, true}, Ni: 2, Nj: 2}
+	sections := testgrib.MustEncodeSections(spec)
+	for number, sec := range sections[1:] {
+		require.NotEmpty(t, sec, "section %d", number+1)
+		assert.Equal(t, uint32(len(sec)), binary.BigEndian.Uint32(sec), "section %d", number+1)
+		assert.Equal(t, byte(number+1), sec[4], "section %d", number+1)
+	}
+	assert.Equal(t, testgrib.MustEncode(spec), testgrib.Assemble(0, sections[1:]...))
+
+	// Without local use data there is no Section 2
+	assert.Nil(t, testgrib.MustEncodeSections(testgrib.Spec{})[2])
+}
+
+func TestEncodeErrors(t *testing.T) {
+	_, err := testgrib.Encode(testgrib.Spec{GridTemplate: 999})
+	assert.ErrorContains(t, err, "unsupported grid definition template 999")
+
+	_, err = testgrib.Encode(testgrib.Spec{ProductTemplate: 999})
+	assert.ErrorContains(t, err, "unsupported product definition template 999")
+
+	_, err = testgrib.Encode(testgrib.Spec{Packing: 999})
+	assert.ErrorContains(t, err, "unsupported data representation template 999")
+
+	_, err = testgrib.Encode(testgrib.Spec{Values: []float64{1, 2}})
+	assert.ErrorContains(t, err, "2 values for 16 grid points")
+
+	_, err = testgrib.Encode(testgrib.Spec{Bitmap: []bool{true}})
+	assert.ErrorContains(t, err, "bit-map has 1 entries")
+}
+
+func TestValidateDetectsInconsistencies(t *testing.T) {
+	data := testgrib.MustEncode(testgrib.Spec{})
+
+	// Section 5 starts after Sections 0 (16), 1 (21), 3 (72) and 4 (34); claim one extra packed value
+	numberOfValues := data[16+21+72+34+5:]
+	binary.BigEndian.PutUint32(numberOfValues, binary.BigEndian.Uint32(numberOfValues)+1)
+
+	messages, _ := readMessages(t, data)
+	require.Len(t, messages, 1)
+	assert.ErrorContains(t, messages[0].Validate(), "packs 17 values, expected 16")
+}
+
+func TestValidateGridWithoutPoints(t *testing.T) {
+	// A product without a grid packs values that no grid point describes
+	messages, _ := readMessages(t, testgrib.MustEncode(testgrib.Spec{GridSource: 255}))
+	require.Len(t, messages, 1)
+	assert.NoError(t, messages[0].Validate())
+
+	// Section 3 starts after Sections 0 (16) and 1 (21); a template grid must have points
+	data := testgrib.MustEncode(testgrib.Spec{})
+	binary.BigEndian.PutUint32(data[16+21+6:], 0)
+	messages, _ = readMessages(t, data)
+	require.Len(t, messages, 1)
+	assert.ErrorContains(t, messages[0].Validate(), "grid block 0.0 has no data points")
+}
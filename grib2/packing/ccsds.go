@@ -0,0 +1,41 @@
+package packing
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/scorix/grib/grib2/internal/aec"
+	"github.com/scorix/grib/grib2/template"
+)
+
+// DecodeCCSDSRaw unpacks the n packed integers X of a field with CCSDS recommended
+// lossless compression (template 5.42) along with its scaling parameters. Section 7 holds
+// the packed integers compressed with the Adaptive Entropy Coding of CCSDS 121.0-B, as
+// done by libaec with the compression options mask, block size and reference sample
+// interval of the template. A field packed with 0 bits is constant and has no data: every
+// X is 0.
+func DecodeCCSDSRaw(dataRep *template.DataRepTemplate, data []byte, n int) (raw []int64, ref float64, E, D int, err error) {
+	ccsds := dataRep.CCSDS
+	if ccsds == nil {
+		return nil, 0, 0, 0, errors.New("packing: CCSDS parameters are missing")
+	}
+	if n < 0 {
+		return nil, 0, 0, 0, fmt.Errorf("packing: invalid number of values %d", n)
+	}
+
+	ref, E, D = dataRep.ReferenceValue, int(dataRep.BinaryScaleFactor), int(dataRep.DecimalScaleFactor)
+	if dataRep.NumberOfBitsUsedForData == 0 || n == 0 {
+		return make([]int64, n), ref, E, D, nil
+	}
+
+	raw, err = aec.Decode(data, n, aec.Params{
+		BitsPerSample: int(dataRep.NumberOfBitsUsedForData),
+		BlockSize:     int(ccsds.BlockSize),
+		RSI:           int(ccsds.RSILength),
+		Flags:         ccsds.CCSDSFlags,
+	})
+	if err != nil {
+		return nil, 0, 0, 0, fmt.Errorf("packing: %w", err)
+	}
+	return raw, ref, E, D, nil
+}
@@ -0,0 +1,64 @@
+package packing_test
+
+import (
+	"testing"
+
+	"github.com/scorix/grib/grib2/internal/aec"
+	"github.com/scorix/grib/grib2/packing"
+	"github.com/scorix/grib/grib2/template"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeCCSDS(t *testing.T) {
+	raw := make([]int64, 300)
+	for i := range raw {
+		raw[i] = int64(1000 + i*i%700)
+	}
+	ccsds := &template.CCSDSPackingInfo{CCSDSFlags: aec.FlagPreprocess | aec.FlagMSB, BlockSize: 16, RSILength: 8}
+	data, err := aec.Encode(raw, aec.Params{BitsPerSample: 11, BlockSize: 16, RSI: 8, Flags: ccsds.CCSDSFlags})
+	require.NoError(t, err)
+
+	dataRep := &template.DataRepTemplate{
+		TemplateNumber:          42,
+		ReferenceValue:          -5,
+		BinaryScaleFactor:       1,
+		DecimalScaleFactor:      2,
+		NumberOfBitsUsedForData: 11,
+		CCSDS:                   ccsds,
+	}
+	got, ref, E, D, err := packing.DecodeRaw(dataRep, data, len(raw))
+	require.NoError(t, err)
+	assert.Equal(t, raw, got)
+	assert.Equal(t, -5.0, ref)
+	assert.Equal(t, 1, E)
+	assert.Equal(t, 2, D)
+
+	values, err := packing.Decode(dataRep, data, len(raw))
+	require.NoError(t, err)
+	assert.InDelta(t, (-5+2*float64(raw[7]))/100, values[7], 1e-12)
+
+	_, _, _, _, err = packing.DecodeCCSDSRaw(dataRep, data[:len(data)/2], len(raw))
+	assert.ErrorIs(t, err, aec.ErrTruncated)
+}
+
+func TestDecodeCCSDS_Constant(t *testing.T) {
+	// A constant field has no data
+	dataRep := &template.DataRepTemplate{TemplateNumber: 42, ReferenceValue: 2, CCSDS: &template.CCSDSPackingInfo{}}
+	values, err := packing.Decode(dataRep, nil, 3)
+	require.NoError(t, err)
+	assert.Equal(t, []float64{2, 2, 2}, values)
+}
+
+func TestDecodeCCSDSRaw_Errors(t *testing.T) {
+	_, _, _, _, err := packing.DecodeCCSDSRaw(&template.DataRepTemplate{TemplateNumber: 42}, nil, 3)
+	assert.EqualError(t, err, "packing: CCSDS parameters are missing")
+
+	dataRep := &template.DataRepTemplate{
+		TemplateNumber:          42,
+		NumberOfBitsUsedForData: 8,
+		CCSDS:                   &template.CCSDSPackingInfo{BlockSize: 12, RSILength: 128},
+	}
+	_, _, _, _, err = packing.DecodeCCSDSRaw(dataRep, []byte{0xff}, 3)
+	assert.EqualError(t, err, "packing: aec: invalid block size 12")
+}
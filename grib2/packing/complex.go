@@ -0,0 +1,226 @@
+package packing
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/scorix/grib/grib2/internal/bitio"
+	"github.com/scorix/grib/grib2/template"
+)
+
+// Missing value management of complex packing (Code Table 5.5)
+const (
+	MissingNone      = 0 // No explicit missing values
+	MissingPrimary   = 1 // Primary missing values
+	MissingSecondary = 2 // Primary and secondary missing values
+)
+
+// DecodeComplexRaw unpacks the n packed integers X of a field with complex packing
+// (template 5.2) along with its scaling parameters. The values are split into groups,
+// each with a reference added to its packed integers and its own width; with missing
+// value management, the largest integers of a group's width, all ones, stand for missing
+// values, which are returned as MissingRaw.
+func DecodeComplexRaw(dataRep *template.DataRepTemplate, data []byte, n int) (raw []int64, ref float64, E, D int, err error) {
+	complex := dataRep.Complex
+	if complex == nil {
+		return nil, 0, 0, 0, errors.New("packing: complex packing parameters are missing")
+	}
+	if n < 0 {
+		return nil, 0, 0, 0, fmt.Errorf("packing: invalid number of values %d", n)
+	}
+
+	ref, E, D = dataRep.ReferenceValue, int(dataRep.BinaryScaleFactor), int(dataRep.DecimalScaleFactor)
+	raw, err = unpackGroups(complex, uint(dataRep.NumberOfBitsUsedForData), bitio.NewReader(data), n)
+	if err != nil {
+		return nil, 0, 0, 0, err
+	}
+	return raw, ref, E, D, nil
+}
+
+// DecodeComplexSpatialRaw unpacks the n packed integers X of a field with complex packing
+// and spatial differencing (template 5.3) along with its scaling parameters. Section 7
+// starts with the extra descriptors, the first values of the field and the overall minimum
+// of the differences, followed by the groups of complex packing, which hold the first or
+// second order differences less that minimum. Missing values are MissingRaw and take no
+// part in the differencing.
+func DecodeComplexSpatialRaw(dataRep *template.DataRepTemplate, data []byte, n int) (raw []int64, ref float64, E, D int, err error) {
+	complex := dataRep.Complex
+	if complex == nil || complex.OrderOfSpatialDifferencing == nil || complex.NumberOfOctetsExtraDescriptors == nil {
+		return nil, 0, 0, 0, errors.New("packing: spatial differencing parameters are missing")
+	}
+	if n < 0 {
+		return nil, 0, 0, 0, fmt.Errorf("packing: invalid number of values %d", n)
+	}
+	order, octets := int(*complex.OrderOfSpatialDifferencing), uint(*complex.NumberOfOctetsExtraDescriptors)
+	if order != 1 && order != 2 {
+		return nil, 0, 0, 0, fmt.Errorf("packing: unsupported order of spatial differencing %d", order)
+	}
+	if octets > 8 {
+		return nil, 0, 0, 0, fmt.Errorf("packing: %d octets per extra descriptor exceeds the limit of 8", octets)
+	}
+
+	// First values of the field, then the overall minimum of the differences
+	r := bitio.NewReader(data)
+	descriptors := make([]int64, order+1)
+	for i := range descriptors {
+		v, err := r.ReadBits(8 * octets)
+		if err != nil {
+			return nil, 0, 0, 0, fmt.Errorf("packing: extra descriptors: %w", err)
+		}
+		descriptors[i] = signMagnitude(v, 8*octets)
+	}
+	first, minimum := descriptors[:order], descriptors[order]
+
+	ref, E, D = dataRep.ReferenceValue, int(dataRep.BinaryScaleFactor), int(dataRep.DecimalScaleFactor)
+	raw, err = unpackGroups(complex, uint(dataRep.NumberOfBitsUsedForData), r, n)
+	if err != nil {
+		return nil, 0, 0, 0, err
+	}
+
+	// Undo the differencing over the values present, in order
+	var previous [2]int64 // Last two values, the latest first
+	k := 0
+	for i, x := range raw {
+		if x == MissingRaw {
+			continue
+		}
+		switch {
+		case k < order:
+			x = first[k]
+		case order == 1:
+			x += minimum + previous[0]
+		default:
+			x += minimum + 2*previous[0] - previous[1]
+		}
+		raw[i] = x
+		previous[0], previous[1] = x, previous[0]
+		k++
+	}
+	return raw, ref, E, D, nil
+}
+
+// signMagnitude converts a sign and magnitude integer of the given width, the sign being
+// its leftmost bit
+func signMagnitude(v uint64, bits uint) int64 {
+	if bits == 0 {
+		return 0
+	}
+	sign := uint64(1) << (bits - 1)
+	if v&sign != 0 {
+		return -int64(v &^ sign)
+	}
+	return int64(v)
+}
+
+// unpackGroups reads the group references, widths and lengths of a complex packed field
+// from r, each list padded to a whole octet, then the n packed integers of the groups
+func unpackGroups(c *template.ComplexPackingInfo, refBits uint, r *bitio.Reader, n int) ([]int64, error) {
+	ng := uint64(c.NumberOfGroupsOfDataValues)
+	widthBits, lengthBits := uint(c.NumberOfBitsUsedForGroupWidths), uint(c.NumberOfBitsUsedForGroupLengths)
+	for _, bits := range []uint{refBits, widthBits, lengthBits} {
+		if bits > maxBitsPerValue {
+			return nil, fmt.Errorf("packing: %d bits per group parameter exceeds the limit of %d", bits, maxBitsPerValue)
+		}
+	}
+	if ng > uint64(n) || n > 0 && ng == 0 {
+		return nil, fmt.Errorf("packing: %d groups for %d values", ng, n)
+	}
+	if c.MissingValueManagement > MissingSecondary {
+		return nil, fmt.Errorf("packing: unsupported missing value management %d", c.MissingValueManagement)
+	}
+
+	readList := func(name string, bits uint) ([]uint64, error) {
+		list := make([]uint64, ng)
+		for i := range list {
+			v, err := r.ReadBits(bits)
+			if err != nil {
+				return nil, fmt.Errorf("packing: group %s: %w", name, err)
+			}
+			list[i] = v
+		}
+		r.Align()
+		return list, nil
+	}
+	refs, err := readList("references", refBits)
+	if err != nil {
+		return nil, err
+	}
+	widths, err := readList("widths", widthBits)
+	if err != nil {
+		return nil, err
+	}
+	lengths, err := readList("lengths", lengthBits)
+	if err != nil {
+		return nil, err
+	}
+
+	total := uint64(0)
+	for i := range lengths {
+		widths[i] += uint64(c.ReferenceForGroupWidths)
+		if widths[i] > maxBitsPerValue {
+			return nil, fmt.Errorf("packing: group %d width %d exceeds the limit of %d", i, widths[i], maxBitsPerValue)
+		}
+		if i == len(lengths)-1 {
+			lengths[i] = uint64(c.TrueLengthOfLastGroup)
+		} else {
+			lengths[i] = uint64(c.ReferenceForGroupLengths) + lengths[i]*uint64(c.LengthIncrementForGroupLengths)
+		}
+		total += lengths[i]
+	}
+	if total != uint64(n) {
+		return nil, fmt.Errorf("packing: groups hold %d values, expected %d", total, n)
+	}
+
+	raw := make([]int64, 0, n)
+	for i, length := range lengths {
+		primary, secondary := missingCodes(refBits, c.MissingValueManagement)
+		if widths[i] == 0 {
+			// Constant group: its reference is the value of all its points
+			x := int64(refs[i])
+			if refs[i] == primary || refs[i] == secondary || refs[i] > math.MaxInt64 {
+				x = MissingRaw
+			}
+			for range length {
+				raw = append(raw, x)
+			}
+			continue
+		}
+
+		primary, secondary = missingCodes(uint(widths[i]), c.MissingValueManagement)
+		for range length {
+			packed, err := r.ReadBits(uint(widths[i]))
+			if err != nil {
+				return nil, fmt.Errorf("packing: group %d: %w", i, err)
+			}
+			if packed == primary || packed == secondary {
+				raw = append(raw, MissingRaw)
+				continue
+			}
+			x := refs[i] + packed
+			if x < refs[i] || x > math.MaxInt64 {
+				return nil, fmt.Errorf("packing: packed integer of group %d overflows int64", i)
+			}
+			raw = append(raw, int64(x))
+		}
+	}
+	return raw, nil
+}
+
+// missingCodes returns the packed integers of the given width standing for primary and
+// secondary missing values, all ones and all ones but the last bit, or an impossible
+// value when the missing value management does not use them
+func missingCodes(bits uint, management uint8) (primary, secondary uint64) {
+	primary, secondary = math.MaxUint64, math.MaxUint64
+	if bits == 0 || bits >= 64 {
+		return primary, secondary
+	}
+	allOnes := uint64(1)<<bits - 1
+	if management >= MissingPrimary {
+		primary = allOnes
+	}
+	if management == MissingSecondary {
+		secondary = allOnes - 1
+	}
+	return primary, secondary
+}
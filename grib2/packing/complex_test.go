@@ -0,0 +1,154 @@
+package packing_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/scorix/grib/grib2/packing"
+	"github.com/scorix/grib/grib2/template"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeComplexRaw(t *testing.T) {
+	dataRep := &template.DataRepTemplate{
+		TemplateNumber:          2,
+		NumberOfBitsUsedForData: 4,
+		Complex: &template.ComplexPackingInfo{
+			NumberOfGroupsOfDataValues:      2,
+			NumberOfBitsUsedForGroupWidths:  2,
+			ReferenceForGroupLengths:        2,
+			LengthIncrementForGroupLengths:  1,
+			TrueLengthOfLastGroup:           2,
+			NumberOfBitsUsedForGroupLengths: 1,
+		},
+	}
+
+	data := []byte{
+		0x59, // References 5 and 9 at 4 bits
+		0x80, // Widths 2 and 0 at 2 bits
+		0x80, // Scaled lengths 1 (2 + 1) and 0 (the true length of the last group) at 1 bit
+		0x18, // Packed integers 0, 1, 2 of the first group at 2 bits; the second is constant
+	}
+	raw, _, _, _, err := packing.DecodeComplexRaw(dataRep, data, 5)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{5, 6, 7, 9, 9}, raw)
+
+	_, _, _, _, err = packing.DecodeComplexRaw(dataRep, data, 6)
+	assert.ErrorContains(t, err, "groups hold 5 values, expected 6")
+}
+
+func TestDecodeComplex_MissingValues(t *testing.T) {
+	dataRep := &template.DataRepTemplate{
+		TemplateNumber:          2,
+		ReferenceValue:          100,
+		DecimalScaleFactor:      1,
+		NumberOfBitsUsedForData: 4,
+		Complex: &template.ComplexPackingInfo{
+			MissingValueManagement:         packing.MissingSecondary,
+			NumberOfGroupsOfDataValues:     4,
+			NumberOfBitsUsedForGroupWidths: 2,
+			ReferenceForGroupLengths:       2,
+			TrueLengthOfLastGroup:          2,
+		},
+	}
+
+	data := []byte{
+		0x3f, 0xe7, // References 3, 15 (primary missing), 14 (secondary missing) and 7 at 4 bits
+		0x80, // Widths 2, 0, 0 and 0 at 2 bits
+		0x60, // Packed integers 1 and 2 (secondary missing) of the first group at 2 bits
+	}
+	raw, _, _, _, err := packing.DecodeComplexRaw(dataRep, data, 8)
+	require.NoError(t, err)
+	m := int64(packing.MissingRaw)
+	assert.Equal(t, []int64{4, m, m, m, m, m, 7, 7}, raw)
+
+	values, err := packing.Decode(dataRep, data, 8)
+	require.NoError(t, err)
+	assert.InDelta(t, 10.4, values[0], 1e-9)
+	for _, v := range values[1:6] {
+		assert.True(t, math.IsNaN(v))
+	}
+	assert.InDeltaSlice(t, []float64{10.7, 10.7}, values[6:], 1e-9)
+
+	// Without missing value management, the same integers are values
+	dataRep.Complex.MissingValueManagement = packing.MissingNone
+	raw, _, _, _, err = packing.DecodeComplexRaw(dataRep, data, 8)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{4, 5, 15, 15, 14, 14, 7, 7}, raw)
+}
+
+func TestDecodeComplexRaw_Errors(t *testing.T) {
+	_, _, _, _, err := packing.DecodeComplexRaw(&template.DataRepTemplate{TemplateNumber: 2}, nil, 1)
+	assert.ErrorContains(t, err, "complex packing parameters are missing")
+
+	dataRep := &template.DataRepTemplate{
+		TemplateNumber: 2,
+		Complex:        &template.ComplexPackingInfo{NumberOfGroupsOfDataValues: 3, TrueLengthOfLastGroup: 1},
+	}
+	_, _, _, _, err = packing.DecodeComplexRaw(dataRep, nil, 2)
+	assert.ErrorContains(t, err, "3 groups for 2 values")
+
+	dataRep.Complex.NumberOfGroupsOfDataValues = 1
+	dataRep.Complex.NumberOfBitsUsedForGroupWidths = 8
+	_, _, _, _, err = packing.DecodeComplexRaw(dataRep, nil, 1)
+	assert.ErrorContains(t, err, "group widths")
+}
+
+func TestDecodeComplexSpatialRaw(t *testing.T) {
+	order, octets := uint8(1), uint8(1)
+	dataRep := &template.DataRepTemplate{
+		TemplateNumber: 3,
+		Complex: &template.ComplexPackingInfo{
+			NumberOfGroupsOfDataValues:     1,
+			ReferenceForGroupWidths:        3,
+			TrueLengthOfLastGroup:          4,
+			OrderOfSpatialDifferencing:     &order,
+			NumberOfOctetsExtraDescriptors: &octets,
+		},
+	}
+
+	// First order differences 2, -1 and 4 of 10, 12, 11, 15 less their minimum -1
+	data := []byte{
+		0x0a,       // First value 10
+		0x81,       // Minimum of the differences -1, sign and magnitude
+		0x0c, 0x50, // A group of width 3 with reference 0 at 0 bits: 0, 3, 0, 5
+	}
+	raw, _, _, _, err := packing.DecodeComplexSpatialRaw(dataRep, data, 4)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{10, 12, 11, 15}, raw)
+}
+
+func TestDecodeComplexSpatialRaw_SecondOrderWithMissingValues(t *testing.T) {
+	order, octets := uint8(2), uint8(1)
+	dataRep := &template.DataRepTemplate{
+		TemplateNumber: 3,
+		Complex: &template.ComplexPackingInfo{
+			MissingValueManagement:         packing.MissingPrimary,
+			NumberOfGroupsOfDataValues:     1,
+			ReferenceForGroupWidths:        1,
+			TrueLengthOfLastGroup:          5,
+			OrderOfSpatialDifferencing:     &order,
+			NumberOfOctetsExtraDescriptors: &octets,
+		},
+	}
+
+	// Second order differences 1 and 1 of 1, 3, 6, 10 less their minimum 1; missing
+	// values are left out of the differencing
+	data := []byte{
+		0x01, 0x03, // First values 1 and 3
+		0x01, // Minimum of the differences 1
+		0x40, // A group of width 1: 0, 1 (missing), 0, 0, 0
+	}
+	raw, _, _, _, err := packing.DecodeComplexSpatialRaw(dataRep, data, 5)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1, packing.MissingRaw, 3, 6, 10}, raw)
+
+	order = 3
+	_, _, _, _, err = packing.DecodeComplexSpatialRaw(dataRep, data, 5)
+	assert.ErrorContains(t, err, "unsupported order of spatial differencing 3")
+
+	dataRep.Complex.OrderOfSpatialDifferencing = nil
+	_, _, _, _, err = packing.DecodeComplexSpatialRaw(dataRep, data, 5)
+	assert.ErrorContains(t, err, "spatial differencing parameters are missing")
+}
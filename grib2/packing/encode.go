@@ -0,0 +1,256 @@
+package packing
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/bits"
+
+	"github.com/scorix/grib/grib2/internal/bitio"
+	"github.com/scorix/grib/grib2/template"
+	"github.com/scorix/grib/grib2/units"
+)
+
+// maxEncodeBits is the widest packed integer written by EncodeSimple, the widest that
+// other tools commonly decode
+const maxEncodeBits = 32
+
+// PackingParams are the scaling parameters of a simple packed field: each value Y is
+// packed as the integer X nearest to (Y * 10^D - R) / 2^E, on BitsPerValue bits
+type PackingParams struct {
+	ReferenceValue float32 // Reference value R, not above any scaled value
+	BinaryScale    int16   // Binary scale factor E
+	DecimalScale   int16   // Decimal scale factor D
+	BitsPerValue   uint8   // Bits per packed integer, 0 for a constant field
+}
+
+// ChoosePacking chooses the simple packing parameters that keep the values within
+// precision of their decoded values with the fewest bits. D is chosen so that a decimal
+// step is the coarsest power of ten not finer than the allowed step of 2 * precision,
+// then E, zero or negative, refines the step to it; the bits follow from the range of the
+// packed integers. Fields whose range then needs more than 32 bits have E raised to fit
+// them, losing precision, as do fields packed with a precision that is not positive,
+// which get the finest step that fits 32 bits.
+//
+// Missing values, NaN, are left out. A field with no value present, or whose values are
+// within precision of one another, packs with 0 bits and only the reference value.
+func ChoosePacking(values []float64, precision float64) PackingParams {
+	minimum, maximum, present := math.Inf(1), math.Inf(-1), false
+	for _, v := range values {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			continue
+		}
+		minimum, maximum, present = min(minimum, v), max(maximum, v), true
+	}
+	if !present {
+		return PackingParams{}
+	}
+
+	D, E := 0, math.MinInt16
+	if precision > 0 && !math.IsInf(precision, 0) {
+		D = int(math.Floor(-math.Log10(2 * precision)))
+		// Keep the scaled values, and the reference value with them, within float32
+		for D > math.MinInt16+1 && max(math.Abs(minimum), math.Abs(maximum))*math.Pow(10, float64(D)) > math.MaxFloat32 {
+			D--
+		}
+		D = min(max(D, math.MinInt16+1), math.MaxInt16)
+		E = int(math.Floor(math.Log2(2 * precision * math.Pow(10, float64(D)))))
+	}
+
+	scale := math.Pow(10, float64(D))
+	ref := float32(minimum * scale)
+	if float64(ref) > minimum*scale {
+		ref = math.Nextafter32(ref, float32(math.Inf(-1)))
+	}
+	span := maximum*scale - float64(ref)
+
+	// Raise E until the largest packed integer fits
+	maxPacked := math.Exp2(maxEncodeBits) - 1
+	if span > 0 {
+		E = max(E, int(math.Ceil(math.Log2(span/maxPacked))), math.MinInt16+1)
+	}
+	for math.Round(span/math.Exp2(float64(E))) > maxPacked {
+		E++
+	}
+	E = min(E, math.MaxInt16)
+
+	largest := math.Round(span / math.Exp2(float64(E)))
+	if largest == 0 {
+		return PackingParams{ReferenceValue: ref, DecimalScale: int16(D)}
+	}
+	return PackingParams{
+		ReferenceValue: ref,
+		BinaryScale:    int16(E),
+		DecimalScale:   int16(D),
+		BitsPerValue:   uint8(bits.Len64(uint64(largest))),
+	}
+}
+
+// ChoosePackingBits chooses the simple packing parameters of values with the decimal scale
+// factor D and bitsPerValue bits per packed integer: E is the smallest binary scale factor
+// whose packed integers fit the bits. With bitsPerValue zero, E is 0 and the bits are the
+// fewest that hold the range of the values in steps of 10^-D. Missing values, NaN, are left
+// out, and a field with no value present packs with 0 bits.
+func ChoosePackingBits(values []float64, decimalScale int16, bitsPerValue uint8) (PackingParams, error) {
+	if bitsPerValue > maxEncodeBits {
+		return PackingParams{}, fmt.Errorf("packing: %d bits per value exceeds %d", bitsPerValue, maxEncodeBits)
+	}
+	minimum, maximum, present := math.Inf(1), math.Inf(-1), false
+	for _, v := range values {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			continue
+		}
+		minimum, maximum, present = min(minimum, v), max(maximum, v), true
+	}
+	if !present {
+		return PackingParams{DecimalScale: decimalScale}, nil
+	}
+
+	scale := math.Pow(10, float64(decimalScale))
+	if max(math.Abs(minimum), math.Abs(maximum))*scale > math.MaxFloat32 {
+		return PackingParams{}, fmt.Errorf("packing: values scaled by 10^%d exceed the range of the reference value", decimalScale)
+	}
+	ref := float32(minimum * scale)
+	if float64(ref) > minimum*scale {
+		ref = math.Nextafter32(ref, float32(math.Inf(-1)))
+	}
+	span := maximum*scale - float64(ref)
+
+	E := 0
+	if bitsPerValue == 0 {
+		largest := math.Round(span)
+		if largest > math.Exp2(maxEncodeBits)-1 {
+			return PackingParams{}, fmt.Errorf("packing: range %g in steps of 10^-%d needs more than %d bits", maximum-minimum, decimalScale, maxEncodeBits)
+		}
+		bitsPerValue = uint8(bits.Len64(uint64(largest)))
+	} else if span > 0 {
+		maxPacked := math.Exp2(float64(bitsPerValue)) - 1
+		E = max(int(math.Ceil(math.Log2(span/maxPacked))), math.MinInt16+1)
+		for E > math.MinInt16+1 && math.Round(span/math.Exp2(float64(E-1))) <= maxPacked {
+			E--
+		}
+		for math.Round(span/math.Exp2(float64(E))) > maxPacked {
+			E++
+		}
+	}
+
+	return PackingParams{
+		ReferenceValue: ref,
+		BinaryScale:    int16(E),
+		DecimalScale:   decimalScale,
+		BitsPerValue:   bitsPerValue,
+	}, nil
+}
+
+// Step returns the difference between consecutive values the parameters represent
+func (p PackingParams) Step() float64 {
+	return QuantizationStep(int(p.BinaryScale), int(p.DecimalScale))
+}
+
+// Template returns the octets of data representation template 5.0 holding the parameters,
+// octets 12-21 of Section 5, for floating point original values
+func (p PackingParams) Template() []byte {
+	octets := binary.BigEndian.AppendUint32(nil, math.Float32bits(p.ReferenceValue))
+	octets = binary.BigEndian.AppendUint16(octets, units.EncodeSignMagnitudeInt16(p.BinaryScale))
+	octets = binary.BigEndian.AppendUint16(octets, units.EncodeSignMagnitudeInt16(p.DecimalScale))
+	return append(octets, p.BitsPerValue, 0)
+}
+
+// DataRepTemplate returns the data representation template 5.0 of the parameters, as
+// read back from Section 5
+func (p PackingParams) DataRepTemplate() template.DataRepTemplate {
+	return template.DataRepTemplate{
+		TemplateNumber:          0,
+		ReferenceValue:          float64(p.ReferenceValue),
+		BinaryScaleFactor:       p.BinaryScale,
+		DecimalScaleFactor:      p.DecimalScale,
+		NumberOfBitsUsedForData: p.BitsPerValue,
+		Simple:                  &template.SimplePackingInfo{},
+	}
+}
+
+// SimpleField is a field packed with simple packing (template 5.0)
+type SimpleField struct {
+	PackingParams
+
+	Values int    // Number of values packed in Data, the values present
+	Bitmap []byte // Bit-map of Section 6 with a bit set for each value present, nil when none is missing
+	Data   []byte // Payload of Section 7, empty for a constant field or one without values
+}
+
+// EncodeSimple packs values with simple packing within precision of their decoded values,
+// with the parameters chosen by ChoosePacking. Missing values, NaN, are left out of Data
+// and marked in a bit-map, which has no bit set when all values are missing. Infinite
+// values cannot be packed and fail the encoding.
+func EncodeSimple(values []float64, precision float64) (SimpleField, error) {
+	return EncodeSimpleParams(values, ChoosePacking(values, precision))
+}
+
+// EncodeSimpleParams packs values with simple packing with the given parameters, e.g. those
+// chosen by ChoosePackingBits. Values outside the range of the parameters are clamped to
+// it. Missing values are handled as by EncodeSimple.
+func EncodeSimpleParams(values []float64, params PackingParams) (SimpleField, error) {
+	if params.BitsPerValue > 64 {
+		return SimpleField{}, fmt.Errorf("packing: %d bits per value exceeds 64", params.BitsPerValue)
+	}
+	var bitmap *bitio.Writer
+	for i, v := range values {
+		if math.IsInf(v, 0) {
+			return SimpleField{}, fmt.Errorf("packing: value %d is infinite", i)
+		}
+		if math.IsNaN(v) && bitmap == nil {
+			bitmap = &bitio.Writer{}
+		}
+	}
+
+	field := SimpleField{PackingParams: params}
+	scale := math.Pow(10, float64(field.DecimalScale))
+	step := math.Exp2(float64(field.BinaryScale))
+	maxPacked := uint64(math.MaxUint64)
+	if field.BitsPerValue < 64 {
+		maxPacked = uint64(1)<<field.BitsPerValue - 1
+	}
+
+	data := &bitio.Writer{}
+	for _, v := range values {
+		if bitmap != nil {
+			present := uint64(0)
+			if !math.IsNaN(v) {
+				present = 1
+			}
+			bitmap.WriteBits(present, 1)
+		}
+		if math.IsNaN(v) {
+			continue
+		}
+
+		field.Values++
+		if field.BitsPerValue > 0 {
+			x := math.Round((v*scale - float64(field.ReferenceValue)) / step)
+			data.WriteBits(min(uint64(max(x, 0)), maxPacked), uint(field.BitsPerValue))
+		}
+	}
+
+	field.Data = data.Bytes()
+	if bitmap != nil {
+		field.Bitmap = bitmap.Bytes()
+	}
+	return field, nil
+}
+
+// DataRepresentationPayload returns the payload of the Section 5 of the field: the number of
+// values packed, template number 0 and the template octets
+func (f SimpleField) DataRepresentationPayload() []byte {
+	payload := binary.BigEndian.AppendUint32(nil, uint32(f.Values))
+	payload = binary.BigEndian.AppendUint16(payload, 0)
+	return append(payload, f.Template()...)
+}
+
+// BitmapPayload returns the payload of the Section 6 of the field: the bit-map with bit-map
+// indicator 0, or bit-map indicator 255 when no value is missing
+func (f SimpleField) BitmapPayload() []byte {
+	if f.Bitmap == nil {
+		return []byte{255}
+	}
+	return append([]byte{0}, f.Bitmap...)
+}
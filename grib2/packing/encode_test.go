@@ -0,0 +1,181 @@
+package packing_test
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/scorix/grib/grib2/packing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// decodeField decodes a field packed by EncodeSimple, with NaN at the missing values
+func decodeField(t *testing.T, field packing.SimpleField, n int) []float64 {
+	dataRep := field.DataRepTemplate()
+	present, err := packing.DecodeSimple(&dataRep, field.Data, field.Values)
+	require.NoError(t, err)
+
+	values := make([]float64, n)
+	k := 0
+	for i := range values {
+		if field.Bitmap != nil && field.Bitmap[i/8]&(0x80>>(i%8)) == 0 {
+			values[i] = math.NaN()
+			continue
+		}
+		values[i] = present[k]
+		k++
+	}
+	return values
+}
+
+// assertWithin asserts that got decodes want within precision, missing values included
+func assertWithin(t *testing.T, want, got []float64, precision float64) {
+	t.Helper()
+	require.Len(t, got, len(want))
+	for i := range want {
+		if math.IsNaN(want[i]) {
+			assert.True(t, math.IsNaN(got[i]), "value %d is missing", i)
+			continue
+		}
+		assert.LessOrEqual(t, math.Abs(got[i]-want[i]), precision, "value %d: %v decoded as %v", i, want[i], got[i])
+	}
+}
+
+func TestEncodeSimple_RoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	for range 500 {
+		n := 1 + rng.IntN(64)
+		precision := math.Pow(10, float64(rng.IntN(9)-6)) * (1 + rng.Float64())
+		offset := (rng.Float64() - 0.5) * math.Pow(10, float64(rng.IntN(8)))
+		spread := math.Pow(10, float64(rng.IntN(8)-3))
+
+		values := make([]float64, n)
+		kind := rng.IntN(4)
+		for i := range values {
+			switch {
+			case kind == 0:
+				values[i] = offset // Constant
+			case kind == 1 && rng.IntN(3) == 0:
+				values[i] = math.NaN() // Partly missing
+			case kind == 2:
+				values[i] = math.NaN() // All missing
+			default:
+				values[i] = offset + rng.Float64()*spread
+			}
+		}
+
+		field, err := packing.EncodeSimple(values, precision)
+		require.NoError(t, err)
+		assertWithin(t, values, decodeField(t, field, n), precision)
+		assert.LessOrEqual(t, field.BitsPerValue, uint8(32))
+		assert.Len(t, field.Data, (field.Values*int(field.BitsPerValue)+7)/8)
+	}
+}
+
+func TestEncodeSimple_Constant(t *testing.T) {
+	field, err := packing.EncodeSimple([]float64{273.15, 273.15, math.NaN(), 273.15}, 0.01)
+	require.NoError(t, err)
+	assert.Zero(t, field.BitsPerValue)
+	assert.Empty(t, field.Data)
+	assert.Equal(t, 3, field.Values)
+	assert.Equal(t, []byte{0b1101_0000}, field.Bitmap)
+	assertWithin(t, []float64{273.15, 273.15, math.NaN(), 273.15}, decodeField(t, field, 4), 0.01)
+}
+
+func TestEncodeSimple_AllMissing(t *testing.T) {
+	values := []float64{math.NaN(), math.NaN(), math.NaN(), math.NaN(), math.NaN(), math.NaN(), math.NaN(), math.NaN(), math.NaN()}
+	field, err := packing.EncodeSimple(values, 0.1)
+	require.NoError(t, err)
+	assert.Equal(t, packing.PackingParams{}, field.PackingParams)
+	assert.Zero(t, field.Values)
+	assert.Empty(t, field.Data)
+	assert.Equal(t, []byte{0, 0}, field.Bitmap, "a zero-set bit-map of one bit per value")
+}
+
+func TestEncodeSimple_Infinite(t *testing.T) {
+	_, err := packing.EncodeSimple([]float64{1, math.Inf(1)}, 0.1)
+	assert.ErrorContains(t, err, "value 1 is infinite")
+}
+
+func TestChoosePacking(t *testing.T) {
+	t.Run("negative binary scale", func(t *testing.T) {
+		// A step of 2 * 0.003 is refined from the decimal step 0.01 by E = -1
+		params := packing.ChoosePacking([]float64{0, 1}, 0.003)
+		assert.Equal(t, int16(2), params.DecimalScale)
+		assert.Equal(t, int16(-1), params.BinaryScale)
+		assert.Equal(t, uint8(8), params.BitsPerValue) // 200 steps of 0.005
+		assert.LessOrEqual(t, params.Step(), 2*0.003)
+	})
+
+	t.Run("32 bits at most", func(t *testing.T) {
+		params := packing.ChoosePacking([]float64{0, 1e12}, 1e-6)
+		assert.Equal(t, uint8(32), params.BitsPerValue)
+		assert.Greater(t, params.Step(), 2e-6, "the precision cannot be met")
+	})
+
+	t.Run("without precision", func(t *testing.T) {
+		params := packing.ChoosePacking([]float64{0.001, 0.002}, 0)
+		assert.Equal(t, uint8(32), params.BitsPerValue)
+		assert.Negative(t, params.BinaryScale)
+	})
+
+	t.Run("template", func(t *testing.T) {
+		params := packing.PackingParams{ReferenceValue: 1, BinaryScale: -1, DecimalScale: 2, BitsPerValue: 8}
+		assert.Equal(t, []byte{0x3f, 0x80, 0, 0, 0x80, 0x01, 0x00, 0x02, 8, 0}, params.Template())
+	})
+}
+
+func TestChoosePackingBits(t *testing.T) {
+	values := []float64{271.3, 280.15, math.NaN(), 301.72}
+
+	t.Run("automatic bits", func(t *testing.T) {
+		params, err := packing.ChoosePackingBits(values, 2, 0)
+		require.NoError(t, err)
+		assert.Equal(t, int16(2), params.DecimalScale)
+		assert.Zero(t, params.BinaryScale)
+		assert.Equal(t, uint8(12), params.BitsPerValue) // 3042 steps of 0.01
+
+		field, err := packing.EncodeSimpleParams(values, params)
+		require.NoError(t, err)
+		assertWithin(t, values, decodeField(t, field, len(values)), 0.005+1e-9)
+	})
+
+	t.Run("given bits", func(t *testing.T) {
+		for _, nbits := range []uint8{4, 8, 16, 24} {
+			params, err := packing.ChoosePackingBits(values, 1, nbits)
+			require.NoError(t, err)
+			assert.Equal(t, nbits, params.BitsPerValue)
+
+			// E is the smallest binary scale whose packed integers fit the bits
+			span := (301.72 - 271.3) * 10
+			assert.LessOrEqual(t, span/math.Exp2(float64(params.BinaryScale)), math.Exp2(float64(nbits))-0.5)
+			assert.Greater(t, span/math.Exp2(float64(params.BinaryScale-1)), math.Exp2(float64(nbits))-1.5)
+
+			field, err := packing.EncodeSimpleParams(values, params)
+			require.NoError(t, err)
+			assertWithin(t, values, decodeField(t, field, len(values)), params.Step()/2+1e-9)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := packing.ChoosePackingBits(values, 0, 33)
+		assert.ErrorContains(t, err, "33 bits per value exceeds 32")
+		_, err = packing.ChoosePackingBits([]float64{0, 1e12}, 0, 0)
+		assert.ErrorContains(t, err, "needs more than 32 bits")
+		_, err = packing.ChoosePackingBits([]float64{1e30}, 10, 8)
+		assert.ErrorContains(t, err, "exceed the range of the reference value")
+	})
+
+	t.Run("payloads", func(t *testing.T) {
+		params := packing.PackingParams{ReferenceValue: 1, BinaryScale: -1, DecimalScale: 2, BitsPerValue: 8}
+		field, err := packing.EncodeSimpleParams([]float64{0.01, math.NaN(), 0.02}, params)
+		require.NoError(t, err)
+		assert.Equal(t, append([]byte{0, 0, 0, 2, 0, 0}, params.Template()...), field.DataRepresentationPayload())
+		assert.Equal(t, []byte{0, 0b1010_0000}, field.BitmapPayload())
+
+		field, err = packing.EncodeSimpleParams([]float64{0.01, 0.02}, params)
+		require.NoError(t, err)
+		assert.Equal(t, []byte{255}, field.BitmapPayload())
+	})
+}
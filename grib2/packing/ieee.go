@@ -0,0 +1,56 @@
+package packing
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/scorix/grib/grib2/template"
+)
+
+// Precision of the IEEE floating point data (Code Table 5.7)
+const (
+	PrecisionSingle = 1 // IEEE 32-bit
+	PrecisionDouble = 2 // IEEE 64-bit
+	PrecisionQuad   = 3 // IEEE 128-bit
+)
+
+// DecodeIEEE unpacks the n values of a field stored as IEEE floating point numbers
+// (template 5.4), big-endian float32 or float64 as given by the precision of the template.
+// The values are stored as they are: no reference value nor scale factors apply.
+func DecodeIEEE(dataRep *template.DataRepTemplate, data []byte, n int) ([]float64, error) {
+	ieee := dataRep.IEEE
+	if ieee == nil {
+		return nil, errors.New("packing: IEEE floating point precision is missing")
+	}
+	if n < 0 {
+		return nil, fmt.Errorf("packing: invalid number of values %d", n)
+	}
+
+	var size int
+	switch ieee.PrecisionOfFloatingPointNumbers {
+	case PrecisionSingle:
+		size = 4
+	case PrecisionDouble:
+		size = 8
+	default:
+		return nil, fmt.Errorf("packing: unsupported IEEE floating point precision %d", ieee.PrecisionOfFloatingPointNumbers)
+	}
+	if len(data)%size != 0 {
+		return nil, fmt.Errorf("packing: IEEE data of %d bytes is not a whole number of %d-byte values", len(data), size)
+	}
+	if len(data)/size != n {
+		return nil, fmt.Errorf("packing: IEEE data holds %d values, expected %d", len(data)/size, n)
+	}
+
+	values := make([]float64, n)
+	for i := range values {
+		if size == 4 {
+			values[i] = float64(math.Float32frombits(binary.BigEndian.Uint32(data[4*i:])))
+		} else {
+			values[i] = math.Float64frombits(binary.BigEndian.Uint64(data[8*i:]))
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,62 @@
+package packing_test
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/scorix/grib/grib2/packing"
+	"github.com/scorix/grib/grib2/template"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeIEEE(t *testing.T) {
+	var single, double []byte
+	for _, v := range []float64{-2.5, 0, 273.15} {
+		single = binary.BigEndian.AppendUint32(single, math.Float32bits(float32(v)))
+		double = binary.BigEndian.AppendUint64(double, math.Float64bits(v))
+	}
+
+	// The reference value and scale factors do not apply
+	dataRep := &template.DataRepTemplate{
+		TemplateNumber:     4,
+		ReferenceValue:     100,
+		DecimalScaleFactor: 2,
+		IEEE:               &template.IEEEPackingInfo{PrecisionOfFloatingPointNumbers: packing.PrecisionSingle},
+	}
+	values, err := packing.Decode(dataRep, single, 3)
+	require.NoError(t, err)
+	assert.Equal(t, []float64{-2.5, 0, float64(float32(273.15))}, values)
+
+	dataRep.IEEE.PrecisionOfFloatingPointNumbers = packing.PrecisionDouble
+	values, err = packing.Decode(dataRep, double, 3)
+	require.NoError(t, err)
+	assert.Equal(t, []float64{-2.5, 0, 273.15}, values)
+
+	assert.True(t, packing.CanDecode(4))
+	assert.False(t, packing.HasPackedIntegers(4))
+	assert.Contains(t, packing.TemplateNumbers(), 4)
+	_, _, _, _, err = packing.DecodeRaw(dataRep, double, 3)
+	assert.EqualError(t, err, "packing: data representation template 4 stores values, not packed integers")
+}
+
+func TestDecodeIEEE_Errors(t *testing.T) {
+	dataRep := &template.DataRepTemplate{
+		TemplateNumber: 4,
+		IEEE:           &template.IEEEPackingInfo{PrecisionOfFloatingPointNumbers: packing.PrecisionDouble},
+	}
+
+	_, err := packing.DecodeIEEE(dataRep, make([]byte, 20), 3)
+	assert.EqualError(t, err, "packing: IEEE data of 20 bytes is not a whole number of 8-byte values")
+
+	_, err = packing.DecodeIEEE(dataRep, make([]byte, 16), 3)
+	assert.EqualError(t, err, "packing: IEEE data holds 2 values, expected 3")
+
+	dataRep.IEEE.PrecisionOfFloatingPointNumbers = packing.PrecisionQuad
+	_, err = packing.DecodeIEEE(dataRep, make([]byte, 48), 3)
+	assert.EqualError(t, err, "packing: unsupported IEEE floating point precision 3")
+
+	_, err = packing.DecodeIEEE(&template.DataRepTemplate{TemplateNumber: 4}, nil, 0)
+	assert.EqualError(t, err, "packing: IEEE floating point precision is missing")
+}
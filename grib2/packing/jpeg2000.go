@@ -0,0 +1,80 @@
+package packing
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/scorix/grib/grib2/internal/jpeg2000"
+	"github.com/scorix/grib/grib2/template"
+)
+
+// JPEG2000Decoder decodes a JPEG 2000 codestream into its single component of width x
+// height samples, in raster order
+type JPEG2000Decoder func(codestream []byte) (width, height int, samples []int64, err error)
+
+var (
+	jpeg2000DecoderMu sync.RWMutex
+	jpeg2000Decoder   JPEG2000Decoder = decodeJPEG2000Codestream
+)
+
+// SetJPEG2000Decoder makes fn decode the codestreams of JPEG 2000 packed fields in place of
+// the package's pure Go decoder, e.g. a wrapper of OpenJPEG. A nil fn restores the built-in
+// decoder. Set it during initialisation, before fields are decoded.
+func SetJPEG2000Decoder(fn JPEG2000Decoder) {
+	jpeg2000DecoderMu.Lock()
+	defer jpeg2000DecoderMu.Unlock()
+	if fn == nil {
+		fn = decodeJPEG2000Codestream
+	}
+	jpeg2000Decoder = fn
+}
+
+// decodeJPEG2000Codestream is the built-in JPEG2000Decoder
+func decodeJPEG2000Codestream(codestream []byte) (width, height int, samples []int64, err error) {
+	img, err := jpeg2000.Decode(codestream)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	return img.Width, img.Height, img.Samples, nil
+}
+
+// DecodeJPEG2000Raw unpacks the n packed integers X of a field with JPEG 2000 packing
+// (template 5.40) along with its scaling parameters. Section 7 holds a JPEG 2000
+// codestream whose samples are the packed integers, decoded by the JPEG2000Decoder set
+// with SetJPEG2000Decoder once its SIZ marker segment gives a single component of n
+// samples. A field packed with 0 bits is constant and has no codestream: every X is 0.
+func DecodeJPEG2000Raw(dataRep *template.DataRepTemplate, data []byte, n int) (raw []int64, ref float64, E, D int, err error) {
+	if n < 0 {
+		return nil, 0, 0, 0, fmt.Errorf("packing: invalid number of values %d", n)
+	}
+
+	ref, E, D = dataRep.ReferenceValue, int(dataRep.BinaryScaleFactor), int(dataRep.DecimalScaleFactor)
+	if dataRep.NumberOfBitsUsedForData == 0 || n == 0 {
+		return make([]int64, n), ref, E, D, nil
+	}
+
+	// The size of the image is checked before it is decoded, by whichever decoder: the
+	// codestream of a corrupt field can describe a far larger image than its own size
+	cfg, err := jpeg2000.DecodeConfig(data)
+	if err != nil {
+		return nil, 0, 0, 0, fmt.Errorf("packing: %w", err)
+	}
+	if cfg.Components != 1 {
+		return nil, 0, 0, 0, fmt.Errorf("packing: JPEG 2000 image of %d components, expected one", cfg.Components)
+	}
+	if cfg.Width*cfg.Height != n {
+		return nil, 0, 0, 0, fmt.Errorf("packing: JPEG 2000 image of %dx%d samples for %d values", cfg.Width, cfg.Height, n)
+	}
+
+	jpeg2000DecoderMu.RLock()
+	decode := jpeg2000Decoder
+	jpeg2000DecoderMu.RUnlock()
+	width, height, samples, err := decode(data)
+	if err != nil {
+		return nil, 0, 0, 0, fmt.Errorf("packing: %w", err)
+	}
+	if width*height != n || len(samples) != n {
+		return nil, 0, 0, 0, fmt.Errorf("packing: JPEG 2000 image of %dx%d samples for %d values", width, height, n)
+	}
+	return samples, ref, E, D, nil
+}
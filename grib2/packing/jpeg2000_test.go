@@ -0,0 +1,90 @@
+package packing_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/scorix/grib/grib2/internal/jpeg2000"
+	"github.com/scorix/grib/grib2/packing"
+	"github.com/scorix/grib/grib2/template"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeJPEG2000(t *testing.T) {
+	img := &jpeg2000.Image{Width: 5, Height: 3, Precision: 12}
+	for i := range 15 {
+		img.Samples = append(img.Samples, int64(i*i*17%4096))
+	}
+	data, err := jpeg2000.Encode(img, jpeg2000.EncodeOptions{Levels: 1})
+	require.NoError(t, err)
+
+	dataRep := &template.DataRepTemplate{
+		TemplateNumber:          40,
+		ReferenceValue:          250,
+		BinaryScaleFactor:       -2,
+		DecimalScaleFactor:      1,
+		NumberOfBitsUsedForData: 12,
+		JPEG2000:                &template.JPEG2000PackingInfo{TargetCompressionRatio: 255},
+	}
+	raw, ref, E, D, err := packing.DecodeRaw(dataRep, data, 15)
+	require.NoError(t, err)
+	assert.Equal(t, img.Samples, raw)
+	assert.Equal(t, 250.0, ref)
+	assert.Equal(t, -2, E)
+	assert.Equal(t, 1, D)
+
+	values, err := packing.Decode(dataRep, data, 15)
+	require.NoError(t, err)
+	assert.InDelta(t, (250+float64(img.Samples[3])/4)/10, values[3], 1e-9)
+
+	_, _, _, _, err = packing.DecodeJPEG2000Raw(dataRep, data, 16)
+	assert.ErrorContains(t, err, "JPEG 2000 image of 5x3 samples for 16 values")
+	_, _, _, _, err = packing.DecodeJPEG2000Raw(dataRep, data[:20], 15)
+	assert.ErrorContains(t, err, "packing: jpeg2000:")
+}
+
+func TestDecodeJPEG2000_Constant(t *testing.T) {
+	// A constant field has no codestream
+	dataRep := &template.DataRepTemplate{TemplateNumber: 40, ReferenceValue: 7}
+	values, err := packing.Decode(dataRep, nil, 4)
+	require.NoError(t, err)
+	assert.Equal(t, []float64{7, 7, 7, 7}, values)
+}
+
+func TestSetJPEG2000Decoder(t *testing.T) {
+	t.Cleanup(func() { packing.SetJPEG2000Decoder(nil) })
+	data, err := jpeg2000.Encode(&jpeg2000.Image{Width: 2, Height: 1, Precision: 8, Samples: []int64{5, 6}}, jpeg2000.EncodeOptions{})
+	require.NoError(t, err)
+
+	var got []byte
+	packing.SetJPEG2000Decoder(func(codestream []byte) (int, int, []int64, error) {
+		got = codestream
+		return 2, 1, []int64{3, 4}, nil
+	})
+	dataRep := &template.DataRepTemplate{TemplateNumber: 40, NumberOfBitsUsedForData: 8}
+	raw, _, _, _, err := packing.DecodeRaw(dataRep, data, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{3, 4}, raw)
+	assert.Equal(t, data, got)
+
+	// Codestreams of another size do not reach the decoder
+	got = nil
+	_, _, _, _, err = packing.DecodeRaw(dataRep, data, 3)
+	assert.EqualError(t, err, "packing: JPEG 2000 image of 2x1 samples for 3 values")
+	_, _, _, _, err = packing.DecodeRaw(dataRep, []byte{1, 2, 3}, 2)
+	assert.ErrorContains(t, err, "missing SOC marker")
+	assert.Nil(t, got)
+
+	packing.SetJPEG2000Decoder(func([]byte) (int, int, []int64, error) {
+		return 0, 0, nil, errors.New("no codec")
+	})
+	_, _, _, _, err = packing.DecodeRaw(dataRep, data, 2)
+	assert.EqualError(t, err, "packing: no codec")
+
+	// Restoring the built-in decoder
+	packing.SetJPEG2000Decoder(nil)
+	raw, _, _, _, err = packing.DecodeRaw(dataRep, data, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{5, 6}, raw)
+}
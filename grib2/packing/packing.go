@@ -0,0 +1,121 @@
+// Package packing implements the GRIB2 data representation methods (Code Table 5.0)
+// used to turn the packed Section 7 payload into data values, and simple packing of data
+// values into a Section 7 payload.
+package packing
+
+import (
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/scorix/grib/grib2/template"
+)
+
+// MissingRaw is the packed integer returned for missing values by the decoders of
+// templates with their own missing value management, which Scale turns into NaN
+const MissingRaw = -1
+
+// rawDecodeFunc unpacks n packed integers from a Section 7 payload together with
+// the reference value, binary scale factor and decimal scale factor needed to scale them
+type rawDecodeFunc func(dataRep *template.DataRepTemplate, data []byte, n int) (raw []int64, ref float64, E, D int, err error)
+
+// decoders contains the data representation templates that can be decoded, keyed by template number
+var decoders = map[int]rawDecodeFunc{
+	0:     DecodeSimpleRaw,         // Grid point data - simple packing
+	2:     DecodeComplexRaw,        // Grid point data - complex packing
+	3:     DecodeComplexSpatialRaw, // Grid point data - complex packing and spatial differencing
+	40:    DecodeJPEG2000Raw,       // Grid point data - JPEG 2000 code stream format
+	41:    DecodePNGRaw,            // Grid point data - Portable Network Graphics (PNG)
+	42:    DecodeCCSDSRaw,          // Grid point data - CCSDS recommended lossless compression
+	40000: DecodeJPEG2000Raw,       // Grid point data - JPEG 2000 code stream format (NCEP local)
+	40010: DecodePNGRaw,            // Grid point data - Portable Network Graphics (NCEP local)
+}
+
+// valueDecodeFunc unpacks n data values from a Section 7 payload that stores them
+// directly rather than as packed integers
+type valueDecodeFunc func(dataRep *template.DataRepTemplate, data []byte, n int) ([]float64, error)
+
+// valueDecoders contains the data representation templates storing the data values
+// themselves, keyed by template number
+var valueDecoders = map[int]valueDecodeFunc{
+	4: DecodeIEEE, // Grid point data - IEEE floating point data
+}
+
+// Decode unpacks n data values from the Section 7 payload according to the
+// data representation template. Only values present in Section 7 are returned;
+// bit-map expansion is left to the caller.
+func Decode(dataRep *template.DataRepTemplate, data []byte, n int) ([]float64, error) {
+	if decode, ok := valueDecoders[dataRep.TemplateNumber]; ok {
+		return decode(dataRep, data, n)
+	}
+	raw, ref, E, D, err := DecodeRaw(dataRep, data, n)
+	if err != nil {
+		return nil, err
+	}
+	return Scale(raw, ref, E, D), nil
+}
+
+// DecodeRaw unpacks n packed integers X from the Section 7 payload without applying
+// the scaling, returning them with the reference value R, binary scale factor E and
+// decimal scale factor D so that Scale recovers the data values. Templates storing the
+// data values themselves, such as IEEE floating point data, have no packed integers.
+func DecodeRaw(dataRep *template.DataRepTemplate, data []byte, n int) (raw []int64, ref float64, E, D int, err error) {
+	if _, ok := valueDecoders[dataRep.TemplateNumber]; ok {
+		return nil, 0, 0, 0, fmt.Errorf("packing: data representation template %d stores values, not packed integers", dataRep.TemplateNumber)
+	}
+	decode, ok := decoders[dataRep.TemplateNumber]
+	if !ok {
+		return nil, 0, 0, 0, fmt.Errorf("packing: unsupported data representation template %d", dataRep.TemplateNumber)
+	}
+	return decode(dataRep, data, n)
+}
+
+// QuantizationStep returns the difference between consecutive data values that packed
+// integers can represent, 2^E / 10^D
+func QuantizationStep(E, D int) float64 {
+	return math.Pow(2, float64(E)) * math.Pow(10, -float64(D))
+}
+
+// Scale converts packed integers into data values using Y = (R + X * 2^E) / 10^D.
+// Missing values, MissingRaw, become NaN.
+func Scale(raw []int64, ref float64, E, D int) []float64 {
+	binaryScale := math.Pow(2, float64(E))
+	decimalScale := math.Pow(10, -float64(D))
+
+	values := make([]float64, len(raw))
+	for i, x := range raw {
+		if x == MissingRaw {
+			values[i] = math.NaN()
+			continue
+		}
+		values[i] = (ref + float64(x)*binaryScale) * decimalScale
+	}
+	return values
+}
+
+// CanDecode reports whether a decoder is available for the data representation template
+func CanDecode(templateNumber int) bool {
+	_, packed := decoders[templateNumber]
+	_, values := valueDecoders[templateNumber]
+	return packed || values
+}
+
+// HasPackedIntegers reports whether the data representation template stores packed
+// integers, which DecodeRaw returns, rather than the data values themselves
+func HasPackedIntegers(templateNumber int) bool {
+	_, ok := decoders[templateNumber]
+	return ok
+}
+
+// TemplateNumbers returns the data representation templates with a decoder, in ascending order
+func TemplateNumbers() []int {
+	numbers := make([]int, 0, len(decoders)+len(valueDecoders))
+	for number := range decoders {
+		numbers = append(numbers, number)
+	}
+	for number := range valueDecoders {
+		numbers = append(numbers, number)
+	}
+	slices.Sort(numbers)
+	return numbers
+}
@@ -0,0 +1,79 @@
+package packing
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+
+	"github.com/scorix/grib/grib2/template"
+)
+
+// DecodePNGRaw unpacks the n packed integers X of a field with PNG packing (template 5.41)
+// along with its scaling parameters. Section 7 holds a PNG image whose pixels are the
+// packed integers in raster order, stored in as many whole octets as the bits per value
+// need: 8 bit grey, 16 bit grey, 8 bit RGB for 24 bits and 8 bit RGBA for 32 bits, the
+// first channel holding the most significant octet. A field packed with 0 bits is constant
+// and has no image: every X is 0.
+func DecodePNGRaw(dataRep *template.DataRepTemplate, data []byte, n int) (raw []int64, ref float64, E, D int, err error) {
+	if n < 0 {
+		return nil, 0, 0, 0, fmt.Errorf("packing: invalid number of values %d", n)
+	}
+
+	ref, E, D = dataRep.ReferenceValue, int(dataRep.BinaryScaleFactor), int(dataRep.DecimalScaleFactor)
+	bits := int(dataRep.NumberOfBitsUsedForData)
+	if bits == 0 || n == 0 {
+		return make([]int64, n), ref, E, D, nil
+	}
+	if bits > 32 {
+		return nil, 0, 0, 0, fmt.Errorf("packing: %d bits per value exceeds the PNG packing limit of 32", bits)
+	}
+
+	// The size of the image is checked against its header before it is decoded: the image
+	// of a corrupt field can describe far more pixels than its own size
+	cfg, err := png.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, 0, 0, 0, fmt.Errorf("packing: PNG image: %w", err)
+	}
+	if cfg.Width*cfg.Height != n {
+		return nil, 0, 0, 0, fmt.Errorf("packing: PNG image of %dx%d pixels for %d values", cfg.Width, cfg.Height, n)
+	}
+
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, 0, 0, 0, fmt.Errorf("packing: PNG image: %w", err)
+	}
+	size := img.Bounds().Size()
+
+	// The integers take the first octets of the pixels in the pixel buffer: all but the
+	// alpha channel of RGB images, which the buffer adds
+	var pix []byte
+	var stride, pixelSize int
+	octets := (bits + 7) / 8
+	switch img := img.(type) {
+	case *image.Gray:
+		pix, stride, pixelSize = img.Pix, img.Stride, 1
+	case *image.Gray16:
+		pix, stride, pixelSize = img.Pix, img.Stride, 2
+	case *image.RGBA: // RGB images, which are opaque
+		pix, stride, pixelSize = img.Pix, img.Stride, 4
+	case *image.NRGBA:
+		pix, stride, pixelSize = img.Pix, img.Stride, 4
+	}
+	if octets != pixelSize && !(octets == 3 && pixelSize == 4) {
+		return nil, 0, 0, 0, fmt.Errorf("packing: %T PNG image for %d bits per value", img, bits)
+	}
+
+	raw = make([]int64, n)
+	for y := range size.Y {
+		row := pix[y*stride:]
+		for x := range size.X {
+			var v int64
+			for _, b := range row[x*pixelSize : x*pixelSize+octets] {
+				v = v<<8 | int64(b)
+			}
+			raw[y*size.X+x] = v
+		}
+	}
+	return raw, ref, E, D, nil
+}
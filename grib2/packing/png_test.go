@@ -0,0 +1,114 @@
+package packing_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/scorix/grib/grib2/packing"
+	"github.com/scorix/grib/grib2/template"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// encodePNG encodes a 3x2 image whose pixels are set by pixel
+func encodePNG(t *testing.T, img interface {
+	image.Image
+	Set(x, y int, c color.Color)
+}, pixel func(i int) color.Color) []byte {
+	for i := range 6 {
+		img.Set(i%3, i/3, pixel(i))
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestDecodePNGRaw(t *testing.T) {
+	rect := image.Rect(0, 0, 3, 2)
+	tests := []struct {
+		name string
+		bits uint8
+		data []byte
+		want []int64
+	}{
+		{
+			name: "8 bit grey",
+			bits: 8,
+			data: encodePNG(t, image.NewGray(rect), func(i int) color.Color { return color.Gray{uint8(40 * i)} }),
+			want: []int64{0, 40, 80, 120, 160, 200},
+		},
+		{
+			name: "16 bit grey for 12 bits",
+			bits: 12,
+			data: encodePNG(t, image.NewGray16(rect), func(i int) color.Color { return color.Gray16{uint16(800 * i)} }),
+			want: []int64{0, 800, 1600, 2400, 3200, 4000},
+		},
+		{
+			name: "RGB",
+			bits: 24,
+			data: encodePNG(t, image.NewNRGBA(rect), func(i int) color.Color { return color.NRGBA{uint8(i), 2, 3, 0xff} }),
+			want: []int64{0x000203, 0x010203, 0x020203, 0x030203, 0x040203, 0x050203},
+		},
+		{
+			name: "RGBA",
+			bits: 32,
+			data: encodePNG(t, image.NewNRGBA(rect), func(i int) color.Color { return color.NRGBA{0x80, 2, 3, uint8(i)} }),
+			want: []int64{0x80020300, 0x80020301, 0x80020302, 0x80020303, 0x80020304, 0x80020305},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dataRep := &template.DataRepTemplate{TemplateNumber: 41, NumberOfBitsUsedForData: tt.bits, PNG: &template.PNGPackingInfo{}}
+			raw, _, _, _, err := packing.DecodeRaw(dataRep, tt.data, 6)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, raw)
+		})
+	}
+}
+
+func TestDecodePNG(t *testing.T) {
+	data := encodePNG(t, image.NewGray(image.Rect(0, 0, 3, 2)), func(i int) color.Color { return color.Gray{uint8(i)} })
+	dataRep := &template.DataRepTemplate{TemplateNumber: 41, ReferenceValue: 10, DecimalScaleFactor: 1, NumberOfBitsUsedForData: 8}
+	values, err := packing.Decode(dataRep, data, 6)
+	require.NoError(t, err)
+	assert.InDeltaSlice(t, []float64{1, 1.1, 1.2, 1.3, 1.4, 1.5}, values, 1e-12)
+
+	// A field packed with 0 bits has no image
+	values, err = packing.Decode(&template.DataRepTemplate{TemplateNumber: 41, ReferenceValue: 3}, nil, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []float64{3, 3}, values)
+}
+
+func TestDecodePNGRaw_Errors(t *testing.T) {
+	gray := encodePNG(t, image.NewGray(image.Rect(0, 0, 3, 2)), func(i int) color.Color { return color.Gray{uint8(i)} })
+	dataRep := &template.DataRepTemplate{TemplateNumber: 41, NumberOfBitsUsedForData: 8}
+
+	_, _, _, _, err := packing.DecodePNGRaw(dataRep, gray, 7)
+	assert.EqualError(t, err, "packing: PNG image of 3x2 pixels for 7 values")
+
+	// A header describing a huge image is rejected before any pixel is allocated: IHDR
+	// holds the width and height from octet 16, followed by its CRC
+	huge := bytes.Clone(gray)
+	binary.BigEndian.PutUint32(huge[16:], 1<<15)
+	binary.BigEndian.PutUint32(huge[20:], 1<<15)
+	binary.BigEndian.PutUint32(huge[29:], crc32.ChecksumIEEE(huge[12:29]))
+	_, _, _, _, err = packing.DecodePNGRaw(dataRep, huge, 6)
+	assert.EqualError(t, err, "packing: PNG image of 32768x32768 pixels for 6 values")
+
+	_, _, _, _, err = packing.DecodePNGRaw(dataRep, gray[:20], 6)
+	assert.ErrorContains(t, err, "packing: PNG image:")
+
+	dataRep.NumberOfBitsUsedForData = 16
+	_, _, _, _, err = packing.DecodePNGRaw(dataRep, gray, 6)
+	assert.EqualError(t, err, "packing: *image.Gray PNG image for 16 bits per value")
+
+	dataRep.NumberOfBitsUsedForData = 33
+	_, _, _, _, err = packing.DecodePNGRaw(dataRep, gray, 6)
+	assert.ErrorContains(t, err, "exceeds the PNG packing limit")
+}
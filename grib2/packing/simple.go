@@ -0,0 +1,81 @@
+package packing
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/scorix/grib/grib2/internal/bitio"
+	"github.com/scorix/grib/grib2/template"
+)
+
+// maxBitsPerValue is the widest packed integer the decoders read
+const maxBitsPerValue = 64
+
+// DecodeSimple unpacks n values packed with simple packing (template 5.0).
+// Each value is Y = (R + X * 2^E) / 10^D where X is the packed integer.
+func DecodeSimple(dataRep *template.DataRepTemplate, data []byte, n int) ([]float64, error) {
+	raw, ref, E, D, err := DecodeSimpleRaw(dataRep, data, n)
+	if err != nil {
+		return nil, err
+	}
+	return Scale(raw, ref, E, D), nil
+}
+
+// DecodeSimpleRaw unpacks the n packed integers X of a simple packed field (template 5.0)
+// along with its scaling parameters. A field packed with 0 bits is constant: every X is 0.
+// Fields may be packed with up to 64 bits per value; at 64 bits, a packed integer above
+// math.MaxInt64 cannot be returned and fails the decoding.
+func DecodeSimpleRaw(dataRep *template.DataRepTemplate, data []byte, n int) (raw []int64, ref float64, E, D int, err error) {
+	return DecodeSimpleRawFrom(dataRep, data, 0, n)
+}
+
+// SimpleByteRange returns the range of the Section 7 payload of a simple packed field
+// (template 5.0) holding the n packed integers from the first-th, and the number of bits
+// preceding the first of them in that range, for DecodeSimpleRawFrom
+func SimpleByteRange(dataRep *template.DataRepTemplate, first, n int) (offset, length int64, skip uint) {
+	bits := int64(dataRep.NumberOfBitsUsedForData)
+	start, end := int64(first)*bits, int64(first+n)*bits
+	offset = start / 8
+	return offset, (end+7)/8 - offset, uint(start % 8)
+}
+
+// DecodeSimpleRawFrom is like DecodeSimpleRaw for packed integers starting skip bits into
+// data, such as the range given by SimpleByteRange
+func DecodeSimpleRawFrom(dataRep *template.DataRepTemplate, data []byte, skip uint, n int) (raw []int64, ref float64, E, D int, err error) {
+	if n < 0 {
+		return nil, 0, 0, 0, fmt.Errorf("packing: invalid number of values %d", n)
+	}
+
+	bits := uint(dataRep.NumberOfBitsUsedForData)
+	if bits > maxBitsPerValue {
+		return nil, 0, 0, 0, fmt.Errorf("packing: %d bits per value exceeds the limit of %d", bits, maxBitsPerValue)
+	}
+
+	ref, E, D = dataRep.ReferenceValue, int(dataRep.BinaryScaleFactor), int(dataRep.DecimalScaleFactor)
+	if bits == 0 {
+		return make([]int64, n), ref, E, D, nil
+	}
+
+	// Checked before allocating, so that n cannot exceed what the data holds
+	if need := (uint64(skip) + uint64(n)*uint64(bits) + 7) / 8; uint64(len(data)) < need {
+		return nil, 0, 0, 0, fmt.Errorf("packing: simple packing needs %d bytes for %d values, got %d", need, n, len(data))
+	}
+
+	raw = make([]int64, n)
+	r := bitio.NewReader(data)
+	if err := r.Skip(uint64(skip)); err != nil {
+		return nil, 0, 0, 0, err
+	}
+	for i := range raw {
+		x, err := r.ReadBits(bits)
+		if err != nil {
+			return nil, 0, 0, 0, err
+		}
+		if x > math.MaxInt64 {
+			return nil, 0, 0, 0, fmt.Errorf("packing: packed integer %d of value %d overflows int64", x, i)
+		}
+		raw[i] = int64(x)
+	}
+
+	return raw, ref, E, D, nil
+}
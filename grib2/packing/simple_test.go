@@ -0,0 +1,150 @@
+package packing_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/scorix/grib/grib2/packing"
+	"github.com/scorix/grib/grib2/template"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeSimple(t *testing.T) {
+	dataRep := &template.DataRepTemplate{
+		TemplateNumber:          0,
+		ReferenceValue:          100,
+		BinaryScaleFactor:       1,
+		DecimalScaleFactor:      1,
+		NumberOfBitsUsedForData: 4,
+	}
+
+	// Packed integers 0, 1, 15, 8 at 4 bits each
+	values, err := packing.DecodeSimple(dataRep, []byte{0x01, 0xf8}, 4)
+	require.NoError(t, err)
+	assert.InDeltaSlice(t, []float64{10, 10.2, 13, 11.6}, values, 1e-9)
+}
+
+func TestDecodeSimple_CrossesByteBoundary(t *testing.T) {
+	dataRep := &template.DataRepTemplate{NumberOfBitsUsedForData: 12}
+
+	// Packed integers 0xabc, 0x123 at 12 bits each
+	values, err := packing.DecodeSimple(dataRep, []byte{0xab, 0xc1, 0x23}, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []float64{0xabc, 0x123}, values)
+}
+
+func TestDecodeSimple_Constant(t *testing.T) {
+	dataRep := &template.DataRepTemplate{ReferenceValue: 2731.5, DecimalScaleFactor: 1}
+
+	values, err := packing.DecodeSimple(dataRep, nil, 3)
+	require.NoError(t, err)
+	assert.InDeltaSlice(t, []float64{273.15, 273.15, 273.15}, values, 1e-9)
+}
+
+func TestDecodeSimple_ShortData(t *testing.T) {
+	dataRep := &template.DataRepTemplate{NumberOfBitsUsedForData: 16}
+
+	_, err := packing.DecodeSimple(dataRep, []byte{0x00, 0x01, 0x02}, 2)
+	assert.Error(t, err)
+
+	// A count far beyond the data is rejected before the values are allocated
+	_, _, _, _, err = packing.DecodeSimpleRaw(dataRep, []byte{0x00, 0x01, 0x02}, 1<<40)
+	assert.ErrorContains(t, err, "simple packing needs 2199023255552 bytes")
+}
+
+func TestDecodeSimple_WideBitsPerValue(t *testing.T) {
+	// Packed integers 2^47 and 1 at 48 bits each
+	dataRep := &template.DataRepTemplate{NumberOfBitsUsedForData: 48}
+	raw, _, _, _, err := packing.DecodeSimpleRaw(dataRep, []byte{0x80, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1}, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1 << 47, 1}, raw)
+
+	// At 64 bits, packed integers above math.MaxInt64 cannot be returned
+	dataRep.NumberOfBitsUsedForData = 64
+	_, _, _, _, err = packing.DecodeSimpleRaw(dataRep, []byte{0x80, 0, 0, 0, 0, 0, 0, 0}, 1)
+	assert.ErrorContains(t, err, "overflows int64")
+
+	dataRep.NumberOfBitsUsedForData = 65
+	_, err = packing.DecodeSimple(dataRep, make([]byte, 16), 1)
+	assert.ErrorContains(t, err, "65 bits per value exceeds the limit of 64")
+}
+
+func TestDecode_UnsupportedTemplate(t *testing.T) {
+	_, err := packing.Decode(&template.DataRepTemplate{TemplateNumber: 200}, nil, 0)
+	assert.Error(t, err)
+}
+
+func TestDecodeSimpleRaw(t *testing.T) {
+	dataRep := &template.DataRepTemplate{
+		TemplateNumber:          0,
+		ReferenceValue:          100,
+		BinaryScaleFactor:       1,
+		DecimalScaleFactor:      1,
+		NumberOfBitsUsedForData: 4,
+	}
+
+	raw, ref, E, D, err := packing.DecodeSimpleRaw(dataRep, []byte{0x01, 0xf8}, 4)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{0, 1, 15, 8}, raw)
+	assert.Equal(t, 100.0, ref)
+	assert.Equal(t, 1, E)
+	assert.Equal(t, 1, D)
+}
+
+func TestDecodeRaw_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		dataRep *template.DataRepTemplate
+		data    []byte
+		n       int
+	}{
+		{
+			name:    "positive scales",
+			dataRep: &template.DataRepTemplate{ReferenceValue: 100, BinaryScaleFactor: 1, DecimalScaleFactor: 1, NumberOfBitsUsedForData: 4},
+			data:    []byte{0x01, 0xf8},
+			n:       4,
+		},
+		{
+			name:    "negative scales",
+			dataRep: &template.DataRepTemplate{ReferenceValue: -3.25, BinaryScaleFactor: -3, DecimalScaleFactor: -2, NumberOfBitsUsedForData: 12},
+			data:    []byte{0xab, 0xc1, 0x23},
+			n:       2,
+		},
+		{
+			name:    "constant",
+			dataRep: &template.DataRepTemplate{ReferenceValue: 2731.5, DecimalScaleFactor: 1},
+			n:       3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw, ref, E, D, err := packing.DecodeRaw(tt.dataRep, tt.data, tt.n)
+			require.NoError(t, err)
+
+			values, err := packing.Decode(tt.dataRep, tt.data, tt.n)
+			require.NoError(t, err)
+
+			require.Len(t, raw, len(values))
+			for i, x := range raw {
+				assert.Equal(t, (ref+float64(x)*math.Pow(2, float64(E)))*math.Pow(10, -float64(D)), values[i])
+			}
+		})
+	}
+}
+
+func TestDecodeSimpleRawFrom(t *testing.T) {
+	dataRep := &template.DataRepTemplate{NumberOfBitsUsedForData: 12}
+	data := []byte{0xab, 0xc1, 0x23, 0x45, 0x6f}
+
+	// The second and third integers, 0x123 and 0x456, start 4 bits into the second byte
+	offset, length, skip := packing.SimpleByteRange(dataRep, 1, 2)
+	assert.Equal(t, int64(1), offset)
+	assert.Equal(t, int64(4), length)
+	assert.Equal(t, uint(4), skip)
+
+	raw, _, _, _, err := packing.DecodeSimpleRawFrom(dataRep, data[offset:offset+length], skip, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{0x123, 0x456}, raw)
+}
@@ -0,0 +1,268 @@
+package reader
+
+import (
+	"fmt"
+
+	"github.com/scorix/grib/grib2/section"
+	"github.com/scorix/grib/grib2/spec"
+)
+
+// ErrUnexpectedSection reports a section that is duplicated or out of order within a message
+type ErrUnexpectedSection struct {
+	Section       uint8  // Number of the unexpected section
+	Position      int    // Position of the section within the message, Section 0 being 0
+	MessageOffset int64  // Start offset of the message
+	Reason        string // What is wrong with the section
+}
+
+// Error implements the error interface
+func (e *ErrUnexpectedSection) Error() string {
+	return fmt.Sprintf("unexpected Section %d at position %d of message at offset %d: %s",
+		e.Section, e.Position, e.MessageOffset, e.Reason)
+}
+
+// sectionOrder checks the order of sections within a message. Sections follow
+// 0, 1, then repetitions of [2] [3] 4 5 [6] 7, then 8: a repetition may start at
+// Section 2, 3 or 4 once a field is complete. A Section 4 directly after a Section 2
+// reuses the previous grid definition, as ecCodes does.
+type sectionOrder struct {
+	offset   int64 // Start offset of the message
+	position int   // Position of the next section
+	last     uint8 // Number of the last accepted section
+	started  bool  // Section 0 was accepted
+	grid     bool  // A Section 3 was accepted
+}
+
+// orderCheck is the outcome of checking one section
+type orderCheck uint8
+
+const (
+	orderAccept  orderCheck = iota // The section is in order
+	orderSkip                      // The section is out of order and is skipped
+	orderDiscard                   // The section is kept but ends an incomplete field, which is dropped
+)
+
+// check validates the next section, advancing the state when the section is accepted.
+// An anomaly is returned for skipped sections and discarded fields.
+func (o *sectionOrder) check(number uint8) (orderCheck, *ErrUnexpectedSection) {
+	position := o.position
+	o.position++
+
+	reason := o.reason(number)
+	if reason == "" {
+		o.advance(number)
+		return orderAccept, nil
+	}
+
+	anomaly := &ErrUnexpectedSection{Section: number, Position: position, MessageOffset: o.offset, Reason: reason}
+
+	// Section 8 always ends the message, and a new field still applies after an incomplete one
+	if number == 8 || number == 4 && o.grid && o.last >= 4 && o.last <= 6 {
+		o.advance(number)
+		return orderDiscard, anomaly
+	}
+	return orderSkip, anomaly
+}
+
+// reason explains why a section may not follow the current state, or returns ""
+func (o *sectionOrder) reason(number uint8) string {
+	if !o.started {
+		if number == 0 {
+			return ""
+		}
+		return "message does not start with Section 0"
+	}
+
+	switch number {
+	case 0:
+		return "duplicate Section 0"
+	case 1:
+		if o.last != 0 {
+			return fmt.Sprintf("Section 1 after Section %d", o.last)
+		}
+		return ""
+	case 4:
+		if !o.grid {
+			return "no grid definition precedes the product definition"
+		}
+		if o.last >= 4 && o.last <= 6 {
+			return "previous field has no data section"
+		}
+	case 8:
+		if o.last >= 4 && o.last <= 6 {
+			return "last field has no data section"
+		}
+	}
+
+	if !o.follows(number) {
+		return fmt.Sprintf("Section %d after Section %d", number, o.last)
+	}
+	return ""
+}
+
+// follows reports whether the section may directly follow the last accepted section
+func (o *sectionOrder) follows(number uint8) bool {
+	switch number {
+	case 2:
+		return o.last == 1 || o.last == 7
+	case 3:
+		return o.last == 1 || o.last == 2 || o.last == 7
+	case 4:
+		return o.last == 2 || o.last == 3 || o.last == 7
+	case 5:
+		return o.last == 4
+	case 6:
+		return o.last == 5
+	case 7:
+		return o.last == 5 || o.last == 6
+	case 8:
+		return o.last == 7
+	default:
+		return false
+	}
+}
+
+// advance records an accepted section
+func (o *sectionOrder) advance(number uint8) {
+	switch number {
+	case 0:
+		o.started = true
+	case 3:
+		o.grid = true
+	}
+	o.last = number
+}
+
+// checkSectionOrder checks the sections of a message, returning the anomalies found,
+// or the first of them in strict mode
+func checkSectionOrder(offset int64, sections []SectionInfo, strict bool) ([]error, error) {
+	order := sectionOrder{offset: offset}
+
+	var anomalies []error
+	for _, sec := range sections {
+		if _, anomaly := order.check(sec.Number); anomaly != nil {
+			if strict {
+				return nil, anomaly
+			}
+			anomalies = append(anomalies, anomaly)
+		}
+	}
+	return anomalies, nil
+}
+
+// assembler builds the nested structure of a message from its sections in file order
+type assembler struct {
+	order     sectionOrder
+	strict    bool
+	message   spec.Message
+	anomalies []error
+
+	local    *spec.LocalBlock
+	grid     *spec.GridBlock
+	field    *spec.DataField
+	lastGrid section.Section3 // Grid definition carried into local blocks without their own
+	bitmap   section.Section6 // Last bit-map defined, reused by bit-map indicator 254
+}
+
+// newAssembler starts assembling the message at offset
+func newAssembler(offset int64, opts options) *assembler {
+	return &assembler{order: sectionOrder{offset: offset}, strict: opts.strictSections}
+}
+
+// add places the next section of the message. Out of order sections are skipped and recorded
+// as anomalies, or returned as *ErrUnexpectedSection in strict mode.
+func (a *assembler) add(sec section.Section) error {
+	check, anomaly := a.order.check(sec.SectionNumber())
+	if anomaly != nil {
+		if a.strict {
+			return anomaly
+		}
+		a.anomalies = append(a.anomalies, anomaly)
+	}
+
+	switch check {
+	case orderSkip:
+		return nil
+	case orderDiscard:
+		a.field = nil
+	}
+
+	if a.strict {
+		if err := checkSectionLength(a.order.offset, sec.SectionNumber(), sec.Length()); err != nil {
+			return err
+		}
+	}
+
+	switch s := sec.(type) {
+	case section.Section0:
+		a.message.Indicator = s
+	case section.Section1:
+		a.message.Identification = s
+	case section.Section2:
+		a.finishLocal()
+		a.local = &spec.LocalBlock{LocalUse: s}
+	case section.Section3:
+		a.finishGrid()
+		if a.local == nil {
+			a.local = &spec.LocalBlock{}
+		}
+		a.grid = &spec.GridBlock{GridDef: s}
+		a.lastGrid = s
+	case section.Section4:
+		a.finishField()
+		if a.grid == nil {
+			// A local block starting without a grid definition reuses the previous one
+			a.grid = &spec.GridBlock{GridDef: a.lastGrid}
+		}
+		a.field = &spec.DataField{ProductDef: s}
+	case section.Section5:
+		a.field.DataRep = s
+	case section.Section6:
+		a.field.Bitmap = s
+		switch s.BitMapIndicator() {
+		case 0:
+			a.bitmap = s
+		case 254:
+			a.field.PreviousBitmap = a.bitmap
+		}
+	case section.Section7:
+		a.field.Data = s
+	case section.Section8:
+		a.finishLocal()
+		a.message.End = s
+	}
+
+	return nil
+}
+
+// finishField appends the current field, if complete, to the current grid block
+func (a *assembler) finishField() {
+	if a.field != nil && a.field.Data != nil {
+		a.grid.Fields = append(a.grid.Fields, *a.field)
+	}
+	a.field = nil
+}
+
+// finishGrid appends the current grid block, if it holds fields, to the current local block
+func (a *assembler) finishGrid() {
+	a.finishField()
+	if a.grid != nil && len(a.grid.Fields) > 0 {
+		a.local.Grids = append(a.local.Grids, *a.grid)
+	}
+	a.grid = nil
+}
+
+// finishLocal appends the current local block, if it holds grids, to the message
+func (a *assembler) finishLocal() {
+	a.finishGrid()
+	if a.local != nil && len(a.local.Grids) > 0 {
+		a.message.Blocks = append(a.message.Blocks, *a.local)
+	}
+	a.local = nil
+}
+
+// result returns the assembled message and the anomalies met, finishing any open blocks
+func (a *assembler) result() (spec.Message, []error) {
+	a.finishLocal()
+	return a.message, a.anomalies
+}
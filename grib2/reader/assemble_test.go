@@ -0,0 +1,198 @@
+package reader_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/scorix/grib/grib2/internal/testgrib"
+	"github.com/scorix/grib/grib2/reader"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// messageFromSections builds a message from sections given by number, in the given order
+func messageFromSections(numbers ...uint8) []byte {
+	sections := testgrib.MustEncodeSections(testgrib.Spec{Ni: 2, Nj: 2, LocalUse: []byte{0x01, 0x02}})
+
+	var parts [][]byte
+	for _, number := range numbers {
+		parts = append(parts, sections[number])
+	}
+	return testgrib.Assemble(0, parts...)
+}
+
+// messageIterator is implemented by both Reader and ReaderAt
+type messageIterator interface {
+	EachMessage(func(int, reader.MessageInfo) bool) error
+	EachFlatMessage(func(int, reader.FlatMessage) bool) error
+}
+
+func TestUnexpectedSections(t *testing.T) {
+	tests := []struct {
+		name      string
+		sections  []uint8 // Sections 1-7 in file order
+		fields    int     // Fields assembled in lenient mode
+		anomalies []reader.ErrUnexpectedSection
+	}{
+		{
+			name:     "in order",
+			sections: []uint8{1, 3, 4, 5, 6, 7, 4, 5, 6, 7},
+			fields:   2,
+		},
+		{
+			name:      "duplicate Section 1",
+			sections:  []uint8{1, 1, 3, 4, 5, 6, 7},
+			fields:    1,
+			anomalies: []reader.ErrUnexpectedSection{{Section: 1, Position: 2, Reason: "Section 1 after Section 1"}},
+		},
+		{
+			name:      "Section 5 before its Section 4",
+			sections:  []uint8{1, 3, 5, 4, 5, 6, 7},
+			fields:    1,
+			anomalies: []reader.ErrUnexpectedSection{{Section: 5, Position: 3, Reason: "Section 5 after Section 3"}},
+		},
+		{
+			name:      "stray Section 6",
+			sections:  []uint8{1, 3, 4, 5, 6, 7, 6},
+			fields:    1,
+			anomalies: []reader.ErrUnexpectedSection{{Section: 6, Position: 7, Reason: "Section 6 after Section 7"}},
+		},
+		{
+			name:      "field without data",
+			sections:  []uint8{1, 3, 4, 5, 6, 4, 5, 6, 7},
+			fields:    1,
+			anomalies: []reader.ErrUnexpectedSection{{Section: 4, Position: 6, Reason: "previous field has no data section"}},
+		},
+		{
+			name:     "last field without data",
+			sections: []uint8{1, 3, 4, 5, 6, 7, 4, 5},
+			fields:   1,
+			anomalies: []reader.ErrUnexpectedSection{
+				{Section: 8, Position: 9, Reason: "last field has no data section"},
+			},
+		},
+		{
+			name:     "local block reusing the previous grid",
+			sections: []uint8{1, 3, 4, 5, 6, 7, 2, 4, 5, 6, 7},
+			fields:   2,
+		},
+		{
+			name:     "local block with its own grid",
+			sections: []uint8{1, 2, 3, 4, 5, 6, 7, 2, 3, 4, 5, 6, 7},
+			fields:   2,
+		},
+		{
+			name:     "local block without any grid",
+			sections: []uint8{1, 2, 4, 5, 6, 7},
+			fields:   0,
+			anomalies: []reader.ErrUnexpectedSection{
+				{Section: 4, Position: 3, Reason: "no grid definition precedes the product definition"},
+				{Section: 5, Position: 4, Reason: "Section 5 after Section 2"},
+				{Section: 6, Position: 5, Reason: "Section 6 after Section 2"},
+				{Section: 7, Position: 6, Reason: "Section 7 after Section 2"},
+				{Section: 8, Position: 7, Reason: "Section 8 after Section 2"},
+			},
+		},
+		{
+			name:     "product definition without grid",
+			sections: []uint8{1, 4, 5, 6, 7},
+			fields:   0,
+			anomalies: []reader.ErrUnexpectedSection{
+				{Section: 4, Position: 2, Reason: "no grid definition precedes the product definition"},
+				{Section: 5, Position: 3, Reason: "Section 5 after Section 1"},
+				{Section: 6, Position: 4, Reason: "Section 6 after Section 1"},
+				{Section: 7, Position: 5, Reason: "Section 7 after Section 1"},
+				{Section: 8, Position: 6, Reason: "Section 8 after Section 1"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		// A leading well-formed message puts the tested one at a non-zero offset
+		leading := messageFromSections(1, 3, 4, 5, 6, 7)
+		data := append(leading, messageFromSections(tt.sections...)...)
+
+		var want []error
+		for _, anomaly := range tt.anomalies {
+			anomaly.MessageOffset = int64(len(leading))
+			want = append(want, &anomaly)
+		}
+
+		readers := map[string]func(opts ...reader.Option) messageIterator{
+			"Reader": func(opts ...reader.Option) messageIterator {
+				return reader.NewReader(bytes.NewReader(data), opts...)
+			},
+			"ReaderAt": func(opts ...reader.Option) messageIterator {
+				return reader.NewReaderAt(bytes.NewReader(data), opts...)
+			},
+		}
+
+		for name, newReader := range readers {
+			t.Run(tt.name+"/"+name, func(t *testing.T) {
+				var infos []reader.MessageInfo
+				require.NoError(t, newReader().EachMessage(func(_ int, info reader.MessageInfo) bool {
+					infos = append(infos, info)
+					return true
+				}))
+				require.Len(t, infos, 2)
+				assert.Empty(t, infos[0].Anomalies)
+				assert.Equal(t, want, infos[1].Anomalies)
+
+				fields := 0
+				require.NoError(t, newReader().EachFlatMessage(func(_ int, msg reader.FlatMessage) bool {
+					if msg.Offset == int64(len(leading)) {
+						require.NotNil(t, msg.Data)
+						fields++
+					}
+					return true
+				}))
+				assert.Equal(t, tt.fields, fields)
+
+				err := newReader(reader.WithStrictSections()).EachMessage(func(int, reader.MessageInfo) bool { return true })
+				if len(want) == 0 {
+					require.NoError(t, err)
+					return
+				}
+				var unexpected *reader.ErrUnexpectedSection
+				require.ErrorAs(t, err, &unexpected)
+				assert.Equal(t, want[0], unexpected)
+			})
+		}
+	}
+}
+
+func TestLocalBlockReusesGrid(t *testing.T) {
+	data := messageFromSections(1, 3, 4, 5, 6, 7, 2, 4, 5, 6, 7)
+
+	for name, r := range map[string]messageIterator{
+		"Reader":   reader.NewReader(bytes.NewReader(data)),
+		"ReaderAt": reader.NewReaderAt(bytes.NewReader(data)),
+	} {
+		t.Run(name, func(t *testing.T) {
+			var messages []reader.FlatMessage
+			require.NoError(t, r.EachFlatMessage(func(_ int, msg reader.FlatMessage) bool {
+				messages = append(messages, msg)
+				return true
+			}))
+			require.Len(t, messages, 2)
+			require.NotNil(t, messages[1].GridDef)
+			assert.Equal(t, messages[0].GridDef, messages[1].GridDef)
+			assert.Equal(t, messages[0].Grid, messages[1].Grid)
+		})
+	}
+
+	// The shared Section 3 is counted once, as is one the local block repeats
+	for _, layout := range [][]uint8{{1, 3, 4, 5, 6, 7, 2, 4, 5, 6, 7}, {1, 3, 4, 5, 6, 7, 2, 3, 4, 5, 6, 7}} {
+		r := reader.NewReaderAt(bytes.NewReader(messageFromSections(layout...)))
+		var infos []reader.MessageInfo
+		require.NoError(t, r.EachMessage(func(_ int, info reader.MessageInfo) bool {
+			infos = append(infos, info)
+			return true
+		}))
+		require.Len(t, infos, 1)
+		msg, err := r.ReadMessage(infos[0])
+		require.NoError(t, err)
+		require.Len(t, msg.Blocks, 2)
+		assert.NoError(t, msg.Validate(), "sections %v", layout)
+	}
+}
@@ -0,0 +1,231 @@
+package reader
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+
+	"github.com/scorix/grib/grib2/section"
+	"github.com/scorix/grib/grib2/spec"
+)
+
+// Levels of template support reported by Audit, from the most to the least complete
+const (
+	SupportGeolocate = "geolocate" // Grid template whose point coordinates are available
+	SupportDecode    = "decode"    // Data representation template whose values can be decoded
+	SupportParse     = "parse"     // Template-specific fields are extracted
+	SupportMetadata  = "metadata"  // Only the common fields of the section are read
+)
+
+// resyncChunkSize is the size of the reads made while searching for the next message
+const resyncChunkSize = 64 << 10
+
+// SectionAudit describes one scanned section of an audited message
+type SectionAudit struct {
+	Number   uint8   `json:"number"`             // Section number
+	Offset   int64   `json:"offset"`             // Start offset of the section in the file
+	Length   uint32  `json:"length"`             // Section length
+	Parsed   bool    `json:"parsed"`             // True if the section was read without error
+	Template *uint16 `json:"template,omitempty"` // Template number of Sections 3, 4 and 5
+	Support  string  `json:"support,omitempty"`  // Support level of the template
+	Error    string  `json:"error,omitempty"`    // Error met while reading the section
+}
+
+// MessageAudit is the integrity summary of one message, found without decoding its data
+type MessageAudit struct {
+	Index            int            `json:"index"`             // Index of the message in the file
+	Offset           int64          `json:"offset"`            // Start offset of the message
+	SkippedBytes     int64          `json:"skipped_bytes"`     // Bytes skipped before the message while searching for its GRIB marker
+	Edition          uint8          `json:"edition"`           // GRIB edition number
+	TotalLength      uint64         `json:"total_length"`      // Total length coded in Section 0
+	ScannedLength    uint64         `json:"scanned_length"`    // Length up to the end marker, zero when none was found
+	LengthConsistent bool           `json:"length_consistent"` // True if the total length matches the scanned length
+	EndMarkerValid   bool           `json:"end_marker_valid"`  // True if the sections end with a valid "7777" marker
+	PointsConsistent bool           `json:"points_consistent"` // True if every assembled field packs as many values as its grid and bit-map select
+	Sections         []SectionAudit `json:"sections"`          // Scanned sections in file order
+	Error            string         `json:"error,omitempty"`   // First error encountered, empty for a sound message
+	Source           string         `json:"source,omitempty"`  // Source label given with WithSource
+}
+
+// OK reports whether no problem was found in the message
+func (a MessageAudit) OK() bool {
+	return a.Error == ""
+}
+
+// Audit checks the integrity of every message in the file without decoding data values:
+// the parse status of each section, the total length against the end marker, the support
+// level of the templates and the number of packed values against the grid and bit-map.
+// Problems are recorded in the audits rather than returned; after a message whose end
+// cannot be located, the search resumes at the next GRIB marker. The error is only set
+// when the file cannot be read.
+func (r *ReaderAt) Audit() ([]MessageAudit, error) {
+	var audits []MessageAudit
+
+	resumeAt := int64(0)
+	for {
+		offset, err := r.nextMarker(resumeAt)
+		if err != nil {
+			return audits, r.opts.sourced(err)
+		}
+		if offset < 0 {
+			return audits, nil
+		}
+
+		audit := r.auditMessage(len(audits), offset)
+		audit.SkippedBytes = offset - resumeAt
+		audits = append(audits, audit)
+
+		if audit.ScannedLength > 0 {
+			resumeAt = offset + int64(audit.ScannedLength)
+		} else {
+			// The end of the message is unknown; search from just past its marker
+			resumeAt = offset + 4
+		}
+	}
+}
+
+// nextMarker returns the offset of the first GRIB marker at or after offset, or -1 when
+// there is none before the end of the file
+func (r *ReaderAt) nextMarker(offset int64) (int64, error) {
+	marker := []byte("GRIB")
+	buf := make([]byte, resyncChunkSize)
+
+	for {
+		n, err := r.reader.ReadAt(buf, offset)
+		if i := bytes.Index(buf[:n], marker); i >= 0 {
+			return offset + int64(i), nil
+		}
+		if err == io.EOF || (err == nil && n < len(marker)) {
+			return -1, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to read at offset %d: %w", offset, err)
+		}
+		// Overlap the chunks so that a marker across their boundary is found
+		offset += int64(n - len(marker) + 1)
+	}
+}
+
+// auditMessage audits the message whose GRIB marker is at offset
+func (r *ReaderAt) auditMessage(index int, offset int64) (audit MessageAudit) {
+	audit = MessageAudit{Index: index, Offset: offset, Source: r.opts.source}
+	var errs []error
+	defer func() {
+		if len(errs) > 0 {
+			audit.Error = errs[0].Error()
+		}
+	}()
+
+	header := make([]byte, 16)
+	if _, err := r.reader.ReadAt(header, offset); err != nil {
+		errs = append(errs, fmt.Errorf("failed to read Section 0 header at offset %d: %w", offset, err))
+		return audit
+	}
+	audit.Edition = header[7]
+	audit.TotalLength = binary.BigEndian.Uint64(header[8:16])
+	if audit.TotalLength < minMessageLength || audit.TotalLength > uint64(math.MaxInt64-offset) {
+		errs = append(errs, fmt.Errorf("invalid total length %d at offset %d", audit.TotalLength, offset))
+		return audit
+	}
+
+	sections, overrun, err := r.scanMessageSections(offset, audit.TotalLength)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("failed to scan sections: %w", err))
+	}
+	if overrun != nil {
+		errs = append(errs, overrun)
+	}
+
+	length, lengthAnomaly, _ := checkMessageLength(offset, audit.TotalLength, sections, false)
+	if len(sections) > 0 && sections[len(sections)-1].Number == 8 {
+		audit.ScannedLength = length
+		audit.LengthConsistent = lengthAnomaly == nil
+	}
+	orderAnomalies, _ := checkSectionOrder(offset, sections, false)
+	errs = append(errs, orderAnomalies...)
+	if lengthAnomaly != nil {
+		errs = append(errs, lengthAnomaly)
+	}
+
+	assembler := newAssembler(offset, options{})
+	for _, info := range sections {
+		sectionAudit, sec := r.auditSection(offset, info)
+		audit.Sections = append(audit.Sections, sectionAudit)
+		if sec == nil {
+			errs = append(errs, fmt.Errorf("section %d at offset %d: %s", info.Number, info.Offset, sectionAudit.Error))
+			continue
+		}
+		if end, ok := sec.(section.Section8); ok {
+			audit.EndMarkerValid = end.IsValid()
+		}
+		_ = assembler.add(sec)
+	}
+	if !audit.EndMarkerValid && err == nil {
+		errs = append(errs, fmt.Errorf("no end marker within the message at offset %d", offset))
+	}
+
+	message, _ := assembler.result()
+	audit.PointsConsistent = true
+	if validateErr := message.Validate(); validateErr != nil {
+		var pointCount *spec.ErrPointCount
+		audit.PointsConsistent = !errors.As(validateErr, &pointCount)
+		if audit.EndMarkerValid && err == nil {
+			// Validation of a message cut short only repeats the problems found above
+			errs = append(errs, validateErr)
+		}
+	}
+
+	return audit
+}
+
+// auditSection reads the section described by info, of the message at messageOffset, and
+// reports its parse status and the support level of its template. The section is nil
+// when it could not be read.
+func (r *ReaderAt) auditSection(messageOffset int64, info SectionInfo) (SectionAudit, section.Section) {
+	audit := SectionAudit{Number: info.Number, Offset: info.Offset, Length: info.Length}
+
+	sec, err := r.readScannedSection(messageOffset, info)
+	if err != nil {
+		audit.Error = err.Error()
+		return audit, nil
+	}
+	audit.Parsed = true
+
+	var number uint16
+	var supports []TemplateSupport
+	switch s := sec.(type) {
+	case section.Section3:
+		number, supports = s.GridDefinitionTemplateNumber(), SupportedGridTemplates()
+	case section.Section4:
+		number, supports = s.ProductDefinitionTemplateNumber(), SupportedProductTemplates()
+	case section.Section5:
+		number, supports = s.DataRepresentationTemplateNumber(), SupportedDataRepTemplates()
+	default:
+		return audit, sec
+	}
+	audit.Template = &number
+	audit.Support = supportLevel(supports, number)
+
+	return audit, sec
+}
+
+// supportLevel returns the most complete support level of a template
+func supportLevel(supports []TemplateSupport, number uint16) string {
+	i := slices.IndexFunc(supports, func(s TemplateSupport) bool { return s.Number == number })
+	switch {
+	case i < 0:
+		return SupportMetadata
+	case supports[i].Geolocate:
+		return SupportGeolocate
+	case supports[i].Decode:
+		return SupportDecode
+	case supports[i].Parse:
+		return SupportParse
+	default:
+		return SupportMetadata
+	}
+}
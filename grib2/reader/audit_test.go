@@ -0,0 +1,87 @@
+package reader_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/scorix/grib/grib2/internal/testgrib"
+	"github.com/scorix/grib/grib2/reader"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// auditCorpus returns a file of one clean message followed by three differently corrupted ones
+func auditCorpus() []byte {
+	clean := testgrib.MustEncode(testgrib.Spec{})
+
+	// Section 0 claims 8 more octets than the message holds
+	longer := testgrib.MustEncode(testgrib.Spec{ForecastHours: 6})
+	binary.BigEndian.PutUint64(longer[8:16], uint64(len(longer)+8))
+
+	// The end marker is damaged, so the end of the message cannot be located
+	unterminated := testgrib.MustEncode(testgrib.Spec{ForecastHours: 12})
+	copy(unterminated[len(unterminated)-4:], "7770")
+
+	// Section 5 starts after Sections 0 (16), 1 (21), 3 (72) and 4 (34); claim one extra packed value
+	miscounted := testgrib.MustEncode(testgrib.Spec{ForecastHours: 18})
+	numberOfValues := miscounted[16+21+72+34+5:]
+	binary.BigEndian.PutUint32(numberOfValues, binary.BigEndian.Uint32(numberOfValues)+1)
+
+	var data []byte
+	for _, part := range [][]byte{clean, longer, unterminated, []byte("junk"), miscounted} {
+		data = append(data, part...)
+	}
+	return data
+}
+
+func TestAudit_Golden(t *testing.T) {
+	audits, err := reader.NewReaderAt(bytes.NewReader(auditCorpus())).Audit()
+	require.NoError(t, err)
+	require.Len(t, audits, 4)
+
+	assert.True(t, audits[0].OK())
+	assert.False(t, audits[1].LengthConsistent)
+	assert.False(t, audits[2].EndMarkerValid)
+	assert.False(t, audits[3].PointsConsistent)
+	for _, audit := range audits[1:] {
+		assert.False(t, audit.OK(), "message %d", audit.Index)
+	}
+
+	got, err := json.MarshalIndent(audits, "", "  ")
+	require.NoError(t, err)
+	got = append(got, '\n')
+
+	const golden = "testdata/audit.golden.json"
+	if *update {
+		require.NoError(t, os.WriteFile(golden, got, 0o644))
+	}
+	want, err := os.ReadFile(golden)
+	require.NoError(t, err)
+	assert.Equal(t, string(want), string(got))
+}
+
+func TestAudit_File(t *testing.T) {
+	data, err := os.ReadFile("testdata/gfs.t00z.pgrb2.0p25.f000")
+	require.NoError(t, err)
+
+	audits, err := reader.NewReaderAt(bytes.NewReader(data)).Audit()
+	require.NoError(t, err)
+	require.Len(t, audits, 3)
+
+	for _, audit := range audits {
+		assert.True(t, audit.OK(), audit.Error)
+		assert.Zero(t, audit.SkippedBytes)
+		assert.Equal(t, audit.TotalLength, audit.ScannedLength)
+		for _, sec := range audit.Sections {
+			assert.True(t, sec.Parsed)
+			if sec.Number == 5 {
+				require.NotNil(t, sec.Template)
+				assert.Equal(t, uint16(3), *sec.Template)
+				assert.Equal(t, reader.SupportDecode, sec.Support)
+			}
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package reader
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"sort"
+
+	"github.com/scorix/grib/grib2/section"
+)
+
+// bitmapBlockBits is the number of bit-map bits summarised by each prefix count
+const bitmapBlockBits = 512
+
+// BitmapIndex answers rank and select queries on a Section 6 bit-map in constant and
+// logarithmic time, to locate the packed value of a grid point without scanning the
+// bit-map. It stores the number of bits set before each block of 512 bits.
+type BitmapIndex struct {
+	bitmap []byte
+	n      int   // Number of grid points covered by the bit-map
+	blocks []int // Number of bits set before each block
+	total  int   // Number of bits set among the n points
+}
+
+// NewBitmapIndex indexes the first n bits of a bit-map, one bit per grid point with the
+// most significant bit of the first byte for the first point
+func NewBitmapIndex(bitmap []byte, n int) *BitmapIndex {
+	n = max(min(n, 8*len(bitmap)), 0)
+	idx := &BitmapIndex{bitmap: bitmap, n: n, blocks: make([]int, 0, n/bitmapBlockBits+1)}
+
+	count := 0
+	for start := 0; start < n; start += bitmapBlockBits {
+		idx.blocks = append(idx.blocks, count)
+		count += idx.countRange(start, min(start+bitmapBlockBits, n))
+	}
+	idx.total = count
+	return idx
+}
+
+// Len returns the number of grid points covered by the bit-map
+func (b *BitmapIndex) Len() int {
+	return b.n
+}
+
+// Count returns the number of bits set, which is the number of packed values
+func (b *BitmapIndex) Count() int {
+	return b.total
+}
+
+// Test reports whether the bit of grid point i is set, i.e. whether a value is packed for it
+func (b *BitmapIndex) Test(i int) bool {
+	return i >= 0 && i < b.n && b.bitmap[i/8]&(0x80>>(i%8)) != 0
+}
+
+// Rank returns the number of bits set before grid point i, which is the position among
+// the packed values of the value of point i when its bit is set. i is clamped to [0, Len()].
+func (b *BitmapIndex) Rank(i int) int {
+	i = max(min(i, b.n), 0)
+	if i == b.n {
+		return b.total
+	}
+	block := i / bitmapBlockBits
+	return b.blocks[block] + b.countRange(block*bitmapBlockBits, i)
+}
+
+// Select returns the grid point of the k-th bit set, counting from 0, which is the point
+// the k-th packed value belongs to, or -1 when fewer than k+1 bits are set
+func (b *BitmapIndex) Select(k int) int {
+	if k < 0 || k >= b.total {
+		return -1
+	}
+
+	// The last block with fewer than k+1 bits set before it holds the bit
+	block := sort.Search(len(b.blocks), func(j int) bool { return b.blocks[j] > k }) - 1
+	remaining := k - b.blocks[block]
+	for i := block * bitmapBlockBits; ; i += 8 {
+		byt := b.bitmap[i/8]
+		if ones := bits.OnesCount8(byt); remaining >= ones {
+			remaining -= ones
+			continue
+		}
+		for bit := 0; ; bit++ {
+			if byt&(0x80>>bit) != 0 {
+				if remaining == 0 {
+					return i + bit
+				}
+				remaining--
+			}
+		}
+	}
+}
+
+// countRange counts the bits set for the points from start, a multiple of 8, to end
+func (b *BitmapIndex) countRange(start, end int) int {
+	count := 0
+	full := end / 8
+	for _, byt := range b.bitmap[start/8 : full] {
+		count += bits.OnesCount8(byt)
+	}
+	if rest := end % 8; rest > 0 {
+		count += bits.OnesCount8(b.bitmap[full] & (0xff << (8 - rest)))
+	}
+	return count
+}
+
+// ApplyBitmap expands the packed values of a field with a bit-map to its n grid points:
+// the values fill the points whose bit is set, in order, and the points masked out are
+// NaN. The bit-map must have one bit per point, padded to a whole number of octets with
+// bits that are ignored, and as many bits set as there are values.
+func ApplyBitmap(values []float64, bitmap []byte, n int) ([]float64, error) {
+	if n < 0 || len(bitmap) != (n+7)/8 {
+		return nil, fmt.Errorf("decode: bit-map of %d octets for %d grid points", len(bitmap), n)
+	}
+	if count := NewBitmapIndex(bitmap, n).Count(); count != len(values) {
+		return nil, fmt.Errorf("decode: bit-map with %d bits set for %d values", count, len(values))
+	}
+
+	expanded := make([]float64, n)
+	k := 0
+	for i := range expanded {
+		if bitSet(bitmap, i) {
+			expanded[i] = values[k]
+			k++
+			continue
+		}
+		expanded[i] = math.NaN()
+	}
+	return expanded, nil
+}
+
+// fieldCache holds what is derived from the sections of a field on first use. It is shared
+// by the copies of a FlatMessage made after it was created.
+type fieldCache struct {
+	bitmapIndex *BitmapIndex
+}
+
+// bitmapSection returns the Section 6 holding the bit-map that applies to the field: its
+// own with bit-map indicator 0, or the one defined previously in the message with
+// indicator 254. It is nil when the field has no bit-map or it does not resolve.
+func (f *FlatMessage) bitmapSection() section.Section6 {
+	switch {
+	case f.Bitmap == nil:
+		return nil
+	case f.Bitmap.BitMapIndicator() == 0:
+		return f.Bitmap
+	case f.Bitmap.BitMapIndicator() == 254 && f.PreviousBitmap != nil:
+		return f.PreviousBitmap
+	}
+	return nil
+}
+
+// BitmapIndex returns the index of the bit-map applying to the field, built on first use
+// and cached on the field; ok is false when the field has no bit-map, or one that is not
+// in the message. Like the other methods of FlatMessage that cache, it must not be called
+// concurrently on the same value.
+func (f *FlatMessage) BitmapIndex() (index *BitmapIndex, ok bool) {
+	sec := f.bitmapSection()
+	if sec == nil {
+		return nil, false
+	}
+	if f.cache == nil {
+		f.cache = &fieldCache{}
+	}
+	if f.cache.bitmapIndex == nil {
+		n := 8 * len(sec.BitMap())
+		if f.GridDef != nil {
+			n = int(f.GridDef.NumberOfDataPoints())
+		}
+		f.cache.bitmapIndex = NewBitmapIndex(sec.BitMap(), n)
+	}
+	return f.cache.bitmapIndex, true
+}
@@ -0,0 +1,338 @@
+package reader_test
+
+import (
+	"bytes"
+	"math"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/scorix/grib/grib2/grid"
+	"github.com/scorix/grib/grib2/internal/testgrib"
+	"github.com/scorix/grib/grib2/reader"
+	"github.com/scorix/grib/grib2/writer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBitmapIndex(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	for _, n := range []int{0, 1, 7, 8, 511, 512, 513, 3000} {
+		bitmap := make([]byte, (n+7)/8+1)
+		for k := range bitmap {
+			bitmap[k] = byte(rng.Uint32())
+		}
+		index := reader.NewBitmapIndex(bitmap, n)
+		require.Equal(t, n, index.Len())
+
+		// Compare with counting the bits one by one; bits past n are ignored
+		count := 0
+		for i := range n + 1 {
+			assert.Equal(t, count, index.Rank(i), "n %d rank %d", n, i)
+			if i < n && bitmap[i/8]&(0x80>>(i%8)) != 0 {
+				assert.True(t, index.Test(i))
+				assert.Equal(t, i, index.Select(count), "n %d select %d", n, count)
+				count++
+			} else {
+				assert.False(t, index.Test(i))
+			}
+		}
+		assert.Equal(t, count, index.Count())
+		assert.Equal(t, -1, index.Select(count))
+		assert.Equal(t, -1, index.Select(-1))
+		assert.Equal(t, 0, index.Rank(-1))
+	}
+}
+
+func TestFlatMessage_ValueAt(t *testing.T) {
+	const ni, nj = 6, 5
+	values := make([]float64, ni*nj)
+	bitmap := make([]bool, ni*nj)
+	for k := range values {
+		values[k] = float64(k)
+		bitmap[k] = k%4 != 2
+	}
+
+	for _, mask := range [][]bool{nil, bitmap} {
+		spec := testgrib.Spec{Ni: ni, Nj: nj, LatFirst: 10, LonFirst: 20, ScanningMode: grid.ScanNegativeI, Values: values, Bitmap: mask, BitsPerValue: 5}
+		msg := flatMessages(t, testgrib.MustEncode(spec))[0]
+		_, hasIndex := msg.BitmapIndex()
+		assert.Equal(t, mask != nil, hasIndex)
+
+		def, err := msg.GridDefinition()
+		require.NoError(t, err)
+		for index, v := range values {
+			lat, lon := grid.LatLonAt(def, index)
+			got, ok, err := msg.ValueAt(lat, lon)
+			require.NoError(t, err)
+			require.True(t, ok)
+			if mask != nil && !mask[index] {
+				assert.True(t, math.IsNaN(got), "point %d", index)
+			} else {
+				assert.Equal(t, v, got, "point %d", index)
+			}
+		}
+
+		_, ok, err := msg.ValueAt(-40, 20)
+		require.NoError(t, err)
+		assert.False(t, ok)
+	}
+}
+
+func BenchmarkFlatMessage_ValueAt(b *testing.B) {
+	// A global quarter-degree field with the oceans of a checkerboard masked out
+	const ni, nj = 1440, 721
+	values := make([]float64, ni*nj)
+	bitmap := make([]bool, ni*nj)
+	for k := range values {
+		values[k] = float64(k % 1000)
+		bitmap[k] = (k%ni/40+k/ni/40)%2 == 0
+	}
+	spec := testgrib.Spec{Ni: ni, Nj: nj, LatFirst: 90, Dx: 0.25, Dy: 0.25, Values: values, Bitmap: bitmap, BitsPerValue: 10}
+	msg := flatMessages(b, testgrib.MustEncode(spec))[0]
+
+	b.Run("first", func(b *testing.B) {
+		for b.Loop() {
+			fresh := msg
+			_, _, _ = fresh.ValueAt(-60, 300)
+		}
+	})
+	b.Run("cached", func(b *testing.B) {
+		cached := msg
+		_, _, _ = cached.ValueAt(-60, 300)
+		for b.Loop() {
+			_, _, _ = cached.ValueAt(-60, 300)
+		}
+	})
+}
+
+func TestApplyBitmap(t *testing.T) {
+	nan := math.NaN()
+	tests := []struct {
+		name   string
+		values []float64
+		bitmap []byte
+		n      int
+		want   []float64
+		err    string
+	}{
+		{
+			name:   "whole octets",
+			values: []float64{1, 2, 3, 4},
+			bitmap: []byte{0b10100101, 0b00000000},
+			n:      16,
+			want:   []float64{1, nan, 2, nan, nan, 3, nan, 4, nan, nan, nan, nan, nan, nan, nan, nan},
+		},
+		{
+			name:   "trailing pad bits are ignored",
+			values: []float64{1, 2, 3},
+			bitmap: []byte{0b11000000, 0b10111111},
+			n:      9,
+			want:   []float64{1, 2, nan, nan, nan, nan, nan, nan, 3},
+		},
+		{
+			name:   "no points",
+			values: []float64{},
+			bitmap: []byte{},
+			n:      0,
+			want:   []float64{},
+		},
+		{
+			name:   "bit-map too short",
+			values: []float64{1},
+			bitmap: []byte{0x80},
+			n:      9,
+			err:    "bit-map of 1 octets for 9 grid points",
+		},
+		{
+			name:   "bit-map too long",
+			values: []float64{1},
+			bitmap: []byte{0x80, 0x00},
+			n:      8,
+			err:    "bit-map of 2 octets for 8 grid points",
+		},
+		{
+			name:   "more values than bits set",
+			values: []float64{1, 2},
+			bitmap: []byte{0x80},
+			n:      8,
+			err:    "bit-map with 1 bits set for 2 values",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := reader.ApplyBitmap(tt.values, tt.bitmap, tt.n)
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, got, len(tt.want))
+			for i, want := range tt.want {
+				if math.IsNaN(want) {
+					assert.True(t, math.IsNaN(got[i]), "point %d", i)
+				} else {
+					assert.Equal(t, want, got[i], "point %d", i)
+				}
+			}
+		})
+	}
+}
+
+func TestFlatMessage_Values(t *testing.T) {
+	const ni, nj = 5, 3
+	values := make([]float64, ni*nj)
+	bitmap := make([]bool, ni*nj)
+	for k := range values {
+		values[k] = float64(k)
+		bitmap[k] = k%3 != 1
+	}
+
+	msg := flatMessages(t, testgrib.MustEncode(testgrib.Spec{Ni: ni, Nj: nj, Values: values, Bitmap: bitmap}))[0]
+	packed, err := msg.DecodeData()
+	require.NoError(t, err)
+	assert.Len(t, packed, 10)
+
+	got, err := msg.Values()
+	require.NoError(t, err)
+	require.Len(t, got, ni*nj)
+	for k, v := range got {
+		if bitmap[k] {
+			assert.Equal(t, values[k], v, "point %d", k)
+		} else {
+			assert.True(t, math.IsNaN(v), "point %d", k)
+		}
+	}
+
+	got, err = msg.Values(reader.WithMissingValue(-999))
+	require.NoError(t, err)
+	assert.Equal(t, -999.0, got[1])
+	assert.Equal(t, 12.0, got[12])
+
+	missing, err := msg.MissingCount()
+	require.NoError(t, err)
+	assert.Equal(t, 5, missing)
+
+	// Without bit-map, the values are those of DecodeData
+	msg = flatMessages(t, testgrib.MustEncode(testgrib.Spec{Ni: ni, Nj: nj, Values: values}))[0]
+	got, err = msg.Values()
+	require.NoError(t, err)
+	assert.Equal(t, values, got)
+	missing, err = msg.MissingCount()
+	require.NoError(t, err)
+	assert.Zero(t, missing)
+
+	// Missing values of the packing count as well as masked points
+	withMissing := append([]float64(nil), values...)
+	withMissing[0] = math.NaN()
+	msg = flatMessages(t, testgrib.MustEncode(testgrib.Spec{Packing: 2, Ni: ni, Nj: nj, Values: withMissing, Bitmap: bitmap}))[0]
+	missing, err = msg.MissingCount()
+	require.NoError(t, err)
+	assert.Equal(t, 6, missing)
+
+	// Every point of a field storing no values is missing
+	msg = flatMessages(t, testgrib.MustEncode(testgrib.Spec{Ni: ni, Nj: nj, Values: values, Bitmap: make([]bool, ni*nj)}))[0]
+	got, err = msg.Values(reader.WithMissingValue(-1))
+	assert.ErrorIs(t, err, &reader.ErrNoStoredValues{})
+	assert.Equal(t, []float64{-1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1}, got)
+	missing, err = msg.MissingCount()
+	require.NoError(t, err)
+	assert.Equal(t, ni*nj, missing)
+}
+
+// sharedBitmapMessage builds a message with two fields on a 2x2 grid, the second reusing
+// the bit-map of the first with bit-map indicator 254. With defined false, the first
+// field has no bit-map and the reuse does not resolve.
+func sharedBitmapMessage(defined bool) []byte {
+	bitmap := []bool{true, false, true, true}
+	first := testgrib.MustEncodeSections(testgrib.Spec{Ni: 2, Nj: 2, Values: []float64{1, 0, 2, 3}, Bitmap: bitmap})
+	second := testgrib.MustEncodeSections(testgrib.Spec{Ni: 2, Nj: 2, Parameter: 2, Values: []float64{4, 0, 5, 6}, Bitmap: bitmap})
+	if !defined {
+		first[6] = testgrib.Section(6, []byte{0xff})
+	}
+	second[6] = testgrib.Section(6, []byte{0xfe})
+	return testgrib.Assemble(0, first[1], first[3], first[4], first[5], first[6], first[7], second[4], second[5], second[6], second[7])
+}
+
+func TestBitmapIndicator254(t *testing.T) {
+	data := sharedBitmapMessage(true)
+	readers := map[string]func() []reader.FlatMessage{
+		"Reader": func() []reader.FlatMessage {
+			var fields []reader.FlatMessage
+			require.NoError(t, reader.NewReader(bytes.NewReader(data)).EachFlatMessage(func(_ int, f reader.FlatMessage) bool {
+				fields = append(fields, f)
+				return true
+			}))
+			return fields
+		},
+		"ReaderAt": func() []reader.FlatMessage {
+			return flatMessages(t, data)
+		},
+		"EachFilteredMessage": func() []reader.FlatMessage {
+			var fields []reader.FlatMessage
+			require.NoError(t, reader.NewReaderAt(bytes.NewReader(data)).EachFilteredMessage(reader.Filter{}, func(_ int, f reader.FlatMessage) bool {
+				fields = append(fields, f)
+				return true
+			}))
+			return fields
+		},
+	}
+
+	nan := math.NaN()
+	for name, read := range readers {
+		t.Run(name, func(t *testing.T) {
+			fields := read()
+			require.Len(t, fields, 2)
+			assert.Nil(t, fields[0].PreviousBitmap)
+			require.NotNil(t, fields[1].PreviousBitmap)
+			assert.Equal(t, uint8(254), fields[1].Bitmap.BitMapIndicator())
+
+			ok, reason := fields[1].CanDecode()
+			assert.True(t, ok)
+			assert.NoError(t, reason)
+
+			index, ok := fields[1].BitmapIndex()
+			require.True(t, ok)
+			assert.Equal(t, 3, index.Count())
+
+			for i, want := range [][]float64{{1, nan, 2, 3}, {4, nan, 5, 6}} {
+				got, err := fields[i].Values()
+				require.NoError(t, err)
+				require.Len(t, got, 4)
+				assert.Equal(t, want[0], got[0])
+				assert.True(t, math.IsNaN(got[1]))
+				assert.Equal(t, want[2:], got[2:])
+			}
+		})
+	}
+
+	t.Run("WriteField", func(t *testing.T) {
+		r := reader.NewReaderAt(bytes.NewReader(data))
+		fields := flatMessagesOf(t, r)
+		require.Len(t, fields, 2)
+
+		var out bytes.Buffer
+		require.NoError(t, r.WriteField(writer.NewMessageWriter(&out, writer.WithoutProvenance()), &fields[1]))
+		copied := flatMessages(t, out.Bytes())
+		require.Len(t, copied, 1)
+		assert.Equal(t, uint8(0), copied[0].Bitmap.BitMapIndicator())
+
+		got, err := copied[0].Values()
+		require.NoError(t, err)
+		assert.Equal(t, 4.0, got[0])
+		assert.True(t, math.IsNaN(got[1]))
+		assert.Equal(t, []float64{5, 6}, got[2:])
+	})
+
+	t.Run("no bit-map defined before", func(t *testing.T) {
+		fields := flatMessages(t, sharedBitmapMessage(false))
+		require.Len(t, fields, 2)
+		assert.Nil(t, fields[1].PreviousBitmap)
+
+		ok, reason := fields[1].CanDecode()
+		assert.False(t, ok)
+		assert.Equal(t, &reader.ErrUnresolvedBitmap{Indicator: 254}, reason)
+		_, err := fields[1].Values()
+		assert.Equal(t, &reader.ErrUnresolvedBitmap{Indicator: 254}, err)
+	})
+}
@@ -0,0 +1,74 @@
+package reader
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/scorix/grib/grib2/grid"
+	"github.com/scorix/grib/grib2/template"
+)
+
+// TemplateSupport describes how far a template is supported by the package.
+// Templates that are not listed are metadata-only: the section is read and its
+// common fields are available, but template-specific fields are not extracted.
+type TemplateSupport struct {
+	Number    uint16 // Template number
+	Parse     bool   // Template-specific fields are extracted into FlatMessage
+	Decode    bool   // Data values can be decoded (data representation templates only)
+	Geolocate bool   // Grid point coordinates and cell areas are available (grid templates only)
+}
+
+// SupportedGridTemplates returns the grid definition templates (Table 3.1) with parsing
+// support, including those registered with template.RegisterGridParser
+func SupportedGridTemplates() []TemplateSupport {
+	supports := templateSupport(template.GridTemplateNumbers(), nil)
+	for i := range supports {
+		supports[i].Geolocate = grid.CanGeolocate(int(supports[i].Number))
+	}
+	return supports
+}
+
+// SupportedProductTemplates returns the product definition templates (Table 4.0) with parsing support
+func SupportedProductTemplates() []TemplateSupport {
+	return templateSupport(parserNumbers(productTemplateParsers), nil)
+}
+
+// SupportedDataRepTemplates returns the data representation templates (Table 5.0)
+// that are parsed or decodable
+func SupportedDataRepTemplates() []TemplateSupport {
+	return templateSupport(parserNumbers(dataRepTemplateParsers), decodableDataRepTemplates())
+}
+
+// parserNumbers returns the template numbers of a parser registry
+func parserNumbers(parsers map[uint16]func(*FlatMessage, []byte)) []uint16 {
+	return slices.Collect(maps.Keys(parsers))
+}
+
+// templateSupport merges the template numbers with a parser and those with a decoder
+func templateSupport(parsed, decodable []uint16) []TemplateSupport {
+	byNumber := make(map[uint16]*TemplateSupport)
+	lookup := func(number uint16) *TemplateSupport {
+		if support, ok := byNumber[number]; ok {
+			return support
+		}
+		support := &TemplateSupport{Number: number}
+		byNumber[number] = support
+		return support
+	}
+
+	for _, number := range parsed {
+		lookup(number).Parse = true
+	}
+	for _, number := range decodable {
+		lookup(number).Decode = true
+	}
+
+	supports := make([]TemplateSupport, 0, len(byNumber))
+	for _, support := range byNumber {
+		supports = append(supports, *support)
+	}
+	slices.SortFunc(supports, func(a, b TemplateSupport) int {
+		return int(a.Number) - int(b.Number)
+	})
+	return supports
+}
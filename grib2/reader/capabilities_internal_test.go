@@ -0,0 +1,50 @@
+package reader
+
+import (
+	"testing"
+
+	"github.com/scorix/grib/grib2/template"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSupportedTemplates_MatchRegistries(t *testing.T) {
+	registries := []struct {
+		name      string
+		parsers   []uint16
+		supported []TemplateSupport
+	}{
+		{"grid", template.GridTemplateNumbers(), SupportedGridTemplates()},
+		{"product", parserNumbers(productTemplateParsers), SupportedProductTemplates()},
+		{"datarep", parserNumbers(dataRepTemplateParsers), SupportedDataRepTemplates()},
+	}
+
+	for _, registry := range registries {
+		t.Run(registry.name, func(t *testing.T) {
+			byNumber := make(map[uint16]TemplateSupport)
+			for i, support := range registry.supported {
+				if i > 0 {
+					assert.Less(t, registry.supported[i-1].Number, support.Number, "templates should be sorted")
+				}
+				byNumber[support.Number] = support
+			}
+
+			// Every registered parser is reported as parsed
+			for _, number := range registry.parsers {
+				support, ok := byNumber[number]
+				if assert.True(t, ok, "template %d is registered but not reported", number) {
+					assert.True(t, support.Parse, "template %d should be parsed", number)
+				}
+			}
+
+			// Decode support is only claimed with a registered decoder
+			for _, support := range registry.supported {
+				if registry.name == "datarep" {
+					_, decodable := lookupDataDecoder(support.Number)
+					assert.Equal(t, decodable, support.Decode, "template %d", support.Number)
+				} else {
+					assert.False(t, support.Decode, "template %d", support.Number)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package reader_test
+
+import (
+	"testing"
+
+	"github.com/scorix/grib/grib2/reader"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSupportedDataRepTemplates(t *testing.T) {
+	assert.Contains(t, reader.SupportedDataRepTemplates(), reader.TemplateSupport{Number: 0, Parse: true, Decode: true})
+}
+
+func TestSupportedGridTemplates(t *testing.T) {
+	assert.Contains(t, reader.SupportedGridTemplates(), reader.TemplateSupport{Number: 0, Parse: true, Geolocate: true})
+}
+
+func TestSupportedProductTemplates(t *testing.T) {
+	supported := reader.SupportedProductTemplates()
+	assert.Contains(t, supported, reader.TemplateSupport{Number: 0, Parse: true})
+	assert.Contains(t, supported, reader.TemplateSupport{Number: 8, Parse: true})
+}
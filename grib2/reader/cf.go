@@ -0,0 +1,102 @@
+package reader
+
+import (
+	"github.com/scorix/grib/grib2/grid"
+	"github.com/scorix/grib/grib2/template"
+)
+
+// CFGridMappingVariable names the grid mapping variable in the attributes returned by
+// FlatMessage.CFAttributes
+const CFGridMappingVariable = "crs"
+
+// cfCellMethods maps Code Table 4.10 to the methods of CF cell_methods attributes.
+// Processes without a CF method, e.g. differences, give no cell_methods.
+var cfCellMethods = map[template.StatisticalProcess]string{
+	template.StatisticalAverage:           "mean",
+	template.StatisticalAccumulation:      "sum",
+	template.StatisticalMaximum:           "maximum",
+	template.StatisticalMinimum:           "minimum",
+	template.StatisticalStandardDeviation: "standard_deviation",
+	template.StatisticalSummation:         "sum",
+	template.StatisticalMedian:            "median",
+}
+
+// cfUnits maps the units of the parameter table that UDUNITS does not parse to their CF
+// equivalents
+var cfUnits = map[string]string{
+	"gpm":        "m",
+	"Proportion": "1",
+}
+
+// CFAttributes returns the CF Conventions attributes of the field's data variable, for
+// exporters writing NetCDF or similar formats:
+//
+//   - units and long_name, from the parameter table
+//   - standard_name, when the parameter has a CF standard name
+//   - cell_methods, e.g. "time: sum" for accumulations and "time: point" for
+//     instantaneous fields, and "area: mean" first for fields processed over a spatial
+//     area (template 4.15)
+//   - grid_mapping, naming CFGridMappingVariable, for grids with geometry support. The
+//     attributes of the grid mapping variable (see grid.CFGridMapping) are keyed
+//     "crs:<name>", as in CDL.
+//
+// Parameters missing from the table have a long_name giving their discipline, category
+// and number, and no units. An error is returned when the geometry of a supported grid
+// cannot be built.
+func (f *FlatMessage) CFAttributes() (map[string]string, error) {
+	attrs := make(map[string]string)
+
+	if param, ok := f.Parameter(); ok {
+		attrs["long_name"] = param.Name
+		attrs["units"] = param.Unit
+		if unit, ok := cfUnits[param.Unit]; ok {
+			attrs["units"] = unit
+		}
+		if param.StandardName != "" {
+			attrs["standard_name"] = param.StandardName
+		}
+	} else {
+		attrs["long_name"] = f.ParameterName()
+	}
+
+	if methods := f.cfCellMethods(); methods != "" {
+		attrs["cell_methods"] = methods
+	}
+
+	if f.Grid.HasTemplate() && grid.CanGeolocate(f.Grid.TemplateNumber) {
+		def, err := f.GridDefinition()
+		if err != nil {
+			return nil, err
+		}
+		if mapping, ok := grid.CFGridMapping(def); ok {
+			attrs["grid_mapping"] = CFGridMappingVariable
+			for name, value := range mapping {
+				attrs[CFGridMappingVariable+":"+name] = value
+			}
+		}
+	}
+	return attrs, nil
+}
+
+// cfCellMethods returns the cell_methods of the field, or "" when its statistical process
+// has no CF method
+func (f *FlatMessage) cfCellMethods() string {
+	methods := ""
+	if spatial := f.Product.Spatial; spatial != nil {
+		method, ok := cfCellMethods[template.StatisticalProcess(spatial.StatisticalProcess)]
+		if !ok {
+			return ""
+		}
+		methods = "area: " + method + " "
+	}
+
+	process, ok := f.StatisticalProcess()
+	if !ok {
+		return methods + "time: point"
+	}
+	method, ok := cfCellMethods[process]
+	if !ok {
+		return ""
+	}
+	return methods + "time: " + method
+}
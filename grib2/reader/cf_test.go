@@ -0,0 +1,111 @@
+//go:build !nointernaltables
+
+package reader_test
+
+import (
+	"testing"
+
+	"github.com/scorix/grib/grib2/internal/testgrib"
+	"github.com/scorix/grib/grib2/reader"
+	"github.com/scorix/grib/grib2/template"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlatMessage_CFAttributes(t *testing.T) {
+	t.Run("instantaneous", func(t *testing.T) {
+		msgs := flatMessages(t, testgrib.MustEncode(testgrib.Spec{}))
+		require.Len(t, msgs, 1)
+
+		attrs, err := msgs[0].CFAttributes()
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{
+			"long_name":             "Temperature",
+			"units":                 "K",
+			"standard_name":         "air_temperature",
+			"cell_methods":          "time: point",
+			"grid_mapping":          "crs",
+			"crs:grid_mapping_name": "latitude_longitude",
+			"crs:earth_radius":      "6371229",
+		}, attrs)
+	})
+
+	t.Run("accumulation", func(t *testing.T) {
+		msgs := flatMessages(t, testgrib.MustEncode(testgrib.Spec{
+			ProductTemplate:    8,
+			Category:           1,
+			Parameter:          8,
+			ForecastHours:      6,
+			StatisticalProcess: 1,
+			RangeHours:         6,
+		}))
+		require.Len(t, msgs, 1)
+
+		attrs, err := msgs[0].CFAttributes()
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{
+			"long_name":             "Total precipitation",
+			"units":                 "kg m-2",
+			"standard_name":         "precipitation_amount",
+			"cell_methods":          "time: sum",
+			"grid_mapping":          "crs",
+			"crs:grid_mapping_name": "latitude_longitude",
+			"crs:earth_radius":      "6371229",
+		}, attrs)
+	})
+
+	t.Run("lambert grid", func(t *testing.T) {
+		// The 3 km HRRR CONUS grid
+		f := reader.FlatMessage{
+			Product: template.ProductTemplate{Category: 2, Parameter: 2},
+			Grid: template.GridTemplate{
+				TemplateNumber:     30,
+				NumberOfDataPoints: 1799 * 1059,
+				Lambert: &template.LambertGrid{
+					ShapeOfEarth:               6,
+					NumberOfGridPointsAlongX:   1799,
+					NumberOfGridPointsAlongY:   1059,
+					LatitudeOfFirstGridPoint:   21138123,
+					LongitudeOfFirstGridPoint:  237280472,
+					LatitudeWhereDxDySpecified: 38500000,
+					OrientationOfGrid:          262500000,
+					XDirectionIncrement:        3000000,
+					YDirectionIncrement:        3000000,
+					ScanningMode:               0x40,
+					LatitudeOfIntersection1:    38500000,
+					LatitudeOfIntersection2:    38500000,
+				},
+			},
+		}
+
+		attrs, err := f.CFAttributes()
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{
+			"long_name":                         "U-component of wind",
+			"units":                             "m s-1",
+			"standard_name":                     "eastward_wind",
+			"cell_methods":                      "time: point",
+			"grid_mapping":                      "crs",
+			"crs:grid_mapping_name":             "lambert_conformal_conic",
+			"crs:standard_parallel":             "38.5",
+			"crs:longitude_of_central_meridian": "262.5",
+			"crs:latitude_of_projection_origin": "38.5",
+			"crs:earth_radius":                  "6371229",
+		}, attrs)
+	})
+
+	t.Run("unknown parameter and no grid", func(t *testing.T) {
+		f := reader.FlatMessage{
+			Discipline: 10,
+			Product:    template.ProductTemplate{Category: 3, Parameter: 200},
+			Grid:       template.GridTemplate{SourceOfGridDefinition: template.GridSourceNone},
+		}
+
+		attrs, err := f.CFAttributes()
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{
+			"long_name":    "discipline 10 category 3 parameter 200",
+			"cell_methods": "time: point",
+		}, attrs)
+	})
+}
@@ -0,0 +1,157 @@
+package reader
+
+import (
+	"fmt"
+
+	"github.com/scorix/grib/grib2/template"
+)
+
+// Converter converts decoded values into other units
+type Converter interface {
+	// Unit returns the unit of the converted values of a parameter, and false when the
+	// converter does not apply to the parameter
+	Unit(param template.ParameterInfo) (string, bool)
+	// Convert converts values of the parameter, given in param.Unit, in place
+	Convert(param template.ParameterInfo, values []float64) error
+}
+
+// UnitConversion is a linear Converter between two units: converted = value*Scale + Offset
+type UnitConversion struct {
+	From   string  // WMO unit the conversion applies to, e.g. "K"
+	To     string  // Unit of the converted values, e.g. "°C"
+	Scale  float64 // Factor applied to values
+	Offset float64 // Offset added after scaling
+}
+
+// Built-in conversions of common units
+var (
+	KelvinToCelsius            = UnitConversion{From: "K", To: "°C", Scale: 1, Offset: -273.15}
+	PascalToHectopascal        = UnitConversion{From: "Pa", To: "hPa", Scale: 0.01}
+	PrecipitationToMillimetres = UnitConversion{From: "kg m-2", To: "mm", Scale: 1} // Depth of liquid water
+)
+
+// commonConversions holds the built-in conversions keyed by the WMO unit they convert from
+var commonConversions = map[string]UnitConversion{
+	KelvinToCelsius.From:            KelvinToCelsius,
+	PascalToHectopascal.From:        PascalToHectopascal,
+	PrecipitationToMillimetres.From: PrecipitationToMillimetres,
+}
+
+// ConversionFrom returns the built-in conversion of values in a WMO unit
+func ConversionFrom(unit string) (UnitConversion, bool) {
+	c, ok := commonConversions[unit]
+	return c, ok
+}
+
+// Unit implements Converter
+func (c UnitConversion) Unit(param template.ParameterInfo) (string, bool) {
+	if param.Unit != c.From {
+		return "", false
+	}
+	return c.To, true
+}
+
+// Convert implements Converter
+func (c UnitConversion) Convert(param template.ParameterInfo, values []float64) error {
+	if param.Unit != c.From {
+		return fmt.Errorf("convert: %s is in %q, not %q", param.Abbreviation, param.Unit, c.From)
+	}
+	for i, v := range values {
+		values[i] = v*c.Scale + c.Offset
+	}
+	return nil
+}
+
+// DecodeOption configures DecodeData
+type DecodeOption func(*decodeOptions)
+
+type decodeOptions struct {
+	converters []Converter
+	dither     bool
+	missing    *float64 // Substitute for missing values
+}
+
+// newDecodeOptions applies opts to the default settings
+func newDecodeOptions(opts []DecodeOption) decodeOptions {
+	var o decodeOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithConverters converts decoded values with the first of the converters that applies to
+// the parameter. Values of parameters that none applies to, or that are not in the parameter
+// table, are returned in their original units.
+func WithConverters(converters ...Converter) DecodeOption {
+	return func(o *decodeOptions) {
+		o.converters = append(o.converters, converters...)
+	}
+}
+
+// WithDither adds uniform noise of up to half a quantisation step, 2^E / 10^D, to the
+// decoded values, which hides the banding of coarsely packed fields when they are
+// regridded or differentiated. It is an approximation: the noise only spreads each value
+// over the interval its packed integer stands for, never below the reference value, and
+// does not restore the original data. The noise is reproducible for a given field.
+// Constant fields, and fields decoded by a registered DataDecoder, are left unchanged.
+func WithDither() DecodeOption {
+	return func(o *decodeOptions) {
+		o.dither = true
+	}
+}
+
+// WithMissingValue returns v in place of the values that the packing marks as missing,
+// such as those of complex packing with missing value management, which are otherwise NaN.
+// Points masked out by a bit-map are not among the values DecodeData returns; Values
+// returns v for them as well.
+func WithMissingValue(v float64) DecodeOption {
+	return func(o *decodeOptions) {
+		o.missing = &v
+	}
+}
+
+// WithCommonConversions converts temperatures to °C, pressures to hPa and
+// precipitation amounts to mm with the built-in conversions
+func WithCommonConversions() DecodeOption {
+	return WithConverters(KelvinToCelsius, PascalToHectopascal, PrecipitationToMillimetres)
+}
+
+// Parameter returns the Code Table 4.2 entry of the field's parameter, from the local
+// table of the originating centre for local parameters
+func (f *FlatMessage) Parameter() (template.ParameterInfo, bool) {
+	return template.LookupCentreParameter(uint16(f.Centre), uint8(f.LocalTablesVersion), uint8(f.Discipline), f.Product.Category, f.Product.Parameter)
+}
+
+// ParameterName returns the name of the field's parameter, falling back to its numbers,
+// as in "discipline 10 category 3 parameter 200", when it is not in the parameter table
+func (f *FlatMessage) ParameterName() string {
+	if param, ok := f.Parameter(); ok {
+		return param.Name
+	}
+	return template.ParameterName(uint8(f.Discipline), f.Product.Category, f.Product.Parameter)
+}
+
+// Unit returns the unit of the values DecodeData returns with the same options,
+// or "" when the parameter is not in the parameter table
+func (f *FlatMessage) Unit(opts ...DecodeOption) string {
+	param, ok := f.Parameter()
+	if !ok {
+		return ""
+	}
+	if converter := f.converter(param, opts); converter != nil {
+		unit, _ := converter.Unit(param)
+		return unit
+	}
+	return param.Unit
+}
+
+// converter returns the first converter of the options applying to the parameter
+func (f *FlatMessage) converter(param template.ParameterInfo, opts []DecodeOption) Converter {
+	for _, converter := range newDecodeOptions(opts).converters {
+		if _, ok := converter.Unit(param); ok {
+			return converter
+		}
+	}
+	return nil
+}
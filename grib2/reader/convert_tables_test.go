@@ -0,0 +1,115 @@
+//go:build !nointernaltables
+
+package reader_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/scorix/grib/grib2/internal/testgrib"
+	"github.com/scorix/grib/grib2/reader"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlatMessage_DecodeData_Conversions(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     testgrib.Spec
+		opts     []reader.DecodeOption
+		unit     string
+		values   []float64
+		original string
+	}{
+		{
+			name:     "temperature",
+			spec:     testgrib.Spec{Ni: 2, Nj: 1, Values: []float64{273.15, 300.15}, DecimalScale: 2},
+			opts:     []reader.DecodeOption{reader.WithConverters(reader.KelvinToCelsius)},
+			unit:     "°C",
+			values:   []float64{0, 27},
+			original: "K",
+		},
+		{
+			name:     "pressure",
+			spec:     testgrib.Spec{Category: 3, Parameter: 1, Ni: 2, Nj: 1, Values: []float64{101325, 98000}},
+			opts:     []reader.DecodeOption{reader.WithCommonConversions()},
+			unit:     "hPa",
+			values:   []float64{1013.25, 980},
+			original: "Pa",
+		},
+		{
+			name:     "precipitation",
+			spec:     testgrib.Spec{Category: 1, Parameter: 8, Ni: 2, Nj: 1, Values: []float64{0, 12.5}, DecimalScale: 1},
+			opts:     []reader.DecodeOption{reader.WithCommonConversions()},
+			unit:     "mm",
+			values:   []float64{0, 12.5},
+			original: "kg m-2",
+		},
+		{
+			name:     "no applicable converter",
+			spec:     testgrib.Spec{Category: 1, Parameter: 1, Ni: 2, Nj: 1, Values: []float64{50, 100}},
+			opts:     []reader.DecodeOption{reader.WithConverters(reader.KelvinToCelsius)},
+			unit:     "%",
+			values:   []float64{50, 100},
+			original: "%",
+		},
+		{
+			name:     "unknown parameter",
+			spec:     testgrib.Spec{Category: 200, Parameter: 1, Ni: 2, Nj: 1, Values: []float64{1, 2}},
+			opts:     []reader.DecodeOption{reader.WithCommonConversions()},
+			unit:     "",
+			values:   []float64{1, 2},
+			original: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := flatMessages(t, testgrib.MustEncode(tt.spec))[0]
+
+			assert.Equal(t, tt.original, msg.Unit())
+			assert.Equal(t, tt.unit, msg.Unit(tt.opts...))
+
+			values, err := msg.DecodeData(tt.opts...)
+			require.NoError(t, err)
+			assert.InDeltaSlice(t, tt.values, values, 1e-3) // R is a 32-bit float
+		})
+	}
+}
+
+func TestFlatMessage_Unit(t *testing.T) {
+	data, err := os.ReadFile("testdata/gfs.t00z.pgrb2.0p25.f000")
+	require.NoError(t, err)
+	msg := flatMessages(t, data)[0]
+
+	param, ok := msg.Parameter()
+	require.True(t, ok)
+	assert.Equal(t, "PRMSL", param.Abbreviation)
+	assert.Equal(t, "Pressure reduced to MSL", msg.ParameterName())
+
+	assert.Equal(t, "Pa", msg.Unit())
+	assert.Equal(t, "hPa", msg.Unit(reader.WithCommonConversions()))
+	assert.Equal(t, "Pa", msg.Unit(reader.WithConverters(reader.KelvinToCelsius)))
+}
+
+func TestFlatMessage_Parameter_Local(t *testing.T) {
+	// Categorical rain, a local parameter of NCEP
+	message := func(centre uint16, localTablesVersion uint8) []byte {
+		msg := testgrib.MustEncode(testgrib.Spec{Centre: centre, Category: 1, Parameter: 192, ForecastHours: 6})
+		msg[16+10] = localTablesVersion // octet 11 of Section 1
+		return msg
+	}
+
+	msg := flatMessages(t, message(7, 1))[0]
+	param, ok := msg.Parameter()
+	require.True(t, ok)
+	assert.Equal(t, "CRAIN", param.Abbreviation)
+	assert.Equal(t, "Categorical rain", msg.ParameterName())
+	assert.Equal(t, "Code table 4.222", msg.Unit())
+
+	for _, msg := range []reader.FlatMessage{flatMessages(t, message(98, 1))[0], flatMessages(t, message(7, 0))[0]} {
+		_, ok := msg.Parameter()
+		assert.False(t, ok)
+		assert.Equal(t, "discipline 0 category 1 parameter 192", msg.ParameterName())
+	}
+}
@@ -0,0 +1,22 @@
+package reader_test
+
+import (
+	"testing"
+
+	"github.com/scorix/grib/grib2/reader"
+	"github.com/scorix/grib/grib2/template"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnitConversion(t *testing.T) {
+	conversion, ok := reader.ConversionFrom("K")
+	require.True(t, ok)
+	assert.Equal(t, reader.KelvinToCelsius, conversion)
+
+	_, ok = reader.ConversionFrom("m s-1")
+	assert.False(t, ok)
+
+	err := reader.PascalToHectopascal.Convert(template.ParameterInfo{Abbreviation: "TMP", Unit: "K"}, []float64{1})
+	require.Error(t, err)
+}
@@ -0,0 +1,139 @@
+package reader
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/scorix/grib/grib2/writer"
+)
+
+// WriteField writes the field f, read from r, to w as a message of its own, e.g. to
+// extract the fields selected by a Filter into a smaller file. The sections of the field
+// are copied from the file as they are, without decoding the data; the Sections 1-3 it
+// shares with other fields of its message are repeated in each message written, and a
+// bit-map it reuses from a previous field (bit-map indicator 254) is written in its Section
+// 6. It fails when the section offsets of f are unknown.
+func (r *ReaderAt) WriteField(w *writer.MessageWriter, f *FlatMessage) error {
+	ranges := f.SectionRanges()
+	if ranges == nil {
+		return errors.New("write field: section offsets are unknown")
+	}
+
+	if err := w.Begin(uint8(f.Discipline)); err != nil {
+		return fmt.Errorf("write field: %w", err)
+	}
+	for number := uint8(1); number <= 7; number++ {
+		sec, ok := ranges[number]
+		if !ok {
+			continue
+		}
+		if number == 6 && f.PreviousBitmap != nil && f.Bitmap != nil && f.Bitmap.BitMapIndicator() == 254 {
+			// The message written has no previous field to take the bit-map from
+			if err := w.WriteSection(6, append([]byte{0}, f.PreviousBitmap.BitMap()...)); err != nil {
+				return fmt.Errorf("write field: %w", err)
+			}
+			continue
+		}
+		payload := io.NewSectionReader(r.reader, sec.Offset+5, sec.Length-5)
+		if err := w.WriteSectionFrom(number, payload, sec.Length-5); err != nil {
+			return fmt.Errorf("write field: %w", err)
+		}
+	}
+	if _, err := w.End(); err != nil {
+		return fmt.Errorf("write field: %w", err)
+	}
+	return nil
+}
+
+// MessageBytes returns the octets of the message described by info, as they are in the
+// file, e.g. to save or upload a message selected with EachMessage without decoding it.
+// It fails when the octets read do not start with "GRIB" and end with "7777".
+func (r *ReaderAt) MessageBytes(info MessageInfo) ([]byte, error) {
+	if err := checkMessageSpan(info); err != nil {
+		return nil, r.opts.sourced(err)
+	}
+	data := make([]byte, info.Length)
+	if _, err := r.reader.ReadAt(data, info.Offset); err != nil {
+		return nil, r.opts.sourced(fmt.Errorf("message %d at offset %d: %w", info.Index, info.Offset, err))
+	}
+	if err := checkMessageMarkers(info, data[:4], data[len(data)-4:]); err != nil {
+		return nil, r.opts.sourced(err)
+	}
+	return data, nil
+}
+
+// WriteMessageTo copies the octets of the message described by info to w, as they are in
+// the file, without holding the message in memory, and returns the number of octets
+// written. The markers at both ends of the message are checked before any octet is
+// written, as by MessageBytes.
+func (r *ReaderAt) WriteMessageTo(w io.Writer, info MessageInfo) (int64, error) {
+	if err := checkMessageSpan(info); err != nil {
+		return 0, r.opts.sourced(err)
+	}
+	start, end := make([]byte, 4), make([]byte, 4)
+	if _, err := r.reader.ReadAt(start, info.Offset); err != nil {
+		return 0, r.opts.sourced(fmt.Errorf("message %d at offset %d: %w", info.Index, info.Offset, err))
+	}
+	if _, err := r.reader.ReadAt(end, info.Offset+int64(info.Length)-4); err != nil {
+		return 0, r.opts.sourced(fmt.Errorf("message %d at offset %d: %w", info.Index, info.Offset, err))
+	}
+	if err := checkMessageMarkers(info, start, end); err != nil {
+		return 0, r.opts.sourced(err)
+	}
+
+	n, err := io.Copy(w, io.NewSectionReader(r.reader, info.Offset, int64(info.Length)))
+	if err == nil && n < int64(info.Length) {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return n, r.opts.sourced(fmt.Errorf("message %d at offset %d: copied %d of %d octets: %w", info.Index, info.Offset, n, info.Length, err))
+	}
+	return n, nil
+}
+
+// checkMessageSpan checks that info locates a message long enough to hold its markers
+func checkMessageSpan(info MessageInfo) error {
+	if info.Offset < 0 || info.Length < minMessageLength || info.Length > uint64(math.MaxInt64-info.Offset) {
+		return fmt.Errorf("message %d: invalid length %d at offset %d", info.Index, info.Length, info.Offset)
+	}
+	return nil
+}
+
+// checkMessageMarkers checks the first and last four octets of the message described by info
+func checkMessageMarkers(info MessageInfo, start, end []byte) error {
+	if string(start) != "GRIB" {
+		return fmt.Errorf("invalid GRIB marker at offset %d", info.Offset)
+	}
+	if string(end) != "7777" {
+		return fmt.Errorf("message %d at offset %d: missing end marker at offset %d", info.Index, info.Offset, info.Offset+int64(info.Length)-4)
+	}
+	return nil
+}
+
+// CopyMessages copies the messages of src accepted by filter to dst, octet for octet and
+// in file order, as wgrib2 does with -match and -grib, and returns the number of messages
+// copied. A nil filter accepts every message. It stops at the first error, such as a write
+// to dst failing part way through a message, after the messages counted.
+func CopyMessages(dst io.Writer, src *ReaderAt, filter func(MessageInfo) bool) (int, error) {
+	copied := 0
+	var copyErr error
+	err := src.EachMessage(func(_ int, info MessageInfo) bool {
+		if filter != nil && !filter(info) {
+			return true
+		}
+		if _, copyErr = src.WriteMessageTo(dst, info); copyErr != nil {
+			return false
+		}
+		copied++
+		return true
+	})
+	if copyErr != nil {
+		return copied, fmt.Errorf("copy messages: %w", copyErr)
+	}
+	if err != nil {
+		return copied, fmt.Errorf("copy messages: %w", err)
+	}
+	return copied, nil
+}
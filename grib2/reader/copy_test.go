@@ -0,0 +1,154 @@
+package reader_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/scorix/grib/grib2/reader"
+	"github.com/scorix/grib/grib2/writer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReaderAt_WriteField(t *testing.T) {
+	r := reader.NewReaderAt(bytes.NewReader(messageFromSections(1, 3, 4, 5, 6, 7, 4, 5, 6, 7)))
+	fields := flatMessagesOf(t, r)
+	require.Len(t, fields, 2)
+
+	var out bytes.Buffer
+	w := writer.NewMessageWriter(&out, writer.WithoutProvenance())
+	require.NoError(t, r.WriteField(w, &fields[1]))
+
+	copied := flatMessagesOf(t, reader.NewReaderAt(bytes.NewReader(out.Bytes())))
+	require.Len(t, copied, 1)
+	assert.Equal(t, fields[1].Product, copied[0].Product)
+	assert.Equal(t, fields[1].Grid, copied[0].Grid)
+
+	want, err := fields[1].DecodeData()
+	require.NoError(t, err)
+	got, err := copied[0].DecodeData()
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+
+	t.Run("unknown offsets", func(t *testing.T) {
+		assert.Error(t, r.WriteField(w, &reader.FlatMessage{}))
+	})
+}
+
+func TestReaderAt_MessageBytes(t *testing.T) {
+	data, err := os.ReadFile("testdata/gfs.t00z.pgrb2.0p25.f000")
+	require.NoError(t, err)
+	r := reader.NewReaderAt(bytes.NewReader(data))
+
+	var infos []reader.MessageInfo
+	require.NoError(t, r.EachMessage(func(_ int, info reader.MessageInfo) bool {
+		infos = append(infos, info)
+		return true
+	}))
+	require.Greater(t, len(infos), 1)
+
+	info := infos[1]
+	want := data[info.Offset : info.Offset+int64(info.Length)]
+	got, err := r.MessageBytes(info)
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+
+	var out bytes.Buffer
+	n, err := r.WriteMessageTo(&out, info)
+	require.NoError(t, err)
+	assert.Equal(t, int64(info.Length), n)
+	assert.Equal(t, want, out.Bytes())
+
+	// The message extracted is a file of its own
+	extracted := flatMessagesOf(t, reader.NewReaderAt(bytes.NewReader(out.Bytes())))
+	require.Len(t, extracted, 1)
+
+	t.Run("markers", func(t *testing.T) {
+		shifted := info
+		shifted.Offset++
+		_, err := r.MessageBytes(shifted)
+		assert.ErrorContains(t, err, "invalid GRIB marker")
+
+		short := info
+		short.Length--
+		out.Reset()
+		_, err = r.WriteMessageTo(&out, short)
+		assert.ErrorContains(t, err, "missing end marker")
+		assert.Zero(t, out.Len())
+
+		beyond := infos[len(infos)-1]
+		beyond.Length += 100
+		_, err = r.MessageBytes(beyond)
+		assert.Error(t, err)
+
+		_, err = r.MessageBytes(reader.MessageInfo{Length: 4})
+		assert.ErrorContains(t, err, "invalid length 4")
+	})
+
+	t.Run("write error", func(t *testing.T) {
+		n, err := r.WriteMessageTo(failingWriter{limit: 100}, info)
+		assert.ErrorContains(t, err, fmt.Sprintf("copied 100 of %d octets", info.Length))
+		assert.Equal(t, int64(100), n)
+	})
+}
+
+// failingWriter accepts limit octets and fails to write any more
+type failingWriter struct {
+	limit int
+}
+
+func (w failingWriter) Write(p []byte) (int, error) {
+	if len(p) > w.limit {
+		return w.limit, errors.New("disk full")
+	}
+	return len(p), nil
+}
+
+func TestCopyMessages(t *testing.T) {
+	data, err := os.ReadFile("testdata/gfs.t00z.pgrb2.0p25.f000")
+	require.NoError(t, err)
+	src := reader.NewReaderAt(bytes.NewReader(data))
+	all := flatMessagesOf(t, src)
+
+	// Split the file into its even and odd messages
+	var even, odd bytes.Buffer
+	nEven, err := reader.CopyMessages(&even, src, func(info reader.MessageInfo) bool { return info.Index%2 == 0 })
+	require.NoError(t, err)
+	nOdd, err := reader.CopyMessages(&odd, src, func(info reader.MessageInfo) bool { return info.Index%2 == 1 })
+	require.NoError(t, err)
+	assert.Equal(t, len(data), even.Len()+odd.Len())
+
+	evenFields := flatMessagesOf(t, reader.NewReaderAt(bytes.NewReader(even.Bytes())))
+	oddFields := flatMessagesOf(t, reader.NewReaderAt(bytes.NewReader(odd.Bytes())))
+	require.Len(t, evenFields, nEven)
+	require.Len(t, oddFields, nOdd)
+	require.Equal(t, len(all), nEven+nOdd)
+	for k, f := range all {
+		copied := evenFields
+		if k%2 == 1 {
+			copied = oddFields
+		}
+		assert.Equal(t, f.Product, copied[k/2].Product)
+		want, err := f.Values()
+		require.NoError(t, err)
+		got, err := copied[k/2].Values()
+		require.NoError(t, err)
+		assert.Equal(t, len(want), len(got))
+	}
+
+	// A nil filter copies the whole file
+	var whole bytes.Buffer
+	n, err := reader.CopyMessages(&whole, src, nil)
+	require.NoError(t, err)
+	assert.Equal(t, len(all), n)
+	assert.Equal(t, data, whole.Bytes())
+
+	t.Run("write error", func(t *testing.T) {
+		n, err := reader.CopyMessages(failingWriter{limit: 100}, src, nil)
+		assert.ErrorContains(t, err, "copy messages: message 0 at offset 0: copied 100 of")
+		assert.Zero(t, n)
+	})
+}
@@ -0,0 +1,294 @@
+package reader
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"github.com/scorix/grib/grib2/packing"
+)
+
+// ErrUnsupportedTemplate reports a template that the package cannot handle
+type ErrUnsupportedTemplate struct {
+	Kind   string // Kind of template: "grid", "product" or "datarep"
+	Number int    // Template number
+}
+
+// Error implements the error interface
+func (e *ErrUnsupportedTemplate) Error() string {
+	return fmt.Sprintf("unsupported %s template %d", e.Kind, e.Number)
+}
+
+// ErrUnresolvedBitmap reports a bit-map that is not in the message: a predefined bit-map
+// (indicators 1-253), or a reference to a previous one (254) with no bit-map defined
+// before the field
+type ErrUnresolvedBitmap struct {
+	Indicator uint8 // Bit-map indicator (Code Table 6.0)
+}
+
+// Error implements the error interface
+func (e *ErrUnresolvedBitmap) Error() string {
+	return fmt.Sprintf("bit-map indicator %d does not resolve to a bit-map", e.Indicator)
+}
+
+// ErrNoStoredValues reports a field that stores no values: its bit-map masks out every
+// point, or Section 5 gives no packed values, as in the placeholder fields of some
+// products. It is not a failure: DecodeData returns it along with a missing (NaN) value
+// for every point of the grid, and the methods built on DecodeData treat the field as all
+// missing. errors.Is(err, &ErrNoStoredValues{}) matches it whatever its fields.
+type ErrNoStoredValues struct {
+	Points    int  // Number of points of the grid
+	AllMasked bool // Whether the bit-map masks out every point
+}
+
+// Error implements the error interface
+func (e *ErrNoStoredValues) Error() string {
+	if e.AllMasked {
+		return fmt.Sprintf("decode: the bit-map masks out all %d points of the field", e.Points)
+	}
+	return fmt.Sprintf("decode: the field stores no values for its %d points", e.Points)
+}
+
+// Is reports whether target is an *ErrNoStoredValues
+func (e *ErrNoStoredValues) Is(target error) bool {
+	_, ok := target.(*ErrNoStoredValues)
+	return ok
+}
+
+// noStoredValues returns the reason why the field stores no values, or nil when it does
+func (f *FlatMessage) noStoredValues() *ErrNoStoredValues {
+	points := 0
+	if f.GridDef != nil {
+		points = int(f.GridDef.NumberOfDataPoints())
+	}
+	if index, ok := f.BitmapIndex(); ok && index.Count() == 0 {
+		return &ErrNoStoredValues{Points: points, AllMasked: true}
+	}
+	if f.DataRepSec != nil && f.DataRepSec.NumberOfDataPoints() == 0 {
+		return &ErrNoStoredValues{Points: points}
+	}
+	return nil
+}
+
+// checkPointCount checks the number of values packed according to Section 5 against the
+// grid points the bit-map selects, or all of them without a bit-map, so that a corrupt
+// Section 5 cannot make the decoder allocate more values than the grid holds. Fields
+// whose number of grid points is unknown, and those with a bit-map that does not resolve,
+// are only checked as far as the grid allows, and fields storing no values not at all.
+func (f *FlatMessage) checkPointCount() error {
+	if f.GridDef == nil || f.GridDef.NumberOfDataPoints() == 0 || f.noStoredValues() != nil {
+		return nil
+	}
+	packed, points := int(f.DataRepSec.NumberOfDataPoints()), int(f.GridDef.NumberOfDataPoints())
+	if index, ok := f.BitmapIndex(); ok {
+		if packed != index.Count() {
+			return fmt.Errorf("decode: Section 5 packs %d values for a bit-map with %d bits set", packed, index.Count())
+		}
+		return nil
+	}
+	if packed > points || (packed != points && (f.Bitmap == nil || f.Bitmap.BitMapIndicator() == 255)) {
+		return fmt.Errorf("decode: Section 5 packs %d values for %d grid points", packed, points)
+	}
+	return nil
+}
+
+// missingValues returns n NaN values
+func missingValues(n int) []float64 {
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = math.NaN()
+	}
+	return values
+}
+
+// CanDecode reports whether DecodeData can be expected to succeed, without reading Section 7.
+// The reason is *ErrUnsupportedTemplate when no decoder is registered for the data
+// representation template, *ErrUnresolvedBitmap when the bit-map is not in the message,
+// or an error naming a missing section, an unknown number of grid points or a number of
+// packed values the grid and bit-map do not select.
+func (f *FlatMessage) CanDecode() (bool, error) {
+	if f.DataRepSec == nil || f.Data == nil {
+		return false, fmt.Errorf("decode: message %d has no data representation or data section", f.Index)
+	}
+	if _, ok := lookupDataDecoder(uint16(f.DataRep.TemplateNumber)); !ok {
+		return false, &ErrUnsupportedTemplate{Kind: "datarep", Number: f.DataRep.TemplateNumber}
+	}
+	if f.GridDef == nil || f.GridDef.NumberOfDataPoints() == 0 {
+		return false, fmt.Errorf("decode: message %d has no grid with a known number of points", f.Index)
+	}
+	if f.Bitmap != nil && f.Bitmap.BitMapIndicator() != 255 && f.bitmapSection() == nil {
+		return false, &ErrUnresolvedBitmap{Indicator: f.Bitmap.BitMapIndicator()}
+	}
+	if err := f.checkPointCount(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// DecodeData unpacks the data values stored in Section 7 according to Section 5.
+// The returned slice contains one value per packed data point; points masked out
+// by a bit-map are not included, see Values for one value per grid point. Values are in the units of the parameter table
+// unless converters are given; Unit reports the unit of the returned values.
+// The values are unpacked by the DataDecoder of the data representation template,
+// see RegisterDataDecoder. Values the packing marks as missing are NaN, or the value given
+// with WithMissingValue. For a field storing no values, DecodeData returns NaN for
+// every point of the grid with *ErrNoStoredValues.
+func (f *FlatMessage) DecodeData(opts ...DecodeOption) ([]float64, error) {
+	if f.metrics != nil {
+		defer func(start time.Time) { f.metrics.DataDecoded(time.Since(start)) }(time.Now())
+	}
+
+	if f.DataRepSec == nil || f.Data == nil {
+		return nil, fmt.Errorf("decode: message %d has no data representation or data section", f.Index)
+	}
+	decoder, ok := lookupDataDecoder(uint16(f.DataRep.TemplateNumber))
+	if !ok {
+		return nil, &ErrUnsupportedTemplate{Kind: "datarep", Number: f.DataRep.TemplateNumber}
+	}
+	if noValues := f.noStoredValues(); noValues != nil {
+		return missingValues(noValues.Points), noValues
+	}
+	if err := f.checkPointCount(); err != nil {
+		return nil, err
+	}
+	values, err := decoder.decode(f.DataRepSec, f.Data, int(f.DataRepSec.NumberOfDataPoints()))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(opts) == 0 {
+		return values, nil
+	}
+	o := newDecodeOptions(opts)
+	if o.dither && decoder.builtin {
+		f.dither(values, f.DataRep.ReferenceValue, int(f.DataRep.BinaryScaleFactor), int(f.DataRep.DecimalScaleFactor))
+	}
+	if param, ok := f.Parameter(); ok {
+		if converter := f.converter(param, opts); converter != nil {
+			if err := converter.Convert(param, values); err != nil {
+				return nil, fmt.Errorf("decode: %w", err)
+			}
+		}
+	}
+	return f.substituteMissing(values, opts), nil
+}
+
+// Values decodes the data values like DecodeData and expands them to the points of the
+// grid, in scanning order: the points masked out by the bit-map are NaN, or the value
+// given with WithMissingValue. A bit-map that does not cover the grid, or whose bits set
+// do not match the number of packed values, is an error, as is a field without bit-map
+// whose number of values differs from the number of grid points. For a field storing no values,
+// Values returns a missing value for every point with *ErrNoStoredValues.
+func (f *FlatMessage) Values(opts ...DecodeOption) ([]float64, error) {
+	if ok, err := f.CanDecode(); !ok {
+		return nil, err
+	}
+	packed, err := f.DecodeData(opts...)
+	var noValues *ErrNoStoredValues
+	if errors.As(err, &noValues) {
+		return f.substituteMissing(packed, opts), err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	n := int(f.GridDef.NumberOfDataPoints())
+	sec := f.bitmapSection()
+	if sec == nil {
+		if len(packed) != n {
+			return nil, fmt.Errorf("decode: %d values for %d grid points", len(packed), n)
+		}
+		return packed, nil
+	}
+	values, err := ApplyBitmap(packed, sec.BitMap(), n)
+	if err != nil {
+		return nil, err
+	}
+	return f.substituteMissing(values, opts), nil
+}
+
+// MissingCount returns the number of grid points without a value: those masked out by the
+// bit-map and those the packing marks as missing. It decodes the field, unless the field
+// stores no values, in which case every point is missing.
+func (f *FlatMessage) MissingCount() (int, error) {
+	values, err := f.Values()
+	var noValues *ErrNoStoredValues
+	if errors.As(err, &noValues) {
+		return noValues.Points, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, v := range values {
+		if math.IsNaN(v) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// substituteMissing replaces the NaN values with the value given with WithMissingValue, if any
+func (f *FlatMessage) substituteMissing(values []float64, opts []DecodeOption) []float64 {
+	if o := newDecodeOptions(opts); o.missing != nil {
+		for i, v := range values {
+			if math.IsNaN(v) {
+				values[i] = *o.missing
+			}
+		}
+	}
+	return values
+}
+
+// dither adds uniform noise of up to half a quantisation step to the scaled values, without
+// going below the reference value. The noise is seeded from the packed data so that
+// decoding the same field always gives the same values.
+func (f *FlatMessage) dither(values []float64, ref float64, E, D int) {
+	if f.DataRep.NumberOfBitsUsedForData == 0 {
+		// Constant fields carry no quantisation error
+		return
+	}
+
+	h := fnv.New64a()
+	h.Write(f.Data.Data())
+	h.Write(binary.BigEndian.AppendUint64(nil, math.Float64bits(ref)))
+	rng := rand.New(rand.NewPCG(h.Sum64(), uint64(E)<<32|uint64(uint32(D))))
+
+	step := packing.QuantizationStep(E, D)
+	minimum := packing.Scale([]int64{0}, ref, E, D)[0]
+	for i, v := range values {
+		values[i] = max(v+(rng.Float64()-0.5)*step, minimum)
+	}
+}
+
+// DecodeRaw unpacks the packed integers X stored in Section 7 without scaling them,
+// along with the reference value R, binary scale factor E and decimal scale factor D.
+// Data values are Y = (R + X * 2^E) / 10^D, as computed by DecodeData. Only the templates
+// decoded by the package have packed integers, and not those storing the values
+// themselves such as IEEE floating point data: for these, and for the templates whose
+// decoder was replaced with RegisterDataDecoder, the error is *ErrUnsupportedTemplate.
+// Missing values of packings with their own missing value management are
+// packing.MissingRaw.
+func (f *FlatMessage) DecodeRaw() (raw []int64, ref float64, E, D int, err error) {
+	if f.DataRepSec == nil || f.Data == nil {
+		return nil, 0, 0, 0, fmt.Errorf("decode: message %d has no data representation or data section", f.Index)
+	}
+
+	if decoder, ok := lookupDataDecoder(uint16(f.DataRep.TemplateNumber)); !ok || !decoder.builtin {
+		return nil, 0, 0, 0, &ErrUnsupportedTemplate{Kind: "datarep", Number: f.DataRep.TemplateNumber}
+	}
+	if err := f.checkPointCount(); err != nil {
+		return nil, 0, 0, 0, err
+	}
+	data := f.Data.Data()
+	if err := f.Data.LoadError(); err != nil {
+		return nil, 0, 0, 0, fmt.Errorf("decode: failed to load data section: %w", err)
+	}
+
+	return packing.DecodeRaw(&f.DataRep, data, int(f.DataRepSec.NumberOfDataPoints()))
+}
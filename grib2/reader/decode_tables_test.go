@@ -0,0 +1,25 @@
+//go:build !nointernaltables
+
+package reader_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/scorix/grib/grib2/internal/testgrib"
+	"github.com/scorix/grib/grib2/reader"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeData_ComplexPacking_Conversions(t *testing.T) {
+	// The unit of the temperature comes from the built-in tables
+	messages := flatMessages(t, testgrib.MustEncode(testgrib.Spec{
+		Packing: 2, GroupSize: 3, DecimalScale: 1, Ni: 3, Nj: 2, Values: []float64{271.5, math.NaN(), 273.2, 280.1, 280.1, 280.1},
+	}))
+	require.Len(t, messages, 1)
+
+	values, err := messages[0].DecodeData(reader.WithMissingValue(-9999), reader.WithCommonConversions())
+	require.NoError(t, err)
+	assert.InDeltaSlice(t, []float64{-1.65, -9999, 0.05, 6.95, 6.95, 6.95}, values, 1e-4)
+}
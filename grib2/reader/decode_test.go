@@ -0,0 +1,502 @@
+package reader_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/scorix/grib/grib2/internal/testgrib"
+	"github.com/scorix/grib/grib2/packing"
+	"github.com/scorix/grib/grib2/reader"
+	"github.com/scorix/grib/grib2/section"
+	"github.com/scorix/grib/grib2/template"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeRawRoundTrip(t *testing.T) {
+	// Packed integers 0, 1, 15, 8 at 4 bits each, with R = 100, E = 1 and D = 1
+	sections := testgrib.MustEncodeSections(testgrib.Spec{Ni: 2, Nj: 2, BitsPerValue: 4})
+	binary.BigEndian.PutUint32(sections[5][11:], math.Float32bits(100))
+	binary.BigEndian.PutUint16(sections[5][15:], 1)
+	binary.BigEndian.PutUint16(sections[5][17:], 1)
+	sections[7] = testgrib.Section(7, []byte{0x01, 0xf8})
+	messages := flatMessages(t, testgrib.Assemble(0, sections[1:]...))
+	require.Len(t, messages, 1)
+
+	raw, ref, E, D, err := messages[0].DecodeRaw()
+	require.NoError(t, err)
+	assert.Equal(t, []int64{0, 1, 15, 8}, raw)
+	assert.Equal(t, 100.0, ref)
+	assert.Equal(t, 1, E)
+	assert.Equal(t, 1, D)
+
+	values, err := messages[0].DecodeData()
+	require.NoError(t, err)
+	require.Len(t, values, len(raw))
+	for i, x := range raw {
+		assert.Equal(t, (ref+float64(x)*math.Pow(2, float64(E)))*math.Pow(10, -float64(D)), values[i])
+	}
+}
+
+func TestDecodeData_WideBitsPerValue(t *testing.T) {
+	values := make([]float64, 16)
+	for i := range values {
+		values[i] = 250 + float64(i)*1.234567891
+	}
+
+	for _, bits := range []uint8{31, 32, 33, 48} {
+		messages := flatMessages(t, testgrib.MustEncode(testgrib.Spec{Values: values, DecimalScale: 2, BitsPerValue: bits}))
+		require.Len(t, messages, 1)
+		assert.Equal(t, bits, messages[0].DataRep.NumberOfBitsUsedForData)
+
+		raw, ref, E, D, err := messages[0].DecodeRaw()
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, slices.Max(raw), int64(1)<<(bits-1), "%d bits: the full width is used", bits)
+		assert.Less(t, slices.Max(raw), int64(1)<<bits, "%d bits", bits)
+
+		decoded, err := messages[0].DecodeData()
+		require.NoError(t, err)
+		assert.InDeltaSlice(t, values, decoded, packing.QuantizationStep(E, D)/2+1e-9*math.Abs(ref), "%d bits", bits)
+	}
+}
+
+func TestCanDecode(t *testing.T) {
+	t.Run("supported", func(t *testing.T) {
+		source := &rangeRecorder{ReaderAt: bytes.NewReader(testgrib.MustEncode(testgrib.Spec{}))}
+		var messages []reader.FlatMessage
+		require.NoError(t, reader.NewReaderAt(source).EachFlatMessage(func(_ int, msg reader.FlatMessage) bool {
+			messages = append(messages, msg)
+			return true
+		}))
+		require.Len(t, messages, 1)
+
+		source.ranges = nil
+		ok, reason := messages[0].CanDecode()
+		assert.True(t, ok)
+		assert.NoError(t, reason)
+		assert.Empty(t, source.ranges, "Section 7 must not be read")
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		messages := flatMessages(t, unsupportedPackingMessage(t))
+		require.NotEmpty(t, messages)
+
+		ok, reason := messages[0].CanDecode()
+		assert.False(t, ok)
+		var unsupported *reader.ErrUnsupportedTemplate
+		require.ErrorAs(t, reason, &unsupported)
+		assert.Equal(t, &reader.ErrUnsupportedTemplate{Kind: "datarep", Number: 51}, unsupported)
+	})
+
+	t.Run("predefined bitmap", func(t *testing.T) {
+		sections := testgrib.MustEncodeSections(testgrib.Spec{Ni: 2, Nj: 2})
+		sections[6] = testgrib.Section(6, []byte{253})
+		messages := flatMessages(t, testgrib.Assemble(0, sections[1:]...))
+		require.Len(t, messages, 1)
+
+		ok, reason := messages[0].CanDecode()
+		assert.False(t, ok)
+		var unresolved *reader.ErrUnresolvedBitmap
+		require.ErrorAs(t, reason, &unresolved)
+		assert.Equal(t, uint8(253), unresolved.Indicator)
+	})
+}
+
+func TestDecodeData_Dither(t *testing.T) {
+	// Precipitation-like values packed at 3 bits with E = 0 and D = 1: steps of 0.1
+	values := make([]float64, 64)
+	for i := range values {
+		values[i] = float64(i%8) / 10
+	}
+	data := testgrib.MustEncode(testgrib.Spec{Ni: 8, Nj: 8, DecimalScale: 1, BitsPerValue: 3, Values: values})
+	const step = 0.1
+
+	decode := func(opts ...reader.DecodeOption) []float64 {
+		messages := flatMessages(t, data)
+		require.Len(t, messages, 1)
+		decoded, err := messages[0].DecodeData(opts...)
+		require.NoError(t, err)
+		return decoded
+	}
+
+	plain := decode()
+	dithered := decode(reader.WithDither())
+	require.Len(t, dithered, len(plain))
+
+	changed := 0
+	for i := range plain {
+		assert.InDelta(t, plain[i], dithered[i], step/2+1e-12, "value %d", i)
+		assert.GreaterOrEqual(t, dithered[i], 0.0, "value %d", i)
+		if dithered[i] != plain[i] {
+			changed++
+		}
+	}
+	assert.Greater(t, changed, len(plain)/2)
+
+	// The noise is seeded by the field, so every decode gives the same values
+	assert.Equal(t, dithered, decode(reader.WithDither()))
+	assert.Equal(t, plain, decode())
+
+	// Constant fields are not dithered
+	constant := flatMessages(t, testgrib.MustEncode(testgrib.Spec{Ni: 2, Nj: 2, Values: []float64{5, 5, 5, 5}}))
+	decoded, err := constant[0].DecodeData(reader.WithDither())
+	require.NoError(t, err)
+	assert.Equal(t, []float64{5, 5, 5, 5}, decoded)
+}
+
+func TestRegisterDataDecoder(t *testing.T) {
+	// A local data representation template 5.59999 holding one octet per value
+	sections := testgrib.MustEncodeSections(testgrib.Spec{Ni: 2, Nj: 2})
+	sections[5] = testgrib.Section(5, binary.BigEndian.AppendUint32(nil, 4), binary.BigEndian.AppendUint16(nil, 59999), []byte{0x2a})
+	sections[7] = testgrib.Section(7, []byte{1, 2, 3, 4})
+	messages := flatMessages(t, testgrib.Assemble(0, sections[1:]...))
+	require.Len(t, messages, 1)
+	field := messages[0]
+
+	ok, reason := field.CanDecode()
+	assert.False(t, ok)
+	assert.Equal(t, &reader.ErrUnsupportedTemplate{Kind: "datarep", Number: 59999}, reason)
+	_, err := field.DecodeData()
+	assert.Equal(t, &reader.ErrUnsupportedTemplate{Kind: "datarep", Number: 59999}, err)
+
+	calls := 0
+	reader.RegisterDataDecoder(59999, func(sec5 section.Section5, sec7 section.Section7, npoints int) ([]float64, error) {
+		calls++
+		assert.Equal(t, uint16(59999), sec5.DataRepresentationTemplateNumber())
+		assert.Equal(t, []byte{0x2a}, sec5.DataRepresentationTemplate())
+		assert.Equal(t, []byte{1, 2, 3, 4}, sec7.Data())
+		assert.Equal(t, 4, npoints)
+
+		values := make([]float64, npoints)
+		for i, b := range sec7.Data() {
+			values[i] = float64(b) * 10
+		}
+		return values, nil
+	})
+
+	ok, reason = field.CanDecode()
+	assert.True(t, ok)
+	assert.NoError(t, reason)
+	assert.Contains(t, reader.SupportedDataRepTemplates(), reader.TemplateSupport{Number: 59999, Decode: true})
+
+	values, err := field.DecodeData(reader.WithDither())
+	require.NoError(t, err)
+	assert.Equal(t, []float64{10, 20, 30, 40}, values)
+	assert.Equal(t, 1, calls)
+
+	// Values are read through the decoder rather than from the packed integers
+	rows, err := field.DecodeRows(0, 1)
+	require.NoError(t, err)
+	assert.Len(t, rows, 4)
+	assert.Equal(t, 2, calls)
+	_, _, _, _, err = field.DecodeRaw()
+	assert.Equal(t, &reader.ErrUnsupportedTemplate{Kind: "datarep", Number: 59999}, err)
+}
+
+// noValuesMessage builds a message on a 3x2 grid without a bit-map, packing no values
+// in Section 5 and holding an empty Section 7
+func noValuesMessage(t *testing.T) []byte {
+	sections := testgrib.MustEncodeSections(testgrib.Spec{Ni: 3, Nj: 2, BitsPerValue: 8})
+	binary.BigEndian.PutUint32(sections[5][5:], 0)
+	sections[7] = testgrib.Section(7)
+	return testgrib.Assemble(0, sections[1:]...)
+}
+
+func TestDecodeData_NoStoredValues(t *testing.T) {
+	fields := map[string]reader.FlatMessage{
+		// Every point masked out by the bit-map
+		"all masked": flatMessages(t, testgrib.MustEncode(testgrib.Spec{
+			Ni: 3, Nj: 2, Values: make([]float64, 6), Bitmap: make([]bool, 6),
+		}))[0],
+		// No packed values in Section 5 and an empty Section 7, without a bit-map
+		"no values": flatMessages(t, noValuesMessage(t))[0],
+	}
+
+	for name, field := range fields {
+		t.Run(name, func(t *testing.T) {
+			ok, reason := field.CanDecode()
+			assert.True(t, ok)
+			assert.NoError(t, reason)
+
+			values, err := field.DecodeData(reader.WithDither())
+			require.ErrorIs(t, err, &reader.ErrNoStoredValues{})
+			var noValues *reader.ErrNoStoredValues
+			require.ErrorAs(t, err, &noValues)
+			assert.Equal(t, 6, noValues.Points)
+			assert.Equal(t, name == "all masked", noValues.AllMasked)
+			require.Len(t, values, 6)
+			for _, v := range values {
+				assert.True(t, math.IsNaN(v))
+			}
+
+			// Consumers of the decoded values see a field of missing points
+			rows, err := field.DecodeRows(0, 1)
+			require.NoError(t, err)
+			require.Len(t, rows, 6)
+			for _, v := range rows {
+				assert.True(t, math.IsNaN(v))
+			}
+
+			lat, lon, err := field.LatLonAt(4)
+			require.NoError(t, err)
+			v, ok, err := field.ValueAt(lat, lon)
+			require.NoError(t, err)
+			assert.True(t, ok)
+			assert.True(t, math.IsNaN(v))
+
+			report, err := reader.PackingDiagnostics(field)
+			require.NoError(t, err)
+			assert.Zero(t, report.NumberOfValues)
+			assert.True(t, report.IsConstant)
+		})
+	}
+}
+
+func TestDecodeData_PointCountMismatch(t *testing.T) {
+	// withPacked returns msg with the number of data points in Section 5 replaced by n
+	withPacked := func(msg []byte, n uint32) []byte {
+		binary.BigEndian.PutUint32(msg[sectionOffset(t, msg, 5)+5:], n)
+		return msg
+	}
+	bitmap := []bool{true, false, true, true, false, true}
+
+	tests := map[string]struct {
+		msg  []byte
+		want string
+	}{
+		"more than the grid": {
+			msg:  withPacked(testgrib.MustEncode(testgrib.Spec{Ni: 3, Nj: 2}), 1<<30),
+			want: "decode: Section 5 packs 1073741824 values for 6 grid points",
+		},
+		"fewer without a bit-map": {
+			msg:  withPacked(testgrib.MustEncode(testgrib.Spec{Ni: 3, Nj: 2}), 5),
+			want: "decode: Section 5 packs 5 values for 6 grid points",
+		},
+		"other than the bit-map": {
+			msg:  withPacked(testgrib.MustEncode(testgrib.Spec{Ni: 3, Nj: 2, Bitmap: bitmap}), 6),
+			want: "decode: Section 5 packs 6 values for a bit-map with 4 bits set",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			field := flatMessages(t, tt.msg)[0]
+
+			ok, reason := field.CanDecode()
+			assert.False(t, ok)
+			assert.EqualError(t, reason, tt.want)
+
+			_, err := field.DecodeData()
+			assert.EqualError(t, err, tt.want)
+
+			_, _, _, _, err = field.DecodeRaw()
+			assert.EqualError(t, err, tt.want)
+		})
+	}
+}
+
+func TestDecodeData_ComplexPacking(t *testing.T) {
+	nan := math.NaN()
+	messages := flatMessages(t, testgrib.MustEncode(testgrib.Spec{
+		Packing: 2, GroupSize: 3, DecimalScale: 1, Ni: 3, Nj: 2, Values: []float64{271.5, nan, 273.2, 280.1, 280.1, 280.1},
+	}))
+	require.Len(t, messages, 1)
+	field := messages[0]
+
+	complex := field.DataRep.Complex
+	require.NotNil(t, complex)
+	assert.Equal(t, uint8(packing.MissingPrimary), complex.MissingValueManagement)
+	assert.Equal(t, uint32(2), complex.NumberOfGroupsOfDataValues)
+	assert.Equal(t, uint32(3), complex.ReferenceForGroupLengths)
+	assert.Equal(t, uint32(3), complex.TrueLengthOfLastGroup)
+	assert.InDelta(t, 9.999e20, complex.PrimaryMissingValueSubstitute, 1e14)
+	assert.True(t, field.DataRep.HasMissingValues())
+
+	values, err := field.DecodeData()
+	require.NoError(t, err)
+	assert.InDeltaSlice(t, []float64{271.5, nan, 273.2, 280.1, 280.1, 280.1}, values, 1e-4)
+
+	values, err = field.DecodeData(reader.WithMissingValue(-9999))
+	require.NoError(t, err)
+	assert.InDeltaSlice(t, []float64{271.5, -9999, 273.2, 280.1, 280.1, 280.1}, values, 1e-4)
+}
+
+func TestDecodeData_SpatialDifferencing(t *testing.T) {
+	messages := flatMessages(t, getTestData(t))
+	require.Len(t, messages, 3)
+
+	prmsl := messages[0]
+	require.Equal(t, 3, prmsl.DataRep.TemplateNumber)
+	complex := prmsl.DataRep.Complex
+	require.NotNil(t, complex)
+	require.NotNil(t, complex.OrderOfSpatialDifferencing)
+	assert.Equal(t, uint8(2), *complex.OrderOfSpatialDifferencing)
+	assert.Equal(t, uint8(2), *complex.NumberOfOctetsExtraDescriptors)
+	assert.Equal(t, uint8(packing.MissingNone), complex.MissingValueManagement)
+
+	values, err := prmsl.DecodeData()
+	require.NoError(t, err)
+	require.Len(t, values, 1440*721)
+
+	// Mean sea level pressure in Pa on the 0.25 degree grid from 90N 0E
+	for _, point := range []struct {
+		index int
+		want  float64
+	}{
+		{0, 101403.825},            // 90N 0E
+		{1439, 101403.825},         // 90N 359.75E
+		{360 * 1440, 101200.225},   // 0N 0E
+		{360*1440 + 1, 101203.025}, // 0N 0.25E
+		{123456, 100415.025},       // 68.75N 264E
+		{777777, 100119.425},       // 45S 44.25E
+		{1440*721 - 1, 103063.025}, // 90S 359.75E
+	} {
+		assert.InDelta(t, point.want, values[point.index], 1e-6, "point %d", point.index)
+	}
+	assert.InDelta(t, 94041.025, slices.Min(values), 1e-6)
+	assert.InDelta(t, 108561.025, slices.Max(values), 1e-6)
+
+	// The cloud mixing ratios are packed with 3 octets per extra descriptor
+	for i, want := range map[int]float64{1: 0.0013702, 2: 0.000155504} {
+		values, err := messages[i].DecodeData()
+		require.NoError(t, err)
+		assert.InDelta(t, 0, slices.Min(values), 1e-12)
+		assert.InDelta(t, want, slices.Max(values), 1e-12)
+	}
+}
+
+func TestDecodeData_JPEG2000Packing(t *testing.T) {
+	values := make([]float64, 30*20)
+	for i := range values {
+		x, y := float64(i%30), float64(i/30)
+		values[i] = 250 + 30*math.Sin(x/5)*math.Cos(y/4)
+	}
+	messages := flatMessages(t, testgrib.MustEncode(testgrib.Spec{
+		Packing: 40, Ni: 30, Nj: 20, DecimalScale: 2, Values: values,
+	}))
+	require.Len(t, messages, 1)
+	field := messages[0]
+
+	ok, reason := field.CanDecode()
+	assert.True(t, ok)
+	assert.NoError(t, reason)
+	require.NotNil(t, field.DataRep.JPEG2000)
+	assert.Equal(t, &template.JPEG2000PackingInfo{CompressionType: 0, TargetCompressionRatio: 255}, field.DataRep.JPEG2000)
+	assert.False(t, field.DataRep.IsLossyCompression())
+
+	decoded, err := field.DecodeData()
+	require.NoError(t, err)
+	assert.InDeltaSlice(t, values, decoded, 0.005+1e-9)
+
+	rows, err := field.DecodeRows(3, 4)
+	require.NoError(t, err)
+	assert.Equal(t, decoded[3*30:5*30], rows)
+}
+
+func TestDecodeData_PNGPacking(t *testing.T) {
+	values := []float64{0.5, 12.25, 3, 7.75, 0, 40.5}
+	messages := flatMessages(t, testgrib.MustEncode(testgrib.Spec{Packing: 41, Ni: 3, Nj: 2, DecimalScale: 2, Values: values}))
+	require.Len(t, messages, 1)
+	field := messages[0]
+
+	assert.NotNil(t, field.DataRep.PNG)
+	assert.Equal(t, uint8(16), field.DataRep.NumberOfBitsUsedForData)
+	decoded, err := field.DecodeData()
+	require.NoError(t, err)
+	assert.InDeltaSlice(t, values, decoded, 1e-9)
+}
+
+func TestDecodeData_CCSDSPacking(t *testing.T) {
+	values := make([]float64, 40*25)
+	for i := range values {
+		values[i] = 101325 + 800*math.Sin(float64(i%40)/7) - 3*float64(i/40)
+	}
+	messages := flatMessages(t, testgrib.MustEncode(testgrib.Spec{Packing: 42, Ni: 40, Nj: 25, DecimalScale: 1, Values: values}))
+	require.Len(t, messages, 1)
+	field := messages[0]
+
+	assert.Equal(t, &template.CCSDSPackingInfo{CCSDSFlags: 14, BlockSize: 32, RSILength: 128}, field.DataRep.CCSDS)
+	assert.False(t, field.DataRep.IsLossyCompression())
+	decoded, err := field.DecodeData()
+	require.NoError(t, err)
+	assert.InDeltaSlice(t, values, decoded, 0.05+1e-9)
+}
+
+// TestDecodeData_CCSDSReference decodes testdata/ccsds.grib2, a template 5.42 message laid
+// out as ecCodes writes it (options mask 14, blocks of 32 samples, intervals of 128
+// blocks) by an encoder written apart from internal/aec, over two reference sample
+// intervals and with every code option. testdata/ccsds.values holds its values in the
+// output format of grib_get_data -F %.17g, so that a dump of ecCodes can replace it.
+func TestDecodeData_CCSDSReference(t *testing.T) {
+	data, err := os.ReadFile("testdata/ccsds.grib2")
+	require.NoError(t, err)
+	golden, err := os.ReadFile("testdata/ccsds.values")
+	require.NoError(t, err)
+
+	var want [][3]float64
+	for _, line := range strings.Split(strings.TrimSpace(string(golden)), "\n")[1:] {
+		columns := strings.Fields(line)
+		require.Len(t, columns, 3, line)
+		var point [3]float64
+		for k := range point {
+			point[k], err = strconv.ParseFloat(columns[k], 64)
+			require.NoError(t, err, line)
+		}
+		want = append(want, point)
+	}
+
+	messages := flatMessages(t, data)
+	require.Len(t, messages, 1)
+	field := messages[0]
+	assert.Equal(t, &template.CCSDSPackingInfo{CCSDSFlags: 14, BlockSize: 32, RSILength: 128}, field.DataRep.CCSDS)
+	values, err := field.DecodeData()
+	require.NoError(t, err)
+	require.Len(t, values, len(want))
+	for i, point := range want {
+		lat, lon, err := field.LatLonAt(i)
+		require.NoError(t, err)
+		assert.InDelta(t, point[0], lat, 1e-3, "latitude %d", i)
+		assert.InDelta(t, point[1], lon, 1e-3, "longitude %d", i)
+		if !assert.InDelta(t, point[2], values[i], 1e-9, "value %d", i) {
+			break
+		}
+	}
+}
+
+func TestDecodeData_IEEE(t *testing.T) {
+	values := []float64{-40.125, 0, 1e-7, 5e9, 12.5, math.NaN()}
+	for _, bits := range []uint8{32, 64} {
+		messages := flatMessages(t, testgrib.MustEncode(testgrib.Spec{Packing: 4, BitsPerValue: bits, Ni: 3, Nj: 2, Values: values}))
+		require.Len(t, messages, 1)
+		field := messages[0]
+
+		require.NotNil(t, field.DataRep.IEEE)
+		assert.Equal(t, bits, field.DataRep.NumberOfBitsUsedForData)
+		ok, reason := field.CanDecode()
+		assert.True(t, ok)
+		assert.NoError(t, reason)
+
+		// Values are not dithered: they carry no quantisation error
+		decoded, err := field.DecodeData(reader.WithDither())
+		require.NoError(t, err)
+		for i, want := range values {
+			if bits == 32 {
+				want = float64(float32(want))
+			}
+			if math.IsNaN(want) {
+				assert.True(t, math.IsNaN(decoded[i]))
+				continue
+			}
+			assert.Equal(t, want, decoded[i], "%d bits, value %d", bits, i)
+		}
+
+		_, _, _, _, err = field.DecodeRaw()
+		assert.Equal(t, &reader.ErrUnsupportedTemplate{Kind: "datarep", Number: 4}, err)
+	}
+}
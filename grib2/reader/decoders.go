@@ -0,0 +1,83 @@
+package reader
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/scorix/grib/grib2/packing"
+	"github.com/scorix/grib/grib2/section"
+)
+
+// DataDecoder unpacks the data values of a field from its data representation section and
+// data section. npoints is the number of packed values given in Section 5; points masked
+// out by a bit-map have no value and are left to the caller.
+type DataDecoder func(sec5 section.Section5, sec7 section.Section7, npoints int) ([]float64, error)
+
+// dataDecoder is a decoder in the registry
+type dataDecoder struct {
+	decode  DataDecoder
+	builtin bool // Decoded by the packing package from packed integers, which it also gives
+}
+
+var (
+	dataDecodersMu sync.RWMutex
+	dataDecoders   = make(map[uint16]dataDecoder)
+)
+
+func init() {
+	// The decoders of the packing package are dispatched like any other
+	for _, number := range packing.TemplateNumbers() {
+		dataDecoders[uint16(number)] = dataDecoder{decode: decodePacked, builtin: packing.HasPackedIntegers(number)}
+	}
+}
+
+// RegisterDataDecoder makes fn decode the fields packed with a data representation
+// template (Code Table 5.0), e.g. a local template of a centre decoded by a wrapper of the
+// centre's library. FlatMessage.DecodeData and the readers built on it then decode these
+// fields, and CanDecode and SupportedDataRepTemplates report them as decodable. A decoder
+// registered for a template decoded by the package replaces the built-in one.
+//
+// Decoders are shared by all readers; register them during initialisation, before fields
+// are decoded.
+func RegisterDataDecoder(templateNumber uint16, fn DataDecoder) {
+	dataDecodersMu.Lock()
+	defer dataDecodersMu.Unlock()
+	dataDecoders[templateNumber] = dataDecoder{decode: fn}
+}
+
+// lookupDataDecoder returns the decoder registered for a data representation template
+func lookupDataDecoder(templateNumber uint16) (dataDecoder, bool) {
+	dataDecodersMu.RLock()
+	defer dataDecodersMu.RUnlock()
+	decoder, ok := dataDecoders[templateNumber]
+	return decoder, ok
+}
+
+// decodableDataRepTemplates returns the data representation templates with a decoder, in
+// ascending order
+func decodableDataRepTemplates() []uint16 {
+	dataDecodersMu.RLock()
+	defer dataDecodersMu.RUnlock()
+	return slices.Sorted(maps.Keys(dataDecoders))
+}
+
+// simplePacking reports whether the field is simply packed and decoded by the packing
+// package, so that its values can be read individually (see packing.SimpleByteRange)
+func (f *FlatMessage) simplePacking() bool {
+	decoder, ok := lookupDataDecoder(uint16(f.DataRep.TemplateNumber))
+	return f.DataRep.TemplateNumber == 0 && ok && decoder.builtin
+}
+
+// decodePacked is the DataDecoder of the templates decoded by the packing package
+func decodePacked(sec5 section.Section5, sec7 section.Section7, npoints int) ([]float64, error) {
+	f := FlatMessage{DataRepSec: sec5, Data: sec7}
+	f.extractDataRepInfo()
+
+	data := sec7.Data()
+	if err := sec7.LoadError(); err != nil {
+		return nil, fmt.Errorf("decode: failed to load data section: %w", err)
+	}
+	return packing.Decode(&f.DataRep, data, npoints)
+}
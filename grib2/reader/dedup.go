@@ -0,0 +1,129 @@
+package reader
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// DedupMode selects how WithDeduplicate recognises a repeated message
+type DedupMode int
+
+const (
+	// DedupBytes recognises messages whose bytes are identical to an earlier message
+	DedupBytes DedupMode = iota
+	// DedupMetadata recognises messages whose sections are identical to an earlier message
+	// apart from the content of their bit-maps and data, e.g. a field sent again with
+	// corrected values
+	DedupMetadata
+)
+
+// String returns the name of the mode
+func (m DedupMode) String() string {
+	switch m {
+	case DedupBytes:
+		return "bytes"
+	case DedupMetadata:
+		return "metadata"
+	default:
+		return fmt.Sprintf("DedupMode(%d)", int(m))
+	}
+}
+
+// WithDeduplicate skips the messages that repeat an earlier message of the file, as
+// recognised by mode, so that files assembled from several sources do not yield the same
+// field twice. The number of messages skipped by the last iteration is reported by
+// DuplicatesSkipped. Skipped messages keep their place in the message indexes.
+func WithDeduplicate(mode DedupMode) Option {
+	return func(o *options) {
+		o.dedup = true
+		o.dedupMode = mode
+	}
+}
+
+// digest is a SHA-256 hash. Messages are recognised as duplicates by their hashes alone,
+// as the streaming Reader cannot read earlier messages again to compare their bytes: a
+// cryptographic hash makes a collision between distinct messages implausible, which a
+// 64 bit hash such as FNV-1a does not in files of many messages.
+type digest [sha256.Size]byte
+
+// deduplicator remembers the fingerprints of the messages of one iteration
+type deduplicator struct {
+	seen    map[digest]bool
+	skipped int
+	err     error // Error fingerprinting a message, which stops the iteration
+}
+
+// duplicate reports whether fingerprint was seen before, and remembers it
+func (d *deduplicator) duplicate(fingerprint digest) bool {
+	if d.seen == nil {
+		d.seen = make(map[digest]bool)
+	}
+	if d.seen[fingerprint] {
+		d.skipped++
+		return true
+	}
+	d.seen[fingerprint] = true
+	return false
+}
+
+// skipDuplicates wraps fn to skip the messages that d has seen before
+func (r *ReaderAt) skipDuplicates(d *deduplicator, fn func(int, MessageInfo) bool) func(int, MessageInfo) bool {
+	return func(index int, info MessageInfo) bool {
+		fingerprint, err := r.dedupFingerprint(info)
+		if err != nil {
+			d.err = err
+			return false
+		}
+		if d.duplicate(fingerprint) {
+			return true
+		}
+		return fn(index, info)
+	}
+}
+
+// dedupFingerprint fingerprints the message described by info for WithDeduplicate
+func (r *ReaderAt) dedupFingerprint(info MessageInfo) (digest, error) {
+	h := sha256.New()
+	if r.opts.dedupMode == DedupMetadata {
+		if err := r.hashMetadata(h, info); err != nil {
+			return digest{}, err
+		}
+		return digest(h.Sum(nil)), nil
+	}
+
+	if _, err := io.Copy(h, io.NewSectionReader(r.reader, info.Offset, int64(info.Length))); err != nil {
+		return digest{}, fmt.Errorf("failed to read message at offset %d: %w", info.Offset, err)
+	}
+	return digest(h.Sum(nil)), nil
+}
+
+// DuplicatesSkipped returns the number of messages skipped as duplicates by the last
+// iteration with WithDeduplicate
+func (r *ReaderAt) DuplicatesSkipped() int {
+	return int(r.skipped.Load())
+}
+
+// messageFingerprint combines the digests of the sections first to end of a message,
+// computed as their bytes streamed past, leaving out the bit-maps and data with
+// DedupMetadata
+func (r *Reader) messageFingerprint(first, end int) digest {
+	h := sha256.New()
+	for k := first; k <= end; k++ {
+		sec := r.sections[k]
+		h.Write([]byte{sec.SectionNumber()})
+		h.Write(binary.BigEndian.AppendUint32(nil, r.getSectionLength(sec)))
+		if r.opts.dedupMode == DedupMetadata && (sec.SectionNumber() == 6 || sec.SectionNumber() == 7) {
+			continue
+		}
+		h.Write(r.digests[k][:])
+	}
+	return digest(h.Sum(nil))
+}
+
+// DuplicatesSkipped returns the number of messages skipped as duplicates by the last
+// iteration with WithDeduplicate
+func (r *Reader) DuplicatesSkipped() int {
+	return r.skipped
+}
@@ -0,0 +1,70 @@
+package reader_test
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/scorix/grib/grib2/internal/testgrib"
+	"github.com/scorix/grib/grib2/reader"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// dedupReader is implemented by Reader and ReaderAt
+type dedupReader interface {
+	EachFlatMessage(fn func(int, reader.FlatMessage) bool) error
+	DuplicatesSkipped() int
+}
+
+func TestWithDeduplicate(t *testing.T) {
+	data, err := os.ReadFile("testdata/gfs.t00z.pgrb2.0p25.f000")
+	require.NoError(t, err)
+	first := data[:868737]
+	repeated := bytes.Join([][]byte{first, first}, nil)
+
+	// The same field holding other values in the same range has the same metadata but not
+	// the same bytes
+	values := make([]float64, 16)
+	for k := range values {
+		values[k] = float64(15 - k)
+	}
+	repacked := bytes.Join([][]byte{testgrib.MustEncode(testgrib.Spec{}), testgrib.MustEncode(testgrib.Spec{Values: values})}, nil)
+
+	for _, tt := range []struct {
+		name    string
+		data    []byte
+		mode    reader.DedupMode
+		fields  int
+		skipped int
+	}{
+		{"repeated bytes", repeated, reader.DedupBytes, 1, 1},
+		{"repeated metadata", repeated, reader.DedupMetadata, 1, 1},
+		{"repacked bytes", repacked, reader.DedupBytes, 2, 0},
+		{"repacked metadata", repacked, reader.DedupMetadata, 1, 1},
+	} {
+		for name, open := range map[string]func(...reader.Option) dedupReader{
+			"ReaderAt": func(opts ...reader.Option) dedupReader { return reader.NewReaderAt(bytes.NewReader(tt.data), opts...) },
+			"Reader":   func(opts ...reader.Option) dedupReader { return reader.NewReader(bytes.NewReader(tt.data), opts...) },
+		} {
+			t.Run(tt.name+"/"+name, func(t *testing.T) {
+				r := open(reader.WithDeduplicate(tt.mode))
+				var indexes []int
+				require.NoError(t, r.EachFlatMessage(func(index int, _ reader.FlatMessage) bool {
+					indexes = append(indexes, index)
+					return true
+				}))
+				assert.Len(t, indexes, tt.fields)
+				assert.Equal(t, tt.skipped, r.DuplicatesSkipped())
+
+				// Without the option both messages are read
+				var count int
+				require.NoError(t, open().EachFlatMessage(func(int, reader.FlatMessage) bool {
+					count++
+					return true
+				}))
+				assert.Equal(t, 2, count)
+			})
+		}
+	}
+}
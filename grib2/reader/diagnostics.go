@@ -0,0 +1,71 @@
+package reader
+
+import (
+	"errors"
+	"math"
+	"math/bits"
+
+	"github.com/scorix/grib/grib2/template"
+)
+
+// PackingReport describes how a data field is packed and how much space an
+// optimally configured simple packing would need for the same values
+type PackingReport struct {
+	TemplateNumber     int     `json:"template_number"`      // Data representation template number
+	Method             string  `json:"method"`               // Name of the packing method (Code Table 5.0)
+	NumberOfValues     int     `json:"number_of_values"`     // Number of packed values
+	Minimum            float64 `json:"minimum"`              // Smallest decoded value
+	Maximum            float64 `json:"maximum"`              // Largest decoded value
+	IsConstant         bool    `json:"is_constant"`          // True if packed with 0 bits or all values are equal
+	BitsPerValue       int     `json:"bits_per_value"`       // Bits per value declared in Section 5
+	MinimumBits        int     `json:"minimum_bits"`         // Bits needed for the observed range at the declared decimal scale
+	PackedBytes        int     `json:"packed_bytes"`         // Size of the Section 7 payload
+	OptimalPackedBytes int     `json:"optimal_packed_bytes"` // Estimated simple packing size at MinimumBits
+}
+
+// PackingDiagnostics decodes the data field of msg and reports its packing efficiency
+func PackingDiagnostics(msg FlatMessage) (PackingReport, error) {
+	report := PackingReport{
+		TemplateNumber: msg.DataRep.TemplateNumber,
+		Method:         template.DataRepresentationName(msg.DataRep.TemplateNumber),
+		BitsPerValue:   int(msg.DataRep.NumberOfBitsUsedForData),
+	}
+	if msg.Data != nil {
+		report.PackedBytes = int(msg.Data.DataSize())
+	}
+
+	// Fields storing no values decode as all missing
+	values, err := msg.DecodeData()
+	if err != nil && !errors.Is(err, &ErrNoStoredValues{}) {
+		return report, err
+	}
+
+	// Single pass over the values for the observed range
+	minimum, maximum := math.Inf(1), math.Inf(-1)
+	for _, v := range values {
+		if math.IsNaN(v) {
+			continue
+		}
+		report.NumberOfValues++
+		minimum = math.Min(minimum, v)
+		maximum = math.Max(maximum, v)
+	}
+
+	if report.NumberOfValues == 0 {
+		report.IsConstant = true
+		return report, nil
+	}
+
+	report.Minimum = minimum
+	report.Maximum = maximum
+	report.IsConstant = report.BitsPerValue == 0 || minimum == maximum
+
+	// Integer range the packer has to represent once the decimal scale is applied
+	scaledRange := math.Round((maximum - minimum) * math.Pow(10, float64(msg.DataRep.DecimalScaleFactor)))
+	if scaledRange > 0 {
+		report.MinimumBits = bits.Len64(uint64(scaledRange))
+	}
+	report.OptimalPackedBytes = (report.NumberOfValues*report.MinimumBits + 7) / 8
+
+	return report, nil
+}
@@ -0,0 +1,87 @@
+package reader_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/scorix/grib/grib2/internal/testgrib"
+	"github.com/scorix/grib/grib2/reader"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPackingDiagnostics_ConstantField(t *testing.T) {
+	messages := flatMessages(t, testgrib.MustEncode(testgrib.Spec{Ni: 2, Nj: 2, DecimalScale: 1, Values: []float64{273.15, 273.15, 273.15, 273.15}}))
+	require.Len(t, messages, 1)
+
+	report, err := reader.PackingDiagnostics(messages[0])
+	require.NoError(t, err)
+
+	assert.True(t, report.IsConstant)
+	assert.Equal(t, 0, report.TemplateNumber)
+	assert.Equal(t, "grid point data - simple packing", report.Method)
+	assert.Equal(t, 4, report.NumberOfValues)
+	assert.InDelta(t, 273.15, report.Minimum, 1e-4)
+	assert.InDelta(t, 273.15, report.Maximum, 1e-4)
+	assert.Equal(t, 0, report.BitsPerValue)
+	assert.Equal(t, 0, report.MinimumBits)
+	assert.Equal(t, 0, report.PackedBytes)
+	assert.Equal(t, 0, report.OptimalPackedBytes)
+}
+
+func TestPackingDiagnostics_ConstantFieldPackedWithBits(t *testing.T) {
+	// Four identical values wastefully packed at 16 bits
+	messages := flatMessages(t, testgrib.MustEncode(testgrib.Spec{Ni: 2, Nj: 2, BitsPerValue: 16, Values: []float64{5, 5, 5, 5}}))
+	require.Len(t, messages, 1)
+
+	report, err := reader.PackingDiagnostics(messages[0])
+	require.NoError(t, err)
+
+	assert.True(t, report.IsConstant)
+	assert.Equal(t, 16, report.BitsPerValue)
+	assert.Equal(t, 0, report.MinimumBits)
+	assert.Equal(t, 8, report.PackedBytes)
+	assert.Equal(t, 0, report.OptimalPackedBytes)
+}
+
+func TestPackingDiagnostics_FullRangeField(t *testing.T) {
+	// Values 0, 85, 170, 255 use the full 8-bit range
+	messages := flatMessages(t, testgrib.MustEncode(testgrib.Spec{Ni: 2, Nj: 2, Values: []float64{0, 85, 170, 255}}))
+	require.Len(t, messages, 1)
+
+	report, err := reader.PackingDiagnostics(messages[0])
+	require.NoError(t, err)
+
+	assert.False(t, report.IsConstant)
+	assert.Equal(t, 0.0, report.Minimum)
+	assert.Equal(t, 255.0, report.Maximum)
+	assert.Equal(t, 8, report.BitsPerValue)
+	assert.Equal(t, 8, report.MinimumBits)
+	assert.Equal(t, 4, report.PackedBytes)
+	assert.Equal(t, 4, report.OptimalPackedBytes)
+
+	encoded, err := json.Marshal(report)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"template_number": 0,
+		"method": "grid point data - simple packing",
+		"number_of_values": 4,
+		"minimum": 0,
+		"maximum": 255,
+		"is_constant": false,
+		"bits_per_value": 8,
+		"minimum_bits": 8,
+		"packed_bytes": 4,
+		"optimal_packed_bytes": 4
+	}`, string(encoded))
+}
+
+func TestPackingDiagnostics_UnsupportedTemplate(t *testing.T) {
+	messages := flatMessages(t, unsupportedPackingMessage(t))
+	require.NotEmpty(t, messages)
+
+	report, err := reader.PackingDiagnostics(messages[0])
+	assert.Error(t, err)
+	assert.Equal(t, 51, report.TemplateNumber)
+	assert.Equal(t, 8, report.BitsPerValue)
+}
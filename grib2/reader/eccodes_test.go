@@ -0,0 +1,238 @@
+//go:build eccodes
+
+package reader_test
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scorix/grib/grib2/internal/testgrib"
+	"github.com/scorix/grib/grib2/packing"
+	"github.com/scorix/grib/grib2/reader"
+	"github.com/stretchr/testify/require"
+)
+
+// The comparison with ecCodes runs with
+//
+//	GRIB_ECCODES=1 go test -tags eccodes -run ECCodes ./reader
+//
+// and is skipped when the ecCodes command line tools are not installed. Fields are aligned
+// by their index in the file, which matches the order ecCodes visits them in with its
+// multi-field support, on by default in its tools.
+//
+// The comparison has only been exercised without ecCodes so far, where it skips. Its first
+// run against an installed ecCodes should also confirm the grib_get keys and the layout of
+// the grib_get_data output parsed below.
+
+// eccodesMismatches is the number of mismatching values reported per field
+const eccodesMismatches = 10
+
+// eccodesKeys are the keys read with grib_get for each field
+var eccodesKeys = []string{
+	"discipline",
+	"parameterCategory",
+	"parameterNumber",
+	"typeOfFirstFixedSurface",
+	"scaleFactorOfFirstFixedSurface",
+	"scaledValueOfFirstFixedSurface",
+	"stepRange",
+}
+
+func TestECCodes(t *testing.T) {
+	if os.Getenv("GRIB_ECCODES") == "" {
+		t.Skip("set GRIB_ECCODES=1 to compare with ecCodes")
+	}
+	for _, tool := range []string{"grib_get", "grib_get_data"} {
+		if _, err := exec.LookPath(tool); err != nil {
+			t.Skipf("ecCodes is not installed: %v", err)
+		}
+	}
+
+	// Fixtures of simple and CCSDS packing, whose values this package decodes, next to the
+	// testdata
+	var fixtures []byte
+	for _, spec := range []testgrib.Spec{
+		{DecimalScale: 1, Values: []float64{-1.5, 0, 2.5, 1e3, -7, 3.1, 0.2, 9, 1, 2, 3, 4, 5, 6, 7, 8}},
+		{ProductTemplate: 8, Category: 1, Parameter: 8, RangeHours: 6, StatisticalProcess: 1, SurfaceType: 1},
+		{SurfaceType: 100, SurfaceValue: 50000, ForecastHours: 12, Bitmap: []bool{true, false, true, true, false, true, true, true, true, true, true, true, true, true, true, false}},
+		{LatFirst: -10, LonFirst: 350, ScanningMode: 0x40, Ni: 5, Nj: 3, BitsPerValue: 20},
+		{Packing: 42, DecimalScale: 1, Values: []float64{271.5, 271.5, 271.6, 272, 280.3, 265.1, 271.5, 271.5, 1, 2, 3, 4, 5, 6, 7, 8}},
+	} {
+		fixtures = append(fixtures, testgrib.MustEncode(spec)...)
+	}
+	fixturePath := filepath.Join(t.TempDir(), "fixtures.grib2")
+	require.NoError(t, os.WriteFile(fixturePath, fixtures, 0o644))
+
+	for _, path := range []string{"testdata/gfs.t00z.pgrb2.0p25.f000", "testdata/ccsds.grib2", fixturePath} {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			compareWithECCodes(t, path)
+		})
+	}
+}
+
+// compareWithECCodes compares the identification, level, step and decoded values of each
+// field of the file at path with those ecCodes reads
+func compareWithECCodes(t *testing.T, path string) {
+	want := eccodesFields(t, path)
+
+	f, err := reader.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var fields []reader.FlatMessage
+	require.NoError(t, f.EachFlatMessage(func(_ int, msg reader.FlatMessage) bool {
+		fields = append(fields, msg)
+		return true
+	}))
+	require.Len(t, fields, len(want), "number of fields")
+
+	for i, msg := range fields {
+		name := fmt.Sprintf("field %d (%s, %s, %s)", i, parameterCode(&msg), msg.LevelString(), msg.StepString())
+		if got := identification(&msg); got != want[i] {
+			t.Errorf("%s: %v, ecCodes has %v", name, got, want[i])
+		}
+
+		if ok, err := msg.CanDecode(); !ok {
+			t.Logf("%s: values not compared: %v", name, err)
+			continue
+		}
+		compareValues(t, name, &msg, eccodesValues(t, path, i))
+	}
+}
+
+// parameterCode names the parameter of a field by discipline, category and number
+func parameterCode(msg *reader.FlatMessage) string {
+	return fmt.Sprintf("%d.%d.%d", msg.Discipline, msg.Product.Category, msg.Product.Parameter)
+}
+
+// identification returns the values of eccodesKeys for a field, formatted as grib_get
+// prints them
+func identification(msg *reader.FlatMessage) string {
+	p := &msg.Product
+	scaleFactor, scaledValue := strconv.Itoa(int(p.ScaleFactorOfFirstFixedSurface)), strconv.FormatUint(uint64(p.ScaledValueOfFirstFixedSurface), 10)
+	if p.ScaleFactorOfFirstFixedSurface == -127 {
+		scaleFactor = "MISSING"
+	}
+	if p.ScaledValueOfFirstFixedSurface == math.MaxUint32 {
+		scaledValue = "MISSING"
+	}
+
+	stepRange := "?"
+	if start, end, err := msg.StepRange(); err == nil {
+		stepRange = strconv.Itoa(int(start / time.Hour))
+		if end != start {
+			stepRange += "-" + strconv.Itoa(int(end/time.Hour))
+		}
+	}
+
+	return strings.Join([]string{
+		strconv.Itoa(msg.Discipline),
+		strconv.Itoa(int(p.Category)),
+		strconv.Itoa(int(p.Parameter)),
+		strconv.Itoa(int(p.TypeOfFirstFixedSurface)),
+		scaleFactor,
+		scaledValue,
+		stepRange,
+	}, " ")
+}
+
+// eccodesFields returns the values of eccodesKeys for each field of the file, one line per
+// field as printed by grib_get
+func eccodesFields(t *testing.T, path string) []string {
+	out := runECCodes(t, "grib_get", "-p", strings.Join(eccodesKeys, ","), path)
+	var fields []string
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		fields = append(fields, strings.Join(strings.Fields(line), " "))
+	}
+	return fields
+}
+
+// eccodesPoint is a grid point printed by grib_get_data
+type eccodesPoint struct {
+	lat, lon, value float64
+}
+
+// eccodesValues returns the grid points of the index-th field of the file, in scanning
+// order, with NaN for missing values
+func eccodesValues(t *testing.T, path string, index int) []eccodesPoint {
+	out := runECCodes(t, "grib_get_data", "-w", fmt.Sprintf("count=%d", index+1), "-F", "%.17g", "-m", "nan", path)
+
+	var points []eccodesPoint
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		columns := strings.Fields(scanner.Text())
+		if len(columns) != 3 || columns[0] == "Latitude" {
+			continue // Header
+		}
+		var p eccodesPoint
+		var err error
+		for k, dst := range []*float64{&p.lat, &p.lon, &p.value} {
+			if *dst, err = strconv.ParseFloat(columns[k], 64); err != nil {
+				t.Fatalf("grib_get_data: line %q: %v", scanner.Text(), err)
+			}
+		}
+		points = append(points, p)
+	}
+	return points
+}
+
+// compareValues compares the decoded values and grid points of a field with those of
+// ecCodes, reporting the first eccodesMismatches differences with their grid indices.
+// Values may differ by half the quantization step of the field.
+func compareValues(t *testing.T, name string, msg *reader.FlatMessage, want []eccodesPoint) {
+	values, err := msg.DecodeData()
+	if err != nil {
+		t.Errorf("%s: %v", name, err)
+		return
+	}
+	if len(values) != len(want) {
+		t.Errorf("%s: %d values, ecCodes has %d", name, len(values), len(want))
+		return
+	}
+
+	step := packing.QuantizationStep(int(msg.DataRep.BinaryScaleFactor), int(msg.DataRep.DecimalScaleFactor))
+	mismatches := 0
+	for i, v := range values {
+		w := want[i]
+		lat, lon, err := msg.LatLonAt(i)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			return
+		}
+
+		tolerance := step/2 + 1e-9*math.Abs(w.value)
+		valueOK := math.IsNaN(v) == math.IsNaN(w.value) && (math.IsNaN(v) || math.Abs(v-w.value) <= tolerance)
+		locationOK := math.Abs(lat-w.lat) <= 1e-6 && math.Abs(math.Remainder(lon-w.lon, 360)) <= 1e-6
+		if valueOK && locationOK {
+			continue
+		}
+
+		mismatches++
+		if mismatches <= eccodesMismatches {
+			t.Errorf("%s: index %d: %v at (%v, %v), ecCodes has %v at (%v, %v)", name, i, v, lat, lon, w.value, w.lat, w.lon)
+		}
+	}
+	if mismatches > eccodesMismatches {
+		t.Errorf("%s: %d more mismatches", name, mismatches-eccodesMismatches)
+	}
+}
+
+// runECCodes runs an ecCodes tool and returns its output
+func runECCodes(t *testing.T, tool string, args ...string) string {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(tool, args...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("%s %s: %v: %s", tool, strings.Join(args, " "), err, stderr.String())
+	}
+	return stdout.String()
+}
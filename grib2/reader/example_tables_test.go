@@ -0,0 +1,99 @@
+//go:build !nointernaltables
+
+package reader_test
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/scorix/grib/grib2/reader"
+	"github.com/scorix/grib/grib2/template"
+	"github.com/scorix/grib/grib2/writer"
+)
+
+// printInventory lists the fields of r in the style of a wgrib2 inventory
+func printInventory(r *reader.ReaderAt) error {
+	return r.EachFlatMessage(func(index int, msg reader.FlatMessage) bool {
+		param, _ := msg.Parameter()
+		fmt.Printf("%d:%d:%s:%s:%s\n", index+1, msg.Offset, param.Abbreviation, msg.LevelString(), msg.StepString())
+		return true
+	})
+}
+
+// Lists the fields of a file served over HTTP, reading only their headers with range
+// requests
+func Example_remoteInventory() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		http.ServeContent(w, req, "forecast.grib2", time.Time{}, bytes.NewReader(exampleFile()))
+	}))
+	defer server.Close()
+
+	remote, err := reader.NewHTTPReaderAt(server.URL + "/forecast.grib2")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := printInventory(reader.NewReaderAt(remote)); err != nil {
+		log.Fatal(err)
+	}
+	// Output:
+	// 1:0:TMP:surface:anl
+	// 2:187:TMP:surface:6 hour fcst
+	// 3:374:TMP:surface:12 hour fcst
+	// 4:561:APCP:surface:0-6 hour acc fcst
+}
+
+// Decodes the one field selected by a filter
+func ExampleReaderAt_EachFilteredMessage() {
+	r := reader.NewReaderAt(bytes.NewReader(exampleFile()))
+
+	filter := reader.Filter{
+		Category:  reader.Ptr(uint8(0)),
+		Number:    reader.Ptr(uint8(0)),
+		StepRange: &reader.Step{Start: 6 * time.Hour, End: 6 * time.Hour},
+	}
+	err := r.EachFilteredMessage(filter, func(_ int, msg reader.FlatMessage) bool {
+		values, err := msg.DecodeData()
+		if err != nil {
+			log.Fatal(err)
+		}
+		valid, _ := msg.ValidTime()
+		fmt.Println(msg.StepString(), "valid at", valid.Format(time.RFC3339), msg.Unit())
+		fmt.Println(values[:4])
+		return true
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	// Output:
+	// 6 hour fcst valid at 2024-01-01T06:00:00Z K
+	// [283 284 285 286]
+}
+
+// Writes the accumulations of a file to a smaller file, copying their sections without
+// decoding them
+func ExampleReaderAt_WriteField() {
+	r := reader.NewReaderAt(bytes.NewReader(exampleFile()))
+
+	var out bytes.Buffer
+	w := writer.NewMessageWriter(&out, writer.WithoutProvenance())
+	filter := reader.Filter{StatisticalProcess: reader.Ptr(template.StatisticalAccumulation)}
+	err := r.EachFilteredMessage(filter, func(_ int, msg reader.FlatMessage) bool {
+		if err := r.WriteField(w, &msg); err != nil {
+			log.Fatal(err)
+		}
+		return true
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := printInventory(reader.NewReaderAt(bytes.NewReader(out.Bytes()))); err != nil {
+		log.Fatal(err)
+	}
+	// Output:
+	// 1:0:APCP:surface:0-6 hour acc fcst
+}
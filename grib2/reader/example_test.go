@@ -0,0 +1,94 @@
+package reader_test
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/scorix/grib/grib2/internal/testgrib"
+	"github.com/scorix/grib/grib2/reader"
+)
+
+// exampleFile builds the forecast file of the examples: the temperature at the surface at
+// 0, 6 and 12 hours and the precipitation accumulated over the first 6 hours, on a 4x4
+// grid from 3N 0E to 0N 3E
+func exampleFile() []byte {
+	var data []byte
+	for _, hours := range []uint32{0, 6, 12} {
+		values := make([]float64, 16)
+		for i := range values {
+			values[i] = 280 + float64(i) + float64(hours)/2
+		}
+		data = append(data, testgrib.MustEncode(testgrib.Spec{LatFirst: 3, ForecastHours: hours, Values: values})...)
+	}
+	return append(data, testgrib.MustEncode(testgrib.Spec{
+		LatFirst:           3,
+		ProductTemplate:    8,
+		Category:           1,
+		Parameter:          8,
+		StatisticalProcess: 1,
+		RangeHours:         6,
+	})...)
+}
+
+// Extracts the time series of a parameter at a point
+func ExampleFlatMessage_ValueAt() {
+	r := reader.NewReaderAt(bytes.NewReader(exampleFile()))
+
+	filter := reader.Filter{
+		Category:              reader.Ptr(uint8(0)),
+		Number:                reader.Ptr(uint8(0)),
+		ProductTemplateNumber: reader.Ptr(uint16(0)),
+	}
+	var fields []reader.FlatMessage
+	if err := r.EachFilteredMessage(filter, func(_ int, msg reader.FlatMessage) bool {
+		fields = append(fields, msg)
+		return true
+	}); err != nil {
+		log.Fatal(err)
+	}
+	reader.SortFlatMessages(fields, reader.ValidTime)
+
+	for _, msg := range fields {
+		value, ok, err := msg.ValueAt(1, 2)
+		if err != nil || !ok {
+			log.Fatal(ok, err)
+		}
+		valid, _ := msg.ValidTime()
+		fmt.Println(valid.Format(time.RFC3339), value)
+	}
+	// Output:
+	// 2024-01-01T00:00:00Z 290
+	// 2024-01-01T06:00:00Z 293
+	// 2024-01-01T12:00:00Z 296
+}
+
+// Subsets a field to the region from 2N to 1N and 1E to 2E
+func ExampleFlatMessage_DecodeRows() {
+	r := reader.NewReaderAt(bytes.NewReader(exampleFile()))
+
+	err := r.EachFlatMessage(func(_ int, msg reader.FlatMessage) bool {
+		def, err := msg.GridDefinition()
+		if err != nil {
+			log.Fatal(err)
+		}
+		ni, _ := def.Dims()
+
+		// Rows are north-up: row 1 is 2N and row 2 is 1N
+		rows, err := msg.DecodeRows(1, 2)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for row := range 2 {
+			fmt.Println(rows[row*ni+1 : row*ni+3])
+		}
+		return false
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	// Output:
+	// [285 286]
+	// [289 290]
+}
@@ -0,0 +1,100 @@
+package reader_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"testing"
+
+	"github.com/scorix/grib/grib2/internal/testgrib"
+	"github.com/scorix/grib/grib2/reader"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fieldKey identifies a field as external systems cache it
+type fieldKey struct {
+	Index     int
+	Offset    int64 // Offset of the message
+	Section4  int64 // Offset of the Section 4 of the field
+	Parameter string
+}
+
+// fieldKeys returns the keys of the fields yielded by EachFlatMessage
+func fieldKeys(t *testing.T, it messageIterator) []fieldKey {
+	var keys []fieldKey
+	require.NoError(t, it.EachFlatMessage(func(index int, msg reader.FlatMessage) bool {
+		assert.Equal(t, msg.Index, index)
+		section4, ok := msg.Section4Offset()
+		assert.True(t, ok)
+		keys = append(keys, fieldKey{Index: index, Offset: msg.Offset, Section4: section4, Parameter: msg.StepString() + " " + msg.LevelString()})
+		return true
+	}))
+	return keys
+}
+
+// anomalyCorpus returns messages whose sections are out of order or incomplete, followed
+// by a clean message, with the indexes of their fields
+func anomalyCorpus() (data []byte, indexes []int) {
+	// Section 0 claims 8 more octets than the message holds
+	longer := testgrib.MustEncode(testgrib.Spec{ForecastHours: 6})
+	binary.BigEndian.PutUint64(longer[8:16], uint64(len(longer)+8))
+
+	for _, part := range [][]byte{
+		messageFromSections(1, 3, 4, 5, 6, 7, 4, 5, 6, 7), // Fields 0 and 1
+		messageFromSections(1, 3, 4, 5, 6, 4, 5, 6, 7),    // Field 2 has no data, field 3 does
+		messageFromSections(1, 3, 5, 4, 5, 6, 7),          // Field 4 after a stray Section 5
+		messageFromSections(1, 3, 4, 5, 6, 7, 4, 5),       // Field 5, and field 6 without data
+		longer,                               // Field 7
+		testgrib.MustEncode(testgrib.Spec{}), // Field 8
+	} {
+		data = append(data, part...)
+	}
+	return data, []int{0, 1, 3, 4, 5, 7, 8}
+}
+
+func TestFieldIndex_AcrossReaders(t *testing.T) {
+	gfs, err := os.ReadFile("testdata/gfs.t00z.pgrb2.0p25.f000")
+	require.NoError(t, err)
+	anomalies, indexes := anomalyCorpus()
+
+	for name, data := range map[string][]byte{"testdata": gfs, "anomalies": anomalies} {
+		t.Run(name, func(t *testing.T) {
+			fromReaderAt := fieldKeys(t, reader.NewReaderAt(bytes.NewReader(data)))
+			fromReader := fieldKeys(t, reader.NewReader(bytes.NewReader(data)))
+			assert.Equal(t, fromReaderAt, fromReader)
+
+			var filtered []fieldKey
+			require.NoError(t, reader.NewReaderAt(bytes.NewReader(data)).EachFilteredMessage(reader.Filter{}, func(index int, msg reader.FlatMessage) bool {
+				section4, _ := msg.Section4Offset()
+				filtered = append(filtered, fieldKey{Index: index, Offset: msg.Offset, Section4: section4, Parameter: msg.StepString() + " " + msg.LevelString()})
+				return true
+			}))
+			assert.Equal(t, fromReaderAt, filtered)
+
+			if name == "anomalies" {
+				var got []int
+				for _, key := range fromReaderAt {
+					got = append(got, key.Index)
+				}
+				assert.Equal(t, indexes, got)
+			}
+		})
+	}
+}
+
+func TestFieldIndex_Deduplicated(t *testing.T) {
+	first := testgrib.MustEncode(testgrib.Spec{})
+	second := testgrib.MustEncode(testgrib.Spec{ForecastHours: 6})
+	data := bytes.Join([][]byte{first, first, second}, nil)
+
+	for name, it := range map[string]messageIterator{
+		"reader at": reader.NewReaderAt(bytes.NewReader(data), reader.WithDeduplicate(reader.DedupBytes)),
+		"reader":    reader.NewReader(bytes.NewReader(data), reader.WithDeduplicate(reader.DedupBytes)),
+	} {
+		keys := fieldKeys(t, it)
+		require.Len(t, keys, 2, name)
+		assert.Equal(t, 0, keys[0].Index, name)
+		assert.Equal(t, 2, keys[1].Index, name, "the skipped duplicate keeps its index")
+	}
+}
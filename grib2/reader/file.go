@@ -0,0 +1,135 @@
+package reader
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+)
+
+// EnumerationMode tells how the messages of a file are located
+type EnumerationMode uint8
+
+const (
+	ModeScan  EnumerationMode = iota // Messages are found by following Section 0 lengths from the start of the file
+	ModeIndex                        // Message offsets come from a sidecar .idx inventory
+)
+
+// String returns the name of the mode
+func (m EnumerationMode) String() string {
+	switch m {
+	case ModeScan:
+		return "scan"
+	case ModeIndex:
+		return "index"
+	default:
+		return fmt.Sprintf("mode(%d)", m)
+	}
+}
+
+// File is a local GRIB2 file opened for random access
+type File struct {
+	*ReaderAt
+
+	closer    io.Closer
+	mode      EnumerationMode
+	index     []IndexEntry
+	indexPath string
+	indexErr  error
+}
+
+// Open opens a local GRIB2 file. When a wgrib2 style inventory is found next to it
+// (path + ".idx", or path + ".grb2.idx"), message enumeration is driven by the offsets
+// of the inventory instead of scanning the file. An inventory that does not match the
+// file, e.g. because it is stale, is ignored and the file is scanned as usual.
+// Messages and errors are labelled with path unless another WithSource is given.
+func Open(path string, opts ...Option) (*File, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	f := newFile(file, file, path, opts)
+	f.useIndex(file, info.Size(), path, readIndexFile)
+	return f, nil
+}
+
+// newFile wraps r, whose resources closer releases, as the File named name
+func newFile(r io.ReaderAt, closer io.Closer, name string, opts []Option) *File {
+	return &File{
+		ReaderAt: NewReaderAt(r, append([]Option{WithSource(name)}, opts...)...),
+		closer:   closer,
+		mode:     ModeScan,
+	}
+}
+
+// useIndex drives the enumeration of the messages of the file of size octets named name
+// with the first inventory found next to it, read with readIndex, that matches the file
+func (f *File) useIndex(r io.ReaderAt, size int64, name string, readIndex func(string) ([]IndexEntry, error)) {
+	for _, candidate := range []string{name + ".idx", name + ".grb2.idx"} {
+		index, err := readIndex(candidate)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+
+		f.indexPath = candidate
+		if err != nil {
+			f.indexErr = err
+			return
+		}
+
+		spans, err := indexSpans(r, size, index)
+		if err != nil {
+			f.indexErr = fmt.Errorf("stale index %s: %w", candidate, err)
+			return
+		}
+
+		f.ReaderAt.spans = spans
+		f.index = index
+		f.mode = ModeIndex
+		return
+	}
+}
+
+// readIndexFile parses the inventory at path
+func readIndexFile(path string) ([]IndexEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ParseIndex(file)
+}
+
+// Close closes the underlying file
+func (f *File) Close() error {
+	return f.closer.Close()
+}
+
+// Mode returns how the messages of the file are located
+func (f *File) Mode() EnumerationMode {
+	return f.mode
+}
+
+// Index returns the entries of the inventory driving enumeration, or nil in scan mode
+func (f *File) Index() []IndexEntry {
+	return f.index
+}
+
+// IndexPath returns the path of the inventory found next to the file, whether or not it is used
+func (f *File) IndexPath() string {
+	return f.indexPath
+}
+
+// IndexError returns why an inventory found next to the file was not used, or nil
+func (f *File) IndexError() error {
+	return f.indexErr
+}
@@ -0,0 +1,139 @@
+package reader_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/scorix/grib/grib2/reader"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gfsIndex is the wgrib2 inventory of testdata/gfs.t00z.pgrb2.0p25.f000
+const gfsIndex = `1:0:d=2024100100:PRMSL:mean sea level:anl:
+2:868737:d=2024100100:CLMR:1 hybrid level:anl:
+3:966585:d=2024100100:ICMR:1 hybrid level:anl:
+`
+
+// linkTestdata links the GFS testdata file into a temporary directory, optionally with an inventory
+func linkTestdata(t *testing.T, indexName, index string) string {
+	source, err := filepath.Abs("testdata/gfs.t00z.pgrb2.0p25.f000")
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "gfs.t00z.pgrb2.0p25.f000")
+	require.NoError(t, os.Symlink(source, path))
+	if indexName != "" {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, indexName), []byte(index), 0o644))
+	}
+	return path
+}
+
+// fileMessages enumerates the messages of a file
+func fileMessages(t *testing.T, f *reader.File) []reader.MessageInfo {
+	var infos []reader.MessageInfo
+	require.NoError(t, f.EachMessage(func(_ int, info reader.MessageInfo) bool {
+		infos = append(infos, info)
+		return true
+	}))
+	return infos
+}
+
+func TestOpen(t *testing.T) {
+	scanned := func() []reader.MessageInfo {
+		// A fixed source keeps the messages comparable across temporary directories
+		f, err := reader.Open(linkTestdata(t, "", ""), reader.WithSource("gfs"))
+		require.NoError(t, err)
+		defer f.Close()
+
+		assert.Equal(t, reader.ModeScan, f.Mode())
+		assert.Empty(t, f.IndexPath())
+		return fileMessages(t, f)
+	}()
+	require.Len(t, scanned, 3)
+
+	tests := []struct {
+		name      string
+		indexName string
+		index     string
+		mode      reader.EnumerationMode
+	}{
+		{name: "index", indexName: "gfs.t00z.pgrb2.0p25.f000.idx", index: gfsIndex, mode: reader.ModeIndex},
+		{name: "grb2 index", indexName: "gfs.t00z.pgrb2.0p25.f000.grb2.idx", index: gfsIndex, mode: reader.ModeIndex},
+		{
+			name:      "offset not at a message",
+			indexName: "gfs.t00z.pgrb2.0p25.f000.idx",
+			index:     strings.Replace(gfsIndex, ":868737:", ":868700:", 1),
+			mode:      reader.ModeScan,
+		},
+		{
+			name:      "missing message",
+			indexName: "gfs.t00z.pgrb2.0p25.f000.idx",
+			index:     strings.Replace(gfsIndex, "3:966585:d=2024100100:ICMR:1 hybrid level:anl:\n", "", 1),
+			mode:      reader.ModeScan,
+		},
+		{name: "unparsable", indexName: "gfs.t00z.pgrb2.0p25.f000.idx", index: "garbage\n", mode: reader.ModeScan},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := reader.Open(linkTestdata(t, tt.indexName, tt.index), reader.WithSource("gfs"))
+			require.NoError(t, err)
+			defer f.Close()
+
+			assert.Equal(t, tt.mode, f.Mode())
+			assert.Equal(t, tt.indexName, filepath.Base(f.IndexPath()))
+			if tt.mode == reader.ModeIndex {
+				assert.NoError(t, f.IndexError())
+				assert.Len(t, f.Index(), 3)
+			} else {
+				assert.Error(t, f.IndexError())
+				assert.Nil(t, f.Index())
+			}
+
+			assert.Equal(t, scanned, fileMessages(t, f))
+		})
+	}
+}
+
+func TestParseIndex(t *testing.T) {
+	entries, err := reader.ParseIndex(strings.NewReader(
+		"1:0:d=2024100100:UGRD:10 m above ground:anl:\n" +
+			"1.2:0:d=2024100100:VGRD:10 m above ground:anl:\n" +
+			"\n" +
+			"2:4096:d=2024100100:TMP:2 m above ground:6 hour fcst:ENS=+1\n",
+	))
+	require.NoError(t, err)
+
+	assert.Equal(t, []reader.IndexEntry{
+		{Message: 1, Offset: 0, Date: "d=2024100100", Name: "UGRD", Level: "10 m above ground", Step: "anl"},
+		{Message: 1, Field: 2, Offset: 0, Date: "d=2024100100", Name: "VGRD", Level: "10 m above ground", Step: "anl"},
+		{Message: 2, Offset: 4096, Date: "d=2024100100", Name: "TMP", Level: "2 m above ground", Step: "6 hour fcst", Extra: []string{"ENS=+1"}},
+	}, entries)
+
+	_, err = reader.ParseIndex(strings.NewReader("x:0:d=2024100100\n"))
+	require.Error(t, err)
+
+	_, err = reader.ParseIndex(strings.NewReader("1:-5:d=2024100100\n"))
+	require.Error(t, err)
+}
+
+func TestOpen_Source(t *testing.T) {
+	path := linkTestdata(t, "", "")
+
+	f, err := reader.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	for _, info := range fileMessages(t, f) {
+		assert.Equal(t, path, info.Source)
+	}
+
+	g, err := reader.Open(path, reader.WithSource("gfs"))
+	require.NoError(t, err)
+	defer g.Close()
+	for _, info := range fileMessages(t, g) {
+		assert.Equal(t, "gfs", info.Source)
+	}
+}
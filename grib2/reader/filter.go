@@ -0,0 +1,262 @@
+package reader
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/scorix/grib/grib2/section"
+	"github.com/scorix/grib/grib2/template"
+)
+
+// Filter selects fields by their identification and product definition. Nil criteria match
+// every field; a field must satisfy all the others. All criteria are evaluated from
+// Sections 0-4, so filtering never needs the data of a field.
+type Filter struct {
+	Discipline *uint8 // Discipline (Code Table 0.0)
+	Category   *uint8 // Parameter category (Code Table 4.1)
+	Number     *uint8 // Parameter number (Code Table 4.2)
+
+	Significance     *section.ReferenceTimeSignificance // Significance of the reference time (Code Table 1.2)
+	ProductionStatus *section.ProductionStatus          // Production status (Code Table 1.3)
+	DataType         *section.ProcessedDataType         // Type of processed data (Code Table 1.4)
+
+	// ProductTemplateNumber tells instantaneous products (e.g. 4.0) from statistically
+	// processed ones (e.g. 4.8) of the same parameter
+	ProductTemplateNumber *uint16
+	// StatisticalProcess is the type of statistical processing (Code Table 4.10), e.g.
+	// template.StatisticalAccumulation; it only matches statistically processed products
+	StatisticalProcess *template.StatisticalProcess
+	// StepRange is the forecast step as returned by FlatMessage.StepRange
+	StepRange *Step
+
+	// LevelValue is the value of the first fixed surface after its scale factor is applied,
+	// compared in the unit of the level, e.g. 500 hPa whether coded as 50000 Pa or as 500
+	// with scale factor -2
+	LevelValue *Level
+	// LevelRange is an inclusive range of values of the first fixed surface
+	LevelRange *LevelRange
+}
+
+// Step is a forecast step as offsets from the reference time. Start and End are equal
+// for products that are not statistically processed.
+type Step struct {
+	Start, End time.Duration
+}
+
+// Ptr returns a pointer to v, for setting Filter criteria
+func Ptr[T any](v T) *T {
+	return &v
+}
+
+// Match reports whether the field satisfies all criteria of the filter
+func (flt *Filter) Match(f *FlatMessage) bool {
+	if !matches(flt.Discipline, uint8(f.Discipline)) ||
+		!matches(flt.Category, f.Product.Category) ||
+		!matches(flt.Number, f.Product.Parameter) ||
+		!matches(flt.Significance, f.Significance()) ||
+		!matches(flt.ProductionStatus, f.Status()) ||
+		!matches(flt.DataType, f.ProcessedDataType()) ||
+		!matches(flt.ProductTemplateNumber, f.Product.TemplateNumber) {
+		return false
+	}
+
+	if flt.StatisticalProcess != nil {
+		if process, ok := f.StatisticalProcess(); !ok || process != *flt.StatisticalProcess {
+			return false
+		}
+	}
+
+	if flt.LevelValue != nil && !f.matchLevel(*flt.LevelValue) {
+		return false
+	}
+	if flt.LevelRange != nil && !f.matchLevelRange(*flt.LevelRange) {
+		return false
+	}
+
+	if flt.StepRange != nil {
+		start, end, err := f.StepRange()
+		if err != nil || (Step{Start: start, End: end}) != *flt.StepRange {
+			return false
+		}
+	}
+
+	return true
+}
+
+// matches reports whether an optional criterion accepts v
+func matches[T comparable](criterion *T, v T) bool {
+	return criterion == nil || *criterion == v
+}
+
+// EachFilteredMessage iterates through the flattened fields that match filter. Each field is
+// matched on its Sections 0-4 before its Sections 5-7 are read, so the data of fields that do
+// not match is never read nor decoded. The callback receives the index of the field, the
+// same as in EachFlatMessage. Return true to continue iteration, false to stop.
+func (r *ReaderAt) EachFilteredMessage(filter Filter, fn func(int, FlatMessage) bool) error {
+	var readErr error
+
+	err := r.EachMessage(func(_ int, info MessageInfo) bool {
+		if len(info.Anomalies) > 0 {
+			// Skipped sections make the scanned layout differ from the assembled fields
+			message, err := r.buildMessageFromInfo(info)
+			if err != nil {
+				readErr = err
+				return false
+			}
+			for _, msg := range message.FlattenToFlatMessages() {
+				if filter.Match(&msg) && !fn(msg.Index, msg) {
+					return false
+				}
+			}
+			return true
+		}
+
+		fields := newFieldReader(r, info)
+		for _, ranges := range fieldSectionRanges(info.Sections) {
+			msg, err := fields.header(ranges)
+			if err != nil {
+				readErr = err
+				return false
+			}
+			if !filter.Match(&msg) {
+				continue
+			}
+
+			if err := fields.data(&msg); err != nil {
+				readErr = err
+				return false
+			}
+			if !fn(msg.Index, msg) {
+				return false
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	return r.opts.sourced(readErr)
+}
+
+// fieldReader reads the sections of the fields of one message, reading the sections
+// shared by several fields once
+type fieldReader struct {
+	r        *ReaderAt
+	info     MessageInfo
+	sections map[int64]section.Section
+}
+
+// newFieldReader starts reading the fields of the message described by info
+func newFieldReader(r *ReaderAt, info MessageInfo) *fieldReader {
+	return &fieldReader{r: r, info: info, sections: make(map[int64]section.Section)}
+}
+
+// header reads Sections 0-4 of the field with the given section ranges
+func (fr *fieldReader) header(ranges map[uint8]ByteRange) (FlatMessage, error) {
+	msg := FlatMessage{
+		Index:         fieldIndex(fr.info, ranges[4].Offset),
+		Offset:        fr.info.Offset,
+		Length:        fr.info.Length,
+		Discipline:    int(fr.info.Discipline),
+		Edition:       int(fr.info.Edition),
+		Source:        fr.info.Source,
+		LonConvention: fr.info.LonConvention,
+		sectionRanges: ranges,
+		metrics:       fr.info.metrics,
+	}
+
+	var err error
+	if msg.Indicator, err = readFieldSection[section.Section0](fr, ranges, 0); err != nil {
+		return FlatMessage{}, err
+	}
+	if msg.Identification, err = readFieldSection[section.Section1](fr, ranges, 1); err != nil {
+		return FlatMessage{}, err
+	}
+	if msg.LocalUse, err = readFieldSection[section.Section2](fr, ranges, 2); err != nil {
+		return FlatMessage{}, err
+	}
+	if msg.GridDef, err = readFieldSection[section.Section3](fr, ranges, 3); err != nil {
+		return FlatMessage{}, err
+	}
+	if msg.ProductDef, err = readFieldSection[section.Section4](fr, ranges, 4); err != nil {
+		return FlatMessage{}, err
+	}
+
+	start := startTimer(msg.metrics)
+	msg.extractProductInfo()
+	msg.extractGridInfo()
+	if msg.metrics != nil {
+		msg.metrics.TemplatesExtracted(time.Since(start))
+	}
+	return msg, nil
+}
+
+// data reads Sections 5-8 of a field whose header was read by header
+func (fr *fieldReader) data(msg *FlatMessage) error {
+	var err error
+	if msg.DataRepSec, err = readFieldSection[section.Section5](fr, msg.sectionRanges, 5); err != nil {
+		return err
+	}
+	if msg.Bitmap, err = readFieldSection[section.Section6](fr, msg.sectionRanges, 6); err != nil {
+		return err
+	}
+	if msg.Bitmap != nil && msg.Bitmap.BitMapIndicator() == 254 {
+		if msg.PreviousBitmap, err = fr.previousBitmap(msg.sectionRanges[6].Offset); err != nil {
+			return err
+		}
+	}
+	if msg.Data, err = readFieldSection[section.Section7](fr, msg.sectionRanges, 7); err != nil {
+		return err
+	}
+	if msg.End, err = readFieldSection[section.Section8](fr, msg.sectionRanges, 8); err != nil {
+		return err
+	}
+
+	msg.extractDataRepInfo()
+	return nil
+}
+
+// previousBitmap returns the last Section 6 before offset that defines a bit-map, which a
+// Section 6 with bit-map indicator 254 at offset reuses, or nil when there is none
+func (fr *fieldReader) previousBitmap(offset int64) (section.Section6, error) {
+	for i := len(fr.info.Sections) - 1; i >= 0; i-- {
+		info := fr.info.Sections[i]
+		if info.Number != 6 || info.Offset >= offset {
+			continue
+		}
+		sec, err := readFieldSection[section.Section6](fr, map[uint8]ByteRange{6: {Offset: info.Offset, Length: int64(info.Length)}}, 6)
+		if err != nil {
+			return nil, err
+		}
+		if sec.BitMapIndicator() == 0 {
+			return sec, nil
+		}
+	}
+	return nil, nil
+}
+
+// readFieldSection reads the section with the given number from the field's ranges.
+// The zero value is returned when the field has no such section.
+func readFieldSection[T section.Section](fr *fieldReader, ranges map[uint8]ByteRange, number uint8) (T, error) {
+	var zero T
+	r, ok := ranges[number]
+	if !ok {
+		return zero, nil
+	}
+
+	sec, ok := fr.sections[r.Offset]
+	if !ok {
+		var err error
+		sec, err = fr.r.readScannedSection(fr.info.Offset, SectionInfo{Number: number, Offset: r.Offset, Length: uint32(r.Length)})
+		if err != nil {
+			return zero, fmt.Errorf("failed to read section %d at offset %d: %w", number, r.Offset, err)
+		}
+		fr.sections[r.Offset] = sec
+	}
+
+	typed, ok := sec.(T)
+	if !ok {
+		return zero, fmt.Errorf("section at offset %d is not Section %d", r.Offset, number)
+	}
+	return typed, nil
+}
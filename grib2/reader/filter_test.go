@@ -0,0 +1,213 @@
+package reader_test
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/scorix/grib/grib2/internal/testgrib"
+	"github.com/scorix/grib/grib2/reader"
+	"github.com/scorix/grib/grib2/section"
+	"github.com/scorix/grib/grib2/template"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rangeRecorder records the byte ranges read from the underlying reader
+type rangeRecorder struct {
+	io.ReaderAt
+	mu     sync.Mutex
+	ranges []reader.ByteRange
+}
+
+func (r *rangeRecorder) ReadAt(p []byte, off int64) (int, error) {
+	n, err := r.ReaderAt.ReadAt(p, off)
+	r.mu.Lock()
+	r.ranges = append(r.ranges, reader.ByteRange{Offset: off, Length: int64(n)})
+	r.mu.Unlock()
+	return n, err
+}
+
+// overlaps reports whether any recorded read overlaps target
+func (r *rangeRecorder) overlaps(target reader.ByteRange) bool {
+	for _, read := range r.ranges {
+		if read.Offset < target.End() && target.Offset < read.End() {
+			return true
+		}
+	}
+	return false
+}
+
+func TestEachFilteredMessage(t *testing.T) {
+	specs := []testgrib.Spec{
+		// Instantaneous APCP at 6 hours
+		{Category: 1, Parameter: 8, ForecastHours: 6},
+		// 0-6 hour accumulation: the only match
+		{ProductTemplate: 8, Category: 1, Parameter: 8, RangeHours: 6, StatisticalProcess: 1},
+		// 0-6 hour average
+		{ProductTemplate: 8, Category: 1, Parameter: 8, RangeHours: 6, StatisticalProcess: 0},
+		// 6-12 hour accumulation
+		{ProductTemplate: 8, Category: 1, Parameter: 8, ForecastHours: 6, RangeHours: 6, StatisticalProcess: 1},
+		// 0-6 hour accumulation of precipitation rate
+		{ProductTemplate: 8, Category: 1, Parameter: 7, RangeHours: 6, StatisticalProcess: 1},
+	}
+	var data []byte
+	for _, spec := range specs {
+		data = append(data, testgrib.MustEncode(spec)...)
+	}
+
+	filter := reader.Filter{
+		Discipline:            reader.Ptr(uint8(0)),
+		Category:              reader.Ptr(uint8(1)),
+		Number:                reader.Ptr(uint8(8)),
+		ProductTemplateNumber: reader.Ptr(uint16(8)),
+		StatisticalProcess:    reader.Ptr(template.StatisticalAccumulation),
+		StepRange:             &reader.Step{Start: 0, End: 6 * time.Hour},
+	}
+
+	source := &rangeRecorder{ReaderAt: bytes.NewReader(data)}
+	var matched []reader.FlatMessage
+	require.NoError(t, reader.NewReaderAt(source).EachFilteredMessage(filter, func(index int, msg reader.FlatMessage) bool {
+		assert.Equal(t, index, msg.Index)
+		matched = append(matched, msg)
+		return true
+	}))
+
+	require.Len(t, matched, 1)
+	assert.Equal(t, 1, matched[0].Index)
+	assert.Equal(t, "0-6 hour acc fcst", matched[0].StepString())
+	values, err := matched[0].DecodeData()
+	require.NoError(t, err)
+	assert.Equal(t, float64(15), values[15])
+
+	// Past the headers read while scanning, Sections 5-7 of the other fields were never read
+	all := flatMessages(t, data)
+	require.Len(t, all, len(specs))
+	for i, msg := range all {
+		if i == 1 {
+			continue
+		}
+		for _, number := range []uint8{5, 6, 7} {
+			r := msg.SectionRanges()[number]
+			body := reader.ByteRange{Offset: r.Offset + 5, Length: r.Length - 5}
+			assert.False(t, source.overlaps(body), "field %d Section %d", i, number)
+		}
+	}
+}
+
+func TestFilterMatch(t *testing.T) {
+	analysis := flatMessages(t, testgrib.MustEncode(testgrib.Spec{Category: 1, Parameter: 8}))[0]
+	accumulation := flatMessages(t, testgrib.MustEncode(testgrib.Spec{
+		ProductTemplate: 8, Category: 1, Parameter: 8, ForecastHours: 6, RangeHours: 6, StatisticalProcess: 1,
+	}))[0]
+
+	tests := []struct {
+		name   string
+		filter reader.Filter
+		want   []bool // Matches of analysis and accumulation
+	}{
+		{name: "empty", filter: reader.Filter{}, want: []bool{true, true}},
+		{name: "parameter", filter: reader.Filter{Category: reader.Ptr(uint8(1)), Number: reader.Ptr(uint8(8))}, want: []bool{true, true}},
+		{name: "other discipline", filter: reader.Filter{Discipline: reader.Ptr(uint8(10))}, want: []bool{false, false}},
+		{name: "instantaneous template", filter: reader.Filter{ProductTemplateNumber: reader.Ptr(uint16(0))}, want: []bool{true, false}},
+		{name: "accumulation", filter: reader.Filter{StatisticalProcess: reader.Ptr(template.StatisticalAccumulation)}, want: []bool{false, true}},
+		{name: "average", filter: reader.Filter{StatisticalProcess: reader.Ptr(template.StatisticalAverage)}, want: []bool{false, false}},
+		{name: "analysis step", filter: reader.Filter{StepRange: &reader.Step{}}, want: []bool{true, false}},
+		{name: "6-12 hour step", filter: reader.Filter{StepRange: &reader.Step{Start: 6 * time.Hour, End: 12 * time.Hour}}, want: []bool{false, true}},
+		{name: "operational", filter: reader.Filter{ProductionStatus: reader.Ptr(section.StatusOperational)}, want: []bool{true, true}},
+		{name: "start of forecast", filter: reader.Filter{Significance: reader.Ptr(section.SignificanceStartOfForecast)}, want: []bool{true, true}},
+		{name: "analysis products", filter: reader.Filter{DataType: reader.Ptr(section.DataTypeAnalysis)}, want: []bool{false, false}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, []bool{tt.filter.Match(&analysis), tt.filter.Match(&accumulation)})
+		})
+	}
+}
+
+func TestFilterLevel(t *testing.T) {
+	surfaces := []struct {
+		name  string
+		spec  testgrib.Spec
+		level string
+	}{
+		{name: "500 hPa in Pa", spec: testgrib.Spec{SurfaceType: 100, SurfaceValue: 50000}, level: "500 mb"},
+		{name: "500 hPa scaled", spec: testgrib.Spec{SurfaceType: 100, SurfaceScale: -2, SurfaceValue: 500}, level: "500 mb"},
+		{name: "5 hPa", spec: testgrib.Spec{SurfaceType: 100, SurfaceScale: 2, SurfaceValue: 50000}, level: "5 mb"},
+		{name: "1000 hPa", spec: testgrib.Spec{SurfaceType: 100, SurfaceValue: 100000}, level: "1000 mb"},
+		{name: "250 hPa", spec: testgrib.Spec{SurfaceType: 100, SurfaceScale: -1, SurfaceValue: 2500}, level: "250 mb"},
+		{name: "500 m above ground", spec: testgrib.Spec{SurfaceType: 103, SurfaceValue: 500}, level: "500 m above ground"},
+	}
+
+	var fields []reader.FlatMessage
+	for _, surface := range surfaces {
+		field := flatMessages(t, testgrib.MustEncode(surface.spec))[0]
+		require.Equal(t, surface.level, field.LevelString(), surface.name)
+		fields = append(fields, field)
+	}
+
+	tests := []struct {
+		name   string
+		filter reader.Filter
+		want   []bool // Matches of each surface
+	}{
+		{
+			name:   "500 hPa",
+			filter: reader.Filter{LevelValue: &reader.Level{Type: 100, Value: 500, Unit: "hPa"}},
+			want:   []bool{true, true, false, false, false, false},
+		},
+		{
+			name:   "default pressure unit",
+			filter: reader.Filter{LevelValue: &reader.Level{Type: 100, Value: 500}},
+			want:   []bool{true, true, false, false, false, false},
+		},
+		{
+			name:   "50000 Pa",
+			filter: reader.Filter{LevelValue: &reader.Level{Type: 100, Value: 50000, Unit: "Pa"}},
+			want:   []bool{true, true, false, false, false, false},
+		},
+		{
+			name:   "50 kPa",
+			filter: reader.Filter{LevelValue: &reader.Level{Type: 100, Value: 50, Unit: "kPa"}},
+			want:   []bool{true, true, false, false, false, false},
+		},
+		{
+			name:   "height in table unit",
+			filter: reader.Filter{LevelValue: &reader.Level{Type: 103, Value: 500}},
+			want:   []bool{false, false, false, false, false, true},
+		},
+		{
+			name:   "pressure unit on a height",
+			filter: reader.Filter{LevelValue: &reader.Level{Type: 103, Value: 5, Unit: "hPa"}},
+			want:   []bool{false, false, false, false, false, false},
+		},
+		{
+			name:   "unknown unit",
+			filter: reader.Filter{LevelValue: &reader.Level{Type: 100, Value: 500, Unit: "psi"}},
+			want:   []bool{false, false, false, false, false, false},
+		},
+		{
+			name:   "isobaric levels between 1000 and 300 hPa",
+			filter: reader.Filter{LevelRange: &reader.LevelRange{Type: 100, From: 1000, To: 300}},
+			want:   []bool{true, true, false, true, false, false},
+		},
+		{
+			name:   "isobaric levels up to 250 hPa",
+			filter: reader.Filter{LevelRange: &reader.LevelRange{Type: 100, From: 250, To: 500, Unit: "mb"}},
+			want:   []bool{true, true, false, false, true, false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []bool
+			for i := range fields {
+				got = append(got, tt.filter.Match(&fields[i]))
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
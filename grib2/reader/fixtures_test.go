@@ -0,0 +1,42 @@
+package reader_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/scorix/grib/grib2/internal/testgrib"
+	"github.com/scorix/grib/grib2/reader"
+	"github.com/stretchr/testify/require"
+)
+
+// flatMessages returns all flattened messages of the given GRIB2 data
+func flatMessages(t testing.TB, data []byte) []reader.FlatMessage {
+	var messages []reader.FlatMessage
+	err := reader.NewReaderAt(bytes.NewReader(data)).EachFlatMessage(func(_ int, msg reader.FlatMessage) bool {
+		messages = append(messages, msg)
+		return true
+	})
+	require.NoError(t, err)
+	return messages
+}
+
+// sectionOffset returns the offset in msg of its first section with the given number,
+// following the section lengths from the end of Section 0
+func sectionOffset(t testing.TB, msg []byte, number uint8) int {
+	for offset := 16; offset+5 <= len(msg); offset += int(binary.BigEndian.Uint32(msg[offset:])) {
+		if msg[offset+4] == number {
+			return offset
+		}
+	}
+	require.FailNow(t, "no such section", "Section %d", number)
+	return 0
+}
+
+// unsupportedPackingMessage builds a single field message on a 2x2 grid whose values are
+// packed with a template that has no decoder, 5.51 (spectral data - complex packing)
+func unsupportedPackingMessage(t testing.TB) []byte {
+	msg := testgrib.MustEncode(testgrib.Spec{Ni: 2, Nj: 2, BitsPerValue: 8})
+	binary.BigEndian.PutUint16(msg[sectionOffset(t, msg, 5)+9:], 51)
+	return msg
+}
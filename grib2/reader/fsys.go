@@ -0,0 +1,142 @@
+package reader
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+)
+
+// DefaultExtensions are the file name extensions WalkFS looks for when given none
+var DefaultExtensions = []string{".grib2", ".grb2", ".grib", ".grb"}
+
+// fsSpillThreshold is the size above which OpenFS copies a file without random access to a
+// temporary file rather than to memory
+var fsSpillThreshold int64 = 64 << 20
+
+// OpenFS opens the GRIB2 file name of fsys, e.g. an embed.FS or os.DirFS. Files that
+// support random access through io.ReaderAt, as those of os.DirFS and embed.FS do, are read
+// in place; others are read into memory, or into a temporary file removed by Close when
+// larger than 64 MiB. As with Open, an inventory next to the file in fsys drives the
+// enumeration of its messages, and messages and errors are labelled with name unless
+// another WithSource is given.
+func OpenFS(fsys fs.FS, name string, opts ...Option) (*File, error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if info.IsDir() {
+		file.Close()
+		return nil, &fs.PathError{Op: "open", Path: name, Err: errors.New("is a directory")}
+	}
+
+	r, closer, size := io.ReaderAt(nil), io.Closer(file), info.Size()
+	if ra, ok := file.(io.ReaderAt); ok {
+		r = ra
+	} else {
+		r, closer, size, err = copyFile(file)
+		file.Close()
+		if err != nil {
+			return nil, &fs.PathError{Op: "read", Path: name, Err: err}
+		}
+	}
+
+	f := newFile(r, closer, name, opts)
+	f.useIndex(r, size, name, func(name string) ([]IndexEntry, error) {
+		return readIndexFS(fsys, name)
+	})
+	return f, nil
+}
+
+// copyFile copies the content of file into memory, or into a temporary file past
+// fsSpillThreshold, for random access
+func copyFile(file io.Reader) (io.ReaderAt, io.Closer, int64, error) {
+	data, err := io.ReadAll(io.LimitReader(file, fsSpillThreshold+1))
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	if int64(len(data)) <= fsSpillThreshold {
+		return bytes.NewReader(data), closerFunc(func() error { return nil }), int64(len(data)), nil
+	}
+
+	spill, err := os.CreateTemp("", "grib-*")
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	remove := closerFunc(func() error {
+		return errors.Join(spill.Close(), os.Remove(spill.Name()))
+	})
+
+	n, err := io.Copy(spill, io.MultiReader(bytes.NewReader(data), file))
+	if err != nil {
+		remove.Close()
+		return nil, nil, 0, err
+	}
+	return spill, remove, n, nil
+}
+
+// closerFunc adapts a function to io.Closer
+type closerFunc func() error
+
+// Close calls the function
+func (f closerFunc) Close() error {
+	return f()
+}
+
+// readIndexFS parses the inventory name of fsys
+func readIndexFS(fsys fs.FS, name string) ([]IndexEntry, error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ParseIndex(file)
+}
+
+// WalkFS opens in turn the regular files of fsys whose names end in one of exts, ignoring
+// case, or in one of DefaultExtensions without exts, and calls fn with each in lexical
+// order before closing it. A file that cannot be opened is passed to fn with a nil File
+// and the error. Walking stops at the first error fn returns, which WalkFS returns, unless
+// it is fs.SkipAll.
+func WalkFS(fsys fs.FS, exts []string, fn func(name string, f *File, err error) error, opts ...Option) error {
+	if len(exts) == 0 {
+		exts = DefaultExtensions
+	}
+
+	return fs.WalkDir(fsys, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.Type().IsRegular() || !hasExtension(name, exts) {
+			return nil
+		}
+
+		f, err := OpenFS(fsys, name, opts...)
+		if err != nil {
+			return fn(name, nil, err)
+		}
+		defer f.Close()
+		return fn(name, f, nil)
+	})
+}
+
+// hasExtension reports whether the extension of name is one of exts, ignoring case
+func hasExtension(name string, exts []string) bool {
+	ext := path.Ext(name)
+	for _, candidate := range exts {
+		if strings.EqualFold(ext, candidate) {
+			return true
+		}
+	}
+	return false
+}
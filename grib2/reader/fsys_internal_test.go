@@ -0,0 +1,35 @@
+package reader
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCopyFile_Spill(t *testing.T) {
+	data := []byte("GRIB and more")
+	defer func(threshold int64) { fsSpillThreshold = threshold }(fsSpillThreshold)
+
+	for _, threshold := range []int64{int64(len(data)), 4} {
+		fsSpillThreshold = threshold
+		r, closer, size, err := copyFile(bytes.NewReader(data))
+		require.NoError(t, err)
+		assert.Equal(t, int64(len(data)), size)
+
+		got := make([]byte, size)
+		_, err = r.ReadAt(got, 0)
+		require.NoError(t, err)
+		assert.Equal(t, data, got)
+
+		spill, spilled := r.(*os.File)
+		assert.Equal(t, threshold < int64(len(data)), spilled)
+		require.NoError(t, closer.Close())
+		if spilled {
+			_, err := os.Stat(spill.Name())
+			assert.ErrorIs(t, err, os.ErrNotExist, "the temporary file is removed on close")
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package reader_test
+
+import (
+	"embed"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/scorix/grib/grib2/internal/testgrib"
+	"github.com/scorix/grib/grib2/reader"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+//go:embed testdata/fs
+var embeddedFS embed.FS
+
+// embeddedFixtures are the files under testdata/fs, regenerated with -update
+var embeddedFixtures = map[string][]byte{
+	"analysis.grib2":     testgrib.MustEncode(testgrib.Spec{}),
+	"forecast/f006.GRB2": testgrib.MustEncode(testgrib.Spec{ForecastHours: 6}),
+}
+
+func TestEmbeddedFixtures(t *testing.T) {
+	for name, data := range embeddedFixtures {
+		path := filepath.Join("testdata/fs", name)
+		if *update {
+			require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+			require.NoError(t, os.WriteFile(path, data, 0o644))
+		}
+		got, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, data, got, "%s is stale, run the tests with -update", path)
+	}
+}
+
+// noReaderAtFS hides the io.ReaderAt implementation of the files of an fs.FS
+type noReaderAtFS struct {
+	fs.FS
+}
+
+func (f noReaderAtFS) Open(name string) (fs.File, error) {
+	file, err := f.FS.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return struct{ fs.File }{file}, nil
+}
+
+func TestOpenFS(t *testing.T) {
+	want := fileMessages(t, func() *reader.File {
+		f, err := reader.Open("testdata/gfs.t00z.pgrb2.0p25.f000", reader.WithSource("gfs"))
+		require.NoError(t, err)
+		t.Cleanup(func() { f.Close() })
+		return f
+	}())
+
+	dirFS := os.DirFS("testdata")
+	for name, fsys := range map[string]fs.FS{"dir": dirFS, "without ReaderAt": noReaderAtFS{dirFS}} {
+		t.Run(name, func(t *testing.T) {
+			f, err := reader.OpenFS(fsys, "gfs.t00z.pgrb2.0p25.f000", reader.WithSource("gfs"))
+			require.NoError(t, err)
+			defer f.Close()
+
+			assert.Equal(t, reader.ModeScan, f.Mode())
+			assert.Equal(t, want, fileMessages(t, f))
+		})
+	}
+
+	t.Run("embedded", func(t *testing.T) {
+		f, err := reader.OpenFS(embeddedFS, "testdata/fs/analysis.grib2")
+		require.NoError(t, err)
+		defer f.Close()
+
+		msgs := flatMessagesOf(t, f.ReaderAt)
+		require.Len(t, msgs, 1)
+		assert.Equal(t, "testdata/fs/analysis.grib2", msgs[0].Source)
+		assert.Equal(t, "anl", msgs[0].StepString())
+	})
+
+	t.Run("index", func(t *testing.T) {
+		gfs, err := os.ReadFile("testdata/gfs.t00z.pgrb2.0p25.f000")
+		require.NoError(t, err)
+		fsys := fstest.MapFS{
+			"gfs.grib2":     {Data: gfs},
+			"gfs.grib2.idx": {Data: []byte(gfsIndex)},
+		}
+
+		f, err := reader.OpenFS(fsys, "gfs.grib2", reader.WithSource("gfs"))
+		require.NoError(t, err)
+		defer f.Close()
+
+		assert.Equal(t, reader.ModeIndex, f.Mode())
+		assert.Equal(t, "gfs.grib2.idx", f.IndexPath())
+		assert.Len(t, fileMessages(t, f), 3)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := reader.OpenFS(dirFS, "missing.grib2")
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+
+		_, err = reader.OpenFS(embeddedFS, "testdata/fs")
+		assert.Error(t, err)
+	})
+}
+
+// flatMessagesOf collects the fields of a ReaderAt
+func flatMessagesOf(t *testing.T, r *reader.ReaderAt) []reader.FlatMessage {
+	var msgs []reader.FlatMessage
+	require.NoError(t, r.EachFlatMessage(func(_ int, msg reader.FlatMessage) bool {
+		msgs = append(msgs, msg)
+		return true
+	}))
+	return msgs
+}
+
+func TestWalkFS(t *testing.T) {
+	walk := func(t *testing.T, fsys fs.FS, exts []string) map[string]int {
+		fields := make(map[string]int)
+		require.NoError(t, reader.WalkFS(fsys, exts, func(name string, f *reader.File, err error) error {
+			require.NoError(t, err)
+			fields[name] = len(flatMessagesOf(t, f.ReaderAt))
+			return nil
+		}))
+		return fields
+	}
+
+	assert.Equal(t, map[string]int{
+		"testdata/fs/analysis.grib2":     1,
+		"testdata/fs/forecast/f006.GRB2": 1,
+	}, walk(t, embeddedFS, nil))
+	assert.Equal(t, map[string]int{"gfs.t00z.pgrb2.0p25.f000": 3}, walk(t, os.DirFS("testdata"), []string{".f000"}))
+
+	t.Run("stop", func(t *testing.T) {
+		var names []string
+		require.NoError(t, reader.WalkFS(embeddedFS, nil, func(name string, _ *reader.File, _ error) error {
+			names = append(names, name)
+			return fs.SkipAll
+		}))
+		assert.Equal(t, []string{"testdata/fs/analysis.grib2"}, names)
+
+		stop := errors.New("stop")
+		assert.ErrorIs(t, reader.WalkFS(embeddedFS, nil, func(string, *reader.File, error) error { return stop }), stop)
+	})
+
+	t.Run("open error", func(t *testing.T) {
+		fsys := failingFS{FS: fstest.MapFS{"bad.grib2": {}, "good.grib2": {Data: embeddedFixtures["analysis.grib2"]}}, name: "bad.grib2"}
+		opened := make(map[string]error)
+		require.NoError(t, reader.WalkFS(fsys, nil, func(name string, f *reader.File, err error) error {
+			assert.Equal(t, err != nil, f == nil)
+			opened[name] = err
+			return nil
+		}))
+		require.Len(t, opened, 2)
+		assert.ErrorIs(t, opened["bad.grib2"], fs.ErrPermission)
+		assert.NoError(t, opened["good.grib2"])
+	})
+}
+
+// failingFS fails to open the file name
+type failingFS struct {
+	fs.FS
+	name string
+}
+
+func (f failingFS) Open(name string) (fs.File, error) {
+	if name == f.name {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrPermission}
+	}
+	return f.FS.Open(name)
+}
@@ -0,0 +1,169 @@
+package reader
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/scorix/grib/grib2/grid"
+)
+
+// GridDefinition returns the geometry of the message's grid, for locating grid points
+// and computing cell areas
+func (f *FlatMessage) GridDefinition() (grid.Definition, error) {
+	return grid.FromTemplate(&f.Grid)
+}
+
+// LatLonAt returns the latitude and longitude in degrees of the data value at index, with
+// the longitude in the LonConvention of the field
+func (f *FlatMessage) LatLonAt(index int) (lat, lon float64, err error) {
+	def, err := f.GridDefinition()
+	if err != nil {
+		return 0, 0, err
+	}
+	if n := grid.NumberOfPoints(def); index < 0 || index >= n {
+		return 0, 0, fmt.Errorf("grid: index %d out of range [0, %d)", index, n)
+	}
+
+	lat, lon = f.LonConvention.LatLonAt(def, index)
+	return lat, lon, nil
+}
+
+// Coordinates returns the latitudes and longitudes in degrees of all the data values, in
+// the order of the values DecodeData returns for a field without a bit-map, with the
+// longitudes in the LonConvention of the field
+func (f *FlatMessage) Coordinates() (lats, lons []float64, err error) {
+	def, err := f.GridDefinition()
+	if err != nil {
+		return nil, nil, err
+	}
+	lats, lons = f.LonConvention.Coordinates(def)
+	return lats, lons, nil
+}
+
+// ValueAt returns the value of the grid point nearest to a latitude and longitude in
+// degrees, in either LonConvention, and false when the location lies outside the grid.
+// Points masked out by the bit-map, and all the points of a field storing no values (see
+// ErrNoStoredValues), have the value NaN.
+//
+// The packed value of the point is located with the field's BitmapIndex, so that only the
+// first call on a bitmapped field scans the bit-map. For simple packing only the bytes
+// holding the value are read from Section 7; other fields are fully decoded.
+func (f *FlatMessage) ValueAt(lat, lon float64) (float64, bool, error) {
+	if ok, err := f.CanDecode(); !ok {
+		return 0, false, err
+	}
+	def, err := f.GridDefinition()
+	if err != nil {
+		return 0, false, fmt.Errorf("decode: %w", err)
+	}
+	index, ok := grid.LatLonToIndex(def, lat, lon)
+	if !ok {
+		return 0, false, nil
+	}
+
+	if f.noStoredValues() != nil {
+		return math.NaN(), true, nil
+	}
+
+	k := index
+	if bitmapIndex, ok := f.BitmapIndex(); ok {
+		if !bitmapIndex.Test(index) {
+			return math.NaN(), true, nil
+		}
+		k = bitmapIndex.Rank(index)
+	}
+
+	if f.simplePacking() {
+		values, err := f.decodeSimpleRange(k, 1)
+		if err != nil {
+			return 0, false, err
+		}
+		return values[0], true, nil
+	}
+
+	values, err := f.DecodeData()
+	if err != nil {
+		return 0, false, err
+	}
+	if k >= len(values) {
+		return 0, false, fmt.Errorf("decode: %d values for data point %d", len(values), k)
+	}
+	return values[k], true, nil
+}
+
+// Regrid decodes the values of the field with Values and interpolates them onto the dst
+// grid, see grid.Regrid. Points masked out by the bit-map take no part in the interpolation.
+// For a field storing no values, every point is NaN and the error is *ErrNoStoredValues.
+func (f *FlatMessage) Regrid(dst grid.Definition, method grid.RegridMethod, opts ...DecodeOption) ([]float64, error) {
+	def, err := f.GridDefinition()
+	if err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+	values, err := f.Values(opts...)
+	var noValues *ErrNoStoredValues
+	if err != nil && !errors.As(err, &noValues) {
+		return nil, err
+	}
+
+	regridded, regridErr := grid.Regrid(def, values, dst, method)
+	if regridErr != nil {
+		return nil, fmt.Errorf("decode: %w", regridErr)
+	}
+	return regridded, err
+}
+
+// PointsPerRow returns the number of points along each row of a reduced (quasi-regular)
+// grid, from the optional list of Section 3, and false when the grid is not reduced
+func (f *FlatMessage) PointsPerRow() ([]uint32, bool) {
+	if f.GridDef == nil {
+		return nil, false
+	}
+	switch f.GridDef.OptionalListInterpretation() {
+	case 1, 2: // Full circles, or lines within the extreme coordinates (Code Table 3.11)
+		list := f.GridDef.OptionalList()
+		return list, len(list) > 0
+	default:
+		return nil, false
+	}
+}
+
+// ReducedRows decodes the values of a reduced grid with Values and splits them into rows
+// of the lengths given by PointsPerRow, see grid.ReducedRows
+func (f *FlatMessage) ReducedRows(opts ...DecodeOption) ([][]float64, error) {
+	pointsPerRow, ok := f.PointsPerRow()
+	if !ok {
+		return nil, fmt.Errorf("decode: message %d does not have a reduced grid", f.Index)
+	}
+	values, err := f.Values(opts...)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := grid.ReducedRows(values, pointsPerRow)
+	if err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+	return rows, nil
+}
+
+// ExpandReducedGrid decodes the values of a reduced grid with Values and interpolates every
+// row to targetNi points, or to the points of the longest row when targetNi is zero, see
+// grid.ExpandReducedGrid. Only rows covering full circles of latitude can be expanded.
+func (f *FlatMessage) ExpandReducedGrid(targetNi int, opts ...DecodeOption) ([]float64, error) {
+	pointsPerRow, ok := f.PointsPerRow()
+	if !ok {
+		return nil, fmt.Errorf("decode: message %d does not have a reduced grid", f.Index)
+	}
+	if interpretation := f.GridDef.OptionalListInterpretation(); interpretation != 1 {
+		return nil, fmt.Errorf("decode: unsupported list of numbers of points %d for expanding rows", interpretation)
+	}
+	values, err := f.Values(opts...)
+	if err != nil {
+		return nil, err
+	}
+	expanded, err := grid.ExpandReducedGrid(values, pointsPerRow, targetNi)
+	if err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+	return expanded, nil
+}
@@ -0,0 +1,497 @@
+package reader_test
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"math"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/scorix/grib/grib2/grid"
+	"github.com/scorix/grib/grib2/internal/testgrib"
+	"github.com/scorix/grib/grib2/reader"
+	"github.com/scorix/grib/grib2/template"
+	"github.com/scorix/grib/grib2/units"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlatMessage_GridDefinition(t *testing.T) {
+	f, err := os.Open("testdata/gfs.t00z.pgrb2.0p25.f000")
+	require.NoError(t, err)
+	defer f.Close()
+
+	var msg reader.FlatMessage
+	err = reader.NewReaderAt(f).EachFlatMessage(func(_ int, m reader.FlatMessage) bool {
+		msg = m
+		return false
+	})
+	require.NoError(t, err)
+
+	def, err := msg.GridDefinition()
+	require.NoError(t, err)
+
+	ni, nj := def.Dims()
+	assert.Equal(t, 1440, ni)
+	assert.Equal(t, 721, nj)
+	assert.Equal(t, 6371229.0, def.Earth().Radius())
+
+	lat, lon := grid.LatLonAt(def, 1441)
+	assert.InDelta(t, 89.75, lat, 1e-9)
+	assert.InDelta(t, 0.25, lon, 1e-9)
+
+	lats, lons, err := msg.Coordinates()
+	require.NoError(t, err)
+	require.Len(t, lats, 1440*721)
+	require.Len(t, lons, 1440*721)
+	assert.InDelta(t, 90, lats[0], 1e-9)
+	assert.InDelta(t, 0, lons[0], 1e-9)
+	assert.InDelta(t, 89.75, lats[1441], 1e-9)
+	assert.InDelta(t, 0.25, lons[1441], 1e-9)
+	assert.InDelta(t, -90, lats[len(lats)-1], 1e-9)
+	assert.InDelta(t, 359.75, lons[len(lons)-1], 1e-9)
+
+	msg.LonConvention = grid.LonMinus180To180
+	_, lons, err = msg.Coordinates()
+	require.NoError(t, err)
+	assert.InDelta(t, -0.25, lons[len(lons)-1], 1e-9)
+
+	areas, err := grid.CellAreas(def)
+	require.NoError(t, err)
+	var total float64
+	for _, area := range areas {
+		total += area
+	}
+	assert.InEpsilon(t, 4*math.Pi*6371229*6371229, total, 1e-3)
+}
+
+func TestFlatMessage_LatLonAt_LonConvention(t *testing.T) {
+	f, err := os.Open("testdata/gfs.t00z.pgrb2.0p25.f000")
+	require.NoError(t, err)
+	defer f.Close()
+
+	// Last point of the first row, and the first point west of the antimeridian
+	for _, tt := range []struct {
+		convention grid.LonConvention
+		last, west float64
+	}{
+		{grid.Lon0To360, 359.75, 180.25},
+		{grid.LonMinus180To180, -0.25, -179.75},
+	} {
+		t.Run(tt.convention.String(), func(t *testing.T) {
+			var msg reader.FlatMessage
+			require.NoError(t, reader.NewReaderAt(f, reader.WithLonConvention(tt.convention)).EachFlatMessage(func(_ int, m reader.FlatMessage) bool {
+				msg = m
+				return false
+			}))
+			assert.Equal(t, tt.convention, msg.LonConvention)
+
+			_, lon, err := msg.LatLonAt(1439)
+			require.NoError(t, err)
+			assert.InDelta(t, tt.last, lon, 1e-9)
+			_, lon, err = msg.LatLonAt(721)
+			require.NoError(t, err)
+			assert.InDelta(t, tt.west, lon, 1e-9)
+
+			record := msg.Metadata("")
+			assert.InDelta(t, 0, *record.LonFirst, 1e-9)
+			assert.InDelta(t, tt.last, *record.LonLast, 1e-9)
+
+			_, _, err = msg.LatLonAt(1440 * 721)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestFlatMessage_GridSource(t *testing.T) {
+	for _, tt := range []struct {
+		name          string
+		source        uint8
+		centreDefined bool
+		absent        bool
+		err           string
+	}{
+		{"centre defined", 1, true, false, "predetermined by the originating centre"},
+		{"no grid", 255, false, true, "no grid definition applies"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			fields := flatMessages(t, testgrib.MustEncode(testgrib.Spec{GridSource: tt.source}))
+			require.Len(t, fields, 1)
+			msg := fields[0]
+
+			assert.Equal(t, int(tt.source), msg.Grid.SourceOfGridDefinition)
+			assert.False(t, msg.Grid.HasTemplate())
+			assert.Equal(t, tt.centreDefined, msg.Grid.CentreDefined())
+			assert.Equal(t, tt.absent, msg.Grid.Absent())
+
+			// The template bytes are not read as template 3.0
+			assert.Nil(t, msg.Grid.LatLon)
+			_, err := msg.GridDefinition()
+			assert.ErrorContains(t, err, tt.err)
+			_, _, err = msg.LatLonAt(0)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestFlatMessage_SignMagnitudeFields(t *testing.T) {
+	// Negative values are coded as a sign bit and a magnitude, so -10 degrees is
+	// 0x80989680 rather than the two's complement 0xff676980
+	fields := flatMessages(t, testgrib.MustEncode(testgrib.Spec{
+		Ni: 3, Nj: 3, LatFirst: -10, LonFirst: 20, Dx: 1, Dy: 1,
+		SurfaceType: 103, SurfaceScale: -1, SurfaceValue: 2,
+		DecimalScale: -1, Values: []float64{10, 20, 30, 40, 50, 60, 70, 80, 90},
+	}))
+	require.Len(t, fields, 1)
+	msg := fields[0]
+
+	require.NotNil(t, msg.Grid.LatLon)
+	assert.Equal(t, int32(-10000000), msg.Grid.LatLon.LatitudeOfFirstGridPoint)
+	assert.Equal(t, int32(-12000000), msg.Grid.LatLon.LatitudeOfLastGridPoint)
+	assert.Equal(t, int8(-1), msg.Product.ScaleFactorOfFirstFixedSurface)
+	assert.Equal(t, int16(-1), msg.DataRep.DecimalScaleFactor)
+
+	lat, lon, err := msg.LatLonAt(8)
+	require.NoError(t, err)
+	assert.InDelta(t, -12, lat, 1e-9)
+	assert.InDelta(t, 22, lon, 1e-9)
+
+	values, err := msg.DecodeData()
+	require.NoError(t, err)
+	assert.InDeltaSlice(t, []float64{10, 20, 30, 40, 50, 60, 70, 80, 90}, values, 1e-9)
+}
+
+// hexDump decodes a dump of octets in hexadecimal, ignoring white space and the lines
+// starting with #
+func hexDump(t *testing.T, dump string) []byte {
+	t.Helper()
+	var octets []string
+	for _, line := range strings.Split(dump, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "#") {
+			octets = append(octets, strings.Fields(line)...)
+		}
+	}
+	data, err := hex.DecodeString(strings.Join(octets, ""))
+	require.NoError(t, err)
+	return data
+}
+
+// gridMessage builds a message of a constant field on the grid of the given Section 3,
+// whose points number those of the grid
+func gridMessage(t *testing.T, sec3 []byte, points uint32) []byte {
+	t.Helper()
+	sections := testgrib.MustEncodeSections(testgrib.Spec{Ni: 1, Nj: 1, Values: []float64{280}})
+	sections[3] = sec3
+	binary.BigEndian.PutUint32(sections[5][5:], points)
+	return testgrib.Assemble(0, sections[1:]...)
+}
+
+func TestFlatMessage_RotatedLatLonGrid(t *testing.T) {
+	// A rotated grid laid out like DWD's COSMO-D2 domain: 651x716 points of 0.02 degree
+	// from (-6.3, 352.5) to (8, 5.5) in rotated coordinates, with the southern pole of the
+	// rotation at (-40, 10) and no further rotation
+	spec := testgrib.Spec{
+		GridTemplate: 1, Ni: 651, Nj: 716, LatFirst: -6.3, LonFirst: 352.5, Dx: 0.02, Dy: 0.02,
+		ScanningMode: 0x40, SouthPoleLat: -40, SouthPoleLon: 10, Values: make([]float64, 651*716),
+	}
+	fields := flatMessages(t, testgrib.MustEncode(spec))
+	require.Len(t, fields, 1)
+	msg := fields[0]
+
+	assert.Equal(t, 1, msg.Grid.TemplateNumber)
+	assert.Nil(t, msg.Grid.LatLon)
+	require.NotNil(t, msg.Grid.RotatedLatLon)
+	assert.Equal(t, template.RotatedLatLonGrid{
+		LatLonGrid: template.LatLonGrid{
+			ShapeOfEarth:               6,
+			NumberOfGridPointsAlongX:   651,
+			NumberOfGridPointsAlongY:   716,
+			SubdivisionOfBasicAngle:    0xffffffff,
+			LatitudeOfFirstGridPoint:   -6300000,
+			LongitudeOfFirstGridPoint:  352500000,
+			ResolutionAndComponentFlag: 0x30,
+			LatitudeOfLastGridPoint:    8000000,
+			LongitudeOfLastGridPoint:   5500000,
+			XDirectionIncrement:        20000,
+			YDirectionIncrement:        20000,
+			ScanningMode:               0x40,
+		},
+		LatitudeOfSouthernPole:  -40000000,
+		LongitudeOfSouthernPole: 10000000,
+	}, *msg.Grid.RotatedLatLon)
+
+	// A non-zero angle of rotation is an IEEE single precision number of degrees
+	spec.RotationAngle = -12.5
+	fields = flatMessages(t, testgrib.MustEncode(spec))
+	require.Len(t, fields, 1)
+	assert.Equal(t, int32(-12500000), fields[0].Grid.RotatedLatLon.AngleOfRotation)
+}
+
+func TestFlatMessage_Regrid(t *testing.T) {
+	// A 3x3 grid from 10N 20E scanning southwards, with the centre point masked out
+	msg := flatMessages(t, testgrib.MustEncode(testgrib.Spec{
+		Ni: 3, Nj: 3, LatFirst: 10, LonFirst: 20,
+		Values: []float64{1, 2, 3, 4, 5, 6, 7, 8, 9},
+		Bitmap: []bool{true, true, true, true, false, true, true, true, true},
+	}))[0]
+
+	dst := &grid.RegularLatLon{Ni: 3, Nj: 1, LatFirst: 9.5, LonFirst: 19.5, DLat: -1, DLon: 0.5, Shape: grid.Sphere(6371229)}
+	got, err := msg.Regrid(dst, grid.RegridBilinear)
+	require.NoError(t, err)
+	require.Len(t, got, 3)
+	assert.True(t, math.IsNaN(got[0]))
+	assert.InDelta(t, 2.5, got[1], 1e-9)
+	assert.InDelta(t, (1+2+4)/3.0, got[2], 1e-9)
+
+	got, err = msg.Regrid(dst, grid.RegridNearest, reader.WithMissingValue(-1))
+	require.NoError(t, err)
+	assert.Equal(t, []float64{4, 4, -1}, got)
+}
+
+func TestFlatMessage_LambertGrid(t *testing.T) {
+	// The HRRR 3 km CONUS grid, whose Section 3 is dumped in testdata
+	spec := testgrib.Spec{
+		GridTemplate: 30, Ni: 1799, Nj: 1059, LatFirst: 21.138123, LonFirst: 237.280472,
+		LaD: 38.5, LoV: 262.5, Dx: 3000, Dy: 3000, ScanningMode: 0x40, Latin1: 38.5, Latin2: 38.5,
+		Values: make([]float64, 1799*1059),
+	}
+	dump, err := os.ReadFile("testdata/hrrr.sec3.hex")
+	require.NoError(t, err)
+	assert.Equal(t, hexDump(t, string(dump)), testgrib.MustEncodeSections(spec)[3])
+
+	fields := flatMessages(t, testgrib.MustEncode(spec))
+	require.Len(t, fields, 1)
+	msg := fields[0]
+
+	assert.Equal(t, 30, msg.Grid.TemplateNumber)
+	require.NotNil(t, msg.Grid.Lambert)
+	assert.Equal(t, template.LambertGrid{
+		ShapeOfEarth:               6,
+		NumberOfGridPointsAlongX:   1799,
+		NumberOfGridPointsAlongY:   1059,
+		LatitudeOfFirstGridPoint:   21138123,
+		LongitudeOfFirstGridPoint:  237280472,
+		ResolutionAndComponentFlag: 0x08,
+		LatitudeWhereDxDySpecified: 38500000,
+		OrientationOfGrid:          262500000,
+		XDirectionIncrement:        3000000,
+		YDirectionIncrement:        3000000,
+		ScanningMode:               0x40,
+		LatitudeOfIntersection1:    38500000,
+		LatitudeOfIntersection2:    38500000,
+	}, *msg.Grid.Lambert)
+
+	// The corners of the HRRR grid
+	lat, lon, err := msg.LatLonAt(0)
+	require.NoError(t, err)
+	assert.InDelta(t, 21.138123, lat, 1e-5)
+	assert.InDelta(t, 237.280472, lon, 1e-5)
+	lat, lon, err = msg.LatLonAt(1799*1059 - 1)
+	require.NoError(t, err)
+	assert.InDelta(t, 47.842195, lat, 1e-5)
+	assert.InDelta(t, 299.082807, lon, 1e-5)
+
+	def, err := msg.GridDefinition()
+	require.NoError(t, err)
+	lambert, ok := def.(*grid.LambertConformal)
+	require.True(t, ok)
+	i, j := lambert.LatLonToIJ(47.842195, 299.082807)
+	assert.InDelta(t, 1798, i, 1e-3)
+	assert.InDelta(t, 1058, j, 1e-3)
+}
+
+func TestFlatMessage_PolarStereoGrid(t *testing.T) {
+	// NCEP grid 242 over Alaska: 553x425 points 11.25 km apart at 60N, north pole
+	fields := flatMessages(t, testgrib.MustEncode(testgrib.Spec{
+		GridTemplate: 20, Ni: 553, Nj: 425, LatFirst: 30, LonFirst: 187,
+		LaD: 60, LoV: 225, Dx: 11250, Dy: 11250, ScanningMode: 0x40,
+	}))
+	require.Len(t, fields, 1)
+	msg := fields[0]
+
+	assert.Equal(t, 20, msg.Grid.TemplateNumber)
+	require.NotNil(t, msg.Grid.PolarStereo)
+	assert.Equal(t, template.PolarStereoGrid{
+		ShapeOfEarth:               6,
+		NumberOfGridPointsAlongX:   553,
+		NumberOfGridPointsAlongY:   425,
+		LatitudeOfFirstGridPoint:   30_000_000,
+		LongitudeOfFirstGridPoint:  187_000_000,
+		ResolutionAndComponentFlag: 0x08,
+		LatitudeWhereDxDySpecified: 60_000_000,
+		OrientationOfGrid:          225_000_000,
+		XDirectionIncrement:        11_250_000,
+		YDirectionIncrement:        11_250_000,
+		ScanningMode:               0x40,
+	}, *msg.Grid.PolarStereo)
+
+	lat, lon, err := msg.LatLonAt(0)
+	require.NoError(t, err)
+	assert.InDelta(t, 30, lat, 1e-6)
+	assert.InDelta(t, 187, lon, 1e-6)
+
+	def, err := msg.GridDefinition()
+	require.NoError(t, err)
+	polar, ok := def.(*grid.PolarStereographic)
+	require.True(t, ok)
+	assert.InDelta(t, 1, polar.MapFactor(60), 1e-12, "true scale at LaD")
+	// The last grid point is the published corner of grid 242, 70.111N 62.850W
+	lat, lon, err = msg.LatLonAt(553*425 - 1)
+	require.NoError(t, err)
+	assert.InDelta(t, 70.111, lat, 1e-3)
+	assert.InDelta(t, 297.150, lon, 1e-3)
+	i, j := polar.LatLonToIJ(lat, lon)
+	assert.InDelta(t, 552, i, 1e-6)
+	assert.InDelta(t, 424, j, 1e-6)
+}
+
+func TestFlatMessage_RegisteredGridParser(t *testing.T) {
+	// A local template holding the number of points along x and y only
+	const local = 32769
+	template.RegisterGridParser(local, func(data []byte, grid *template.GridTemplate) error {
+		grid.LatLon = &template.LatLonGrid{
+			NumberOfGridPointsAlongX: binary.BigEndian.Uint32(data[0:4]),
+			NumberOfGridPointsAlongY: binary.BigEndian.Uint32(data[4:8]),
+		}
+		return nil
+	})
+
+	sec3 := []byte{0, 0, 0, 22, 3, 0, 0, 0, 0, 6, 0, 0, 0x80, 0x01, 0, 0, 0, 3, 0, 0, 0, 2}
+	fields := flatMessages(t, gridMessage(t, sec3, 6))
+	require.Len(t, fields, 1)
+	msg := fields[0]
+	assert.Equal(t, local, msg.Grid.TemplateNumber)
+	assert.Equal(t, 6, msg.Grid.NumberOfDataPoints)
+	require.NotNil(t, msg.Grid.LatLon)
+	assert.Equal(t, uint32(3), msg.Grid.LatLon.NumberOfGridPointsAlongX)
+	assert.Equal(t, uint32(2), msg.Grid.LatLon.NumberOfGridPointsAlongY)
+	assert.Contains(t, reader.SupportedGridTemplates(), reader.TemplateSupport{Number: local, Parse: true})
+}
+
+func TestFlatMessage_SpaceViewGrid(t *testing.T) {
+	// Grids seen with an apparent earth diameter of 3622 grid lengths, on an oblate spheroid
+	// earth with axes in metres
+	message := func(spec testgrib.Spec) reader.FlatMessage {
+		spec.GridTemplate, spec.MajorAxis, spec.MinorAxis = 90, 6378169, 6356584
+		spec.Dx, spec.Dy, spec.Orientation = 3622, 3622, -1.5
+		spec.Values = make([]float64, spec.Ni*spec.Nj)
+		fields := flatMessages(t, testgrib.MustEncode(spec))
+		require.Len(t, fields, 1)
+		return fields[0]
+	}
+
+	t.Run("sector", func(t *testing.T) {
+		msg := message(testgrib.Spec{
+			Ni: 3712, Nj: 1000, SubSatelliteLat: -2.5, SubSatelliteLon: 359.5,
+			Altitude: 6610700, SectorX: 1200, SectorY: 2700,
+		})
+		assert.Equal(t, 90, msg.Grid.TemplateNumber)
+		require.NotNil(t, msg.Grid.SpaceView)
+		assert.Equal(t, template.SpaceViewGrid{
+			ShapeOfEarth:                7,
+			ScaledValueMajorAxis:        6378169,
+			ScaledValueMinorAxis:        6356584,
+			NumberOfGridPointsAlongX:    3712,
+			NumberOfGridPointsAlongY:    1000,
+			LapValue:                    -2500000,
+			LopValue:                    359500000,
+			XDirectionIncrement:         3622,
+			YDirectionIncrement:         3622,
+			XCoordinateOfOrigin:         1856000,
+			YCoordinateOfOrigin:         500000,
+			OrientationOfGrid:           -1500000,
+			NrValue:                     6610700,
+			XCoordinateOfOriginOfSector: 1200,
+			YCoordinateOfOriginOfSector: 2700,
+		}, *msg.Grid.SpaceView)
+
+		assert.False(t, msg.Grid.SpaceView.Orthographic())
+		x, y, ok := msg.Grid.SpaceView.SectorOrigin()
+		assert.True(t, ok)
+		assert.Equal(t, uint32(1200), x)
+		assert.Equal(t, uint32(2700), y)
+	})
+
+	t.Run("missing fields", func(t *testing.T) {
+		msg := message(testgrib.Spec{Ni: 10, Nj: 10, Altitude: 0xffffffff, SectorX: 0xffffffff, SectorY: 0xffffffff})
+		require.NotNil(t, msg.Grid.SpaceView)
+		g := msg.Grid.SpaceView
+		assert.True(t, g.Orthographic())
+		_, _, ok := g.SectorOrigin()
+		assert.False(t, ok)
+		assert.Equal(t, int32(0), g.LapValue)
+	})
+}
+
+func TestFlatMessage_GaussianGrid(t *testing.T) {
+	// Global grids of 2 × 2 parallels holding a constant field
+	message := func(spec testgrib.Spec) reader.FlatMessage {
+		spec.GridTemplate, spec.Parallels = 40, 2
+		spec.Values = slices.Repeat([]float64{280}, 32)
+		if spec.PointsPerParallel != nil {
+			spec.Values = spec.Values[:24]
+		}
+		fields := flatMessages(t, testgrib.MustEncode(spec))
+		require.Len(t, fields, 1)
+		return fields[0]
+	}
+	lats, _ := grid.GaussianLatitudes(2)
+
+	t.Run("regular", func(t *testing.T) {
+		msg := message(testgrib.Spec{Ni: 8})
+		require.NotNil(t, msg.Grid.Gaussian)
+		g := msg.Grid.Gaussian
+		assert.False(t, g.Reduced())
+		assert.Equal(t, uint32(2), g.NumberOfParallels)
+		assert.Equal(t, uint32(8), g.NumberOfGridPointsAlongX)
+		assert.Equal(t, uint32(4), g.NumberOfGridPointsAlongY)
+		assert.Equal(t, uint32(45_000_000), g.XDirectionIncrement)
+		assert.True(t, template.IsMissing(g.YDirectionIncrement))
+		assert.Equal(t, units.DegreesToMicro(lats[3]), g.LatitudeOfLastGridPoint)
+		assert.Nil(t, g.PointsPerParallel)
+
+		lat, lon, err := msg.LatLonAt(8 + 3)
+		require.NoError(t, err)
+		assert.InDelta(t, lats[1], lat, 1e-9)
+		assert.InDelta(t, 135, lon, 1e-9)
+	})
+
+	t.Run("reduced", func(t *testing.T) {
+		for _, width := range []uint8{1, 2, 4} {
+			msg := message(testgrib.Spec{PointsPerParallel: []uint32{4, 8, 8, 4}, ListWidth: width})
+			require.NotNil(t, msg.Grid.Gaussian)
+			g := msg.Grid.Gaussian
+			assert.True(t, g.Reduced())
+			assert.Equal(t, uint32(2), g.NumberOfParallels)
+			assert.Equal(t, uint32(4), g.NumberOfGridPointsAlongY)
+			assert.Equal(t, []uint32{4, 8, 8, 4}, g.PointsPerParallel, "%d octets", width)
+			assert.Equal(t, 24, g.GridTemplate().NumberOfDataPoints)
+			assert.Equal(t, units.DegreesToMicro(lats[0]), g.LatitudeOfFirstGridPoint)
+
+			pointsPerRow, ok := msg.PointsPerRow()
+			assert.True(t, ok)
+			assert.Equal(t, []uint32{4, 8, 8, 4}, pointsPerRow)
+
+			rows, err := msg.ReducedRows()
+			require.NoError(t, err)
+			require.Len(t, rows, 4)
+			for k, row := range rows {
+				assert.Len(t, row, int(pointsPerRow[k]))
+			}
+
+			expanded, err := msg.ExpandReducedGrid(0)
+			require.NoError(t, err)
+			require.Len(t, expanded, 4*8)
+			assert.Equal(t, 280.0, expanded[31])
+		}
+
+		// A regular grid has no rows to expand
+		msg := message(testgrib.Spec{Ni: 8})
+		_, ok := msg.PointsPerRow()
+		assert.False(t, ok)
+		_, err := msg.ExpandReducedGrid(8)
+		assert.ErrorContains(t, err, "does not have a reduced grid")
+	})
+}
@@ -0,0 +1,375 @@
+package reader
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"reflect"
+	"slices"
+	"sync"
+)
+
+// Header blobs start with headerMagic and headerVersion, followed by the hash of the
+// layout of FlatMessage they were written for, the HeaderKey of the message, the extracted
+// fields and the section ranges
+const (
+	headerMagic   = "GRBH"
+	headerVersion = 1
+)
+
+// HeaderKey identifies the message a header was extracted from. A header whose key differs
+// from the message found at the same place in the file is stale.
+type HeaderKey struct {
+	Source string // Source label of the file, as given with WithSource
+	Offset int64  // Start offset of the message
+	Length uint64 // Total length of the message
+}
+
+// ErrStaleHeader reports a header blob extracted from another message than the one expected
+type ErrStaleHeader struct {
+	Want HeaderKey // Key of the message expected
+	Got  HeaderKey // Key recorded in the blob
+}
+
+// Error implements the error interface
+func (e *ErrStaleHeader) Error() string {
+	return fmt.Sprintf("header: stale header for %s at offset %d with length %d, expected %s at offset %d with length %d",
+		e.Got.Source, e.Got.Offset, e.Got.Length, e.Want.Source, e.Want.Offset, e.Want.Length)
+}
+
+// HeaderKey returns the key of the message the field belongs to
+func (f *FlatMessage) HeaderKey() HeaderKey {
+	return HeaderKey{Source: f.Source, Offset: f.Offset, Length: f.Length}
+}
+
+// MarshalHeader serialises the fields extracted from the field's sections, without its
+// data, into a compact binary blob that UnmarshalHeader turns back into a FlatMessage
+// without reading the file again. The raw sections are not kept.
+func (f *FlatMessage) MarshalHeader() ([]byte, error) {
+	hash, err := flatMessageLayout()
+	if err != nil {
+		return nil, err
+	}
+
+	b := append([]byte(headerMagic), headerVersion)
+	b = binary.BigEndian.AppendUint64(b, hash)
+
+	key := f.HeaderKey()
+	b = appendString(b, key.Source)
+	b = binary.AppendVarint(b, key.Offset)
+	b = binary.AppendUvarint(b, key.Length)
+
+	b = appendValue(b, reflect.ValueOf(f).Elem())
+
+	numbers := make([]uint8, 0, len(f.sectionRanges))
+	for number := range f.sectionRanges {
+		numbers = append(numbers, number)
+	}
+	slices.Sort(numbers)
+	b = binary.AppendUvarint(b, uint64(len(numbers)))
+	for _, number := range numbers {
+		r := f.sectionRanges[number]
+		b = append(b, number)
+		b = binary.AppendVarint(b, r.Offset)
+		b = binary.AppendVarint(b, r.Length)
+	}
+	return b, nil
+}
+
+// UnmarshalHeader rehydrates a field from a blob written by MarshalHeader. The blob must have
+// been extracted from the message identified by key, or *ErrStaleHeader is returned. The
+// field's raw sections are nil: it can be filtered and catalogued, and its section ranges
+// locate the sections to read for decoding.
+func UnmarshalHeader(blob []byte, key HeaderKey) (FlatMessage, error) {
+	got, d, err := readHeaderKey(blob)
+	if err != nil {
+		return FlatMessage{}, err
+	}
+	if got != key {
+		return FlatMessage{}, &ErrStaleHeader{Want: key, Got: got}
+	}
+
+	var f FlatMessage
+	d.value(reflect.ValueOf(&f).Elem())
+
+	n := d.uvarint()
+	if n > uint64(len(d.b)) {
+		d.fail()
+	}
+	if n > 0 && d.err == nil {
+		f.sectionRanges = make(map[uint8]ByteRange, n)
+		for range n {
+			number := d.byte()
+			f.sectionRanges[number] = ByteRange{Offset: d.varint(), Length: d.varint()}
+		}
+	}
+
+	if d.err == nil && len(d.b) > 0 {
+		d.err = fmt.Errorf("header: %d trailing bytes", len(d.b))
+	}
+	if d.err != nil {
+		return FlatMessage{}, d.err
+	}
+	return f, nil
+}
+
+// HeaderKeyOf returns the key recorded in a header blob, for looking up the message it
+// was extracted from
+func HeaderKeyOf(blob []byte) (HeaderKey, error) {
+	key, _, err := readHeaderKey(blob)
+	return key, err
+}
+
+// readHeaderKey checks the preamble of a header blob and reads its key
+func readHeaderKey(blob []byte) (HeaderKey, *headerDecoder, error) {
+	hash, err := flatMessageLayout()
+	if err != nil {
+		return HeaderKey{}, nil, err
+	}
+
+	preamble := len(headerMagic) + 1 + 8
+	if len(blob) < preamble || string(blob[:len(headerMagic)]) != headerMagic {
+		return HeaderKey{}, nil, errors.New("header: not a header blob")
+	}
+	if version := blob[len(headerMagic)]; version != headerVersion {
+		return HeaderKey{}, nil, fmt.Errorf("header: unsupported format version %d", version)
+	}
+	if binary.BigEndian.Uint64(blob[len(headerMagic)+1:]) != hash {
+		return HeaderKey{}, nil, errors.New("header: blob was written for another layout of FlatMessage")
+	}
+
+	d := &headerDecoder{b: blob[preamble:]}
+	key := HeaderKey{Source: d.string(), Offset: d.varint(), Length: d.uvarint()}
+	if d.err != nil {
+		return HeaderKey{}, nil, d.err
+	}
+	return key, d, nil
+}
+
+var (
+	layoutOnce sync.Once
+	layoutHash uint64
+	layoutErr  error
+)
+
+// flatMessageLayout returns a hash of the names and kinds of the fields serialised by
+// MarshalHeader, so that blobs written for another layout are rejected
+func flatMessageLayout() (uint64, error) {
+	layoutOnce.Do(func() {
+		h := fnv.New64a()
+		layoutErr = hashLayout(h.Write, reflect.TypeFor[FlatMessage]())
+		layoutHash = h.Sum64()
+	})
+	return layoutHash, layoutErr
+}
+
+// hashLayout writes the layout of t, following the traversal of appendValue
+func hashLayout(write func([]byte) (int, error), t reflect.Type) error {
+	write([]byte{byte(t.Kind())})
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice:
+		return hashLayout(write, t.Elem())
+	case reflect.Array:
+		write(binary.AppendUvarint(nil, uint64(t.Len())))
+		return hashLayout(write, t.Elem())
+	case reflect.Struct:
+		for i := range t.NumField() {
+			field := t.Field(i)
+			if !serialised(field) {
+				continue
+			}
+			write([]byte(field.Name))
+			if err := hashLayout(write, field.Type); err != nil {
+				return err
+			}
+		}
+	case reflect.Bool, reflect.String, reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+	default:
+		return fmt.Errorf("header: cannot serialise %s", t)
+	}
+	return nil
+}
+
+// serialised reports whether a struct field is part of header blobs: raw sections and
+// unexported fields are not
+func serialised(field reflect.StructField) bool {
+	return field.IsExported() && !field.Anonymous && field.Type.Kind() != reflect.Interface
+}
+
+// appendValue appends the binary encoding of v. Nil pointers and slices are told apart from
+// zero values, and floats keep their exact bits.
+func appendValue(b []byte, v reflect.Value) []byte {
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			return append(b, 1)
+		}
+		return append(b, 0)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return binary.AppendVarint(b, v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return binary.AppendUvarint(b, v.Uint())
+	case reflect.Float32:
+		return binary.BigEndian.AppendUint32(b, math.Float32bits(float32(v.Float())))
+	case reflect.Float64:
+		return binary.BigEndian.AppendUint64(b, math.Float64bits(v.Float()))
+	case reflect.String:
+		return appendString(b, v.String())
+	case reflect.Pointer:
+		if v.IsNil() {
+			return append(b, 0)
+		}
+		return appendValue(append(b, 1), v.Elem())
+	case reflect.Slice:
+		if v.IsNil() {
+			return append(b, 0)
+		}
+		b = binary.AppendUvarint(b, uint64(v.Len())+1)
+		for i := range v.Len() {
+			b = appendValue(b, v.Index(i))
+		}
+		return b
+	case reflect.Array:
+		for i := range v.Len() {
+			b = appendValue(b, v.Index(i))
+		}
+		return b
+	case reflect.Struct:
+		for i := range v.NumField() {
+			if serialised(v.Type().Field(i)) {
+				b = appendValue(b, v.Field(i))
+			}
+		}
+		return b
+	default:
+		// Unreachable: flatMessageLayout rejects other kinds
+		panic(fmt.Sprintf("header: cannot serialise %s", v.Type()))
+	}
+}
+
+// appendString appends a length-prefixed string
+func appendString(b []byte, s string) []byte {
+	return append(binary.AppendUvarint(b, uint64(len(s))), s...)
+}
+
+// headerDecoder reads the encoding of appendValue; the first error stops decoding
+type headerDecoder struct {
+	b   []byte
+	err error
+}
+
+func (d *headerDecoder) fail() {
+	if d.err == nil {
+		d.err = errors.New("header: truncated or corrupt blob")
+	}
+	d.b = nil
+}
+
+func (d *headerDecoder) take(n int) []byte {
+	if d.err != nil || n < 0 || n > len(d.b) {
+		d.fail()
+		return make([]byte, max(n, 0))
+	}
+	p := d.b[:n]
+	d.b = d.b[n:]
+	return p
+}
+
+func (d *headerDecoder) byte() byte {
+	return d.take(1)[0]
+}
+
+func (d *headerDecoder) varint() int64 {
+	v, n := binary.Varint(d.b)
+	if n <= 0 {
+		d.fail()
+		return 0
+	}
+	d.b = d.b[n:]
+	return v
+}
+
+func (d *headerDecoder) uvarint() uint64 {
+	v, n := binary.Uvarint(d.b)
+	if n <= 0 {
+		d.fail()
+		return 0
+	}
+	d.b = d.b[n:]
+	return v
+}
+
+func (d *headerDecoder) string() string {
+	n := d.uvarint()
+	if n > uint64(len(d.b)) {
+		d.fail()
+		return ""
+	}
+	return string(d.take(int(n)))
+}
+
+// value decodes into v, which must be settable
+func (d *headerDecoder) value(v reflect.Value) {
+	if d.err != nil {
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		v.SetBool(d.byte() != 0)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		x := d.varint()
+		if v.OverflowInt(x) {
+			d.fail()
+			return
+		}
+		v.SetInt(x)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		x := d.uvarint()
+		if v.OverflowUint(x) {
+			d.fail()
+			return
+		}
+		v.SetUint(x)
+	case reflect.Float32:
+		v.SetFloat(float64(math.Float32frombits(binary.BigEndian.Uint32(d.take(4)))))
+	case reflect.Float64:
+		v.SetFloat(math.Float64frombits(binary.BigEndian.Uint64(d.take(8))))
+	case reflect.String:
+		v.SetString(d.string())
+	case reflect.Pointer:
+		if d.byte() == 0 {
+			return
+		}
+		v.Set(reflect.New(v.Type().Elem()))
+		d.value(v.Elem())
+	case reflect.Slice:
+		n := d.uvarint()
+		if n == 0 {
+			return
+		}
+		// Every element takes at least one byte
+		if n-1 > uint64(len(d.b)) {
+			d.fail()
+			return
+		}
+		v.Set(reflect.MakeSlice(v.Type(), int(n-1), int(n-1)))
+		for i := range int(n - 1) {
+			d.value(v.Index(i))
+		}
+	case reflect.Array:
+		for i := range v.Len() {
+			d.value(v.Index(i))
+		}
+	case reflect.Struct:
+		for i := range v.NumField() {
+			if serialised(v.Type().Field(i)) {
+				d.value(v.Field(i))
+			}
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package reader_test
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/scorix/grib/grib2/internal/testgrib"
+	"github.com/scorix/grib/grib2/reader"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// withoutSections returns the field without its raw sections, which headers do not keep
+func withoutSections(f reader.FlatMessage) reader.FlatMessage {
+	f.Indicator, f.Identification, f.LocalUse, f.GridDef, f.ProductDef = nil, nil, nil, nil, nil
+	f.DataRepSec, f.Bitmap, f.Data, f.End = nil, nil, nil, nil
+	return f
+}
+
+func TestHeader_RoundTrip(t *testing.T) {
+	gfs, err := os.ReadFile("testdata/gfs.t00z.pgrb2.0p25.f000")
+	require.NoError(t, err)
+
+	var data []byte
+	for _, spec := range []testgrib.Spec{
+		{},
+		{ProductTemplate: 1, EnsembleType: 3, PerturbationNumber: 5, EnsembleSize: 30},
+		{ProductTemplate: 8, StatisticalProcess: 1, ForecastHours: 6, RangeHours: 6},
+		{ProductTemplate: 15, StatisticalProcess: 2, SpatialPoints: 25},
+		{Ni: 3, Nj: 3, DecimalScale: 1, Bitmap: []bool{true, false, true, false, true, false, true, false, true}},
+	} {
+		data = append(data, testgrib.MustEncode(spec)...)
+	}
+
+	for name, data := range map[string][]byte{"gfs": gfs, "synthetic": data} {
+		t.Run(name, func(t *testing.T) {
+			var fields []reader.FlatMessage
+			require.NoError(t, reader.NewReaderAt(bytes.NewReader(data), reader.WithSource(name)).EachFlatMessage(func(_ int, f reader.FlatMessage) bool {
+				fields = append(fields, f)
+				return true
+			}))
+			require.NotEmpty(t, fields)
+
+			for _, field := range fields {
+				blob, err := field.MarshalHeader()
+				require.NoError(t, err)
+
+				key, err := reader.HeaderKeyOf(blob)
+				require.NoError(t, err)
+				assert.Equal(t, reader.HeaderKey{Source: name, Offset: field.Offset, Length: field.Length}, key)
+
+				got, err := reader.UnmarshalHeader(blob, field.HeaderKey())
+				require.NoError(t, err)
+				// The missing value substitutes of GFS are NaN, which never compare
+				// equal: its fields round trip when they marshal to the same blob
+				again, err := got.MarshalHeader()
+				require.NoError(t, err)
+				assert.Equal(t, blob, again)
+				if name == "synthetic" {
+					assert.Equal(t, withoutSections(field), got)
+				}
+				assert.Equal(t, field.SectionRanges(), got.SectionRanges())
+				assert.Equal(t, field.LevelString(), got.LevelString())
+				assert.Equal(t, field.StepString(), got.StepString())
+			}
+		})
+	}
+}
+
+func TestHeader_Stale(t *testing.T) {
+	msgs := flatMessages(t, testgrib.MustEncode(testgrib.Spec{}))
+	blob, err := msgs[0].MarshalHeader()
+	require.NoError(t, err)
+
+	key := msgs[0].HeaderKey()
+	key.Length++
+	_, err = reader.UnmarshalHeader(blob, key)
+	var stale *reader.ErrStaleHeader
+	require.ErrorAs(t, err, &stale)
+	assert.Equal(t, msgs[0].HeaderKey(), stale.Got)
+	assert.Equal(t, key, stale.Want)
+}
+
+func TestHeader_Corrupt(t *testing.T) {
+	msgs := flatMessages(t, testgrib.MustEncode(testgrib.Spec{ProductTemplate: 8, RangeHours: 6}))
+	blob, err := msgs[0].MarshalHeader()
+	require.NoError(t, err)
+	key := msgs[0].HeaderKey()
+
+	for n := range len(blob) {
+		_, err := reader.UnmarshalHeader(blob[:n], key)
+		assert.Error(t, err, "truncated to %d bytes", n)
+	}
+
+	_, err = reader.UnmarshalHeader(append(bytes.Clone(blob), 0), key)
+	assert.ErrorContains(t, err, "trailing")
+
+	newer := bytes.Clone(blob)
+	newer[4]++
+	_, err = reader.UnmarshalHeader(newer, key)
+	assert.ErrorContains(t, err, "version")
+
+	layout := bytes.Clone(blob)
+	layout[5] ^= 0xff
+	_, err = reader.UnmarshalHeader(layout, key)
+	assert.ErrorContains(t, err, "layout")
+}
@@ -12,6 +12,7 @@ type HTTPReaderAt struct {
 	url    string
 	client *http.Client
 	size   int64
+	etag   string
 }
 
 func NewHTTPReaderAt(url string) (*HTTPReaderAt, error) {
@@ -34,6 +35,7 @@ func NewHTTPReaderAt(url string) (*HTTPReaderAt, error) {
 		url:    url,
 		client: client,
 		size:   resp.ContentLength,
+		etag:   resp.Header.Get("ETag"),
 	}, nil
 }
 
@@ -55,6 +57,10 @@ func (h *HTTPReaderAt) ReadAt(p []byte, off int64) (n int, err error) {
 	}
 
 	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", off, end))
+	if h.etag != "" {
+		// Fail rather than mix bytes of two versions when the file is replaced
+		req.Header.Set("If-Match", h.etag)
+	}
 
 	resp, err := h.client.Do(req)
 	if err != nil {
@@ -72,3 +78,8 @@ func (h *HTTPReaderAt) ReadAt(p []byte, off int64) (n int, err error) {
 func (h *HTTPReaderAt) Size() int64 {
 	return h.size
 }
+
+// ETag returns the entity tag of the file when it was opened, empty when the server sent none
+func (h *HTTPReaderAt) ETag() string {
+	return h.etag
+}
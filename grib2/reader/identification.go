@@ -0,0 +1,68 @@
+package reader
+
+import (
+	"github.com/scorix/grib/grib2/section"
+	"github.com/scorix/grib/grib2/template"
+)
+
+// Significance returns the significance of the reference time (Code Table 1.2)
+func (f *FlatMessage) Significance() section.ReferenceTimeSignificance {
+	return section.ReferenceTimeSignificance(f.ReferenceTimeSignificance)
+}
+
+// Status returns the production status of the data (Code Table 1.3)
+func (f *FlatMessage) Status() section.ProductionStatus {
+	return section.ProductionStatus(f.ProductionStatus)
+}
+
+// ProcessedDataType returns the type of processed data (Code Table 1.4)
+func (f *FlatMessage) ProcessedDataType() section.ProcessedDataType {
+	return section.ProcessedDataType(f.TypeOfData)
+}
+
+// IsAnalysis reports whether Section 1 declares analysis products
+func (f *FlatMessage) IsAnalysis() bool {
+	return f.ProcessedDataType().IsAnalysis()
+}
+
+// IsForecast reports whether Section 1 declares forecast products, including ensemble forecasts
+func (f *FlatMessage) IsForecast() bool {
+	return f.ProcessedDataType().IsForecast()
+}
+
+// IsOperational reports whether Section 1 declares operational products
+func (f *FlatMessage) IsOperational() bool {
+	return f.Status().IsOperational()
+}
+
+// IsTestData reports whether Section 1 declares test products
+func (f *FlatMessage) IsTestData() bool {
+	return f.Status().IsTestData()
+}
+
+// GeneratingProcessType returns the type of generating process (Code Table 4.3)
+func (f *FlatMessage) GeneratingProcessType() template.GeneratingProcessType {
+	return template.GeneratingProcessType(f.Product.TypeOfGeneratingProcess)
+}
+
+// GeneratingProcessName returns the name of the model or process that generated the
+// field, looked up in the generating process identifiers of the originating centre, or ""
+// when it is not known
+func (f *FlatMessage) GeneratingProcessName() string {
+	id, ok := f.Product.GeneratingProcessID()
+	if !ok {
+		return ""
+	}
+	name, _ := template.LookupGeneratingProcess(uint16(f.Centre), id)
+	return name
+}
+
+// Provenance returns the software that produced the message, as recorded in its Section 2
+// by writer.MessageWriter. It returns false when the message has no Section 2 or its local
+// use data does not record provenance.
+func (f *FlatMessage) Provenance() (section.Provenance, bool, error) {
+	if f.LocalUse == nil {
+		return section.Provenance{}, false, nil
+	}
+	return section.ParseProvenance(f.LocalUse.LocalUseData())
+}
@@ -0,0 +1,34 @@
+//go:build !nointernaltables
+
+package reader_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/scorix/grib/grib2/internal/testgrib"
+	"github.com/scorix/grib/grib2/template"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeneratingProcess(t *testing.T) {
+	data, err := os.ReadFile("testdata/gfs.t00z.pgrb2.0p25.f000")
+	require.NoError(t, err)
+
+	msg := flatMessages(t, data)[0]
+	assert.Equal(t, template.ProcessForecast, msg.GeneratingProcessType())
+	assert.Equal(t, "forecast", msg.GeneratingProcessType().String())
+	assert.Equal(t, "Analysis from GFS (Global Forecast System)", msg.GeneratingProcessName())
+	assert.Equal(t, "Analysis from GFS (Global Forecast System)", msg.Metadata("").GeneratingProcessName)
+
+	// Fixtures are generated by process 96 at the centre of the spec
+	gfs := flatMessages(t, testgrib.MustEncode(testgrib.Spec{}))[0]
+	assert.Equal(t, "Global Forecast System Model (GFS)", gfs.GeneratingProcessName())
+
+	local := flatMessages(t, testgrib.MustEncode(testgrib.Spec{Centre: 250}))[0]
+	assert.Empty(t, local.GeneratingProcessName())
+	template.RegisterGeneratingProcesses(250, map[uint8]string{96: "Local model"})
+	assert.Equal(t, "Local model", local.GeneratingProcessName())
+	assert.Equal(t, "Global Forecast System Model (GFS)", gfs.GeneratingProcessName())
+}
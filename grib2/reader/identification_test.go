@@ -0,0 +1,25 @@
+package reader_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/scorix/grib/grib2/section"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdentificationPredicates(t *testing.T) {
+	data, err := os.ReadFile("testdata/gfs.t00z.pgrb2.0p25.f000")
+	require.NoError(t, err)
+
+	msg := flatMessages(t, data)[0]
+
+	assert.Equal(t, section.SignificanceStartOfForecast, msg.Significance())
+	assert.Equal(t, section.StatusOperational, msg.Status())
+	assert.Equal(t, section.DataTypeForecast, msg.ProcessedDataType())
+	assert.False(t, msg.IsAnalysis())
+	assert.True(t, msg.IsForecast())
+	assert.True(t, msg.IsOperational())
+	assert.False(t, msg.IsTestData())
+}
@@ -0,0 +1,117 @@
+package reader
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// IndexEntry is one line of a wgrib2 style inventory (.idx) file, e.g.
+// "2:868737:d=2024100100:CLMR:1 hybrid level:anl:"
+type IndexEntry struct {
+	Message int      // Message number, starting at 1
+	Field   int      // Field number within the message for "n.m" submessages, 0 otherwise
+	Offset  int64    // Start offset of the message in the GRIB file
+	Date    string   // Reference time as written, e.g. "d=2024100100"
+	Name    string   // Parameter abbreviation, e.g. "TMP"
+	Level   string   // Level description, e.g. "500 mb"
+	Step    string   // Forecast step description, e.g. "6 hour fcst"
+	Extra   []string // Any further fields, e.g. ensemble information
+}
+
+// ParseIndex parses a wgrib2 style inventory. Blank lines are skipped.
+func ParseIndex(r io.Reader) ([]IndexEntry, error) {
+	var entries []IndexEntry
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		fields := strings.Split(strings.TrimSuffix(text, ":"), ":")
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("index line %d: expected at least 2 fields, got %d", line, len(fields))
+		}
+
+		var entry IndexEntry
+		number, field, hasField := strings.Cut(fields[0], ".")
+		message, err := strconv.Atoi(number)
+		if err != nil {
+			return nil, fmt.Errorf("index line %d: invalid message number %q", line, fields[0])
+		}
+		entry.Message = message
+		if hasField {
+			if entry.Field, err = strconv.Atoi(field); err != nil {
+				return nil, fmt.Errorf("index line %d: invalid message number %q", line, fields[0])
+			}
+		}
+		if entry.Offset, err = strconv.ParseInt(fields[1], 10, 64); err != nil || entry.Offset < 0 {
+			return nil, fmt.Errorf("index line %d: invalid offset %q", line, fields[1])
+		}
+
+		rest := fields[2:]
+		for _, target := range []*string{&entry.Date, &entry.Name, &entry.Level, &entry.Step} {
+			if len(rest) == 0 {
+				break
+			}
+			*target, rest = rest[0], rest[1:]
+		}
+		if len(rest) > 0 {
+			entry.Extra = rest
+		}
+
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read index: %w", err)
+	}
+
+	return entries, nil
+}
+
+// indexSpans returns the location of every message listed in an index, checked against the
+// GRIB file: each offset must hold a GRIB marker and a total length reaching exactly the next
+// message, or the end of the file for the last one. Submessages sharing an offset count once.
+func indexSpans(r io.ReaderAt, size int64, entries []IndexEntry) ([]ByteRange, error) {
+	var offsets []int64
+	for _, entry := range entries {
+		if len(offsets) > 0 && entry.Offset == offsets[len(offsets)-1] {
+			continue
+		}
+		if len(offsets) > 0 && entry.Offset < offsets[len(offsets)-1] {
+			return nil, fmt.Errorf("index offsets are not increasing at message %d", entry.Message)
+		}
+		offsets = append(offsets, entry.Offset)
+	}
+	if len(offsets) == 0 {
+		return nil, fmt.Errorf("index lists no messages")
+	}
+
+	spans := make([]ByteRange, len(offsets))
+	header := make([]byte, 16)
+	for i, offset := range offsets {
+		end := size
+		if i+1 < len(offsets) {
+			end = offsets[i+1]
+		}
+
+		if _, err := r.ReadAt(header, offset); err != nil {
+			return nil, fmt.Errorf("failed to read Section 0 header at offset %d: %w", offset, err)
+		}
+		if string(header[:4]) != "GRIB" {
+			return nil, fmt.Errorf("index offset %d does not point at a GRIB marker", offset)
+		}
+		if length := binary.BigEndian.Uint64(header[8:16]); length != uint64(end-offset) {
+			return nil, fmt.Errorf("message at offset %d has length %d, index implies %d", offset, length, end-offset)
+		}
+
+		spans[i] = ByteRange{Offset: offset, Length: end - offset}
+	}
+
+	return spans, nil
+}
@@ -0,0 +1,130 @@
+package reader_test
+
+import (
+	"encoding/binary"
+	"io"
+	"testing"
+
+	"github.com/scorix/grib/grib2/internal/testgrib"
+	"github.com/scorix/grib/grib2/reader"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sparseReaderAt simulates a large file holding a few chunks of data, reading zeros elsewhere
+type sparseReaderAt struct {
+	size   int64
+	chunks map[int64][]byte // Data keyed by file offset
+}
+
+func (s *sparseReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off >= s.size {
+		return 0, io.EOF
+	}
+
+	n := len(p)
+	if remaining := s.size - off; int64(n) > remaining {
+		n = int(remaining)
+	}
+	clear(p[:n])
+
+	for start, chunk := range s.chunks {
+		end := start + int64(len(chunk))
+		if end <= off || start >= off+int64(n) {
+			continue
+		}
+		lo, hi := max(start, off), min(end, off+int64(n))
+		copy(p[lo-off:hi-off], chunk[lo-start:hi-start])
+	}
+
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// hugeMessage returns the chunks of a message padded beyond 4 GiB with two maximal
+// Section 2 local use blocks, and the message length
+func hugeMessage() (map[int64][]byte, int64) {
+	const localUseLength = 0xffffffff
+
+	sec1 := testgrib.MustEncode(testgrib.Spec{})[16 : 16+21]
+	length := int64(16 + len(sec1) + 2*localUseLength + 4)
+
+	sec0 := []byte{'G', 'R', 'I', 'B', 0x00, 0x00, 0x00, 0x02}
+	sec0 = binary.BigEndian.AppendUint64(sec0, uint64(length))
+	sec2 := binary.BigEndian.AppendUint32(nil, localUseLength)
+	sec2 = append(sec2, 0x02)
+
+	first := int64(16 + len(sec1))
+	return map[int64][]byte{
+		0:                        append(sec0, sec1...),
+		first:                    sec2,
+		first + localUseLength:   sec2,
+		first + 2*localUseLength: []byte("7777"),
+	}, length
+}
+
+func TestReaderAtMessageBeyond4GiB(t *testing.T) {
+	chunks, hugeLength := hugeMessage()
+	small := testgrib.MustEncode(testgrib.Spec{Values: []float64{
+		1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16,
+	}})
+	chunks[hugeLength] = small
+
+	r := reader.NewReaderAt(&sparseReaderAt{size: hugeLength + int64(len(small)), chunks: chunks})
+
+	var infos []reader.MessageInfo
+	require.NoError(t, r.EachMessage(func(_ int, info reader.MessageInfo) bool {
+		infos = append(infos, info)
+		return true
+	}))
+	require.Len(t, infos, 2)
+
+	assert.Equal(t, uint64(hugeLength), infos[0].Length)
+	require.Len(t, infos[0].Sections, 5)
+	assert.Equal(t, hugeLength-4, infos[0].Sections[4].Offset)
+
+	high := infos[1]
+	assert.Equal(t, hugeLength, high.Offset)
+	assert.Greater(t, high.Offset, int64(1)<<32)
+	assert.Equal(t, uint64(len(small)), high.Length)
+	assert.Equal(t, hugeLength+16, high.Sections[1].Offset)
+
+	msg, err := r.ReadMessage(high)
+	require.NoError(t, err)
+	require.NoError(t, msg.Validate())
+
+	fields := msg.FlattenToFlatMessages()
+	require.Len(t, fields, 1)
+	assert.Equal(t, hugeLength, fields[0].Offset)
+
+	field, ok := fields[0].FieldByteRange()
+	require.True(t, ok)
+	assert.Equal(t, hugeLength+16+21+72, field.Offset)
+
+	values, err := fields[0].DecodeData()
+	require.NoError(t, err)
+	assert.Equal(t, float64(16), values[15])
+}
+
+func TestReaderAtInvalidTotalLength(t *testing.T) {
+	for _, totalLength := range []uint64{0, 19, 1 << 63} {
+		header := []byte{'G', 'R', 'I', 'B', 0x00, 0x00, 0x00, 0x02}
+		header = binary.BigEndian.AppendUint64(header, totalLength)
+
+		r := reader.NewReaderAt(&sparseReaderAt{size: 1 << 40, chunks: map[int64][]byte{0: header}})
+		err := r.EachMessage(func(int, reader.MessageInfo) bool { return true })
+		assert.ErrorContains(t, err, "invalid total length", "total length %d", totalLength)
+	}
+}
+
+func TestReaderAtSectionOverrunsMessage(t *testing.T) {
+	data := testgrib.MustEncode(testgrib.Spec{})
+	binary.BigEndian.PutUint64(data[8:16], 16+21+10) // truncate the message inside Section 3
+	data = data[:len(data)-4]                        // without an end marker to trust instead
+
+	r := reader.NewReaderAt(&sparseReaderAt{size: int64(len(data)), chunks: map[int64][]byte{0: data}})
+	err := r.EachMessage(func(int, reader.MessageInfo) bool { return true })
+	assert.ErrorContains(t, err, "overruns message end")
+}
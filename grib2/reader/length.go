@@ -0,0 +1,133 @@
+package reader
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrLengthMismatch reports a message whose Section 0 total length disagrees with the
+// position of its end marker
+type ErrLengthMismatch struct {
+	MessageOffset int64  // Start offset of the message
+	TotalLength   uint64 // Total length coded in Section 0
+	ScannedLength uint64 // Length of the scanned sections, up to and including Section 8
+}
+
+// Error implements the error interface
+func (e *ErrLengthMismatch) Error() string {
+	return fmt.Sprintf("message at offset %d: Section 0 total length %d disagrees with scanned length %d",
+		e.MessageOffset, e.TotalLength, e.ScannedLength)
+}
+
+// ErrShortSection reports a section shorter than its defined octets, such as a Section 1
+// of fewer than 21 octets. It is only returned in strict mode; otherwise the missing
+// fields read as zero.
+type ErrShortSection struct {
+	MessageOffset int64  // Start offset of the message
+	Section       uint8  // Section number
+	Length        uint32 // Length of the section
+	Expected      uint32 // Length of the defined octets of the section
+}
+
+// Error implements the error interface
+func (e *ErrShortSection) Error() string {
+	return fmt.Sprintf("Section %d of message at offset %d has %d octets, %d expected",
+		e.Section, e.MessageOffset, e.Length, e.Expected)
+}
+
+// ErrSectionOverrun reports a section whose declared length runs past the end marker of
+// its message, as located by the Section 0 total length. By default an overrunning
+// Section 7 followed by the end marker at the total length is clipped to the octets
+// before the marker, its data failing to load with this error, which is also recorded in
+// MessageInfo.Anomalies; in strict mode the message fails with it.
+type ErrSectionOverrun struct {
+	MessageOffset int64  // Start offset of the message
+	Section       uint8  // Section number
+	Offset        int64  // Start offset of the section
+	Length        uint32 // Length declared by the section
+	Available     uint32 // Octets from the start of the section to the end marker
+}
+
+// Error implements the error interface
+func (e *ErrSectionOverrun) Error() string {
+	return fmt.Sprintf("Section %d of %d octets at offset %d overruns message end: %d octets available before the end marker of the message at offset %d",
+		e.Section, e.Length, e.Offset, e.Available, e.MessageOffset)
+}
+
+// definedSectionLengths holds the length of the defined octets of sections of a fixed layout
+var definedSectionLengths = map[uint8]uint32{
+	1: 21,
+}
+
+// checkSectionLength returns *ErrShortSection when a section of the message at offset
+// is shorter than its defined octets
+func checkSectionLength(offset int64, number uint8, length uint32) error {
+	if expected, ok := definedSectionLengths[number]; ok && length < expected {
+		return &ErrShortSection{MessageOffset: offset, Section: number, Length: length, Expected: expected}
+	}
+	return nil
+}
+
+// checkMessageLength compares the total length of the message at offset with its scanned
+// sections. When they disagree and the sections end with Section 8, the end marker is
+// trusted: the scanned length is returned with the mismatch as an anomaly, or as an error
+// in strict mode.
+func checkMessageLength(offset int64, totalLength uint64, sections []SectionInfo, strict bool) (length uint64, anomaly, err error) {
+	if len(sections) == 0 || sections[len(sections)-1].Number != 8 {
+		return totalLength, nil, nil
+	}
+
+	last := sections[len(sections)-1]
+	scanned := uint64(last.Offset - offset + int64(last.Length))
+	if scanned == totalLength {
+		return totalLength, nil, nil
+	}
+
+	mismatch := &ErrLengthMismatch{MessageOffset: offset, TotalLength: totalLength, ScannedLength: scanned}
+	if strict {
+		return 0, nil, mismatch
+	}
+	return scanned, mismatch, nil
+}
+
+// scanMessageSections scans the sections of the message at offset. Sections are first
+// scanned within the Section 0 total length; when that does not end exactly with
+// Section 8, they are scanned again up to the first end marker, wherever it lies.
+//
+// A Section 7 running past the end marker at the total length is instead clipped to end
+// before it, and the overrun returned as an anomaly, or as an error in strict mode.
+func (r *ReaderAt) scanMessageSections(offset int64, totalLength uint64) (sections []SectionInfo, anomaly, err error) {
+	end := offset + int64(totalLength)
+	sections, err = r.scanSectionsInRange(offset, end)
+	if err == nil && len(sections) > 0 && sections[len(sections)-1].Number == 8 {
+		return sections, nil, nil
+	}
+
+	var overrun *ErrSectionOverrun
+	if errors.As(err, &overrun) && overrun.Section == 7 && overrun.Available >= 5 && r.endMarkerAt(end-4) {
+		overrun.MessageOffset = offset
+		if r.opts.strictSections {
+			return sections, nil, overrun
+		}
+		clipped := append(sections,
+			SectionInfo{Number: 7, Offset: overrun.Offset, Length: overrun.Available},
+			SectionInfo{Number: 8, Offset: end - 4, Length: 4},
+		)
+		return clipped, overrun, nil
+	}
+
+	rescanned, rescanErr := r.scanSectionsInRange(offset, math.MaxInt64)
+	if rescanErr != nil || len(rescanned) == 0 || rescanned[len(rescanned)-1].Number != 8 {
+		// No end marker to trust either; report the sections within the total length
+		return sections, nil, err
+	}
+	return rescanned, nil, nil
+}
+
+// endMarkerAt reports whether the end marker "7777" is at offset
+func (r *ReaderAt) endMarkerAt(offset int64) bool {
+	marker := make([]byte, 4)
+	_, err := r.reader.ReadAt(marker, offset)
+	return err == nil && string(marker) == "7777"
+}
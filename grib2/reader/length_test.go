@@ -0,0 +1,174 @@
+package reader_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/scorix/grib/grib2/internal/testgrib"
+	"github.com/scorix/grib/grib2/reader"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessageLengthMismatch(t *testing.T) {
+	for _, delta := range []int{8, -8} {
+		first := messageFromSections(1, 3, 4, 5, 6, 7)
+		corrupt := messageFromSections(1, 3, 4, 5, 6, 7, 4, 5, 6, 7)
+		last := messageFromSections(1, 3, 4, 5, 6, 7)
+
+		scanned := uint64(len(corrupt))
+		total := uint64(len(corrupt) + delta)
+		binary.BigEndian.PutUint64(corrupt[8:16], total)
+
+		data := append(append(append([]byte(nil), first...), corrupt...), last...)
+		want := &reader.ErrLengthMismatch{MessageOffset: int64(len(first)), TotalLength: total, ScannedLength: scanned}
+
+		readers := map[string]func(opts ...reader.Option) messageIterator{
+			"Reader": func(opts ...reader.Option) messageIterator {
+				return reader.NewReader(bytes.NewReader(data), opts...)
+			},
+			"ReaderAt": func(opts ...reader.Option) messageIterator {
+				return reader.NewReaderAt(bytes.NewReader(data), opts...)
+			},
+		}
+
+		for name, newReader := range readers {
+			t.Run(name, func(t *testing.T) {
+				var infos []reader.MessageInfo
+				require.NoError(t, newReader().EachMessage(func(_ int, info reader.MessageInfo) bool {
+					infos = append(infos, info)
+					return true
+				}))
+				require.Len(t, infos, 3, "total length %+d", delta)
+
+				assert.Empty(t, infos[0].Anomalies)
+				assert.Equal(t, scanned, infos[1].Length)
+				assert.Equal(t, []error{want}, infos[1].Anomalies)
+				assert.Equal(t, int64(len(first)+len(corrupt)), infos[2].Offset)
+				assert.Empty(t, infos[2].Anomalies)
+
+				fields := 0
+				require.NoError(t, newReader().EachFlatMessage(func(int, reader.FlatMessage) bool {
+					fields++
+					return true
+				}))
+				assert.Equal(t, 4, fields)
+
+				err := newReader(reader.WithStrictSections()).EachMessage(func(int, reader.MessageInfo) bool { return true })
+				var mismatch *reader.ErrLengthMismatch
+				require.ErrorAs(t, err, &mismatch)
+				assert.Equal(t, want, mismatch)
+			})
+		}
+	}
+}
+
+func TestShortIdentificationSection(t *testing.T) {
+	// Section 1 of 20 octets, without its type of data
+	sections := testgrib.MustEncodeSections(testgrib.Spec{Ni: 2, Nj: 2, ReferenceTime: time.Date(2024, 3, 15, 6, 0, 0, 0, time.UTC)})
+	sections[1] = sections[1][:20]
+	sections[1][3] = 20
+	data := testgrib.Assemble(0, sections[1:]...)
+	data = append(data, messageFromSections(1, 3, 4, 5, 6, 7)...)
+
+	readers := map[string]func(opts ...reader.Option) messageIterator{
+		"Reader": func(opts ...reader.Option) messageIterator {
+			return reader.NewReader(bytes.NewReader(data), opts...)
+		},
+		"ReaderAt": func(opts ...reader.Option) messageIterator {
+			return reader.NewReaderAt(bytes.NewReader(data), opts...)
+		},
+	}
+
+	for name, newReader := range readers {
+		t.Run(name, func(t *testing.T) {
+			var fields []reader.FlatMessage
+			require.NoError(t, newReader().EachFlatMessage(func(_ int, msg reader.FlatMessage) bool {
+				fields = append(fields, msg)
+				return true
+			}))
+			require.Len(t, fields, 2)
+
+			assert.True(t, fields[0].Identification.Truncated())
+			assert.Equal(t, 2024, fields[0].Year)
+			assert.Equal(t, 6, fields[0].Hour)
+			assert.Equal(t, 0, fields[0].TypeOfData)
+			assert.False(t, fields[1].Identification.Truncated())
+
+			err := newReader(reader.WithStrictSections()).EachMessage(func(int, reader.MessageInfo) bool { return true })
+			var short *reader.ErrShortSection
+			require.ErrorAs(t, err, &short)
+			assert.Equal(t, &reader.ErrShortSection{MessageOffset: 0, Section: 1, Length: 20, Expected: 21}, short)
+		})
+	}
+}
+
+func TestSection7Overrun(t *testing.T) {
+	first := testgrib.MustEncode(testgrib.Spec{})
+	corrupt := testgrib.MustEncode(testgrib.Spec{Parameter: 2})
+	last := testgrib.MustEncode(testgrib.Spec{Parameter: 4})
+
+	// Inflate the length of Section 7 past the end of its message
+	offset := sectionOffset(t, corrupt, 7)
+	available := uint32(len(corrupt) - 4 - offset)
+	binary.BigEndian.PutUint32(corrupt[offset:], available+100)
+
+	data := append(append(append([]byte(nil), first...), corrupt...), last...)
+	want := &reader.ErrSectionOverrun{
+		MessageOffset: int64(len(first)),
+		Section:       7,
+		Offset:        int64(len(first) + offset),
+		Length:        available + 100,
+		Available:     available,
+	}
+
+	readers := map[string]func(opts ...reader.Option) messageIterator{
+		"Reader": func(opts ...reader.Option) messageIterator {
+			return reader.NewReader(bytes.NewReader(data), opts...)
+		},
+		"ReaderAt": func(opts ...reader.Option) messageIterator {
+			return reader.NewReaderAt(bytes.NewReader(data), opts...)
+		},
+	}
+
+	for name, newReader := range readers {
+		t.Run(name, func(t *testing.T) {
+			var infos []reader.MessageInfo
+			require.NoError(t, newReader().EachMessage(func(_ int, info reader.MessageInfo) bool {
+				infos = append(infos, info)
+				return true
+			}))
+			require.Len(t, infos, 3)
+			assert.Equal(t, []error{want}, infos[1].Anomalies)
+			assert.Equal(t, uint64(len(corrupt)), infos[1].Length)
+			assert.Equal(t, int64(len(first)+len(corrupt)), infos[2].Offset)
+			assert.Empty(t, infos[2].Anomalies)
+
+			var fields []reader.FlatMessage
+			require.NoError(t, newReader().EachFlatMessage(func(_ int, f reader.FlatMessage) bool {
+				fields = append(fields, f)
+				return true
+			}))
+			require.Len(t, fields, 3)
+
+			// The clipped field fails to load, the following one is intact
+			var overrun *reader.ErrSectionOverrun
+			require.ErrorAs(t, fields[1].Data.LoadError(), &overrun)
+			assert.Equal(t, want, overrun)
+			assert.Equal(t, available, fields[1].Data.Length())
+			_, err := fields[1].DecodeData()
+			assert.ErrorAs(t, err, &overrun)
+
+			values, err := fields[2].DecodeData()
+			require.NoError(t, err)
+			assert.Equal(t, uint8(4), fields[2].Product.Parameter)
+			assert.Equal(t, 15.0, values[15])
+
+			err = newReader(reader.WithStrictSections()).EachMessage(func(int, reader.MessageInfo) bool { return true })
+			require.ErrorAs(t, err, &overrun)
+			assert.Equal(t, want, overrun)
+		})
+	}
+}
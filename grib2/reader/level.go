@@ -0,0 +1,277 @@
+package reader
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/scorix/grib/grib2/grid"
+	"github.com/scorix/grib/grib2/template"
+)
+
+// levelFormat describes how a Code Table 4.5 fixed surface is written in level strings
+type levelFormat struct {
+	name   string  // Surface name, following the value when the surface has one
+	valued bool    // Whether the surface value is part of the level string
+	scale  float64 // Divisor applied to the value, e.g. Pa to mb
+}
+
+// levelFormats maps Code Table 4.5 surface types to the names used by wgrib2 style level strings
+var levelFormats = map[uint8]levelFormat{
+	1:   {name: "surface"},
+	2:   {name: "cloud base"},
+	3:   {name: "cloud top"},
+	4:   {name: "0C isotherm"},
+	5:   {name: "level of adiabatic condensation from sfc"},
+	6:   {name: "max wind"},
+	7:   {name: "tropopause"},
+	8:   {name: "top of atmosphere"},
+	9:   {name: "sea bottom"},
+	10:  {name: "entire atmosphere"},
+	11:  {name: "cumulonimbus base"},
+	12:  {name: "cumulonimbus top"},
+	14:  {name: "level of free convection"},
+	15:  {name: "convective condensation level"},
+	16:  {name: "level of neutral buoyancy"},
+	20:  {name: "K level", valued: true, scale: 1},
+	100: {name: "mb", valued: true, scale: 100},
+	101: {name: "mean sea level"},
+	102: {name: "m above mean sea level", valued: true, scale: 1},
+	103: {name: "m above ground", valued: true, scale: 1},
+	104: {name: "sigma level", valued: true, scale: 1},
+	105: {name: "hybrid level", valued: true, scale: 1},
+	106: {name: "m below ground", valued: true, scale: 1},
+	107: {name: "K isentropic level", valued: true, scale: 1},
+	108: {name: "mb above ground", valued: true, scale: 100},
+	111: {name: "eta level", valued: true, scale: 1},
+	160: {name: "m below sea level", valued: true, scale: 1},
+
+	// NCEP local surfaces
+	200: {name: "entire atmosphere (considered as a single layer)"},
+	204: {name: "highest tropospheric freezing level"},
+	211: {name: "boundary layer cloud layer"},
+	212: {name: "low cloud bottom level"},
+	213: {name: "low cloud top level"},
+	214: {name: "low cloud layer"},
+	220: {name: "planetary boundary layer"},
+	222: {name: "middle cloud bottom level"},
+	223: {name: "middle cloud top level"},
+	224: {name: "middle cloud layer"},
+	232: {name: "high cloud bottom level"},
+	233: {name: "high cloud top level"},
+	234: {name: "high cloud layer"},
+	242: {name: "convective cloud bottom level"},
+	243: {name: "convective cloud top level"},
+	244: {name: "convective cloud layer"},
+}
+
+// LevelString formats the fixed surfaces following wgrib2 conventions,
+// e.g. "surface", "500 mb", "2 m above ground" or "0-0.1 m below ground".
+// Missing components are omitted: a layer whose second surface is missing is
+// written as a single level, and a missing value leaves only the surface name.
+// Products processed over a spatial area append their processing, as in
+// "2 m above ground, max over 25 points", and radar products, which have no fixed
+// surface, describe their site, as in "radar site 35.33N 97.28W elev 390 m".
+func (f *FlatMessage) LevelString() string {
+	if radar := f.Product.Radar; radar != nil {
+		return radarSiteString(radar)
+	}
+
+	level := f.Level().String()
+	if spatial := f.SpatialProcessingString(); spatial != "" {
+		return level + ", " + spatial
+	}
+	return level
+}
+
+// LevelInfo describes the fixed surfaces of a field (Code Table 4.5): a single level, or a
+// layer between two surfaces
+type LevelInfo struct {
+	First    template.FixedSurface // First fixed surface, valid when HasFirst is true
+	Second   template.FixedSurface // Second fixed surface bounding a layer, valid when IsLayer is true
+	HasFirst bool                  // False when the type of the first surface is missing
+	IsLayer  bool                  // Whether the second surface is present, its type not being missing
+}
+
+// Level returns the fixed surfaces of the field, with their values scaled. The surface
+// names and units of Code Table 4.5 are given by template.FixedSurface.Name and Unit.
+func (f *FlatMessage) Level() LevelInfo {
+	var level LevelInfo
+	level.First, level.HasFirst = f.Product.FirstSurface()
+	level.Second, level.IsLayer = f.Product.SecondSurface()
+	return level
+}
+
+// String formats the fixed surfaces following wgrib2 conventions, e.g. "surface",
+// "500 mb", "2 m above ground", "0-0.1 m below ground" or "entire atmosphere". Layers
+// between surfaces of different types join both, as in "surface - top of atmosphere".
+func (l LevelInfo) String() string {
+	if !l.HasFirst {
+		return "missing"
+	}
+
+	first := surfaceString(l.First)
+	if !l.IsLayer {
+		return first
+	}
+	if l.Second.Type != l.First.Type {
+		return first + " - " + surfaceString(l.Second)
+	}
+
+	format, known := levelFormats[l.First.Type]
+	if !known || !format.valued || !l.First.HasValue || !l.Second.HasValue {
+		return first
+	}
+	return fmt.Sprintf("%s-%s %s", formatLevelValue(l.First.Value, format.scale), formatLevelValue(l.Second.Value, format.scale), format.name)
+}
+
+// surfaceString formats a single fixed surface
+func surfaceString(surface template.FixedSurface) string {
+	format, known := levelFormats[surface.Type]
+	if !known {
+		if surface.HasValue {
+			return fmt.Sprintf("%s level(%d)", formatLevelValue(surface.Value, 1), surface.Type)
+		}
+		return fmt.Sprintf("level(%d)", surface.Type)
+	}
+
+	if !format.valued || !surface.HasValue {
+		return format.name
+	}
+	return fmt.Sprintf("%s %s", formatLevelValue(surface.Value, format.scale), format.name)
+}
+
+// spatialInterpolations maps Code Table 4.15 to the names of the interpolations applied
+// before spatial processing
+var spatialInterpolations = map[uint8]string{
+	1: "bilinear interpolation",
+	2: "bicubic interpolation",
+	3: "nearest neighbour",
+	4: "budget interpolation",
+	5: "spectral interpolation",
+	6: "neighbour-budget interpolation",
+}
+
+// SpatialProcessingString describes the spatial processing of products processed over a
+// spatial area (template 4.15), e.g. "max over 25 points" or "ave over 9 points after
+// bilinear interpolation". It is empty for all other products.
+func (f *FlatMessage) SpatialProcessingString() string {
+	spatial := f.Product.Spatial
+	if spatial == nil {
+		return ""
+	}
+
+	process, ok := statisticalProcessAbbreviations[template.StatisticalProcess(spatial.StatisticalProcess)]
+	if !ok {
+		process = fmt.Sprintf("process(%d)", spatial.StatisticalProcess)
+	}
+	s := fmt.Sprintf("%s over %d points", process, spatial.NumberOfPoints)
+
+	switch interpolation, ok := spatialInterpolations[spatial.SpatialProcessType]; {
+	case ok:
+		s += " after " + interpolation
+	case spatial.SpatialProcessType != 0:
+		// Type 0 processes the source grid directly
+		s += fmt.Sprintf(" after spatial process(%d)", spatial.SpatialProcessType)
+	}
+	return s
+}
+
+// radarSiteString describes the site of a radar product
+func radarSiteString(radar *template.RadarInfo) string {
+	lat, lon := radar.SiteLatLon()
+	lon = grid.LonMinus180To180.Longitude(lon)
+
+	ns, ew := "N", "E"
+	if lat < 0 {
+		ns = "S"
+	}
+	if lon < 0 {
+		ew = "W"
+	}
+	return fmt.Sprintf("radar site %.2f%s %.2f%s elev %d m", math.Abs(lat), ns, math.Abs(lon), ew, radar.SiteElevation)
+}
+
+// formatLevelValue writes a surface value divided by scale with the shortest exact representation
+func formatLevelValue(value, scale float64) string {
+	return strconv.FormatFloat(value/scale, 'g', -1, 64)
+}
+
+// levelEpsilon is the relative tolerance of level comparisons, which absorbs the rounding
+// of scaled surface values
+const levelEpsilon = 1e-9
+
+// pressureSurfaces are the Code Table 4.5 surfaces whose values are pressures in Pa
+var pressureSurfaces = map[uint8]bool{
+	100: true, // Isobaric surface
+	108: true, // Level at specified pressure difference from ground to level
+}
+
+// pressureUnits maps the units accepted for pressure surfaces to their size in Pa
+var pressureUnits = map[string]float64{
+	"Pa":  1,
+	"hPa": 100,
+	"mb":  100,
+	"kPa": 1000,
+}
+
+// Level is the value of a fixed surface in a given unit, e.g. Level{Type: 100, Value: 500, Unit: "hPa"}
+type Level struct {
+	Type  uint8   // Type of fixed surface (Code Table 4.5)
+	Value float64 // Value of the surface in Unit
+	Unit  string  // Unit of Value: Pa, hPa, mb or kPa for pressure surfaces; empty for hPa and for the Code Table 4.5 unit of other surfaces
+}
+
+// LevelRange is an inclusive range of values of a fixed surface. The bounds may be given
+// in either order, e.g. LevelRange{Type: 100, From: 1000, To: 300, Unit: "hPa"}.
+type LevelRange struct {
+	Type     uint8   // Type of fixed surface (Code Table 4.5)
+	From, To float64 // Bounds of the range in Unit
+	Unit     string  // Unit of the bounds, as in Level
+}
+
+// levelInUnit returns the value of the first fixed surface of the field in unit, when the
+// surface has the given type and a value
+func (f *FlatMessage) levelInUnit(surfaceType uint8, unit string) (float64, bool) {
+	surface, ok := f.Product.FirstSurface()
+	if !ok || !surface.HasValue || surface.Type != surfaceType {
+		return 0, false
+	}
+
+	size, ok := levelUnitSize(surfaceType, unit)
+	if !ok {
+		return 0, false
+	}
+	return surface.Value / size, true
+}
+
+// levelUnitSize returns the size of unit in the Code Table 4.5 unit of the surface type
+func levelUnitSize(surfaceType uint8, unit string) (float64, bool) {
+	if !pressureSurfaces[surfaceType] {
+		return 1, unit == ""
+	}
+	if unit == "" {
+		unit = "hPa"
+	}
+
+	size, ok := pressureUnits[unit]
+	return size, ok
+}
+
+// matchLevel reports whether the first fixed surface of the field equals level
+func (f *FlatMessage) matchLevel(level Level) bool {
+	value, ok := f.levelInUnit(level.Type, level.Unit)
+	return ok && math.Abs(value-level.Value) <= levelEpsilon*math.Max(1, math.Abs(level.Value))
+}
+
+// matchLevelRange reports whether the first fixed surface of the field lies within r
+func (f *FlatMessage) matchLevelRange(r LevelRange) bool {
+	value, ok := f.levelInUnit(r.Type, r.Unit)
+	if !ok {
+		return false
+	}
+
+	low, high := min(r.From, r.To), max(r.From, r.To)
+	tolerance := levelEpsilon * math.Max(1, math.Max(math.Abs(low), math.Abs(high)))
+	return value >= low-tolerance && value <= high+tolerance
+}
@@ -0,0 +1,189 @@
+package reader_test
+
+import (
+	"encoding/binary"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/scorix/grib/grib2/internal/testgrib"
+	"github.com/scorix/grib/grib2/reader"
+	"github.com/scorix/grib/grib2/template"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLevelString(t *testing.T) {
+	tests := []struct {
+		name        string
+		surfaceType uint8
+		scale       int8
+		value       uint32
+		second      testgrib.Spec // Second surface
+		want        string
+	}{
+		{name: "surface", surfaceType: 1, want: "surface"},
+		{name: "isobaric", surfaceType: 100, value: 50000, want: "500 mb"},
+		{name: "height above ground", surfaceType: 103, value: 2, want: "2 m above ground"},
+		{name: "missing value", surfaceType: 103, scale: -127, value: 0xffffffff, want: "m above ground"},
+		{name: "layer", surfaceType: 106, value: 0, second: testgrib.Spec{SecondSurfaceType: 106, SecondSurfaceScale: 1, SecondSurfaceValue: 1}, want: "0-0.1 m below ground"},
+		{name: "layer with missing second value", surfaceType: 106, value: 0, second: testgrib.Spec{SecondSurfaceType: 106, SecondSurfaceScale: -127, SecondSurfaceValue: 0xffffffff}, want: "0 m below ground"},
+		{name: "layer of different types", surfaceType: 1, second: testgrib.Spec{SecondSurfaceType: 8, SecondSurfaceScale: -127, SecondSurfaceValue: 0xffffffff}, want: "surface - top of atmosphere"},
+		{name: "pressure layer", surfaceType: 108, value: 3000, second: testgrib.Spec{SecondSurfaceType: 108}, want: "30-0 mb above ground"},
+		{name: "isothermal", surfaceType: 20, scale: 1, value: 2532, want: "253.2 K level"},
+		{name: "local", surfaceType: 214, want: "low cloud layer"},
+		{name: "unknown type", surfaceType: 150, value: 3, want: "3 level(150)"},
+		{name: "missing type", surfaceType: 0xff, scale: -127, value: 0xffffffff, want: "missing"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := tt.second
+			spec.SurfaceType, spec.SurfaceScale, spec.SurfaceValue = tt.surfaceType, tt.scale, tt.value
+
+			messages := flatMessages(t, testgrib.MustEncode(spec))
+			require.Len(t, messages, 1)
+			assert.Equal(t, tt.want, messages[0].LevelString())
+		})
+	}
+}
+
+func TestFlatMessage_Level(t *testing.T) {
+	// The layer between the surface and 10 cm below ground
+	level := flatMessages(t, testgrib.MustEncode(testgrib.Spec{
+		SurfaceType: 106, SecondSurfaceType: 106, SecondSurfaceScale: 1, SecondSurfaceValue: 1,
+	}))[0].Level()
+
+	assert.Equal(t, reader.LevelInfo{
+		First:    template.FixedSurface{Type: 106, Value: 0, HasValue: true},
+		Second:   template.FixedSurface{Type: 106, Value: 0.1, HasValue: true},
+		HasFirst: true,
+		IsLayer:  true,
+	}, level)
+	assert.Equal(t, "Depth below land surface", level.First.Name())
+	assert.Equal(t, "m", level.First.Unit())
+	assert.Equal(t, "0-0.1 m below ground", level.String())
+
+	// A single level, its second surface missing
+	level = flatMessages(t, testgrib.MustEncode(testgrib.Spec{SurfaceType: 100, SurfaceValue: 85000}))[0].Level()
+	assert.True(t, level.HasFirst)
+	assert.False(t, level.IsLayer)
+	assert.Equal(t, "Isobaric surface", level.First.Name())
+	assert.Equal(t, "Pa", level.First.Unit())
+	assert.InDelta(t, 85000, level.First.Value, 1e-9)
+	assert.Equal(t, "850 mb", level.String())
+
+	assert.Equal(t, "missing", reader.LevelInfo{}.String())
+}
+
+func TestLevelStringTestdata(t *testing.T) {
+	data, err := os.ReadFile("testdata/gfs.t00z.pgrb2.0p25.f000")
+	require.NoError(t, err)
+
+	var levels []string
+	for _, msg := range flatMessages(t, data) {
+		levels = append(levels, msg.LevelString())
+	}
+	assert.Equal(t, []string{"mean sea level", "1 hybrid level", "1 hybrid level"}, levels)
+}
+
+func TestLevelString_SpatialProcessing(t *testing.T) {
+	tests := []struct {
+		name    string
+		spatial []byte // statistical process, type of spatial processing and number of points
+		want    *template.SpatialProcessingInfo
+		level   string
+	}{
+		{
+			name:    "maximum over the source grid",
+			spatial: []byte{2, 0, 25},
+			want:    &template.SpatialProcessingInfo{StatisticalProcess: 2, NumberOfPoints: 25},
+			level:   "2 m above ground, max over 25 points",
+		},
+		{
+			name:    "average after bilinear interpolation",
+			spatial: []byte{0, 1, 9},
+			want:    &template.SpatialProcessingInfo{SpatialProcessType: 1, NumberOfPoints: 9},
+			level:   "2 m above ground, ave over 9 points after bilinear interpolation",
+		},
+		{
+			name:    "unknown codes",
+			spatial: []byte{190, 200, 4},
+			want:    &template.SpatialProcessingInfo{StatisticalProcess: 190, SpatialProcessType: 200, NumberOfPoints: 4},
+			level:   "2 m above ground, process(190) over 4 points after spatial process(200)",
+		},
+		{
+			name:  "truncated template",
+			level: "2 m above ground",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Template 4.15 holds the octets of template 4.0 followed by the spatial processing
+			sections := testgrib.MustEncodeSections(testgrib.Spec{SurfaceType: 103, SurfaceValue: 2})
+			product := slices.Concat(sections[4][5:], tt.spatial)
+			binary.BigEndian.PutUint16(product[2:], 15)
+			sections[4] = testgrib.Section(4, product)
+
+			messages := flatMessages(t, testgrib.Assemble(0, sections[1:]...))
+			require.Len(t, messages, 1)
+			assert.Equal(t, tt.want, messages[0].Product.Spatial)
+			assert.Equal(t, tt.level, messages[0].LevelString())
+		})
+	}
+}
+
+func TestLevelString_Radar(t *testing.T) {
+	product := []byte{
+		15, 15, // parameter category and number: base reflectivity
+		0,    // type of generating process: analysis
+		1,    // number of radar sites used
+		0x00, // indicator of unit of time range: minute
+	}
+	product = binary.BigEndian.AppendUint32(product, 35330000)            // site latitude
+	product = binary.BigEndian.AppendUint32(product, 0x80000000|97280000) // site longitude, negative in sign-and-magnitude
+	product = binary.BigEndian.AppendUint16(product, 390)                 // site elevation
+	product = append(product, "KTLX"...)                                  // site identifier
+	product = binary.BigEndian.AppendUint16(product, 0)                   // numeric site identifier
+	product = append(product,
+		2,     // operating mode: precipitation
+		0,     // reflectivity calibration constant
+		1,     // quality control applied
+		1,     // clutter filter applied
+		5,     // antenna elevation angle: 0.5 degree
+		0, 60, // accumulation interval
+		18,               // reference reflectivity for echo top
+		0x00, 0x00, 0xfa, // range resolution: 250 m
+		0, 10, // radial angular spacing: 1 degree
+	)
+	require.Len(t, product, 34)
+
+	messages := flatMessages(t, testgrib.MustEncode(testgrib.Spec{ProductTemplate: 20, ProductData: product}))
+	require.Len(t, messages, 1)
+	msg := messages[0]
+
+	assert.Equal(t, &template.RadarInfo{
+		NumberOfRadarSites:   1,
+		SiteLatitude:         35330000,
+		SiteLongitude:        -97280000,
+		SiteElevation:        390,
+		SiteID:               "KTLX",
+		OperatingMode:        2,
+		QualityControl:       1,
+		ClutterFilter:        1,
+		ElevationAngle:       5,
+		AccumulationInterval: 60,
+		EchoTopReflectivity:  18,
+		RangeResolution:      250,
+		RadialAngularSpacing: 10,
+	}, msg.Product.Radar)
+	assert.Equal(t, uint8(15), msg.Product.Category)
+	assert.Equal(t, template.TimeUnitMinute, msg.Product.IndicatorOfUnitOfTimeRange)
+	assert.Zero(t, msg.Product.ForecastTime)
+
+	_, ok := msg.Product.FirstSurface()
+	assert.False(t, ok)
+	assert.Equal(t, "radar site 35.33N 97.28W elev 390 m", msg.LevelString())
+	assert.Equal(t, "anl", msg.StepString())
+}
@@ -0,0 +1,111 @@
+package reader
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// WithMaxMessages stops iterations after the first n messages of the file, duplicates
+// skipped by WithDeduplicate included, so that sampling the start of a huge file reads no
+// further. Iterations end without error; Truncated reports whether messages were left
+// unread. n <= 0 means no limit.
+func WithMaxMessages(n int) Option {
+	return func(o *options) {
+		o.maxMessages = n
+	}
+}
+
+// WithMaxBytes stops iterations before the first message that does not end within the
+// first b octets of the file, bounding the bytes read, e.g. downloaded over HTTP. Only
+// the Section 0 of that message is read to find its length, and none of it when it would
+// cross b; telling the end of the file from truncation reads at most one octet past b.
+// Iterations end without error; Truncated reports whether messages were left unread.
+// b <= 0 means no limit.
+func WithMaxBytes(b int64) Option {
+	return func(o *options) {
+		o.maxBytes = b
+	}
+}
+
+// errLimitReached stops the scan of a message that WithMaxBytes leaves unread
+var errLimitReached = errors.New("read limit reached")
+
+// beyondMaxBytes reports whether a message of totalLength at offset ends past the
+// WithMaxBytes limit
+func (o options) beyondMaxBytes(offset int64, totalLength uint64) bool {
+	return o.maxBytes > 0 && (offset > o.maxBytes || totalLength > uint64(o.maxBytes-offset))
+}
+
+// Truncated reports whether the last iteration was stopped by WithMaxMessages or
+// WithMaxBytes with messages left unread
+func (r *ReaderAt) Truncated() bool {
+	return r.truncated.Load()
+}
+
+// limitReached reports whether WithMaxMessages or WithMaxBytes stops the iteration before
+// the message with the given index at offset, recording whether the file holds more
+func (r *ReaderAt) limitReached(messageIndex int, offset int64) (bool, error) {
+	if (r.opts.maxMessages <= 0 || messageIndex < r.opts.maxMessages) &&
+		!r.opts.beyondMaxBytes(offset, 16) {
+		return false, nil
+	}
+
+	next := make([]byte, 1)
+	_, err := r.reader.ReadAt(next, offset)
+	switch {
+	case err == io.EOF:
+		return true, nil
+	case err != nil:
+		return true, err
+	}
+	r.truncated.Store(true)
+	return true, nil
+}
+
+// Truncated reports whether reading was stopped by WithMaxMessages or WithMaxBytes with
+// messages left unread
+func (r *Reader) Truncated() bool {
+	return r.truncated
+}
+
+// limitReached reports whether WithMaxMessages or WithMaxBytes stops the reading before
+// the next message, peeking at the stream to tell whether one follows
+func (r *Reader) limitReached() (bool, error) {
+	if r.opts.maxMessages > 0 && r.messagesRead >= r.opts.maxMessages || r.opts.beyondMaxBytes(r.offset, 16) {
+		next, err := r.peek(1)
+		if err != nil {
+			return true, err
+		}
+		r.truncated = len(next) > 0
+		return true, nil
+	}
+	if r.opts.maxBytes <= 0 {
+		return false, nil
+	}
+
+	header, err := r.peek(16)
+	if err != nil || len(header) < 16 || string(header[:4]) != "GRIB" {
+		return false, err // Left for ReadSection to report
+	}
+	if r.opts.beyondMaxBytes(r.offset, binary.BigEndian.Uint64(header[8:16])) {
+		r.truncated = true
+		return true, nil
+	}
+	return false, nil
+}
+
+// peek reads up to n octets of the stream without consuming them; fewer are returned at
+// the end of the stream
+func (r *Reader) peek(n int) ([]byte, error) {
+	buf := make([]byte, n)
+	read, err := io.ReadFull(r.Reader, buf)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		err = nil
+	}
+	if read > 0 {
+		r.Reader = io.MultiReader(bytes.NewReader(buf[:read]), r.Reader)
+	}
+	return buf[:read], err
+}
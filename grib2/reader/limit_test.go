@@ -0,0 +1,131 @@
+package reader_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/scorix/grib/grib2/internal/testgrib"
+	"github.com/scorix/grib/grib2/reader"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// extentReaderAt records the end of the furthest read
+type extentReaderAt struct {
+	io.ReaderAt
+	extent int64
+}
+
+func (r *extentReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	n, err := r.ReaderAt.ReadAt(p, off)
+	r.extent = max(r.extent, off+int64(n))
+	return n, err
+}
+
+// countReader counts the bytes read
+type countReader struct {
+	io.Reader
+	n int64
+}
+
+func (r *countReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+// limitCorpus returns a file of four messages and the length of each
+func limitCorpus() (data []byte, length int64) {
+	for hours := range 4 {
+		data = append(data, testgrib.MustEncode(testgrib.Spec{ForecastHours: uint32(hours)})...)
+	}
+	return data, int64(len(data) / 4)
+}
+
+func TestWithMaxMessages(t *testing.T) {
+	data, length := limitCorpus()
+
+	t.Run("reader at", func(t *testing.T) {
+		src := &extentReaderAt{ReaderAt: bytes.NewReader(data)}
+		r := reader.NewReaderAt(src, reader.WithMaxMessages(2))
+
+		var count int
+		require.NoError(t, r.EachMessage(func(int, reader.MessageInfo) bool { count++; return true }))
+		assert.Equal(t, 2, count)
+		assert.True(t, r.Truncated())
+		assert.LessOrEqual(t, src.extent, 2*length+1)
+	})
+
+	t.Run("reader", func(t *testing.T) {
+		src := &countReader{Reader: bytes.NewReader(data)}
+		r := reader.NewReader(src, reader.WithMaxMessages(2))
+
+		var count int
+		require.NoError(t, r.EachFlatMessage(func(int, reader.FlatMessage) bool { count++; return true }))
+		assert.Equal(t, 2, count)
+		assert.True(t, r.Truncated())
+		assert.LessOrEqual(t, src.n, 2*length+1)
+	})
+
+	t.Run("whole file", func(t *testing.T) {
+		r := reader.NewReaderAt(bytes.NewReader(data), reader.WithMaxMessages(4))
+		var count int
+		require.NoError(t, r.EachMessage(func(int, reader.MessageInfo) bool { count++; return true }))
+		assert.Equal(t, 4, count)
+		assert.False(t, r.Truncated())
+
+		s := reader.NewReader(bytes.NewReader(data), reader.WithMaxMessages(4))
+		require.NoError(t, s.EachMessage(func(int, reader.MessageInfo) bool { return true }))
+		assert.False(t, s.Truncated())
+	})
+}
+
+func TestWithMaxBytes(t *testing.T) {
+	data, length := limitCorpus()
+	limit := 2*length + length/2 // Half way through the third message
+
+	t.Run("reader at", func(t *testing.T) {
+		src := &extentReaderAt{ReaderAt: bytes.NewReader(data)}
+		r := reader.NewReaderAt(src, reader.WithMaxBytes(limit))
+
+		var offsets []int64
+		require.NoError(t, r.EachMessage(func(_ int, info reader.MessageInfo) bool {
+			offsets = append(offsets, info.Offset)
+			return true
+		}))
+		assert.Equal(t, []int64{0, length}, offsets)
+		assert.True(t, r.Truncated())
+		assert.LessOrEqual(t, src.extent, limit)
+	})
+
+	t.Run("reader", func(t *testing.T) {
+		src := &countReader{Reader: bytes.NewReader(data)}
+		r := reader.NewReader(src, reader.WithMaxBytes(limit))
+
+		var count int
+		require.NoError(t, r.EachMessage(func(int, reader.MessageInfo) bool { count++; return true }))
+		assert.Equal(t, 2, count)
+		assert.True(t, r.Truncated())
+		assert.LessOrEqual(t, src.n, limit)
+	})
+
+	t.Run("limit within Section 0", func(t *testing.T) {
+		src := &extentReaderAt{ReaderAt: bytes.NewReader(data)}
+		r := reader.NewReaderAt(src, reader.WithMaxBytes(length+8))
+
+		var count int
+		require.NoError(t, r.EachMessage(func(int, reader.MessageInfo) bool { count++; return true }))
+		assert.Equal(t, 1, count)
+		assert.True(t, r.Truncated())
+		assert.LessOrEqual(t, src.extent, length+8)
+	})
+
+	t.Run("whole file", func(t *testing.T) {
+		r := reader.NewReaderAt(bytes.NewReader(data), reader.WithMaxBytes(int64(len(data))))
+		var count int
+		require.NoError(t, r.EachMessage(func(int, reader.MessageInfo) bool { count++; return true }))
+		assert.Equal(t, 4, count)
+		assert.False(t, r.Truncated())
+	})
+}
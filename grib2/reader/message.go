@@ -2,21 +2,34 @@ package reader
 
 import (
 	"encoding/binary"
+	"math"
+	"strings"
+	"time"
 
+	"github.com/scorix/grib/grib2/grid"
+	"github.com/scorix/grib/grib2/packing"
 	"github.com/scorix/grib/grib2/section"
 	"github.com/scorix/grib/grib2/spec"
 	"github.com/scorix/grib/grib2/template"
+	"github.com/scorix/grib/grib2/units"
 )
 
 // MessageInfo contains metadata about a GRIB2 message's location
 type MessageInfo struct {
 	Index       int           // Message index in file (0-based)
 	Offset      int64         // Start offset of the message (Section 0 start)
-	Length      uint64        // Total length of the message (from Section 0)
+	Length      uint64        // Total length of the message (from Section 0, or up to its end marker when they disagree)
 	Discipline  uint8         // Discipline code from Section 0
 	Edition     uint8         // GRIB edition from Section 0
 	Sections    []SectionInfo // All sections within this message
+	FirstField  int           // Number of Section 4s in the preceding messages of the file, from which the Index of its fields count
 	IsFlattened bool          // True if this is a flattened message (single data field)
+	Anomalies   []error       // Skipped sections that were duplicated or out of order, as *ErrUnexpectedSection, a disagreeing total length, as *ErrLengthMismatch, and a clipped Section 7, as *ErrSectionOverrun
+	Source      string        // Source label given with WithSource, empty by default
+
+	LonConvention grid.LonConvention // Range of the longitudes reported for the message, chosen with WithLonConvention
+
+	metrics Metrics // Collector given with WithMetrics, if any
 }
 
 // Message represents a complete GRIB2 message with reader-specific metadata
@@ -29,11 +42,20 @@ type Message struct {
 // with all relevant information extracted and easily accessible
 type FlatMessage struct {
 	// Basic message info
-	Index      int    // Sequential index in flattened list
+
+	// Index is the position of the Section 4 of the field among the Section 4s of the
+	// file. It is the same whichever reader or iteration method yields the field, with
+	// filtering, deduplication or limits, and skips the Section 4s of fields dropped for
+	// being out of order or incomplete.
+	Index int
+
 	Offset     int64  // Start offset of the original message
 	Length     uint64 // Total length of the original message
 	Discipline int    // Discipline code
 	Edition    int    // GRIB edition
+	Source     string // Source label given with WithSource, empty by default
+
+	LonConvention grid.LonConvention // Range of the longitudes reported for the field, chosen with WithLonConvention
 
 	// Identification information (from Section 1)
 	Centre                    int // Originating/generating centre
@@ -65,6 +87,11 @@ type FlatMessage struct {
 	Bitmap         section.Section6 // Section 6 - Bitmap (may be nil)
 	Data           section.Section7 // Section 7 - Data
 	End            section.Section8 // Section 8 - End
+	PreviousBitmap section.Section6 // Section 6 defining the bit-map reused by Bitmap with indicator 254 (may be nil)
+
+	sectionRanges map[uint8]ByteRange // Byte ranges of this field's sections, when known
+	metrics       Metrics             // Collector given with WithMetrics, if any
+	cache         *fieldCache         // Derived data built on first use, e.g. by BitmapIndex
 }
 
 // FlattenMessages converts a nested GRIB2 message into multiple flat messages
@@ -86,6 +113,10 @@ func (m *Message) FlattenMessages() []Message {
 						Edition:     m.Info.Edition,
 						Sections:    m.Info.Sections,
 						IsFlattened: true, // Mark as flattened message
+						Source:      m.Info.Source,
+
+						LonConvention: m.Info.LonConvention,
+						metrics:       m.Info.metrics,
 					},
 					Message: spec.Message{
 						Indicator:      m.Indicator,
@@ -114,17 +145,22 @@ func (m *Message) FlattenMessages() []Message {
 // Returns m*n*l flat messages where m=local blocks, n=grid blocks per local block, l=data fields per grid block
 func (m *Message) FlattenToFlatMessages() []FlatMessage {
 	var flatMessages []FlatMessage
+	ranges := fieldSectionRanges(m.Info.Sections)
 
 	for _, localBlock := range m.Blocks {
 		for _, gridBlock := range localBlock.Grids {
 			for _, dataField := range gridBlock.Fields {
 				flatMsg := FlatMessage{
 					// Basic info
-					Index:      len(flatMessages),
+					Index:      m.Info.FirstField + len(flatMessages),
 					Offset:     m.Info.Offset,
 					Length:     m.Info.Length,
 					Discipline: int(m.Info.Discipline),
 					Edition:    int(m.Info.Edition),
+					Source:     m.Info.Source,
+
+					LonConvention: m.Info.LonConvention,
+					metrics:       m.Info.metrics,
 
 					// Raw sections
 					Indicator:      m.Indicator,
@@ -136,14 +172,26 @@ func (m *Message) FlattenToFlatMessages() []FlatMessage {
 					Bitmap:         dataField.Bitmap,
 					Data:           dataField.Data,
 					End:            m.End,
+					PreviousBitmap: dataField.PreviousBitmap,
+				}
+
+				if len(flatMessages) < len(ranges) {
+					flatMsg.sectionRanges = ranges[len(flatMessages)]
+					flatMsg.Index = fieldIndex(m.Info, flatMsg.sectionRanges[4].Offset)
 				}
 
+				start := startTimer(flatMsg.metrics)
+
 				// Extract fields from Section 4 (Product Definition)
 				flatMsg.extractProductInfo()
 
 				// Extract fields from Section 3 (Grid Definition)
 				flatMsg.extractGridInfo()
 
+				if flatMsg.metrics != nil {
+					flatMsg.metrics.TemplatesExtracted(time.Since(start))
+				}
+
 				flatMessages = append(flatMessages, flatMsg)
 			}
 		}
@@ -155,7 +203,7 @@ func (m *Message) FlattenToFlatMessages() []FlatMessage {
 // extractProductInfo extracts product-related information from Section 4
 func (f *FlatMessage) extractProductInfo() {
 	// Extract basic fields from Section 4
-	f.Product.TemplateNumber = uint16(f.ProductDef.ProductDefinitionTemplateNumber())
+	f.Product.TemplateNumber = f.ProductDef.ProductDefinitionTemplateNumber()
 
 	// Extract from Section 1 (Identification)
 	f.Centre = int(f.Identification.OriginatingCenter())
@@ -190,68 +238,46 @@ func (f *FlatMessage) extractProductInfo() {
 	// f.ForecastTime = extractForecastTime(f.ProductDef)
 }
 
-// extractGridInfo extracts grid-related information from Section 3
+// extractGridInfo extracts grid-related information from Section 3, along with the
+// product and data representation template fields of the sections that are present
 func (f *FlatMessage) extractGridInfo() {
 	// Extract basic fields from Section 3
-	f.Grid.SourceOfGridDefinition = int(f.GridDef.GridDefinitionSource())
-	f.Grid.NumberOfDataPoints = int(f.GridDef.NumberOfDataPoints())
-	f.Grid.NumberOfOctectsForOptional = int(f.GridDef.OptionalListOctets())
-	f.Grid.InterpretationOfOptional = int(f.GridDef.OptionalListInterpretation())
-	f.Grid.TemplateNumber = int(f.GridDef.GridDefinitionTemplateNumber())
-
-	// Extract from Section 5 (Data Representation)
-	f.DataRep.TemplateNumber = int(f.DataRepSec.DataRepresentationTemplateNumber())
-
-	// TODO: Extract detailed grid definition template fields
-	// This requires parsing the raw gridDefinitionTemplate bytes
-	// For common templates like lat/lon regular grids (template 0):
-	// - Scanning Mode (usually octet 72 in template)
-	// - Number of points along parallels/meridians (octets 31-34, 35-38)
-	// - Latitude/Longitude of first/last grid points (octets 47-50, 51-54, 56-59, 60-63)
-	// - Direction increments (octets 64-67, 68-71)
-
-	// These would need to be extracted based on the template structure:
-	// f.ScanningMode = extractScanningMode(f.GridDef)
-	// f.NumberOfGridPointsAlongX = extractGridPointsX(f.GridDef)
-	// f.NumberOfGridPointsAlongY = extractGridPointsY(f.GridDef)
-	// f.LatitudeOfFirstGridPoint = extractFirstLatitude(f.GridDef)
-	// f.LongitudeOfFirstGridPoint = extractFirstLongitude(f.GridDef)
-	// f.XDirectionIncrement = extractXIncrement(f.GridDef)
-	// f.YDirectionIncrement = extractYIncrement(f.GridDef)
-
-	// TODO: Extract data representation template fields
-	// This requires parsing the raw dataRepresentationTemplate bytes
-	// For simple packing (template 0):
-	// - Reference value (octets 12-15)
-	// - Binary scale factor (octets 16-17)
-	// - Decimal scale factor (octets 18-19)
-	// - Number of bits for packing (octet 20)
-	// - Type of original field values (octet 21)
-
-	// f.ReferenceValue = extractReferenceValue(f.DataRep)
-	// f.BinaryScaleFactor = extractBinaryScaleFactor(f.DataRep)
-	// f.DecimalScaleFactor = extractDecimalScaleFactor(f.DataRep)
-	// f.NumberOfBitsUsedForData = extractNumberOfBits(f.DataRep)
+	if f.GridDef != nil {
+		f.Grid.SourceOfGridDefinition = int(f.GridDef.GridDefinitionSource())
+		f.Grid.NumberOfDataPoints = int(f.GridDef.NumberOfDataPoints())
+		f.Grid.NumberOfOctectsForOptional = int(f.GridDef.OptionalListOctets())
+		f.Grid.InterpretationOfOptional = int(f.GridDef.OptionalListInterpretation())
+		f.Grid.TemplateNumber = int(f.GridDef.GridDefinitionTemplateNumber())
+	}
 
 	// Extract some basic template fields if we can access raw template data
 	f.extractProductTemplate()
 	f.extractGridTemplate()
+	f.extractDataRepInfo()
+}
+
+// extractDataRepInfo extracts data representation information from Section 5
+func (f *FlatMessage) extractDataRepInfo() {
+	if f.DataRepSec == nil {
+		return
+	}
+
+	f.DataRep.TemplateNumber = int(f.DataRepSec.DataRepresentationTemplateNumber())
 	f.extractDataRepTemplate()
 }
 
 // extractProductTemplate extracts common fields from product definition template
 func (f *FlatMessage) extractProductTemplate() {
-	// Use reflection or type assertion to access the underlying implementation
-	// This is a bit of a hack, but allows us to extract template data
-	if sec4, ok := f.ProductDef.(interface{ productDefinitionTemplate() []byte }); ok {
-		template := sec4.productDefinitionTemplate()
-		f.extractFromProductTemplate(template, int(f.ProductDef.ProductDefinitionTemplateNumber()))
+	if f.ProductDef == nil {
+		return
 	}
+
+	f.extractFromProductTemplate(f.ProductDef.ProductDefinitionTemplate(), int(f.ProductDef.ProductDefinitionTemplateNumber()))
 }
 
 // extractFromProductTemplate extracts fields from the raw product definition template bytes
 func (f *FlatMessage) extractFromProductTemplate(templateData []byte, templateNumber int) {
-	if len(templateData) < 34 { // Minimum length for most templates
+	if len(templateData) < 25 { // Minimum length for most templates (octets 10-34)
 		return
 	}
 
@@ -273,6 +299,20 @@ func (f *FlatMessage) extractFromProductTemplate(templateData []byte, templateNu
 		f.Product.TypeOfGeneratingProcess = uint8(templateData[2])
 	}
 
+	// Radar products have their own layout past the type of generating process
+	if templateNumber != radarProductTemplate {
+		f.extractForecastAndSurfaces(templateData)
+	}
+
+	// Template-specific fields
+	if parse, ok := productTemplateParsers[uint16(templateNumber)]; ok {
+		parse(f, templateData)
+	}
+}
+
+// extractForecastAndSurfaces extracts the generating process identifiers, forecast time and
+// fixed surfaces shared by most product templates (octets 13-34)
+func (f *FlatMessage) extractForecastAndSurfaces(templateData []byte) {
 	// Background Process (octet 13 of template = octet 3 of template data)
 	if len(templateData) > 3 {
 		f.Product.BackgroundProcess = uint8(templateData[3])
@@ -310,7 +350,7 @@ func (f *FlatMessage) extractFromProductTemplate(templateData []byte, templateNu
 
 	// Scale factor of first fixed surface (octet 24 of template = octet 14 of template data)
 	if len(templateData) > 14 {
-		f.Product.ScaleFactorOfFirstFixedSurface = int8(templateData[14]) // Signed
+		f.Product.ScaleFactorOfFirstFixedSurface = units.SignMagnitudeInt8(templateData[14])
 	}
 
 	// Scaled value of first fixed surface (octets 25-28 of template = octets 15-18 of template data)
@@ -325,7 +365,7 @@ func (f *FlatMessage) extractFromProductTemplate(templateData []byte, templateNu
 
 	// Scale factor of second fixed surface (octet 30 of template = octet 20 of template data)
 	if len(templateData) > 20 {
-		f.Product.ScaleFactorOfSecondFixedSurface = int8(templateData[20]) // Signed
+		f.Product.ScaleFactorOfSecondFixedSurface = units.SignMagnitudeInt8(templateData[20])
 	}
 
 	// Scaled value of second fixed surface (octets 31-34 of template = octets 21-24 of template data)
@@ -334,89 +374,328 @@ func (f *FlatMessage) extractFromProductTemplate(templateData []byte, templateNu
 	}
 }
 
-// extractGridTemplate extracts common fields from grid definition template
-func (f *FlatMessage) extractGridTemplate() {
-	// Access the underlying implementation to get raw template data
-	if sec3, ok := f.GridDef.(interface{ gridDefinitionTemplate() []byte }); ok {
-		template := sec3.gridDefinitionTemplate()
-		f.extractFromGridTemplate(template, int(f.GridDef.GridDefinitionTemplateNumber()))
-	}
+// productTemplateParsers extract the template-specific fields of product definition templates,
+// keyed by template number. Fields shared by all templates are extracted beforehand.
+var productTemplateParsers = map[uint16]func(f *FlatMessage, templateData []byte){
+	0: func(*FlatMessage, []byte) {}, // Analysis or forecast at a horizontal level: shared fields only
+	1: (*FlatMessage).extractEnsembleProduct,
+	2: (*FlatMessage).extractDerivedProduct,
+	3: (*FlatMessage).extractRectangularClusterProduct,
+	4: (*FlatMessage).extractCircularClusterProduct,
+	5: (*FlatMessage).extractProbabilityProduct,
+	6: (*FlatMessage).extractPercentileProduct,
+	7: func(*FlatMessage, []byte) {}, // Analysis or forecast error: shared fields only
+
+	// Statistically processed products: at a horizontal level (4.8), probability (4.9),
+	// percentile (4.10), individual ensemble (4.11) and derived forecasts based on all
+	// members (4.12) or a cluster over a rectangular (4.13) or circular (4.14) area
+	8:  statisticalProduct(25),
+	9:  productParsers((*FlatMessage).extractProbabilityProduct, statisticalProduct(38)),
+	10: productParsers((*FlatMessage).extractPercentileProduct, statisticalProduct(26)),
+	11: productParsers((*FlatMessage).extractEnsembleProduct, statisticalProduct(28)),
+	12: productParsers((*FlatMessage).extractDerivedProduct, statisticalProduct(27)),
+	13: productParsers((*FlatMessage).extractRectangularClusterProduct, statisticalProduct(59)),
+	14: productParsers((*FlatMessage).extractCircularClusterProduct, statisticalProduct(56)),
+	15: (*FlatMessage).extractSpatialProduct,
+	20: (*FlatMessage).extractRadarProduct,
 }
 
-// extractFromGridTemplate extracts fields from the raw grid definition template bytes
-func (f *FlatMessage) extractFromGridTemplate(templateData []byte, templateNumber int) {
-	// Template 0: Latitude/longitude (or equidistant cylindrical, or Plate Carree)
-	// This is the most common template, let's implement it fully
-	if templateNumber == 0 && len(templateData) >= 72 {
-		// Shape of the Earth (octet 15 of section 3 = octet 1 of template)
-		// We could extract this but it's not in our FlatMessage struct
-
-		// Create LatLonGrid template for template 0
-		if f.Grid.LatLon == nil {
-			f.Grid.LatLon = &template.LatLonGrid{}
-		}
+// radarProductTemplate is the number of the radar product template, whose layout departs from
+// the shared fields after the type of generating process
+const radarProductTemplate = 20
 
-		// Number of points along a parallel (octets 31-34 = octets 17-20 of template)
-		if len(templateData) >= 20 {
-			f.Grid.LatLon.NumberOfGridPointsAlongX = binary.BigEndian.Uint32(templateData[16:20])
+// productParsers returns the parser of a product template made of the blocks that parsers
+// extract, in order
+func productParsers(parsers ...func(f *FlatMessage, templateData []byte)) func(f *FlatMessage, templateData []byte) {
+	return func(f *FlatMessage, templateData []byte) {
+		for _, parse := range parsers {
+			parse(f, templateData)
 		}
+	}
+}
 
-		// Number of points along a meridian (octets 35-38 = octets 21-24 of template)
-		if len(templateData) >= 24 {
-			f.Grid.LatLon.NumberOfGridPointsAlongY = binary.BigEndian.Uint32(templateData[20:24])
-		}
+// extractEnsembleProduct extracts the ensemble forecast block of templates 4.1 and 4.11
+// (individual ensemble forecast, control and perturbed)
+func (f *FlatMessage) extractEnsembleProduct(templateData []byte) {
+	// Octets 35-37 (octets 25-27 of template data)
+	if len(templateData) < 28 {
+		return
+	}
+	f.Product.Ensemble = &template.EnsembleInfo{
+		TypeOfEnsembleForecast:      templateData[25],
+		PerturbationNumber:          templateData[26],
+		NumberOfForecastsInEnsemble: templateData[27],
+	}
+}
 
-		// Latitude of first grid point (octets 47-50 = octets 33-36 of template)
-		if len(templateData) >= 36 {
-			f.Grid.LatLon.LatitudeOfFirstGridPoint = int32(binary.BigEndian.Uint32(templateData[32:36]))
-		}
+// extractProbabilityProduct extracts the probability block of templates 4.5 and 4.9
+// (probability forecasts). Limits coded as missing, such as the upper limit of a
+// probability above a threshold, have NaN values.
+func (f *FlatMessage) extractProbabilityProduct(templateData []byte) {
+	// Octets 35-47 (octets 25-37 of template data)
+	if len(templateData) < 38 {
+		return
+	}
+	probability := &template.ProbabilityInfo{
+		ForecastProbabilityNumber:          templateData[25],
+		TotalNumberOfForecastProbabilities: templateData[26],
+		ProbabilityType:                    templateData[27],
+		ScaleFactorOfLowerLimit:            units.SignMagnitudeInt8(templateData[28]),
+		ScaledValueOfLowerLimit:            binary.BigEndian.Uint32(templateData[29:33]),
+		ScaleFactorOfUpperLimit:            units.SignMagnitudeInt8(templateData[33]),
+		ScaledValueOfUpperLimit:            binary.BigEndian.Uint32(templateData[34:38]),
+		LowerLimitValue:                    math.NaN(),
+		UpperLimitValue:                    math.NaN(),
+	}
+	if limit, ok := probability.LowerLimit(); ok {
+		probability.LowerLimitValue = limit
+	}
+	if limit, ok := probability.UpperLimit(); ok {
+		probability.UpperLimitValue = limit
+	}
+	f.Product.Probability = probability
+}
 
-		// Longitude of first grid point (octets 51-54 = octets 37-40 of template)
-		if len(templateData) >= 40 {
-			f.Grid.LatLon.LongitudeOfFirstGridPoint = binary.BigEndian.Uint32(templateData[36:40])
-		}
+// extractPercentileProduct extracts the percentile value of templates 4.6 and 4.10
+// (percentile forecasts)
+func (f *FlatMessage) extractPercentileProduct(templateData []byte) {
+	// Octet 35 (octet 25 of template data)
+	if len(templateData) < 26 {
+		return
+	}
+	f.Product.Percentile = &template.PercentileInfo{PercentileValue: templateData[25]}
+}
 
-		// Latitude of last grid point (octets 56-59 = octets 42-45 of template)
-		if len(templateData) >= 45 {
-			f.Grid.LatLon.LatitudeOfLastGridPoint = int32(binary.BigEndian.Uint32(templateData[41:45]))
-		}
+// extractDerivedProduct extracts the derived forecast block of templates 4.2 and 4.12
+// (derived forecasts based on all ensemble members)
+func (f *FlatMessage) extractDerivedProduct(templateData []byte) {
+	// Octets 35-36 (octets 25-26 of template data)
+	if len(templateData) < 27 {
+		return
+	}
+	f.Product.Derived = derivedInfo(templateData)
+}
 
-		// Longitude of last grid point (octets 60-63 = octets 46-49 of template)
-		if len(templateData) >= 49 {
-			f.Grid.LatLon.LongitudeOfLastGridPoint = binary.BigEndian.Uint32(templateData[45:49])
-		}
+// extractRectangularClusterProduct extracts the derived forecast block of templates 4.3
+// and 4.13 (derived forecasts based on a cluster of ensemble members over a rectangular
+// area)
+func (f *FlatMessage) extractRectangularClusterProduct(templateData []byte) {
+	// Octets 35-68 (octets 25-58 of template data)
+	if len(templateData) < 59 {
+		return
+	}
+	d := derivedInfo(templateData)
+	d.ClusterIdentifier = templateData[27]
+	d.ClusterOfHighResolutionControl = templateData[28]
+	d.ClusterOfLowResolutionControl = templateData[29]
+	d.NumberOfClustersOfEnsemble = templateData[30]
+	d.ClusteringMethod = templateData[31]
+	d.NorthernLatitudeOfCluster = units.SignMagnitudeInt32(binary.BigEndian.Uint32(templateData[32:36]))
+	d.SouthernLatitudeOfCluster = units.SignMagnitudeInt32(binary.BigEndian.Uint32(templateData[36:40]))
+	d.EasternLongitudeOfCluster = binary.BigEndian.Uint32(templateData[40:44])
+	d.WesternLongitudeOfCluster = binary.BigEndian.Uint32(templateData[44:48])
+	d.NumberOfForecastsInCluster = templateData[48]
+	d.ScaleFactorOfStandardDeviation = units.SignMagnitudeInt8(templateData[49])
+	d.ScaledValueOfStandardDeviation = binary.BigEndian.Uint32(templateData[50:54])
+	d.ScaleFactorOfDistanceFromMean = units.SignMagnitudeInt8(templateData[54])
+	d.ScaledValueOfDistanceFromMean = binary.BigEndian.Uint32(templateData[55:59])
+	f.Product.Derived = d
+}
 
-		// i direction increment (octets 64-67 = octets 50-53 of template)
-		if len(templateData) >= 53 {
-			f.Grid.LatLon.XDirectionIncrement = binary.BigEndian.Uint32(templateData[49:53])
-		}
+// extractCircularClusterProduct extracts the derived forecast block of templates 4.4 and
+// 4.14 (derived forecasts based on a cluster of ensemble members over a circular area)
+func (f *FlatMessage) extractCircularClusterProduct(templateData []byte) {
+	// Octets 35-65 (octets 25-55 of template data)
+	if len(templateData) < 56 {
+		return
+	}
+	d := derivedInfo(templateData)
+	d.ClusterIdentifier = templateData[27]
+	d.ClusterOfHighResolutionControl = templateData[28]
+	d.ClusterOfLowResolutionControl = templateData[29]
+	d.NumberOfClustersOfEnsemble = templateData[30]
+	d.ClusteringMethod = templateData[31]
+	d.LatitudeOfClusterCentre = units.SignMagnitudeInt32(binary.BigEndian.Uint32(templateData[32:36]))
+	d.LongitudeOfClusterCentre = binary.BigEndian.Uint32(templateData[36:40])
+	d.ScaleFactorOfClusterRadius = units.SignMagnitudeInt8(templateData[40])
+	d.ScaledValueOfClusterRadius = binary.BigEndian.Uint32(templateData[41:45])
+	d.NumberOfForecastsInCluster = templateData[45]
+	d.ScaleFactorOfStandardDeviation = units.SignMagnitudeInt8(templateData[46])
+	d.ScaledValueOfStandardDeviation = binary.BigEndian.Uint32(templateData[47:51])
+	d.ScaleFactorOfDistanceFromMean = units.SignMagnitudeInt8(templateData[51])
+	d.ScaledValueOfDistanceFromMean = binary.BigEndian.Uint32(templateData[52:56])
+	f.Product.Derived = d
+}
 
-		// j direction increment (octets 68-71 = octets 54-57 of template)
-		if len(templateData) >= 57 {
-			f.Grid.LatLon.YDirectionIncrement = binary.BigEndian.Uint32(templateData[53:57])
-		}
+// derivedInfo returns the type of derived forecast and number of forecasts shared by the
+// derived forecast templates (octets 35-36), with the fields of the clusters set to missing
+func derivedInfo(templateData []byte) *template.DerivedInfo {
+	const missing8, missing32 = 0xff, 0xffffffff
+	return &template.DerivedInfo{
+		DerivedForecastType:            templateData[25],
+		NumberOfForecastsInEnsemble:    templateData[26],
+		ClusterIdentifier:              missing8,
+		ClusterOfHighResolutionControl: missing8,
+		ClusterOfLowResolutionControl:  missing8,
+		NumberOfClustersOfEnsemble:     missing8,
+		ClusteringMethod:               missing8,
+		NorthernLatitudeOfCluster:      units.SignMagnitudeInt32(missing32),
+		SouthernLatitudeOfCluster:      units.SignMagnitudeInt32(missing32),
+		EasternLongitudeOfCluster:      missing32,
+		WesternLongitudeOfCluster:      missing32,
+		LatitudeOfClusterCentre:        units.SignMagnitudeInt32(missing32),
+		LongitudeOfClusterCentre:       missing32,
+		ScaleFactorOfClusterRadius:     units.SignMagnitudeInt8(missing8),
+		ScaledValueOfClusterRadius:     missing32,
+		NumberOfForecastsInCluster:     missing8,
+		ScaleFactorOfStandardDeviation: units.SignMagnitudeInt8(missing8),
+		ScaledValueOfStandardDeviation: missing32,
+		ScaleFactorOfDistanceFromMean:  units.SignMagnitudeInt8(missing8),
+		ScaledValueOfDistanceFromMean:  missing32,
+		ScaleFactorOfCentralWaveNumber: units.SignMagnitudeInt8(missing8),
+		ScaledValueOfCentralWaveNumber: missing32,
+	}
+}
 
-		// Scanning mode (octet 72 = octet 58 of template)
-		if len(templateData) >= 58 {
-			f.Grid.LatLon.ScanningMode = templateData[57]
-		}
+// statisticalProduct returns the parser of templates 4.8 to 4.14 (average, accumulation,
+// extreme values or other statistically processed values), which follow the fields of
+// their instantaneous counterparts, 4.0 to 4.4, with the overall time interval and time
+// range specifications from the given template data offset: octet 35 for 4.8, after the
+// ensemble, probability, percentile or derived forecast block for the others.
+func statisticalProduct(start int) func(f *FlatMessage, templateData []byte) {
+	return func(f *FlatMessage, templateData []byte) {
+		f.Product.TimeRange = extractTimeRange(templateData, start)
+	}
+}
+
+// extractSpatialProduct extracts template 4.15 (average, accumulation, extreme values or
+// other statistically processed values over a spatial area at a horizontal level)
+func (f *FlatMessage) extractSpatialProduct(templateData []byte) {
+	// Spatial processing block at octets 35-37 (octets 25-27 of template data)
+	if len(templateData) < 28 {
+		return
+	}
+	f.Product.Spatial = &template.SpatialProcessingInfo{
+		StatisticalProcess: templateData[25],
+		SpatialProcessType: templateData[26],
+		NumberOfPoints:     templateData[27],
+	}
+}
+
+// extractRadarProduct extracts template 4.20 (radar product). The fixed surfaces are set to
+// missing, as the template has none.
+func (f *FlatMessage) extractRadarProduct(templateData []byte) {
+	// Octets 14-43 (octets 4-33 of template data)
+	if len(templateData) < 34 {
+		return
 	}
 
-	// For other templates, we'd add similar extraction logic
-	// Template 1: Rotated latitude/longitude
-	// Template 10: Mercator
-	// Template 20: Polar stereographic projection
-	// Template 30: Lambert conformal
-	// etc.
+	f.Product.IndicatorOfUnitOfTimeRange = templateData[4]
+	f.Product.TypeOfFirstFixedSurface = 0xff
+	f.Product.TypeOfSecondFixedSurface = 0xff
+	f.Product.Radar = &template.RadarInfo{
+		NumberOfRadarSites:      templateData[3],
+		SiteLatitude:            units.SignMagnitudeInt32(binary.BigEndian.Uint32(templateData[5:9])),
+		SiteLongitude:           units.SignMagnitudeInt32(binary.BigEndian.Uint32(templateData[9:13])),
+		SiteElevation:           binary.BigEndian.Uint16(templateData[13:15]),
+		SiteID:                  strings.TrimRight(string(templateData[15:19]), " \x00"),
+		SiteNumber:              binary.BigEndian.Uint16(templateData[19:21]),
+		OperatingMode:           templateData[21],
+		ReflectivityCalibration: templateData[22],
+		QualityControl:          templateData[23],
+		ClutterFilter:           templateData[24],
+		ElevationAngle:          templateData[25],
+		AccumulationInterval:    binary.BigEndian.Uint16(templateData[26:28]),
+		EchoTopReflectivity:     templateData[28],
+		RangeResolution:         uint32(templateData[29])<<16 | uint32(binary.BigEndian.Uint16(templateData[30:32])),
+		RadialAngularSpacing:    binary.BigEndian.Uint16(templateData[32:34]),
+	}
+}
+
+// extractTimeRange extracts the overall time interval and time range specifications
+// of a statistically processed product template, starting at the given template data offset
+func extractTimeRange(templateData []byte, start int) *template.TimeRangeInfo {
+	// End of overall time interval (7 octets), number of time ranges (1 octet),
+	// number of missing values (4 octets), then 12 octets per time range specification
+	if len(templateData) < start+12 {
+		return nil
+	}
+
+	data := templateData[start:]
+	timeRange := &template.TimeRangeInfo{
+		EndYear:               binary.BigEndian.Uint16(data[0:2]),
+		EndMonth:              data[2],
+		EndDay:                data[3],
+		EndHour:               data[4],
+		EndMinute:             data[5],
+		EndSecond:             data[6],
+		NumberOfTimeRanges:    uint16(data[7]),
+		NumberOfMissingValues: binary.BigEndian.Uint32(data[8:12]),
+	}
+
+	specs := data[12:]
+	for i := 0; i < int(timeRange.NumberOfTimeRanges) && len(specs) >= 12; i++ {
+		timeRange.TimeRanges = append(timeRange.TimeRanges, template.TimeRangeSpec{
+			StatisticalProcessType:          specs[0],
+			TimeIncrementType:               specs[1],
+			IndicatorOfUnitForTimeRange:     specs[2],
+			TimeRangeLength:                 binary.BigEndian.Uint32(specs[3:7]),
+			IndicatorOfUnitForTimeIncrement: specs[7],
+			TimeIncrement:                   binary.BigEndian.Uint32(specs[8:12]),
+		})
+		specs = specs[12:]
+	}
+
+	// Expose the outermost time range directly for convenience
+	if len(timeRange.TimeRanges) > 0 {
+		outer := timeRange.TimeRanges[0]
+		timeRange.TypeOfStatisticalProcessing = outer.StatisticalProcessType
+		timeRange.TypeOfTimeIncrement = outer.TimeIncrementType
+		timeRange.IndicatorOfUnitForTimeRange = outer.IndicatorOfUnitForTimeRange
+		timeRange.LengthOfTimeRange = outer.TimeRangeLength
+		timeRange.IndicatorOfUnitForTimeIncrement = outer.IndicatorOfUnitForTimeIncrement
+		timeRange.TimeIncrement = outer.TimeIncrement
+	}
+
+	return timeRange
+}
+
+// extractGridTemplate extracts common fields from grid definition template
+func (f *FlatMessage) extractGridTemplate() {
+	// The template bytes of centre-defined or absent grids do not follow a WMO template
+	if f.GridDef == nil || !f.Grid.HasTemplate() {
+		return
+	}
+
+	f.extractFromGridTemplate(f.GridDef.GridDefinitionTemplate(), int(f.GridDef.GridDefinitionTemplateNumber()))
+}
+
+// extractFromGridTemplate extracts fields from the raw grid definition template bytes.
+// Templates without a parser, or too short for theirs, keep the common fields only.
+func (f *FlatMessage) extractFromGridTemplate(templateData []byte, templateNumber int) {
+	parsed, err := template.ParseGridTemplate(uint16(templateNumber), templateData)
+	if err != nil {
+		return
+	}
+	parsed.SourceOfGridDefinition = f.Grid.SourceOfGridDefinition
+	parsed.NumberOfDataPoints = f.Grid.NumberOfDataPoints
+	parsed.NumberOfOctectsForOptional = f.Grid.NumberOfOctectsForOptional
+	parsed.InterpretationOfOptional = f.Grid.InterpretationOfOptional
+	f.Grid = *parsed
+
+	// The points along each parallel of a reduced Gaussian grid follow the template
+	if g := f.Grid.Gaussian; g != nil && g.Reduced() && f.GridDef.OptionalListInterpretation() == 1 {
+		g.PointsPerParallel = f.GridDef.OptionalList()
+	}
 }
 
 // extractDataRepTemplate extracts common fields from data representation template
 func (f *FlatMessage) extractDataRepTemplate() {
-	// Access the underlying implementation to get raw template data
-	if sec5, ok := f.DataRepSec.(interface{ dataRepresentationTemplate() []byte }); ok {
-		template := sec5.dataRepresentationTemplate()
-		f.extractFromDataRepTemplate(template, f.DataRep.TemplateNumber)
+	if f.DataRepSec == nil {
+		return
 	}
+
+	f.extractFromDataRepTemplate(f.DataRepSec.DataRepresentationTemplate(), f.DataRep.TemplateNumber)
 }
 
 // extractFromDataRepTemplate extracts fields from the raw data representation template bytes
@@ -426,21 +705,21 @@ func (f *FlatMessage) extractFromDataRepTemplate(templateData []byte, templateNu
 	// Template 3: Grid point data - complex packing and spatial differencing
 	// Most templates share the first few fields
 
-	if len(templateData) >= 21 { // Minimum length for most templates
-		// Reference value (octets 12-15 = octets 0-3 of template)
+	if len(templateData) >= 10 { // Minimum length for most templates (octets 12-21)
+		// Reference value (octets 12-15 = octets 0-3 of template, IEEE 32-bit float)
 		if len(templateData) >= 4 {
 			refRaw := binary.BigEndian.Uint32(templateData[0:4])
-			f.DataRep.ReferenceValue = float64(refRaw) // This is actually IEEE float, need proper conversion
+			f.DataRep.ReferenceValue = float64(math.Float32frombits(refRaw))
 		}
 
 		// Binary scale factor (octets 16-17 = octets 4-5 of template)
 		if len(templateData) >= 6 {
-			f.DataRep.BinaryScaleFactor = int16(binary.BigEndian.Uint16(templateData[4:6])) // Signed
+			f.DataRep.BinaryScaleFactor = units.SignMagnitudeInt16(binary.BigEndian.Uint16(templateData[4:6]))
 		}
 
 		// Decimal scale factor (octets 18-19 = octets 6-7 of template)
 		if len(templateData) >= 8 {
-			f.DataRep.DecimalScaleFactor = int16(binary.BigEndian.Uint16(templateData[6:8])) // Signed
+			f.DataRep.DecimalScaleFactor = units.SignMagnitudeInt16(binary.BigEndian.Uint16(templateData[6:8]))
 		}
 
 		// Number of bits used for each packed value (octet 20 = octet 8 of template)
@@ -455,19 +734,120 @@ func (f *FlatMessage) extractFromDataRepTemplate(templateData []byte, templateNu
 	}
 
 	// Template-specific fields
-	switch templateNumber {
-	case 2, 3: // Complex packing
-		if len(templateData) >= 31 {
-			// Group splitting method (octet 22 = octet 10 of template)
-			// Missing value management (octet 23 = octet 11 of template)
-			// Primary missing value substitute (octets 24-27 = octets 12-15 of template)
-			// Secondary missing value substitute (octets 28-31 = octets 16-19 of template)
-			// Number of groups of data values (octets 32-35 = octets 20-23 of template)
-
-			// For now, we don't have these fields in FlatMessage
-			// They could be added if needed for complex packing support
-		}
+	if parse, ok := dataRepTemplateParsers[uint16(templateNumber)]; ok {
+		parse(f, templateData)
+	}
+}
+
+// dataRepTemplateParsers extract the template-specific fields of data representation templates,
+// keyed by template number. Fields shared by all templates are extracted beforehand.
+var dataRepTemplateParsers = map[uint16]func(f *FlatMessage, templateData []byte){
+	0:     func(f *FlatMessage, _ []byte) { f.DataRep.Simple = &template.SimplePackingInfo{} }, // Simple packing: shared fields only
+	2:     (*FlatMessage).extractComplexPacking,                                                // Complex packing
+	3:     (*FlatMessage).extractSpatialDifferencing,                                           // Complex packing and spatial differencing
+	4:     (*FlatMessage).extractIEEEPacking,                                                   // IEEE floating point data
+	40:    (*FlatMessage).extractJPEG2000Packing,                                               // JPEG 2000 code stream format
+	41:    func(f *FlatMessage, _ []byte) { f.DataRep.PNG = &template.PNGPackingInfo{} },       // PNG: shared fields only
+	42:    (*FlatMessage).extractCCSDSPacking,                                                  // CCSDS recommended lossless compression
+	40000: (*FlatMessage).extractJPEG2000Packing,                                               // JPEG 2000 code stream format (NCEP local)
+	40010: func(f *FlatMessage, _ []byte) { f.DataRep.PNG = &template.PNGPackingInfo{} },       // PNG (NCEP local)
+}
+
+// extractIEEEPacking extracts the precision of template 5.4 (IEEE floating point data),
+// which has none of the fields shared by the other templates. The bits used for each value
+// are those of the precision.
+func (f *FlatMessage) extractIEEEPacking(templateData []byte) {
+	// Precision at octet 12 (octet 1 of template data)
+	if len(templateData) < 1 {
+		return
+	}
+
+	precision := templateData[0]
+	f.DataRep.IEEE = &template.IEEEPackingInfo{PrecisionOfFloatingPointNumbers: precision}
+	switch precision {
+	case packing.PrecisionSingle:
+		f.DataRep.NumberOfBitsUsedForData = 32
+	case packing.PrecisionDouble:
+		f.DataRep.NumberOfBitsUsedForData = 64
+	}
+}
+
+// extractJPEG2000Packing extracts the compression parameters of template 5.40 (JPEG 2000
+// code stream format), which NCEP's local template 5.40000 shares
+func (f *FlatMessage) extractJPEG2000Packing(templateData []byte) {
+	// Type of compression and target compression ratio at octets 22-23 (octets 11-12 of
+	// template data)
+	if len(templateData) < 12 {
+		return
+	}
+
+	f.DataRep.JPEG2000 = &template.JPEG2000PackingInfo{
+		CompressionType:        templateData[10],
+		TargetCompressionRatio: templateData[11],
+	}
+}
+
+// extractCCSDSPacking extracts the compression parameters of template 5.42 (CCSDS
+// recommended lossless compression)
+func (f *FlatMessage) extractCCSDSPacking(templateData []byte) {
+	// Compression options mask, block size and reference sample interval at octets 22-25
+	// (octets 11-14 of template data)
+	if len(templateData) < 14 {
+		return
+	}
+
+	f.DataRep.CCSDS = &template.CCSDSPackingInfo{
+		CCSDSFlags: templateData[10],
+		BlockSize:  templateData[11],
+		RSILength:  binary.BigEndian.Uint16(templateData[12:14]),
+	}
+}
+
+// extractSpatialDifferencing extracts template 5.3 (complex packing and spatial
+// differencing): the group parameters of template 5.2 followed by the differencing
+func (f *FlatMessage) extractSpatialDifferencing(templateData []byte) {
+	f.extractComplexPacking(templateData)
+
+	// Order of spatial differencing and octets of the extra descriptors at octets 48-49
+	// (octets 37-38 of template data)
+	if f.DataRep.Complex == nil || len(templateData) < 38 {
+		return
+	}
+	order, octets := templateData[36], templateData[37]
+	f.DataRep.Complex.OrderOfSpatialDifferencing = &order
+	f.DataRep.Complex.NumberOfOctetsExtraDescriptors = &octets
+}
+
+// extractComplexPacking extracts the group parameters of template 5.2 (complex packing)
+func (f *FlatMessage) extractComplexPacking(templateData []byte) {
+	// Group parameters end at octet 47 (octet 36 of template data)
+	if len(templateData) < 36 {
+		return
+	}
+
+	f.DataRep.Complex = &template.ComplexPackingInfo{
+		GroupSplittingMethod:            int(templateData[10]),
+		MissingValueManagement:          templateData[11],
+		PrimaryMissingValueSubstitute:   f.missingValueSubstitute(templateData[12:16]),
+		SecondaryMissingValueSubstitute: f.missingValueSubstitute(templateData[16:20]),
+		NumberOfGroupsOfDataValues:      binary.BigEndian.Uint32(templateData[20:24]),
+		ReferenceForGroupWidths:         templateData[24],
+		NumberOfBitsUsedForGroupWidths:  templateData[25],
+		ReferenceForGroupLengths:        binary.BigEndian.Uint32(templateData[26:30]),
+		LengthIncrementForGroupLengths:  templateData[30],
+		TrueLengthOfLastGroup:           binary.BigEndian.Uint32(templateData[31:35]),
+		NumberOfBitsUsedForGroupLengths: templateData[35],
+	}
+}
+
+// missingValueSubstitute reads a missing value substitute of complex packing, a floating
+// point number for floating point fields and an integer otherwise (Code Table 5.1)
+func (f *FlatMessage) missingValueSubstitute(b []byte) float32 {
+	bits := binary.BigEndian.Uint32(b)
+	if f.DataRep.TypeOfOriginalFieldValues == 0 {
+		return math.Float32frombits(bits)
 	}
+	return float32(bits)
 }
 
 // IsFlattened returns true if this message contains only a single data field
@@ -0,0 +1,322 @@
+package reader
+
+import (
+	"bufio"
+	"io"
+	"iter"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/scorix/grib/grib2/grid"
+)
+
+// MetadataRecord is a flat, column-friendly summary of a data field, suitable for
+// bulk-inserting message catalogues into SQL databases. Column names are given by the
+// db tags and are stable across releases; new columns are only ever appended.
+// Pointer fields are nil (NULL) when the value is missing or does not apply.
+type MetadataRecord struct {
+	// Location of the field, for ranged reads
+	FilePath      string `db:"file_path" json:"file_path"`           // Path or URL of the file holding the message
+	MessageOffset int64  `db:"message_offset" json:"message_offset"` // Start offset of the message in the file
+	MessageLength int64  `db:"message_length" json:"message_length"` // Total length of the message
+	FieldIndex    int    `db:"field_index" json:"field_index"`       // Sequential index of the field in the flattened file
+	FieldOffset   *int64 `db:"field_offset" json:"field_offset"`     // Start offset of Sections 4-7 of the field
+	FieldLength   *int64 `db:"field_length" json:"field_length"`     // Length of Sections 4-7 of the field
+
+	// Indicator and identification (Sections 0 and 1)
+	Discipline                int        `db:"discipline" json:"discipline"`                                   // Discipline (Code Table 0.0)
+	Edition                   int        `db:"edition" json:"edition"`                                         // GRIB edition number
+	Centre                    int        `db:"centre" json:"centre"`                                           // Originating centre (Common Code Table C-11)
+	SubCentre                 int        `db:"sub_centre" json:"sub_centre"`                                   // Originating sub-centre
+	MasterTablesVersion       int        `db:"master_tables_version" json:"master_tables_version"`             // Version of the master tables
+	LocalTablesVersion        int        `db:"local_tables_version" json:"local_tables_version"`               // Version of the local tables
+	ReferenceTimeSignificance int        `db:"reference_time_significance" json:"reference_time_significance"` // Code Table 1.2
+	ReferenceTime             *time.Time `db:"reference_time" json:"reference_time"`                           // Reference time, nil when invalid
+	ProductionStatus          int        `db:"production_status" json:"production_status"`                     // Code Table 1.3
+	TypeOfData                int        `db:"type_of_data" json:"type_of_data"`                               // Code Table 1.4
+
+	// Product definition (Section 4)
+	ProductTemplate         int        `db:"product_template" json:"product_template"`                     // Product definition template number
+	ParameterCategory       int        `db:"parameter_category" json:"parameter_category"`                 // Parameter category (Code Table 4.1)
+	ParameterNumber         int        `db:"parameter_number" json:"parameter_number"`                     // Parameter number (Code Table 4.2)
+	TypeOfGeneratingProcess int        `db:"type_of_generating_process" json:"type_of_generating_process"` // Code Table 4.3
+	GeneratingProcess       int        `db:"generating_process" json:"generating_process"`                 // Generating process or model identifier
+	GeneratingProcessName   string     `db:"generating_process_name" json:"generating_process_name"`       // Name of the generating process at the centre, empty when unknown
+	TimeUnit                int        `db:"time_unit" json:"time_unit"`                                   // Unit of the forecast time (Code Table 4.4)
+	ForecastTime            int64      `db:"forecast_time" json:"forecast_time"`                           // Forecast time in TimeUnit
+	Step                    string     `db:"step" json:"step"`                                             // wgrib2 style step, e.g. "6 hour fcst"
+	ValidTime               *time.Time `db:"valid_time" json:"valid_time"`                                 // End of the forecast step, nil when unknown
+	Level                   string     `db:"level" json:"level"`                                           // wgrib2 style level, e.g. "500 mb"
+	FirstSurfaceType        *int       `db:"first_surface_type" json:"first_surface_type"`                 // Code Table 4.5
+	FirstSurfaceValue       *float64   `db:"first_surface_value" json:"first_surface_value"`               // Value of the first fixed surface
+	SecondSurfaceType       *int       `db:"second_surface_type" json:"second_surface_type"`               // Code Table 4.5
+	SecondSurfaceValue      *float64   `db:"second_surface_value" json:"second_surface_value"`             // Value of the second fixed surface
+	StatisticalProcess      *int       `db:"statistical_process" json:"statistical_process"`               // Code Table 4.10, statistically processed products only
+	EnsembleType            *int       `db:"ensemble_type" json:"ensemble_type"`                           // Code Table 4.6, ensemble products only
+	PerturbationNumber      *int       `db:"perturbation_number" json:"perturbation_number"`               // Ensemble perturbation number
+	EnsembleSize            *int       `db:"ensemble_size" json:"ensemble_size"`                           // Number of forecasts in the ensemble
+
+	// Grid summary (Section 3)
+	GridTemplate   int      `db:"grid_template" json:"grid_template"`       // Grid definition template number
+	NumberOfPoints int      `db:"number_of_points" json:"number_of_points"` // Number of grid points
+	Ni             *int     `db:"ni" json:"ni"`                             // Points along the i axis, when the geometry is known
+	Nj             *int     `db:"nj" json:"nj"`                             // Points along the j axis, when the geometry is known
+	LatFirst       *float64 `db:"lat_first" json:"lat_first"`               // Latitude of the first grid point in degrees
+	LonFirst       *float64 `db:"lon_first" json:"lon_first"`               // Longitude of the first grid point in degrees, in the LonConvention of the field
+	LatLast        *float64 `db:"lat_last" json:"lat_last"`                 // Latitude of the last grid point in degrees
+	LonLast        *float64 `db:"lon_last" json:"lon_last"`                 // Longitude of the last grid point in degrees, in the LonConvention of the field
+
+	// Packing summary (Sections 5 and 6)
+	PackingTemplate int     `db:"packing_template" json:"packing_template"` // Data representation template number
+	NumberOfValues  int     `db:"number_of_values" json:"number_of_values"` // Number of packed values
+	ReferenceValue  float64 `db:"reference_value" json:"reference_value"`   // Reference value R
+	BinaryScale     int     `db:"binary_scale" json:"binary_scale"`         // Binary scale factor E
+	DecimalScale    int     `db:"decimal_scale" json:"decimal_scale"`       // Decimal scale factor D
+	BitsPerValue    int     `db:"bits_per_value" json:"bits_per_value"`     // Bits per packed value
+	BitmapIndicator int     `db:"bitmap_indicator" json:"bitmap_indicator"` // Bit-map indicator (Code Table 6.0)
+}
+
+// Metadata summarises the field as a MetadataRecord, located in the file at path.
+// An empty path falls back to the source label of the field.
+func (f *FlatMessage) Metadata(path string) MetadataRecord {
+	if path == "" {
+		path = f.Source
+	}
+	r := MetadataRecord{
+		FilePath:      path,
+		MessageOffset: f.Offset,
+		MessageLength: int64(f.Length),
+		FieldIndex:    f.Index,
+
+		Discipline:                f.Discipline,
+		Edition:                   f.Edition,
+		Centre:                    f.Centre,
+		SubCentre:                 f.SubCentre,
+		MasterTablesVersion:       f.MasterTablesVersion,
+		LocalTablesVersion:        f.LocalTablesVersion,
+		ReferenceTimeSignificance: f.ReferenceTimeSignificance,
+		ProductionStatus:          f.ProductionStatus,
+		TypeOfData:                f.TypeOfData,
+
+		ProductTemplate:         int(f.Product.TemplateNumber),
+		ParameterCategory:       int(f.Product.Category),
+		ParameterNumber:         int(f.Product.Parameter),
+		TypeOfGeneratingProcess: int(f.Product.TypeOfGeneratingProcess),
+		GeneratingProcess:       int(f.Product.GeneratingProcessIdentifier),
+		GeneratingProcessName:   f.GeneratingProcessName(),
+		TimeUnit:                int(f.Product.IndicatorOfUnitOfTimeRange),
+		ForecastTime:            int64(f.Product.ForecastTime),
+		Step:                    f.StepString(),
+		Level:                   f.LevelString(),
+
+		GridTemplate:   f.Grid.TemplateNumber,
+		NumberOfPoints: f.Grid.NumberOfDataPoints,
+
+		PackingTemplate: f.DataRep.TemplateNumber,
+		ReferenceValue:  f.DataRep.ReferenceValue,
+		BinaryScale:     int(f.DataRep.BinaryScaleFactor),
+		DecimalScale:    int(f.DataRep.DecimalScaleFactor),
+		BitsPerValue:    int(f.DataRep.NumberOfBitsUsedForData),
+	}
+
+	if field, ok := f.FieldByteRange(); ok {
+		r.FieldOffset, r.FieldLength = &field.Offset, &field.Length
+	}
+	if t, err := f.ReferenceTime(); err == nil {
+		r.ReferenceTime = &t
+	}
+	if t, err := f.ValidTime(); err == nil {
+		r.ValidTime = &t
+	}
+
+	if first, ok := f.Product.FirstSurface(); ok {
+		r.FirstSurfaceType = Ptr(int(first.Type))
+		if first.HasValue {
+			r.FirstSurfaceValue = &first.Value
+		}
+	}
+	if second, ok := f.Product.SecondSurface(); ok {
+		r.SecondSurfaceType = Ptr(int(second.Type))
+		if second.HasValue {
+			r.SecondSurfaceValue = &second.Value
+		}
+	}
+	if timeRange := f.Product.TimeRange; timeRange != nil && len(timeRange.TimeRanges) > 0 {
+		r.StatisticalProcess = Ptr(int(timeRange.TypeOfStatisticalProcessing))
+	}
+	if ensemble := f.Product.Ensemble; ensemble != nil {
+		r.EnsembleType = Ptr(int(ensemble.TypeOfEnsembleForecast))
+		r.PerturbationNumber = Ptr(int(ensemble.PerturbationNumber))
+		r.EnsembleSize = Ptr(int(ensemble.NumberOfForecastsInEnsemble))
+	}
+
+	if def, err := f.GridDefinition(); err == nil {
+		ni, nj := def.Dims()
+		r.Ni, r.Nj = &ni, &nj
+		if n := grid.NumberOfPoints(def); n > 0 {
+			latFirst, lonFirst := f.LonConvention.LatLonAt(def, 0)
+			latLast, lonLast := f.LonConvention.LatLonAt(def, n-1)
+			r.LatFirst, r.LonFirst = &latFirst, &lonFirst
+			r.LatLast, r.LonLast = &latLast, &lonLast
+		}
+	}
+
+	if f.DataRepSec != nil {
+		r.NumberOfValues = int(f.DataRepSec.NumberOfDataPoints())
+	}
+	r.BitmapIndicator = 255
+	if f.Bitmap != nil {
+		r.BitmapIndicator = int(f.Bitmap.BitMapIndicator())
+	}
+
+	return r
+}
+
+// metadataColumn formats one MetadataRecord column for TSV output
+type metadataColumn struct {
+	name   string
+	format func(r *MetadataRecord) string
+}
+
+// metadataColumns lists the TSV columns in output order, matching the db tags of MetadataRecord.
+// Columns are never reordered or removed; new columns are appended.
+var metadataColumns = []metadataColumn{
+	{"file_path", func(r *MetadataRecord) string { return formatString(r.FilePath) }},
+	{"message_offset", func(r *MetadataRecord) string { return formatInt(r.MessageOffset) }},
+	{"message_length", func(r *MetadataRecord) string { return formatInt(r.MessageLength) }},
+	{"field_index", func(r *MetadataRecord) string { return formatInt(r.FieldIndex) }},
+	{"field_offset", func(r *MetadataRecord) string { return formatOptional(r.FieldOffset, formatInt[int64]) }},
+	{"field_length", func(r *MetadataRecord) string { return formatOptional(r.FieldLength, formatInt[int64]) }},
+	{"discipline", func(r *MetadataRecord) string { return formatInt(r.Discipline) }},
+	{"edition", func(r *MetadataRecord) string { return formatInt(r.Edition) }},
+	{"centre", func(r *MetadataRecord) string { return formatInt(r.Centre) }},
+	{"sub_centre", func(r *MetadataRecord) string { return formatInt(r.SubCentre) }},
+	{"master_tables_version", func(r *MetadataRecord) string { return formatInt(r.MasterTablesVersion) }},
+	{"local_tables_version", func(r *MetadataRecord) string { return formatInt(r.LocalTablesVersion) }},
+	{"reference_time_significance", func(r *MetadataRecord) string { return formatInt(r.ReferenceTimeSignificance) }},
+	{"reference_time", func(r *MetadataRecord) string { return formatOptional(r.ReferenceTime, formatTime) }},
+	{"production_status", func(r *MetadataRecord) string { return formatInt(r.ProductionStatus) }},
+	{"type_of_data", func(r *MetadataRecord) string { return formatInt(r.TypeOfData) }},
+	{"product_template", func(r *MetadataRecord) string { return formatInt(r.ProductTemplate) }},
+	{"parameter_category", func(r *MetadataRecord) string { return formatInt(r.ParameterCategory) }},
+	{"parameter_number", func(r *MetadataRecord) string { return formatInt(r.ParameterNumber) }},
+	{"type_of_generating_process", func(r *MetadataRecord) string { return formatInt(r.TypeOfGeneratingProcess) }},
+	{"generating_process", func(r *MetadataRecord) string { return formatInt(r.GeneratingProcess) }},
+	{"generating_process_name", func(r *MetadataRecord) string { return formatString(r.GeneratingProcessName) }},
+	{"time_unit", func(r *MetadataRecord) string { return formatInt(r.TimeUnit) }},
+	{"forecast_time", func(r *MetadataRecord) string { return formatInt(r.ForecastTime) }},
+	{"step", func(r *MetadataRecord) string { return formatString(r.Step) }},
+	{"valid_time", func(r *MetadataRecord) string { return formatOptional(r.ValidTime, formatTime) }},
+	{"level", func(r *MetadataRecord) string { return formatString(r.Level) }},
+	{"first_surface_type", func(r *MetadataRecord) string { return formatOptional(r.FirstSurfaceType, formatInt[int]) }},
+	{"first_surface_value", func(r *MetadataRecord) string { return formatOptional(r.FirstSurfaceValue, formatFloat) }},
+	{"second_surface_type", func(r *MetadataRecord) string { return formatOptional(r.SecondSurfaceType, formatInt[int]) }},
+	{"second_surface_value", func(r *MetadataRecord) string { return formatOptional(r.SecondSurfaceValue, formatFloat) }},
+	{"statistical_process", func(r *MetadataRecord) string { return formatOptional(r.StatisticalProcess, formatInt[int]) }},
+	{"ensemble_type", func(r *MetadataRecord) string { return formatOptional(r.EnsembleType, formatInt[int]) }},
+	{"perturbation_number", func(r *MetadataRecord) string { return formatOptional(r.PerturbationNumber, formatInt[int]) }},
+	{"ensemble_size", func(r *MetadataRecord) string { return formatOptional(r.EnsembleSize, formatInt[int]) }},
+	{"grid_template", func(r *MetadataRecord) string { return formatInt(r.GridTemplate) }},
+	{"number_of_points", func(r *MetadataRecord) string { return formatInt(r.NumberOfPoints) }},
+	{"ni", func(r *MetadataRecord) string { return formatOptional(r.Ni, formatInt[int]) }},
+	{"nj", func(r *MetadataRecord) string { return formatOptional(r.Nj, formatInt[int]) }},
+	{"lat_first", func(r *MetadataRecord) string { return formatOptional(r.LatFirst, formatFloat) }},
+	{"lon_first", func(r *MetadataRecord) string { return formatOptional(r.LonFirst, formatFloat) }},
+	{"lat_last", func(r *MetadataRecord) string { return formatOptional(r.LatLast, formatFloat) }},
+	{"lon_last", func(r *MetadataRecord) string { return formatOptional(r.LonLast, formatFloat) }},
+	{"packing_template", func(r *MetadataRecord) string { return formatInt(r.PackingTemplate) }},
+	{"number_of_values", func(r *MetadataRecord) string { return formatInt(r.NumberOfValues) }},
+	{"reference_value", func(r *MetadataRecord) string { return formatFloat(r.ReferenceValue) }},
+	{"binary_scale", func(r *MetadataRecord) string { return formatInt(r.BinaryScale) }},
+	{"decimal_scale", func(r *MetadataRecord) string { return formatInt(r.DecimalScale) }},
+	{"bits_per_value", func(r *MetadataRecord) string { return formatInt(r.BitsPerValue) }},
+	{"bitmap_indicator", func(r *MetadataRecord) string { return formatInt(r.BitmapIndicator) }},
+}
+
+// MetadataColumns returns the column names of MetadataRecord in TSV output order
+func MetadataColumns() []string {
+	names := make([]string, len(metadataColumns))
+	for i, column := range metadataColumns {
+		names[i] = column.name
+	}
+	return names
+}
+
+// FileMessages pairs each message with the path of the file it was read from, for WriteTSV
+func FileMessages(path string, msgs iter.Seq[FlatMessage]) iter.Seq2[string, FlatMessage] {
+	return func(yield func(string, FlatMessage) bool) {
+		for msg := range msgs {
+			if !yield(path, msg) {
+				return
+			}
+		}
+	}
+}
+
+// SourceMessages pairs each message with its source label, for WriteTSV over messages
+// read from several files with WithSource
+func SourceMessages(msgs iter.Seq[FlatMessage]) iter.Seq2[string, FlatMessage] {
+	return func(yield func(string, FlatMessage) bool) {
+		for msg := range msgs {
+			if !yield(msg.Source, msg) {
+				return
+			}
+		}
+	}
+}
+
+// WriteTSV streams the metadata of messages, keyed by the path of their file, as
+// tab-separated values with a header line of column names. Values are escaped as in
+// the PostgreSQL COPY text format: backslash, tab, newline and carriage return are
+// backslash-escaped and missing values are written as \N.
+func WriteTSV(w io.Writer, msgs iter.Seq2[string, FlatMessage]) error {
+	bw := bufio.NewWriter(w)
+
+	writeRow := func(fields func(i int) string) {
+		for i := range metadataColumns {
+			if i > 0 {
+				bw.WriteByte('\t')
+			}
+			bw.WriteString(fields(i))
+		}
+		bw.WriteByte('\n')
+	}
+
+	writeRow(func(i int) string { return metadataColumns[i].name })
+	for path, msg := range msgs {
+		record := msg.Metadata(path)
+		writeRow(func(i int) string { return metadataColumns[i].format(&record) })
+	}
+
+	return bw.Flush()
+}
+
+// tsvNull is the TSV representation of a missing value
+const tsvNull = `\N`
+
+// tsvEscaper escapes the characters with a special meaning in TSV fields
+var tsvEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+
+func formatString(v string) string {
+	return tsvEscaper.Replace(v)
+}
+
+func formatOptional[T any](v *T, format func(T) string) string {
+	if v == nil {
+		return tsvNull
+	}
+	return format(*v)
+}
+
+func formatInt[T int | int64](v T) string {
+	return strconv.FormatInt(int64(v), 10)
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,32 @@
+//go:build !nointernaltables
+
+package reader_test
+
+import (
+	"bytes"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/scorix/grib/grib2/reader"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteTSV_Golden(t *testing.T) {
+	const path = "testdata/gfs.t00z.pgrb2.0p25.f000"
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	err = reader.WriteTSV(&buf, reader.FileMessages(path, slices.Values(flatMessages(t, data))))
+	require.NoError(t, err)
+
+	const golden = "testdata/metadata.golden.tsv"
+	if *update {
+		require.NoError(t, os.WriteFile(golden, buf.Bytes(), 0o644))
+	}
+	want, err := os.ReadFile(golden)
+	require.NoError(t, err)
+	assert.Equal(t, string(want), buf.String())
+}
@@ -0,0 +1,54 @@
+package reader_test
+
+import (
+	"bytes"
+	"flag"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/scorix/grib/grib2/internal/testgrib"
+	"github.com/scorix/grib/grib2/reader"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func TestWriteTSV_Escaping(t *testing.T) {
+	msgs := flatMessages(t, testgrib.MustEncode(testgrib.Spec{ProductTemplate: 8, StatisticalProcess: 1, RangeHours: 6}))
+
+	var buf bytes.Buffer
+	err := reader.WriteTSV(&buf, reader.FileMessages("dir\twith\\odd\nname", slices.Values(msgs)))
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	require.Len(t, lines, 2)
+
+	header := strings.Split(lines[0], "\t")
+	row := strings.Split(lines[1], "\t")
+	require.Len(t, row, len(header))
+
+	fields := make(map[string]string, len(header))
+	for i, name := range header {
+		fields[name] = row[i]
+	}
+	assert.Equal(t, `dir\twith\\odd\nname`, fields["file_path"])
+	assert.Equal(t, "1", fields["statistical_process"])
+	assert.Equal(t, "0-6 hour acc fcst", fields["step"])
+	assert.Equal(t, `\N`, fields["perturbation_number"])
+	assert.Equal(t, `\N`, fields["second_surface_type"])
+	assert.Equal(t, "surface", fields["level"])
+	assert.Equal(t, "2024-01-01T00:00:00Z", fields["reference_time"])
+}
+
+func TestMetadataColumns(t *testing.T) {
+	// Columns follow the db tags of MetadataRecord in declaration order
+	typ := reflect.TypeOf(reader.MetadataRecord{})
+	tags := make([]string, typ.NumField())
+	for i := range tags {
+		tags[i] = typ.Field(i).Tag.Get("db")
+	}
+	assert.Equal(t, tags, reader.MetadataColumns())
+}
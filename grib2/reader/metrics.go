@@ -0,0 +1,255 @@
+package reader
+
+import (
+	"io"
+	"math/bits"
+	"sync"
+	"time"
+)
+
+// Metrics receives timing and counter events from the readers, for profiling large scans.
+// It is set with WithMetrics and must be safe for concurrent use when the readers are.
+// Without it, the readers do not measure anything.
+type Metrics interface {
+	// SectionParsed is called for each section read and parsed, with the time taken
+	SectionParsed(number uint8, d time.Duration)
+	// BytesRead is called with the number of bytes read from the underlying source
+	BytesRead(n int64)
+	// MessageBuilt is called for each message assembled from its sections, with the time
+	// taken including reading the sections
+	MessageBuilt(d time.Duration)
+	// TemplatesExtracted is called for each field whose template fields were extracted
+	TemplatesExtracted(d time.Duration)
+	// DataDecoded is called for each call to FlatMessage.DecodeData
+	DataDecoded(d time.Duration)
+}
+
+// WithMetrics sends timing and counter events of the reader, and of the fields it reads,
+// to m
+func WithMetrics(m Metrics) Option {
+	return func(o *options) {
+		o.metrics = m
+	}
+}
+
+// startTimer returns the current time when m collects metrics, and the zero time otherwise
+// so that unset metrics cost nothing
+func startTimer(m Metrics) time.Time {
+	if m == nil {
+		return time.Time{}
+	}
+	return time.Now()
+}
+
+// countingReaderAt reports the bytes read from an io.ReaderAt
+type countingReaderAt struct {
+	io.ReaderAt
+	metrics Metrics
+}
+
+func (c countingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	n, err := c.ReaderAt.ReadAt(p, off)
+	c.metrics.BytesRead(int64(n))
+	return n, err
+}
+
+// countingReader reports the bytes read from an io.Reader
+type countingReader struct {
+	io.Reader
+	metrics Metrics
+}
+
+func (c countingReader) Read(p []byte) (int, error) {
+	n, err := c.Reader.Read(p)
+	c.metrics.BytesRead(int64(n))
+	return n, err
+}
+
+// TimingStats summarises the durations of one kind of event
+type TimingStats struct {
+	Count int           `json:"count"`
+	Total time.Duration `json:"total"`
+	Mean  time.Duration `json:"mean"`
+	P50   time.Duration `json:"p50"`
+	P90   time.Duration `json:"p90"`
+	P99   time.Duration `json:"p99"`
+	Max   time.Duration `json:"max"`
+}
+
+// MemoryMetrics is a Metrics collecting events in memory. Its zero value is ready to use
+// and it is safe for concurrent use. It keeps a fixed-size histogram of each kind of event
+// rather than the events themselves, so that its memory does not grow with long scans;
+// percentiles are read from the histograms and are within 1/8 above the exact ones.
+type MemoryMetrics struct {
+	mu          sync.Mutex
+	sections    map[uint8]*timingHistogram
+	bytes       int64
+	messages    timingHistogram
+	extractions timingHistogram
+	decodes     timingHistogram
+}
+
+// SectionParsed implements Metrics
+func (m *MemoryMetrics) SectionParsed(number uint8, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.sections == nil {
+		m.sections = make(map[uint8]*timingHistogram)
+	}
+	h := m.sections[number]
+	if h == nil {
+		h = &timingHistogram{}
+		m.sections[number] = h
+	}
+	h.add(d)
+}
+
+// BytesRead implements Metrics
+func (m *MemoryMetrics) BytesRead(n int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.bytes += n
+}
+
+// MessageBuilt implements Metrics
+func (m *MemoryMetrics) MessageBuilt(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages.add(d)
+}
+
+// TemplatesExtracted implements Metrics
+func (m *MemoryMetrics) TemplatesExtracted(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.extractions.add(d)
+}
+
+// DataDecoded implements Metrics
+func (m *MemoryMetrics) DataDecoded(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.decodes.add(d)
+}
+
+// Sections returns the parse timings of the sections read, by section number
+func (m *MemoryMetrics) Sections() map[uint8]TimingStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stats := make(map[uint8]TimingStats, len(m.sections))
+	for number, h := range m.sections {
+		stats[number] = h.stats()
+	}
+	return stats
+}
+
+// TotalBytes returns the number of bytes read from the sources
+func (m *MemoryMetrics) TotalBytes() int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.bytes
+}
+
+// Messages returns the timings of the messages built
+func (m *MemoryMetrics) Messages() TimingStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.messages.stats()
+}
+
+// Extractions returns the timings of the template extractions
+func (m *MemoryMetrics) Extractions() TimingStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.extractions.stats()
+}
+
+// Decodes returns the timings of the data decoded
+func (m *MemoryMetrics) Decodes() TimingStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.decodes.stats()
+}
+
+// Reset discards the events collected so far
+func (m *MemoryMetrics) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sections, m.bytes = nil, 0
+	m.messages, m.extractions, m.decodes = timingHistogram{}, timingHistogram{}, timingHistogram{}
+}
+
+// histogramSubBuckets is the number of buckets each power of two of nanoseconds is split
+// into, which bounds the relative width of a bucket to 1/8
+const histogramSubBuckets = 8
+
+// histogramBuckets covers all positive durations: the exact values below
+// histogramSubBuckets, then histogramSubBuckets buckets for each bit length up to 63
+const histogramBuckets = (63 - 2) * histogramSubBuckets
+
+// timingHistogram counts durations in log-linear buckets, keeping their exact count, total
+// and maximum
+type timingHistogram struct {
+	count   int
+	total   time.Duration
+	max     time.Duration
+	buckets [histogramBuckets]uint64
+}
+
+// add counts d, negative durations as zero
+func (h *timingHistogram) add(d time.Duration) {
+	d = max(d, 0)
+	h.count++
+	h.total += d
+	h.max = max(h.max, d)
+	h.buckets[histogramBucket(d)]++
+}
+
+// histogramBucket returns the index of the bucket of d: d itself when small, else the
+// bit length of d and the three bits following its leading one
+func histogramBucket(d time.Duration) int {
+	v := uint64(d)
+	if v < histogramSubBuckets {
+		return int(v)
+	}
+	shift := bits.Len64(v) - 4
+	return (shift+1)*histogramSubBuckets + int(v>>shift)&(histogramSubBuckets-1)
+}
+
+// histogramUpperBound returns the largest duration counted in bucket i
+func histogramUpperBound(i int) time.Duration {
+	if i < histogramSubBuckets {
+		return time.Duration(i)
+	}
+	shift := i/histogramSubBuckets - 1
+	lower := uint64(histogramSubBuckets+i%histogramSubBuckets) << shift
+	return time.Duration(lower + 1<<shift - 1)
+}
+
+// stats summarises the durations counted, using the nearest-rank percentiles. A percentile
+// is the upper bound of the bucket holding its rank, capped by the maximum.
+func (h *timingHistogram) stats() TimingStats {
+	if h.count == 0 {
+		return TimingStats{}
+	}
+	percentile := func(p int) time.Duration {
+		rank := uint64(max((p*h.count+99)/100, 1))
+		var seen uint64
+		for i, n := range h.buckets {
+			seen += n
+			if seen >= rank {
+				return min(histogramUpperBound(i), h.max)
+			}
+		}
+		return h.max
+	}
+	return TimingStats{
+		Count: h.count,
+		Total: h.total,
+		Mean:  h.total / time.Duration(h.count),
+		P50:   percentile(50),
+		P90:   percentile(90),
+		P99:   percentile(99),
+		Max:   h.max,
+	}
+}
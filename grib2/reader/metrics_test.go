@@ -0,0 +1,126 @@
+package reader_test
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/scorix/grib/grib2/internal/testgrib"
+	"github.com/scorix/grib/grib2/reader"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithMetrics_Scan(t *testing.T) {
+	data, err := os.ReadFile("testdata/gfs.t00z.pgrb2.0p25.f000")
+	require.NoError(t, err)
+
+	// The section counts of the file, as scanned without metrics
+	want := make(map[uint8]int)
+	require.NoError(t, reader.NewReaderAt(bytes.NewReader(data)).EachMessage(func(_ int, info reader.MessageInfo) bool {
+		for _, sec := range info.Sections {
+			want[sec.Number]++
+		}
+		return true
+	}))
+	assert.Equal(t, map[uint8]int{0: 3, 1: 3, 3: 3, 4: 3, 5: 3, 6: 3, 7: 3, 8: 3}, want)
+
+	for _, tt := range []struct {
+		name string
+		scan func(reader.Option) error
+		// ReaderAt reads the data of Section 7 lazily, so its scan skips most of the file
+		fullRead bool
+	}{
+		{"ReaderAt", func(opt reader.Option) error {
+			return reader.NewReaderAt(bytes.NewReader(data), opt).EachFlatMessage(func(int, reader.FlatMessage) bool { return true })
+		}, false},
+		{"Reader", func(opt reader.Option) error {
+			return reader.NewReader(bytes.NewReader(data), opt).EachFlatMessage(func(int, reader.FlatMessage) bool { return true })
+		}, true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics := &reader.MemoryMetrics{}
+			require.NoError(t, tt.scan(reader.WithMetrics(metrics)))
+
+			got := make(map[uint8]int)
+			for number, stats := range metrics.Sections() {
+				got[number] = stats.Count
+				assert.LessOrEqual(t, stats.P50, stats.P99)
+				assert.LessOrEqual(t, stats.P99, stats.Max)
+				assert.LessOrEqual(t, stats.Max, stats.Total)
+			}
+			assert.Equal(t, want, got)
+			assert.Equal(t, 3, metrics.Messages().Count)
+			assert.Equal(t, 3, metrics.Extractions().Count)
+			assert.Zero(t, metrics.Decodes().Count)
+			if tt.fullRead {
+				assert.Equal(t, int64(len(data)), metrics.TotalBytes())
+			} else {
+				assert.Positive(t, metrics.TotalBytes())
+				assert.Less(t, metrics.TotalBytes(), int64(len(data))/100)
+			}
+		})
+	}
+}
+
+func TestWithMetrics_Decode(t *testing.T) {
+	data := testgrib.MustEncode(testgrib.Spec{Values: []float64{1, 2, 3, 4}, Ni: 2, Nj: 2, BitsPerValue: 8})
+	metrics := &reader.MemoryMetrics{}
+
+	var fields []reader.FlatMessage
+	require.NoError(t, reader.NewReaderAt(bytes.NewReader(data), reader.WithMetrics(metrics)).EachFilteredMessage(reader.Filter{}, func(_ int, msg reader.FlatMessage) bool {
+		fields = append(fields, msg)
+		return true
+	}))
+	require.Len(t, fields, 1)
+
+	_, err := fields[0].DecodeData()
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, metrics.Extractions().Count)
+	assert.Equal(t, 1, metrics.Decodes().Count)
+	assert.Equal(t, 1, metrics.Sections()[7].Count)
+
+	metrics.Reset()
+	assert.Empty(t, metrics.Sections())
+	assert.Zero(t, metrics.TotalBytes())
+}
+
+func TestMemoryMetrics_Percentiles(t *testing.T) {
+	metrics := &reader.MemoryMetrics{}
+	for i := 1000; i >= 1; i-- {
+		metrics.MessageBuilt(time.Duration(i) * time.Microsecond)
+	}
+	metrics.MessageBuilt(-time.Second) // counted as zero
+
+	stats := metrics.Messages()
+	assert.Equal(t, 1001, stats.Count)
+	assert.Equal(t, 500500*time.Microsecond, stats.Total)
+	assert.Equal(t, 500*time.Microsecond, stats.Mean)
+	assert.Equal(t, time.Millisecond, stats.Max)
+
+	// Percentiles are at most 1/8 above the exact nearest-rank ones
+	for _, tt := range []struct {
+		got  time.Duration
+		want time.Duration
+	}{
+		{stats.P50, 500 * time.Microsecond},
+		{stats.P90, 900 * time.Microsecond},
+		{stats.P99, 990 * time.Microsecond},
+	} {
+		assert.GreaterOrEqual(t, tt.got, tt.want)
+		assert.LessOrEqual(t, tt.got, tt.want+tt.want/8)
+	}
+	assert.LessOrEqual(t, stats.P99, stats.Max)
+
+	// Small durations are counted exactly
+	metrics.Reset()
+	for _, d := range []time.Duration{1, 2, 3, 4, 5, 6, 7} {
+		metrics.DataDecoded(d)
+	}
+	stats = metrics.Decodes()
+	assert.Equal(t, time.Duration(4), stats.P50)
+	assert.Equal(t, time.Duration(7), stats.P90)
+	assert.Equal(t, time.Duration(7), stats.Max)
+}
@@ -0,0 +1,80 @@
+package reader
+
+import (
+	"fmt"
+
+	"github.com/scorix/grib/grib2/grid"
+)
+
+// Option configures a Reader, ReaderAt or File
+type Option func(*options)
+
+// options holds the settings shared by the readers
+type options struct {
+	strictSections bool
+	source         string
+	lonConvention  grid.LonConvention
+	metrics        Metrics
+	dedup          bool
+	dedupMode      DedupMode
+	maxMessages    int
+	maxBytes       int64
+	skipGRIB1      bool
+}
+
+// WithStrictSections makes sections that are duplicated or out of order fail the message
+// with *ErrUnexpectedSection, a Section 0 total length that disagrees with the end
+// marker fail it with *ErrLengthMismatch, a Section 7 running past the end marker fail it
+// with *ErrSectionOverrun, and a Section 1 shorter than 21 octets fail it with
+// *ErrShortSection. By default such sections are skipped, the end marker is trusted, the
+// Section 7 is clipped before the end marker and these are recorded in
+// MessageInfo.Anomalies, and the fields missing from a short Section 1 read as zero.
+func WithStrictSections() Option {
+	return func(o *options) {
+		o.strictSections = true
+	}
+}
+
+// WithSkipGRIB1 skips the messages of GRIB edition 1 in files that mix editions, moving
+// past each one by its total length, and reads the GRIB2 messages around them; they are
+// not counted in the indexes of messages. By default a GRIB1 message stops reading with
+// *section.ErrGRIB1NotSupported.
+func WithSkipGRIB1() Option {
+	return func(o *options) {
+		o.skipGRIB1 = true
+	}
+}
+
+// WithSource labels everything read with source, typically the path or URL of the file:
+// it is set as the Source of messages and fields, and prefixes the errors returned by the
+// iteration methods, so that results aggregated across files can be attributed.
+func WithSource(source string) Option {
+	return func(o *options) {
+		o.source = source
+	}
+}
+
+// WithLonConvention selects the range of the longitudes reported for messages and fields,
+// e.g. in their metadata. GRIB2 codes longitudes in [0, 360), the default.
+func WithLonConvention(convention grid.LonConvention) Option {
+	return func(o *options) {
+		o.lonConvention = convention
+	}
+}
+
+// sourced prefixes err with the source label, if any
+func (o options) sourced(err error) error {
+	if err == nil || o.source == "" {
+		return err
+	}
+	return fmt.Errorf("%s: %w", o.source, err)
+}
+
+// newOptions applies opts to the default settings
+func newOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
@@ -0,0 +1,342 @@
+package reader_test
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/scorix/grib/grib2/geo"
+	"github.com/scorix/grib/grib2/internal/testgrib"
+	"github.com/scorix/grib/grib2/reader"
+	"github.com/scorix/grib/grib2/template"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlatMessage_Ensemble(t *testing.T) {
+	// A GEFS-like file: the control forecast, then perturbed members 1 to 3, instantaneous
+	// (4.1) and accumulated (4.11)
+	var data []byte
+	for _, productTemplate := range []uint16{1, 11} {
+		for member := range uint8(4) {
+			spec := testgrib.Spec{ProductTemplate: productTemplate, EnsembleType: 1, PerturbationNumber: member, EnsembleSize: 31, ForecastHours: 6}
+			if member > 0 {
+				spec.EnsembleType = 3
+			}
+			if productTemplate == 11 {
+				spec.Category, spec.Parameter, spec.StatisticalProcess, spec.RangeHours = 1, 8, 1, 6
+			}
+			data = append(data, testgrib.MustEncode(spec)...)
+		}
+	}
+
+	messages := flatMessages(t, data)
+	require.Len(t, messages, 8)
+	for i, msg := range messages {
+		require.NotNil(t, msg.Product.Ensemble, "message %d", i)
+		member := uint8(i % 4)
+		want := template.EnsembleInfo{TypeOfEnsembleForecast: 3, PerturbationNumber: member, NumberOfForecastsInEnsemble: 31}
+		if member == 0 {
+			want.TypeOfEnsembleForecast = 1
+		}
+		assert.Equal(t, want, *msg.Product.Ensemble, "message %d", i)
+	}
+
+	// The accumulated members keep their time range after the ensemble block
+	for _, msg := range messages[4:] {
+		require.NotNil(t, msg.Product.TimeRange)
+		start, end, err := msg.StepRange()
+		require.NoError(t, err)
+		assert.Equal(t, 6*time.Hour, start)
+		assert.Equal(t, 12*time.Hour, end)
+	}
+}
+
+func TestFlatMessage_Ensemble_NotEnsemble(t *testing.T) {
+	data := testgrib.MustEncode(testgrib.Spec{ForecastHours: 6})
+	messages := flatMessages(t, data)
+	require.Len(t, messages, 1)
+	assert.Nil(t, messages[0].Product.Ensemble)
+
+	// A template 4.1 cut short of its ensemble block
+	binary.BigEndian.PutUint16(data[sectionOffset(t, data, 4)+7:], 1)
+	messages = flatMessages(t, data)
+	require.Len(t, messages, 1)
+	assert.Nil(t, messages[0].Product.Ensemble)
+}
+
+// probabilityBytes builds the probability block of templates 4.5 and 4.9, octets 35-47
+func probabilityBytes(probabilityType uint8, lowerScale uint8, lower uint32, upperScale uint8, upper uint32) []byte {
+	block := []byte{1, 2, probabilityType, lowerScale}
+	block = binary.BigEndian.AppendUint32(block, lower)
+	block = append(block, upperScale)
+	return binary.BigEndian.AppendUint32(block, upper)
+}
+
+func TestFlatMessage_Probability(t *testing.T) {
+	tests := []struct {
+		name         string
+		block        []byte
+		want         *template.ProbabilityInfo
+		lower, upper float64
+	}{
+		{
+			name:  "above a threshold",
+			block: probabilityBytes(3, 2, 27315, 0xff, 0xffffffff),
+			want: &template.ProbabilityInfo{
+				ForecastProbabilityNumber: 1, TotalNumberOfForecastProbabilities: 2, ProbabilityType: 3,
+				ScaleFactorOfLowerLimit: 2, ScaledValueOfLowerLimit: 27315,
+				ScaleFactorOfUpperLimit: -127, ScaledValueOfUpperLimit: 0xffffffff,
+			},
+			lower: 273.15,
+			upper: math.NaN(),
+		},
+		{
+			name:  "between limits",
+			block: probabilityBytes(2, 0x81, 5, 0, 100), // 5 × 10^1 and 100
+			want: &template.ProbabilityInfo{
+				ForecastProbabilityNumber: 1, TotalNumberOfForecastProbabilities: 2, ProbabilityType: 2,
+				ScaleFactorOfLowerLimit: -1, ScaledValueOfLowerLimit: 5,
+				ScaleFactorOfUpperLimit: 0, ScaledValueOfUpperLimit: 100,
+			},
+			lower: 50,
+			upper: 100,
+		},
+		{
+			name:  "below a threshold",
+			block: probabilityBytes(4, 0xff, 0xffffffff, 3, 254),
+			want: &template.ProbabilityInfo{
+				ForecastProbabilityNumber: 1, TotalNumberOfForecastProbabilities: 2, ProbabilityType: 4,
+				ScaleFactorOfLowerLimit: -127, ScaledValueOfLowerLimit: 0xffffffff,
+				ScaleFactorOfUpperLimit: 3, ScaledValueOfUpperLimit: 254,
+			},
+			lower: math.NaN(),
+			upper: 0.254,
+		},
+	}
+
+	for _, tt := range tests {
+		check := func(t *testing.T, got *template.ProbabilityInfo) {
+			require.NotNil(t, got)
+			assertNaNOrInDelta(t, tt.lower, got.LowerLimitValue)
+			assertNaNOrInDelta(t, tt.upper, got.UpperLimitValue)
+			got.LowerLimitValue, got.UpperLimitValue = 0, 0
+			assert.Equal(t, tt.want, got)
+		}
+
+		spec := testgrib.Spec{ReferenceTime: stepReference, Category: 1, Parameter: 8, ForecastHours: 6, ProductBlock: tt.block}
+
+		t.Run(tt.name, func(t *testing.T) {
+			spec.ProductTemplate = 5
+			messages := flatMessages(t, testgrib.MustEncode(spec))
+			require.Len(t, messages, 1)
+			check(t, messages[0].Product.Probability)
+		})
+
+		t.Run(tt.name+" over a time range", func(t *testing.T) {
+			spec.ProductTemplate = 9
+			spec.TimeRanges = []testgrib.TimeRange{{Process: 1, Unit: 1, Length: 6}}
+			messages := flatMessages(t, testgrib.MustEncode(spec))
+			require.Len(t, messages, 1)
+			check(t, messages[0].Product.Probability)
+			require.NotNil(t, messages[0].Product.TimeRange)
+			assert.Equal(t, uint32(6), messages[0].Product.TimeRange.LengthOfTimeRange)
+			assert.Equal(t, "6-12 hour acc fcst", messages[0].StepString())
+		})
+	}
+
+	t.Run("truncated template", func(t *testing.T) {
+		messages := flatMessages(t, testgrib.MustEncode(testgrib.Spec{ProductTemplate: 5, Category: 1, Parameter: 8, ForecastHours: 6}))
+		require.Len(t, messages, 1)
+		assert.Nil(t, messages[0].Product.Probability)
+	})
+}
+
+// assertNaNOrInDelta asserts that got is NaN when want is, else close to want
+func assertNaNOrInDelta(t *testing.T, want, got float64) {
+	t.Helper()
+	if math.IsNaN(want) {
+		assert.True(t, math.IsNaN(got), "want NaN, got %v", got)
+		return
+	}
+	assert.InDelta(t, want, got, 1e-9)
+}
+
+func TestFlatMessage_Percentile(t *testing.T) {
+	spec := testgrib.Spec{ReferenceTime: stepReference, ForecastHours: 6, SurfaceType: 103}
+
+	t.Run("4.6", func(t *testing.T) {
+		spec.ProductTemplate, spec.ProductBlock = 6, []byte{90}
+		messages := flatMessages(t, testgrib.MustEncode(spec))
+		require.Len(t, messages, 1)
+		assert.Equal(t, &template.PercentileInfo{PercentileValue: 90}, messages[0].Product.Percentile)
+		assert.Nil(t, messages[0].Product.TimeRange)
+	})
+
+	t.Run("4.10", func(t *testing.T) {
+		spec.ProductTemplate, spec.ProductBlock = 10, []byte{10}
+		spec.TimeRanges = []testgrib.TimeRange{{Process: 0, Unit: 1, Length: 18}}
+		messages := flatMessages(t, testgrib.MustEncode(spec))
+		require.Len(t, messages, 1)
+		assert.Equal(t, &template.PercentileInfo{PercentileValue: 10}, messages[0].Product.Percentile)
+		require.NotNil(t, messages[0].Product.TimeRange)
+		assert.Equal(t, uint32(18), messages[0].Product.TimeRange.LengthOfTimeRange)
+	})
+}
+
+func TestFlatMessage_ForecastError(t *testing.T) {
+	// Template 4.7 has the fields of template 4.0 only
+	messages := flatMessages(t, testgrib.MustEncode(testgrib.Spec{ProductTemplate: 7, ForecastHours: 6, SurfaceType: 103}))
+	require.Len(t, messages, 1)
+	product := messages[0].Product
+	assert.Equal(t, uint16(7), product.TemplateNumber)
+	assert.Equal(t, uint32(6), product.ForecastTime)
+	assert.Equal(t, uint8(103), product.TypeOfFirstFixedSurface)
+	assert.Nil(t, product.Derived)
+	assert.Nil(t, product.Percentile)
+	assert.Contains(t, reader.SupportedProductTemplates(), reader.TemplateSupport{Number: 7, Parse: true})
+}
+
+func TestFlatMessage_Derived(t *testing.T) {
+	accumulation := []testgrib.TimeRange{{Process: 1, Unit: 1, Length: 18}}
+
+	// The fields of derived forecasts based on all members, with the clusters missing
+	allMembers := template.DerivedInfo{
+		DerivedForecastType:            2, // Standard deviation with respect to the cluster mean
+		NumberOfForecastsInEnsemble:    31,
+		ClusterIdentifier:              0xff,
+		ClusterOfHighResolutionControl: 0xff,
+		ClusterOfLowResolutionControl:  0xff,
+		NumberOfClustersOfEnsemble:     0xff,
+		ClusteringMethod:               0xff,
+		NorthernLatitudeOfCluster:      -0x7fffffff,
+		SouthernLatitudeOfCluster:      -0x7fffffff,
+		EasternLongitudeOfCluster:      0xffffffff,
+		WesternLongitudeOfCluster:      0xffffffff,
+		LatitudeOfClusterCentre:        -0x7fffffff,
+		LongitudeOfClusterCentre:       0xffffffff,
+		ScaleFactorOfClusterRadius:     -127,
+		ScaledValueOfClusterRadius:     0xffffffff,
+		NumberOfForecastsInCluster:     0xff,
+		ScaleFactorOfStandardDeviation: -127,
+		ScaledValueOfStandardDeviation: 0xffffffff,
+		ScaleFactorOfDistanceFromMean:  -127,
+		ScaledValueOfDistanceFromMean:  0xffffffff,
+		ScaleFactorOfCentralWaveNumber: -127,
+		ScaledValueOfCentralWaveNumber: 0xffffffff,
+	}
+	derivedBlock := []byte{2, 31}
+
+	// A cluster of 12 members over Europe, the high resolution control in cluster 1
+	rectangular := allMembers
+	rectangular.ClusterIdentifier = 2
+	rectangular.ClusterOfHighResolutionControl = 1
+	rectangular.ClusterOfLowResolutionControl = 3
+	rectangular.NumberOfClustersOfEnsemble = 6
+	rectangular.ClusteringMethod = 1
+	rectangular.NorthernLatitudeOfCluster = 75_000_000
+	rectangular.SouthernLatitudeOfCluster = -5_000_000
+	rectangular.EasternLongitudeOfCluster = 42_500_000
+	rectangular.WesternLongitudeOfCluster = 340_000_000
+	rectangular.NumberOfForecastsInCluster = 12
+	rectangular.ScaleFactorOfStandardDeviation = 1
+	rectangular.ScaledValueOfStandardDeviation = 25
+	rectangular.ScaleFactorOfDistanceFromMean = -1
+	rectangular.ScaledValueOfDistanceFromMean = 3
+	rectangularBlock := []byte{2, 31, 2, 1, 3, 6, 1}
+	rectangularBlock = binary.BigEndian.AppendUint32(rectangularBlock, 75_000_000)
+	rectangularBlock = binary.BigEndian.AppendUint32(rectangularBlock, 0x80000000|5_000_000)
+	rectangularBlock = binary.BigEndian.AppendUint32(rectangularBlock, 42_500_000)
+	rectangularBlock = binary.BigEndian.AppendUint32(rectangularBlock, 340_000_000)
+	rectangularBlock = append(rectangularBlock, 12, 1)
+	rectangularBlock = binary.BigEndian.AppendUint32(rectangularBlock, 25)
+	rectangularBlock = append(rectangularBlock, 0x81)
+	rectangularBlock = binary.BigEndian.AppendUint32(rectangularBlock, 3)
+
+	// The same cluster within 1500 km of 50° N 10° E
+	circular := allMembers
+	circular.ClusterIdentifier = 2
+	circular.ClusterOfHighResolutionControl = 1
+	circular.ClusterOfLowResolutionControl = 3
+	circular.NumberOfClustersOfEnsemble = 6
+	circular.ClusteringMethod = 1
+	circular.LatitudeOfClusterCentre = 50_000_000
+	circular.LongitudeOfClusterCentre = 10_000_000
+	circular.ScaleFactorOfClusterRadius = -3
+	circular.ScaledValueOfClusterRadius = 1500
+	circular.NumberOfForecastsInCluster = 12
+	circular.ScaleFactorOfStandardDeviation = 1
+	circular.ScaledValueOfStandardDeviation = 25
+	circular.ScaleFactorOfDistanceFromMean = -1
+	circular.ScaledValueOfDistanceFromMean = 3
+	circularBlock := []byte{2, 31, 2, 1, 3, 6, 1}
+	circularBlock = binary.BigEndian.AppendUint32(circularBlock, 50_000_000)
+	circularBlock = binary.BigEndian.AppendUint32(circularBlock, 10_000_000)
+	circularBlock = append(circularBlock, 0x83)
+	circularBlock = binary.BigEndian.AppendUint32(circularBlock, 1500)
+	circularBlock = append(circularBlock, 12, 1)
+	circularBlock = binary.BigEndian.AppendUint32(circularBlock, 25)
+	circularBlock = append(circularBlock, 0x81)
+	circularBlock = binary.BigEndian.AppendUint32(circularBlock, 3)
+
+	tests := []struct {
+		template  uint16
+		block     []byte
+		want      template.DerivedInfo
+		timeRange bool
+	}{
+		{2, derivedBlock, allMembers, false},
+		{3, rectangularBlock, rectangular, false},
+		{4, circularBlock, circular, false},
+		{12, derivedBlock, allMembers, true},
+		{13, rectangularBlock, rectangular, true},
+		{14, circularBlock, circular, true},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("4.%d", tt.template), func(t *testing.T) {
+			spec := testgrib.Spec{
+				ProductTemplate: tt.template, ProductBlock: tt.block,
+				ForecastHours: 6, SurfaceType: 103, TimeRanges: accumulation,
+			}
+			messages := flatMessages(t, testgrib.MustEncode(spec))
+			require.Len(t, messages, 1)
+			product := messages[0].Product
+			require.NotNil(t, product.Derived)
+			assert.Equal(t, tt.want, *product.Derived)
+			assert.Nil(t, product.Ensemble)
+
+			if tt.timeRange {
+				require.NotNil(t, product.TimeRange)
+				assert.Equal(t, uint32(18), product.TimeRange.LengthOfTimeRange)
+			} else {
+				assert.Nil(t, product.TimeRange)
+			}
+		})
+	}
+
+	// The scaled fields of the cluster
+	derived := rectangular
+	domain, ok := derived.ClusterDomain()
+	assert.True(t, ok)
+	assert.Equal(t, geo.BBox{South: -5, North: 75, West: -20, East: 42.5}, domain)
+	deviation, ok := derived.StandardDeviation()
+	assert.True(t, ok)
+	assert.InDelta(t, 2.5, deviation, 1e-12)
+	distance, ok := derived.DistanceFromMean()
+	assert.True(t, ok)
+	assert.InDelta(t, 30, distance, 1e-12)
+	_, ok = derived.ClusterRadius()
+	assert.False(t, ok)
+	_, ok = derived.CentralWaveNumber()
+	assert.False(t, ok)
+
+	radius, ok := circular.ClusterRadius()
+	assert.True(t, ok)
+	assert.InDelta(t, 1_500_000, radius, 1e-6)
+	_, ok = circular.ClusterDomain()
+	assert.False(t, ok)
+	_, ok = allMembers.StandardDeviation()
+	assert.False(t, ok)
+}
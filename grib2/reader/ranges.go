@@ -0,0 +1,110 @@
+package reader
+
+// ByteRange is a contiguous range of bytes within a GRIB2 file
+type ByteRange struct {
+	Offset int64 // Start offset of the range
+	Length int64 // Number of bytes in the range
+}
+
+// End returns the offset just past the last byte of the range
+func (r ByteRange) End() int64 {
+	return r.Offset + r.Length
+}
+
+// SectionRanges returns the byte ranges of the sections that make up this field, keyed by section number.
+// Shared sections (0-3 and 8) refer to their single occurrence in the original message.
+// Returns nil when section offsets are unknown.
+func (f *FlatMessage) SectionRanges() map[uint8]ByteRange {
+	return f.sectionRanges
+}
+
+// Section4Offset returns the offset of the Section 4 of this field in the file, which
+// identifies the field as stably as its Index. ok is false when section offsets are unknown.
+func (f *FlatMessage) Section4Offset() (offset int64, ok bool) {
+	r, ok := f.sectionRanges[4]
+	return r.Offset, ok
+}
+
+// FieldByteRange returns the byte range covering Sections 4 through 7 of this field.
+// ok is false when section offsets are unknown.
+func (f *FlatMessage) FieldByteRange() (r ByteRange, ok bool) {
+	first, ok := f.sectionRanges[4]
+	if !ok {
+		return ByteRange{}, false
+	}
+	last, ok := f.sectionRanges[7]
+	if !ok {
+		return ByteRange{}, false
+	}
+	return ByteRange{Offset: first.Offset, Length: last.End() - first.Offset}, true
+}
+
+// fieldSectionRanges splits the sections of a message into the byte ranges of each data field,
+// in the same order as the fields are flattened. Each Section 4 starts a new field that inherits
+// the most recent Sections 0-3; Section 8 is shared by all fields. Sections are checked for
+// order as the message is assembled: out of order sections are left out, and so are fields
+// that end without a Section 7.
+func fieldSectionRanges(sections []SectionInfo) []map[uint8]ByteRange {
+	var fields []map[uint8]ByteRange
+	var field map[uint8]ByteRange // Field being read, until its Section 7
+	shared := make(map[uint8]ByteRange, 4)
+	var order sectionOrder
+
+	for _, sec := range sections {
+		switch check, _ := order.check(sec.Number); check {
+		case orderSkip:
+			continue
+		case orderDiscard:
+			field = nil
+		}
+		r := ByteRange{Offset: sec.Offset, Length: int64(sec.Length)}
+
+		switch {
+		case sec.Number <= 3:
+			// A new local use section keeps the previous grid, which applies to fields
+			// that follow it directly
+			shared[sec.Number] = r
+		case sec.Number == 4:
+			field = make(map[uint8]ByteRange, 9)
+			for number, sharedRange := range shared {
+				field[number] = sharedRange
+			}
+			field[4] = r
+		case sec.Number == 8:
+			for _, field := range fields {
+				field[8] = r
+			}
+		case field != nil:
+			field[sec.Number] = r
+			if sec.Number == 7 {
+				fields = append(fields, field)
+				field = nil
+			}
+		}
+	}
+
+	return fields
+}
+
+// fieldIndex returns the index in the file of the field whose Section 4 is at offset in
+// the message described by info
+func fieldIndex(info MessageInfo, offset int64) int {
+	index := info.FirstField
+	for _, sec := range info.Sections {
+		if sec.Number == 4 && sec.Offset < offset {
+			index++
+		}
+	}
+	return index
+}
+
+// countSections returns the number of sections with the given number
+func countSections(sections []SectionInfo, number uint8) int {
+	n := 0
+	for _, sec := range sections {
+		if sec.Number == number {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,83 @@
+package reader_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/scorix/grib/grib2/internal/testgrib"
+	"github.com/scorix/grib/grib2/reader"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSectionRanges(t *testing.T) {
+	data, err := os.ReadFile("testdata/gfs.t00z.pgrb2.0p25.f000")
+	require.NoError(t, err)
+
+	msg := flatMessages(t, data)[0]
+
+	assert.Equal(t, map[uint8]reader.ByteRange{
+		0: {Offset: 0, Length: 16},
+		1: {Offset: 16, Length: 21},
+		3: {Offset: 37, Length: 72},
+		4: {Offset: 109, Length: 34},
+		5: {Offset: 143, Length: 49},
+		6: {Offset: 192, Length: 6},
+		7: {Offset: 198, Length: 868535},
+		8: {Offset: 868733, Length: 4},
+	}, msg.SectionRanges())
+
+	field, ok := msg.FieldByteRange()
+	require.True(t, ok)
+	assert.Equal(t, reader.ByteRange{Offset: 109, Length: 868624}, field)
+	assert.Equal(t, int64(868733), field.End())
+
+	// The range can be cut out directly: it starts with the Section 4 header
+	assert.Equal(t, byte(4), data[field.Offset+4])
+}
+
+func TestSectionRangesMultiFieldMessage(t *testing.T) {
+	// Two fields of 4 values packed with 4 and 6 bits, so that their data sections differ
+	firstField := testgrib.MustEncodeSections(testgrib.Spec{Ni: 2, Nj: 2, BitsPerValue: 4})
+	secondField := testgrib.MustEncodeSections(testgrib.Spec{Ni: 2, Nj: 2, BitsPerValue: 6})
+	data := testgrib.Assemble(0,
+		firstField[1],  // offset 16, 21 octets
+		firstField[3],  // offset 37, 72 octets
+		firstField[4],  // offset 109, 34 octets
+		firstField[5],  // offset 143, 21 octets
+		firstField[6],  // offset 164, 6 octets
+		firstField[7],  // offset 170, 7 octets
+		secondField[4], // offset 177, 34 octets
+		secondField[5], // offset 211, 21 octets
+		secondField[6], // offset 232, 6 octets
+		secondField[7], // offset 238, 8 octets
+	)
+
+	messages := flatMessages(t, data)
+	require.Len(t, messages, 2)
+
+	first, second := messages[0].SectionRanges(), messages[1].SectionRanges()
+	assert.Equal(t, first[3], second[3])
+	assert.Equal(t, reader.ByteRange{Offset: 246, Length: 4}, first[8])
+	assert.Equal(t, first[8], second[8])
+
+	assert.Equal(t, reader.ByteRange{Offset: 170, Length: 7}, first[7])
+	assert.Equal(t, reader.ByteRange{Offset: 177, Length: 34}, second[4])
+	assert.Equal(t, reader.ByteRange{Offset: 238, Length: 8}, second[7])
+
+	field, ok := messages[0].FieldByteRange()
+	require.True(t, ok)
+	assert.Equal(t, reader.ByteRange{Offset: 109, Length: 68}, field)
+
+	field, ok = messages[1].FieldByteRange()
+	require.True(t, ok)
+	assert.Equal(t, reader.ByteRange{Offset: 177, Length: 69}, field)
+}
+
+func TestFieldByteRangeUnknown(t *testing.T) {
+	var msg reader.FlatMessage
+
+	assert.Nil(t, msg.SectionRanges())
+	_, ok := msg.FieldByteRange()
+	assert.False(t, ok)
+}
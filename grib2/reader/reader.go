@@ -1,31 +1,66 @@
 package reader
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
 	"io"
+	"math"
+	"time"
 
 	"github.com/scorix/grib/grib2/section"
-	"github.com/scorix/grib/grib2/spec"
 )
 
 // Reader implements sequential reading of GRIB files using io.Reader
 type Reader struct {
 	io.Reader
+	opts     options
 	sections []section.Section
 	messages []Message
+
+	// With WithDeduplicate, the sections are hashed as they are read
+	hash         hash.Hash
+	digests      []digest // Hash of each section
+	fingerprints []digest // Fingerprint of each message
+	skipped      int      // Duplicates skipped by the last iteration
+
+	// Position of the next section, and the extent of the message it belongs to given
+	// by Section 0, with messageEnd zero between messages
+	offset                   int64
+	messageStart, messageEnd int64
+	pending                  section.Section // End section read along with a clipped Section 7
+	skippedBefore            map[int]int64   // Octets of GRIB1 messages skipped before the section of each index
+
+	messagesRead int  // Section 0s read, for WithMaxMessages
+	truncated    bool // Whether reading stopped at a limit with messages unread
 }
 
 // NewReader creates a new Reader from an io.Reader
-func NewReader(reader io.Reader) *Reader {
-	return &Reader{
+func NewReader(reader io.Reader, opts ...Option) *Reader {
+	r := &Reader{
 		Reader: reader,
+		opts:   newOptions(opts),
+	}
+	if r.opts.metrics != nil {
+		r.Reader = countingReader{Reader: reader, metrics: r.opts.metrics}
 	}
+	if r.opts.dedup {
+		r.hash = sha256.New()
+		r.Reader = io.TeeReader(r.Reader, r.hash)
+	}
+	return r
 }
 
 // ReadSection reads the next section from the GRIB file
 func (r *Reader) ReadSection() (section.Section, error) {
-	sec, err := section.NewReader(r.Reader).ReadSection()
+	start := startTimer(r.opts.metrics)
+	sec, err := r.readSection()
 	if err == nil {
 		r.sections = append(r.sections, sec)
+		r.advance(sec)
 
 		// For Section 7, we need to consume the data to advance the reader
 		if sec.SectionNumber() == 7 {
@@ -33,30 +68,98 @@ func (r *Reader) ReadSection() (section.Section, error) {
 				_ = sec7.Data() // Force reading all data to advance the underlying reader
 			}
 		}
+		if r.opts.metrics != nil {
+			r.opts.metrics.SectionParsed(sec.SectionNumber(), time.Since(start))
+		}
+		if r.hash != nil {
+			r.digests = append(r.digests, digest(r.hash.Sum(nil)))
+			r.hash.Reset()
+		}
 	}
 	return sec, err
 }
 
+// readSection reads the next section. Within a message, a Section 7 whose declared
+// length runs past the end marker located by the Section 0 total length is clipped to end
+// before the marker, its data failing to load with *ErrSectionOverrun, or fails with it in
+// strict mode. The end marker is read along with it and returned next.
+func (r *Reader) readSection() (section.Section, error) {
+	if sec := r.pending; sec != nil {
+		r.pending = nil
+		return sec, nil
+	}
+	if r.messageEnd == 0 {
+		return section.NewReader(r.Reader).ReadSection()
+	}
+
+	header := make([]byte, 5)
+	n, err := io.ReadFull(r.Reader, header[:4])
+	if err != nil || string(header[:4]) == "GRIB" || string(header[:4]) == "7777" {
+		return section.NewReader(io.MultiReader(bytes.NewReader(header[:n]), r.Reader)).ReadSection()
+	}
+	n, err = io.ReadFull(r.Reader, header[4:])
+	header = header[:4+n]
+
+	length := binary.BigEndian.Uint32(header)
+	available := r.messageEnd - 4 - r.offset
+	if err != nil || header[4] != 7 || int64(length) <= available || available < 5 {
+		return section.NewReader(io.MultiReader(bytes.NewReader(header), r.Reader)).ReadSection()
+	}
+
+	// Clip the section only if the end marker is where Section 0 places it
+	rest := make([]byte, available-5+4)
+	n, err = io.ReadFull(r.Reader, rest)
+	if err != nil || string(rest[n-4:]) != "7777" {
+		return section.NewReader(io.MultiReader(bytes.NewReader(header), bytes.NewReader(rest[:n]), r.Reader)).ReadSection()
+	}
+
+	overrun := &ErrSectionOverrun{
+		MessageOffset: r.messageStart,
+		Section:       7,
+		Offset:        r.offset,
+		Length:        length,
+		Available:     uint32(available),
+	}
+	if r.opts.strictSections {
+		return nil, overrun
+	}
+	end, err := section.NewSection8FromReader(bytes.NewReader(rest[n-4:]))
+	if err != nil {
+		return nil, err
+	}
+	r.pending = end
+	return section.NewTruncatedSection7(uint32(available), overrun), nil
+}
+
+// advance moves the position of the reader past a section it has read
+func (r *Reader) advance(sec section.Section) {
+	if sec0, ok := sec.(section.Section0); ok {
+		r.messageStart = r.offset
+		r.messageEnd = r.offset + int64(min(sec0.TotalLength(), math.MaxInt64/2))
+		r.messagesRead++
+	}
+	r.offset += int64(r.getSectionLength(sec))
+	if sec.SectionNumber() == 8 {
+		r.messageEnd = 0
+	}
+}
+
 // EachMessage iterates through messages in the GRIB file
 // The callback function receives the message index and MessageInfo
 // Return true to continue iteration, false to stop
 func (r *Reader) EachMessage(fn func(int, MessageInfo) bool) error {
-	// First ensure we have read all sections
-	err := r.readAllSections()
-	if err != nil {
+	if err := r.load(); err != nil {
 		return err
 	}
 
-	// Build messages if not already done
-	if len(r.messages) == 0 {
-		err = r.buildMessages()
-		if err != nil {
-			return err
-		}
-	}
+	var dedup deduplicator
+	defer func() { r.skipped = dedup.skipped }()
 
 	// Iterate through messages
 	for i, msg := range r.messages {
+		if r.opts.dedup && dedup.duplicate(r.fingerprints[i]) {
+			continue
+		}
 		if !fn(i, msg.Info) {
 			break // Stop iteration if callback returns false
 		}
@@ -69,42 +172,65 @@ func (r *Reader) EachMessage(fn func(int, MessageInfo) bool) error {
 // Each nested message is flattened into multiple FlatMessage structs, one per data field
 // Return true to continue iteration, false to stop
 func (r *Reader) EachFlatMessage(fn func(int, FlatMessage) bool) error {
-	// First ensure we have read all sections
-	err := r.readAllSections()
-	if err != nil {
+	if err := r.load(); err != nil {
 		return err
 	}
 
-	// Build messages if not already done
-	if len(r.messages) == 0 {
-		err = r.buildMessages()
-		if err != nil {
-			return err
-		}
-	}
+	var dedup deduplicator
+	defer func() { r.skipped = dedup.skipped }()
 
 	// Iterate through messages and flatten each one
-	flatIndex := 0
-	for _, msg := range r.messages {
+	for i, msg := range r.messages {
+		if r.opts.dedup && dedup.duplicate(r.fingerprints[i]) {
+			continue
+		}
 		flatMessages := msg.FlattenToFlatMessages()
 		for _, flatMsg := range flatMessages {
-			// Update the index to be sequential across all flat messages
-			flatMsg.Index = flatIndex
-			if !fn(flatIndex, flatMsg) {
+			if !fn(flatMsg.Index, flatMsg) {
 				return nil // Stop iteration if callback returns false
 			}
-			flatIndex++
 		}
 	}
 
 	return nil
 }
 
-// readAllSections reads all sections sequentially from the entire file
+// load reads all sections and builds the messages from them, if not already done
+func (r *Reader) load() error {
+	// First ensure we have read all sections
+	if err := r.readAllSections(); err != nil {
+		return r.opts.sourced(err)
+	}
+
+	// Build messages if not already done
+	if len(r.messages) == 0 {
+		if err := r.buildMessages(); err != nil {
+			return r.opts.sourced(err)
+		}
+	}
+
+	return nil
+}
+
+// readAllSections reads all sections sequentially from the entire file, or up to the
+// limits set by WithMaxMessages and WithMaxBytes
 func (r *Reader) readAllSections() error {
 	// Continue reading until EOF
 	for {
+		if r.messageEnd == 0 && r.pending == nil {
+			if stop, err := r.limitReached(); stop || err != nil {
+				return err
+			}
+		}
+
 		_, err := r.ReadSection()
+		var grib1 *section.ErrGRIB1NotSupported
+		if errors.As(err, &grib1) {
+			err = r.skipGRIB1(grib1)
+			if err == nil {
+				continue
+			}
+		}
 		if err != nil {
 			if err == io.EOF {
 				break
@@ -119,159 +245,122 @@ func (r *Reader) readAllSections() error {
 	return nil
 }
 
-// buildMessages constructs Message objects from cached sections according to GRIB2 specification
-// Supports three levels of repetition: sections 2-7, sections 3-7, and sections 4-7
-func (r *Reader) buildMessages() error {
-	if len(r.sections) == 0 {
-		return nil
+// skipGRIB1 moves past the GRIB1 message whose Section 0 was just read with WithSkipGRIB1,
+// checking its end marker, or reports it
+func (r *Reader) skipGRIB1(grib1 *section.ErrGRIB1NotSupported) error {
+	if !r.opts.skipGRIB1 || r.messageEnd != 0 {
+		return fmt.Errorf("message at offset %d: %w", r.offset, grib1)
+	}
+	if grib1.TotalLength < 16+4 {
+		return fmt.Errorf("GRIB1 message at offset %d: invalid total length %d", r.offset, grib1.TotalLength)
 	}
 
-	var currentMessage *Message
-	var currentLocalBlock *LocalBlock
-	var currentGridBlock *GridBlock
-	var currentDataField *DataField
-	offset := int64(0)
+	// Section 0 was read as the 16 octets of a GRIB2 one
+	end := make([]byte, 4)
+	_, err := io.CopyN(io.Discard, r.Reader, int64(grib1.TotalLength)-16-4)
+	if err == nil {
+		_, err = io.ReadFull(r.Reader, end)
+	}
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return fmt.Errorf("GRIB1 message at offset %d: %w", r.offset, err)
+	}
+	if string(end) != "7777" {
+		return fmt.Errorf("GRIB1 message at offset %d: no end marker at its total length %d", r.offset, grib1.TotalLength)
+	}
 
-	for i, sec := range r.sections {
-		switch sec.SectionNumber() {
-		case 0:
-			// Section 0: Start new message
-			if currentMessage != nil {
-				r.finalizeMessage(currentMessage)
-			}
+	r.offset += int64(grib1.TotalLength)
+	if r.skippedBefore == nil {
+		r.skippedBefore = make(map[int]int64)
+	}
+	r.skippedBefore[len(r.sections)] += int64(grib1.TotalLength)
+	if r.hash != nil {
+		r.hash.Reset()
+	}
+	return nil
+}
 
-			if sec0, ok := sec.(section.Section0); ok {
-				currentMessage = &Message{
-					Info: MessageInfo{
-						Index:      len(r.messages),
-						Offset:     offset,
-						Length:     sec0.TotalLength(),
-						Discipline: sec0.Discipline(),
-						Edition:    sec0.Edition(),
-					},
-					Message: spec.Message{
-						Indicator: sec0,
-					},
-				}
-				currentLocalBlock = nil
-				currentGridBlock = nil
-				currentDataField = nil
-			}
+// buildMessages constructs Message objects from cached sections according to GRIB2 specification
+// Supports three levels of repetition: sections 2-7, sections 3-7, and sections 4-7
+func (r *Reader) buildMessages() error {
+	var current *Message
+	var assembler *assembler
+	var start time.Time
+	var first int // Index of the Section 0 of the current message
+	offset := int64(0)
+	fields := 0 // Section 4s read, which field indexes count
 
-		case 1:
-			// Section 1: Identification (required, once per message)
-			if currentMessage != nil {
-				if sec1, ok := sec.(section.Section1); ok {
-					currentMessage.Message.Identification = sec1
-				}
-			}
+	var overruns []error // Section 7 clipped to the end of the current message
 
-		case 2:
-			// Section 2: Local Use (optional, starts new local block)
-			if currentMessage != nil {
-				// Finalize previous local block
-				if currentLocalBlock != nil {
-					r.finalizeLocalBlock(currentMessage, currentLocalBlock)
-				}
+	finish := func(lengthAnomaly error, end int) {
+		if r.hash != nil {
+			r.fingerprints = append(r.fingerprints, r.messageFingerprint(first, end))
+		}
+		current.Message, current.Info.Anomalies = assembler.result()
+		current.Info.Anomalies = append(current.Info.Anomalies, overruns...)
+		if lengthAnomaly != nil {
+			current.Info.Anomalies = append(current.Info.Anomalies, lengthAnomaly)
+		}
+		r.messages = append(r.messages, *current)
+		current = nil
+		if r.opts.metrics != nil {
+			r.opts.metrics.MessageBuilt(time.Since(start))
+		}
+	}
 
-				// Start new local block
-				currentLocalBlock = &LocalBlock{}
-				if sec2, ok := sec.(section.Section2); ok {
-					currentLocalBlock.LocalUse = sec2
-				}
-				currentGridBlock = nil
-				currentDataField = nil
+	for i, sec := range r.sections {
+		offset += r.skippedBefore[i]
+		if sec0, ok := sec.(section.Section0); ok {
+			// Section 0 starts a new message; keep an unterminated previous one as is
+			if current != nil {
+				finish(nil, i-1)
 			}
-
-		case 3:
-			// Section 3: Grid Definition (starts new grid block)
-			if currentMessage != nil {
-				// Ensure we have a local block (create empty one if needed)
-				if currentLocalBlock == nil {
-					currentLocalBlock = &LocalBlock{}
-				}
-
-				// Finalize previous grid block
-				if currentGridBlock != nil {
-					r.finalizeGridBlock(currentLocalBlock, currentGridBlock)
-				}
-
-				// Start new grid block
-				if sec3, ok := sec.(section.Section3); ok {
-					currentGridBlock = &GridBlock{
-						GridDef: sec3,
-					}
-				}
-				currentDataField = nil
+			first = i
+
+			current = &Message{
+				Info: MessageInfo{
+					Index:      len(r.messages),
+					Offset:     offset,
+					Length:     sec0.TotalLength(),
+					Discipline: sec0.Discipline(),
+					Edition:    sec0.Edition(),
+					FirstField: fields,
+					Source:     r.opts.source,
+
+					LonConvention: r.opts.lonConvention,
+					metrics:       r.opts.metrics,
+				},
 			}
+			assembler = newAssembler(offset, r.opts)
+			start = startTimer(r.opts.metrics)
+			overruns = nil
+		}
 
-		case 4:
-			// Section 4: Product Definition (starts new data field)
-			if currentGridBlock != nil {
-				// Finalize previous data field
-				if currentDataField != nil {
-					r.finalizeDataField(currentGridBlock, currentDataField)
-				}
-
-				// Start new data field
-				if sec4, ok := sec.(section.Section4); ok {
-					currentDataField = &DataField{
-						ProductDef: sec4,
-					}
-				}
-			}
+		var overrun *ErrSectionOverrun
+		if sec7, ok := sec.(section.Section7); ok && current != nil && errors.As(sec7.LoadError(), &overrun) {
+			overruns = append(overruns, overrun)
+		}
 
-		case 5:
-			// Section 5: Data Representation (required for data field)
-			if currentDataField != nil {
-				if sec5, ok := sec.(section.Section5); ok {
-					currentDataField.DataRep = sec5
-				}
+		if current != nil {
+			if sec.SectionNumber() == 4 {
+				fields++
 			}
-
-		case 6:
-			// Section 6: Bit-map (optional for data field)
-			if currentDataField != nil {
-				if sec6, ok := sec.(section.Section6); ok {
-					currentDataField.Bitmap = sec6
-				}
+			if err := assembler.add(sec); err != nil {
+				return fmt.Errorf("message %d: %w", current.Info.Index, err)
 			}
 
-		case 7:
-			// Section 7: Data (required for data field)
-			if currentDataField != nil {
-				if sec7, ok := sec.(section.Section7); ok {
-					currentDataField.Data = sec7
-				}
-			}
+			if sec.SectionNumber() == 8 {
+				r.buildSectionInfo(current, i)
 
-		case 8:
-			// Section 8: End (finalize all structures)
-			if currentMessage != nil {
-				if sec8, ok := sec.(section.Section8); ok {
-					// Finalize current data field
-					if currentDataField != nil {
-						r.finalizeDataField(currentGridBlock, currentDataField)
-						currentDataField = nil
-					}
-
-					// Finalize current grid block
-					if currentGridBlock != nil {
-						r.finalizeGridBlock(currentLocalBlock, currentGridBlock)
-						currentGridBlock = nil
-					}
-
-					// Finalize current local block
-					if currentLocalBlock != nil {
-						r.finalizeLocalBlock(currentMessage, currentLocalBlock)
-						currentLocalBlock = nil
-					}
-
-					currentMessage.Message.End = sec8
-					r.buildSectionInfo(currentMessage, i)
-					r.messages = append(r.messages, *currentMessage)
-					currentMessage = nil
+				// Sections are read one after another, so the end marker is where the message ends
+				length, anomaly, err := checkMessageLength(current.Info.Offset, current.Info.Length, current.Info.Sections, r.opts.strictSections)
+				if err != nil {
+					return fmt.Errorf("message %d: %w", current.Info.Index, err)
 				}
+				current.Info.Length = length
+				finish(anomaly, i)
 			}
 		}
 
@@ -282,34 +371,6 @@ func (r *Reader) buildMessages() error {
 	return nil
 }
 
-// finalizeDataField adds the current data field to the grid block
-func (r *Reader) finalizeDataField(gridBlock *GridBlock, dataField *DataField) {
-	if gridBlock != nil && dataField != nil {
-		gridBlock.Fields = append(gridBlock.Fields, *dataField)
-	}
-}
-
-// finalizeGridBlock adds the current grid block to the local block
-func (r *Reader) finalizeGridBlock(localBlock *LocalBlock, gridBlock *GridBlock) {
-	if localBlock != nil && gridBlock != nil {
-		localBlock.Grids = append(localBlock.Grids, *gridBlock)
-	}
-}
-
-// finalizeLocalBlock adds the current local block to the message
-func (r *Reader) finalizeLocalBlock(message *Message, localBlock *LocalBlock) {
-	if message != nil && localBlock != nil {
-		message.Message.Blocks = append(message.Message.Blocks, *localBlock)
-	}
-}
-
-// finalizeMessage adds the message to the messages list (fallback for incomplete messages)
-func (r *Reader) finalizeMessage(message *Message) {
-	if message != nil {
-		r.messages = append(r.messages, *message)
-	}
-}
-
 // buildSectionInfo builds section info for a message
 func (r *Reader) buildSectionInfo(msg *Message, endIndex int) {
 	var sections []SectionInfo
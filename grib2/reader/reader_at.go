@@ -2,27 +2,47 @@ package reader
 
 import (
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
+	"math"
+	"sync/atomic"
+	"time"
 
 	"github.com/scorix/grib/grib2/section"
-	"github.com/scorix/grib/grib2/spec"
 )
 
+// minMessageLength is the length of a message consisting only of Sections 0 and 8
+const minMessageLength = 16 + 4
+
 // ReaderAt implements random-access reading of GRIB files using io.ReaderAt
 type ReaderAt struct {
-	reader io.ReaderAt
+	reader io.ReaderAt // Source, counting the bytes read when metrics are collected
+	base   io.ReaderAt // Source as given, for its optional interfaces
+	opts   options
+	spans  []ByteRange // Message locations from a validated index; nil to scan the file
+
+	skipped   atomic.Int64 // Duplicates skipped by the last iteration with WithDeduplicate
+	truncated atomic.Bool  // Whether the last iteration stopped at a limit with messages unread
 }
 
 // NewReaderAt creates a new ReaderAt from an io.ReaderAt
-func NewReaderAt(reader io.ReaderAt) *ReaderAt {
-	return &ReaderAt{
+func NewReaderAt(reader io.ReaderAt, opts ...Option) *ReaderAt {
+	r := &ReaderAt{
 		reader: reader,
+		base:   reader,
+		opts:   newOptions(opts),
+	}
+	if r.opts.metrics != nil {
+		r.reader = countingReaderAt{ReaderAt: reader, metrics: r.opts.metrics}
 	}
+	return r
 }
 
 // ReadSectionAt reads a specific section at the given offset
 func (r *ReaderAt) ReadSectionAt(offset int64) (section.Section, error) {
+	start := startTimer(r.opts.metrics)
+
 	// First read the section header to determine the exact length
 	first4 := make([]byte, 4)
 	_, err := r.reader.ReadAt(first4, offset)
@@ -42,23 +62,66 @@ func (r *ReaderAt) ReadSectionAt(offset int64) (section.Section, error) {
 	default:
 		// For other sections, first 4 bytes contain the length
 		sectionLength = int64(binary.BigEndian.Uint32(first4))
+
+		// The data of Section 7 can be read at random, e.g. to decode some rows only
+		number := make([]byte, 1)
+		if _, err := r.reader.ReadAt(number, offset+4); err == nil && number[0] == 7 && sectionLength >= 5 {
+			sec := section.NewSection7FromDataReaderAt(uint32(sectionLength), io.NewSectionReader(r.reader, offset+5, sectionLength-5))
+			if r.opts.metrics != nil {
+				r.opts.metrics.SectionParsed(7, time.Since(start))
+			}
+			return sec, nil
+		}
 	}
 
 	// Create a section reader with the exact length
 	sectionReader := io.NewSectionReader(r.reader, offset, sectionLength)
 
 	// Use the existing section reader logic
-	return section.NewReader(sectionReader).ReadSection()
+	sec, err := section.NewReader(sectionReader).ReadSection()
+	if err == nil && r.opts.metrics != nil {
+		r.opts.metrics.SectionParsed(sec.SectionNumber(), time.Since(start))
+	}
+	return sec, err
 }
 
 // EachMessage iterates through messages in the GRIB file
 // The callback function receives the message index and MessageInfo
 // Return true to continue iteration, false to stop
 func (r *ReaderAt) EachMessage(fn func(int, MessageInfo) bool) error {
+	r.truncated.Store(false)
+
+	var dedup *deduplicator
+	if r.opts.dedup {
+		dedup = &deduplicator{}
+		fn = r.skipDuplicates(dedup, fn)
+		defer func() { r.skipped.Store(int64(dedup.skipped)) }()
+	}
+
+	var err error
+	if r.spans != nil {
+		err = r.eachIndexedMessage(fn)
+	} else {
+		err = r.eachScannedMessage(fn)
+	}
+	if err == nil && dedup != nil {
+		err = dedup.err
+	}
+	return r.opts.sourced(err)
+}
+
+// eachScannedMessage iterates through the messages found by following their lengths from
+// the start of the file
+func (r *ReaderAt) eachScannedMessage(fn func(int, MessageInfo) bool) error {
 	offset := int64(0)
 	messageIndex := 0
+	fields := 0
 
 	for {
+		if stop, err := r.limitReached(messageIndex, offset); stop {
+			return err
+		}
+
 		// Read first 4 bytes to check for GRIB marker
 		first4 := make([]byte, 4)
 		_, err := r.reader.ReadAt(first4, offset)
@@ -74,42 +137,149 @@ func (r *ReaderAt) EachMessage(fn func(int, MessageInfo) bool) error {
 			return fmt.Errorf("invalid GRIB marker at offset %d", offset)
 		}
 
-		// Read Section 0 header (16 bytes total)
-		header := make([]byte, 16)
-		_, err = r.reader.ReadAt(header, offset)
+		messageInfo, err := r.readMessageInfo(messageIndex, offset)
+		if errors.Is(err, errLimitReached) {
+			r.truncated.Store(true)
+			break
+		}
+		var grib1 *section.ErrGRIB1NotSupported
+		if errors.As(err, &grib1) && r.opts.skipGRIB1 {
+			if err := r.checkGRIB1End(offset, grib1.TotalLength); err != nil {
+				return err
+			}
+			offset += int64(grib1.TotalLength)
+			continue
+		}
 		if err != nil {
-			return fmt.Errorf("failed to read Section 0 header at offset %d: %w", offset, err)
+			return err
 		}
+		messageInfo.FirstField = fields
+		fields += countSections(messageInfo.Sections, 4)
 
-		// Parse Section 0 data
-		discipline := header[6]
-		edition := header[7]
-		totalLength := binary.BigEndian.Uint64(header[8:16])
+		// Call the callback function
+		if !fn(messageIndex, messageInfo) {
+			break // Stop iteration if callback returns false
+		}
 
-		// Scan sections within this message
-		sections, err := r.scanSectionsInRange(offset, offset+int64(totalLength))
-		if err != nil {
-			return fmt.Errorf("failed to scan sections in message %d: %w", messageIndex, err)
+		offset += int64(messageInfo.Length)
+		messageIndex++
+	}
+
+	return nil
+}
+
+// eachIndexedMessage iterates through the messages at the offsets of a validated index,
+// without searching for the start of each message
+func (r *ReaderAt) eachIndexedMessage(fn func(int, MessageInfo) bool) error {
+	fields := 0
+	for messageIndex, span := range r.spans {
+		if r.opts.maxMessages > 0 && messageIndex >= r.opts.maxMessages || r.opts.beyondMaxBytes(span.Offset, uint64(span.Length)) {
+			r.truncated.Store(true)
+			break
 		}
 
-		messageInfo := MessageInfo{
-			Index:      messageIndex,
-			Offset:     offset,
-			Length:     totalLength,
-			Discipline: discipline,
-			Edition:    edition,
-			Sections:   sections,
+		messageInfo, err := r.readMessageInfo(messageIndex, span.Offset)
+		if err != nil {
+			return err
 		}
+		messageInfo.FirstField = fields
+		fields += countSections(messageInfo.Sections, 4)
 
-		// Call the callback function
 		if !fn(messageIndex, messageInfo) {
-			break // Stop iteration if callback returns false
+			break
 		}
+	}
 
-		offset += int64(totalLength)
-		messageIndex++
+	return nil
+}
+
+// readMessageInfo reads the Section 0 header of the message at offset and scans its sections
+func (r *ReaderAt) readMessageInfo(messageIndex int, offset int64) (MessageInfo, error) {
+	// Read Section 0 header (16 bytes total)
+	header := make([]byte, 16)
+	_, err := r.reader.ReadAt(header, offset)
+	if err != nil {
+		return MessageInfo{}, fmt.Errorf("failed to read Section 0 header at offset %d: %w", offset, err)
+	}
+
+	// Must start with GRIB marker
+	if string(header[:4]) != "GRIB" {
+		return MessageInfo{}, fmt.Errorf("invalid GRIB marker at offset %d", offset)
+	}
+
+	if _, err := section.NewSection0FromBytes(header); err != nil {
+		return MessageInfo{}, fmt.Errorf("message %d at offset %d: %w", messageIndex, offset, err)
 	}
 
+	// Parse Section 0 data
+	discipline := header[6]
+	edition := header[7]
+	totalLength := binary.BigEndian.Uint64(header[8:16])
+
+	// The message must at least hold Sections 0 and 8, and its end must be addressable
+	if totalLength < minMessageLength || totalLength > uint64(math.MaxInt64-offset) {
+		return MessageInfo{}, fmt.Errorf("invalid total length %d of message %d at offset %d", totalLength, messageIndex, offset)
+	}
+	if r.opts.beyondMaxBytes(offset, totalLength) {
+		return MessageInfo{}, errLimitReached
+	}
+
+	// Scan sections within this message
+	sections, overrun, err := r.scanMessageSections(offset, totalLength)
+	if err != nil {
+		return MessageInfo{}, fmt.Errorf("failed to scan sections in message %d: %w", messageIndex, err)
+	}
+
+	length, lengthAnomaly, err := checkMessageLength(offset, totalLength, sections, r.opts.strictSections)
+	if err != nil {
+		return MessageInfo{}, fmt.Errorf("message %d: %w", messageIndex, err)
+	}
+
+	anomalies, err := checkSectionOrder(offset, sections, r.opts.strictSections)
+	if err != nil {
+		return MessageInfo{}, fmt.Errorf("message %d: %w", messageIndex, err)
+	}
+	if r.opts.strictSections {
+		for _, sec := range sections {
+			if err := checkSectionLength(offset, sec.Number, sec.Length); err != nil {
+				return MessageInfo{}, fmt.Errorf("message %d: %w", messageIndex, err)
+			}
+		}
+	}
+	if overrun != nil {
+		anomalies = append(anomalies, overrun)
+	}
+	if lengthAnomaly != nil {
+		anomalies = append(anomalies, lengthAnomaly)
+	}
+
+	return MessageInfo{
+		Index:      messageIndex,
+		Offset:     offset,
+		Length:     length,
+		Discipline: discipline,
+		Edition:    edition,
+		Sections:   sections,
+		Anomalies:  anomalies,
+		Source:     r.opts.source,
+
+		LonConvention: r.opts.lonConvention,
+		metrics:       r.opts.metrics,
+	}, nil
+}
+
+// checkGRIB1End checks that a GRIB1 message of totalLength octets at offset ends with its
+// end marker, before it is skipped
+func (r *ReaderAt) checkGRIB1End(offset int64, totalLength uint32) error {
+	end := make([]byte, 4)
+	if totalLength >= 8+4 {
+		if _, err := r.reader.ReadAt(end, offset+int64(totalLength)-4); err != nil {
+			return fmt.Errorf("GRIB1 message at offset %d: %w", offset, err)
+		}
+	}
+	if string(end) != "7777" {
+		return fmt.Errorf("GRIB1 message at offset %d: no end marker at its total length %d", offset, totalLength)
+	}
 	return nil
 }
 
@@ -117,8 +287,6 @@ func (r *ReaderAt) EachMessage(fn func(int, MessageInfo) bool) error {
 // Each nested message is flattened into multiple FlatMessage structs, one per data field
 // Return true to continue iteration, false to stop
 func (r *ReaderAt) EachFlatMessage(fn func(int, FlatMessage) bool) error {
-	flatIndex := 0
-
 	return r.EachMessage(func(msgIndex int, info MessageInfo) bool {
 		// Build complete message from MessageInfo
 		message, err := r.buildMessageFromInfo(info)
@@ -133,118 +301,74 @@ func (r *ReaderAt) EachFlatMessage(fn func(int, FlatMessage) bool) error {
 
 		// Call callback for each flattened message
 		for _, flatMsg := range flatMessages {
-			// Update the index to be sequential across all flat messages
-			flatMsg.Index = flatIndex
-			if !fn(flatIndex, flatMsg) {
+			if !fn(flatMsg.Index, flatMsg) {
 				return false // Stop iteration if callback returns false
 			}
-			flatIndex++
 		}
 
 		return true // Continue with next message
 	})
 }
 
+// ReadMessage reads all sections of the message described by info and assembles them into a Message
+func (r *ReaderAt) ReadMessage(info MessageInfo) (*Message, error) {
+	return r.buildMessageFromInfo(info)
+}
+
 // buildMessageFromInfo constructs a complete Message from MessageInfo
 func (r *ReaderAt) buildMessageFromInfo(info MessageInfo) (*Message, error) {
+	start := startTimer(r.opts.metrics)
+
 	// Read all sections for this message
 	var sections []section.Section
 	for _, secInfo := range info.Sections {
-		sec, err := r.ReadSectionAt(secInfo.Offset)
+		sec, err := r.readScannedSection(info.Offset, secInfo)
 		if err != nil {
 			return nil, fmt.Errorf("failed to read section %d at offset %d: %w", secInfo.Number, secInfo.Offset, err)
 		}
 		sections = append(sections, sec)
 	}
 
-	// Build the Message structure similar to Reader.buildMessages
-	message := &Message{
-		Info: info,
-	}
-
-	// Extract sections by type
-	var sec0 section.Section0
-	var sec1 section.Section1
-	var sec8 section.Section8
-	var currentLocalBlocks []spec.LocalBlock
-
-	currentLocalBlock := spec.LocalBlock{}
-	currentGridBlocks := []spec.GridBlock{}
-	currentGridBlock := spec.GridBlock{}
-	currentDataFields := []spec.DataField{}
-
+	assembler := newAssembler(info.Offset, r.opts)
 	for _, sec := range sections {
-		switch sec.SectionNumber() {
-		case 0:
-			sec0 = sec.(section.Section0)
-			message.Message.Indicator = sec0
-		case 1:
-			sec1 = sec.(section.Section1)
-			message.Message.Identification = sec1
-		case 2:
-			// Local use section - finalize previous local block and start new one
-			if len(currentDataFields) > 0 {
-				currentGridBlock.Fields = currentDataFields
-				currentGridBlocks = append(currentGridBlocks, currentGridBlock)
-				currentDataFields = []spec.DataField{}
-				currentGridBlock = spec.GridBlock{}
-			}
-			if len(currentGridBlocks) > 0 {
-				currentLocalBlock.Grids = currentGridBlocks
-				currentLocalBlocks = append(currentLocalBlocks, currentLocalBlock)
-				currentGridBlocks = []spec.GridBlock{}
-				currentLocalBlock = spec.LocalBlock{}
-			}
-			currentLocalBlock.LocalUse = sec.(section.Section2)
-		case 3:
-			// Grid definition section - finalize previous grid block and start new one
-			if len(currentDataFields) > 0 {
-				currentGridBlock.Fields = currentDataFields
-				currentGridBlocks = append(currentGridBlocks, currentGridBlock)
-				currentDataFields = []spec.DataField{}
-			}
-			currentGridBlock.GridDef = sec.(section.Section3)
-		case 4:
-			// Product definition section - start new data field
-			currentDataFields = append(currentDataFields, spec.DataField{
-				ProductDef: sec.(section.Section4),
-			})
-		case 5:
-			// Data representation section - complete current data field
-			if len(currentDataFields) > 0 {
-				currentDataFields[len(currentDataFields)-1].DataRep = sec.(section.Section5)
-			}
-		case 6:
-			// Bitmap section - add to current data field
-			if len(currentDataFields) > 0 {
-				currentDataFields[len(currentDataFields)-1].Bitmap = sec.(section.Section6)
-			}
-		case 7:
-			// Data section - complete current data field
-			if len(currentDataFields) > 0 {
-				currentDataFields[len(currentDataFields)-1].Data = sec.(section.Section7)
-			}
-		case 8:
-			sec8 = sec.(section.Section8)
-			message.Message.End = sec8
+		if err := assembler.add(sec); err != nil {
+			return nil, err
 		}
 	}
 
-	// Finalize remaining blocks
-	if len(currentDataFields) > 0 {
-		currentGridBlock.Fields = currentDataFields
-		currentGridBlocks = append(currentGridBlocks, currentGridBlock)
-	}
-	if len(currentGridBlocks) > 0 {
-		currentLocalBlock.Grids = currentGridBlocks
-		currentLocalBlocks = append(currentLocalBlocks, currentLocalBlock)
+	// The anomalies of info were found by the same checks when its sections were scanned
+	message := &Message{Info: info}
+	message.Message, _ = assembler.result()
+	if r.opts.metrics != nil {
+		r.opts.metrics.MessageBuilt(time.Since(start))
 	}
-
-	message.Message.Blocks = currentLocalBlocks
 	return message, nil
 }
 
-// scanSectionsInRange scans sections within a specific byte range
+// readScannedSection reads a section of the message at messageOffset located by
+// scanning it. A Section 7 clipped by the scan to end before the end marker is returned
+// truncated to its scanned length, its data failing to load with *ErrSectionOverrun.
+func (r *ReaderAt) readScannedSection(messageOffset int64, info SectionInfo) (section.Section, error) {
+	if info.Number == 7 {
+		header := make([]byte, 4)
+		if _, err := r.reader.ReadAt(header, info.Offset); err != nil {
+			return nil, fmt.Errorf("failed to read section header at offset %d: %w", info.Offset, err)
+		}
+		if declared := binary.BigEndian.Uint32(header); declared > info.Length {
+			return section.NewTruncatedSection7(info.Length, &ErrSectionOverrun{
+				MessageOffset: messageOffset,
+				Section:       7,
+				Offset:        info.Offset,
+				Length:        declared,
+				Available:     info.Length,
+			}), nil
+		}
+	}
+	return r.ReadSectionAt(info.Offset)
+}
+
+// scanSectionsInRange scans sections within a specific byte range. On error, the sections
+// scanned so far are returned with it.
 func (r *ReaderAt) scanSectionsInRange(startOffset, endOffset int64) ([]SectionInfo, error) {
 	var sections []SectionInfo
 	offset := startOffset
@@ -257,7 +381,7 @@ func (r *ReaderAt) scanSectionsInRange(startOffset, endOffset int64) ([]SectionI
 			if err == io.EOF {
 				break
 			}
-			return nil, fmt.Errorf("failed to read at offset %d: %w", offset, err)
+			return sections, fmt.Errorf("failed to read at offset %d: %w", offset, err)
 		}
 
 		var sectionNumber uint8
@@ -276,16 +400,27 @@ func (r *ReaderAt) scanSectionsInRange(startOffset, endOffset int64) ([]SectionI
 			// Other sections have length in first 4 bytes
 			sectionLength = binary.BigEndian.Uint32(first4)
 			if sectionLength < 5 {
-				return nil, fmt.Errorf("invalid section length %d at offset %d", sectionLength, offset)
+				return sections, fmt.Errorf("invalid section length %d at offset %d", sectionLength, offset)
 			}
 
 			// Read section number (5th byte)
 			sectionNumberByte := make([]byte, 1)
 			_, err = r.reader.ReadAt(sectionNumberByte, offset+4)
 			if err != nil {
-				return nil, fmt.Errorf("failed to read section number at offset %d: %w", offset+4, err)
+				return sections, fmt.Errorf("failed to read section number at offset %d: %w", offset+4, err)
 			}
 			sectionNumber = sectionNumberByte[0]
+
+			// The section must leave room for the end marker
+			if available := endOffset - 4 - offset; int64(sectionLength) > available {
+				return sections, &ErrSectionOverrun{
+					MessageOffset: startOffset,
+					Section:       sectionNumber,
+					Offset:        offset,
+					Length:        sectionLength,
+					Available:     uint32(min(max(available, 0), math.MaxUint32)),
+				}
+			}
 		}
 
 		sections = append(sections, SectionInfo{
@@ -2,9 +2,11 @@ package reader_test
 
 import (
 	"bytes"
+	"fmt"
 	"os"
 	"testing"
 
+	"github.com/scorix/grib/grib2/internal/testgrib"
 	"github.com/scorix/grib/grib2/reader"
 	"github.com/scorix/grib/grib2/section"
 	"github.com/stretchr/testify/assert"
@@ -135,3 +137,60 @@ func TestReaderAt_EachFlatMessage_EarlyStop(t *testing.T) {
 	// Should have called only once and stopped
 	assert.Equal(t, 1, callCount)
 }
+
+// grib1Message fabricates a GRIB edition 1 message of length octets, its sections filled
+// with zeros
+func grib1Message(length int) []byte {
+	msg := make([]byte, length)
+	copy(msg, "GRIB")
+	msg[4], msg[5], msg[6], msg[7] = byte(length>>16), byte(length>>8), byte(length), 1
+	copy(msg[length-4:], "7777")
+	return msg
+}
+
+func TestEachMessage_GRIB1(t *testing.T) {
+	first := testgrib.MustEncode(testgrib.Spec{Values: make([]float64, 16)})
+	second := testgrib.MustEncode(testgrib.Spec{Ni: 2, Nj: 2, Values: []float64{1, 2, 3, 4}})
+	mixed := bytes.Join([][]byte{first, grib1Message(70000), second, grib1Message(100)}, nil)
+
+	readers := map[string]func([]byte, ...reader.Option) interface {
+		EachMessage(func(int, reader.MessageInfo) bool) error
+	}{
+		"ReaderAt": func(data []byte, opts ...reader.Option) interface {
+			EachMessage(func(int, reader.MessageInfo) bool) error
+		} {
+			return reader.NewReaderAt(bytes.NewReader(data), opts...)
+		},
+		"Reader": func(data []byte, opts ...reader.Option) interface {
+			EachMessage(func(int, reader.MessageInfo) bool) error
+		} {
+			return reader.NewReader(bytes.NewReader(data), opts...)
+		},
+	}
+	for name, newReader := range readers {
+		t.Run(name, func(t *testing.T) {
+			// By default the GRIB1 message stops the iteration with a typed error
+			err := newReader(mixed).EachMessage(func(int, reader.MessageInfo) bool { return true })
+			var grib1 *section.ErrGRIB1NotSupported
+			require.ErrorAs(t, err, &grib1)
+			assert.Equal(t, uint32(70000), grib1.TotalLength)
+			assert.ErrorContains(t, err, fmt.Sprintf("offset %d", len(first)))
+
+			// The GRIB2 messages around GRIB1 ones are read when they are skipped
+			var infos []reader.MessageInfo
+			require.NoError(t, newReader(mixed, reader.WithSkipGRIB1()).EachMessage(func(_ int, info reader.MessageInfo) bool {
+				infos = append(infos, info)
+				return true
+			}))
+			require.Len(t, infos, 2)
+			assert.Equal(t, 1, infos[1].Index)
+			assert.Equal(t, int64(len(first)+70000), infos[1].Offset)
+			assert.Equal(t, uint64(len(second)), infos[1].Length)
+
+			// A GRIB1 message whose length does not end at its end marker
+			broken := bytes.Join([][]byte{first, grib1Message(100)[:90], second}, nil)
+			err = newReader(broken, reader.WithSkipGRIB1()).EachMessage(func(int, reader.MessageInfo) bool { return true })
+			assert.ErrorContains(t, err, fmt.Sprintf("GRIB1 message at offset %d", len(first)))
+		})
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"io"
 	"os"
 	"testing"
+	"testing/iotest"
 
+	"github.com/scorix/grib/grib2/internal/testgrib"
 	"github.com/scorix/grib/grib2/reader"
 	"github.com/scorix/grib/grib2/section"
 	"github.com/stretchr/testify/assert"
@@ -157,3 +159,65 @@ func TestReader_EachFlatMessage_EarlyStop(t *testing.T) {
 	// Should have called only once and stopped
 	assert.Equal(t, 1, callCount)
 }
+
+func TestReader_OneByteReads(t *testing.T) {
+	data := getTestData(t)
+	data = append(data, testgrib.MustEncode(testgrib.Spec{Bitmap: []bool{
+		true, false, true, true, true, true, false, true,
+		true, true, true, true, false, true, true, true,
+	}})...)
+	data = append(data, messageFromSections(1, 2, 3, 4, 5, 6, 7)...)
+
+	readAll := func(r io.Reader) []reader.FlatMessage {
+		var msgs []reader.FlatMessage
+		require.NoError(t, reader.NewReader(r).EachFlatMessage(func(_ int, msg reader.FlatMessage) bool {
+			msgs = append(msgs, msg)
+			return true
+		}))
+		return msgs
+	}
+
+	// A reader returning a single byte per Read call must yield the same fields as a full one
+	want := readAll(bytes.NewReader(data))
+	got := readAll(iotest.OneByteReader(bytes.NewReader(data)))
+	require.Len(t, want, 5)
+	require.Len(t, got, len(want))
+
+	for i := range want {
+		assert.Equal(t, want[i].Offset, got[i].Offset, "field %d", i)
+		assert.Equal(t, want[i].Product, got[i].Product, "field %d", i)
+		assert.Equal(t, want[i].Grid, got[i].Grid, "field %d", i)
+		// Missing value substitutes may be NaN, which never compare equal: compare the
+		// data representation through the header blobs holding it
+		wantHeader, err := want[i].MarshalHeader()
+		require.NoError(t, err)
+		gotHeader, err := got[i].MarshalHeader()
+		require.NoError(t, err)
+		assert.Equal(t, wantHeader, gotHeader, "field %d", i)
+		assert.Equal(t, want[i].LocalUse != nil, got[i].LocalUse != nil, "field %d", i)
+		if want[i].Bitmap != nil {
+			require.NotNil(t, got[i].Bitmap, "field %d", i)
+			assert.Equal(t, want[i].Bitmap.BitMap(), got[i].Bitmap.BitMap(), "field %d", i)
+		}
+		assert.Equal(t, want[i].Data.Data(), got[i].Data.Data(), "field %d", i)
+	}
+}
+
+func TestSectionReader_OneByteReads(t *testing.T) {
+	data := getTestData(t)
+	r := section.NewReader(iotest.OneByteReader(bytes.NewReader(data)))
+
+	var numbers []uint8
+	for {
+		sec, err := r.ReadSection()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		if sec7, ok := sec.(section.Section7); ok {
+			require.Len(t, sec7.Data(), int(sec7.DataSize()))
+		}
+		numbers = append(numbers, sec.SectionNumber())
+	}
+	assert.Equal(t, []uint8{0, 1, 3, 4, 5, 6, 7, 8, 0, 1, 3, 4, 5, 6, 7, 8, 0, 1, 3, 4, 5, 6, 7, 8}, numbers)
+}
@@ -0,0 +1,113 @@
+package reader
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/scorix/grib/grib2/grid"
+	"github.com/scorix/grib/grib2/packing"
+)
+
+// DecodeRows decodes the rows firstRow to lastRow, inclusive, of the field's grid in
+// north-up order (see grid.NorthUp): row 0 is the northernmost row and each row runs in
+// the direction of increasing x. The result holds ni values per row, with NaN at the
+// points masked out by the bit-map.
+//
+// For simple packing on grids whose rows are stored consecutively, only the part of
+// Section 7 holding the rows is read, which ReaderAt reads directly from the file. Other
+// fields, including those packed with other templates, are fully decoded first. Fields
+// storing no values (see ErrNoStoredValues) give NaN rows.
+func (f *FlatMessage) DecodeRows(firstRow, lastRow int) ([]float64, error) {
+	if ok, err := f.CanDecode(); !ok {
+		return nil, err
+	}
+	def, err := f.GridDefinition()
+	if err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+	ni, nj := def.Dims()
+	if firstRow < 0 || lastRow < firstRow || lastRow >= nj {
+		return nil, fmt.Errorf("decode: rows %d to %d out of range [0, %d)", firstRow, lastRow, nj)
+	}
+	if f.noStoredValues() != nil {
+		return missingValues((lastRow - firstRow + 1) * ni), nil
+	}
+
+	var bitmap []byte
+	bitmapIndex, hasBitmap := f.BitmapIndex()
+	if hasBitmap {
+		bitmap = f.bitmapSection().BitMap()
+	}
+
+	if !f.simplePacking() || def.ScanningMode()&grid.ScanConsecutiveJ != 0 {
+		return f.decodeRowsFully(def, firstRow, lastRow)
+	}
+
+	// The rows are consecutive in the scanning order, from storage row jFirst to jLast
+	jFirst, jLast := firstRow, lastRow
+	if def.ScanningMode()&grid.ScanPositiveJ != 0 {
+		jFirst, jLast = nj-1-lastRow, nj-1-firstRow
+	}
+	start, end := jFirst*ni, (jLast+1)*ni
+
+	first, n := start, end-start
+	if hasBitmap {
+		first = bitmapIndex.Rank(start)
+		n = bitmapIndex.Rank(end) - first
+	}
+
+	packed, err := f.decodeSimpleRange(first, n)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]float64, (lastRow-firstRow+1)*ni)
+	k := 0
+	for index := start; index < end; index++ {
+		v := math.NaN()
+		if bitmap == nil || bitSet(bitmap, index) {
+			v = packed[k]
+			k++
+		}
+		rows[grid.NorthUpIndex(def, index)-firstRow*ni] = v
+	}
+	return rows, nil
+}
+
+// decodeSimpleRange decodes the n simply packed values from the first-th one, reading
+// only the part of Section 7 holding them
+func (f *FlatMessage) decodeSimpleRange(first, n int) ([]float64, error) {
+	offset, length, skip := packing.SimpleByteRange(&f.DataRep, first, n)
+	data := make([]byte, length)
+	if length > 0 {
+		if _, err := f.Data.ReadDataAt(data, offset); err != nil {
+			return nil, fmt.Errorf("decode: failed to read data section: %w", err)
+		}
+	}
+	raw, ref, E, D, err := packing.DecodeSimpleRawFrom(&f.DataRep, data, skip, n)
+	if err != nil {
+		return nil, err
+	}
+	return packing.Scale(raw, ref, E, D), nil
+}
+
+// decodeRowsFully decodes the whole field and returns the rows firstRow to lastRow of
+// its north-up grid
+func (f *FlatMessage) decodeRowsFully(def grid.Definition, firstRow, lastRow int) ([]float64, error) {
+	values, err := f.Values()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := grid.NorthUp(def, values)
+	if err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+	ni, _ := def.Dims()
+	return rows[firstRow*ni : (lastRow+1)*ni], nil
+}
+
+// bitSet reports whether the bit of the point at index is set in a bit-map
+func bitSet(bitmap []byte, index int) bool {
+	return index/8 < len(bitmap) && bitmap[index/8]&(0x80>>(index%8)) != 0
+}
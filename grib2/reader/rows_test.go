@@ -0,0 +1,99 @@
+package reader_test
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/scorix/grib/grib2/grid"
+	"github.com/scorix/grib/grib2/internal/testgrib"
+	"github.com/scorix/grib/grib2/reader"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// northUpRows expands the decoded values of a field over its grid and returns its
+// north-up rows, as the reference for DecodeRows
+func northUpRows(t *testing.T, msg reader.FlatMessage, bitmap []bool) []float64 {
+	t.Helper()
+	packed, err := msg.DecodeData()
+	require.NoError(t, err)
+	def, err := msg.GridDefinition()
+	require.NoError(t, err)
+
+	values := packed
+	if bitmap != nil {
+		values = make([]float64, len(bitmap))
+		k := 0
+		for index, present := range bitmap {
+			values[index] = math.NaN()
+			if present {
+				values[index] = packed[k]
+				k++
+			}
+		}
+	}
+	rows, err := grid.NorthUp(def, values)
+	require.NoError(t, err)
+	return rows
+}
+
+func TestFlatMessage_DecodeRows(t *testing.T) {
+	const ni, nj = 5, 7
+	values := make([]float64, ni*nj)
+	bitmap := make([]bool, ni*nj)
+	for k := range values {
+		values[k] = float64(k)
+		bitmap[k] = k%3 != 1
+	}
+
+	for scan := range uint8(16) {
+		scan <<= 4
+		for _, mask := range [][]bool{nil, bitmap} {
+			spec := testgrib.Spec{Ni: ni, Nj: nj, LatFirst: 10, LonFirst: 20, ScanningMode: scan, Values: values, Bitmap: mask, BitsPerValue: 7}
+			msg := flatMessages(t, testgrib.MustEncode(spec))[0]
+			want := northUpRows(t, msg, mask)
+
+			for _, rows := range [][2]int{{0, 0}, {2, 4}, {0, nj - 1}, {6, 6}} {
+				got, err := msg.DecodeRows(rows[0], rows[1])
+				require.NoError(t, err)
+				assert.Equal(t, len(want[rows[0]*ni:(rows[1]+1)*ni]), len(got))
+				for k, v := range want[rows[0]*ni : (rows[1]+1)*ni] {
+					if math.IsNaN(v) {
+						assert.True(t, math.IsNaN(got[k]), "scan %08b rows %v at %d", scan, rows, k)
+					} else {
+						assert.Equal(t, v, got[k], "scan %08b rows %v at %d", scan, rows, k)
+					}
+				}
+			}
+		}
+	}
+
+	msg := flatMessages(t, testgrib.MustEncode(testgrib.Spec{}))[0]
+	_, err := msg.DecodeRows(2, 4)
+	assert.ErrorContains(t, err, "rows 2 to 4 out of range [0, 4)")
+}
+
+func TestFlatMessage_DecodeRows_ReadsRowsOnly(t *testing.T) {
+	const ni, nj = 360, 181
+	values := make([]float64, ni*nj)
+	for k := range values {
+		values[k] = float64(k % 1000)
+	}
+	data := testgrib.MustEncode(testgrib.Spec{Ni: ni, Nj: nj, LatFirst: 90, Values: values, BitsPerValue: 10})
+
+	metrics := &reader.MemoryMetrics{}
+	var msg reader.FlatMessage
+	require.NoError(t, reader.NewReaderAt(bytes.NewReader(data), reader.WithMetrics(metrics)).EachFlatMessage(func(_ int, m reader.FlatMessage) bool {
+		msg = m
+		return false
+	}))
+
+	before := metrics.TotalBytes()
+	rows, err := msg.DecodeRows(100, 109)
+	require.NoError(t, err)
+	assert.Equal(t, values[100*ni:110*ni], rows)
+
+	// Ten rows of 10 bit values, rather than the whole section
+	assert.Equal(t, int64(10*ni*10/8), metrics.TotalBytes()-before)
+}
@@ -0,0 +1,131 @@
+package reader
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ScanDir summarises the files under dir whose names match pattern, in the syntax of
+// filepath.Match, or every file for an empty pattern; inventories ending in ".idx" are
+// never summarised themselves. Up to workers files are read at a time, or GOMAXPROCS for
+// workers <= 0. Each file is opened with Open, so an inventory next to it drives the
+// enumeration of its messages.
+//
+// A file that cannot be summarised does not stop the walk: its summary carries the
+// error. The error returned is for walking dir, or ctx being cancelled. Summaries are
+// ordered by path; RollUpCycles combines their cycles.
+func ScanDir(ctx context.Context, dir, pattern string, workers int, opts ...Option) ([]FileSummary, error) {
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("scan dir: %w", err)
+	}
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	var paths []string
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !entry.Type().IsRegular() || strings.HasSuffix(entry.Name(), ".idx") {
+			return nil
+		}
+		if matched, _ := filepath.Match(pattern, entry.Name()); pattern == "" || matched {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scan dir: %w", err)
+	}
+
+	summaries := make([]FileSummary, len(paths))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range min(workers, len(paths)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				summaries[i] = summarizeFile(paths[i], opts)
+			}
+		}()
+	}
+
+dispatch:
+	for i := range paths {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return summaries, nil
+}
+
+// summarizeFile summarises the file at path, recording in the summary why it failed
+func summarizeFile(path string, opts []Option) FileSummary {
+	f, err := Open(path, opts...)
+	if err != nil {
+		return FileSummary{Path: path, Error: err.Error()}
+	}
+	defer f.Close()
+
+	summary, err := f.Summary()
+	if err != nil {
+		return FileSummary{Path: path, Error: err.Error()}
+	}
+	summary.Path = path
+	return summary
+}
+
+// RollUpCycles combines the cycles of several file summaries, such as those returned by
+// ScanDir, so that a cycle spread over several files is counted once with the number of
+// files holding it. Files that could not be summarised contribute nothing.
+func RollUpCycles(summaries []FileSummary) []CycleSummary {
+	byKey := make(map[cycleKey]*CycleSummary)
+	for _, summary := range summaries {
+		for _, c := range summary.Cycles {
+			key := cycleKey{referenceTime: c.ReferenceTime, centre: c.Centre, generatingProcess: c.GeneratingProcess}
+			total, ok := byKey[key]
+			if !ok {
+				total = &CycleSummary{
+					ReferenceTime:     c.ReferenceTime,
+					Centre:            c.Centre,
+					GeneratingProcess: c.GeneratingProcess,
+					Model:             c.Model,
+				}
+				byKey[key] = total
+			}
+			total.Files += c.Files
+			total.Messages += c.Messages
+			total.Fields += c.Fields
+			for _, step := range c.Steps {
+				total.Steps = countStep(total.Steps, step, step.Fields)
+			}
+		}
+	}
+
+	cycles := make([]CycleSummary, 0, len(byKey))
+	for _, c := range byKey {
+		slices.SortFunc(c.Steps, compareSteps)
+		cycles = append(cycles, *c)
+	}
+	slices.SortFunc(cycles, compareCycles)
+	return cycles
+}
@@ -0,0 +1,67 @@
+package reader_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/scorix/grib/grib2/reader"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanDir(t *testing.T) {
+	data, err := os.ReadFile("testdata/gfs.t00z.pgrb2.0p25.f000")
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "sub"), 0o755))
+	for name, content := range map[string][]byte{
+		"a.grib2":     data,
+		"sub/b.grib2": data,
+		"c.grib2":     append([]byte("GRIB"), make([]byte, 12)...), // Invalid total length
+		"notes.txt":   []byte("not matched"),
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), content, 0o644))
+	}
+
+	summaries, err := reader.ScanDir(context.Background(), dir, "*.grib2", 2)
+	require.NoError(t, err)
+	require.Len(t, summaries, 3)
+
+	assert.Equal(t, filepath.Join(dir, "a.grib2"), summaries[0].Path)
+	assert.Empty(t, summaries[0].Error)
+	assert.Equal(t, 3, summaries[0].Fields)
+
+	assert.Equal(t, filepath.Join(dir, "c.grib2"), summaries[1].Path)
+	assert.Contains(t, summaries[1].Error, "invalid total length")
+	assert.Contains(t, summaries[1].String(), "error: ")
+
+	assert.Equal(t, filepath.Join(dir, "sub", "b.grib2"), summaries[2].Path)
+	assert.Empty(t, summaries[2].Error)
+
+	cycles := reader.RollUpCycles(summaries)
+	require.Len(t, cycles, 1)
+	assert.Equal(t, "2024-10-01T00:00:00Z", cycles[0].ReferenceTime.Format("2006-01-02T15:04:05Z07:00"))
+	assert.Equal(t, 7, cycles[0].Centre)
+	assert.Equal(t, 2, cycles[0].Files)
+	assert.Equal(t, 6, cycles[0].Messages)
+	assert.Equal(t, 6, cycles[0].Fields)
+	require.Len(t, cycles[0].Steps, 1)
+	assert.Equal(t, "anl", cycles[0].Steps[0].Step)
+	assert.Equal(t, 6, cycles[0].Steps[0].Fields)
+}
+
+func TestScanDir_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := reader.ScanDir(ctx, "testdata", "", 1)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestScanDir_BadPattern(t *testing.T) {
+	_, err := reader.ScanDir(context.Background(), "testdata", "[", 1)
+	assert.Error(t, err)
+}
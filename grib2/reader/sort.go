@@ -0,0 +1,156 @@
+package reader
+
+import (
+	"cmp"
+	"slices"
+	"time"
+)
+
+// SortKey is an attribute flat messages are sorted on
+type SortKey int
+
+// Predefined sort keys
+const (
+	// ValidTime orders messages by valid time; messages without a valid time sort first
+	ValidTime SortKey = iota
+	// Parameter orders messages by discipline, parameter category and parameter number
+	Parameter
+	// LevelValue orders messages by type of first fixed surface and then by its value;
+	// missing values sort first
+	LevelValue
+	// EnsembleMember orders messages by perturbation number; non-ensemble messages sort first
+	EnsembleMember
+	// Offset orders messages by file position
+	Offset
+)
+
+// sortRecord holds the attributes of a message compared by the sort keys, computed once
+// per message
+type sortRecord struct {
+	validTime  time.Time
+	parameter  [3]int // Discipline, category and number
+	surface    uint8
+	hasLevel   bool
+	level      float64
+	member     int
+	offset     int64
+	fieldIndex int
+}
+
+// SortFlatMessages sorts msgs in place by the given keys, in order of precedence.
+// The sort is stable, so messages that compare equal on all keys keep their file order.
+// The keys of each message are computed once, and the messages themselves are moved only
+// once into their sorted positions.
+func SortFlatMessages(msgs []FlatMessage, keys ...SortKey) {
+	if len(keys) == 0 {
+		return
+	}
+
+	records := make([]sortRecord, len(msgs))
+	order := make([]int, len(msgs))
+	for i := range msgs {
+		records[i] = newSortRecord(&msgs[i], keys)
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(i, j int) int {
+		for _, key := range keys {
+			if c := compareSortRecords(&records[i], &records[j], key); c != 0 {
+				return c
+			}
+		}
+		return 0
+	})
+
+	// Move each message to its sorted position, following the cycles of the permutation;
+	// order[j] is the position the message sorted at j comes from, or j once it is in place
+	for i := range order {
+		if order[i] == i {
+			continue
+		}
+		first := msgs[i]
+		j := i
+		for {
+			from := order[j]
+			order[j] = j
+			if from == i {
+				msgs[j] = first
+				break
+			}
+			msgs[j] = msgs[from]
+			j = from
+		}
+	}
+}
+
+// newSortRecord computes the attributes of f that the keys compare
+func newSortRecord(f *FlatMessage, keys []SortKey) sortRecord {
+	var r sortRecord
+	for _, key := range keys {
+		switch key {
+		case ValidTime:
+			r.validTime, _ = f.ValidTime()
+		case Parameter:
+			r.parameter = [3]int{f.Discipline, int(f.Product.Category), int(f.Product.Parameter)}
+		case LevelValue:
+			r.surface = f.Product.TypeOfFirstFixedSurface
+			r.level, r.hasLevel = f.Product.FirstFixedSurfaceValue()
+		case EnsembleMember:
+			r.member = ensembleMember(f)
+		case Offset:
+			r.offset, r.fieldIndex = f.Offset, f.Index
+		}
+	}
+	return r
+}
+
+// compareSortRecords compares a and b on key
+func compareSortRecords(a, b *sortRecord, key SortKey) int {
+	switch key {
+	case ValidTime:
+		return a.validTime.Compare(b.validTime)
+	case Parameter:
+		return slices.Compare(a.parameter[:], b.parameter[:])
+	case LevelValue:
+		return cmp.Or(
+			cmp.Compare(a.surface, b.surface),
+			compareBool(a.hasLevel, b.hasLevel),
+			cmp.Compare(a.level, b.level),
+		)
+	case EnsembleMember:
+		return cmp.Compare(a.member, b.member)
+	case Offset:
+		return cmp.Or(cmp.Compare(a.offset, b.offset), cmp.Compare(a.fieldIndex, b.fieldIndex))
+	}
+	return 0
+}
+
+// GroupBy groups msgs by the key returned from keyFn.
+// Messages keep their relative order within each group.
+func GroupBy(msgs []FlatMessage, keyFn func(*FlatMessage) string) map[string][]FlatMessage {
+	groups := make(map[string][]FlatMessage)
+	for i := range msgs {
+		key := keyFn(&msgs[i])
+		groups[key] = append(groups[key], msgs[i])
+	}
+	return groups
+}
+
+// ensembleMember returns the perturbation number, or -1 for non-ensemble messages
+func ensembleMember(f *FlatMessage) int {
+	if f.Product.Ensemble == nil {
+		return -1
+	}
+	return int(f.Product.Ensemble.PerturbationNumber)
+}
+
+// compareBool orders false before true
+func compareBool(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case !a:
+		return -1
+	default:
+		return 1
+	}
+}
@@ -0,0 +1,114 @@
+package reader_test
+
+import (
+	"math/rand/v2"
+	"testing"
+
+	"github.com/scorix/grib/grib2/internal/testgrib"
+	"github.com/scorix/grib/grib2/reader"
+	"github.com/scorix/grib/grib2/template"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sortFixture builds a set of flat messages covering two parameters, two forecast steps
+// and two isobaric levels, in file order
+func sortFixture(t *testing.T) []reader.FlatMessage {
+	var data []byte
+	for _, parameter := range []uint8{0, 2} {
+		for _, forecastTime := range []uint32{6, 0} {
+			for _, level := range []uint32{85000, 50000} {
+				data = append(data, testgrib.MustEncode(testgrib.Spec{
+					Parameter:     parameter,
+					ForecastHours: forecastTime,
+					SurfaceType:   100,
+					SurfaceValue:  level,
+				})...)
+			}
+		}
+	}
+
+	messages := flatMessages(t, data)
+	require.Len(t, messages, 8)
+	return messages
+}
+
+func TestSortFlatMessages(t *testing.T) {
+	messages := sortFixture(t)
+	rand.New(rand.NewPCG(1, 2)).Shuffle(len(messages), func(i, j int) {
+		messages[i], messages[j] = messages[j], messages[i]
+	})
+
+	reader.SortFlatMessages(messages, reader.ValidTime, reader.Parameter, reader.LevelValue)
+
+	type key struct {
+		step      uint32
+		parameter uint8
+		level     float64
+	}
+	var got []key
+	for _, msg := range messages {
+		level, ok := msg.Product.FirstFixedSurfaceValue()
+		require.True(t, ok)
+		got = append(got, key{msg.Product.ForecastTime, msg.Product.Parameter, level})
+	}
+
+	assert.Equal(t, []key{
+		{0, 0, 50000}, {0, 0, 85000}, {0, 2, 50000}, {0, 2, 85000},
+		{6, 0, 50000}, {6, 0, 85000}, {6, 2, 50000}, {6, 2, 85000},
+	}, got)
+}
+
+func TestSortFlatMessagesByOffsetRestoresFileOrder(t *testing.T) {
+	messages := sortFixture(t)
+	want := append([]reader.FlatMessage{}, messages...)
+
+	reader.SortFlatMessages(messages, reader.LevelValue)
+	reader.SortFlatMessages(messages, reader.Offset)
+
+	assert.Equal(t, want, messages)
+}
+
+func TestSortFlatMessagesIsStable(t *testing.T) {
+	messages := sortFixture(t)
+
+	reader.SortFlatMessages(messages, reader.Parameter)
+
+	var indices []int
+	for _, msg := range messages {
+		indices = append(indices, msg.Index)
+	}
+	assert.Equal(t, []int{0, 1, 2, 3, 4, 5, 6, 7}, indices)
+}
+
+func TestSortFlatMessagesByEnsembleMember(t *testing.T) {
+	messages := []reader.FlatMessage{
+		{Index: 0, Product: template.ProductTemplate{Ensemble: &template.EnsembleInfo{PerturbationNumber: 2}}},
+		{Index: 1},
+		{Index: 2, Product: template.ProductTemplate{Ensemble: &template.EnsembleInfo{PerturbationNumber: 1}}},
+	}
+
+	reader.SortFlatMessages(messages, reader.EnsembleMember)
+
+	assert.Equal(t, 1, messages[0].Index)
+	assert.Equal(t, 2, messages[1].Index)
+	assert.Equal(t, 0, messages[2].Index)
+}
+
+func TestGroupBy(t *testing.T) {
+	messages := sortFixture(t)
+
+	groups := reader.GroupBy(messages, func(msg *reader.FlatMessage) string {
+		return msg.StepString()
+	})
+
+	require.Len(t, groups, 2)
+	for step, group := range groups {
+		require.Len(t, group, 4, step)
+		for i := 1; i < len(group); i++ {
+			assert.Less(t, group[i-1].Index, group[i].Index, "group %q keeps file order", step)
+		}
+	}
+	assert.Equal(t, uint32(0), groups["anl"][0].Product.ForecastTime)
+	assert.Equal(t, uint32(6), groups["6 hour fcst"][0].Product.ForecastTime)
+}
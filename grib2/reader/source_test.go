@@ -0,0 +1,108 @@
+package reader_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/scorix/grib/grib2/internal/testgrib"
+	"github.com/scorix/grib/grib2/reader"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithSource(t *testing.T) {
+	// Two fields sharing a grid, then a single field message
+	data := append(messageFromSections(1, 3, 4, 5, 6, 7, 4, 5, 6, 7), testgrib.MustEncode(testgrib.Spec{})...)
+	const source = "s3://bucket/gfs.t00z.pgrb2.0p25.f000"
+
+	t.Run("ReaderAt", func(t *testing.T) {
+		r := reader.NewReaderAt(bytes.NewReader(data), reader.WithSource(source))
+		require.NoError(t, r.EachMessage(func(_ int, info reader.MessageInfo) bool {
+			assert.Equal(t, source, info.Source)
+			return true
+		}))
+
+		var fields int
+		require.NoError(t, r.EachFlatMessage(func(_ int, msg reader.FlatMessage) bool {
+			assert.Equal(t, source, msg.Source)
+			fields++
+			return true
+		}))
+		assert.Equal(t, 3, fields)
+
+		fields = 0
+		require.NoError(t, r.EachFilteredMessage(reader.Filter{}, func(_ int, msg reader.FlatMessage) bool {
+			assert.Equal(t, source, msg.Source)
+			fields++
+			return true
+		}))
+		assert.Equal(t, 3, fields)
+	})
+
+	t.Run("Reader", func(t *testing.T) {
+		var fields int
+		require.NoError(t, reader.NewReader(bytes.NewReader(data), reader.WithSource(source)).EachFlatMessage(func(_ int, msg reader.FlatMessage) bool {
+			assert.Equal(t, source, msg.Source)
+			fields++
+			return true
+		}))
+		assert.Equal(t, 3, fields)
+	})
+
+	t.Run("Stream", func(t *testing.T) {
+		// Fields of several files interleave on the consumer side, each carrying its source
+		sources := map[string][]byte{"a.grib2": data, "b.grib2": testgrib.MustEncode(testgrib.Spec{})}
+		counts := make(map[string]int)
+		for _, name := range slices.Sorted(maps.Keys(sources)) {
+			results, err := reader.NewReaderAt(bytes.NewReader(sources[name]), reader.WithSource(name)).
+				Stream(context.Background(), reader.StreamOptions{Buffer: 1})
+			require.NoError(t, err)
+			for result := range results {
+				require.NoError(t, result.Err)
+				counts[result.Message.Source]++
+			}
+		}
+		assert.Equal(t, map[string]int{"a.grib2": 3, "b.grib2": 1}, counts)
+	})
+
+	t.Run("metadata", func(t *testing.T) {
+		msgs := flatMessages(t, data)
+		for i := range msgs {
+			msgs[i].Source = source
+		}
+
+		var buf bytes.Buffer
+		require.NoError(t, reader.WriteTSV(&buf, reader.SourceMessages(slices.Values(msgs))))
+		lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+		require.Len(t, lines, 4)
+		for _, line := range lines[1:] {
+			assert.True(t, strings.HasPrefix(line, source+"\t"), line)
+		}
+
+		encoded, err := json.Marshal(msgs[0].Metadata(""))
+		require.NoError(t, err)
+		assert.Contains(t, string(encoded), `"file_path":"`+source+`"`)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		corrupt := append(append([]byte(nil), data...), "junk"...)
+
+		err := reader.NewReaderAt(bytes.NewReader(corrupt), reader.WithSource(source)).EachMessage(func(int, reader.MessageInfo) bool { return true })
+		require.Error(t, err)
+		assert.True(t, strings.HasPrefix(err.Error(), source+": "), err.Error())
+
+		results, err := reader.NewReaderAt(bytes.NewReader(corrupt), reader.WithSource(source)).Stream(context.Background(), reader.StreamOptions{})
+		require.NoError(t, err)
+		var streamErr error
+		for result := range results {
+			streamErr = result.Err
+		}
+		require.Error(t, streamErr)
+		assert.True(t, strings.HasPrefix(streamErr.Error(), source+": "), streamErr.Error())
+	})
+}
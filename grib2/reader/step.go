@@ -0,0 +1,132 @@
+package reader
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/scorix/grib/grib2/template"
+)
+
+// stepUnit describes how a Code Table 4.4 unit is written in step strings
+type stepUnit struct {
+	name       string // Unit name used in step strings
+	multiplier uint32 // Number of named units per coded unit
+}
+
+// stepUnits maps Code Table 4.4 units to the names used by wgrib2 style step strings
+var stepUnits = map[uint8]stepUnit{
+	template.TimeUnitMinute:  {"min", 1},
+	template.TimeUnitHour:    {"hour", 1},
+	template.TimeUnitDay:     {"day", 1},
+	template.TimeUnitMonth:   {"month", 1},
+	template.TimeUnitYear:    {"year", 1},
+	template.TimeUnitDecade:  {"year", 10},
+	template.TimeUnitNormal:  {"year", 30},
+	template.TimeUnitCentury: {"year", 100},
+	template.TimeUnit3Hours:  {"hour", 3},
+	template.TimeUnit6Hours:  {"hour", 6},
+	template.TimeUnit12Hours: {"hour", 12},
+	template.TimeUnitSecond:  {"sec", 1},
+}
+
+// statisticalProcessAbbreviations maps Code Table 4.10 to the abbreviations used in step strings
+var statisticalProcessAbbreviations = map[template.StatisticalProcess]string{
+	template.StatisticalAverage:             "ave",
+	template.StatisticalAccumulation:        "acc",
+	template.StatisticalMaximum:             "max",
+	template.StatisticalMinimum:             "min",
+	template.StatisticalDifference:          "last-first",
+	template.StatisticalRootMeanSquare:      "RMS",
+	template.StatisticalStandardDeviation:   "StdDev",
+	template.StatisticalCovariance:          "covar",
+	template.StatisticalReverseDifference:   "first-last",
+	template.StatisticalRatio:               "ratio",
+	template.StatisticalStandardizedAnomaly: "standardized anomaly",
+	template.StatisticalSummation:           "summ",
+}
+
+// StatisticalProcess returns the statistical processing over time of statistically
+// processed products, from their outermost time range. It reports false for all other
+// products; the processing over a spatial area of template 4.15 is in Product.Spatial.
+func (f *FlatMessage) StatisticalProcess() (template.StatisticalProcess, bool) {
+	timeRange := f.Product.TimeRange
+	if timeRange == nil || len(timeRange.TimeRanges) == 0 {
+		return 0, false
+	}
+	return timeRange.StatisticalProcess(), true
+}
+
+// StepRange returns the forecast step as offsets from the reference time.
+// For statistically processed products the range spans the outermost time range,
+// starting at the forecast time; for all other products start and end are equal.
+func (f *FlatMessage) StepRange() (start, end time.Duration, err error) {
+	unit, ok := template.TimeUnitDuration(f.Product.IndicatorOfUnitOfTimeRange)
+	if !ok {
+		return 0, 0, fmt.Errorf("step: unsupported unit of time range %d", f.Product.IndicatorOfUnitOfTimeRange)
+	}
+	start = time.Duration(f.Product.ForecastTime) * unit
+
+	timeRange := f.Product.TimeRange
+	if timeRange == nil || len(timeRange.TimeRanges) == 0 {
+		return start, start, nil
+	}
+
+	rangeUnit, ok := template.TimeUnitDuration(timeRange.IndicatorOfUnitForTimeRange)
+	if !ok {
+		return 0, 0, fmt.Errorf("step: unsupported unit for time range %d", timeRange.IndicatorOfUnitForTimeRange)
+	}
+
+	return start, start + time.Duration(timeRange.LengthOfTimeRange)*rangeUnit, nil
+}
+
+// StepString formats the forecast step following wgrib2 conventions,
+// e.g. "anl", "6 hour fcst" or "0-6 hour acc fcst"
+func (f *FlatMessage) StepString() string {
+	timeRange := f.Product.TimeRange
+	if timeRange == nil || len(timeRange.TimeRanges) == 0 {
+		if f.Product.ForecastTime == 0 {
+			return "anl"
+		}
+
+		value, name, ok := stepValue(f.Product.ForecastTime, f.Product.IndicatorOfUnitOfTimeRange)
+		if !ok {
+			return fmt.Sprintf("%d unit(%d) fcst", f.Product.ForecastTime, f.Product.IndicatorOfUnitOfTimeRange)
+		}
+		return fmt.Sprintf("%d %s fcst", value, name)
+	}
+
+	process, ok := statisticalProcessAbbreviations[timeRange.StatisticalProcess()]
+	if !ok {
+		process = fmt.Sprintf("stat(%d)", timeRange.TypeOfStatisticalProcessing)
+	}
+
+	// Prefer the native units when both ends are expressed in the same unit
+	start, startName, startOK := stepValue(f.Product.ForecastTime, f.Product.IndicatorOfUnitOfTimeRange)
+	length, lengthName, lengthOK := stepValue(timeRange.LengthOfTimeRange, timeRange.IndicatorOfUnitForTimeRange)
+	if startOK && lengthOK && startName == lengthName {
+		return fmt.Sprintf("%d-%d %s %s fcst", start, start+length, startName, process)
+	}
+
+	startDuration, endDuration, err := f.StepRange()
+	if err != nil {
+		return fmt.Sprintf("? %s fcst", process)
+	}
+
+	switch {
+	case startDuration%time.Hour == 0 && endDuration%time.Hour == 0:
+		return fmt.Sprintf("%d-%d hour %s fcst", startDuration/time.Hour, endDuration/time.Hour, process)
+	case startDuration%time.Minute == 0 && endDuration%time.Minute == 0:
+		return fmt.Sprintf("%d-%d min %s fcst", startDuration/time.Minute, endDuration/time.Minute, process)
+	default:
+		return fmt.Sprintf("%d-%d sec %s fcst", startDuration/time.Second, endDuration/time.Second, process)
+	}
+}
+
+// stepValue converts a value in a Code Table 4.4 unit into the unit named in step strings
+func stepValue(value uint32, unit uint8) (uint32, string, bool) {
+	u, ok := stepUnits[unit]
+	if !ok {
+		return 0, "", false
+	}
+	return value * u.multiplier, u.name, true
+}
@@ -0,0 +1,253 @@
+package reader_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/scorix/grib/grib2/internal/testgrib"
+	"github.com/scorix/grib/grib2/template"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stepReference is the reference time of the messages in the step tests
+var stepReference = time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+
+func TestFlatMessage_StepString(t *testing.T) {
+	tests := []struct {
+		name      string
+		spec      testgrib.Spec
+		wantStart time.Duration
+		wantEnd   time.Duration
+		wantStep  string
+	}{
+		{
+			name:     "analysis",
+			spec:     testgrib.Spec{Category: 3, Parameter: 1, SurfaceType: 101},
+			wantStep: "anl",
+		},
+		{
+			name:      "instantaneous forecast",
+			spec:      testgrib.Spec{Forecast: &testgrib.Duration{Unit: 1, Length: 6}},
+			wantStart: 6 * time.Hour,
+			wantEnd:   6 * time.Hour,
+			wantStep:  "6 hour fcst",
+		},
+		{
+			name:      "minute forecast",
+			spec:      testgrib.Spec{Forecast: &testgrib.Duration{Unit: 0, Length: 90}},
+			wantStart: 90 * time.Minute,
+			wantEnd:   90 * time.Minute,
+			wantStep:  "90 min fcst",
+		},
+		{
+			// Total precipitation since the reference time
+			name: "accumulation from reference time",
+			spec: testgrib.Spec{
+				ProductTemplate: 8, Category: 1, Parameter: 8,
+				TimeRanges: []testgrib.TimeRange{{Process: 1, Unit: 1, Length: 6}},
+			},
+			wantStart: 0,
+			wantEnd:   6 * time.Hour,
+			wantStep:  "0-6 hour acc fcst",
+		},
+		{
+			// Total precipitation over a 6-hour bucket
+			name: "accumulation bucket",
+			spec: testgrib.Spec{
+				ProductTemplate: 8, Category: 1, Parameter: 8, ForecastHours: 6,
+				TimeRanges: []testgrib.TimeRange{{Process: 1, Unit: 1, Length: 6}},
+			},
+			wantStart: 6 * time.Hour,
+			wantEnd:   12 * time.Hour,
+			wantStep:  "6-12 hour acc fcst",
+		},
+		{
+			// Maximum temperature over 6 hours
+			name: "maximum",
+			spec: testgrib.Spec{
+				ProductTemplate: 8, Parameter: 4, ForecastHours: 6, SurfaceType: 103,
+				TimeRanges: []testgrib.TimeRange{{Process: 2, Unit: 1, Length: 6}},
+			},
+			wantStart: 6 * time.Hour,
+			wantEnd:   12 * time.Hour,
+			wantStep:  "6-12 hour max fcst",
+		},
+		{
+			name: "average with mixed units",
+			spec: testgrib.Spec{
+				ProductTemplate: 8, Forecast: &testgrib.Duration{Unit: 0, Length: 0},
+				TimeRanges: []testgrib.TimeRange{{Process: 0, Unit: 2, Length: 1}},
+			},
+			wantStart: 0,
+			wantEnd:   24 * time.Hour,
+			wantStep:  "0-24 hour ave fcst",
+		},
+		{
+			name: "six hour units",
+			spec: testgrib.Spec{
+				ProductTemplate: 8, Category: 1, Parameter: 8, Forecast: &testgrib.Duration{Unit: 11, Length: 1},
+				TimeRanges: []testgrib.TimeRange{{Process: 1, Unit: 11, Length: 1}},
+			},
+			wantStart: 6 * time.Hour,
+			wantEnd:   12 * time.Hour,
+			wantStep:  "6-12 hour acc fcst",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.spec.ReferenceTime = stepReference
+			messages := flatMessages(t, testgrib.MustEncode(tt.spec))
+			require.Len(t, messages, 1)
+			msg := messages[0]
+
+			start, end, err := msg.StepRange()
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStart, start)
+			assert.Equal(t, tt.wantEnd, end)
+			assert.Equal(t, tt.wantStep, msg.StepString())
+		})
+	}
+}
+
+func TestFlatMessage_TimeRange(t *testing.T) {
+	messages := flatMessages(t, testgrib.MustEncode(testgrib.Spec{
+		ReferenceTime: stepReference, ProductTemplate: 8, Category: 1, Parameter: 8,
+		TimeRanges: []testgrib.TimeRange{{Process: 1, Unit: 1, Length: 6}},
+	}))
+	require.Len(t, messages, 1)
+
+	timeRange := messages[0].Product.TimeRange
+	require.NotNil(t, timeRange)
+	assert.Equal(t, uint16(2024), timeRange.EndYear)
+	assert.Equal(t, uint8(3), timeRange.EndMonth)
+	assert.Equal(t, uint8(15), timeRange.EndDay)
+	assert.Equal(t, uint8(6), timeRange.EndHour)
+	assert.Equal(t, uint16(1), timeRange.NumberOfTimeRanges)
+	assert.Equal(t, uint8(1), timeRange.TypeOfStatisticalProcessing) // accumulation
+	assert.Equal(t, uint32(6), timeRange.LengthOfTimeRange)
+	require.Len(t, timeRange.TimeRanges, 1)
+	assert.Equal(t, uint8(1), timeRange.TimeRanges[0].IndicatorOfUnitForTimeRange)
+}
+
+func TestFlatMessage_TimeRange_Templates(t *testing.T) {
+	// The overall time interval follows the template-specific block of each template
+	blocks := map[uint16]int{
+		9:  13, // Probability number, type and limits
+		10: 1,  // Percentile value
+		11: 3,  // Type of ensemble forecast, perturbation number, number of forecasts
+		12: 2,  // Type of derived forecast, number of forecasts
+		13: 34, // Derived forecast and rectangular cluster
+		14: 31, // Derived forecast and circular cluster
+	}
+	for number, size := range blocks {
+		t.Run(fmt.Sprintf("4.%d", number), func(t *testing.T) {
+			spec := testgrib.Spec{
+				ReferenceTime: stepReference, ProductTemplate: number,
+				Category: 1, Parameter: 8, ForecastHours: 6,
+				ProductBlock: bytes.Repeat([]byte{0x07}, size),
+				TimeRanges: []testgrib.TimeRange{
+					{Process: 1, Unit: 1, Length: 18},
+					{Process: 0, Unit: 0, Length: 60},
+				},
+				EndTime: time.Date(2024, 3, 16, 0, 30, 0, 0, time.UTC),
+			}
+			if number == 11 {
+				// Template 4.11 lays out its ensemble block from the ensemble fields
+				spec.ProductBlock = nil
+				spec.EnsembleType, spec.PerturbationNumber, spec.EnsembleSize = 7, 7, 7
+			}
+			messages := flatMessages(t, testgrib.MustEncode(spec))
+			require.Len(t, messages, 1)
+
+			timeRange := messages[0].Product.TimeRange
+			require.NotNil(t, timeRange)
+			assert.Equal(t, uint16(2024), timeRange.EndYear)
+			assert.Equal(t, uint8(3), timeRange.EndMonth)
+			assert.Equal(t, uint8(16), timeRange.EndDay)
+			assert.Equal(t, uint8(0), timeRange.EndHour)
+			assert.Equal(t, uint8(30), timeRange.EndMinute)
+			assert.Equal(t, uint16(2), timeRange.NumberOfTimeRanges)
+			assert.Equal(t, []template.TimeRangeSpec{
+				{StatisticalProcessType: 1, TimeIncrementType: 2, IndicatorOfUnitForTimeRange: 1, TimeRangeLength: 18, IndicatorOfUnitForTimeIncrement: 0xff},
+				{StatisticalProcessType: 0, TimeIncrementType: 2, IndicatorOfUnitForTimeRange: 0, TimeRangeLength: 60, IndicatorOfUnitForTimeIncrement: 0xff},
+			}, timeRange.TimeRanges)
+			assert.Equal(t, template.StatisticalAccumulation, timeRange.StatisticalProcess())
+
+			start, end, err := messages[0].StepRange()
+			require.NoError(t, err)
+			assert.Equal(t, 6*time.Hour, start)
+			assert.Equal(t, 24*time.Hour, end)
+		})
+	}
+}
+
+func TestFlatMessage_StepString_TestData(t *testing.T) {
+	messages := flatMessages(t, getTestData(t))
+	require.NotEmpty(t, messages)
+
+	for _, msg := range messages {
+		assert.Equal(t, "anl", msg.StepString())
+	}
+}
+
+func TestFlatMessage_StatisticalProcess(t *testing.T) {
+	tests := []struct {
+		name        string
+		spec        testgrib.Spec
+		want        template.StatisticalProcess
+		wantOK      bool
+		wantStep    string
+		wantMethods string
+	}{
+		{
+			name:        "instantaneous",
+			spec:        testgrib.Spec{ForecastHours: 6},
+			wantStep:    "6 hour fcst",
+			wantMethods: "time: point",
+		},
+		{
+			name:        "accumulation",
+			spec:        testgrib.Spec{ProductTemplate: 8, StatisticalProcess: 1, RangeHours: 6},
+			want:        template.StatisticalAccumulation,
+			wantOK:      true,
+			wantStep:    "0-6 hour acc fcst",
+			wantMethods: "time: sum",
+		},
+		{
+			name:        "average",
+			spec:        testgrib.Spec{ProductTemplate: 8, StatisticalProcess: 0, ForecastHours: 6, RangeHours: 6},
+			want:        template.StatisticalAverage,
+			wantOK:      true,
+			wantStep:    "6-12 hour ave fcst",
+			wantMethods: "time: mean",
+		},
+		{
+			name:        "maximum",
+			spec:        testgrib.Spec{ProductTemplate: 8, StatisticalProcess: 2, RangeHours: 3},
+			want:        template.StatisticalMaximum,
+			wantOK:      true,
+			wantStep:    "0-3 hour max fcst",
+			wantMethods: "time: maximum",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msgs := flatMessages(t, testgrib.MustEncode(tt.spec))
+			require.Len(t, msgs, 1)
+
+			process, ok := msgs[0].StatisticalProcess()
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, process)
+			assert.Equal(t, tt.wantStep, msgs[0].StepString())
+
+			attrs, err := msgs[0].CFAttributes()
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantMethods, attrs["cell_methods"])
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package reader
+
+import (
+	"context"
+	"fmt"
+)
+
+// StreamOptions configures ReaderAt.Stream
+type StreamOptions struct {
+	// Buffer is the capacity of the result channel: how many fields the producer may
+	// read ahead of the consumer. Zero makes every send wait for the consumer.
+	Buffer int
+	// MetadataOnly drops Section 7 from the streamed fields, so no data buffers are
+	// held while results wait in the channel. DecodeData is unavailable on such fields;
+	// FieldByteRange locates the data for a later read.
+	MetadataOnly bool
+	// DecodableOnly skips the fields for which CanDecode reports false, so that they can be
+	// routed elsewhere without failing mid-decode.
+	DecodableOnly bool
+}
+
+// FlatResult is one item of a field stream: either a field or the error that ended the stream
+type FlatResult struct {
+	Message FlatMessage
+	Err     error
+}
+
+// Stream reads the flattened fields of the file in a separate goroutine and sends them on
+// the returned channel, which is closed when all fields have been sent, after an error
+// result, or once ctx is cancelled. A consumer that stops receiving early must cancel ctx
+// to release the producer.
+func (r *ReaderAt) Stream(ctx context.Context, opts StreamOptions) (<-chan FlatResult, error) {
+	if opts.Buffer < 0 {
+		return nil, fmt.Errorf("stream: invalid buffer size %d", opts.Buffer)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	results := make(chan FlatResult, opts.Buffer)
+	send := func(result FlatResult) bool {
+		select {
+		case results <- result:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	go func() {
+		defer close(results)
+
+		err := r.EachFlatMessage(func(_ int, msg FlatMessage) bool {
+			if ctx.Err() != nil {
+				return false
+			}
+			if opts.DecodableOnly {
+				if ok, _ := msg.CanDecode(); !ok {
+					return true
+				}
+			}
+			if opts.MetadataOnly {
+				msg.Data = nil
+			}
+			return send(FlatResult{Message: msg})
+		})
+		if err != nil && ctx.Err() == nil {
+			send(FlatResult{Err: err})
+		}
+	}()
+
+	return results, nil
+}
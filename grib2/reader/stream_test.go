@@ -0,0 +1,135 @@
+package reader_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/scorix/grib/grib2/internal/testgrib"
+	"github.com/scorix/grib/grib2/reader"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// trackingReaderAt records the furthest offset read
+type trackingReaderAt struct {
+	io.ReaderAt
+	furthest atomic.Int64
+}
+
+func (r *trackingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	n, err := r.ReaderAt.ReadAt(p, off)
+	end := off + int64(n)
+	for {
+		current := r.furthest.Load()
+		if end <= current || r.furthest.CompareAndSwap(current, end) {
+			break
+		}
+	}
+	return n, err
+}
+
+func TestReaderAt_Stream(t *testing.T) {
+	message := testgrib.MustEncode(testgrib.Spec{})
+	data := bytes.Repeat(message, 20)
+
+	results, err := reader.NewReaderAt(bytes.NewReader(data)).Stream(context.Background(), reader.StreamOptions{Buffer: 4})
+	require.NoError(t, err)
+
+	var count int
+	for result := range results {
+		require.NoError(t, result.Err)
+		assert.Equal(t, count, result.Message.Index)
+		assert.Equal(t, int64(count*len(message)), result.Message.Offset)
+
+		values, err := result.Message.DecodeData()
+		require.NoError(t, err)
+		assert.Len(t, values, 16)
+		count++
+	}
+	assert.Equal(t, 20, count)
+}
+
+func TestReaderAt_Stream_SlowConsumer(t *testing.T) {
+	message := testgrib.MustEncode(testgrib.Spec{})
+	source := &trackingReaderAt{ReaderAt: bytes.NewReader(bytes.Repeat(message, 50))}
+
+	const buffer = 2
+	ctx, cancel := context.WithCancel(context.Background())
+	results, err := reader.NewReaderAt(source).Stream(ctx, reader.StreamOptions{Buffer: buffer, MetadataOnly: true})
+	require.NoError(t, err)
+
+	received := 0
+	for received < 3 {
+		result := <-results
+		require.NoError(t, result.Err)
+		assert.Nil(t, result.Message.Data)
+		received++
+	}
+
+	// Give the producer time to run ahead as far as it can
+	time.Sleep(50 * time.Millisecond)
+
+	// The producer holds at most one field blocked on send beyond the buffered ones,
+	// so it cannot have read past the message after those
+	limit := int64((received + buffer + 2) * len(message))
+	assert.LessOrEqual(t, source.furthest.Load(), limit)
+
+	cancel()
+	done := make(chan struct{})
+	go func() {
+		for range results {
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("stream did not shut down after cancel")
+	}
+	assert.Less(t, source.furthest.Load(), int64(50*len(message)))
+}
+
+func TestReaderAt_Stream_Errors(t *testing.T) {
+	_, err := reader.NewReaderAt(bytes.NewReader(nil)).Stream(context.Background(), reader.StreamOptions{Buffer: -1})
+	require.Error(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = reader.NewReaderAt(bytes.NewReader(nil)).Stream(ctx, reader.StreamOptions{})
+	require.ErrorIs(t, err, context.Canceled)
+
+	// A broken message ends the stream with an error result
+	data := append(testgrib.MustEncode(testgrib.Spec{}), []byte("JUNKJUNKJUNKJUNK")...)
+	results, err := reader.NewReaderAt(bytes.NewReader(data)).Stream(context.Background(), reader.StreamOptions{})
+	require.NoError(t, err)
+
+	var got []reader.FlatResult
+	for result := range results {
+		got = append(got, result)
+	}
+	require.Len(t, got, 2)
+	assert.NoError(t, got[0].Err)
+	assert.Error(t, got[1].Err)
+}
+
+func TestReaderAt_Stream_DecodableOnly(t *testing.T) {
+	// Two decodable fields around one packed with an unsupported template
+	message := testgrib.MustEncode(testgrib.Spec{})
+	data := append(append(append([]byte(nil), message...), unsupportedPackingMessage(t)...), message...)
+
+	results, err := reader.NewReaderAt(bytes.NewReader(data)).Stream(context.Background(), reader.StreamOptions{DecodableOnly: true})
+	require.NoError(t, err)
+
+	var indexes []int
+	for result := range results {
+		require.NoError(t, result.Err)
+		ok, reason := result.Message.CanDecode()
+		require.True(t, ok, reason)
+		indexes = append(indexes, result.Message.Index)
+	}
+	assert.Equal(t, []int{0, 2}, indexes)
+}
@@ -0,0 +1,551 @@
+package reader
+
+import (
+	"cmp"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/scorix/grib/grib2/section"
+)
+
+// FileSummary is an inventory of a file: the parameters, levels, times, grids and
+// packings its fields cover. It is built from Sections 0-5 only, so summarising a file
+// never reads the data of its fields.
+type FileSummary struct {
+	Path  string `json:"path,omitempty"`  // Path of the file, set by ScanDir
+	Error string `json:"error,omitempty"` // Why the file could not be summarised, set by ScanDir
+
+	Messages int `json:"messages"` // Number of messages
+	Fields   int `json:"fields"`   // Number of fields, counting each field of multi-field messages
+
+	Parameters     []ParameterSummary `json:"parameters"`      // Distinct parameters, by discipline, category and number
+	Levels         []LevelSummary     `json:"levels"`          // Distinct levels, by surface type and value
+	ReferenceTimes []time.Time        `json:"reference_times"` // Distinct reference times, ascending; invalid times are left out
+	Steps          []StepSummary      `json:"steps"`           // Distinct forecast steps, by end then start of the step
+	Grids          []GridSummary      `json:"grids"`           // Distinct grids, in order of first appearance
+	Packings       []PackingSummary   `json:"packings"`        // Data representation templates used, ascending
+	Cycles         []CycleSummary     `json:"cycles"`          // Forecast cycles, by reference time, centre and generating process
+	Storage        StorageReport      `json:"storage"`         // Octets taken by the data of the fields
+}
+
+// StorageReport accounts for the octets the data of the fields of a file take, for
+// capacity planning. The data of a field is its Section 7, whose length is known without
+// reading it; its uncompressed size is that of 32-bit floats for all its grid points.
+type StorageReport struct {
+	StorageUsage
+
+	Parameters []ParameterStorage `json:"parameters"` // Usage of each parameter, by octets descending
+	Packings   []PackingStorage   `json:"packings"`   // Usage of each data representation template, by octets descending
+}
+
+// StorageUsage totals the data of a set of fields
+type StorageUsage struct {
+	Fields            int     `json:"fields"`             // Number of fields
+	DataBytes         int64   `json:"data_bytes"`         // Octets of the Sections 7 of the fields
+	UncompressedBytes int64   `json:"uncompressed_bytes"` // Octets of the fields as 32-bit floats, 4 per grid point
+	CompressionRatio  float64 `json:"compression_ratio"`  // UncompressedBytes over DataBytes, 0 without data
+}
+
+// ParameterStorage is the storage used by the fields of one parameter
+type ParameterStorage struct {
+	Discipline   uint8  `json:"discipline"`   // Discipline (Code Table 0.0)
+	Category     uint8  `json:"category"`     // Parameter category (Code Table 4.1)
+	Number       uint8  `json:"number"`       // Parameter number (Code Table 4.2)
+	Abbreviation string `json:"abbreviation"` // Abbreviation, e.g. "TMP", or "" when the parameter is unknown
+	StorageUsage
+}
+
+// PackingStorage is the storage used by the fields of one data representation template
+type PackingStorage struct {
+	Template int `json:"template"` // Data representation template number
+	StorageUsage
+}
+
+// add counts a field of the given data and uncompressed sizes
+func (u *StorageUsage) add(dataBytes, uncompressedBytes int64) {
+	u.Fields++
+	u.DataBytes += dataBytes
+	u.UncompressedBytes += uncompressedBytes
+	if u.DataBytes > 0 {
+		u.CompressionRatio = float64(u.UncompressedBytes) / float64(u.DataBytes)
+	}
+}
+
+// CycleSummary counts the messages and fields of one forecast cycle: a reference time of
+// a model, identified by its originating centre and generating process
+type CycleSummary struct {
+	ReferenceTime     time.Time     `json:"reference_time"`     // Reference time, zero when invalid
+	Centre            int           `json:"centre"`             // Originating centre (Common Code Table C-11)
+	GeneratingProcess uint8         `json:"generating_process"` // Generating process or model identifier
+	Model             string        `json:"model"`              // Name of the generating process at the centre, empty when unknown
+	Files             int           `json:"files"`              // Number of files holding the cycle
+	Messages          int           `json:"messages"`           // Number of messages of the cycle
+	Fields            int           `json:"fields"`             // Number of fields of the cycle
+	Steps             []StepSummary `json:"steps"`              // Forecast steps of the cycle, ordered as FileSummary.Steps
+}
+
+// ParameterSummary counts the fields of one parameter
+type ParameterSummary struct {
+	Discipline   uint8  `json:"discipline"`   // Discipline (Code Table 0.0)
+	Category     uint8  `json:"category"`     // Parameter category (Code Table 4.1)
+	Number       uint8  `json:"number"`       // Parameter number (Code Table 4.2)
+	Abbreviation string `json:"abbreviation"` // Abbreviation, e.g. "TMP", or "" when the parameter is unknown
+	Name         string `json:"name"`         // Parameter name, or "" when the parameter is unknown
+	Unit         string `json:"unit"`         // WMO unit, or "" when the parameter is unknown
+	Fields       int    `json:"fields"`       // Number of fields of the parameter
+}
+
+// LevelSummary counts the fields on one level
+type LevelSummary struct {
+	SurfaceType uint8    `json:"surface_type"` // Type of the first fixed surface (Code Table 4.5), 255 when missing
+	Value       *float64 `json:"value"`        // Value of the first fixed surface, nil when it has none
+	Level       string   `json:"level"`        // wgrib2 style level, e.g. "500 mb"
+	Fields      int      `json:"fields"`       // Number of fields on the level
+}
+
+// StepSummary counts the fields of one forecast step
+type StepSummary struct {
+	Step   string `json:"step"`   // wgrib2 style step, e.g. "6 hour fcst"
+	Fields int    `json:"fields"` // Number of fields of the step
+
+	start, end time.Duration // Range of the step, which steps are sorted by
+	valid      bool          // Whether the range is known
+}
+
+// GridSummary counts the fields on one grid
+type GridSummary struct {
+	// Fingerprint identifies the grid by a hash of its Section 3, so that fields on the
+	// same grid share it whatever their other sections
+	Fingerprint string `json:"fingerprint"`
+	Template    int    `json:"template"` // Grid definition template number
+	Points      int    `json:"points"`   // Number of data points
+	Ni          int    `json:"ni"`       // Points along the i axis, 0 when the geometry is unknown
+	Nj          int    `json:"nj"`       // Points along the j axis, 0 when the geometry is unknown
+	Fields      int    `json:"fields"`   // Number of fields on the grid
+}
+
+// PackingSummary counts the fields of one data representation template
+type PackingSummary struct {
+	Template int `json:"template"` // Data representation template number
+	Fields   int `json:"fields"`   // Number of fields packed with the template
+}
+
+// Summary scans the file and summarises its fields. Only Sections 0-5 of each field are
+// read, so the data of the fields is never read nor decoded.
+func (r *ReaderAt) Summary() (FileSummary, error) {
+	var b summaryBuilder
+	var readErr error
+
+	err := r.EachMessage(func(_ int, info MessageInfo) bool {
+		b.summary.Messages++
+
+		if len(info.Anomalies) > 0 {
+			// Skipped sections make the scanned layout differ from the assembled fields
+			message, err := r.buildMessageFromInfo(info)
+			if err != nil {
+				readErr = err
+				return false
+			}
+			for i, msg := range message.FlattenToFlatMessages() {
+				b.add(&msg, i == 0)
+			}
+			return true
+		}
+
+		fields := newFieldReader(r, info)
+		for i, ranges := range fieldSectionRanges(info.Sections) {
+			msg, err := fields.header(ranges)
+			if err == nil {
+				msg.DataRepSec, err = readFieldSection[section.Section5](fields, ranges, 5)
+			}
+			if err != nil {
+				readErr = err
+				return false
+			}
+			msg.extractDataRepInfo()
+			b.add(&msg, i == 0)
+		}
+		return true
+	})
+	if err != nil {
+		return FileSummary{}, err
+	}
+	if readErr != nil {
+		return FileSummary{}, r.opts.sourced(readErr)
+	}
+	return b.finish(), nil
+}
+
+// summaryBuilder accumulates the fields of a FileSummary
+type summaryBuilder struct {
+	summary    FileSummary
+	parameters map[[3]uint8]*ParameterSummary
+	levels     map[string]*LevelSummary
+	times      map[time.Time]bool
+	steps      map[string]*StepSummary
+	grids      map[string]*GridSummary
+	gridOrder  []string // Grid fingerprints in order of first appearance
+	packings   map[int]*PackingSummary
+	cycles     map[cycleKey]*CycleSummary
+
+	parameterStorage map[[3]uint8]*ParameterStorage
+	packingStorage   map[int]*PackingStorage
+}
+
+// cycleKey identifies a forecast cycle
+type cycleKey struct {
+	referenceTime     time.Time
+	centre            int
+	generatingProcess uint8
+}
+
+// add counts one field, and its message along with the first field of each message
+func (b *summaryBuilder) add(f *FlatMessage, firstOfMessage bool) {
+	if b.parameters == nil {
+		b.parameters = make(map[[3]uint8]*ParameterSummary)
+		b.levels = make(map[string]*LevelSummary)
+		b.times = make(map[time.Time]bool)
+		b.steps = make(map[string]*StepSummary)
+		b.grids = make(map[string]*GridSummary)
+		b.packings = make(map[int]*PackingSummary)
+		b.cycles = make(map[cycleKey]*CycleSummary)
+		b.parameterStorage = make(map[[3]uint8]*ParameterStorage)
+		b.packingStorage = make(map[int]*PackingStorage)
+	}
+	b.summary.Fields++
+
+	paramKey := [3]uint8{uint8(f.Discipline), f.Product.Category, f.Product.Parameter}
+	param, ok := b.parameters[paramKey]
+	if !ok {
+		param = &ParameterSummary{Discipline: paramKey[0], Category: paramKey[1], Number: paramKey[2]}
+		if info, known := f.Parameter(); known {
+			param.Abbreviation, param.Name, param.Unit = info.Abbreviation, info.Name, info.Unit
+		}
+		b.parameters[paramKey] = param
+	}
+	param.Fields++
+
+	levelString := f.LevelString()
+	level, ok := b.levels[levelString]
+	if !ok {
+		level = &LevelSummary{SurfaceType: 255, Level: levelString}
+		if surface, ok := f.Product.FirstSurface(); ok {
+			level.SurfaceType = surface.Type
+			if surface.HasValue {
+				level.Value = &surface.Value
+			}
+		}
+		b.levels[levelString] = level
+	}
+	level.Fields++
+
+	referenceTime, err := f.ReferenceTime()
+	if err == nil {
+		b.times[referenceTime] = true
+	}
+
+	stepString := f.StepString()
+	step, ok := b.steps[stepString]
+	if !ok {
+		step = &StepSummary{Step: stepString}
+		start, end, err := f.StepRange()
+		step.start, step.end, step.valid = start, end, err == nil
+		b.steps[stepString] = step
+	}
+	step.Fields++
+
+	fingerprint := gridFingerprint(f)
+	grid, ok := b.grids[fingerprint]
+	if !ok {
+		grid = &GridSummary{Fingerprint: fingerprint, Template: f.Grid.TemplateNumber, Points: f.Grid.NumberOfDataPoints}
+		if def, err := f.GridDefinition(); err == nil {
+			grid.Ni, grid.Nj = def.Dims()
+		}
+		b.grids[fingerprint] = grid
+		b.gridOrder = append(b.gridOrder, fingerprint)
+	}
+	grid.Fields++
+
+	packing, ok := b.packings[f.DataRep.TemplateNumber]
+	if !ok {
+		packing = &PackingSummary{Template: f.DataRep.TemplateNumber}
+		b.packings[f.DataRep.TemplateNumber] = packing
+	}
+	packing.Fields++
+
+	key := cycleKey{referenceTime: referenceTime, centre: f.Centre, generatingProcess: f.Product.GeneratingProcessIdentifier}
+	cycle, ok := b.cycles[key]
+	if !ok {
+		cycle = &CycleSummary{
+			ReferenceTime:     referenceTime,
+			Centre:            f.Centre,
+			GeneratingProcess: f.Product.GeneratingProcessIdentifier,
+			Model:             f.GeneratingProcessName(),
+			Files:             1,
+		}
+		b.cycles[key] = cycle
+	}
+	if firstOfMessage {
+		cycle.Messages++
+	}
+	cycle.Fields++
+	cycle.Steps = countStep(cycle.Steps, *step, 1)
+
+	dataBytes, uncompressedBytes := fieldStorage(f)
+	b.summary.Storage.add(dataBytes, uncompressedBytes)
+	paramStorage, ok := b.parameterStorage[paramKey]
+	if !ok {
+		paramStorage = &ParameterStorage{Discipline: param.Discipline, Category: param.Category, Number: param.Number, Abbreviation: param.Abbreviation}
+		b.parameterStorage[paramKey] = paramStorage
+	}
+	paramStorage.add(dataBytes, uncompressedBytes)
+	packingStorage, ok := b.packingStorage[f.DataRep.TemplateNumber]
+	if !ok {
+		packingStorage = &PackingStorage{Template: f.DataRep.TemplateNumber}
+		b.packingStorage[f.DataRep.TemplateNumber] = packingStorage
+	}
+	packingStorage.add(dataBytes, uncompressedBytes)
+}
+
+// fieldStorage returns the length of the Section 7 of the field and the size of its grid
+// points as 32-bit floats
+func fieldStorage(f *FlatMessage) (dataBytes, uncompressedBytes int64) {
+	if r, ok := f.sectionRanges[7]; ok {
+		dataBytes = r.Length
+	} else if f.Data != nil {
+		dataBytes = int64(f.Data.Length())
+	}
+	return dataBytes, 4 * int64(f.Grid.NumberOfDataPoints)
+}
+
+// finish sorts the distinct values into the summary
+func (b *summaryBuilder) finish() FileSummary {
+	s := b.summary
+
+	for _, param := range b.parameters {
+		s.Parameters = append(s.Parameters, *param)
+	}
+	slices.SortFunc(s.Parameters, func(a, b ParameterSummary) int {
+		return cmp.Or(cmp.Compare(a.Discipline, b.Discipline), cmp.Compare(a.Category, b.Category), cmp.Compare(a.Number, b.Number))
+	})
+
+	for _, level := range b.levels {
+		s.Levels = append(s.Levels, *level)
+	}
+	slices.SortFunc(s.Levels, func(a, b LevelSummary) int {
+		return cmp.Or(cmp.Compare(a.SurfaceType, b.SurfaceType), compareOptional(a.Value, b.Value), strings.Compare(a.Level, b.Level))
+	})
+
+	for t := range b.times {
+		s.ReferenceTimes = append(s.ReferenceTimes, t)
+	}
+	slices.SortFunc(s.ReferenceTimes, time.Time.Compare)
+
+	for _, step := range b.steps {
+		s.Steps = append(s.Steps, *step)
+	}
+	slices.SortFunc(s.Steps, compareSteps)
+
+	for _, fingerprint := range b.gridOrder {
+		s.Grids = append(s.Grids, *b.grids[fingerprint])
+	}
+
+	for _, cycle := range b.cycles {
+		slices.SortFunc(cycle.Steps, compareSteps)
+		s.Cycles = append(s.Cycles, *cycle)
+	}
+	slices.SortFunc(s.Cycles, compareCycles)
+
+	for _, packing := range b.packings {
+		s.Packings = append(s.Packings, *packing)
+	}
+	slices.SortFunc(s.Packings, func(a, b PackingSummary) int {
+		return cmp.Compare(a.Template, b.Template)
+	})
+
+	for _, param := range b.parameterStorage {
+		s.Storage.Parameters = append(s.Storage.Parameters, *param)
+	}
+	slices.SortFunc(s.Storage.Parameters, func(a, b ParameterStorage) int {
+		return cmp.Or(cmp.Compare(b.DataBytes, a.DataBytes),
+			cmp.Compare(a.Discipline, b.Discipline), cmp.Compare(a.Category, b.Category), cmp.Compare(a.Number, b.Number))
+	})
+	for _, packing := range b.packingStorage {
+		s.Storage.Packings = append(s.Storage.Packings, *packing)
+	}
+	slices.SortFunc(s.Storage.Packings, func(a, b PackingStorage) int {
+		return cmp.Or(cmp.Compare(b.DataBytes, a.DataBytes), cmp.Compare(a.Template, b.Template))
+	})
+
+	return s
+}
+
+// compareSteps orders steps by end then start, steps of unknown range last
+func compareSteps(a, b StepSummary) int {
+	if a.valid != b.valid {
+		if a.valid {
+			return -1
+		}
+		return 1
+	}
+	return cmp.Or(cmp.Compare(a.end, b.end), cmp.Compare(a.start, b.start), strings.Compare(a.Step, b.Step))
+}
+
+// countStep adds fields to the count of step in steps, appending the step when missing
+func countStep(steps []StepSummary, step StepSummary, fields int) []StepSummary {
+	i := slices.IndexFunc(steps, func(s StepSummary) bool { return s.Step == step.Step })
+	if i < 0 {
+		step.Fields = 0
+		steps = append(steps, step)
+		i = len(steps) - 1
+	}
+	steps[i].Fields += fields
+	return steps
+}
+
+// compareCycles orders cycles by reference time, centre and generating process
+func compareCycles(a, b CycleSummary) int {
+	return cmp.Or(a.ReferenceTime.Compare(b.ReferenceTime), cmp.Compare(a.Centre, b.Centre), cmp.Compare(a.GeneratingProcess, b.GeneratingProcess))
+}
+
+// compareOptional orders nil before any value
+func compareOptional(a, b *float64) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	default:
+		return cmp.Compare(*a, *b)
+	}
+}
+
+// gridFingerprint hashes Section 3 of the field from its source of grid definition on,
+// which leaves out only the section length and number
+func gridFingerprint(f *FlatMessage) string {
+	if f.GridDef == nil {
+		return "0000000000000000"
+	}
+	h := fnv.New64a()
+	header := []byte{f.GridDef.GridDefinitionSource()}
+	header = binary.BigEndian.AppendUint32(header, f.GridDef.NumberOfDataPoints())
+	header = append(header, byte(f.GridDef.OptionalListOctets()), f.GridDef.OptionalListInterpretation())
+	header = binary.BigEndian.AppendUint16(header, f.GridDef.GridDefinitionTemplateNumber())
+	h.Write(header)
+	h.Write(f.GridDef.GridDefinitionTemplate())
+	for _, n := range f.GridDef.OptionalList() {
+		h.Write(binary.BigEndian.AppendUint32(nil, n))
+	}
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+// MarshalJSON encodes the summary with empty lists as [] rather than null
+func (s FileSummary) MarshalJSON() ([]byte, error) {
+	type plain FileSummary
+	p := plain(s)
+	p.Parameters = nonNil(p.Parameters)
+	p.Levels = nonNil(p.Levels)
+	p.ReferenceTimes = nonNil(p.ReferenceTimes)
+	p.Steps = nonNil(p.Steps)
+	p.Grids = nonNil(p.Grids)
+	p.Packings = nonNil(p.Packings)
+	p.Cycles = nonNil(p.Cycles)
+	p.Storage.Parameters = nonNil(p.Storage.Parameters)
+	p.Storage.Packings = nonNil(p.Storage.Packings)
+	return json.Marshal(p)
+}
+
+// nonNil returns s, or an empty slice when s is nil
+func nonNil[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+	return s
+}
+
+// String formats the summary as an indented, human-readable listing
+func (s FileSummary) String() string {
+	var sb strings.Builder
+	if s.Path != "" {
+		fmt.Fprintf(&sb, "%s: ", s.Path)
+	}
+	if s.Error != "" {
+		fmt.Fprintf(&sb, "error: %s\n", s.Error)
+		return sb.String()
+	}
+	fmt.Fprintf(&sb, "%d messages, %d fields\n", s.Messages, s.Fields)
+
+	sb.WriteString("parameters:\n")
+	for _, p := range s.Parameters {
+		name := "unknown"
+		if p.Abbreviation != "" {
+			name = fmt.Sprintf("%s %s [%s]", p.Abbreviation, p.Name, p.Unit)
+		}
+		fmt.Fprintf(&sb, "  %d.%d.%d %s: %d\n", p.Discipline, p.Category, p.Number, name, p.Fields)
+	}
+
+	sb.WriteString("levels:\n")
+	for _, l := range s.Levels {
+		fmt.Fprintf(&sb, "  %s: %d\n", l.Level, l.Fields)
+	}
+
+	sb.WriteString("reference times:\n")
+	for _, t := range s.ReferenceTimes {
+		fmt.Fprintf(&sb, "  %s\n", t.Format(time.RFC3339))
+	}
+
+	sb.WriteString("steps:\n")
+	for _, step := range s.Steps {
+		fmt.Fprintf(&sb, "  %s: %d\n", step.Step, step.Fields)
+	}
+
+	sb.WriteString("grids:\n")
+	for _, g := range s.Grids {
+		fmt.Fprintf(&sb, "  %s template 3.%d %dx%d (%d points): %d\n", g.Fingerprint, g.Template, g.Ni, g.Nj, g.Points, g.Fields)
+	}
+
+	sb.WriteString("packings:\n")
+	for _, p := range s.Packings {
+		fmt.Fprintf(&sb, "  template 5.%d: %d\n", p.Template, p.Fields)
+	}
+
+	sb.WriteString("cycles:\n")
+	for _, c := range s.Cycles {
+		writeCycle(&sb, c)
+	}
+
+	fmt.Fprintf(&sb, "storage: %s\n", s.Storage.StorageUsage)
+	for _, p := range s.Storage.Parameters {
+		name := p.Abbreviation
+		if name == "" {
+			name = "unknown"
+		}
+		fmt.Fprintf(&sb, "  %d.%d.%d %s: %s\n", p.Discipline, p.Category, p.Number, name, p.StorageUsage)
+	}
+	for _, p := range s.Storage.Packings {
+		fmt.Fprintf(&sb, "  template 5.%d: %s\n", p.Template, p.StorageUsage)
+	}
+	return sb.String()
+}
+
+// String formats the usage as octets of data and compression ratio
+func (u StorageUsage) String() string {
+	return fmt.Sprintf("%d fields, %d bytes of data, %d uncompressed, ratio %.2f", u.Fields, u.DataBytes, u.UncompressedBytes, u.CompressionRatio)
+}
+
+// writeCycle formats a cycle as a line of FileSummary.String
+func writeCycle(sb *strings.Builder, c CycleSummary) {
+	model := c.Model
+	if model == "" {
+		model = "unknown model"
+	}
+	fmt.Fprintf(sb, "  %s centre %d process %d (%s): %d messages, %d fields, %d steps\n",
+		c.ReferenceTime.Format(time.RFC3339), c.Centre, c.GeneratingProcess, model, c.Messages, c.Fields, len(c.Steps))
+}
@@ -0,0 +1,117 @@
+//go:build !nointernaltables
+
+package reader_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/scorix/grib/grib2/internal/testgrib"
+	"github.com/scorix/grib/grib2/reader"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReaderAt_Summary_Golden(t *testing.T) {
+	data, err := os.ReadFile("testdata/gfs.t00z.pgrb2.0p25.f000")
+	require.NoError(t, err)
+
+	summary, err := reader.NewReaderAt(bytes.NewReader(data)).Summary()
+	require.NoError(t, err)
+	assert.Equal(t, 3, summary.Messages)
+	assert.Equal(t, 3, summary.Fields)
+
+	got, err := json.MarshalIndent(summary, "", "  ")
+	require.NoError(t, err)
+	got = append(got, '\n')
+	got = append(got, summary.String()...)
+
+	const golden = "testdata/summary.golden"
+	if *update {
+		require.NoError(t, os.WriteFile(golden, got, 0o644))
+	}
+	want, err := os.ReadFile(golden)
+	require.NoError(t, err)
+	assert.Equal(t, string(want), string(got))
+}
+
+func TestReaderAt_Summary(t *testing.T) {
+	var data []byte
+	for _, spec := range []testgrib.Spec{
+		{ForecastHours: 6, SurfaceType: 100, SurfaceValue: 50000},
+		{ForecastHours: 0, SurfaceType: 100, SurfaceValue: 85000},
+		{ForecastHours: 6, SurfaceType: 100, SurfaceValue: 85000, Ni: 8, Nj: 2},
+		{ProductTemplate: 8, Category: 1, Parameter: 8, ForecastHours: 0, StatisticalProcess: 1, RangeHours: 6, SurfaceType: 1},
+	} {
+		data = append(data, testgrib.MustEncode(spec)...)
+	}
+
+	summary, err := reader.NewReaderAt(bytes.NewReader(data)).Summary()
+	require.NoError(t, err)
+	assert.Equal(t, 4, summary.Messages)
+	assert.Equal(t, 4, summary.Fields)
+
+	require.Len(t, summary.Parameters, 2)
+	assert.Equal(t, "TMP", summary.Parameters[0].Abbreviation)
+	assert.Equal(t, 3, summary.Parameters[0].Fields)
+	assert.Equal(t, "APCP", summary.Parameters[1].Abbreviation)
+
+	var levels []string
+	for _, level := range summary.Levels {
+		levels = append(levels, level.Level)
+	}
+	assert.Equal(t, []string{"surface", "500 mb", "850 mb"}, levels)
+
+	var steps []string
+	for _, step := range summary.Steps {
+		steps = append(steps, step.Step)
+	}
+	assert.Equal(t, []string{"anl", "0-6 hour acc fcst", "6 hour fcst"}, steps)
+
+	require.Len(t, summary.Grids, 2)
+	assert.Equal(t, 3, summary.Grids[0].Fields)
+	assert.Equal(t, [2]int{8, 2}, [2]int{summary.Grids[1].Ni, summary.Grids[1].Nj})
+	assert.Equal(t, []reader.PackingSummary{{Template: 0, Fields: 4}}, summary.Packings)
+	require.Len(t, summary.ReferenceTimes, 1)
+}
+
+func TestReaderAt_Summary_Storage(t *testing.T) {
+	data, err := os.ReadFile("testdata/gfs.t00z.pgrb2.0p25.f000")
+	require.NoError(t, err)
+	r := reader.NewReaderAt(bytes.NewReader(data))
+
+	var section7Bytes int64
+	require.NoError(t, r.EachMessage(func(_ int, info reader.MessageInfo) bool {
+		for _, sec := range info.Sections {
+			if sec.Number == 7 {
+				section7Bytes += int64(sec.Length)
+			}
+		}
+		return true
+	}))
+
+	summary, err := r.Summary()
+	require.NoError(t, err)
+	storage := summary.Storage
+	assert.Equal(t, 3, storage.Fields)
+	assert.Equal(t, section7Bytes, storage.DataBytes)
+	assert.Equal(t, int64(3*4*1440*721), storage.UncompressedBytes)
+	assert.InDelta(t, float64(storage.UncompressedBytes)/float64(section7Bytes), storage.CompressionRatio, 1e-9)
+
+	var parameterBytes, packingBytes int64
+	for i, p := range storage.Parameters {
+		parameterBytes += p.DataBytes
+		if i > 0 {
+			assert.GreaterOrEqual(t, storage.Parameters[i-1].DataBytes, p.DataBytes, "parameters are sorted by octets descending")
+		}
+	}
+	for _, p := range storage.Packings {
+		packingBytes += p.DataBytes
+	}
+	assert.Equal(t, section7Bytes, parameterBytes)
+	assert.Equal(t, section7Bytes, packingBytes)
+	assert.Len(t, storage.Parameters, 3)
+	assert.Equal(t, "PRMSL", storage.Parameters[0].Abbreviation)
+}
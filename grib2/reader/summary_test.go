@@ -0,0 +1,17 @@
+package reader_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/scorix/grib/grib2/reader"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileSummary_MarshalJSON_Empty(t *testing.T) {
+	got, err := json.Marshal(reader.FileSummary{})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"messages":0,"fields":0,"parameters":[],"levels":[],"reference_times":[],"steps":[],"grids":[],"packings":[],"cycles":[],`+
+		`"storage":{"fields":0,"data_bytes":0,"uncompressed_bytes":0,"compression_ratio":0,"parameters":[],"packings":[]}}`, string(got))
+}
@@ -0,0 +1,176 @@
+package reader
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"io"
+	"slices"
+)
+
+// SyncState records how much of a growing file NewMessagesSince has processed. It is meant
+// to be persisted between polls, e.g. as JSON, and starts as the zero value.
+type SyncState struct {
+	Size         int64    `json:"size"`           // End offset of the last complete message processed
+	ETag         string   `json:"etag,omitempty"` // Entity tag of the file version the size refers to
+	Messages     int      `json:"messages"`       // Number of messages before Size
+	Fields       int      `json:"fields"`         // Number of Section 4s before Size, from which the Index of new fields count
+	Fingerprints []string `json:"fingerprints"`   // Fingerprints of the processed messages
+}
+
+// sizer is implemented by sources that know their size, such as HTTPReaderAt and bytes.Reader
+type sizer interface {
+	Size() int64
+}
+
+// etagger is implemented by sources that identify the version of their content, such as
+// HTTPReaderAt
+type etagger interface {
+	ETag() string
+}
+
+// NewMessagesSince calls fn for each field of the messages added to the file since state
+// was last updated. GRIB files grow by appending whole messages, so only the bytes past
+// state.Size are scanned; a message still being written at the end of the file is left
+// for the next call. When the file was replaced, detected by a changed ETag or a size
+// below state.Size, the whole file is scanned again and only the messages whose
+// fingerprint is not in state are passed to fn.
+//
+// The state is updated as messages are processed, including when fn returns false to stop
+// or an error is returned, so that the next call resumes with the first message whose
+// fields were not all passed to fn.
+func (r *ReaderAt) NewMessagesSince(state *SyncState, fn func(FlatMessage) bool) error {
+	size := int64(-1)
+	if s, ok := r.base.(sizer); ok {
+		size = s.Size()
+	}
+	var etag string
+	if e, ok := r.base.(etagger); ok {
+		etag = e.ETag()
+	}
+
+	replaced := (etag != "" && state.ETag != "" && etag != state.ETag) || (size >= 0 && size < state.Size)
+	if replaced {
+		state.Size, state.Messages, state.Fields = 0, 0, 0
+	}
+	state.ETag = etag
+
+	seen := make(map[string]bool, len(state.Fingerprints))
+	for _, fingerprint := range state.Fingerprints {
+		seen[fingerprint] = true
+	}
+
+	for {
+		complete, err := r.messageComplete(state.Size, size)
+		if err != nil {
+			return r.opts.sourced(err)
+		}
+		if !complete {
+			return nil
+		}
+
+		info, err := r.readMessageInfo(state.Messages, state.Size)
+		if err != nil {
+			return r.opts.sourced(err)
+		}
+		info.FirstField = state.Fields
+		message, err := r.buildMessageFromInfo(info)
+		if err != nil {
+			return r.opts.sourced(err)
+		}
+		fingerprint, err := r.fingerprint(info)
+		if err != nil {
+			return r.opts.sourced(err)
+		}
+
+		fields := message.FlattenToFlatMessages()
+		if !seen[fingerprint] {
+			for _, field := range fields {
+				if !fn(field) {
+					return nil
+				}
+			}
+			seen[fingerprint] = true
+			state.Fingerprints = append(state.Fingerprints, fingerprint)
+		}
+
+		state.Size += int64(info.Length)
+		state.Messages++
+		state.Fields += countSections(info.Sections, 4)
+	}
+}
+
+// messageComplete reports whether a whole message starts at offset, given the size of the
+// source or -1 when it is unknown. It is false at the end of the file and for a message
+// that is still being written.
+func (r *ReaderAt) messageComplete(offset, size int64) (bool, error) {
+	header := make([]byte, 16)
+	if _, err := r.reader.ReadAt(header, offset); err != nil {
+		if errors.Is(err, io.EOF) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to read at offset %d: %w", offset, err)
+	}
+	if string(header[:4]) != "GRIB" {
+		return false, fmt.Errorf("invalid GRIB marker at offset %d", offset)
+	}
+
+	totalLength := binary.BigEndian.Uint64(header[8:16])
+	if totalLength < minMessageLength {
+		return false, fmt.Errorf("invalid total length %d at offset %d", totalLength, offset)
+	}
+	if size >= 0 {
+		return totalLength <= uint64(size-offset), nil
+	}
+
+	// Without a known size, the message is complete once its last octet can be read
+	last := make([]byte, 1)
+	if _, err := r.reader.ReadAt(last, offset+int64(totalLength)-1); err != nil {
+		if errors.Is(err, io.EOF) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to read at offset %d: %w", offset+int64(totalLength)-1, err)
+	}
+	return true, nil
+}
+
+// fingerprint identifies a message by its section layout and the content of all its
+// sections except the bit-maps and data, so that it is recognised at any offset
+func (r *ReaderAt) fingerprint(info MessageInfo) (string, error) {
+	sum, err := r.fingerprintSum(info)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%016x", sum), nil
+}
+
+// fingerprintSum computes the fingerprint of the message described by info
+func (r *ReaderAt) fingerprintSum(info MessageInfo) (uint64, error) {
+	h := fnv.New64a()
+	if err := r.hashMetadata(h, info); err != nil {
+		return 0, err
+	}
+	return h.Sum64(), nil
+}
+
+// hashMetadata writes to h the section layout of the message described by info and the
+// content of its sections except the bit-maps and data
+func (r *ReaderAt) hashMetadata(h hash.Hash, info MessageInfo) error {
+	var buf []byte
+	for _, sec := range info.Sections {
+		h.Write([]byte{sec.Number})
+		h.Write(binary.BigEndian.AppendUint32(nil, sec.Length))
+		if sec.Number == 6 || sec.Number == 7 {
+			continue
+		}
+
+		buf = slices.Grow(buf[:0], int(sec.Length))[:sec.Length]
+		if _, err := r.reader.ReadAt(buf, sec.Offset); err != nil {
+			return fmt.Errorf("failed to read section %d at offset %d: %w", sec.Number, sec.Offset, err)
+		}
+		h.Write(buf)
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package reader_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/scorix/grib/grib2/internal/testgrib"
+	"github.com/scorix/grib/grib2/reader"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cyclingServer serves a file that grows and is replaced like the output of a running
+// model cycle, recording the ranges requested
+type cyclingServer struct {
+	mu      sync.Mutex
+	content []byte
+	etag    string
+	ranges  []string
+}
+
+func (s *cyclingServer) set(content []byte, etag string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.content, s.etag, s.ranges = content, etag, nil
+}
+
+func (s *cyclingServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	s.mu.Lock()
+	content, etag := s.content, s.etag
+	if r := req.Header.Get("Range"); r != "" {
+		s.ranges = append(s.ranges, r)
+	}
+	s.mu.Unlock()
+
+	w.Header().Set("ETag", etag)
+	http.ServeContent(w, req, "cycle.grib2", time.Time{}, bytes.NewReader(content))
+}
+
+func TestReaderAt_NewMessagesSince(t *testing.T) {
+	messages := make([][]byte, 5)
+	for k := range messages {
+		messages[k] = testgrib.MustEncode(testgrib.Spec{Parameter: uint8(k)})
+	}
+	join := func(parts ...[]byte) []byte { return bytes.Join(parts, nil) }
+
+	server := &cyclingServer{}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	// poll opens the file as a consumer would on each poll, passing the state through JSON
+	var persisted []byte
+	poll := func(t *testing.T) []uint8 {
+		var state reader.SyncState
+		if persisted != nil {
+			require.NoError(t, json.Unmarshal(persisted, &state))
+		}
+
+		source, err := reader.NewHTTPReaderAt(ts.URL)
+		require.NoError(t, err)
+
+		var parameters []uint8
+		require.NoError(t, reader.NewReaderAt(source).NewMessagesSince(&state, func(msg reader.FlatMessage) bool {
+			parameters = append(parameters, msg.Product.Parameter)
+			return true
+		}))
+
+		persisted, err = json.Marshal(state)
+		require.NoError(t, err)
+		return parameters
+	}
+
+	size := int64(len(messages[0]))
+
+	// The cycle starts with one message and part of the next
+	server.set(join(messages[0], messages[1][:40]), `"v1"`)
+	assert.Equal(t, []uint8{0}, poll(t))
+
+	// The second message is completed and a third appended: only the new bytes are read
+	server.set(join(messages[0], messages[1], messages[2]), `"v1"`)
+	assert.Equal(t, []uint8{1, 2}, poll(t))
+	assert.Equal(t, fmt.Sprintf("bytes=%d-%d", size, size+15), server.ranges[0])
+
+	// Polling an unchanged file yields nothing
+	assert.Empty(t, poll(t))
+
+	server.set(join(messages[0], messages[1], messages[2], messages[3]), `"v1"`)
+	assert.Equal(t, []uint8{3}, poll(t))
+
+	var state reader.SyncState
+	require.NoError(t, json.Unmarshal(persisted, &state))
+	assert.Equal(t, 4, state.Messages)
+	assert.Equal(t, 4, state.Fields)
+	assert.Len(t, state.Fingerprints, 4)
+
+	// The file is replaced by a rerun holding a known message and a new one
+	server.set(join(messages[2], messages[4]), `"v2"`)
+	assert.Equal(t, []uint8{4}, poll(t))
+
+	require.NoError(t, json.Unmarshal(persisted, &state))
+	assert.Equal(t, `"v2"`, state.ETag)
+	assert.Equal(t, int64(len(messages[2])+len(messages[4])), state.Size)
+	assert.Equal(t, 2, state.Messages)
+	assert.Len(t, state.Fingerprints, 5)
+}
+
+func TestReaderAt_NewMessagesSince_Stop(t *testing.T) {
+	var data []byte
+	for parameter := range uint8(3) {
+		data = append(data, testgrib.MustEncode(testgrib.Spec{Parameter: parameter})...)
+	}
+	r := reader.NewReaderAt(bytes.NewReader(data))
+
+	var state reader.SyncState
+	var indexes []int
+	collect := func(limit int) func(reader.FlatMessage) bool {
+		return func(msg reader.FlatMessage) bool {
+			if len(indexes) == limit {
+				return false
+			}
+			indexes = append(indexes, msg.Index)
+			return true
+		}
+	}
+
+	// Stopping in the second message leaves it for the next call
+	require.NoError(t, r.NewMessagesSince(&state, collect(1)))
+	assert.Equal(t, int64(len(data)/3), state.Size)
+
+	require.NoError(t, r.NewMessagesSince(&state, collect(3)))
+	assert.Equal(t, []int{0, 1, 2}, indexes)
+	assert.Equal(t, int64(len(data)), state.Size)
+	assert.Equal(t, 3, state.Fields)
+}
@@ -0,0 +1,68 @@
+package reader
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/scorix/grib/grib2/section"
+	"github.com/scorix/grib/grib2/template"
+)
+
+// ReferenceTime returns the reference time from Section 1 in UTC.
+// Timestamps with out-of-range components are rejected as *section.ErrInvalidTimestamp
+// unless section.Normalize is given.
+func (f *FlatMessage) ReferenceTime(opts ...section.TimeOption) (time.Time, error) {
+	t, err := section.Timestamp(f.Year, f.Month, f.Day, f.Hour, f.Minute, f.Second, opts...)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("reference time: %w", err)
+	}
+	return t, nil
+}
+
+// ValidTime returns the time the data applies to. For statistically processed products it
+// is the end of the overall time interval encoded in the template; when that is not a
+// valid timestamp, and for all other products, it is the reference time plus the forecast
+// time and the length of the outermost time range. Forecast times in months, years and
+// longer units are added to the calendar, as template.AddTimeUnits does.
+// The options apply to the reference time and the end of the overall time interval, as
+// for ReferenceTime.
+func (f *FlatMessage) ValidTime(opts ...section.TimeOption) (time.Time, error) {
+	reference, err := f.ReferenceTime(opts...)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	timeRange := f.Product.TimeRange
+	if timeRange != nil {
+		end, err := section.Timestamp(int(timeRange.EndYear), int(timeRange.EndMonth), int(timeRange.EndDay),
+			int(timeRange.EndHour), int(timeRange.EndMinute), int(timeRange.EndSecond), opts...)
+		if err == nil {
+			return end, nil
+		}
+	}
+
+	valid, ok := template.AddTimeUnits(reference, f.Product.IndicatorOfUnitOfTimeRange, f.Product.ForecastTime)
+	if !ok {
+		return time.Time{}, fmt.Errorf("valid time: unsupported unit of time range %d", f.Product.IndicatorOfUnitOfTimeRange)
+	}
+	if timeRange == nil || len(timeRange.TimeRanges) == 0 {
+		return valid, nil
+	}
+	valid, ok = template.AddTimeUnits(valid, timeRange.IndicatorOfUnitForTimeRange, timeRange.LengthOfTimeRange)
+	if !ok {
+		return time.Time{}, fmt.Errorf("valid time: unsupported unit for time range %d", timeRange.IndicatorOfUnitForTimeRange)
+	}
+	return valid, nil
+}
+
+// ForecastDuration returns the forecast time as an offset from the reference time: the
+// start of the time range for statistically processed products. Forecast times in months,
+// years and longer units have no fixed length and are reported as errors; ValidTime adds
+// them to the calendar.
+func (f *FlatMessage) ForecastDuration() (time.Duration, error) {
+	unit, ok := template.TimeUnitDuration(f.Product.IndicatorOfUnitOfTimeRange)
+	if !ok {
+		return 0, fmt.Errorf("forecast duration: unit of time range %d has no fixed length", f.Product.IndicatorOfUnitOfTimeRange)
+	}
+	return time.Duration(f.Product.ForecastTime) * unit, nil
+}
@@ -0,0 +1,154 @@
+package reader_test
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/scorix/grib/grib2/internal/testgrib"
+	"github.com/scorix/grib/grib2/section"
+	"github.com/scorix/grib/grib2/template"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// referenceTimeMessage builds a 6 hour forecast whose Section 1 gives the reference time
+// as is, valid or not: the year in octets 13-14, then the month, day and hour
+func referenceTimeMessage(year uint16, month, day, hour uint8) []byte {
+	msg := testgrib.MustEncode(testgrib.Spec{Ni: 2, Nj: 2, ForecastHours: 6})
+	binary.BigEndian.PutUint16(msg[16+12:], year)
+	msg[16+14], msg[16+15], msg[16+16] = month, day, hour
+	return msg
+}
+
+func TestFlatMessage_ReferenceTime(t *testing.T) {
+	tests := []struct {
+		name       string
+		year       uint16
+		month, day uint8
+		hour       uint8
+		want       time.Time
+		wantErr    bool
+	}{
+		{name: "valid", year: 2024, month: 3, day: 15, hour: 12, want: time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)},
+		{name: "leap day", year: 2024, month: 2, day: 29, hour: 0, want: time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{name: "february 29 of a common year", year: 2023, month: 2, day: 29, wantErr: true},
+		{name: "month 0", year: 2024, month: 0, day: 10, wantErr: true},
+		{name: "month 13", year: 2024, month: 13, day: 1, wantErr: true},
+		{name: "day 32", year: 2024, month: 1, day: 32, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := flatMessages(t, referenceTimeMessage(tt.year, tt.month, tt.day, tt.hour))[0]
+
+			reference, err := msg.ReferenceTime()
+			if tt.wantErr {
+				var invalid *section.ErrInvalidTimestamp
+				require.ErrorAs(t, err, &invalid)
+				assert.True(t, reference.IsZero())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, reference)
+			assert.Equal(t, time.UTC, reference.Location())
+		})
+	}
+}
+
+func TestFlatMessage_ValidTime(t *testing.T) {
+	// Hour 24 on the last day of February, six hours into the forecast
+	msg := flatMessages(t, referenceTimeMessage(2023, 2, 28, 24))[0]
+
+	_, err := msg.ReferenceTime()
+	var invalid *section.ErrInvalidTimestamp
+	require.ErrorAs(t, err, &invalid)
+	assert.Equal(t, section.ErrInvalidTimestamp{Year: 2023, Month: 2, Day: 28, Hour: 24}, *invalid)
+
+	_, err = msg.ValidTime()
+	require.ErrorAs(t, err, &invalid)
+
+	valid, err := msg.ValidTime(section.Normalize())
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2023, 3, 1, 6, 0, 0, 0, time.UTC), valid)
+}
+
+func TestFlatMessage_ValidTime_Units(t *testing.T) {
+	reference := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name         string
+		unit         uint8
+		forecastTime uint32
+		want         time.Time
+		duration     time.Duration
+		durationErr  bool
+	}{
+		{name: "minutes", unit: template.TimeUnitMinute, forecastTime: 90, want: reference.Add(90 * time.Minute), duration: 90 * time.Minute},
+		{name: "hours", unit: template.TimeUnitHour, forecastTime: 6, want: reference.Add(6 * time.Hour), duration: 6 * time.Hour},
+		{name: "days", unit: template.TimeUnitDay, forecastTime: 2, want: reference.Add(48 * time.Hour), duration: 48 * time.Hour},
+		{name: "3 hours", unit: template.TimeUnit3Hours, forecastTime: 3, want: reference.Add(9 * time.Hour), duration: 9 * time.Hour},
+		{name: "6 hours", unit: template.TimeUnit6Hours, forecastTime: 3, want: reference.Add(18 * time.Hour), duration: 18 * time.Hour},
+		{name: "12 hours", unit: template.TimeUnit12Hours, forecastTime: 3, want: reference.Add(36 * time.Hour), duration: 36 * time.Hour},
+		{name: "seconds", unit: template.TimeUnitSecond, forecastTime: 30, want: reference.Add(30 * time.Second), duration: 30 * time.Second},
+		{name: "months", unit: template.TimeUnitMonth, forecastTime: 1, want: time.Date(2024, 4, 15, 0, 0, 0, 0, time.UTC), durationErr: true},
+		{name: "years", unit: template.TimeUnitYear, forecastTime: 1, want: time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC), durationErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := flatMessages(t, testgrib.MustEncode(testgrib.Spec{
+				ReferenceTime: reference,
+				Forecast:      &testgrib.Duration{Unit: tt.unit, Length: tt.forecastTime},
+			}))[0]
+
+			valid, err := msg.ValidTime()
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, valid)
+
+			duration, err := msg.ForecastDuration()
+			if tt.durationErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.duration, duration)
+		})
+	}
+
+	// A reserved unit
+	msg := flatMessages(t, testgrib.MustEncode(testgrib.Spec{Forecast: &testgrib.Duration{Unit: 8, Length: 1}}))[0]
+	_, err := msg.ValidTime()
+	assert.ErrorContains(t, err, "unsupported unit of time range 8")
+	_, err = msg.ForecastDuration()
+	assert.Error(t, err)
+}
+
+func TestFlatMessage_ValidTime_Statistical(t *testing.T) {
+	reference := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+
+	// The end of the overall time interval is the valid time, even when it disagrees with
+	// the forecast time and the length of the time range, 6 and 12 hours here
+	msg := flatMessages(t, testgrib.MustEncode(testgrib.Spec{
+		ReferenceTime: reference, ProductTemplate: 8, Parameter: 1, ForecastHours: 6,
+		StatisticalProcess: 1, RangeHours: 12, EndTime: time.Date(2024, 3, 16, 0, 0, 0, 0, time.UTC),
+	}))[0]
+	valid, err := msg.ValidTime()
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 3, 16, 0, 0, 0, 0, time.UTC), valid)
+	duration, err := msg.ForecastDuration()
+	require.NoError(t, err)
+	assert.Equal(t, 6*time.Hour, duration)
+
+	// Without a valid end, the forecast time plus the length of the time range, here a
+	// monthly mean starting at the reference time. The end is zeroed in octets 35-41 of
+	// Section 4.
+	data := testgrib.MustEncode(testgrib.Spec{
+		ReferenceTime: reference, ProductTemplate: 8,
+		TimeRanges: []testgrib.TimeRange{{Process: 0, Unit: template.TimeUnitMonth, Length: 1}},
+	})
+	clear(data[sectionOffset(t, data, 4)+34:][:7])
+	msg = flatMessages(t, data)[0]
+	valid, err = msg.ValidTime()
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 4, 15, 0, 0, 0, 0, time.UTC), valid)
+}
@@ -0,0 +1,82 @@
+package reader
+
+import "fmt"
+
+// CoordinateKind classifies the coordinate values listed after the product definition template
+type CoordinateKind int
+
+const (
+	// CoordinateNone means no coordinate values are present
+	CoordinateNone CoordinateKind = iota
+	// CoordinateHybridPressure holds (A, B) pairs for hybrid levels (Code Table 4.5 type 105),
+	// where the pressure at a half level is A + B × surface pressure
+	CoordinateHybridPressure
+	// CoordinateHybridHeight holds (A, B) pairs for hybrid height levels (Code Table 4.5 type 118),
+	// where the height at a half level is A + B × orography
+	CoordinateHybridHeight
+	// CoordinateGeneralizedHeight means the values identify an external generalized vertical
+	// height coordinate (Code Table 4.5 type 150) and do not form level pairs
+	CoordinateGeneralizedHeight
+)
+
+// String returns the name of the coordinate kind
+func (k CoordinateKind) String() string {
+	switch k {
+	case CoordinateNone:
+		return "none"
+	case CoordinateHybridPressure:
+		return "hybrid pressure"
+	case CoordinateHybridHeight:
+		return "hybrid height"
+	case CoordinateGeneralizedHeight:
+		return "generalized height"
+	default:
+		return fmt.Sprintf("CoordinateKind(%d)", int(k))
+	}
+}
+
+// coordinateKinds maps Code Table 4.5 fixed surface types to the meaning of their coordinate values
+var coordinateKinds = map[uint8]CoordinateKind{
+	105: CoordinateHybridPressure,
+	118: CoordinateHybridHeight,
+	150: CoordinateGeneralizedHeight,
+}
+
+// VerticalCoordinates returns the coordinate values listed after the product definition template,
+// classified by the type of the first fixed surface.
+//
+// For hybrid levels the list holds all A coefficients followed by all B coefficients,
+// which are returned as one (A, B) pair per half level. Generalized height coordinates
+// carry no pairs; the raw values remain available from Section4.CoordinateValues.
+func (f *FlatMessage) VerticalCoordinates() (kind CoordinateKind, pairs [][2]float64, err error) {
+	if f.ProductDef == nil {
+		return CoordinateNone, nil, nil
+	}
+
+	values := f.ProductDef.CoordinateValues()
+	if len(values) == 0 {
+		return CoordinateNone, nil, nil
+	}
+
+	surfaceType := f.Product.TypeOfFirstFixedSurface
+	kind, ok := coordinateKinds[surfaceType]
+	if !ok {
+		return CoordinateNone, nil, fmt.Errorf("vertical coordinates: %d values for unsupported surface type %d", len(values), surfaceType)
+	}
+
+	if kind == CoordinateGeneralizedHeight {
+		return kind, nil, nil
+	}
+
+	if len(values)%2 != 0 {
+		return kind, nil, fmt.Errorf("vertical coordinates: odd number of values %d for %s levels", len(values), kind)
+	}
+
+	half := len(values) / 2
+	pairs = make([][2]float64, half)
+	for i := range pairs {
+		pairs[i] = [2]float64{float64(values[i]), float64(values[half+i])}
+	}
+
+	return kind, pairs, nil
+}
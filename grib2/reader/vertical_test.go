@@ -0,0 +1,88 @@
+package reader_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/scorix/grib/grib2/internal/testgrib"
+	"github.com/scorix/grib/grib2/reader"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// verticalMessage builds a model level message with the given surface type and coordinate values
+func verticalMessage(t *testing.T, surfaceType uint8, coordinates []float32) reader.FlatMessage {
+	messages := flatMessages(t, testgrib.MustEncode(testgrib.Spec{Ni: 2, Nj: 2, SurfaceType: surfaceType, Coordinates: coordinates}))
+	require.Len(t, messages, 1)
+	return messages[0]
+}
+
+// hybridCoefficients returns the A coefficients followed by the B coefficients for the given
+// number of levels, with A decreasing from the model top and B increasing towards the surface
+func hybridCoefficients(levels int) []float32 {
+	coordinates := make([]float32, 2*(levels+1))
+	for k := 0; k <= levels; k++ {
+		coordinates[k] = float32(levels-k) * 100
+		coordinates[levels+1+k] = float32(k) / float32(levels)
+	}
+	return coordinates
+}
+
+func TestVerticalCoordinatesHybridPressure(t *testing.T) {
+	for _, levels := range []int{137, 65} {
+		msg := verticalMessage(t, 105, hybridCoefficients(levels))
+
+		kind, pairs, err := msg.VerticalCoordinates()
+		require.NoError(t, err)
+
+		assert.Equal(t, reader.CoordinateHybridPressure, kind)
+		require.Len(t, pairs, levels+1)
+		assert.Equal(t, [2]float64{float64(levels) * 100, 0}, pairs[0])
+		assert.Equal(t, [2]float64{0, 1}, pairs[levels])
+	}
+}
+
+func TestVerticalCoordinatesHybridHeight(t *testing.T) {
+	msg := verticalMessage(t, 118, []float32{20, 10, 0, 0, 0.5, 1})
+
+	kind, pairs, err := msg.VerticalCoordinates()
+	require.NoError(t, err)
+
+	assert.Equal(t, reader.CoordinateHybridHeight, kind)
+	assert.Equal(t, [][2]float64{{20, 0}, {10, 0.5}, {0, 1}}, pairs)
+}
+
+func TestVerticalCoordinatesGeneralizedHeight(t *testing.T) {
+	msg := verticalMessage(t, 150, []float32{65, 1, 0, 0, 0, 0})
+
+	kind, pairs, err := msg.VerticalCoordinates()
+	require.NoError(t, err)
+
+	assert.Equal(t, reader.CoordinateGeneralizedHeight, kind)
+	assert.Nil(t, pairs)
+	assert.Len(t, msg.ProductDef.CoordinateValues(), 6)
+}
+
+func TestVerticalCoordinatesErrors(t *testing.T) {
+	odd := verticalMessage(t, 105, []float32{1, 2, 3})
+	_, _, err := odd.VerticalCoordinates()
+	assert.ErrorContains(t, err, "odd number of values 3")
+
+	isobaric := verticalMessage(t, 100, []float32{1, 2})
+	_, _, err = isobaric.VerticalCoordinates()
+	assert.ErrorContains(t, err, "unsupported surface type 100")
+}
+
+func TestVerticalCoordinatesNone(t *testing.T) {
+	data, err := os.ReadFile("testdata/gfs.t00z.pgrb2.0p25.f000")
+	require.NoError(t, err)
+
+	// CLWMR on hybrid level 1 without a coordinate list
+	msg := flatMessages(t, data)[1]
+	require.Equal(t, uint8(105), msg.Product.TypeOfFirstFixedSurface)
+
+	kind, pairs, err := msg.VerticalCoordinates()
+	require.NoError(t, err)
+	assert.Equal(t, reader.CoordinateNone, kind)
+	assert.Nil(t, pairs)
+}
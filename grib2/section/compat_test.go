@@ -0,0 +1,88 @@
+package section_test
+
+import (
+	"testing"
+
+	"github.com/scorix/grib/grib2/section"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// oldStyleTemplates is a sample of code written when the template number accessors
+// returned uint8: switching on them and converting them keeps compiling now that they
+// return uint16
+func oldStyleTemplates(sec3 section.Section3, sec4 section.Section4, sec5 section.Section5) (grid, product, dataRep int) {
+	switch sec3.GridDefinitionTemplateNumber() {
+	case 0, 40:
+		grid = int(sec3.GridDefinitionTemplateNumber())
+	default:
+		grid = -1
+	}
+	return grid, int(sec4.ProductDefinitionTemplateNumber()), int(sec5.DataRepresentationTemplateNumber())
+}
+
+func TestTemplateNumberMigration(t *testing.T) {
+	sec3 := []byte{
+		0x00, 0x00, 0x00, 0x0e, // length: 14 octets
+		0x03,                   // section number: 3
+		0x00,                   // grid definition source
+		0x00, 0x00, 0x00, 0x01, // number of data points: 1
+		0x00,       // number of octets for optional list
+		0x00,       // interpretation of optional list
+		0x00, 0x00, // grid definition template number: 0
+	}
+	sec4 := []byte{
+		0x00, 0x00, 0x00, 0x09, // length: 9 octets
+		0x04,       // section number: 4
+		0x00, 0x00, // number of coordinate values
+		0x00, 0x08, // product definition template number: 8
+	}
+	sec5 := []byte{
+		0x00, 0x00, 0x00, 0x0b, // length: 11 octets
+		0x05,                   // section number: 5
+		0x00, 0x00, 0x00, 0x01, // number of data points: 1
+		0x00, 0x28, // data representation template number: 40
+	}
+
+	t.Run("numbers below 256", func(t *testing.T) {
+		s3, err := section.NewSection3FromBytes(sec3)
+		require.NoError(t, err)
+		s4, err := section.NewSection4FromBytes(sec4)
+		require.NoError(t, err)
+		s5, err := section.NewSection5FromBytes(sec5)
+		require.NoError(t, err)
+
+		grid, product, dataRep := oldStyleTemplates(s3, s4, s5)
+		assert.Equal(t, 0, grid)
+		assert.Equal(t, 8, product)
+		assert.Equal(t, 40, dataRep)
+	})
+
+	t.Run("numbers above 255", func(t *testing.T) {
+		// Cross-section grid 3.1000, product template 4.1000 and the local JPEG 2000
+		// packing 5.40000 of NCEP
+		wide3 := append([]byte(nil), sec3...)
+		wide3[12], wide3[13] = 0x03, 0xe8
+		wide4 := append([]byte(nil), sec4...)
+		wide4[7], wide4[8] = 0x03, 0xe8
+		wide5 := append([]byte(nil), sec5...)
+		wide5[9], wide5[10] = 0x9c, 0x40
+
+		s3, err := section.NewSection3FromBytes(wide3)
+		require.NoError(t, err)
+		s4, err := section.NewSection4FromBytes(wide4)
+		require.NoError(t, err)
+		s5, err := section.NewSection5FromBytes(wide5)
+		require.NoError(t, err)
+
+		grid, product, dataRep := oldStyleTemplates(s3, s4, s5)
+		assert.Equal(t, -1, grid)
+		assert.Equal(t, 1000, product)
+		assert.Equal(t, 40000, dataRep)
+
+		// The deprecated accessors are aliases
+		assert.Equal(t, uint16(1000), s3.GridDefinitionTemplateNumber16())
+		assert.Equal(t, uint16(1000), s4.ProductDefinitionTemplateNumber16())
+		assert.Equal(t, uint16(40000), s5.DataRepresentationTemplateNumber16())
+	})
+}
@@ -1,6 +1,9 @@
 package section
 
-import "io"
+import (
+	"io"
+	"time"
+)
 
 type Section interface {
 	Length() uint32
@@ -78,10 +81,26 @@ type Section1 interface {
 	Hour() uint8
 	Minute() uint8
 	Second() uint8
+	ReferenceTime(opts ...TimeOption) (time.Time, error)
 
 	// Production status
 	ProductionStatus() uint8
 	DataType() uint8
+
+	// Typed code table values
+	Significance() ReferenceTimeSignificance
+	Status() ProductionStatus
+	ProcessedDataType() ProcessedDataType
+
+	// Convenience predicates on the type of data and production status
+	IsAnalysis() bool
+	IsForecast() bool
+	IsOperational() bool
+	IsTestData() bool
+
+	// Sections shorter than 21 octets have their missing fields zero; octets past the 21st are reserved
+	Truncated() bool
+	Reserved() []byte
 }
 
 // Section2 represents the GRIB2 Local Use Section (Section 2)
@@ -140,9 +159,15 @@ type Section3 interface {
 	// Grid definition
 	GridDefinitionSource() uint8
 	NumberOfDataPoints() uint32
-	GridDefinitionTemplateNumber() uint8
+	GridDefinitionTemplateNumber() uint16
+	// Deprecated: GridDefinitionTemplateNumber16 is an alias of
+	// GridDefinitionTemplateNumber, kept from when the latter returned uint8.
+	GridDefinitionTemplateNumber16() uint16
+	GridDefinitionTemplate() []byte
 
-	// Optional list information
+	// Optional list information: the number of octets of each entry, their meaning and
+	// the entries, read for the latitude/longitude and Gaussian templates (3.0-3.3 and
+	// 3.40-3.43). The rest of the section of other templates is their template.
 	OptionalListOctets() uint32
 	OptionalListInterpretation() uint8
 	OptionalList() []uint32
@@ -174,7 +199,11 @@ type Section4 interface {
 
 	// Product definition
 	NumberOfCoordinateValues() uint32
-	ProductDefinitionTemplateNumber() uint8
+	ProductDefinitionTemplateNumber() uint16
+	// Deprecated: ProductDefinitionTemplateNumber16 is an alias of
+	// ProductDefinitionTemplateNumber, kept from when the latter returned uint8.
+	ProductDefinitionTemplateNumber16() uint16
+	ProductDefinitionTemplate() []byte
 
 	// Optional coordinate values
 	CoordinateValues() []float32
@@ -205,7 +234,11 @@ type Section5 interface {
 
 	// Data representation
 	NumberOfDataPoints() uint32
-	DataRepresentationTemplateNumber() uint8
+	DataRepresentationTemplateNumber() uint16
+	// Deprecated: DataRepresentationTemplateNumber16 is an alias of
+	// DataRepresentationTemplateNumber, kept from when the latter returned uint8.
+	DataRepresentationTemplateNumber16() uint16
+	DataRepresentationTemplate() []byte
 }
 
 // Section6 represents the GRIB2 Bit-map Section (Section 6)
@@ -265,6 +298,10 @@ type Section7 interface {
 	DataReader() io.Reader // Returns a reader for streaming data access
 	DataSize() uint32      // Returns the size of data payload in bytes
 
+	// ReadDataAt reads the data payload from offset off, without loading the whole payload
+	// when the section supports random access
+	ReadDataAt(p []byte, off int64) (int, error)
+
 	// Error handling
 	LoadError() error // Returns any error encountered during data loading
 }
@@ -0,0 +1,70 @@
+package section
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// provenanceSignature starts the local use data of a Section 2 written to record provenance
+var provenanceSignature = []byte("PROV")
+
+var errTruncatedProvenance = errors.New("provenance: local use data is truncated")
+
+// provenanceLayout is the version of the layout of the provenance local use data
+const provenanceLayout = 1
+
+// Provenance identifies the software that produced a message. It is recorded in the local
+// use data of Section 2 with the following layout, following the section number:
+//
+//	Octets 6-9     "PROV" in ASCII
+//	Octet 10       Layout version, 1
+//	Octet 11       Length n of the software name
+//	Octets 12-     Software name in UTF-8, n octets
+//	Next octet     Length m of the software version
+//	Next m octets  Software version in UTF-8
+//
+// Octets following the version are reserved for later layout versions and ignored.
+type Provenance struct {
+	Software string // Name of the producing software, e.g. its module path
+	Version  string // Version of the producing software
+}
+
+// MarshalLocalUse returns the local use data recording the provenance
+func (p Provenance) MarshalLocalUse() ([]byte, error) {
+	if len(p.Software) > 255 || len(p.Version) > 255 {
+		return nil, errors.New("provenance: software name and version must not exceed 255 octets")
+	}
+
+	data := append([]byte(nil), provenanceSignature...)
+	data = append(data, provenanceLayout, byte(len(p.Software)))
+	data = append(data, p.Software...)
+	data = append(data, byte(len(p.Version)))
+	return append(data, p.Version...), nil
+}
+
+// ParseProvenance recovers the provenance from the local use data of a Section 2. It
+// returns false when the data does not start with the provenance signature, as is the case
+// for the local use data defined by originating centres.
+func ParseProvenance(localUse []byte) (Provenance, bool, error) {
+	rest, ok := bytes.CutPrefix(localUse, provenanceSignature)
+	if !ok {
+		return Provenance{}, false, nil
+	}
+	if len(rest) < 1 {
+		return Provenance{}, true, errTruncatedProvenance
+	}
+	if rest[0] != provenanceLayout {
+		return Provenance{}, true, fmt.Errorf("provenance: unsupported layout version %d", rest[0])
+	}
+	rest = rest[1:]
+
+	var fields [2]string
+	for k := range fields {
+		if len(rest) < 1 || len(rest) < 1+int(rest[0]) {
+			return Provenance{}, true, errTruncatedProvenance
+		}
+		fields[k], rest = string(rest[1:1+int(rest[0])]), rest[1+int(rest[0]):]
+	}
+	return Provenance{Software: fields[0], Version: fields[1]}, true, nil
+}
@@ -0,0 +1,34 @@
+package section_test
+
+import (
+	"testing"
+
+	"github.com/scorix/grib/grib2/section"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProvenance(t *testing.T) {
+	want := section.Provenance{Software: "github.com/scorix/grib/grib2", Version: "v0.4.0"}
+	data, err := want.MarshalLocalUse()
+	require.NoError(t, err)
+	assert.Equal(t, []byte("PROV\x01\x1cgithub.com/scorix/grib/grib2\x06v0.4.0"), data)
+
+	// Octets following the version are reserved
+	got, ok, err := section.ParseProvenance(append(data, 0xff))
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, want, got)
+
+	_, ok, err = section.ParseProvenance([]byte{0x01, 0x02})
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	_, _, err = section.ParseProvenance(data[:len(data)-1])
+	assert.ErrorContains(t, err, "truncated")
+	_, _, err = section.ParseProvenance([]byte("PROV\x02"))
+	assert.ErrorContains(t, err, "unsupported layout version 2")
+
+	_, err = section.Provenance{Version: string(make([]byte, 256))}.MarshalLocalUse()
+	assert.Error(t, err)
+}
@@ -28,7 +28,7 @@ var readFunc = map[uint8]func(io.Reader) (Section, error){
 
 func (r *Reader) ReadSection() (Section, error) {
 	first4Bytes := make([]byte, 4)
-	_, err := r.Read(first4Bytes)
+	_, err := io.ReadFull(r.Reader, first4Bytes)
 	if err != nil {
 		return nil, err
 	}
@@ -40,7 +40,7 @@ func (r *Reader) ReadSection() (Section, error) {
 		return NewSection8FromReader(io.MultiReader(bytes.NewReader(first4Bytes), r.Reader))
 	default:
 		nextByte := make([]byte, 1)
-		_, err := r.Read(nextByte)
+		_, err := io.ReadFull(r.Reader, nextByte)
 		if err != nil {
 			return nil, err
 		}
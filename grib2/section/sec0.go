@@ -8,6 +8,19 @@ import (
 	"io"
 )
 
+// ErrGRIB1NotSupported reports a message of GRIB edition 1, such as those of older model
+// output and archives, whose Section 0 is 8 octets long and codes the total length of the
+// message in the 3 octets before the edition. Only its total length is read, so that the
+// message can be skipped.
+type ErrGRIB1NotSupported struct {
+	TotalLength uint32 // Total length of the message in octets
+}
+
+// Error implements the error interface
+func (e *ErrGRIB1NotSupported) Error() string {
+	return fmt.Sprintf("GRIB edition 1 message of %d octets is not supported", e.TotalLength)
+}
+
 type section0 struct {
 	identifier  [4]byte
 	reserved    [2]byte
@@ -55,6 +68,9 @@ func NewSection0FromReader(reader io.Reader) (Section, error) {
 }
 
 func NewSection0FromBytes(data []byte) (Section0, error) {
+	if len(data) >= 8 && string(data[:4]) == "GRIB" && data[7] == 1 {
+		return nil, &ErrGRIB1NotSupported{TotalLength: uint32(data[4])<<16 | uint32(data[5])<<8 | uint32(data[6])}
+	}
 	if len(data) < 16 {
 		return nil, fmt.Errorf("section0: data too short")
 	}
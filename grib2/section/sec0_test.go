@@ -1,6 +1,7 @@
 package section_test
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/scorix/grib/grib2/section"
@@ -28,3 +29,21 @@ func TestNewSection0FromBytes(t *testing.T) {
 	assert.Equal(t, section0.Edition(), uint8(2), "edition")
 	assert.Equal(t, section0.TotalLength(), uint64(16), "total length")
 }
+
+func TestNewSection0FromBytes_GRIB1(t *testing.T) {
+	data := []byte{
+		'G', 'R', 'I', 'B',
+		0x01, 0x02, 0x03, // total length: 66051 octets
+		0x01,                                           // edition
+		0x00, 0x00, 0x1c, 0x02, 0x62, 0x07, 0xff, 0x80, // start of Section 1
+	}
+
+	_, err := section.NewSection0FromBytes(data)
+	var grib1 *section.ErrGRIB1NotSupported
+	require.ErrorAs(t, err, &grib1)
+	assert.Equal(t, uint32(66051), grib1.TotalLength)
+	assert.EqualError(t, err, "GRIB edition 1 message of 66051 octets is not supported")
+
+	_, err = section.NewReader(bytes.NewReader(data)).ReadSection()
+	assert.ErrorAs(t, err, &grib1)
+}
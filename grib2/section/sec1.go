@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"time"
 )
 
 type section1 struct {
@@ -81,6 +82,21 @@ func (s *section1) Second() uint8 {
 	return s.second
 }
 
+// Truncated reports whether the section is shorter than its 21 defined octets
+func (s *section1) Truncated() bool {
+	return s.length < section1Length
+}
+
+// Reserved returns the octets past the 21st, when kept
+func (s *section1) Reserved() []byte {
+	return s.reserved
+}
+
+// ReferenceTime returns the reference time in UTC, validating its components
+func (s *section1) ReferenceTime(opts ...TimeOption) (time.Time, error) {
+	return Timestamp(int(s.year), int(s.month), int(s.day), int(s.hour), int(s.minute), int(s.second), opts...)
+}
+
 func (s *section1) ProductionStatus() uint8 {
 	return s.productionStatus
 }
@@ -89,26 +105,89 @@ func (s *section1) DataType() uint8 {
 	return s.productType
 }
 
+func (s *section1) Significance() ReferenceTimeSignificance {
+	return ReferenceTimeSignificance(s.referenceTimeSignificance)
+}
+
+func (s *section1) Status() ProductionStatus {
+	return ProductionStatus(s.productionStatus)
+}
+
+func (s *section1) ProcessedDataType() ProcessedDataType {
+	return ProcessedDataType(s.productType)
+}
+
+func (s *section1) IsAnalysis() bool {
+	return s.ProcessedDataType().IsAnalysis()
+}
+
+func (s *section1) IsForecast() bool {
+	return s.ProcessedDataType().IsForecast()
+}
+
+func (s *section1) IsOperational() bool {
+	return s.Status().IsOperational()
+}
+
+func (s *section1) IsTestData() bool {
+	return s.Status().IsTestData()
+}
+
 func (s *section1) ReadSection(reader io.Reader) (Section, error) {
 	return NewSection1FromReader(reader)
 }
 
+// section1Length is the length of Section 1 with all its defined octets
+const section1Length = 21
+
+// minSection1Length is the shortest Section 1 accepted: up to the significance of the reference time
+const minSection1Length = 13
+
 func NewSection1FromReader(reader io.Reader) (Section, error) {
-	data := make([]byte, 21) // Section 1 is always 21 bytes
-	_, err := io.ReadFull(reader, data)
-	if err != nil {
+	lengthBytes := make([]byte, 4)
+	if _, err := io.ReadFull(reader, lengthBytes); err != nil {
+		return nil, err
+	}
+
+	length := binary.BigEndian.Uint32(lengthBytes)
+	if length < minSection1Length {
+		return nil, fmt.Errorf("section1: invalid length %d", length)
+	}
+
+	data := make([]byte, length)
+	copy(data, lengthBytes)
+	if _, err := io.ReadFull(reader, data[4:]); err != nil {
 		return nil, err
 	}
 
 	return NewSection1FromBytes(data, true)
 }
 
+// NewSection1FromBytes parses Section 1 from data, which may extend past the section.
+// A section shorter than 21 octets, down to 13, has its missing trailing fields left zero
+// and reports Truncated; octets past the 21st are reserved and kept when keepReserved is set.
 func NewSection1FromBytes(data []byte, keepReserved bool) (Section1, error) {
-	if len(data) < 21 {
+	if len(data) < minSection1Length {
 		return nil, fmt.Errorf("section1: data too short")
 	}
 
-	br := bytes.NewReader(data)
+	length := binary.BigEndian.Uint32(data)
+	if length < minSection1Length {
+		return nil, fmt.Errorf("section1: invalid length %d", length)
+	}
+	if uint32(len(data)) < length {
+		return nil, fmt.Errorf("section1: data too short for length %d", length)
+	}
+
+	// Missing trailing octets read as zero
+	defined := make([]byte, section1Length)
+	copy(defined, data[:min(length, section1Length)])
+	if length < 14 {
+		// Only the first octet of the year is present
+		defined[12] = 0
+	}
+
+	br := bytes.NewReader(defined)
 
 	var s section1
 	var err error
@@ -128,18 +207,12 @@ func NewSection1FromBytes(data []byte, keepReserved bool) (Section1, error) {
 	err = errors.Join(err, binary.Read(br, binary.BigEndian, &s.productionStatus))
 	err = errors.Join(err, binary.Read(br, binary.BigEndian, &s.productType))
 
-	reservedN := s.length - 21
-	reservedWriter := io.Discard
-	if keepReserved {
-		s.reserved = make([]byte, reservedN)
-		reservedWriter = bytes.NewBuffer(s.reserved)
-	}
-	if _, err := io.CopyN(reservedWriter, br, int64(reservedN)); err != nil {
+	if err != nil {
 		return nil, err
 	}
 
-	if err != nil {
-		return nil, err
+	if keepReserved && length > section1Length {
+		s.reserved = bytes.Clone(data[section1Length:length])
 	}
 
 	return &s, nil
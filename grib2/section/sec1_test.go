@@ -1,7 +1,9 @@
 package section_test
 
 import (
+	"bytes"
 	"testing"
+	"time"
 
 	"github.com/scorix/grib/grib2/section"
 	"github.com/stretchr/testify/assert"
@@ -49,4 +51,126 @@ func TestNewSection1FromBytes(t *testing.T) {
 	assert.Equal(t, section1.Second(), uint8(0))
 	assert.Equal(t, section1.ProductionStatus(), uint8(0)) // operational
 	assert.Equal(t, section1.DataType(), uint8(0))         // analysis
+
+	assert.Equal(t, section.SignificanceAnalysis, section1.Significance())
+	assert.Equal(t, section.StatusOperational, section1.Status())
+	assert.Equal(t, section.DataTypeAnalysis, section1.ProcessedDataType())
+	assert.True(t, section1.IsAnalysis())
+	assert.False(t, section1.IsForecast())
+	assert.True(t, section1.IsOperational())
+	assert.False(t, section1.IsTestData())
+}
+
+func TestSection1_ReferenceTime(t *testing.T) {
+	data := []byte{
+		0x00, 0x00, 0x00, 0x15, // length: 21 octets
+		0x01,       // section number: 1
+		0x00, 0x07, // originating center: NCEP (7)
+		0x00, 0x00, // originating subcenter: none (0)
+		0x02,       // master tables version: 2
+		0x00,       // local tables version: 0
+		0x01,       // reference time significance: start of forecast (1)
+		0x07, 0xe8, // year: 2024
+		0x03, // month: March (3)
+		0x0f, // day: 15
+		0x18, // hour: 24
+		0x00, // minute: 0
+		0x00, // second: 0
+		0x00, // production status: operational products (0)
+		0x01, // type of data: forecast products (1)
+	}
+
+	section1, err := section.NewSection1FromBytes(data, false)
+	require.NoError(t, err)
+
+	_, err = section1.ReferenceTime()
+	var invalid *section.ErrInvalidTimestamp
+	require.ErrorAs(t, err, &invalid)
+	assert.Equal(t, 24, invalid.Hour)
+
+	reference, err := section1.ReferenceTime(section.Normalize())
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 3, 16, 0, 0, 0, 0, time.UTC), reference)
+}
+
+func TestNewSection1FromBytes_Lengths(t *testing.T) {
+	// The 21 defined octets, then reserved octets
+	defined := []byte{
+		0x00, 0x00, 0x00, 0x15, // length, patched below
+		0x01,       // section number: 1
+		0x00, 0x62, // originating center: ECMWF (98)
+		0x00, 0x01, // originating subcenter: 1
+		0x1c,       // master tables version: 28
+		0x00,       // local tables version: 0
+		0x01,       // reference time significance: start of forecast (1)
+		0x07, 0xe8, // year: 2024
+		0x02, // month: February
+		0x1d, // day: 29
+		0x06, // hour: 6
+		0x1e, // minute: 30
+		0x0f, // second: 15
+		0x00, // production status: operational products (0)
+		0x01, // type of data: forecast products (1)
+	}
+	reserved := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}
+
+	tests := []struct {
+		name      string
+		length    int
+		truncated bool
+		second    uint8
+		status    uint8
+		dataType  uint8
+		reserved  []byte
+	}{
+		{name: "missing type of data", length: 20, truncated: true, second: 15, status: 0, dataType: 0},
+		{name: "standard", length: 21, second: 15, status: 0, dataType: 1},
+		{name: "padded", length: 32, second: 15, status: 0, dataType: 1, reserved: reserved},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := append(append([]byte(nil), defined...), reserved...)[:tt.length]
+			data[3] = byte(tt.length)
+			// Trailing octets of the next section must not be taken for Section 1
+			data = append(data, 0xff, 0xff, 0xff, 0xff, 0xff)
+
+			for _, keepReserved := range []bool{false, true} {
+				sec, err := section.NewSection1FromBytes(data, keepReserved)
+				require.NoError(t, err)
+
+				assert.Equal(t, uint32(tt.length), sec.Length())
+				assert.Equal(t, tt.truncated, sec.Truncated())
+				assert.Equal(t, uint16(98), sec.OriginatingCenter())
+				assert.Equal(t, uint16(2024), sec.Year())
+				assert.Equal(t, uint8(30), sec.Minute())
+				assert.Equal(t, tt.second, sec.Second())
+				assert.Equal(t, tt.status, sec.ProductionStatus())
+				assert.Equal(t, tt.dataType, sec.DataType())
+				if keepReserved {
+					assert.Equal(t, tt.reserved, sec.Reserved())
+				} else {
+					assert.Nil(t, sec.Reserved())
+				}
+			}
+
+			// Reading from a stream consumes exactly the section
+			r := bytes.NewReader(data)
+			sec, err := section.NewSection1FromReader(r)
+			require.NoError(t, err)
+			assert.Equal(t, uint32(tt.length), sec.Length())
+			assert.Equal(t, 5, r.Len())
+		})
+	}
+
+	t.Run("too short", func(t *testing.T) {
+		data := append([]byte(nil), defined...)
+		data[3] = 12
+		_, err := section.NewSection1FromBytes(data, false)
+		assert.ErrorContains(t, err, "invalid length 12")
+
+		data[3] = 30
+		_, err = section.NewSection1FromBytes(data, false)
+		assert.ErrorContains(t, err, "data too short")
+	})
 }
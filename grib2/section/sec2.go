@@ -33,17 +33,27 @@ func (s *section2) ReadSection(reader io.Reader) (Section, error) {
 }
 
 func NewSection2FromReader(reader io.Reader) (Section, error) {
-	data := bytes.NewBuffer(nil)
-	_, err := io.Copy(data, reader)
-	if err != nil {
+	lengthBytes := make([]byte, 4)
+	if _, err := io.ReadFull(reader, lengthBytes); err != nil {
+		return nil, err
+	}
+	length := binary.BigEndian.Uint32(lengthBytes)
+	if length < 5 {
+		return nil, fmt.Errorf("section2: invalid length %d", length)
+	}
+
+	// Read only this section; the reader continues with the following sections
+	data := make([]byte, length)
+	copy(data[:4], lengthBytes)
+	if _, err := io.ReadFull(reader, data[4:]); err != nil {
 		return nil, err
 	}
 
-	return NewSection2FromBytes(data.Bytes())
+	return NewSection2FromBytes(data)
 }
 
 func NewSection2FromBytes(data []byte) (Section2, error) {
-	if len(data) < 4 {
+	if len(data) < 5 {
 		return nil, fmt.Errorf("section2: data too short")
 	}
 
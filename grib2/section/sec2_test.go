@@ -1,6 +1,7 @@
 package section_test
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/scorix/grib/grib2/section"
@@ -25,3 +26,24 @@ func TestNewSection2FromBytes(t *testing.T) {
 	assert.Equal(t, section2.SectionNumber(), uint8(2))
 	assert.Equal(t, section2.LocalUseData(), []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00})
 }
+
+func TestNewSection2FromReader(t *testing.T) {
+	data := []byte{
+		0x00, 0x00, 0x00, 0x07, // length: 7 octets
+		0x02,       // section number: 2
+		0x01, 0x02, // local use data: 2 octets
+
+		// next section
+		0x00, 0x00, 0x00, 0x06, 0x06, 0xff,
+	}
+
+	r := bytes.NewReader(data)
+	sec, err := section.NewSection2FromReader(r)
+	require.NoError(t, err)
+
+	section2, ok := sec.(section.Section2)
+	require.True(t, ok)
+	assert.Equal(t, uint32(7), section2.Length())
+	assert.Equal(t, []byte{0x01, 0x02}, section2.LocalUseData())
+	assert.Equal(t, 6, r.Len(), "the following section must be left unread")
+}
@@ -38,8 +38,17 @@ func (s *section3) NumberOfDataPoints() uint32 {
 	return s.numberOfDataPoints
 }
 
-func (s *section3) GridDefinitionTemplateNumber() uint8 {
-	return uint8(s.gridDefinitionTemplateNumber)
+func (s *section3) GridDefinitionTemplateNumber() uint16 {
+	return s.gridDefinitionTemplateNumber
+}
+
+// Deprecated: use GridDefinitionTemplateNumber, which returns the same uint16.
+func (s *section3) GridDefinitionTemplateNumber16() uint16 {
+	return s.gridDefinitionTemplateNumber
+}
+
+func (s *section3) GridDefinitionTemplate() []byte {
+	return s.gridDefinitionTemplate
 }
 
 func (s *section3) OptionalListOctets() uint32 {
@@ -98,25 +107,50 @@ func NewSection3FromBytes(data []byte) (Section3, error) {
 		return nil, err
 	}
 
-	// Calculate template size
-	templateSize := int(s.length) - 14 - int(s.optionalListOctets)
+	// The optional list follows the template, whose length is known for the templates of
+	// quasi-regular grids; the rest of the section of other templates is their template
+	templateSize := int(s.length) - 14
+	width := int(s.optionalListOctets)
+	if width > 4 {
+		return nil, fmt.Errorf("section3: optional list entries of %d octets", width)
+	}
+	if n, ok := gridTemplateLengths[s.gridDefinitionTemplateNumber]; ok && width > 0 && templateSize > n {
+		templateSize = n
+	}
 	if templateSize > 0 {
 		s.gridDefinitionTemplate = make([]byte, templateSize)
-		if _, err := br.Read(s.gridDefinitionTemplate); err != nil {
+		if _, err := io.ReadFull(br, s.gridDefinitionTemplate); err != nil {
 			return nil, fmt.Errorf("section3: failed to read grid definition template: %w", err)
 		}
 	}
 
-	// Read optional list if present
-	if s.optionalListOctets > 0 {
-		numEntries := int(s.optionalListOctets) / 4
-		s.optionalList = make([]uint32, numEntries)
-		for i := 0; i < numEntries; i++ {
-			if err := binary.Read(br, binary.BigEndian, &s.optionalList[i]); err != nil {
-				return nil, fmt.Errorf("section3: failed to read optional list: %w", err)
+	// Read optional list if present, one number of points of width octets per entry
+	if listSize := int(s.length) - 14 - max(templateSize, 0); width > 0 && listSize > 0 {
+		list := make([]byte, listSize)
+		if _, err := io.ReadFull(br, list); err != nil {
+			return nil, fmt.Errorf("section3: failed to read optional list: %w", err)
+		}
+		s.optionalList = make([]uint32, listSize/width)
+		for i := range s.optionalList {
+			for _, octet := range list[i*width : (i+1)*width] {
+				s.optionalList[i] = s.optionalList[i]<<8 | uint32(octet)
 			}
 		}
 	}
 
 	return &s, nil
 }
+
+// gridTemplateLengths holds the number of octets of the grid definition templates that
+// an optional list of numbers of points may follow: those of the latitude/longitude and
+// Gaussian grids, which are quasi-regular when a number of points along the rows is missing
+var gridTemplateLengths = map[uint16]int{
+	0:  58, // Latitude/longitude
+	1:  70, // Rotated latitude/longitude
+	2:  70, // Stretched latitude/longitude
+	3:  82, // Stretched and rotated latitude/longitude
+	40: 58, // Gaussian latitude/longitude
+	41: 70, // Rotated Gaussian latitude/longitude
+	42: 70, // Stretched Gaussian latitude/longitude
+	43: 82, // Stretched and rotated Gaussian latitude/longitude
+}
@@ -1,6 +1,8 @@
 package section_test
 
 import (
+	"encoding/binary"
+	"fmt"
 	"testing"
 
 	"github.com/scorix/grib/grib2/section"
@@ -47,10 +49,59 @@ func TestNewSection3FromBytes(t *testing.T) {
 
 	assert.Equal(t, section3.Length(), uint32(72))
 	assert.Equal(t, section3.SectionNumber(), uint8(3))
-	assert.Equal(t, section3.GridDefinitionSource(), uint8(0))         // specified in code table
-	assert.Equal(t, section3.NumberOfDataPoints(), uint32(10000))      // 100x100 grid
-	assert.Equal(t, section3.GridDefinitionTemplateNumber(), uint8(0)) // lat/lon grid
-	assert.Equal(t, section3.OptionalListOctets(), uint32(0))          // no optional list
-	assert.Equal(t, section3.OptionalListInterpretation(), uint8(0))   // none
-	assert.Empty(t, section3.OptionalList())                           // no optional list
+	assert.Equal(t, section3.GridDefinitionSource(), uint8(0))          // specified in code table
+	assert.Equal(t, section3.NumberOfDataPoints(), uint32(10000))       // 100x100 grid
+	assert.Equal(t, section3.GridDefinitionTemplateNumber(), uint16(0)) // lat/lon grid
+	assert.Equal(t, section3.OptionalListOctets(), uint32(0))           // no optional list
+	assert.Equal(t, section3.OptionalListInterpretation(), uint8(0))    // none
+	assert.Empty(t, section3.OptionalList())                            // no optional list
+	assert.Len(t, section3.GridDefinitionTemplate(), 58)                // octets 15-72
+}
+
+func TestNewSection3FromBytes_WideTemplateNumber(t *testing.T) {
+	// A cross-section grid (template 3.1000), whose number does not fit in one octet
+	data := []byte{
+		0x00, 0x00, 0x00, 0x16, // length: 22 octets
+		0x03,                   // section number: 3
+		0x00,                   // grid definition source: specified in code table 3.0 (0)
+		0x00, 0x00, 0x00, 0x64, // number of data points: 100
+		0x00,       // number of octets for optional list: 0
+		0x00,       // interpretation of optional list: none (0)
+		0x03, 0xe8, // grid definition template number: cross-section grid (1000)
+		0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, // grid definition template
+	}
+
+	section3, err := section.NewSection3FromBytes(data)
+	require.NoError(t, err)
+	assert.Equal(t, uint16(1000), section3.GridDefinitionTemplateNumber())
+	assert.Equal(t, data[14:], section3.GridDefinitionTemplate())
+}
+
+func TestNewSection3FromBytes_OptionalList(t *testing.T) {
+	// A reduced Gaussian grid (template 3.40) of 4 parallels with 4, 8, 8 and 4 points
+	template := make([]byte, 58)
+	template[0] = 6
+	for _, width := range []uint8{1, 2, 4} {
+		t.Run(fmt.Sprintf("%d octets", width), func(t *testing.T) {
+			data := []byte{0, 0, 0, 0, 3, 0, 0, 0, 0, 24, width, 1, 0, 40}
+			data = append(data, template...)
+			for _, n := range []uint32{4, 8, 8, 4} {
+				data = append(data, binary.BigEndian.AppendUint32(nil, n)[4-width:]...)
+			}
+			binary.BigEndian.PutUint32(data, uint32(len(data)))
+
+			section3, err := section.NewSection3FromBytes(data)
+			require.NoError(t, err)
+			assert.Equal(t, uint32(width), section3.OptionalListOctets())
+			assert.Equal(t, uint8(1), section3.OptionalListInterpretation())
+			assert.Equal(t, []uint32{4, 8, 8, 4}, section3.OptionalList())
+			assert.Equal(t, template, section3.GridDefinitionTemplate())
+		})
+	}
+
+	t.Run("entries wider than 4 octets", func(t *testing.T) {
+		data := append([]byte{0, 0, 0, 72, 3, 0, 0, 0, 0, 24, 5, 1, 0, 40}, template...)
+		_, err := section.NewSection3FromBytes(data)
+		assert.ErrorContains(t, err, "optional list entries of 5 octets")
+	})
 }
@@ -31,8 +31,17 @@ func (s *section4) NumberOfCoordinateValues() uint32 {
 	return uint32(s.numberOfCoordinateValues)
 }
 
-func (s *section4) ProductDefinitionTemplateNumber() uint8 {
-	return uint8(s.productDefinitionTemplateNumber)
+func (s *section4) ProductDefinitionTemplateNumber() uint16 {
+	return s.productDefinitionTemplateNumber
+}
+
+// Deprecated: use ProductDefinitionTemplateNumber, which returns the same uint16.
+func (s *section4) ProductDefinitionTemplateNumber16() uint16 {
+	return s.productDefinitionTemplateNumber
+}
+
+func (s *section4) ProductDefinitionTemplate() []byte {
+	return s.productDefinitionTemplate
 }
 
 func (s *section4) CoordinateValues() []float32 {
@@ -83,9 +92,12 @@ func NewSection4FromBytes(data []byte) (Section4, error) {
 	// Calculate template size
 	coordinateSize := int(s.numberOfCoordinateValues) * 4 // 4 bytes per float32
 	templateSize := int(s.length) - 9 - coordinateSize
+	if templateSize < 0 || int(s.length) > len(data) {
+		return nil, fmt.Errorf("section4: length %d too short for %d coordinate values", s.length, s.numberOfCoordinateValues)
+	}
 	if templateSize > 0 {
 		s.productDefinitionTemplate = make([]byte, templateSize)
-		if _, err := br.Read(s.productDefinitionTemplate); err != nil {
+		if _, err := io.ReadFull(br, s.productDefinitionTemplate); err != nil {
 			return nil, fmt.Errorf("section4: failed to read product definition template: %w", err)
 		}
 	}
@@ -93,10 +105,8 @@ func NewSection4FromBytes(data []byte) (Section4, error) {
 	// Read coordinate values if present
 	if s.numberOfCoordinateValues > 0 {
 		s.coordinateValues = make([]float32, s.numberOfCoordinateValues)
-		for i := 0; i < int(s.numberOfCoordinateValues); i++ {
-			if err := binary.Read(br, binary.BigEndian, &s.coordinateValues[i]); err != nil {
-				return nil, fmt.Errorf("section4: failed to read coordinate values: %w", err)
-			}
+		if err := binary.Read(br, binary.BigEndian, s.coordinateValues); err != nil {
+			return nil, fmt.Errorf("section4: failed to read coordinate values: %w", err)
 		}
 	}
 
@@ -1,6 +1,8 @@
 package section_test
 
 import (
+	"encoding/binary"
+	"math"
 	"testing"
 
 	"github.com/scorix/grib/grib2/section"
@@ -40,7 +42,58 @@ func TestNewSection4FromBytes(t *testing.T) {
 
 	assert.Equal(t, section4.Length(), uint32(34))
 	assert.Equal(t, section4.SectionNumber(), uint8(4))
-	assert.Equal(t, section4.NumberOfCoordinateValues(), uint32(0))       // no coordinate values
-	assert.Equal(t, section4.ProductDefinitionTemplateNumber(), uint8(0)) // template 4.0
-	assert.Empty(t, section4.CoordinateValues())                          // no coordinate values
+	assert.Equal(t, section4.NumberOfCoordinateValues(), uint32(0))        // no coordinate values
+	assert.Equal(t, section4.ProductDefinitionTemplateNumber(), uint16(0)) // template 4.0
+	assert.Empty(t, section4.CoordinateValues())                           // no coordinate values
+	assert.Len(t, section4.ProductDefinitionTemplate(), 25)                // octets 10-34
+}
+
+func TestNewSection4FromBytes_WideTemplateNumber(t *testing.T) {
+	// Template numbers that do not fit in one octet: 4.1000, 4.1001 and 4.1100 for
+	// cross-sections and Hovmöller diagrams, and a local template of a centre
+	for _, number := range []uint16{1000, 1001, 1100, 65000} {
+		data := []byte{0x00, 0x00, 0x00, 0x0d, 0x04, 0x00, 0x00}
+		data = binary.BigEndian.AppendUint16(data, number)
+		data = append(data, 0x01, 0x02, 0x03, 0x04)
+
+		section4, err := section.NewSection4FromBytes(data)
+		require.NoError(t, err)
+		assert.Equal(t, number, section4.ProductDefinitionTemplateNumber())
+		assert.Equal(t, []byte{0x01, 0x02, 0x03, 0x04}, section4.ProductDefinitionTemplate())
+	}
+}
+
+func TestNewSection4FromBytesWithCoordinateValues(t *testing.T) {
+	const numberOfValues = 640 // 320 pairs
+
+	data := binary.BigEndian.AppendUint32(nil, uint32(9+25+numberOfValues*4))
+	data = append(data, 0x04) // section number: 4
+	data = binary.BigEndian.AppendUint16(data, numberOfValues)
+	data = binary.BigEndian.AppendUint16(data, 0) // product definition template number: 0
+	data = append(data, make([]byte, 25)...)
+	for i := range numberOfValues {
+		data = binary.BigEndian.AppendUint32(data, math.Float32bits(float32(i)/2))
+	}
+
+	section4, err := section.NewSection4FromBytes(data)
+	require.NoError(t, err)
+
+	assert.Equal(t, uint32(numberOfValues), section4.NumberOfCoordinateValues())
+	assert.Len(t, section4.ProductDefinitionTemplate(), 25)
+	require.Len(t, section4.CoordinateValues(), numberOfValues)
+	assert.Equal(t, float32(0), section4.CoordinateValues()[0])
+	assert.Equal(t, float32(319.5), section4.CoordinateValues()[numberOfValues-1])
+}
+
+func TestNewSection4FromBytesLengthTooShortForCoordinateValues(t *testing.T) {
+	data := []byte{
+		0x00, 0x00, 0x00, 0x22, // length: 34 octets
+		0x04,       // section number: 4
+		0x00, 0x10, // number of coordinate values: 16, needing 64 octets
+		0x00, 0x00, // product definition template number: 0
+	}
+	data = append(data, make([]byte, 25)...)
+
+	_, err := section.NewSection4FromBytes(data)
+	assert.ErrorContains(t, err, "too short for 16 coordinate values")
 }
@@ -30,8 +30,17 @@ func (s *section5) NumberOfDataPoints() uint32 {
 	return s.numberOfDataPoints
 }
 
-func (s *section5) DataRepresentationTemplateNumber() uint8 {
-	return uint8(s.dataRepresentationTemplateNumber)
+func (s *section5) DataRepresentationTemplateNumber() uint16 {
+	return s.dataRepresentationTemplateNumber
+}
+
+// Deprecated: use DataRepresentationTemplateNumber, which returns the same uint16.
+func (s *section5) DataRepresentationTemplateNumber16() uint16 {
+	return s.dataRepresentationTemplateNumber
+}
+
+func (s *section5) DataRepresentationTemplate() []byte {
+	return s.dataRepresentationTemplate
 }
 
 func (s *section5) ReadSection(reader io.Reader) (Section, error) {
@@ -79,7 +88,7 @@ func NewSection5FromBytes(data []byte) (Section5, error) {
 	templateSize := int(s.length) - 11
 	if templateSize > 0 {
 		s.dataRepresentationTemplate = make([]byte, templateSize)
-		if _, err := br.Read(s.dataRepresentationTemplate); err != nil {
+		if _, err := io.ReadFull(br, s.dataRepresentationTemplate); err != nil {
 			return nil, fmt.Errorf("section5: failed to read data representation template: %w", err)
 		}
 	}
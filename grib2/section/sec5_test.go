@@ -30,6 +30,25 @@ func TestNewSection5FromBytes(t *testing.T) {
 
 	assert.Equal(t, section5.Length(), uint32(23))
 	assert.Equal(t, section5.SectionNumber(), uint8(5))
-	assert.Equal(t, section5.NumberOfDataPoints(), uint32(10000))          // 10000 data points
-	assert.Equal(t, section5.DataRepresentationTemplateNumber(), uint8(0)) // simple packing
+	assert.Equal(t, section5.NumberOfDataPoints(), uint32(10000))           // 10000 data points
+	assert.Equal(t, section5.DataRepresentationTemplateNumber(), uint16(0)) // simple packing
+	assert.Len(t, section5.DataRepresentationTemplate(), 12)                // octets 12-23
+}
+
+func TestNewSection5FromBytes_WideTemplateNumber(t *testing.T) {
+	// NCEP local JPEG 2000 and PNG packing, 5.40000 and 5.40010
+	for _, number := range []uint16{40000, 40010} {
+		data := []byte{
+			0x00, 0x00, 0x00, 0x0f, // length: 15 octets
+			0x05,                   // section number: 5
+			0x00, 0x00, 0x00, 0x64, // number of data points: 100
+			byte(number >> 8), byte(number), // data representation template number
+			0x01, 0x02, 0x03, 0x04, // data representation template
+		}
+
+		section5, err := section.NewSection5FromBytes(data)
+		require.NoError(t, err)
+		assert.Equal(t, number, section5.DataRepresentationTemplateNumber())
+		assert.Equal(t, []byte{0x01, 0x02, 0x03, 0x04}, section5.DataRepresentationTemplate())
+	}
 }
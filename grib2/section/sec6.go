@@ -82,7 +82,7 @@ func NewSection6FromBytes(data []byte) (Section6, error) {
 		bitMapSize := int(s.length) - 6
 		if bitMapSize > 0 {
 			s.bitMap = make([]byte, bitMapSize)
-			if _, err := br.Read(s.bitMap); err != nil {
+			if _, err := io.ReadFull(br, s.bitMap); err != nil {
 				return nil, fmt.Errorf("section6: failed to read bit-map data: %w", err)
 			}
 		}
@@ -15,6 +15,7 @@ type section7 struct {
 	// Smart buffering - stores data as it's read
 	buffer         []byte
 	originalReader io.Reader
+	dataAt         io.ReaderAt // Random access to the data, when the source supports it
 	isFullyRead    bool
 	readErr        error
 
@@ -58,6 +59,9 @@ func (s *section7) readChunk(minBytes uint32) (int, error) {
 	const chunkSize = 64 * 1024 // 64KB chunks
 	totalRead := 0
 
+	// The chunk is reused across iterations, as short reads may take many of them
+	chunk := make([]byte, min(chunkSize, targetSize-uint32(len(s.buffer))))
+
 	for uint32(len(s.buffer)) < targetSize && s.originalReader != nil {
 		// Calculate chunk size for this iteration
 		remainingNeed := targetSize - uint32(len(s.buffer))
@@ -66,8 +70,7 @@ func (s *section7) readChunk(minBytes uint32) (int, error) {
 			currentChunkSize = int(remainingNeed)
 		}
 
-		chunk := make([]byte, currentChunkSize)
-		n, err := s.originalReader.Read(chunk)
+		n, err := s.originalReader.Read(chunk[:currentChunkSize])
 
 		if n > 0 {
 			s.buffer = append(s.buffer, chunk[:n]...)
@@ -116,6 +119,50 @@ func (s *section7) DataReader() io.Reader {
 	return &section7Reader{section: s, offset: 0}
 }
 
+// ReadDataAt reads len(p) bytes of data starting at offset off of the data. Bytes already
+// buffered are copied; otherwise they are read directly when the section was created with
+// NewSection7FromDataReaderAt, or by buffering the data up to off+len(p).
+func (s *section7) ReadDataAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, fmt.Errorf("section7: negative offset %d", off)
+	}
+	if off >= int64(s.dataSize) {
+		return 0, io.EOF
+	}
+	end := min(off+int64(len(p)), int64(s.dataSize))
+
+	s.mu.RLock()
+	buffered := int64(len(s.buffer)) >= end
+	if buffered {
+		copy(p, s.buffer[off:end])
+	}
+	s.mu.RUnlock()
+
+	switch {
+	case buffered:
+	case s.dataAt != nil:
+		if n, err := s.dataAt.ReadAt(p[:end-off], off); err != nil && !(err == io.EOF && int64(n) == end-off) {
+			return n, err
+		}
+	default:
+		_, _ = s.readChunk(uint32(end))
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+		if s.readErr != nil {
+			return 0, s.readErr
+		}
+		if int64(len(s.buffer)) < end {
+			return 0, io.ErrUnexpectedEOF
+		}
+		copy(p, s.buffer[off:end])
+	}
+
+	if int(end-off) < len(p) {
+		return int(end - off), io.EOF
+	}
+	return len(p), nil
+}
+
 func (s *section7) DataSize() uint32 {
 	return s.dataSize
 }
@@ -214,3 +261,25 @@ func NewSection7FromDataReader(length uint32, sectionNumber uint8, dataReader io
 		buffer:         make([]byte, 0), // Start with empty buffer
 	}
 }
+
+// NewSection7FromDataReaderAt creates a Section7 whose data, starting at offset 0 of data,
+// is buffered as it is read like NewSection7FromDataReader, and can also be read at random
+// with ReadDataAt without reading what precedes it.
+func NewSection7FromDataReaderAt(length uint32, data io.ReaderAt) Section7 {
+	s := NewSection7FromDataReader(length, 7, io.NewSectionReader(data, 0, int64(length-5))).(*section7)
+	s.dataAt = data
+	return s
+}
+
+// NewTruncatedSection7 creates a Section7 of length octets whose data cannot be read,
+// for a section cut short, e.g. because its declared length runs past the end of its
+// message. Its data fails to load with err.
+func NewTruncatedSection7(length uint32, err error) Section7 {
+	return &section7{
+		length:        length,
+		sectionNumber: 7,
+		dataSize:      length - 5,
+		buffer:        make([]byte, 0),
+		readErr:       err,
+	}
+}
@@ -222,3 +222,28 @@ func TestSection7_ConcurrencySafety(t *testing.T) {
 		t.Errorf("LoadError should be nil, got: %v", err)
 	}
 }
+
+// TestReadDataAt reads ranges of the data, with and without random access to the source
+func (s *Section7ReaderTestSuite) TestReadDataAt() {
+	sequential, err := section.NewSection7FromReader(bytes.NewReader(s.sectionData))
+	s.Require().NoError(err)
+
+	for _, sec := range []section.Section7{
+		sequential.(section.Section7),
+		section.NewSection7FromDataReaderAt(uint32(len(s.sectionData)), bytes.NewReader(s.testData)),
+	} {
+		p := make([]byte, 10)
+		n, err := sec.ReadDataAt(p, 40)
+		s.Require().NoError(err)
+		s.Assert().Equal(10, n)
+		s.Assert().Equal(s.testData[40:50], p)
+
+		// Reads past the end of the data are short
+		n, err = sec.ReadDataAt(p, 95)
+		s.Assert().ErrorIs(err, io.EOF)
+		s.Assert().Equal(5, n)
+		s.Assert().Equal(s.testData[95:], p[:n])
+
+		s.Assert().Equal(s.testData, sec.Data())
+	}
+}
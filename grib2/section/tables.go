@@ -0,0 +1,156 @@
+package section
+
+import "fmt"
+
+// ReferenceTimeSignificance is the significance of the reference time (Code Table 1.2)
+type ReferenceTimeSignificance uint8
+
+// Code Table 1.2 values
+const (
+	SignificanceAnalysis        ReferenceTimeSignificance = 0   // Analysis
+	SignificanceStartOfForecast ReferenceTimeSignificance = 1   // Start of forecast
+	SignificanceVerifyingTime   ReferenceTimeSignificance = 2   // Verifying time of forecast
+	SignificanceObservationTime ReferenceTimeSignificance = 3   // Observation time
+	SignificanceLocalTime       ReferenceTimeSignificance = 4   // Local time
+	SignificanceMissing         ReferenceTimeSignificance = 255 // Missing
+)
+
+var referenceTimeSignificanceNames = map[ReferenceTimeSignificance]string{
+	SignificanceAnalysis:        "analysis",
+	SignificanceStartOfForecast: "start of forecast",
+	SignificanceVerifyingTime:   "verifying time of forecast",
+	SignificanceObservationTime: "observation time",
+	SignificanceLocalTime:       "local time",
+}
+
+// String returns the Code Table 1.2 meaning
+func (s ReferenceTimeSignificance) String() string {
+	return tableName(referenceTimeSignificanceNames, s)
+}
+
+// ProductionStatus is the production status of processed data (Code Table 1.3)
+type ProductionStatus uint8
+
+// Code Table 1.3 values
+const (
+	StatusOperational                      ProductionStatus = 0   // Operational products
+	StatusOperationalTest                  ProductionStatus = 1   // Operational test products
+	StatusResearch                         ProductionStatus = 2   // Research products
+	StatusReanalysis                       ProductionStatus = 3   // Re-analysis products
+	StatusTIGGE                            ProductionStatus = 4   // THORPEX Interactive Grand Global Ensemble (TIGGE)
+	StatusTIGGETest                        ProductionStatus = 5   // TIGGE test
+	StatusS2S                              ProductionStatus = 6   // S2S operational products
+	StatusS2STest                          ProductionStatus = 7   // S2S test products
+	StatusUERRA                            ProductionStatus = 8   // Uncertainties in Ensembles of Regional ReAnalyses (UERRA)
+	StatusUERRATest                        ProductionStatus = 9   // UERRA test
+	StatusCopernicusRegionalReanalysis     ProductionStatus = 10  // Copernicus regional reanalysis
+	StatusCopernicusRegionalReanalysisTest ProductionStatus = 11  // Copernicus regional reanalysis test
+	StatusDestinationEarth                 ProductionStatus = 12  // Destination Earth
+	StatusDestinationEarthTest             ProductionStatus = 13  // Destination Earth test
+	StatusMissing                          ProductionStatus = 255 // Missing
+)
+
+var productionStatusNames = map[ProductionStatus]string{
+	StatusOperational:                      "operational products",
+	StatusOperationalTest:                  "operational test products",
+	StatusResearch:                         "research products",
+	StatusReanalysis:                       "re-analysis products",
+	StatusTIGGE:                            "TIGGE",
+	StatusTIGGETest:                        "TIGGE test",
+	StatusS2S:                              "S2S operational products",
+	StatusS2STest:                          "S2S test products",
+	StatusUERRA:                            "UERRA",
+	StatusUERRATest:                        "UERRA test",
+	StatusCopernicusRegionalReanalysis:     "Copernicus regional reanalysis",
+	StatusCopernicusRegionalReanalysisTest: "Copernicus regional reanalysis test",
+	StatusDestinationEarth:                 "Destination Earth",
+	StatusDestinationEarthTest:             "Destination Earth test",
+}
+
+// String returns the Code Table 1.3 meaning
+func (s ProductionStatus) String() string {
+	return tableName(productionStatusNames, s)
+}
+
+// IsOperational reports whether the data are operational products
+func (s ProductionStatus) IsOperational() bool {
+	return s == StatusOperational
+}
+
+// IsTestData reports whether the data are test products of any production stream
+func (s ProductionStatus) IsTestData() bool {
+	switch s {
+	case StatusOperationalTest, StatusTIGGETest, StatusS2STest, StatusUERRATest,
+		StatusCopernicusRegionalReanalysisTest, StatusDestinationEarthTest:
+		return true
+	default:
+		return false
+	}
+}
+
+// ProcessedDataType is the type of processed data in the message (Code Table 1.4)
+type ProcessedDataType uint8
+
+// Code Table 1.4 values
+const (
+	DataTypeAnalysis                    ProcessedDataType = 0   // Analysis products
+	DataTypeForecast                    ProcessedDataType = 1   // Forecast products
+	DataTypeAnalysisAndForecast         ProcessedDataType = 2   // Analysis and forecast products
+	DataTypeControlForecast             ProcessedDataType = 3   // Control forecast products
+	DataTypePerturbedForecast           ProcessedDataType = 4   // Perturbed forecast products
+	DataTypeControlAndPerturbedForecast ProcessedDataType = 5   // Control and perturbed forecast products
+	DataTypeSatelliteObservations       ProcessedDataType = 6   // Processed satellite observations
+	DataTypeRadarObservations           ProcessedDataType = 7   // Processed radar observations
+	DataTypeEventProbability            ProcessedDataType = 8   // Event probability
+	DataTypeMissing                     ProcessedDataType = 255 // Missing
+)
+
+var processedDataTypeNames = map[ProcessedDataType]string{
+	DataTypeAnalysis:                    "analysis products",
+	DataTypeForecast:                    "forecast products",
+	DataTypeAnalysisAndForecast:         "analysis and forecast products",
+	DataTypeControlForecast:             "control forecast products",
+	DataTypePerturbedForecast:           "perturbed forecast products",
+	DataTypeControlAndPerturbedForecast: "control and perturbed forecast products",
+	DataTypeSatelliteObservations:       "processed satellite observations",
+	DataTypeRadarObservations:           "processed radar observations",
+	DataTypeEventProbability:            "event probability",
+}
+
+// String returns the Code Table 1.4 meaning
+func (t ProcessedDataType) String() string {
+	return tableName(processedDataTypeNames, t)
+}
+
+// IsAnalysis reports whether the message contains analysis products
+func (t ProcessedDataType) IsAnalysis() bool {
+	return t == DataTypeAnalysis || t == DataTypeAnalysisAndForecast
+}
+
+// IsForecast reports whether the message contains forecast products, including ensemble forecasts
+func (t ProcessedDataType) IsForecast() bool {
+	switch t {
+	case DataTypeForecast, DataTypeAnalysisAndForecast, DataTypeControlForecast,
+		DataTypePerturbedForecast, DataTypeControlAndPerturbedForecast:
+		return true
+	default:
+		return false
+	}
+}
+
+// tableName looks up the meaning of a Section 1 code table entry,
+// describing local, missing and reserved entries generically
+func tableName[T ~uint8](names map[T]string, v T) string {
+	if name, ok := names[v]; ok {
+		return name
+	}
+
+	switch {
+	case v == 255:
+		return "missing"
+	case v >= 192:
+		return fmt.Sprintf("local(%d)", uint8(v))
+	default:
+		return fmt.Sprintf("reserved(%d)", uint8(v))
+	}
+}
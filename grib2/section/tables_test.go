@@ -0,0 +1,88 @@
+package section_test
+
+import (
+	"testing"
+
+	"github.com/scorix/grib/grib2/section"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReferenceTimeSignificanceString(t *testing.T) {
+	tests := map[section.ReferenceTimeSignificance]string{
+		section.SignificanceAnalysis:        "analysis",
+		section.SignificanceStartOfForecast: "start of forecast",
+		section.SignificanceVerifyingTime:   "verifying time of forecast",
+		section.SignificanceObservationTime: "observation time",
+		section.SignificanceLocalTime:       "local time",
+		5:                                   "reserved(5)",
+		191:                                 "reserved(191)",
+		192:                                 "local(192)",
+		254:                                 "local(254)",
+		section.SignificanceMissing:         "missing",
+	}
+
+	for value, want := range tests {
+		assert.Equal(t, want, value.String())
+	}
+}
+
+func TestProductionStatus(t *testing.T) {
+	tests := []struct {
+		status      section.ProductionStatus
+		name        string
+		operational bool
+		test        bool
+	}{
+		{section.StatusOperational, "operational products", true, false},
+		{section.StatusOperationalTest, "operational test products", false, true},
+		{section.StatusResearch, "research products", false, false},
+		{section.StatusReanalysis, "re-analysis products", false, false},
+		{section.StatusTIGGE, "TIGGE", false, false},
+		{section.StatusTIGGETest, "TIGGE test", false, true},
+		{section.StatusS2S, "S2S operational products", false, false},
+		{section.StatusS2STest, "S2S test products", false, true},
+		{section.StatusUERRA, "UERRA", false, false},
+		{section.StatusUERRATest, "UERRA test", false, true},
+		{section.StatusCopernicusRegionalReanalysis, "Copernicus regional reanalysis", false, false},
+		{section.StatusCopernicusRegionalReanalysisTest, "Copernicus regional reanalysis test", false, true},
+		{section.StatusDestinationEarth, "Destination Earth", false, false},
+		{section.StatusDestinationEarthTest, "Destination Earth test", false, true},
+		{14, "reserved(14)", false, false},
+		{200, "local(200)", false, false},
+		{section.StatusMissing, "missing", false, false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.name, tt.status.String())
+		assert.Equal(t, tt.operational, tt.status.IsOperational(), tt.name)
+		assert.Equal(t, tt.test, tt.status.IsTestData(), tt.name)
+	}
+}
+
+func TestProcessedDataType(t *testing.T) {
+	tests := []struct {
+		dataType section.ProcessedDataType
+		name     string
+		analysis bool
+		forecast bool
+	}{
+		{section.DataTypeAnalysis, "analysis products", true, false},
+		{section.DataTypeForecast, "forecast products", false, true},
+		{section.DataTypeAnalysisAndForecast, "analysis and forecast products", true, true},
+		{section.DataTypeControlForecast, "control forecast products", false, true},
+		{section.DataTypePerturbedForecast, "perturbed forecast products", false, true},
+		{section.DataTypeControlAndPerturbedForecast, "control and perturbed forecast products", false, true},
+		{section.DataTypeSatelliteObservations, "processed satellite observations", false, false},
+		{section.DataTypeRadarObservations, "processed radar observations", false, false},
+		{section.DataTypeEventProbability, "event probability", false, false},
+		{9, "reserved(9)", false, false},
+		{192, "local(192)", false, false},
+		{section.DataTypeMissing, "missing", false, false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.name, tt.dataType.String())
+		assert.Equal(t, tt.analysis, tt.dataType.IsAnalysis(), tt.name)
+		assert.Equal(t, tt.forecast, tt.dataType.IsForecast(), tt.name)
+	}
+}
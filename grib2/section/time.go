@@ -0,0 +1,70 @@
+package section
+
+import (
+	"fmt"
+	"time"
+)
+
+// ErrInvalidTimestamp reports a Section 1 reference time whose components are out of range.
+// It carries the raw components as encoded in the message.
+type ErrInvalidTimestamp struct {
+	Year, Month, Day, Hour, Minute, Second int
+}
+
+// Error implements the error interface
+func (e *ErrInvalidTimestamp) Error() string {
+	return fmt.Sprintf("invalid timestamp %04d-%02d-%02d %02d:%02d:%02d",
+		e.Year, e.Month, e.Day, e.Hour, e.Minute, e.Second)
+}
+
+// TimeOption configures how reference time components are converted into a time.Time
+type TimeOption func(*timeOptions)
+
+type timeOptions struct {
+	normalize bool
+}
+
+// Normalize accepts timestamps that are off by one in a way some producers commonly encode:
+//   - second 60 (a leap second) and minute 60 roll over into the next minute or hour;
+//   - hour 24 rolls over to midnight of the next day;
+//   - month 0 and day 0 are clamped to 1, and a day past the end of the month (e.g. February 30)
+//     is clamped to the last day of that month.
+//
+// Components further out of range are still rejected.
+func Normalize() TimeOption {
+	return func(o *timeOptions) {
+		o.normalize = true
+	}
+}
+
+// Timestamp converts reference time components into a time in UTC. By default every component
+// must be within its calendar range; see Normalize for the lenient mode. Invalid components
+// are reported as *ErrInvalidTimestamp.
+func Timestamp(year, month, day, hour, minute, second int, opts ...TimeOption) (time.Time, error) {
+	var o timeOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	invalid := &ErrInvalidTimestamp{year, month, day, hour, minute, second}
+
+	if o.normalize {
+		if month > 12 || hour > 24 || minute > 60 || second > 60 {
+			return time.Time{}, invalid
+		}
+		month = max(month, 1)
+		day = min(max(day, 1), daysIn(year, month))
+		// time.Date rolls hour 24, minute 60 and second 60 into the next day, hour or minute
+		return time.Date(year, time.Month(month), day, hour, minute, second, 0, time.UTC), nil
+	}
+
+	if month < 1 || month > 12 || day < 1 || day > daysIn(year, month) || hour > 23 || minute > 59 || second > 59 {
+		return time.Time{}, invalid
+	}
+	return time.Date(year, time.Month(month), day, hour, minute, second, 0, time.UTC), nil
+}
+
+// daysIn returns the number of days in a month
+func daysIn(year, month int) int {
+	return time.Date(year, time.Month(month)+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
@@ -0,0 +1,100 @@
+package section_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/scorix/grib/grib2/section"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimestamp(t *testing.T) {
+	tests := []struct {
+		name          string
+		components    [6]int // year, month, day, hour, minute, second
+		want          time.Time
+		wantErr       bool
+		normalized    time.Time
+		normalizedErr bool
+	}{
+		{
+			name:       "valid",
+			components: [6]int{2024, 3, 15, 12, 30, 59},
+			want:       time.Date(2024, 3, 15, 12, 30, 59, 0, time.UTC),
+			normalized: time.Date(2024, 3, 15, 12, 30, 59, 0, time.UTC),
+		},
+		{
+			name:       "leap day",
+			components: [6]int{2024, 2, 29, 0, 0, 0},
+			want:       time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+			normalized: time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:       "leap second",
+			components: [6]int{2016, 12, 31, 23, 59, 60},
+			wantErr:    true,
+			normalized: time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:       "hour 24",
+			components: [6]int{2024, 3, 15, 24, 0, 0},
+			wantErr:    true,
+			normalized: time.Date(2024, 3, 16, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:       "february 30",
+			components: [6]int{2023, 2, 30, 6, 0, 0},
+			wantErr:    true,
+			normalized: time.Date(2023, 2, 28, 6, 0, 0, 0, time.UTC),
+		},
+		{
+			name:       "month 0",
+			components: [6]int{2024, 0, 10, 0, 0, 0},
+			wantErr:    true,
+			normalized: time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:       "day 0",
+			components: [6]int{2024, 5, 0, 0, 0, 0},
+			wantErr:    true,
+			normalized: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:          "month 13",
+			components:    [6]int{2024, 13, 1, 0, 0, 0},
+			wantErr:       true,
+			normalizedErr: true,
+		},
+		{
+			name:          "hour 25",
+			components:    [6]int{2024, 1, 1, 25, 0, 0},
+			wantErr:       true,
+			normalizedErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := tt.components
+
+			got, err := section.Timestamp(c[0], c[1], c[2], c[3], c[4], c[5])
+			if tt.wantErr {
+				var invalid *section.ErrInvalidTimestamp
+				require.ErrorAs(t, err, &invalid)
+				assert.Equal(t, section.ErrInvalidTimestamp{Year: c[0], Month: c[1], Day: c[2], Hour: c[3], Minute: c[4], Second: c[5]}, *invalid)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+
+			got, err = section.Timestamp(c[0], c[1], c[2], c[3], c[4], c[5], section.Normalize())
+			if tt.normalizedErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.normalized, got)
+		})
+	}
+}
@@ -9,10 +9,16 @@ type DataField struct {
 	DataRep    section.Section5 // Section 5 - Data Representation (required)
 	Bitmap     section.Section6 // Section 6 - Bitmap (optional, nil if not present)
 	Data       section.Section7 // Section 7 - Data (required)
+
+	// PreviousBitmap is the Section 6 of an earlier field of the message defining the
+	// bit-map that Bitmap reuses with bit-map indicator 254, nil otherwise
+	PreviousBitmap section.Section6
 }
 
 // GridBlock represents the middle repeatable sequence (sections 3-7)
-// Contains a grid definition followed by one or more data fields using that grid
+// Contains a grid definition followed by one or more data fields using that grid.
+// A grid block of a local block without a Section 3 of its own shares the GridDef of the
+// previous grid block, which the message holds only once.
 type GridBlock struct {
 	GridDef section.Section3 // Section 3 - Grid Definition (required for this block)
 	Fields  []DataField      // Data fields using this grid (sections 4-7 repeated)
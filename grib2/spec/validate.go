@@ -0,0 +1,149 @@
+package spec
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+
+	"github.com/scorix/grib/grib2/section"
+)
+
+// ErrPointCount reports a data field whose Section 5 packs a different number of values
+// than its grid and bit-map select
+type ErrPointCount struct {
+	Field    string // Position of the field as local block.grid block.field
+	Packed   uint32 // Number of values packed according to Section 5
+	Expected uint32 // Number of grid points selected by the bit-map
+}
+
+// Error implements the error interface
+func (e *ErrPointCount) Error() string {
+	return fmt.Sprintf("validate: field %s packs %d values, expected %d", e.Field, e.Packed, e.Expected)
+}
+
+// Validate checks the structural consistency of the message: required sections are present,
+// the edition is 2, the section lengths add up to the total length in Section 0, counting
+// a Section 3 shared by consecutive grid blocks once, and the number of packed values in
+// each Section 5 matches its grid and bit-map, as *ErrPointCount.
+// Grids may have no data points only when no grid definition applies to the product.
+// All problems found are returned joined together.
+func (m *Message) Validate() error {
+	var errs []error
+
+	if m.Indicator == nil {
+		return errors.New("validate: missing Section 0")
+	}
+	if edition := m.Indicator.Edition(); edition != 2 {
+		errs = append(errs, fmt.Errorf("validate: unsupported edition %d", edition))
+	}
+	if m.Identification == nil {
+		errs = append(errs, errors.New("validate: missing Section 1"))
+	}
+	if m.End == nil {
+		errs = append(errs, errors.New("validate: missing Section 8"))
+	} else if !m.End.IsValid() {
+		errs = append(errs, errors.New("validate: invalid end marker"))
+	}
+	if len(m.Blocks) == 0 {
+		errs = append(errs, errors.New("validate: message has no data fields"))
+	}
+
+	totalLength := uint64(m.Indicator.Length())
+	if m.Identification != nil {
+		totalLength += uint64(m.Identification.Length())
+	}
+	if m.End != nil {
+		totalLength += uint64(m.End.Length())
+	}
+
+	var bitmap []byte // Most recent bit-map, reused by bit-map indicator 254
+	var lastGrid section.Section3
+	for i, local := range m.Blocks {
+		if local.LocalUse != nil {
+			totalLength += uint64(local.LocalUse.Length())
+		}
+		if len(local.Grids) == 0 {
+			errs = append(errs, fmt.Errorf("validate: local block %d has no grid", i))
+		}
+
+		for j, grid := range local.Grids {
+			if grid.GridDef == nil {
+				errs = append(errs, fmt.Errorf("validate: grid block %d.%d is missing Section 3", i, j))
+				continue
+			}
+			if grid.GridDef != lastGrid {
+				// A grid block sharing the Section 3 of the previous one does not repeat it
+				totalLength += uint64(grid.GridDef.Length())
+				lastGrid = grid.GridDef
+			}
+			// Only products without a grid (source of grid definition 255) may have no points
+			noGrid := grid.GridDef.GridDefinitionSource() == 255
+			if grid.GridDef.NumberOfDataPoints() == 0 && !noGrid {
+				errs = append(errs, fmt.Errorf("validate: grid block %d.%d has no data points", i, j))
+			}
+			if len(grid.Fields) == 0 {
+				errs = append(errs, fmt.Errorf("validate: grid block %d.%d has no data fields", i, j))
+			}
+
+			for k, field := range grid.Fields {
+				name := fmt.Sprintf("field %d.%d.%d", i, j, k)
+				if field.ProductDef == nil || field.DataRep == nil || field.Data == nil {
+					errs = append(errs, fmt.Errorf("validate: %s is missing Section 4, 5 or 7", name))
+					continue
+				}
+				totalLength += uint64(field.ProductDef.Length()) + uint64(field.DataRep.Length()) + uint64(field.Data.Length())
+
+				points := grid.GridDef.NumberOfDataPoints()
+				if field.Bitmap != nil {
+					totalLength += uint64(field.Bitmap.Length())
+
+					switch field.Bitmap.BitMapIndicator() {
+					case 0:
+						bitmap = field.Bitmap.BitMap()
+						points = countBits(bitmap, points)
+					case 254:
+						if bitmap == nil {
+							errs = append(errs, fmt.Errorf("validate: %s reuses a bit-map that was never defined", name))
+							continue
+						}
+						points = countBits(bitmap, points)
+					case 255:
+					default:
+						// Predefined bit-maps cannot be checked
+						continue
+					}
+				}
+
+				if noGrid {
+					// The number of values is given by Section 5 alone
+					continue
+				}
+				if n := field.DataRep.NumberOfDataPoints(); n != points {
+					errs = append(errs, &ErrPointCount{Field: fmt.Sprintf("%d.%d.%d", i, j, k), Packed: n, Expected: points})
+				}
+			}
+		}
+	}
+
+	if want := m.Indicator.TotalLength(); totalLength != want {
+		errs = append(errs, fmt.Errorf("validate: sections add up to %d octets, Section 0 declares %d", totalLength, want))
+	}
+
+	return errors.Join(errs...)
+}
+
+// countBits counts the set bits among the first n bits of a bit-map
+func countBits(bitmap []byte, n uint32) uint32 {
+	var count int
+	for i, b := range bitmap {
+		remaining := int(n) - 8*i
+		if remaining <= 0 {
+			break
+		}
+		if remaining < 8 {
+			b &= 0xff << (8 - remaining)
+		}
+		count += bits.OnesCount8(b)
+	}
+	return uint32(count)
+}
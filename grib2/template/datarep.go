@@ -1,5 +1,7 @@
 package template
 
+import "fmt"
+
 // DataRepTemplate contains data representation template specific fields
 type DataRepTemplate struct {
 	TemplateNumber            int     // Data representation template number
@@ -68,10 +70,12 @@ type PNGPackingInfo struct {
 	// The actual PNG data is in the data section
 }
 
-// JPEG2000PackingInfo contains JPEG2000 packing specific fields (template 40)
+// JPEG2000PackingInfo contains JPEG2000 packing specific fields (template 40). The template
+// only carries the type of compression and the target compression ratio; the coding
+// parameters of the codestream are not extracted.
 type JPEG2000PackingInfo struct {
-	TargetCompressionRatio uint8 // Target compression ratio M:1
-	CompressionType        uint8 // Type of compression
+	TargetCompressionRatio uint8 // Target compression ratio M:1, 255 when missing
+	CompressionType        uint8 // Type of compression (Code Table 5.40): 0 lossless, 1 lossy
 	CompressionRatio       uint8 // Compression ratio
 
 	// JPEG2000 specific parameters
@@ -83,12 +87,14 @@ type JPEG2000PackingInfo struct {
 	TileSizeY             uint16 // Tile size in y direction
 }
 
-// CCSDSPackingInfo contains CCSDS recommended lossless compression specific fields (template 42)
+// CCSDSPackingInfo contains CCSDS recommended lossless compression specific fields
+// (template 42). The template only carries the compression options mask, the block size
+// and the reference sample interval; the other fields are not extracted.
 type CCSDSPackingInfo struct {
-	CCSDSFlags uint8 // CCSDS flags
-	BlockSize  uint8 // Block size
-	RSILength  uint8 // Reference sample interval length
-	Flags      uint8 // Additional flags
+	CCSDSFlags uint8  // CCSDS compression options mask, the flags of libaec
+	BlockSize  uint8  // Block size, in samples
+	RSILength  uint16 // Reference sample interval, in blocks
+	Flags      uint8  // Additional flags
 
 	// CCSDS specific parameters
 	CompressionOption    uint8   // Compression option
@@ -127,8 +133,8 @@ type DataRepCommonInfo struct {
 // IsLossyCompression returns true if the template uses lossy compression
 func (dr *DataRepTemplate) IsLossyCompression() bool {
 	switch dr.TemplateNumber {
-	case 40: // JPEG2000
-		return dr.JPEG2000 != nil && dr.JPEG2000.CompressionType == 0 // 0 indicates lossy
+	case 40, 40000: // JPEG2000
+		return dr.JPEG2000 != nil && dr.JPEG2000.CompressionType == 1 // 1 indicates lossy
 	case 41: // PNG (always lossless)
 		return false
 	case 42: // CCSDS (always lossless)
@@ -168,3 +174,29 @@ func (dr *DataRepTemplate) GetMissingValueSubstitutes() (primary float32, second
 	}
 	return 0, 0, false
 }
+
+// dataRepNames contains the names of the data representation templates (Code Table 5.0)
+var dataRepNames = map[int]string{
+	0:     "grid point data - simple packing",
+	1:     "matrix value at grid point - simple packing",
+	2:     "grid point data - complex packing",
+	3:     "grid point data - complex packing and spatial differencing",
+	4:     "grid point data - IEEE floating point data",
+	40:    "grid point data - JPEG 2000 code stream format",
+	41:    "grid point data - Portable Network Graphics (PNG)",
+	42:    "grid point data - CCSDS recommended lossless compression",
+	50:    "spectral data - simple packing",
+	51:    "spherical harmonics data - complex packing",
+	61:    "grid point data - simple packing with logarithm pre-processing",
+	200:   "run length packing with level values",
+	40000: "grid point data - JPEG 2000 code stream format (NCEP local)",
+	40010: "grid point data - Portable Network Graphics (NCEP local)",
+}
+
+// DataRepresentationName returns the name of a data representation template (Code Table 5.0)
+func DataRepresentationName(templateNumber int) string {
+	if name, ok := dataRepNames[templateNumber]; ok {
+		return name
+	}
+	return fmt.Sprintf("data representation template %d", templateNumber)
+}
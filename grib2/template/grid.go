@@ -1,5 +1,13 @@
 package template
 
+// Sources of grid definition (Code Table 3.0). The grid definition template of Section 3
+// is only meaningful with GridSourceTemplate.
+const (
+	GridSourceTemplate      = 0   // Specified by the grid definition template
+	GridSourcePredetermined = 1   // Predetermined grid definition, defined by the originating centre
+	GridSourceNone          = 255 // A grid definition does not apply to this product
+)
+
 // GridTemplate contains grid definition template specific fields
 type GridTemplate struct {
 	TemplateNumber             int // Grid definition template number
@@ -36,6 +44,23 @@ type GridTemplate struct {
 	Curvilinear                       *CurvilinearGrid                       // For template 204: Curvilinear orthogonal grids
 }
 
+// HasTemplate reports whether the grid is specified by a WMO grid definition template,
+// whose template-specific fields are then populated
+func (g *GridTemplate) HasTemplate() bool {
+	return g.SourceOfGridDefinition == GridSourceTemplate
+}
+
+// CentreDefined reports whether the grid is predetermined by the originating centre, in
+// which case the template number and bytes are centre-specific
+func (g *GridTemplate) CentreDefined() bool {
+	return g.SourceOfGridDefinition == GridSourcePredetermined
+}
+
+// Absent reports whether no grid applies to the product, e.g. for station data
+func (g *GridTemplate) Absent() bool {
+	return g.SourceOfGridDefinition == GridSourceNone
+}
+
 // LatLonGrid contains latitude/longitude grid specific fields (template 0)
 type LatLonGrid struct {
 	ShapeOfEarth               uint8  // Shape of the Earth
@@ -59,6 +84,15 @@ type LatLonGrid struct {
 	ScanningMode               uint8  // Scanning mode
 }
 
+// GridTemplate returns the grid definition of template 3.0 with these fields
+func (g *LatLonGrid) GridTemplate() GridTemplate {
+	return GridTemplate{
+		TemplateNumber:     0,
+		NumberOfDataPoints: int(g.NumberOfGridPointsAlongX) * int(g.NumberOfGridPointsAlongY),
+		LatLon:             g,
+	}
+}
+
 // RotatedLatLonGrid contains rotated latitude/longitude grid specific fields (template 1)
 type RotatedLatLonGrid struct {
 	LatLonGrid                    // Embedded lat/lon grid
@@ -161,9 +195,10 @@ type PolarStereoGrid struct {
 	LatitudeOfFirstGridPoint   int32  // Latitude of first grid point (microdegrees)
 	LongitudeOfFirstGridPoint  uint32 // Longitude of first grid point (microdegrees)
 	ResolutionAndComponentFlag uint8  // Resolution and component flags
+	LatitudeWhereDxDySpecified int32  // Latitude where Dx and Dy are specified (microdegrees)
 	OrientationOfGrid          uint32 // Orientation of the grid (microdegrees)
-	XDirectionIncrement        uint32 // X-direction grid length (metres)
-	YDirectionIncrement        uint32 // Y-direction grid length (metres)
+	XDirectionIncrement        uint32 // X-direction grid length (10^-3 metres)
+	YDirectionIncrement        uint32 // Y-direction grid length (10^-3 metres)
 	ProjectionCenterFlag       uint8  // Projection center flag
 	ScanningMode               uint8  // Scanning mode
 }
@@ -182,9 +217,10 @@ type LambertGrid struct {
 	LatitudeOfFirstGridPoint   int32  // Latitude of first grid point (microdegrees)
 	LongitudeOfFirstGridPoint  uint32 // Longitude of first grid point (microdegrees)
 	ResolutionAndComponentFlag uint8  // Resolution and component flags
+	LatitudeWhereDxDySpecified int32  // Latitude where Dx and Dy are specified (microdegrees)
 	OrientationOfGrid          uint32 // Orientation of the grid (microdegrees)
-	XDirectionIncrement        uint32 // X-direction grid length (metres)
-	YDirectionIncrement        uint32 // Y-direction grid length (metres)
+	XDirectionIncrement        uint32 // X-direction grid length (10^-3 metres)
+	YDirectionIncrement        uint32 // Y-direction grid length (10^-3 metres)
 	ProjectionCenterFlag       uint8  // Projection center flag
 	ScanningMode               uint8  // Scanning mode
 	LatitudeOfIntersection1    int32  // Latitude of first standard parallel (microdegrees)
@@ -218,10 +254,39 @@ type AlbersGrid struct {
 	LongitudeOfSouthernPole    uint32 // Longitude of the southern pole (microdegrees)
 }
 
-// GaussianGrid contains Gaussian latitude/longitude grid specific fields (template 40)
+// GaussianGrid contains Gaussian latitude/longitude grid specific fields (template 40).
+// The YDirectionIncrement of the embedded lat/lon grid is missing (all ones): the
+// template holds NumberOfParallels in its place.
 type GaussianGrid struct {
 	LatLonGrid               // Embedded lat/lon grid
 	NumberOfParallels uint32 // Number of parallels between a pole and the equator
+
+	// PointsPerParallel holds the number of points along each parallel of a reduced
+	// (quasi-regular) grid, whose NumberOfGridPointsAlongX is missing, from the optional
+	// list of Section 3. It is nil for regular grids.
+	PointsPerParallel []uint32
+}
+
+// Reduced reports whether the grid is reduced (quasi-regular), with a number of points
+// along each parallel given by PointsPerParallel
+func (g *GaussianGrid) Reduced() bool {
+	return IsMissing(g.NumberOfGridPointsAlongX)
+}
+
+// GridTemplate returns the grid definition of template 3.40 with these fields
+func (g *GaussianGrid) GridTemplate() GridTemplate {
+	points := int(g.NumberOfGridPointsAlongX) * int(g.NumberOfGridPointsAlongY)
+	if g.Reduced() {
+		points = 0
+		for _, n := range g.PointsPerParallel {
+			points += int(n)
+		}
+	}
+	return GridTemplate{
+		TemplateNumber:     40,
+		NumberOfDataPoints: points,
+		Gaussian:           g,
+	}
 }
 
 // RotatedGaussianGrid contains rotated Gaussian latitude/longitude grid specific fields (template 41)
@@ -292,18 +357,31 @@ type SpaceViewGrid struct {
 	ScaledValueMinorAxis        uint32 // Scaled value of minor axis of oblate spheroid Earth
 	NumberOfGridPointsAlongX    uint32 // Number of points along x-axis
 	NumberOfGridPointsAlongY    uint32 // Number of points along y-axis
-	LapValue                    int32  // Lap value (angle in microdegrees)
-	LopValue                    uint32 // Lop value (angle in microdegrees)
+	LapValue                    int32  // Latitude of sub-satellite point (microdegrees)
+	LopValue                    uint32 // Longitude of sub-satellite point (microdegrees)
 	ResolutionAndComponentFlag  uint8  // Resolution and component flags
-	XDirectionIncrement         uint32 // Apparent diameter of Earth in grid lengths
-	YDirectionIncrement         uint32 // Apparent diameter of Earth in grid lengths
-	XCoordinateOfOrigin         int32  // X-coordinate of sub-satellite point
-	YCoordinateOfOrigin         int32  // Y-coordinate of sub-satellite point
+	XDirectionIncrement         uint32 // Apparent diameter of Earth in grid lengths, in x-direction
+	YDirectionIncrement         uint32 // Apparent diameter of Earth in grid lengths, in y-direction
+	XCoordinateOfOrigin         uint32 // X-coordinate of sub-satellite point (10^-3 grid lengths)
+	YCoordinateOfOrigin         uint32 // Y-coordinate of sub-satellite point (10^-3 grid lengths)
 	ScanningMode                uint8  // Scanning mode
-	OrientationOfGrid           uint32 // Orientation of the grid (microdegrees)
-	NrValue                     uint32 // Nr value (height of view point in Earth radii × 10^6)
-	XCoordinateOfOriginOfSector int32  // X-coordinate of origin of sector image
-	YCoordinateOfOriginOfSector int32  // Y-coordinate of origin of sector image
+	OrientationOfGrid           int32  // Orientation of the grid (microdegrees)
+	NrValue                     uint32 // Nr value (altitude of view point from the Earth's centre in Earth radii × 10^6)
+	XCoordinateOfOriginOfSector uint32 // X-coordinate of origin of sector image, missing for a full disk
+	YCoordinateOfOriginOfSector uint32 // Y-coordinate of origin of sector image, missing for a full disk
+}
+
+// Orthographic reports whether the view is orthographic, from an infinite distance, which
+// is coded with Nr missing
+func (g *SpaceViewGrid) Orthographic() bool {
+	return IsMissing(g.NrValue)
+}
+
+// SectorOrigin returns the coordinates of the origin of the sector image; ok is false when
+// either is missing
+func (g *SpaceViewGrid) SectorOrigin() (x, y uint32, ok bool) {
+	return g.XCoordinateOfOriginOfSector, g.YCoordinateOfOriginOfSector,
+		!IsMissing(g.XCoordinateOfOriginOfSector) && !IsMissing(g.YCoordinateOfOriginOfSector)
 }
 
 // TriangularGrid contains triangular grid based on an icosahedron specific fields (template 100)
@@ -0,0 +1,313 @@
+package template
+
+import (
+	"encoding/binary"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"sync"
+
+	"github.com/scorix/grib/grib2/units"
+)
+
+// GridParser parses the octets of a grid definition template, from octet 15 of Section 3
+// up to the optional list of numbers, into the template-specific field of grid, e.g.
+// grid.LatLon for template 3.0. grid.TemplateNumber is set before the parser is called.
+type GridParser func(data []byte, grid *GridTemplate) error
+
+var (
+	gridParsersMu sync.RWMutex
+	gridParsers   = map[uint16]GridParser{
+		0:  parseLatLonGridTemplate,
+		1:  parseRotatedLatLonGridTemplate,
+		20: parsePolarStereoGridTemplate,
+		30: parseLambertGridTemplate,
+		40: parseGaussianGridTemplate,
+		90: parseSpaceViewGridTemplate,
+	}
+)
+
+// RegisterGridParser makes fn parse the grid definition template templateNumber (Code
+// Table 3.1), e.g. a local template of a centre, numbered from 32768, whose fields fn
+// stores where its callers expect them. A parser registered for a template parsed by the
+// package replaces the built-in one.
+//
+// Parsers are shared by all readers; register them during initialisation, before messages
+// are read.
+func RegisterGridParser(templateNumber uint16, fn GridParser) {
+	gridParsersMu.Lock()
+	defer gridParsersMu.Unlock()
+	gridParsers[templateNumber] = fn
+}
+
+// GridTemplateNumbers returns the grid definition templates with a parser, in ascending order
+func GridTemplateNumbers() []uint16 {
+	gridParsersMu.RLock()
+	defer gridParsersMu.RUnlock()
+	return slices.Sorted(maps.Keys(gridParsers))
+}
+
+// ParseGridTemplate parses the octets of grid definition template templateNumber, from
+// octet 15 of Section 3 up to the optional list of numbers. The returned grid has its
+// TemplateNumber and template-specific field set; the other common fields come from
+// Section 3 itself, as does the list of points per parallel of reduced Gaussian grids.
+func ParseGridTemplate(templateNumber uint16, data []byte) (*GridTemplate, error) {
+	gridParsersMu.RLock()
+	parse, ok := gridParsers[templateNumber]
+	gridParsersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("template: unsupported grid definition template 3.%d", templateNumber)
+	}
+
+	grid := &GridTemplate{TemplateNumber: int(templateNumber), SourceOfGridDefinition: GridSourceTemplate}
+	if err := parse(data, grid); err != nil {
+		return nil, fmt.Errorf("template: grid definition template 3.%d: %w", templateNumber, err)
+	}
+	return grid, nil
+}
+
+// checkGridTemplateLength returns an error when a grid definition template holds fewer
+// than n octets
+func checkGridTemplateLength(data []byte, n int) error {
+	if len(data) < n {
+		return fmt.Errorf("%d octets, need %d", len(data), n)
+	}
+	return nil
+}
+
+// parseLatLonGridTemplate parses template 3.0 (latitude/longitude, or equidistant
+// cylindrical, or Plate Carree)
+func parseLatLonGridTemplate(data []byte, grid *GridTemplate) error {
+	if err := checkGridTemplateLength(data, 58); err != nil { // Octets 15-72
+		return err
+	}
+	g := latLonGrid(data)
+	grid.LatLon = &g
+	return nil
+}
+
+// parseRotatedLatLonGridTemplate parses template 3.1 (rotated latitude/longitude),
+// template 3.0 followed by the rotation of the grid
+func parseRotatedLatLonGridTemplate(data []byte, grid *GridTemplate) error {
+	if err := checkGridTemplateLength(data, 70); err != nil { // Octets 15-84
+		return err
+	}
+	grid.RotatedLatLon = &RotatedLatLonGrid{
+		LatLonGrid: latLonGrid(data),
+
+		// Latitude and longitude of the southern pole of projection (octets 73-80 = octets 59-66 of template)
+		LatitudeOfSouthernPole:  units.SignMagnitudeInt32(binary.BigEndian.Uint32(data[58:62])),
+		LongitudeOfSouthernPole: int32(binary.BigEndian.Uint32(data[62:66])),
+
+		// Angle of rotation of projection (octets 81-84 = octets 67-70 of template), in
+		// degrees as an IEEE single precision number
+		AngleOfRotation: units.DegreesToMicro(float64(math.Float32frombits(binary.BigEndian.Uint32(data[66:70])))),
+	}
+	return nil
+}
+
+// parsePolarStereoGridTemplate parses template 3.20 (polar stereographic), laid out like
+// the first 51 octets of template 3.30
+func parsePolarStereoGridTemplate(data []byte, grid *GridTemplate) error {
+	if err := checkGridTemplateLength(data, 51); err != nil { // Octets 15-65
+		return err
+	}
+	grid.PolarStereo = &PolarStereoGrid{
+		// Shape of the Earth and its radius or axes (octets 15-30 = octets 1-16 of template)
+		ShapeOfEarth:           data[0],
+		ScaleFactorRadiusEarth: data[1],
+		ScaledValueRadiusEarth: binary.BigEndian.Uint32(data[2:6]),
+		ScaleFactorMajorAxis:   data[6],
+		ScaledValueMajorAxis:   binary.BigEndian.Uint32(data[7:11]),
+		ScaleFactorMinorAxis:   data[11],
+		ScaledValueMinorAxis:   binary.BigEndian.Uint32(data[12:16]),
+
+		// Number of points along the x and y axes (octets 31-38 = octets 17-24 of template)
+		NumberOfGridPointsAlongX: binary.BigEndian.Uint32(data[16:20]),
+		NumberOfGridPointsAlongY: binary.BigEndian.Uint32(data[20:24]),
+
+		// First grid point (octets 39-46 = octets 25-32 of template)
+		LatitudeOfFirstGridPoint:  units.SignMagnitudeInt32(binary.BigEndian.Uint32(data[24:28])),
+		LongitudeOfFirstGridPoint: binary.BigEndian.Uint32(data[28:32]),
+
+		// Resolution and component flags (octet 47 = octet 33 of template)
+		ResolutionAndComponentFlag: data[32],
+
+		// LaD, where Dx and Dy are specified, and LoV, the orientation of the grid
+		// (octets 48-55 = octets 34-41 of template)
+		LatitudeWhereDxDySpecified: units.SignMagnitudeInt32(binary.BigEndian.Uint32(data[33:37])),
+		OrientationOfGrid:          binary.BigEndian.Uint32(data[37:41]),
+
+		// x and y direction grid lengths in millimetres (octets 56-63 = octets 42-49 of template)
+		XDirectionIncrement: binary.BigEndian.Uint32(data[41:45]),
+		YDirectionIncrement: binary.BigEndian.Uint32(data[45:49]),
+
+		// Projection centre flag and scanning mode (octets 64-65 = octets 50-51 of template)
+		ProjectionCenterFlag: data[49],
+		ScanningMode:         data[50],
+	}
+	return nil
+}
+
+// parseLambertGridTemplate parses template 3.30 (Lambert conformal)
+func parseLambertGridTemplate(data []byte, grid *GridTemplate) error {
+	if err := checkGridTemplateLength(data, 67); err != nil { // Octets 15-81
+		return err
+	}
+	grid.Lambert = &LambertGrid{
+		// Shape of the Earth and its radius or axes (octets 15-30 = octets 1-16 of template)
+		ShapeOfEarth:           data[0],
+		ScaleFactorRadiusEarth: data[1],
+		ScaledValueRadiusEarth: binary.BigEndian.Uint32(data[2:6]),
+		ScaleFactorMajorAxis:   data[6],
+		ScaledValueMajorAxis:   binary.BigEndian.Uint32(data[7:11]),
+		ScaleFactorMinorAxis:   data[11],
+		ScaledValueMinorAxis:   binary.BigEndian.Uint32(data[12:16]),
+
+		// Number of points along the x and y axes (octets 31-38 = octets 17-24 of template)
+		NumberOfGridPointsAlongX: binary.BigEndian.Uint32(data[16:20]),
+		NumberOfGridPointsAlongY: binary.BigEndian.Uint32(data[20:24]),
+
+		// First grid point (octets 39-46 = octets 25-32 of template)
+		LatitudeOfFirstGridPoint:  units.SignMagnitudeInt32(binary.BigEndian.Uint32(data[24:28])),
+		LongitudeOfFirstGridPoint: binary.BigEndian.Uint32(data[28:32]),
+
+		// Resolution and component flags (octet 47 = octet 33 of template)
+		ResolutionAndComponentFlag: data[32],
+
+		// LaD, where Dx and Dy are specified, and LoV, the orientation of the grid
+		// (octets 48-55 = octets 34-41 of template)
+		LatitudeWhereDxDySpecified: units.SignMagnitudeInt32(binary.BigEndian.Uint32(data[33:37])),
+		OrientationOfGrid:          binary.BigEndian.Uint32(data[37:41]),
+
+		// x and y direction grid lengths in millimetres (octets 56-63 = octets 42-49 of template)
+		XDirectionIncrement: binary.BigEndian.Uint32(data[41:45]),
+		YDirectionIncrement: binary.BigEndian.Uint32(data[45:49]),
+
+		// Projection centre flag and scanning mode (octets 64-65 = octets 50-51 of template)
+		ProjectionCenterFlag: data[49],
+		ScanningMode:         data[50],
+
+		// Standard parallels Latin 1 and Latin 2 (octets 66-73 = octets 52-59 of template)
+		LatitudeOfIntersection1: units.SignMagnitudeInt32(binary.BigEndian.Uint32(data[51:55])),
+		LatitudeOfIntersection2: units.SignMagnitudeInt32(binary.BigEndian.Uint32(data[55:59])),
+
+		// Southern pole of projection (octets 74-81 = octets 60-67 of template)
+		LatitudeOfSouthernPole:  units.SignMagnitudeInt32(binary.BigEndian.Uint32(data[59:63])),
+		LongitudeOfSouthernPole: binary.BigEndian.Uint32(data[63:67]),
+	}
+	return nil
+}
+
+// parseGaussianGridTemplate parses template 3.40 (Gaussian latitude/longitude), laid out
+// like template 3.0 with the number of parallels between a pole and the equator in place
+// of the j direction increment. Reduced grids, with a missing number of points along a
+// parallel, give the number of points of each parallel in the optional list of Section 3,
+// which is not part of data.
+func parseGaussianGridTemplate(data []byte, grid *GridTemplate) error {
+	if err := checkGridTemplateLength(data, 58); err != nil { // Octets 15-72
+		return err
+	}
+	g := &GaussianGrid{LatLonGrid: latLonGrid(data)}
+
+	// N, the number of parallels between a pole and the equator (octets 68-71 = octets 54-57 of template)
+	g.NumberOfParallels = g.YDirectionIncrement
+	g.YDirectionIncrement = 0xffffffff
+	grid.Gaussian = g
+	return nil
+}
+
+// parseSpaceViewGridTemplate parses template 3.90 (space view perspective or
+// orthographic). Nr and the origin of the sector image keep their all ones coding when
+// missing, which SpaceViewGrid.Orthographic and SpaceViewGrid.SectorOrigin report.
+func parseSpaceViewGridTemplate(data []byte, grid *GridTemplate) error {
+	if err := checkGridTemplateLength(data, 66); err != nil { // Octets 15-80
+		return err
+	}
+	grid.SpaceView = &SpaceViewGrid{
+		// Shape of the Earth and its radius or axes (octets 15-30 = octets 1-16 of template)
+		ShapeOfEarth:           data[0],
+		ScaleFactorRadiusEarth: data[1],
+		ScaledValueRadiusEarth: binary.BigEndian.Uint32(data[2:6]),
+		ScaleFactorMajorAxis:   data[6],
+		ScaledValueMajorAxis:   binary.BigEndian.Uint32(data[7:11]),
+		ScaleFactorMinorAxis:   data[11],
+		ScaledValueMinorAxis:   binary.BigEndian.Uint32(data[12:16]),
+
+		// Number of points along the x and y axes (octets 31-38 = octets 17-24 of template)
+		NumberOfGridPointsAlongX: binary.BigEndian.Uint32(data[16:20]),
+		NumberOfGridPointsAlongY: binary.BigEndian.Uint32(data[20:24]),
+
+		// Lap and Lop, the sub-satellite point (octets 39-46 = octets 25-32 of template)
+		LapValue: units.SignMagnitudeInt32(binary.BigEndian.Uint32(data[24:28])),
+		LopValue: binary.BigEndian.Uint32(data[28:32]),
+
+		// Resolution and component flags (octet 47 = octet 33 of template)
+		ResolutionAndComponentFlag: data[32],
+
+		// dx and dy, the apparent diameter of the Earth in grid lengths
+		// (octets 48-55 = octets 34-41 of template)
+		XDirectionIncrement: binary.BigEndian.Uint32(data[33:37]),
+		YDirectionIncrement: binary.BigEndian.Uint32(data[37:41]),
+
+		// Xp and Yp, the sub-satellite point in grid lengths × 10^3
+		// (octets 56-63 = octets 42-49 of template)
+		XCoordinateOfOrigin: binary.BigEndian.Uint32(data[41:45]),
+		YCoordinateOfOrigin: binary.BigEndian.Uint32(data[45:49]),
+
+		// Scanning mode (octet 64 = octet 50 of template)
+		ScanningMode: data[49],
+
+		// Orientation of the grid and Nr, the altitude of the camera
+		// (octets 65-72 = octets 51-58 of template)
+		OrientationOfGrid: units.SignMagnitudeInt32(binary.BigEndian.Uint32(data[50:54])),
+		NrValue:           binary.BigEndian.Uint32(data[54:58]),
+
+		// Xo and Yo, the origin of the sector image (octets 73-80 = octets 59-66 of template)
+		XCoordinateOfOriginOfSector: binary.BigEndian.Uint32(data[58:62]),
+		YCoordinateOfOriginOfSector: binary.BigEndian.Uint32(data[62:66]),
+	}
+	return nil
+}
+
+// latLonGrid parses the octets 15-72 of template 3.0, which templates 3.1 to 3.3 and 3.40
+// start with. data must hold at least 58 octets.
+func latLonGrid(data []byte) LatLonGrid {
+	return LatLonGrid{
+		// Shape of the Earth and its radius or axes (octets 15-30 = octets 1-16 of template)
+		ShapeOfEarth:           data[0],
+		ScaleFactorRadiusEarth: data[1],
+		ScaledValueRadiusEarth: binary.BigEndian.Uint32(data[2:6]),
+		ScaleFactorMajorAxis:   data[6],
+		ScaledValueMajorAxis:   binary.BigEndian.Uint32(data[7:11]),
+		ScaleFactorMinorAxis:   data[11],
+		ScaledValueMinorAxis:   binary.BigEndian.Uint32(data[12:16]),
+
+		// Number of points along a parallel and a meridian (octets 31-38 = octets 17-24 of template)
+		NumberOfGridPointsAlongX: binary.BigEndian.Uint32(data[16:20]),
+		NumberOfGridPointsAlongY: binary.BigEndian.Uint32(data[20:24]),
+
+		// Basic angle and its subdivisions (octets 39-46 = octets 25-32 of template)
+		BasicAngleOfInitialDomain: binary.BigEndian.Uint32(data[24:28]),
+		SubdivisionOfBasicAngle:   binary.BigEndian.Uint32(data[28:32]),
+
+		// First grid point (octets 47-54 = octets 33-40 of template)
+		LatitudeOfFirstGridPoint:  units.SignMagnitudeInt32(binary.BigEndian.Uint32(data[32:36])),
+		LongitudeOfFirstGridPoint: binary.BigEndian.Uint32(data[36:40]),
+
+		// Resolution and component flags (octet 55 = octet 41 of template)
+		ResolutionAndComponentFlag: data[40],
+
+		// Last grid point (octets 56-63 = octets 42-49 of template)
+		LatitudeOfLastGridPoint:  units.SignMagnitudeInt32(binary.BigEndian.Uint32(data[41:45])),
+		LongitudeOfLastGridPoint: binary.BigEndian.Uint32(data[45:49]),
+
+		// i and j direction increments (octets 64-71 = octets 50-57 of template)
+		XDirectionIncrement: binary.BigEndian.Uint32(data[49:53]),
+		YDirectionIncrement: binary.BigEndian.Uint32(data[53:57]),
+
+		// Scanning mode (octet 72 = octet 58 of template)
+		ScanningMode: data[57],
+	}
+}
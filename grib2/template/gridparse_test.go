@@ -0,0 +1,84 @@
+package template_test
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/scorix/grib/grib2/template"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// latLonTemplateBytes returns the octets 15-72 of a template 3.0 with a global 1 degree
+// grid on a spherical Earth
+func latLonTemplateBytes() []byte {
+	data := []byte{6, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}
+	data = binary.BigEndian.AppendUint32(data, 360)
+	data = binary.BigEndian.AppendUint32(data, 181)
+	data = binary.BigEndian.AppendUint32(data, 0)
+	data = binary.BigEndian.AppendUint32(data, 0xffffffff)
+	data = binary.BigEndian.AppendUint32(data, 90_000_000)
+	data = binary.BigEndian.AppendUint32(data, 0)
+	data = append(data, 0x30)
+	data = binary.BigEndian.AppendUint32(data, 0x80000000|90_000_000) // -90 in sign and magnitude
+	data = binary.BigEndian.AppendUint32(data, 359_000_000)
+	data = binary.BigEndian.AppendUint32(data, 1_000_000)
+	data = binary.BigEndian.AppendUint32(data, 1_000_000)
+	return append(data, 0)
+}
+
+func TestParseGridTemplate(t *testing.T) {
+	grid, err := template.ParseGridTemplate(0, latLonTemplateBytes())
+	require.NoError(t, err)
+	assert.Equal(t, 0, grid.TemplateNumber)
+	assert.True(t, grid.HasTemplate())
+	require.NotNil(t, grid.LatLon)
+	assert.Equal(t, template.LatLonGrid{
+		ShapeOfEarth:               6,
+		NumberOfGridPointsAlongX:   360,
+		NumberOfGridPointsAlongY:   181,
+		SubdivisionOfBasicAngle:    0xffffffff,
+		LatitudeOfFirstGridPoint:   90_000_000,
+		ResolutionAndComponentFlag: 0x30,
+		LatitudeOfLastGridPoint:    -90_000_000,
+		LongitudeOfLastGridPoint:   359_000_000,
+		XDirectionIncrement:        1_000_000,
+		YDirectionIncrement:        1_000_000,
+	}, *grid.LatLon)
+
+	// Template 3.40 shares the layout, with N in place of the j direction increment
+	grid, err = template.ParseGridTemplate(40, latLonTemplateBytes())
+	require.NoError(t, err)
+	require.NotNil(t, grid.Gaussian)
+	assert.Nil(t, grid.LatLon)
+	assert.Equal(t, uint32(1_000_000), grid.Gaussian.NumberOfParallels)
+	assert.True(t, template.IsMissing(grid.Gaussian.YDirectionIncrement))
+}
+
+func TestParseGridTemplate_Errors(t *testing.T) {
+	_, err := template.ParseGridTemplate(999, latLonTemplateBytes())
+	assert.ErrorContains(t, err, "unsupported grid definition template 3.999")
+
+	_, err = template.ParseGridTemplate(0, latLonTemplateBytes()[:57])
+	assert.ErrorContains(t, err, "grid definition template 3.0: 57 octets, need 58")
+}
+
+func TestRegisterGridParser(t *testing.T) {
+	// A local template holding the number of points along x and y only
+	const local = 32768
+	template.RegisterGridParser(local, func(data []byte, grid *template.GridTemplate) error {
+		grid.LatLon = &template.LatLonGrid{
+			NumberOfGridPointsAlongX: binary.BigEndian.Uint32(data[0:4]),
+			NumberOfGridPointsAlongY: binary.BigEndian.Uint32(data[4:8]),
+		}
+		return nil
+	})
+	assert.Contains(t, template.GridTemplateNumbers(), uint16(local))
+
+	grid, err := template.ParseGridTemplate(local, []byte{0, 0, 0, 3, 0, 0, 0, 2})
+	require.NoError(t, err)
+	assert.Equal(t, local, grid.TemplateNumber)
+	require.NotNil(t, grid.LatLon)
+	assert.Equal(t, uint32(3), grid.LatLon.NumberOfGridPointsAlongX)
+	assert.Equal(t, uint32(2), grid.LatLon.NumberOfGridPointsAlongY)
+}
@@ -0,0 +1,33 @@
+package template
+
+import (
+	"math"
+
+	"github.com/scorix/grib/grib2/units"
+)
+
+// IsMissing reports whether a coded value has all bits set, which GRIB2 uses to mean "missing"
+func IsMissing[T ~uint8 | ~uint16 | ~uint32](v T) bool {
+	return v == ^T(0)
+}
+
+// isMissingScaleFactor reports whether a one octet scale factor was coded as all ones,
+// which decodes as -127 in sign and magnitude
+func isMissingScaleFactor(scaleFactor int8) bool {
+	return scaleFactor == -127
+}
+
+// isMissingSigned32 reports whether a four octet signed value was coded as all ones, which
+// decodes as -(2^31 - 1) in sign and magnitude
+func isMissingSigned32(v int32) bool {
+	return v == -math.MaxInt32
+}
+
+// scaledValue computes scaledValue × 10^-scaleFactor.
+// ok is false when either component is missing.
+func scaledValue(scaleFactor int8, value uint32) (float64, bool) {
+	if isMissingScaleFactor(scaleFactor) || IsMissing(value) {
+		return 0, false
+	}
+	return units.ScaledValue(scaleFactor, value), true
+}
@@ -0,0 +1,123 @@
+package template
+
+import (
+	"fmt"
+	"maps"
+	"sync"
+)
+
+// ParameterInfo describes a parameter of Code Table 4.2
+type ParameterInfo struct {
+	Discipline   uint8  // Discipline (Code Table 0.0)
+	Category     uint8  // Parameter category (Code Table 4.1)
+	Number       uint8  // Parameter number (Code Table 4.2)
+	Abbreviation string // Abbreviation used in inventories, e.g. "TMP"
+	Name         string // Parameter name
+	Unit         string // WMO unit, e.g. "K" or "kg m-2"
+	StandardName string // CF standard name, e.g. "air_temperature", or "" when there is none
+}
+
+// IsLocal reports whether the parameter is in the ranges of Code Table 4.2 reserved for
+// local use, which each originating centre defines for itself
+func (p ParameterInfo) IsLocal() bool {
+	return IsLocalParameter(p.Discipline, p.Category, p.Number)
+}
+
+// IsLocalParameter reports whether a discipline, category or number is 192 or above, the
+// ranges of Code Tables 0.0, 4.1 and 4.2 reserved for local use (255 being missing)
+func IsLocalParameter(discipline, category, number uint8) bool {
+	return discipline >= 192 || category >= 192 || number >= 192
+}
+
+// parameterKey identifies a parameter by discipline, category and number
+type parameterKey struct {
+	discipline, category, number uint8
+}
+
+// parameters holds the built-in and registered entries of Code Table 4.2
+var (
+	parametersMu sync.RWMutex
+	parameters   = maps.Clone(builtinParameters)
+)
+
+// RegisterParameters adds entries of Code Table 4.2, such as local parameters of a centre
+// or the parameters needed by a build without the built-in tables. Entries registered
+// before for the same parameters, including built-in ones, are replaced.
+func RegisterParameters(params ...ParameterInfo) {
+	parametersMu.Lock()
+	defer parametersMu.Unlock()
+
+	for _, info := range params {
+		parameters[parameterKey{info.Discipline, info.Category, info.Number}] = info
+	}
+}
+
+// LookupParameter returns the Code Table 4.2 entry of a parameter
+func LookupParameter(discipline, category, number uint8) (ParameterInfo, bool) {
+	parametersMu.RLock()
+	defer parametersMu.RUnlock()
+
+	info, ok := parameters[parameterKey{discipline, category, number}]
+	if !ok {
+		return ParameterInfo{}, false
+	}
+	info.Discipline, info.Category, info.Number = discipline, category, number
+	return info, true
+}
+
+// ParameterName returns the name of a parameter, falling back to its numbers, as in
+// "discipline 10 category 3 parameter 200", when the parameter is not in the tables
+func ParameterName(discipline, category, number uint8) string {
+	if info, ok := LookupParameter(discipline, category, number); ok {
+		return info.Name
+	}
+	return fmt.Sprintf("discipline %d category %d parameter %d", discipline, category, number)
+}
+
+// localParameters holds the local entries of Code Table 4.2 of each originating centre
+// (Common Code Table C-11), built-in for NCEP
+var (
+	localParametersMu sync.RWMutex
+	localParameters   = map[uint16]map[parameterKey]ParameterInfo{centreNCEP: ncepLocalParameters}
+)
+
+// RegisterLocalParameters adds local entries of Code Table 4.2 of an originating centre,
+// which LookupCentreParameter consults for parameters missing from the master table.
+// Entries registered before for the same parameters of the centre, including those of the
+// built-in NCEP table, are replaced.
+func RegisterLocalParameters(centre uint16, params ...ParameterInfo) {
+	localParametersMu.Lock()
+	defer localParametersMu.Unlock()
+
+	table := maps.Clone(localParameters[centre])
+	if table == nil {
+		table = make(map[parameterKey]ParameterInfo, len(params))
+	}
+	for _, info := range params {
+		table[parameterKey{info.Discipline, info.Category, info.Number}] = info
+	}
+	localParameters[centre] = table
+}
+
+// LookupCentreParameter returns the Code Table 4.2 entry of a parameter of a message from
+// an originating centre with the given local tables version (Code Table 1.1). Parameters
+// missing from the master table are looked up in the local table of the centre, unless
+// the version is 0 or 255, for messages that use no local tables.
+func LookupCentreParameter(centre uint16, localTablesVersion, discipline, category, number uint8) (ParameterInfo, bool) {
+	if info, ok := LookupParameter(discipline, category, number); ok {
+		return info, true
+	}
+	if localTablesVersion == 0 || localTablesVersion == 255 {
+		return ParameterInfo{}, false
+	}
+
+	localParametersMu.RLock()
+	defer localParametersMu.RUnlock()
+
+	info, ok := localParameters[centre][parameterKey{discipline, category, number}]
+	if !ok {
+		return ParameterInfo{}, false
+	}
+	info.Discipline, info.Category, info.Number = discipline, category, number
+	return info, true
+}
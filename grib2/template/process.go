@@ -0,0 +1,162 @@
+package template
+
+import (
+	"fmt"
+	"maps"
+	"sync"
+)
+
+// GeneratingProcessType is a type of generating process (Code Table 4.3)
+type GeneratingProcessType uint8
+
+// Types of generating process (Code Table 4.3)
+const (
+	ProcessAnalysis                GeneratingProcessType = 0
+	ProcessInitialization          GeneratingProcessType = 1
+	ProcessForecast                GeneratingProcessType = 2
+	ProcessBiasCorrectedForecast   GeneratingProcessType = 3
+	ProcessEnsembleForecast        GeneratingProcessType = 4
+	ProcessProbabilityForecast     GeneratingProcessType = 5
+	ProcessForecastError           GeneratingProcessType = 6
+	ProcessAnalysisError           GeneratingProcessType = 7
+	ProcessObservation             GeneratingProcessType = 8
+	ProcessClimatological          GeneratingProcessType = 9
+	ProcessProbabilityWeighted     GeneratingProcessType = 10
+	ProcessBiasCorrectedEnsemble   GeneratingProcessType = 11
+	ProcessPostProcessedAnalysis   GeneratingProcessType = 12
+	ProcessPostProcessedForecast   GeneratingProcessType = 13
+	ProcessNowcast                 GeneratingProcessType = 14
+	ProcessHindcast                GeneratingProcessType = 15
+	ProcessPhysicalRetrieval       GeneratingProcessType = 16
+	ProcessRegressionAnalysis      GeneratingProcessType = 17
+	ProcessForecastDifference      GeneratingProcessType = 18
+	ProcessFirstGuess              GeneratingProcessType = 19
+	ProcessAnalysisIncrement       GeneratingProcessType = 20
+	ProcessInitializationIncrement GeneratingProcessType = 21
+	ProcessMissing                 GeneratingProcessType = 255
+)
+
+// generatingProcessTypes names the entries of Code Table 4.3
+var generatingProcessTypes = map[GeneratingProcessType]string{
+	ProcessAnalysis:                "analysis",
+	ProcessInitialization:          "initialization",
+	ProcessForecast:                "forecast",
+	ProcessBiasCorrectedForecast:   "bias corrected forecast",
+	ProcessEnsembleForecast:        "ensemble forecast",
+	ProcessProbabilityForecast:     "probability forecast",
+	ProcessForecastError:           "forecast error",
+	ProcessAnalysisError:           "analysis error",
+	ProcessObservation:             "observation",
+	ProcessClimatological:          "climatological",
+	ProcessProbabilityWeighted:     "probability-weighted forecast",
+	ProcessBiasCorrectedEnsemble:   "bias-corrected ensemble forecast",
+	ProcessPostProcessedAnalysis:   "post-processed analysis",
+	ProcessPostProcessedForecast:   "post-processed forecast",
+	ProcessNowcast:                 "nowcast",
+	ProcessHindcast:                "hindcast",
+	ProcessPhysicalRetrieval:       "physical retrieval",
+	ProcessRegressionAnalysis:      "regression analysis",
+	ProcessForecastDifference:      "difference between two forecasts",
+	ProcessFirstGuess:              "first guess",
+	ProcessAnalysisIncrement:       "analysis increment",
+	ProcessInitializationIncrement: "initialization increment for analysis",
+	ProcessMissing:                 "missing",
+}
+
+// String returns the Code Table 4.3 meaning of the type, e.g. "analysis"
+func (t GeneratingProcessType) String() string {
+	if name, ok := generatingProcessTypes[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("generating process type %d", uint8(t))
+}
+
+// centreNCEP is the identifier of NCEP in Common Code Table C-11
+const centreNCEP = 7
+
+var (
+	generatingProcessesMu sync.RWMutex
+	generatingProcesses   = map[uint16]map[uint8]string{centreNCEP: ncepGeneratingProcesses}
+)
+
+// RegisterGeneratingProcesses adds the names of the generating process identifiers of an
+// originating centre (Common Code Table C-11), which each centre defines for itself.
+// Names registered before for the same identifiers, including those of the built-in NCEP
+// table, are replaced.
+func RegisterGeneratingProcesses(centre uint16, names map[uint8]string) {
+	generatingProcessesMu.Lock()
+	defer generatingProcessesMu.Unlock()
+
+	table := maps.Clone(generatingProcesses[centre])
+	if table == nil {
+		table = make(map[uint8]string, len(names))
+	}
+	maps.Copy(table, names)
+	generatingProcesses[centre] = table
+}
+
+// LookupGeneratingProcess returns the name of the model or process with the given
+// generating process identifier at an originating centre
+func LookupGeneratingProcess(centre uint16, id uint8) (string, bool) {
+	generatingProcessesMu.RLock()
+	defer generatingProcessesMu.RUnlock()
+
+	name, ok := generatingProcesses[centre][id]
+	return name, ok
+}
+
+// StatisticalProcess is a type of statistical processing (Code Table 4.10), telling e.g.
+// accumulations from averages and extremes
+type StatisticalProcess uint8
+
+// Types of statistical processing (Code Table 4.10)
+const (
+	StatisticalAverage             StatisticalProcess = 0
+	StatisticalAccumulation        StatisticalProcess = 1
+	StatisticalMaximum             StatisticalProcess = 2
+	StatisticalMinimum             StatisticalProcess = 3
+	StatisticalDifference          StatisticalProcess = 4 // End minus beginning
+	StatisticalRootMeanSquare      StatisticalProcess = 5
+	StatisticalStandardDeviation   StatisticalProcess = 6
+	StatisticalCovariance          StatisticalProcess = 7
+	StatisticalReverseDifference   StatisticalProcess = 8 // Beginning minus end
+	StatisticalRatio               StatisticalProcess = 9
+	StatisticalStandardizedAnomaly StatisticalProcess = 10
+	StatisticalSummation           StatisticalProcess = 11
+	StatisticalReturnPeriod        StatisticalProcess = 12
+	StatisticalMedian              StatisticalProcess = 13
+	StatisticalMissing             StatisticalProcess = 255
+)
+
+// statisticalProcesses names the entries of Code Table 4.10
+var statisticalProcesses = map[StatisticalProcess]string{
+	StatisticalAverage:             "average",
+	StatisticalAccumulation:        "accumulation",
+	StatisticalMaximum:             "maximum",
+	StatisticalMinimum:             "minimum",
+	StatisticalDifference:          "difference (end minus beginning)",
+	StatisticalRootMeanSquare:      "root mean square",
+	StatisticalStandardDeviation:   "standard deviation",
+	StatisticalCovariance:          "covariance",
+	StatisticalReverseDifference:   "difference (beginning minus end)",
+	StatisticalRatio:               "ratio",
+	StatisticalStandardizedAnomaly: "standardized anomaly",
+	StatisticalSummation:           "summation",
+	StatisticalReturnPeriod:        "return period",
+	StatisticalMedian:              "median",
+	StatisticalMissing:             "missing",
+}
+
+// String returns the Code Table 4.10 meaning of the process, e.g. "accumulation"
+func (p StatisticalProcess) String() string {
+	if name, ok := statisticalProcesses[p]; ok {
+		return name
+	}
+	return fmt.Sprintf("statistical process %d", uint8(p))
+}
+
+// IsExtreme reports whether the process keeps the maximum or minimum over the interval,
+// values which cannot be averaged or summed into those of a longer interval
+func (p StatisticalProcess) IsExtreme() bool {
+	return p == StatisticalMaximum || p == StatisticalMinimum
+}
@@ -1,5 +1,10 @@
 package template
 
+import (
+	"github.com/scorix/grib/grib2/geo"
+	"github.com/scorix/grib/grib2/units"
+)
+
 // ProductTemplate contains product definition template specific fields
 type ProductTemplate struct {
 	TemplateNumber              uint16 // Product definition template number (2 bytes)
@@ -22,33 +27,53 @@ type ProductTemplate struct {
 	ScaledValueOfSecondFixedSurface uint32 // Scaled value of second fixed surface (4 bytes)
 
 	// Template-specific fields (populated based on template number)
-	TimeRange   *TimeRangeInfo   // For templates with time ranges (8, 9, 10, 11, 12, 13, 14)
-	Ensemble    *EnsembleInfo    // For ensemble templates (1, 2, 3, 4, 11, 12, 13, 14)
-	Probability *ProbabilityInfo // For probability templates (5, 9)
-	Percentile  *PercentileInfo  // For percentile templates (6, 10)
-	Derived     *DerivedInfo     // For derived templates (7, 12, 13, 14)
+	TimeRange   *TimeRangeInfo         // For templates with time ranges (8, 9, 10, 11, 12, 13, 14)
+	Ensemble    *EnsembleInfo          // For individual ensemble forecast templates (1, 11)
+	Probability *ProbabilityInfo       // For probability templates (5, 9)
+	Percentile  *PercentileInfo        // For percentile templates (6, 10)
+	Derived     *DerivedInfo           // For derived forecast templates (2, 3, 4, 12, 13, 14)
+	Spatial     *SpatialProcessingInfo // For spatially processed templates (15)
+	Radar       *RadarInfo             // For radar products (20)
 }
 
 // TimeRangeInfo contains time range specific information
 type TimeRangeInfo struct {
+	// End of overall time interval
+	EndYear   uint16 // Year of end of overall time interval (2 bytes)
+	EndMonth  uint8  // Month of end of overall time interval (1 byte)
+	EndDay    uint8  // Day of end of overall time interval (1 byte)
+	EndHour   uint8  // Hour of end of overall time interval (1 byte)
+	EndMinute uint8  // Minute of end of overall time interval (1 byte)
+	EndSecond uint8  // Second of end of overall time interval (1 byte)
+
+	NumberOfTimeRanges    uint16 // Number of time range specifications (1 byte)
+	NumberOfMissingValues uint32 // Total number of data values missing in statistical process (4 bytes)
+
+	// Outermost time range specification (copy of TimeRanges[0])
+	TypeOfStatisticalProcessing     uint8  // Type of statistical processing (1 byte)
 	TypeOfTimeIncrement             uint8  // Type of time increment (1 byte)
 	IndicatorOfUnitForTimeRange     uint8  // Indicator of unit for time range (1 byte)
 	LengthOfTimeRange               uint32 // Length of time range (4 bytes)
 	IndicatorOfUnitForTimeIncrement uint8  // Indicator of unit for time increment (1 byte)
 	TimeIncrement                   uint32 // Time increment (4 bytes)
-	TypeOfStatisticalProcessing     uint8  // Type of statistical processing (1 byte)
-	NumberOfTimeRanges              uint16 // Number of time ranges (2 bytes)
 
 	// For multiple time ranges
 	TimeRanges []TimeRangeSpec // List of time range specifications
 }
 
+// StatisticalProcess returns the type of statistical processing of the outermost time range
+func (t *TimeRangeInfo) StatisticalProcess() StatisticalProcess {
+	return StatisticalProcess(t.TypeOfStatisticalProcessing)
+}
+
 // TimeRangeSpec represents a single time range specification
 type TimeRangeSpec struct {
-	StatisticalProcessType uint8  // Type of statistical processing (1 byte)
-	TimeIncrementType      uint8  // Type of time increment (1 byte)
-	TimeRangeLength        uint32 // Length of time range (4 bytes)
-	TimeIncrement          uint32 // Time increment (4 bytes)
+	StatisticalProcessType          uint8  // Type of statistical processing (1 byte)
+	TimeIncrementType               uint8  // Type of time increment (1 byte)
+	IndicatorOfUnitForTimeRange     uint8  // Indicator of unit for time range (1 byte)
+	TimeRangeLength                 uint32 // Length of time range (4 bytes)
+	IndicatorOfUnitForTimeIncrement uint8  // Indicator of unit for time increment (1 byte)
+	TimeIncrement                   uint32 // Time increment (4 bytes)
 }
 
 // EnsembleInfo contains ensemble forecast specific information
@@ -67,8 +92,8 @@ type ProbabilityInfo struct {
 	ScaledValueOfLowerLimit            uint32  // Scaled value of lower limit (4 bytes)
 	ScaleFactorOfUpperLimit            int8    // Scale factor of upper limit (1 byte, signed)
 	ScaledValueOfUpperLimit            uint32  // Scaled value of upper limit (4 bytes)
-	LowerLimitValue                    float64 // Calculated lower limit value
-	UpperLimitValue                    float64 // Calculated upper limit value
+	LowerLimitValue                    float64 // Lower limit with the scale factor applied, NaN when missing
+	UpperLimitValue                    float64 // Upper limit with the scale factor applied, NaN when missing
 }
 
 // PercentileInfo contains percentile forecast specific information
@@ -76,18 +101,181 @@ type PercentileInfo struct {
 	PercentileValue uint8 // Percentile value (0-100, 1 byte)
 }
 
-// DerivedInfo contains derived forecast specific information
+// DerivedInfo contains derived forecast specific information. Derived forecasts based on
+// all ensemble members (templates 4.2 and 4.12) only have the type of derived forecast
+// and the number of forecasts, those based on a cluster (4.3, 4.4, 4.13 and 4.14) have
+// the cluster and its domain as well. Fields that the template does not carry are coded as
+// missing: the cluster fields of templates 4.2 and 4.12, the circular domain of
+// rectangular clusters and conversely, and the central wave number of all of them.
 type DerivedInfo struct {
-	DerivedForecastType            uint8  // Type of derived forecast (1 byte)
+	DerivedForecastType            uint8  // Type of derived forecast (Code Table 4.7, 1 byte)
 	NumberOfForecastsInEnsemble    uint8  // Number of forecasts used to create derived forecast (1 byte)
 	ClusterIdentifier              uint8  // Cluster identifier (1 byte)
+	ClusterOfHighResolutionControl uint8  // Number of the cluster holding the high resolution control (1 byte)
+	ClusterOfLowResolutionControl  uint8  // Number of the cluster holding the low resolution control (1 byte)
 	NumberOfClustersOfEnsemble     uint8  // Number of clusters (1 byte)
-	ClusteringMethod               uint8  // Clustering method (1 byte)
+	ClusteringMethod               uint8  // Clustering method (Code Table 4.8, 1 byte)
 	NorthernLatitudeOfCluster      int32  // Northern latitude of cluster domain (4 bytes, signed, microdegrees)
 	SouthernLatitudeOfCluster      int32  // Southern latitude of cluster domain (4 bytes, signed, microdegrees)
 	EasternLongitudeOfCluster      uint32 // Eastern longitude of cluster domain (4 bytes, microdegrees)
 	WesternLongitudeOfCluster      uint32 // Western longitude of cluster domain (4 bytes, microdegrees)
+	LatitudeOfClusterCentre        int32  // Latitude of central point of circular cluster domain (4 bytes, signed, microdegrees)
+	LongitudeOfClusterCentre       uint32 // Longitude of central point of circular cluster domain (4 bytes, microdegrees)
+	ScaleFactorOfClusterRadius     int8   // Scale factor of radius of circular cluster domain (1 byte, signed)
+	ScaledValueOfClusterRadius     uint32 // Scaled value of radius of circular cluster domain (4 bytes)
 	NumberOfForecastsInCluster     uint8  // Number of forecasts in cluster (1 byte)
+	ScaleFactorOfStandardDeviation int8   // Scale factor of standard deviation in the cluster (1 byte, signed)
+	ScaledValueOfStandardDeviation uint32 // Scaled value of standard deviation in the cluster (4 bytes)
+	ScaleFactorOfDistanceFromMean  int8   // Scale factor of distance of the cluster from ensemble mean (1 byte, signed)
+	ScaledValueOfDistanceFromMean  uint32 // Scaled value of distance of the cluster from ensemble mean (4 bytes)
 	ScaleFactorOfCentralWaveNumber int8   // Scale factor of central wave number (1 byte, signed)
 	ScaledValueOfCentralWaveNumber uint32 // Scaled value of central wave number (4 bytes)
 }
+
+// SpatialProcessingInfo contains the spatial processing of a product statistically processed over
+// a spatial area
+type SpatialProcessingInfo struct {
+	StatisticalProcess uint8 // Statistical process used within the spatial area (Code Table 4.10, 1 byte)
+	SpatialProcessType uint8 // Type of spatial processing (Code Table 4.15, 1 byte)
+	NumberOfPoints     uint8 // Number of data points used in the spatial processing (1 byte)
+}
+
+// RadarInfo contains the radar site and operating parameters of a radar product (template 4.20).
+// Radar products have no forecast time, generating process identifiers nor fixed surfaces.
+type RadarInfo struct {
+	NumberOfRadarSites      uint8  // Number of radar sites used (1 byte)
+	SiteLatitude            int32  // Site latitude (4 bytes, signed, microdegrees)
+	SiteLongitude           int32  // Site longitude (4 bytes, signed, microdegrees)
+	SiteElevation           uint16 // Site elevation in metres (2 bytes)
+	SiteID                  string // Alphanumeric site identifier (4 bytes)
+	SiteNumber              uint16 // Numeric site identifier (2 bytes)
+	OperatingMode           uint8  // Operating mode (Code Table 4.12, 1 byte)
+	ReflectivityCalibration uint8  // Reflectivity calibration constant in tenths of dB (1 byte)
+	QualityControl          uint8  // Quality control indicator (Code Table 4.13, 1 byte)
+	ClutterFilter           uint8  // Clutter filter indicator (Code Table 4.14, 1 byte)
+	ElevationAngle          uint8  // Constant antenna elevation angle in tenths of a degree (1 byte)
+	AccumulationInterval    uint16 // Accumulation interval in minutes (2 bytes)
+	EchoTopReflectivity     uint8  // Reference reflectivity for echo top in dB (1 byte)
+	RangeResolution         uint32 // Range resolution in metres (3 bytes)
+	RadialAngularSpacing    uint16 // Radial angular spacing in tenths of a degree (2 bytes)
+}
+
+// SiteLatLon returns the latitude and longitude of the radar site in degrees
+func (r *RadarInfo) SiteLatLon() (lat, lon float64) {
+	return units.Microdegrees(r.SiteLatitude), units.Microdegrees(r.SiteLongitude)
+}
+
+// FixedSurface describes one of the fixed surfaces of a product (Code Table 4.5)
+type FixedSurface struct {
+	Type     uint8   // Type of fixed surface
+	Value    float64 // Value with the scale factor applied, valid when HasValue is true
+	HasValue bool    // False when the surface has no value, e.g. the ground or water surface
+}
+
+// FirstSurface returns the first fixed surface; ok is false when its type is missing
+func (p *ProductTemplate) FirstSurface() (surface FixedSurface, ok bool) {
+	return fixedSurface(p.TypeOfFirstFixedSurface, p.ScaleFactorOfFirstFixedSurface, p.ScaledValueOfFirstFixedSurface)
+}
+
+// SecondSurface returns the second fixed surface; ok is false when its type is missing
+func (p *ProductTemplate) SecondSurface() (surface FixedSurface, ok bool) {
+	return fixedSurface(p.TypeOfSecondFixedSurface, p.ScaleFactorOfSecondFixedSurface, p.ScaledValueOfSecondFixedSurface)
+}
+
+// FirstFixedSurfaceValue returns the value of the first fixed surface with its scale factor applied.
+// ok is false when the surface type, scale factor or scaled value is missing.
+func (p *ProductTemplate) FirstFixedSurfaceValue() (value float64, ok bool) {
+	surface, ok := p.FirstSurface()
+	return surface.Value, ok && surface.HasValue
+}
+
+// SecondFixedSurfaceValue returns the value of the second fixed surface with its scale factor applied.
+// ok is false when the surface type, scale factor or scaled value is missing.
+func (p *ProductTemplate) SecondFixedSurfaceValue() (value float64, ok bool) {
+	surface, ok := p.SecondSurface()
+	return surface.Value, ok && surface.HasValue
+}
+
+// BackgroundProcessID returns the background generating process identifier; ok is false when missing
+func (p *ProductTemplate) BackgroundProcessID() (id uint8, ok bool) {
+	return p.BackgroundProcess, !IsMissing(p.BackgroundProcess)
+}
+
+// GeneratingProcessID returns the analysis or forecast generating process identifier; ok is false when missing
+func (p *ProductTemplate) GeneratingProcessID() (id uint8, ok bool) {
+	return p.GeneratingProcessIdentifier, !IsMissing(p.GeneratingProcessIdentifier)
+}
+
+// HoursAfterCutoff returns the hours after reference time of data cutoff; ok is false when missing
+func (p *ProductTemplate) HoursAfterCutoff() (hours uint16, ok bool) {
+	return p.HoursAfterDataCutoff, !IsMissing(p.HoursAfterDataCutoff)
+}
+
+// MinutesAfterCutoff returns the minutes after reference time of data cutoff; ok is false when missing
+func (p *ProductTemplate) MinutesAfterCutoff() (minutes uint8, ok bool) {
+	return p.MinutesAfterDataCutoff, !IsMissing(p.MinutesAfterDataCutoff)
+}
+
+// Forecast returns the forecast time in units of IndicatorOfUnitOfTimeRange; ok is false when missing
+func (p *ProductTemplate) Forecast() (forecastTime uint32, ok bool) {
+	return p.ForecastTime, !IsMissing(p.ForecastTime) && !IsMissing(p.IndicatorOfUnitOfTimeRange)
+}
+
+// Increment returns the time increment between successive fields; ok is false when missing
+func (s *TimeRangeSpec) Increment() (increment uint32, ok bool) {
+	return s.TimeIncrement, !IsMissing(s.TimeIncrement) && !IsMissing(s.IndicatorOfUnitForTimeIncrement)
+}
+
+// LowerLimit returns the lower limit of a probability forecast; ok is false when missing
+func (p *ProbabilityInfo) LowerLimit() (limit float64, ok bool) {
+	return scaledValue(p.ScaleFactorOfLowerLimit, p.ScaledValueOfLowerLimit)
+}
+
+// UpperLimit returns the upper limit of a probability forecast; ok is false when missing
+func (p *ProbabilityInfo) UpperLimit() (limit float64, ok bool) {
+	return scaledValue(p.ScaleFactorOfUpperLimit, p.ScaledValueOfUpperLimit)
+}
+
+// CentralWaveNumber returns the central wave number of a derived forecast; ok is false when missing
+func (d *DerivedInfo) CentralWaveNumber() (waveNumber float64, ok bool) {
+	return scaledValue(d.ScaleFactorOfCentralWaveNumber, d.ScaledValueOfCentralWaveNumber)
+}
+
+// StandardDeviation returns the standard deviation in the cluster of a derived forecast; ok
+// is false when missing
+func (d *DerivedInfo) StandardDeviation() (deviation float64, ok bool) {
+	return scaledValue(d.ScaleFactorOfStandardDeviation, d.ScaledValueOfStandardDeviation)
+}
+
+// DistanceFromMean returns the distance of the cluster of a derived forecast from the
+// ensemble mean; ok is false when missing
+func (d *DerivedInfo) DistanceFromMean() (distance float64, ok bool) {
+	return scaledValue(d.ScaleFactorOfDistanceFromMean, d.ScaledValueOfDistanceFromMean)
+}
+
+// ClusterRadius returns the radius of the circular cluster domain of a derived forecast;
+// ok is false when missing, as for rectangular clusters
+func (d *DerivedInfo) ClusterRadius() (radius float64, ok bool) {
+	return scaledValue(d.ScaleFactorOfClusterRadius, d.ScaledValueOfClusterRadius)
+}
+
+// ClusterDomain returns the area of the cluster of a derived forecast; ok is false when any
+// of its edges is missing
+func (d *DerivedInfo) ClusterDomain() (domain geo.BBox, ok bool) {
+	if isMissingSigned32(d.NorthernLatitudeOfCluster) || isMissingSigned32(d.SouthernLatitudeOfCluster) ||
+		IsMissing(d.EasternLongitudeOfCluster) || IsMissing(d.WesternLongitudeOfCluster) {
+		return geo.BBox{}, false
+	}
+	return geo.BBoxFromMicro(d.NorthernLatitudeOfCluster, d.SouthernLatitudeOfCluster, d.EasternLongitudeOfCluster, d.WesternLongitudeOfCluster), true
+}
+
+// fixedSurface builds a FixedSurface from its coded type, scale factor and scaled value
+func fixedSurface(surfaceType uint8, scaleFactor int8, value uint32) (FixedSurface, bool) {
+	if IsMissing(surfaceType) {
+		return FixedSurface{}, false
+	}
+
+	surface := FixedSurface{Type: surfaceType}
+	surface.Value, surface.HasValue = scaledValue(scaleFactor, value)
+	return surface, true
+}
@@ -0,0 +1,147 @@
+package template_test
+
+import (
+	"testing"
+
+	"github.com/scorix/grib/grib2/geo"
+	"github.com/scorix/grib/grib2/template"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsMissing(t *testing.T) {
+	assert.True(t, template.IsMissing(uint8(0xff)))
+	assert.True(t, template.IsMissing(uint16(0xffff)))
+	assert.True(t, template.IsMissing(uint32(0xffffffff)))
+	assert.False(t, template.IsMissing(uint8(0)))
+	assert.False(t, template.IsMissing(uint32(0xfffffffe)))
+}
+
+func TestProductTemplateMissingSecondSurface(t *testing.T) {
+	// Fields of the template 4.0 fixture in section/sec4_test.go: 12 hour forecast at the surface
+	product := template.ProductTemplate{
+		TypeOfGeneratingProcess:         2,
+		IndicatorOfUnitOfTimeRange:      template.TimeUnitHour,
+		ForecastTime:                    12,
+		TypeOfFirstFixedSurface:         1,
+		TypeOfSecondFixedSurface:        0xff,
+		ScaleFactorOfSecondFixedSurface: -127, // 0xff
+		ScaledValueOfSecondFixedSurface: 0xffffffff,
+	}
+
+	first, ok := product.FirstSurface()
+	assert.True(t, ok)
+	assert.Equal(t, template.FixedSurface{Type: 1, Value: 0, HasValue: true}, first)
+
+	_, ok = product.SecondSurface()
+	assert.False(t, ok)
+	_, ok = product.SecondFixedSurfaceValue()
+	assert.False(t, ok)
+
+	hours, ok := product.HoursAfterCutoff()
+	assert.True(t, ok)
+	assert.Equal(t, uint16(0), hours)
+
+	forecast, ok := product.Forecast()
+	assert.True(t, ok)
+	assert.Equal(t, uint32(12), forecast)
+}
+
+func TestProductTemplateMissingValues(t *testing.T) {
+	product := template.ProductTemplate{
+		BackgroundProcess:              0xff,
+		GeneratingProcessIdentifier:    81,
+		HoursAfterDataCutoff:           0xffff,
+		MinutesAfterDataCutoff:         0xff,
+		IndicatorOfUnitOfTimeRange:     template.TimeUnitHour,
+		ForecastTime:                   0xffffffff,
+		TypeOfFirstFixedSurface:        103,
+		ScaleFactorOfFirstFixedSurface: -127, // 0xff
+		ScaledValueOfFirstFixedSurface: 2,
+	}
+
+	_, ok := product.BackgroundProcessID()
+	assert.False(t, ok)
+	id, ok := product.GeneratingProcessID()
+	assert.True(t, ok)
+	assert.Equal(t, uint8(81), id)
+	_, ok = product.HoursAfterCutoff()
+	assert.False(t, ok)
+	_, ok = product.MinutesAfterCutoff()
+	assert.False(t, ok)
+	_, ok = product.Forecast()
+	assert.False(t, ok)
+
+	// A surface with a type but a missing scale factor has no value
+	first, ok := product.FirstSurface()
+	assert.True(t, ok)
+	assert.False(t, first.HasValue)
+}
+
+func TestScaledLimits(t *testing.T) {
+	probability := template.ProbabilityInfo{
+		ScaleFactorOfLowerLimit: 1,
+		ScaledValueOfLowerLimit: 2731,
+		ScaleFactorOfUpperLimit: -127, // 0xff
+		ScaledValueOfUpperLimit: 0xffffffff,
+	}
+
+	lower, ok := probability.LowerLimit()
+	assert.True(t, ok)
+	assert.InDelta(t, 273.1, lower, 1e-9)
+
+	_, ok = probability.UpperLimit()
+	assert.False(t, ok)
+
+	spec := template.TimeRangeSpec{IndicatorOfUnitForTimeIncrement: 0xff}
+	_, ok = spec.Increment()
+	assert.False(t, ok)
+}
+
+func TestDerivedInfoClusterDomain(t *testing.T) {
+	// A cluster over the North Pacific, across the antimeridian
+	derived := template.DerivedInfo{
+		NorthernLatitudeOfCluster: 60000000,
+		SouthernLatitudeOfCluster: 20000000,
+		EasternLongitudeOfCluster: 230000000,
+		WesternLongitudeOfCluster: 150000000,
+	}
+	domain, ok := derived.ClusterDomain()
+	assert.True(t, ok)
+	assert.Equal(t, geo.BBox{South: 20, North: 60, West: 150, East: -130}, domain)
+	assert.True(t, domain.Contains(40, 180))
+	assert.True(t, domain.Contains(40, -140))
+	assert.False(t, domain.Contains(40, 0))
+
+	derived.SouthernLatitudeOfCluster = -0x7fffffff
+	_, ok = derived.ClusterDomain()
+	assert.False(t, ok)
+}
+
+func TestStatisticalProcess(t *testing.T) {
+	assert.Equal(t, "accumulation", template.StatisticalAccumulation.String())
+	assert.Equal(t, "average", template.StatisticalAverage.String())
+	assert.Equal(t, "maximum", template.StatisticalMaximum.String())
+	assert.Equal(t, "statistical process 200", template.StatisticalProcess(200).String())
+
+	assert.True(t, template.StatisticalMaximum.IsExtreme())
+	assert.True(t, template.StatisticalMinimum.IsExtreme())
+	assert.False(t, template.StatisticalAverage.IsExtreme())
+	assert.False(t, template.StatisticalAccumulation.IsExtreme())
+
+	timeRange := template.TimeRangeInfo{TypeOfStatisticalProcessing: 2}
+	assert.Equal(t, template.StatisticalMaximum, timeRange.StatisticalProcess())
+}
+
+func TestFixedSurface_Name(t *testing.T) {
+	surface := template.FixedSurface{Type: 103, Value: 2, HasValue: true}
+	assert.Equal(t, "Specified height level above ground", surface.Name())
+	assert.Equal(t, "m", surface.Unit())
+
+	surface = template.FixedSurface{Type: 1}
+	assert.Equal(t, "Ground or water surface", surface.Name())
+	assert.Equal(t, "", surface.Unit())
+
+	surface = template.FixedSurface{Type: 200}
+	assert.Equal(t, "fixed surface type 200", surface.Name())
+	assert.Equal(t, "", surface.Unit())
+}
@@ -0,0 +1,87 @@
+package template
+
+import "fmt"
+
+// surfaceType is an entry of Code Table 4.5
+type surfaceType struct {
+	name string // Meaning of the surface type
+	unit string // Unit of the surface value, or "" when the surface has no value
+}
+
+// surfaceTypes holds the entries of Code Table 4.5 defined by WMO
+var surfaceTypes = map[uint8]surfaceType{
+	1:   {name: "Ground or water surface"},
+	2:   {name: "Cloud base level"},
+	3:   {name: "Level of cloud tops"},
+	4:   {name: "Level of 0 °C isotherm"},
+	5:   {name: "Level of adiabatic condensation lifted from the surface"},
+	6:   {name: "Maximum wind level"},
+	7:   {name: "Tropopause"},
+	8:   {name: "Nominal top of the atmosphere"},
+	9:   {name: "Sea bottom"},
+	10:  {name: "Entire atmosphere"},
+	11:  {name: "Cumulonimbus (CB) base", unit: "m"},
+	12:  {name: "Cumulonimbus (CB) top", unit: "m"},
+	13:  {name: "Lowest level where vertically integrated cloud cover exceeds the specified percentage", unit: "%"},
+	14:  {name: "Level of free convection (LFC)"},
+	15:  {name: "Convective condensation level (CCL)"},
+	16:  {name: "Level of neutral buoyancy or equilibrium level (LNB)"},
+	17:  {name: "Departure level of the most unstable parcel of air (MUDL)"},
+	18:  {name: "Departure level of a mixed layer parcel of air with specified layer depth", unit: "Pa"},
+	20:  {name: "Isothermal level", unit: "K"},
+	21:  {name: "Lowest level where mass density exceeds the specified value", unit: "kg m-3"},
+	22:  {name: "Highest level where mass density exceeds the specified value", unit: "kg m-3"},
+	23:  {name: "Lowest level where air concentration exceeds the specified value", unit: "Bq m-3"},
+	24:  {name: "Highest level where air concentration exceeds the specified value", unit: "Bq m-3"},
+	25:  {name: "Highest level where radar reflectivity exceeds the specified value", unit: "dBZ"},
+	100: {name: "Isobaric surface", unit: "Pa"},
+	101: {name: "Mean sea level"},
+	102: {name: "Specific altitude above mean sea level", unit: "m"},
+	103: {name: "Specified height level above ground", unit: "m"},
+	104: {name: "Sigma level", unit: "sigma value"},
+	105: {name: "Hybrid level"},
+	106: {name: "Depth below land surface", unit: "m"},
+	107: {name: "Isentropic (theta) level", unit: "K"},
+	108: {name: "Level at specified pressure difference from ground to level", unit: "Pa"},
+	109: {name: "Potential vorticity surface", unit: "K m2 kg-1 s-1"},
+	111: {name: "Eta level"},
+	113: {name: "Logarithmic hybrid level"},
+	114: {name: "Snow level", unit: "Numeric"},
+	115: {name: "Sigma height level"},
+	117: {name: "Mixed layer depth", unit: "m"},
+	118: {name: "Hybrid height level"},
+	119: {name: "Hybrid pressure level"},
+	150: {name: "Generalized vertical height coordinate"},
+	151: {name: "Soil level", unit: "Numeric"},
+	152: {name: "Sea-ice level", unit: "Numeric"},
+	160: {name: "Depth below sea level", unit: "m"},
+	161: {name: "Depth below water surface", unit: "m"},
+	162: {name: "Lake or river bottom"},
+	163: {name: "Bottom of sediment layer"},
+	164: {name: "Bottom of thermally active sediment layer"},
+	165: {name: "Bottom of sediment layer penetrated by thermal wave"},
+	166: {name: "Mixing layer"},
+	167: {name: "Bottom of root zone"},
+	168: {name: "Ocean model level", unit: "Numeric"},
+	169: {name: "Ocean level defined by water density (sigma-theta) difference from near-surface to level", unit: "kg m-3"},
+	170: {name: "Ocean level defined by water potential temperature difference from near-surface to level", unit: "K"},
+	174: {name: "Top surface of ice on sea, lake or river"},
+	175: {name: "Top surface of ice, under snow, on sea, lake or river"},
+	176: {name: "Bottom surface (underside) ice on sea, lake or river"},
+	177: {name: "Deep soil (of indefinite depth)"},
+}
+
+// Name returns the Code Table 4.5 meaning of the surface type, e.g. "Isobaric surface",
+// or "fixed surface type 200" for local and reserved types
+func (s FixedSurface) Name() string {
+	if entry, ok := surfaceTypes[s.Type]; ok {
+		return entry.name
+	}
+	return fmt.Sprintf("fixed surface type %d", s.Type)
+}
+
+// Unit returns the Code Table 4.5 unit of the surface value, e.g. "Pa", or "" when the
+// surface type has no unit or is not in the table
+func (s FixedSurface) Unit() string {
+	return surfaceTypes[s.Type].unit
+}
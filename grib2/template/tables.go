@@ -0,0 +1,742 @@
+//go:build !nointernaltables
+
+package template
+
+// The built-in tables are left out of builds with the nointernaltables tag, which keeps
+// only the numbers of parameters and generating processes unless tables are registered
+// with RegisterParameters, RegisterLocalParameters and RegisterGeneratingProcesses.
+
+// builtinParameters holds the entries of WMO Code Table 4.2 for disciplines 0, 1, 2 and 10
+// used by operational models. Parameters whose category or number is 192 or above are
+// reserved for local use and are not part of it.
+var builtinParameters = map[parameterKey]ParameterInfo{
+	// Discipline 0, category 0: temperature
+	{0, 0, 0}:  {Abbreviation: "TMP", Name: "Temperature", Unit: "K", StandardName: "air_temperature"},
+	{0, 0, 1}:  {Abbreviation: "VTMP", Name: "Virtual temperature", Unit: "K", StandardName: "virtual_temperature"},
+	{0, 0, 2}:  {Abbreviation: "POT", Name: "Potential temperature", Unit: "K", StandardName: "air_potential_temperature"},
+	{0, 0, 3}:  {Abbreviation: "EPOT", Name: "Pseudo-adiabatic potential temperature or equivalent potential temperature", Unit: "K", StandardName: "equivalent_potential_temperature"},
+	{0, 0, 4}:  {Abbreviation: "TMAX", Name: "Maximum temperature", Unit: "K", StandardName: "air_temperature"},
+	{0, 0, 5}:  {Abbreviation: "TMIN", Name: "Minimum temperature", Unit: "K", StandardName: "air_temperature"},
+	{0, 0, 6}:  {Abbreviation: "DPT", Name: "Dew point temperature", Unit: "K", StandardName: "dew_point_temperature"},
+	{0, 0, 7}:  {Abbreviation: "DEPR", Name: "Dew point depression (or deficit)", Unit: "K", StandardName: "dew_point_depression"},
+	{0, 0, 8}:  {Abbreviation: "LAPR", Name: "Lapse rate", Unit: "K m-1"},
+	{0, 0, 9}:  {Abbreviation: "TMPA", Name: "Temperature anomaly", Unit: "K"},
+	{0, 0, 10}: {Abbreviation: "LHTFL", Name: "Latent heat net flux", Unit: "W m-2"},
+	{0, 0, 11}: {Abbreviation: "SHTFL", Name: "Sensible heat net flux", Unit: "W m-2"},
+	{0, 0, 12}: {Abbreviation: "HEATX", Name: "Heat index", Unit: "K"},
+	{0, 0, 13}: {Abbreviation: "WCF", Name: "Wind chill factor", Unit: "K"},
+	{0, 0, 14}: {Abbreviation: "MINDPD", Name: "Minimum dew point depression", Unit: "K"},
+	{0, 0, 15}: {Abbreviation: "VPTMP", Name: "Virtual potential temperature", Unit: "K"},
+	{0, 0, 16}: {Abbreviation: "SNOHF", Name: "Snow phase change heat flux", Unit: "W m-2"},
+	{0, 0, 17}: {Abbreviation: "SKINT", Name: "Skin temperature", Unit: "K", StandardName: "surface_temperature"},
+	{0, 0, 18}: {Abbreviation: "SNOT", Name: "Snow temperature (top of snow)", Unit: "K"},
+	{0, 0, 19}: {Abbreviation: "TTCHT", Name: "Turbulent transfer coefficient for heat", Unit: "Numeric"},
+	{0, 0, 20}: {Abbreviation: "TDCHT", Name: "Turbulent diffusion coefficient for heat", Unit: "m2 s-1"},
+	{0, 0, 21}: {Abbreviation: "APTMP", Name: "Apparent temperature", Unit: "K", StandardName: "apparent_air_temperature"},
+
+	// Discipline 0, category 1: moisture
+	{0, 1, 0}:  {Abbreviation: "SPFH", Name: "Specific humidity", Unit: "kg kg-1", StandardName: "specific_humidity"},
+	{0, 1, 1}:  {Abbreviation: "RH", Name: "Relative humidity", Unit: "%", StandardName: "relative_humidity"},
+	{0, 1, 2}:  {Abbreviation: "MIXR", Name: "Humidity mixing ratio", Unit: "kg kg-1", StandardName: "humidity_mixing_ratio"},
+	{0, 1, 3}:  {Abbreviation: "PWAT", Name: "Precipitable water", Unit: "kg m-2", StandardName: "atmosphere_mass_content_of_water_vapor"},
+	{0, 1, 4}:  {Abbreviation: "VAPP", Name: "Vapour pressure", Unit: "Pa", StandardName: "water_vapor_partial_pressure_in_air"},
+	{0, 1, 5}:  {Abbreviation: "SATD", Name: "Saturation deficit", Unit: "Pa"},
+	{0, 1, 6}:  {Abbreviation: "EVP", Name: "Evaporation", Unit: "kg m-2", StandardName: "water_evaporation_amount"},
+	{0, 1, 7}:  {Abbreviation: "PRATE", Name: "Precipitation rate", Unit: "kg m-2 s-1", StandardName: "precipitation_flux"},
+	{0, 1, 8}:  {Abbreviation: "APCP", Name: "Total precipitation", Unit: "kg m-2", StandardName: "precipitation_amount"},
+	{0, 1, 9}:  {Abbreviation: "NCPCP", Name: "Large-scale precipitation (non-convective)", Unit: "kg m-2", StandardName: "large_scale_precipitation_amount"},
+	{0, 1, 10}: {Abbreviation: "ACPCP", Name: "Convective precipitation", Unit: "kg m-2", StandardName: "convective_precipitation_amount"},
+	{0, 1, 11}: {Abbreviation: "SNOD", Name: "Snow depth", Unit: "m", StandardName: "surface_snow_thickness"},
+	{0, 1, 12}: {Abbreviation: "SRWEQ", Name: "Snowfall rate water equivalent", Unit: "kg m-2 s-1", StandardName: "snowfall_flux"},
+	{0, 1, 13}: {Abbreviation: "WEASD", Name: "Water equivalent of accumulated snow depth", Unit: "kg m-2", StandardName: "surface_snow_amount"},
+	{0, 1, 14}: {Abbreviation: "SNOC", Name: "Convective snow", Unit: "kg m-2", StandardName: "convective_snowfall_amount"},
+	{0, 1, 15}: {Abbreviation: "SNOL", Name: "Large-scale snow", Unit: "kg m-2", StandardName: "large_scale_snowfall_amount"},
+	{0, 1, 16}: {Abbreviation: "SNOM", Name: "Snow melt", Unit: "kg m-2", StandardName: "surface_snow_melt_amount"},
+	{0, 1, 17}: {Abbreviation: "SNOAG", Name: "Snow age", Unit: "day"},
+	{0, 1, 18}: {Abbreviation: "ABSH", Name: "Absolute humidity", Unit: "kg m-3"},
+	{0, 1, 19}: {Abbreviation: "PTYPE", Name: "Precipitation type", Unit: "Code table 4.201"},
+	{0, 1, 20}: {Abbreviation: "ILIQW", Name: "Integrated liquid water", Unit: "kg m-2"},
+	{0, 1, 21}: {Abbreviation: "TCOND", Name: "Condensate", Unit: "kg kg-1"},
+	{0, 1, 22}: {Abbreviation: "CLMR", Name: "Cloud mixing ratio", Unit: "kg kg-1", StandardName: "cloud_liquid_water_mixing_ratio"},
+	{0, 1, 23}: {Abbreviation: "ICMR", Name: "Ice water mixing ratio", Unit: "kg kg-1", StandardName: "cloud_ice_mixing_ratio"},
+	{0, 1, 24}: {Abbreviation: "RWMR", Name: "Rain mixing ratio", Unit: "kg kg-1"},
+	{0, 1, 25}: {Abbreviation: "SNMR", Name: "Snow mixing ratio", Unit: "kg kg-1"},
+	{0, 1, 26}: {Abbreviation: "MCONV", Name: "Horizontal moisture convergence", Unit: "kg kg-1 s-1"},
+	{0, 1, 27}: {Abbreviation: "MAXRH", Name: "Maximum relative humidity", Unit: "%"},
+	{0, 1, 28}: {Abbreviation: "MAXAH", Name: "Maximum absolute humidity", Unit: "kg m-3"},
+	{0, 1, 29}: {Abbreviation: "ASNOW", Name: "Total snowfall", Unit: "m"},
+	{0, 1, 30}: {Abbreviation: "PWCAT", Name: "Precipitable water category", Unit: "Code table 4.202"},
+	{0, 1, 31}: {Abbreviation: "HAIL", Name: "Hail", Unit: "m"},
+	{0, 1, 32}: {Abbreviation: "GRLE", Name: "Graupel (snow pellets)", Unit: "kg kg-1"},
+	{0, 1, 33}: {Abbreviation: "CRAIN", Name: "Categorical rain", Unit: "Code table 4.222"},
+	{0, 1, 34}: {Abbreviation: "CFRZR", Name: "Categorical freezing rain", Unit: "Code table 4.222"},
+	{0, 1, 35}: {Abbreviation: "CICEP", Name: "Categorical ice pellets", Unit: "Code table 4.222"},
+	{0, 1, 36}: {Abbreviation: "CSNOW", Name: "Categorical snow", Unit: "Code table 4.222"},
+	{0, 1, 37}: {Abbreviation: "CPRAT", Name: "Convective precipitation rate", Unit: "kg m-2 s-1", StandardName: "convective_precipitation_flux"},
+	{0, 1, 38}: {Abbreviation: "MDIV", Name: "Horizontal moisture divergence", Unit: "kg kg-1 s-1"},
+	{0, 1, 39}: {Abbreviation: "CPOFP", Name: "Percent frozen precipitation", Unit: "%"},
+	{0, 1, 40}: {Abbreviation: "PEVAP", Name: "Potential evaporation", Unit: "kg m-2"},
+	{0, 1, 41}: {Abbreviation: "PEVPR", Name: "Potential evaporation rate", Unit: "W m-2"},
+	{0, 1, 42}: {Abbreviation: "SNOWC", Name: "Snow cover", Unit: "%"},
+	{0, 1, 43}: {Abbreviation: "FRAIN", Name: "Rain fraction of total cloud water", Unit: "Proportion"},
+	{0, 1, 44}: {Abbreviation: "RIME", Name: "Rime factor", Unit: "Numeric"},
+	{0, 1, 45}: {Abbreviation: "TCOLR", Name: "Total column integrated rain", Unit: "kg m-2"},
+	{0, 1, 46}: {Abbreviation: "TCOLS", Name: "Total column integrated snow", Unit: "kg m-2"},
+	{0, 1, 47}: {Abbreviation: "LSWP", Name: "Large scale water precipitation (non-convective)", Unit: "kg m-2"},
+	{0, 1, 48}: {Abbreviation: "CWP", Name: "Convective water precipitation", Unit: "kg m-2"},
+	{0, 1, 49}: {Abbreviation: "TWATP", Name: "Total water precipitation", Unit: "kg m-2"},
+	{0, 1, 50}: {Abbreviation: "TSNOWP", Name: "Total snow precipitation", Unit: "kg m-2"},
+	{0, 1, 51}: {Abbreviation: "TCWAT", Name: "Total column water (vertically integrated total water)", Unit: "kg m-2"},
+	{0, 1, 52}: {Abbreviation: "TPRATE", Name: "Total precipitation rate", Unit: "kg m-2 s-1", StandardName: "precipitation_flux"},
+	{0, 1, 53}: {Abbreviation: "TSRWE", Name: "Total snowfall rate water equivalent", Unit: "kg m-2 s-1", StandardName: "snowfall_flux"},
+	{0, 1, 54}: {Abbreviation: "LSPRATE", Name: "Large scale precipitation rate", Unit: "kg m-2 s-1", StandardName: "large_scale_precipitation_flux"},
+	{0, 1, 55}: {Abbreviation: "CSRWE", Name: "Convective snowfall rate water equivalent", Unit: "kg m-2 s-1", StandardName: "convective_snowfall_flux"},
+	{0, 1, 56}: {Abbreviation: "LSSRWE", Name: "Large scale snowfall rate water equivalent", Unit: "kg m-2 s-1", StandardName: "large_scale_snowfall_flux"},
+	{0, 1, 57}: {Abbreviation: "TSRATE", Name: "Total snowfall rate", Unit: "m s-1"},
+	{0, 1, 58}: {Abbreviation: "CSRATE", Name: "Convective snowfall rate", Unit: "m s-1"},
+	{0, 1, 59}: {Abbreviation: "LSSRATE", Name: "Large scale snowfall rate", Unit: "m s-1"},
+	{0, 1, 60}: {Abbreviation: "SDWE", Name: "Snow depth water equivalent", Unit: "kg m-2", StandardName: "surface_snow_amount"},
+	{0, 1, 61}: {Abbreviation: "SDEN", Name: "Snow density", Unit: "kg m-3", StandardName: "snow_density"},
+	{0, 1, 62}: {Abbreviation: "SEVAP", Name: "Snow evaporation", Unit: "kg m-2"},
+	{0, 1, 64}: {Abbreviation: "TCIWV", Name: "Total column integrated water vapour", Unit: "kg m-2", StandardName: "atmosphere_mass_content_of_water_vapor"},
+	{0, 1, 65}: {Abbreviation: "RPRATE", Name: "Rain precipitation rate", Unit: "kg m-2 s-1", StandardName: "rainfall_flux"},
+	{0, 1, 66}: {Abbreviation: "SPRATE", Name: "Snow precipitation rate", Unit: "kg m-2 s-1", StandardName: "snowfall_flux"},
+	{0, 1, 67}: {Abbreviation: "FPRATE", Name: "Freezing rain precipitation rate", Unit: "kg m-2 s-1"},
+	{0, 1, 68}: {Abbreviation: "IPRATE", Name: "Ice pellets precipitation rate", Unit: "kg m-2 s-1"},
+	{0, 1, 69}: {Abbreviation: "TCOLW", Name: "Total column integrated cloud water", Unit: "kg m-2", StandardName: "atmosphere_mass_content_of_cloud_liquid_water"},
+	{0, 1, 70}: {Abbreviation: "TCOLI", Name: "Total column integrated cloud ice", Unit: "kg m-2", StandardName: "atmosphere_mass_content_of_cloud_ice"},
+
+	// Discipline 0, category 2: momentum
+	{0, 2, 0}:  {Abbreviation: "WDIR", Name: "Wind direction (from which blowing)", Unit: "°", StandardName: "wind_from_direction"},
+	{0, 2, 1}:  {Abbreviation: "WIND", Name: "Wind speed", Unit: "m s-1", StandardName: "wind_speed"},
+	{0, 2, 2}:  {Abbreviation: "UGRD", Name: "U-component of wind", Unit: "m s-1", StandardName: "eastward_wind"},
+	{0, 2, 3}:  {Abbreviation: "VGRD", Name: "V-component of wind", Unit: "m s-1", StandardName: "northward_wind"},
+	{0, 2, 4}:  {Abbreviation: "STRM", Name: "Stream function", Unit: "m2 s-1", StandardName: "atmosphere_horizontal_streamfunction"},
+	{0, 2, 5}:  {Abbreviation: "VPOT", Name: "Velocity potential", Unit: "m2 s-1", StandardName: "atmosphere_horizontal_velocity_potential"},
+	{0, 2, 6}:  {Abbreviation: "MNTSF", Name: "Montgomery stream function", Unit: "m2 s-2"},
+	{0, 2, 7}:  {Abbreviation: "SGCVV", Name: "Sigma coordinate vertical velocity", Unit: "s-1"},
+	{0, 2, 8}:  {Abbreviation: "VVEL", Name: "Vertical velocity (pressure)", Unit: "Pa s-1", StandardName: "lagrangian_tendency_of_air_pressure"},
+	{0, 2, 9}:  {Abbreviation: "DZDT", Name: "Vertical velocity (geometric)", Unit: "m s-1", StandardName: "upward_air_velocity"},
+	{0, 2, 10}: {Abbreviation: "ABSV", Name: "Absolute vorticity", Unit: "s-1", StandardName: "atmosphere_absolute_vorticity"},
+	{0, 2, 11}: {Abbreviation: "ABSD", Name: "Absolute divergence", Unit: "s-1"},
+	{0, 2, 12}: {Abbreviation: "RELV", Name: "Relative vorticity", Unit: "s-1", StandardName: "atmosphere_relative_vorticity"},
+	{0, 2, 13}: {Abbreviation: "RELD", Name: "Relative divergence", Unit: "s-1", StandardName: "divergence_of_wind"},
+	{0, 2, 14}: {Abbreviation: "PVORT", Name: "Potential vorticity", Unit: "K m2 kg-1 s-1", StandardName: "ertel_potential_vorticity"},
+	{0, 2, 15}: {Abbreviation: "VUCSH", Name: "Vertical u-component shear", Unit: "s-1"},
+	{0, 2, 16}: {Abbreviation: "VVCSH", Name: "Vertical v-component shear", Unit: "s-1"},
+	{0, 2, 17}: {Abbreviation: "UFLX", Name: "Momentum flux, u-component", Unit: "N m-2"},
+	{0, 2, 18}: {Abbreviation: "VFLX", Name: "Momentum flux, v-component", Unit: "N m-2"},
+	{0, 2, 19}: {Abbreviation: "WMIXE", Name: "Wind mixing energy", Unit: "J"},
+	{0, 2, 20}: {Abbreviation: "BLYDP", Name: "Boundary layer dissipation", Unit: "W m-2"},
+	{0, 2, 21}: {Abbreviation: "MAXGUST", Name: "Maximum wind speed", Unit: "m s-1"},
+	{0, 2, 22}: {Abbreviation: "GUST", Name: "Wind speed (gust)", Unit: "m s-1", StandardName: "wind_speed_of_gust"},
+	{0, 2, 23}: {Abbreviation: "UGUST", Name: "U-component of wind (gust)", Unit: "m s-1"},
+	{0, 2, 24}: {Abbreviation: "VGUST", Name: "V-component of wind (gust)", Unit: "m s-1"},
+	{0, 2, 25}: {Abbreviation: "VWSH", Name: "Vertical speed shear", Unit: "s-1"},
+	{0, 2, 26}: {Abbreviation: "MFLX", Name: "Horizontal momentum flux", Unit: "N m-2"},
+	{0, 2, 27}: {Abbreviation: "USTM", Name: "U-component storm motion", Unit: "m s-1"},
+	{0, 2, 28}: {Abbreviation: "VSTM", Name: "V-component storm motion", Unit: "m s-1"},
+	{0, 2, 29}: {Abbreviation: "CD", Name: "Drag coefficient", Unit: "Numeric"},
+	{0, 2, 30}: {Abbreviation: "FRICV", Name: "Frictional velocity", Unit: "m s-1"},
+
+	// Discipline 0, category 3: mass
+	{0, 3, 0}:  {Abbreviation: "PRES", Name: "Pressure", Unit: "Pa", StandardName: "air_pressure"},
+	{0, 3, 1}:  {Abbreviation: "PRMSL", Name: "Pressure reduced to MSL", Unit: "Pa", StandardName: "air_pressure_at_mean_sea_level"},
+	{0, 3, 2}:  {Abbreviation: "PTEND", Name: "Pressure tendency", Unit: "Pa s-1", StandardName: "tendency_of_air_pressure"},
+	{0, 3, 3}:  {Abbreviation: "ICAHT", Name: "ICAO Standard Atmosphere reference height", Unit: "m"},
+	{0, 3, 4}:  {Abbreviation: "GP", Name: "Geopotential", Unit: "m2 s-2", StandardName: "geopotential"},
+	{0, 3, 5}:  {Abbreviation: "HGT", Name: "Geopotential height", Unit: "gpm", StandardName: "geopotential_height"},
+	{0, 3, 6}:  {Abbreviation: "DIST", Name: "Geometric height", Unit: "m", StandardName: "height"},
+	{0, 3, 7}:  {Abbreviation: "HSTDV", Name: "Standard deviation of height", Unit: "m"},
+	{0, 3, 8}:  {Abbreviation: "PRESA", Name: "Pressure anomaly", Unit: "Pa"},
+	{0, 3, 9}:  {Abbreviation: "GPA", Name: "Geopotential height anomaly", Unit: "gpm", StandardName: "geopotential_height_anomaly"},
+	{0, 3, 10}: {Abbreviation: "DEN", Name: "Density", Unit: "kg m-3", StandardName: "air_density"},
+	{0, 3, 11}: {Abbreviation: "ALTS", Name: "Altimeter setting", Unit: "Pa"},
+	{0, 3, 12}: {Abbreviation: "THICK", Name: "Thickness", Unit: "m"},
+	{0, 3, 13}: {Abbreviation: "PRESALT", Name: "Pressure altitude", Unit: "m"},
+	{0, 3, 14}: {Abbreviation: "DENALT", Name: "Density altitude", Unit: "m"},
+	{0, 3, 15}: {Abbreviation: "5WAVH", Name: "5-wave geopotential height", Unit: "gpm"},
+	{0, 3, 16}: {Abbreviation: "U-GWD", Name: "Zonal flux of gravity wave stress", Unit: "N m-2"},
+	{0, 3, 17}: {Abbreviation: "V-GWD", Name: "Meridional flux of gravity wave stress", Unit: "N m-2"},
+	{0, 3, 18}: {Abbreviation: "HPBL", Name: "Planetary boundary layer height", Unit: "m", StandardName: "atmosphere_boundary_layer_thickness"},
+	{0, 3, 19}: {Abbreviation: "5WAVA", Name: "5-wave geopotential height anomaly", Unit: "gpm"},
+	{0, 3, 20}: {Abbreviation: "SDSGSO", Name: "Standard deviation of sub-grid scale orography", Unit: "m"},
+	{0, 3, 21}: {Abbreviation: "AOSGSO", Name: "Angle of sub-gridscale orography", Unit: "rad"},
+	{0, 3, 22}: {Abbreviation: "SSGSO", Name: "Slope of sub-gridscale orography", Unit: "Numeric"},
+	{0, 3, 23}: {Abbreviation: "GWD", Name: "Gravity wave dissipation", Unit: "W m-2"},
+	{0, 3, 24}: {Abbreviation: "ASGSO", Name: "Anisotropy of sub-gridscale orography", Unit: "Numeric"},
+	{0, 3, 25}: {Abbreviation: "NLPRES", Name: "Natural logarithm of pressure in Pa", Unit: "Numeric"},
+	{0, 3, 26}: {Abbreviation: "EXPRES", Name: "Exner pressure", Unit: "Numeric"},
+
+	// Discipline 0, category 4: short-wave radiation
+	{0, 4, 0}:  {Abbreviation: "NSWRS", Name: "Net short-wave radiation flux (surface)", Unit: "W m-2", StandardName: "surface_net_downward_shortwave_flux"},
+	{0, 4, 1}:  {Abbreviation: "NSWRT", Name: "Net short-wave radiation flux (top of atmosphere)", Unit: "W m-2", StandardName: "toa_net_downward_shortwave_flux"},
+	{0, 4, 2}:  {Abbreviation: "SWAVR", Name: "Short-wave radiation flux", Unit: "W m-2"},
+	{0, 4, 3}:  {Abbreviation: "GRAD", Name: "Global radiation flux", Unit: "W m-2"},
+	{0, 4, 4}:  {Abbreviation: "BRTMP", Name: "Brightness temperature", Unit: "K", StandardName: "brightness_temperature"},
+	{0, 4, 5}:  {Abbreviation: "LWRAD", Name: "Radiance (with respect to wave number)", Unit: "W m-1 sr-1"},
+	{0, 4, 6}:  {Abbreviation: "SWRAD", Name: "Radiance (with respect to wavelength)", Unit: "W m-3 sr-1"},
+	{0, 4, 7}:  {Abbreviation: "DSWRF", Name: "Downward short-wave radiation flux", Unit: "W m-2"},
+	{0, 4, 8}:  {Abbreviation: "USWRF", Name: "Upward short-wave radiation flux", Unit: "W m-2"},
+	{0, 4, 9}:  {Abbreviation: "NSWRF", Name: "Net short wave radiation flux", Unit: "W m-2"},
+	{0, 4, 10}: {Abbreviation: "PHOTAR", Name: "Photosynthetically active radiation", Unit: "W m-2"},
+	{0, 4, 11}: {Abbreviation: "NSWRFCS", Name: "Net short-wave radiation flux, clear sky", Unit: "W m-2"},
+	{0, 4, 12}: {Abbreviation: "DWUVR", Name: "Downward UV radiation", Unit: "W m-2"},
+
+	// Discipline 0, category 5: long-wave radiation
+	{0, 5, 0}: {Abbreviation: "NLWRS", Name: "Net long-wave radiation flux (surface)", Unit: "W m-2", StandardName: "surface_net_downward_longwave_flux"},
+	{0, 5, 1}: {Abbreviation: "NLWRT", Name: "Net long-wave radiation flux (top of atmosphere)", Unit: "W m-2", StandardName: "toa_net_downward_longwave_flux"},
+	{0, 5, 2}: {Abbreviation: "LWAVR", Name: "Long-wave radiation flux", Unit: "W m-2"},
+	{0, 5, 3}: {Abbreviation: "DLWRF", Name: "Downward long-wave radiation flux", Unit: "W m-2"},
+	{0, 5, 4}: {Abbreviation: "ULWRF", Name: "Upward long-wave radiation flux", Unit: "W m-2"},
+	{0, 5, 5}: {Abbreviation: "NLWRF", Name: "Net long wave radiation flux", Unit: "W m-2"},
+	{0, 5, 6}: {Abbreviation: "NLWRCS", Name: "Net long-wave radiation flux, clear sky", Unit: "W m-2"},
+
+	// Discipline 0, category 6: cloud
+	{0, 6, 0}:  {Abbreviation: "CICE", Name: "Cloud ice", Unit: "kg m-2", StandardName: "atmosphere_mass_content_of_cloud_ice"},
+	{0, 6, 1}:  {Abbreviation: "TCDC", Name: "Total cloud cover", Unit: "%", StandardName: "cloud_area_fraction"},
+	{0, 6, 2}:  {Abbreviation: "CDCON", Name: "Convective cloud cover", Unit: "%", StandardName: "convective_cloud_area_fraction"},
+	{0, 6, 3}:  {Abbreviation: "LCDC", Name: "Low cloud cover", Unit: "%", StandardName: "low_type_cloud_area_fraction"},
+	{0, 6, 4}:  {Abbreviation: "MCDC", Name: "Medium cloud cover", Unit: "%", StandardName: "medium_type_cloud_area_fraction"},
+	{0, 6, 5}:  {Abbreviation: "HCDC", Name: "High cloud cover", Unit: "%", StandardName: "high_type_cloud_area_fraction"},
+	{0, 6, 6}:  {Abbreviation: "CWAT", Name: "Cloud water", Unit: "kg m-2", StandardName: "atmosphere_mass_content_of_cloud_condensed_water"},
+	{0, 6, 7}:  {Abbreviation: "CDCA", Name: "Cloud amount", Unit: "%"},
+	{0, 6, 8}:  {Abbreviation: "CDCT", Name: "Cloud type", Unit: "Code table 4.203"},
+	{0, 6, 9}:  {Abbreviation: "TMAXT", Name: "Thunderstorm maximum tops", Unit: "m"},
+	{0, 6, 10}: {Abbreviation: "THUNC", Name: "Thunderstorm coverage", Unit: "Code table 4.204"},
+	{0, 6, 11}: {Abbreviation: "CDCB", Name: "Cloud base", Unit: "m", StandardName: "cloud_base_altitude"},
+	{0, 6, 12}: {Abbreviation: "CDCTOP", Name: "Cloud top", Unit: "m", StandardName: "cloud_top_altitude"},
+	{0, 6, 13}: {Abbreviation: "CEIL", Name: "Ceiling", Unit: "m"},
+	{0, 6, 14}: {Abbreviation: "CDLYR", Name: "Non-convective cloud cover", Unit: "%"},
+	{0, 6, 15}: {Abbreviation: "CWORK", Name: "Cloud work function", Unit: "J kg-1"},
+	{0, 6, 16}: {Abbreviation: "CUEFI", Name: "Convective cloud efficiency", Unit: "Proportion"},
+	{0, 6, 17}: {Abbreviation: "TCOND", Name: "Total condensate", Unit: "kg kg-1"},
+	{0, 6, 18}: {Abbreviation: "TCOLW", Name: "Total column-integrated cloud water", Unit: "kg m-2", StandardName: "atmosphere_mass_content_of_cloud_liquid_water"},
+	{0, 6, 19}: {Abbreviation: "TCOLI", Name: "Total column-integrated cloud ice", Unit: "kg m-2", StandardName: "atmosphere_mass_content_of_cloud_ice"},
+	{0, 6, 20}: {Abbreviation: "TCOLC", Name: "Total column-integrated condensate", Unit: "kg m-2"},
+	{0, 6, 21}: {Abbreviation: "FICE", Name: "Ice fraction of total condensate", Unit: "Proportion"},
+	{0, 6, 22}: {Abbreviation: "CDCC", Name: "Cloud cover", Unit: "%"},
+	{0, 6, 23}: {Abbreviation: "CDCIMR", Name: "Cloud ice mixing ratio", Unit: "kg kg-1"},
+	{0, 6, 24}: {Abbreviation: "SUNS", Name: "Sunshine", Unit: "Numeric"},
+
+	// Discipline 0, category 7: thermodynamic stability indices
+	{0, 7, 0}:  {Abbreviation: "PLI", Name: "Parcel lifted index (to 500 hPa)", Unit: "K"},
+	{0, 7, 1}:  {Abbreviation: "BLI", Name: "Best lifted index (to 500 hPa)", Unit: "K"},
+	{0, 7, 2}:  {Abbreviation: "KX", Name: "K index", Unit: "K"},
+	{0, 7, 3}:  {Abbreviation: "KOX", Name: "KO index", Unit: "K"},
+	{0, 7, 4}:  {Abbreviation: "TOTALX", Name: "Total totals index", Unit: "K"},
+	{0, 7, 5}:  {Abbreviation: "SX", Name: "Sweat index", Unit: "Numeric"},
+	{0, 7, 6}:  {Abbreviation: "CAPE", Name: "Convective available potential energy", Unit: "J kg-1", StandardName: "atmosphere_convective_available_potential_energy"},
+	{0, 7, 7}:  {Abbreviation: "CIN", Name: "Convective inhibition", Unit: "J kg-1", StandardName: "atmosphere_convective_inhibition"},
+	{0, 7, 8}:  {Abbreviation: "HLCY", Name: "Storm relative helicity", Unit: "J kg-1"},
+	{0, 7, 9}:  {Abbreviation: "EHLX", Name: "Energy helicity index", Unit: "Numeric"},
+	{0, 7, 10}: {Abbreviation: "LFTX", Name: "Surface lifted index", Unit: "K"},
+	{0, 7, 11}: {Abbreviation: "4LFTX", Name: "Best (4 layer) lifted index", Unit: "K"},
+	{0, 7, 12}: {Abbreviation: "RI", Name: "Richardson number", Unit: "Numeric"},
+	{0, 7, 13}: {Abbreviation: "SHWINX", Name: "Showalter index", Unit: "K"},
+	{0, 7, 15}: {Abbreviation: "UPHL", Name: "Updraft helicity", Unit: "m2 s-2"},
+	{0, 7, 16}: {Abbreviation: "BLKRN", Name: "Bulk Richardson number", Unit: "Numeric"},
+	{0, 7, 17}: {Abbreviation: "GRDRN", Name: "Gradient Richardson number", Unit: "Numeric"},
+	{0, 7, 18}: {Abbreviation: "FLXRN", Name: "Flux Richardson number", Unit: "Numeric"},
+	{0, 7, 19}: {Abbreviation: "CONAPES", Name: "Convective available potential energy shear", Unit: "m2 s-2"},
+
+	// Discipline 0, category 14: trace gases
+	{0, 14, 0}: {Abbreviation: "TOZNE", Name: "Total ozone", Unit: "DU"},
+	{0, 14, 1}: {Abbreviation: "O3MR", Name: "Ozone mixing ratio", Unit: "kg kg-1", StandardName: "mass_fraction_of_ozone_in_air"},
+	{0, 14, 2}: {Abbreviation: "TCIOZ", Name: "Total column integrated ozone", Unit: "DU"},
+
+	// Discipline 0, category 16: forecast radar imagery
+	{0, 16, 0}: {Abbreviation: "REFZR", Name: "Equivalent radar reflectivity factor for rain", Unit: "mm6 m-3"},
+	{0, 16, 1}: {Abbreviation: "REFZI", Name: "Equivalent radar reflectivity factor for snow", Unit: "mm6 m-3"},
+	{0, 16, 2}: {Abbreviation: "REFZC", Name: "Equivalent radar reflectivity factor for parameterized convection", Unit: "mm6 m-3"},
+	{0, 16, 3}: {Abbreviation: "RETOP", Name: "Echo top", Unit: "m"},
+	{0, 16, 4}: {Abbreviation: "REFD", Name: "Reflectivity", Unit: "dB"},
+	{0, 16, 5}: {Abbreviation: "REFC", Name: "Composite reflectivity", Unit: "dB"},
+
+	// Discipline 0, category 19: physical atmospheric properties
+	{0, 19, 0}:  {Abbreviation: "VIS", Name: "Visibility", Unit: "m", StandardName: "visibility_in_air"},
+	{0, 19, 1}:  {Abbreviation: "ALBDO", Name: "Albedo", Unit: "%", StandardName: "surface_albedo"},
+	{0, 19, 2}:  {Abbreviation: "TSTM", Name: "Thunderstorm probability", Unit: "%"},
+	{0, 19, 3}:  {Abbreviation: "MIXHT", Name: "Mixed layer depth", Unit: "m"},
+	{0, 19, 4}:  {Abbreviation: "VOLASH", Name: "Volcanic ash", Unit: "Code table 4.206"},
+	{0, 19, 5}:  {Abbreviation: "ICIT", Name: "Icing top", Unit: "m"},
+	{0, 19, 6}:  {Abbreviation: "ICIB", Name: "Icing base", Unit: "m"},
+	{0, 19, 7}:  {Abbreviation: "ICI", Name: "Icing", Unit: "Code table 4.207"},
+	{0, 19, 8}:  {Abbreviation: "TURBT", Name: "Turbulence top", Unit: "m"},
+	{0, 19, 9}:  {Abbreviation: "TURBB", Name: "Turbulence base", Unit: "m"},
+	{0, 19, 10}: {Abbreviation: "TURB", Name: "Turbulence", Unit: "Code table 4.208"},
+	{0, 19, 11}: {Abbreviation: "TKE", Name: "Turbulent kinetic energy", Unit: "J kg-1"},
+	{0, 19, 12}: {Abbreviation: "PBLREG", Name: "Planetary boundary layer regime", Unit: "Code table 4.209"},
+	{0, 19, 13}: {Abbreviation: "CONTI", Name: "Contrail intensity", Unit: "Code table 4.210"},
+	{0, 19, 14}: {Abbreviation: "CONTET", Name: "Contrail engine type", Unit: "Code table 4.211"},
+	{0, 19, 15}: {Abbreviation: "CONTT", Name: "Contrail top", Unit: "m"},
+	{0, 19, 16}: {Abbreviation: "CONTB", Name: "Contrail base", Unit: "m"},
+	{0, 19, 17}: {Abbreviation: "MXSALB", Name: "Maximum snow albedo", Unit: "%"},
+	{0, 19, 18}: {Abbreviation: "SNFALB", Name: "Snow free albedo", Unit: "%"},
+	{0, 19, 19}: {Abbreviation: "SALBD", Name: "Snow albedo", Unit: "%"},
+	{0, 19, 20}: {Abbreviation: "ICIP", Name: "Icing", Unit: "%"},
+	{0, 19, 21}: {Abbreviation: "CTP", Name: "In-cloud turbulence", Unit: "%"},
+	{0, 19, 22}: {Abbreviation: "CAT", Name: "Clear air turbulence (CAT)", Unit: "%"},
+	{0, 19, 23}: {Abbreviation: "SLDP", Name: "Supercooled large droplet probability", Unit: "%"},
+
+	// Discipline 0, category 190: CCITT IA5 string
+	{0, 190, 0}: {Abbreviation: "ATEXT", Name: "Arbitrary text string", Unit: "CCITT IA5"},
+
+	// Discipline 0, category 191: miscellaneous
+	{0, 191, 0}: {Abbreviation: "TSEC", Name: "Seconds prior to initial reference time", Unit: "s"},
+	{0, 191, 1}: {Abbreviation: "GEOLAT", Name: "Geographical latitude", Unit: "°N", StandardName: "latitude"},
+	{0, 191, 2}: {Abbreviation: "GEOLON", Name: "Geographical longitude", Unit: "°E", StandardName: "longitude"},
+
+	// Discipline 1, category 0: hydrology basic products
+	{1, 0, 0}: {Abbreviation: "FFLDG", Name: "Flash flood guidance", Unit: "kg m-2"},
+	{1, 0, 1}: {Abbreviation: "FFLDRO", Name: "Flash flood runoff", Unit: "kg m-2"},
+	{1, 0, 2}: {Abbreviation: "RSSC", Name: "Remotely sensed snow cover", Unit: "Code table 4.215"},
+	{1, 0, 3}: {Abbreviation: "ESCT", Name: "Elevation of snow covered terrain", Unit: "Code table 4.216"},
+	{1, 0, 4}: {Abbreviation: "SWEPON", Name: "Snow water equivalent percent of normal", Unit: "%"},
+	{1, 0, 5}: {Abbreviation: "BGRUN", Name: "Baseflow-groundwater runoff", Unit: "kg m-2"},
+	{1, 0, 6}: {Abbreviation: "SSRUN", Name: "Storm surface runoff", Unit: "kg m-2"},
+
+	// Discipline 1, category 1: hydrology probabilities
+	{1, 1, 0}: {Abbreviation: "CPPOP", Name: "Conditional percent precipitation amount fractile for an overall period", Unit: "kg m-2"},
+	{1, 1, 1}: {Abbreviation: "PPOSP", Name: "Percent precipitation in a sub-period of an overall period", Unit: "%"},
+	{1, 1, 2}: {Abbreviation: "POP", Name: "Probability of 0.01 inch of precipitation (POP)", Unit: "%"},
+
+	// Discipline 2, category 0: vegetation/biomass
+	{2, 0, 0}:  {Abbreviation: "LAND", Name: "Land cover (1 = land, 0 = sea)", Unit: "Proportion", StandardName: "land_binary_mask"},
+	{2, 0, 1}:  {Abbreviation: "SFCR", Name: "Surface roughness", Unit: "m", StandardName: "surface_roughness_length"},
+	{2, 0, 2}:  {Abbreviation: "TSOIL", Name: "Soil temperature", Unit: "K", StandardName: "soil_temperature"},
+	{2, 0, 3}:  {Abbreviation: "SOILM", Name: "Soil moisture content", Unit: "kg m-2", StandardName: "mass_content_of_water_in_soil"},
+	{2, 0, 4}:  {Abbreviation: "VEG", Name: "Vegetation", Unit: "%", StandardName: "vegetation_area_fraction"},
+	{2, 0, 5}:  {Abbreviation: "WATR", Name: "Water runoff", Unit: "kg m-2", StandardName: "runoff_amount"},
+	{2, 0, 6}:  {Abbreviation: "EVAPT", Name: "Evapotranspiration", Unit: "kg m-2 s-1"},
+	{2, 0, 7}:  {Abbreviation: "MTERH", Name: "Model terrain height", Unit: "m", StandardName: "surface_altitude"},
+	{2, 0, 8}:  {Abbreviation: "LANDU", Name: "Land use", Unit: "Code table 4.212"},
+	{2, 0, 9}:  {Abbreviation: "SOILW", Name: "Volumetric soil moisture content", Unit: "Proportion"},
+	{2, 0, 10}: {Abbreviation: "GFLUX", Name: "Ground heat flux", Unit: "W m-2"},
+	{2, 0, 11}: {Abbreviation: "MSTAV", Name: "Moisture availability", Unit: "%"},
+	{2, 0, 12}: {Abbreviation: "SFEXC", Name: "Exchange coefficient", Unit: "kg m-2 s-1"},
+	{2, 0, 13}: {Abbreviation: "CNWAT", Name: "Plant canopy surface water", Unit: "kg m-2", StandardName: "canopy_water_amount"},
+	{2, 0, 14}: {Abbreviation: "BMIXL", Name: "Blackadar's mixing length scale", Unit: "m"},
+	{2, 0, 15}: {Abbreviation: "CCOND", Name: "Canopy conductance", Unit: "m s-1"},
+	{2, 0, 16}: {Abbreviation: "RSMIN", Name: "Minimal stomatal resistance", Unit: "s m-1"},
+	{2, 0, 17}: {Abbreviation: "WILT", Name: "Wilting point", Unit: "Proportion"},
+	{2, 0, 18}: {Abbreviation: "RCS", Name: "Solar parameter in canopy conductance", Unit: "Proportion"},
+	{2, 0, 19}: {Abbreviation: "RCT", Name: "Temperature parameter in canopy conductance", Unit: "Proportion"},
+	{2, 0, 20}: {Abbreviation: "RCSOL", Name: "Soil moisture parameter in canopy conductance", Unit: "Proportion"},
+	{2, 0, 21}: {Abbreviation: "RCQ", Name: "Humidity parameter in canopy conductance", Unit: "Proportion"},
+	{2, 0, 22}: {Abbreviation: "SOILM", Name: "Soil moisture", Unit: "kg m-3"},
+	{2, 0, 23}: {Abbreviation: "CISOILW", Name: "Column-integrated soil water", Unit: "kg m-2"},
+	{2, 0, 24}: {Abbreviation: "HFLUX", Name: "Heat flux", Unit: "W m-2"},
+	{2, 0, 25}: {Abbreviation: "VSOILM", Name: "Volumetric soil moisture", Unit: "m3 m-3"},
+	{2, 0, 26}: {Abbreviation: "WILT", Name: "Wilting point", Unit: "kg m-3"},
+	{2, 0, 27}: {Abbreviation: "VWILTP", Name: "Volumetric wilting point", Unit: "m3 m-3"},
+
+	// Discipline 2, category 3: soil products
+	{2, 3, 0}: {Abbreviation: "SOTYP", Name: "Soil type", Unit: "Code table 4.213"},
+	{2, 3, 1}: {Abbreviation: "UPLST", Name: "Upper layer soil temperature", Unit: "K"},
+	{2, 3, 2}: {Abbreviation: "UPLSM", Name: "Upper layer soil moisture", Unit: "kg m-3"},
+	{2, 3, 3}: {Abbreviation: "LOWLSM", Name: "Lower layer soil moisture", Unit: "kg m-3"},
+	{2, 3, 4}: {Abbreviation: "BOTLST", Name: "Bottom layer soil temperature", Unit: "K"},
+	{2, 3, 5}: {Abbreviation: "SOILL", Name: "Liquid volumetric soil moisture (non-frozen)", Unit: "Proportion"},
+	{2, 3, 6}: {Abbreviation: "RLYRS", Name: "Number of soil layers in root zone", Unit: "Numeric"},
+	{2, 3, 7}: {Abbreviation: "SMREF", Name: "Transpiration stress-onset (soil moisture)", Unit: "Proportion"},
+	{2, 3, 8}: {Abbreviation: "SMDRY", Name: "Direct evaporation cease (soil moisture)", Unit: "Proportion"},
+	{2, 3, 9}: {Abbreviation: "POROS", Name: "Soil porosity", Unit: "Proportion"},
+
+	// Discipline 10, category 0: waves
+	{10, 0, 0}:  {Abbreviation: "WVSP1", Name: "Wave spectra (1)", Unit: "-"},
+	{10, 0, 1}:  {Abbreviation: "WVSP2", Name: "Wave spectra (2)", Unit: "-"},
+	{10, 0, 2}:  {Abbreviation: "WVSP3", Name: "Wave spectra (3)", Unit: "-"},
+	{10, 0, 3}:  {Abbreviation: "HTSGW", Name: "Significant height of combined wind waves and swell", Unit: "m", StandardName: "sea_surface_wave_significant_height"},
+	{10, 0, 4}:  {Abbreviation: "WVDIR", Name: "Direction of wind waves", Unit: "°", StandardName: "sea_surface_wind_wave_from_direction"},
+	{10, 0, 5}:  {Abbreviation: "WVHGT", Name: "Significant height of wind waves", Unit: "m", StandardName: "sea_surface_wind_wave_significant_height"},
+	{10, 0, 6}:  {Abbreviation: "WVPER", Name: "Mean period of wind waves", Unit: "s", StandardName: "sea_surface_wind_wave_mean_period"},
+	{10, 0, 7}:  {Abbreviation: "SWDIR", Name: "Direction of swell waves", Unit: "°", StandardName: "sea_surface_swell_wave_from_direction"},
+	{10, 0, 8}:  {Abbreviation: "SWELL", Name: "Significant height of swell waves", Unit: "m", StandardName: "sea_surface_swell_wave_significant_height"},
+	{10, 0, 9}:  {Abbreviation: "SWPER", Name: "Mean period of swell waves", Unit: "s", StandardName: "sea_surface_swell_wave_mean_period"},
+	{10, 0, 10}: {Abbreviation: "DIRPW", Name: "Primary wave direction", Unit: "°"},
+	{10, 0, 11}: {Abbreviation: "PERPW", Name: "Primary wave mean period", Unit: "s"},
+	{10, 0, 12}: {Abbreviation: "DIRSW", Name: "Secondary wave direction", Unit: "°"},
+	{10, 0, 13}: {Abbreviation: "PERSW", Name: "Secondary wave mean period", Unit: "s"},
+	{10, 0, 14}: {Abbreviation: "WWSDIR", Name: "Direction of combined wind waves and swell", Unit: "°", StandardName: "sea_surface_wave_from_direction"},
+	{10, 0, 15}: {Abbreviation: "MWSPER", Name: "Mean period of combined wind waves and swell", Unit: "s", StandardName: "sea_surface_wave_mean_period"},
+	{10, 0, 16}: {Abbreviation: "CDWW", Name: "Coefficient of drag with waves", Unit: "-"},
+	{10, 0, 17}: {Abbreviation: "FRICVW", Name: "Friction velocity", Unit: "m s-1"},
+	{10, 0, 18}: {Abbreviation: "WSTR", Name: "Wave stress", Unit: "N m-2"},
+	{10, 0, 19}: {Abbreviation: "NWSTR", Name: "Normalised waves stress", Unit: "-"},
+	{10, 0, 20}: {Abbreviation: "MSSW", Name: "Mean square slope of waves", Unit: "-"},
+	{10, 0, 21}: {Abbreviation: "USSD", Name: "U-component surface Stokes drift", Unit: "m s-1", StandardName: "sea_surface_wave_stokes_drift_eastward_velocity"},
+	{10, 0, 22}: {Abbreviation: "VSSD", Name: "V-component surface Stokes drift", Unit: "m s-1", StandardName: "sea_surface_wave_stokes_drift_northward_velocity"},
+	{10, 0, 23}: {Abbreviation: "PMAXWH", Name: "Period of maximum individual wave height", Unit: "s"},
+	{10, 0, 24}: {Abbreviation: "MAXWH", Name: "Maximum individual wave height", Unit: "m", StandardName: "sea_surface_wave_maximum_height"},
+
+	// Discipline 10, category 1: currents
+	{10, 1, 0}: {Abbreviation: "DIRC", Name: "Current direction", Unit: "°", StandardName: "direction_of_sea_water_velocity"},
+	{10, 1, 1}: {Abbreviation: "SPC", Name: "Current speed", Unit: "m s-1", StandardName: "sea_water_speed"},
+	{10, 1, 2}: {Abbreviation: "UOGRD", Name: "U-component of current", Unit: "m s-1", StandardName: "eastward_sea_water_velocity"},
+	{10, 1, 3}: {Abbreviation: "VOGRD", Name: "V-component of current", Unit: "m s-1", StandardName: "northward_sea_water_velocity"},
+
+	// Discipline 10, category 2: ice
+	{10, 2, 0}: {Abbreviation: "ICEC", Name: "Ice cover", Unit: "Proportion", StandardName: "sea_ice_area_fraction"},
+	{10, 2, 1}: {Abbreviation: "ICETK", Name: "Ice thickness", Unit: "m", StandardName: "sea_ice_thickness"},
+	{10, 2, 2}: {Abbreviation: "DICED", Name: "Direction of ice drift", Unit: "°"},
+	{10, 2, 3}: {Abbreviation: "SICED", Name: "Speed of ice drift", Unit: "m s-1", StandardName: "sea_ice_speed"},
+	{10, 2, 4}: {Abbreviation: "UICE", Name: "U-component of ice drift", Unit: "m s-1", StandardName: "eastward_sea_ice_velocity"},
+	{10, 2, 5}: {Abbreviation: "VICE", Name: "V-component of ice drift", Unit: "m s-1", StandardName: "northward_sea_ice_velocity"},
+	{10, 2, 6}: {Abbreviation: "ICEG", Name: "Ice growth rate", Unit: "m s-1"},
+	{10, 2, 7}: {Abbreviation: "ICED", Name: "Ice divergence", Unit: "s-1"},
+	{10, 2, 8}: {Abbreviation: "ICET", Name: "Ice temperature", Unit: "K", StandardName: "sea_ice_temperature"},
+	{10, 2, 9}: {Abbreviation: "ICEPRS", Name: "Ice internal pressure", Unit: "Pa m"},
+
+	// Discipline 10, category 3: surface properties
+	{10, 3, 0}: {Abbreviation: "WTMP", Name: "Water temperature", Unit: "K", StandardName: "sea_surface_temperature"},
+	{10, 3, 1}: {Abbreviation: "DSLM", Name: "Deviation of sea level from mean", Unit: "m", StandardName: "sea_surface_height_above_mean_sea_level"},
+
+	// Discipline 10, category 4: sub-surface properties
+	{10, 4, 0}: {Abbreviation: "MTHD", Name: "Main thermocline depth", Unit: "m"},
+	{10, 4, 1}: {Abbreviation: "MTHA", Name: "Main thermocline anomaly", Unit: "m"},
+	{10, 4, 2}: {Abbreviation: "TTHDP", Name: "Transient thermocline depth", Unit: "m"},
+	{10, 4, 3}: {Abbreviation: "SALTY", Name: "Salinity", Unit: "kg kg-1", StandardName: "sea_water_salinity"},
+	{10, 4, 4}: {Abbreviation: "OVHD", Name: "Ocean vertical heat diffusivity", Unit: "m2 s-1", StandardName: "ocean_vertical_heat_diffusivity"},
+	{10, 4, 5}: {Abbreviation: "OVSD", Name: "Ocean vertical salt diffusivity", Unit: "m2 s-1", StandardName: "ocean_vertical_salt_diffusivity"},
+	{10, 4, 6}: {Abbreviation: "OVMD", Name: "Ocean vertical momentum diffusivity", Unit: "m2 s-1", StandardName: "ocean_vertical_momentum_diffusivity"},
+
+	// Discipline 10, category 191: miscellaneous
+	{10, 191, 0}: {Abbreviation: "TSEC", Name: "Seconds prior to initial reference time", Unit: "s"},
+	{10, 191, 1}: {Abbreviation: "MOSF", Name: "Meridional overturning stream function", Unit: "m3 s-1"},
+}
+
+// ncepLocalParameters holds commonly used entries of the NCEP local parameters, the
+// ranges of Code Table 4.2 reserved for local use as defined by NCEP for GFS, GEFS, HRRR
+// and its other models
+var ncepLocalParameters = map[parameterKey]ParameterInfo{
+	// Discipline 0, category 0: temperature
+	{0, 0, 192}: {Abbreviation: "SNOHF", Name: "Snow phase change heat flux", Unit: "W m-2"},
+	{0, 0, 193}: {Abbreviation: "TTRAD", Name: "Temperature tendency by all radiation", Unit: "K s-1"},
+	{0, 0, 194}: {Abbreviation: "REV", Name: "Relative error variance", Unit: "-"},
+	{0, 0, 195}: {Abbreviation: "LRGHR", Name: "Large scale condensate heating rate", Unit: "K s-1"},
+	{0, 0, 196}: {Abbreviation: "CNVHR", Name: "Deep convective heating rate", Unit: "K s-1"},
+	{0, 0, 197}: {Abbreviation: "THFLX", Name: "Total downward heat flux at surface", Unit: "W m-2"},
+	{0, 0, 198}: {Abbreviation: "TTDIA", Name: "Temperature tendency by all physics", Unit: "K s-1"},
+	{0, 0, 199}: {Abbreviation: "TTPHY", Name: "Temperature tendency by non-radiation physics", Unit: "K s-1"},
+	{0, 0, 200}: {Abbreviation: "TSD1D", Name: "Standard dev. of IR temp. over 1x1 deg. area", Unit: "K"},
+	{0, 0, 201}: {Abbreviation: "SHAHR", Name: "Shallow convective heating rate", Unit: "K s-1"},
+	{0, 0, 202}: {Abbreviation: "VDFHR", Name: "Vertical diffusion heating rate", Unit: "K s-1"},
+	{0, 0, 203}: {Abbreviation: "THZ0", Name: "Potential temperature at top of viscous sublayer", Unit: "K"},
+	{0, 0, 204}: {Abbreviation: "TCHP", Name: "Tropical cyclone heat potential", Unit: "J m-2 K"},
+
+	// Discipline 0, category 1: moisture
+	{0, 1, 192}: {Abbreviation: "CRAIN", Name: "Categorical rain", Unit: "Code table 4.222"},
+	{0, 1, 193}: {Abbreviation: "CFRZR", Name: "Categorical freezing rain", Unit: "Code table 4.222"},
+	{0, 1, 194}: {Abbreviation: "CICEP", Name: "Categorical ice pellets", Unit: "Code table 4.222"},
+	{0, 1, 195}: {Abbreviation: "CSNOW", Name: "Categorical snow", Unit: "Code table 4.222"},
+	{0, 1, 196}: {Abbreviation: "CPRAT", Name: "Convective precipitation rate", Unit: "kg m-2 s-1", StandardName: "convective_precipitation_flux"},
+	{0, 1, 197}: {Abbreviation: "MCONV", Name: "Horizontal moisture divergence", Unit: "kg kg-1 s-1"},
+	{0, 1, 198}: {Abbreviation: "MINRH", Name: "Minimum relative humidity", Unit: "%"},
+	{0, 1, 199}: {Abbreviation: "PEVAP", Name: "Potential evaporation", Unit: "kg m-2"},
+	{0, 1, 200}: {Abbreviation: "PEVPR", Name: "Potential evaporation rate", Unit: "W m-2"},
+	{0, 1, 201}: {Abbreviation: "SNOWC", Name: "Snow cover", Unit: "%"},
+	{0, 1, 202}: {Abbreviation: "FRAIN", Name: "Rain fraction of total liquid water", Unit: "Proportion"},
+	{0, 1, 203}: {Abbreviation: "RIME", Name: "Rime factor", Unit: "Numeric"},
+	{0, 1, 204}: {Abbreviation: "TCOLR", Name: "Total column integrated rain", Unit: "kg m-2"},
+	{0, 1, 205}: {Abbreviation: "TCOLS", Name: "Total column integrated snow", Unit: "kg m-2"},
+	{0, 1, 206}: {Abbreviation: "TIPD", Name: "Total icing potential diagnostic", Unit: "Numeric"},
+	{0, 1, 207}: {Abbreviation: "NCIP", Name: "Number concentration for ice particles", Unit: "Numeric"},
+	{0, 1, 208}: {Abbreviation: "SNOT", Name: "Snow temperature", Unit: "K"},
+	{0, 1, 209}: {Abbreviation: "TCLSW", Name: "Total column-integrated supercooled liquid water", Unit: "kg m-2"},
+	{0, 1, 210}: {Abbreviation: "TCOLM", Name: "Total column-integrated melting ice", Unit: "kg m-2"},
+	{0, 1, 211}: {Abbreviation: "EMNP", Name: "Evaporation - precipitation", Unit: "cm day-1"},
+	{0, 1, 212}: {Abbreviation: "SBSNO", Name: "Sublimation (evaporation from snow)", Unit: "W m-2"},
+	{0, 1, 213}: {Abbreviation: "CNVMR", Name: "Deep convective moistening rate", Unit: "kg kg-1 s-1"},
+	{0, 1, 214}: {Abbreviation: "SHAMR", Name: "Shallow convective moistening rate", Unit: "kg kg-1 s-1"},
+	{0, 1, 215}: {Abbreviation: "VDFMR", Name: "Vertical diffusion moistening rate", Unit: "kg kg-1 s-1"},
+	{0, 1, 216}: {Abbreviation: "CONDP", Name: "Condensation pressure of parcel lifted from indicated surface", Unit: "Pa"},
+	{0, 1, 217}: {Abbreviation: "LRGMR", Name: "Large scale moistening rate", Unit: "kg kg-1 s-1"},
+	{0, 1, 218}: {Abbreviation: "QZ0", Name: "Specific humidity at top of viscous sublayer", Unit: "kg kg-1"},
+	{0, 1, 219}: {Abbreviation: "QMAX", Name: "Maximum specific humidity at 2m", Unit: "kg kg-1"},
+	{0, 1, 220}: {Abbreviation: "QMIN", Name: "Minimum specific humidity at 2m", Unit: "kg kg-1"},
+	{0, 1, 221}: {Abbreviation: "ARAIN", Name: "Liquid precipitation (rainfall)", Unit: "kg m-2"},
+	{0, 1, 222}: {Abbreviation: "SNOWT", Name: "Snow temperature, depth-avg", Unit: "K"},
+	{0, 1, 223}: {Abbreviation: "APCPN", Name: "Total precipitation (nearest grid point)", Unit: "kg m-2"},
+	{0, 1, 224}: {Abbreviation: "ACPCPN", Name: "Convective precipitation (nearest grid point)", Unit: "kg m-2"},
+	{0, 1, 225}: {Abbreviation: "FRZR", Name: "Freezing rain", Unit: "kg m-2"},
+
+	// Discipline 0, category 2: momentum
+	{0, 2, 192}: {Abbreviation: "VWSH", Name: "Vertical speed shear", Unit: "s-1"},
+	{0, 2, 193}: {Abbreviation: "MFLX", Name: "Horizontal momentum flux", Unit: "N m-2"},
+	{0, 2, 194}: {Abbreviation: "USTM", Name: "U-component storm motion", Unit: "m s-1"},
+	{0, 2, 195}: {Abbreviation: "VSTM", Name: "V-component storm motion", Unit: "m s-1"},
+	{0, 2, 196}: {Abbreviation: "CD", Name: "Drag coefficient", Unit: "Numeric"},
+	{0, 2, 197}: {Abbreviation: "FRICV", Name: "Frictional velocity", Unit: "m s-1"},
+	{0, 2, 198}: {Abbreviation: "LAUV", Name: "Latitude of U wind component of velocity", Unit: "°"},
+	{0, 2, 199}: {Abbreviation: "LOUV", Name: "Longitude of U wind component of velocity", Unit: "°"},
+	{0, 2, 200}: {Abbreviation: "LAVV", Name: "Latitude of V wind component of velocity", Unit: "°"},
+	{0, 2, 201}: {Abbreviation: "LOVV", Name: "Longitude of V wind component of velocity", Unit: "°"},
+	{0, 2, 202}: {Abbreviation: "LAPP", Name: "Latitude of pressure point", Unit: "°"},
+	{0, 2, 203}: {Abbreviation: "LOPP", Name: "Longitude of pressure point", Unit: "°"},
+	{0, 2, 204}: {Abbreviation: "VEDH", Name: "Vertical eddy diffusivity heat exchange", Unit: "m2 s-1"},
+	{0, 2, 205}: {Abbreviation: "COVMZ", Name: "Covariance between meridional and zonal components of the wind", Unit: "m2 s-2"},
+	{0, 2, 206}: {Abbreviation: "COVTZ", Name: "Covariance between temperature and zonal components of the wind", Unit: "K m s-1"},
+	{0, 2, 207}: {Abbreviation: "COVTM", Name: "Covariance between temperature and meridional components of the wind", Unit: "K m s-1"},
+	{0, 2, 208}: {Abbreviation: "VDFUA", Name: "Vertical diffusion zonal acceleration", Unit: "m s-2"},
+	{0, 2, 209}: {Abbreviation: "VDFVA", Name: "Vertical diffusion meridional acceleration", Unit: "m s-2"},
+	{0, 2, 210}: {Abbreviation: "GWDU", Name: "Gravity wave drag zonal acceleration", Unit: "m s-2"},
+	{0, 2, 211}: {Abbreviation: "GWDV", Name: "Gravity wave drag meridional acceleration", Unit: "m s-2"},
+	{0, 2, 212}: {Abbreviation: "CNVU", Name: "Convective zonal momentum mixing acceleration", Unit: "m s-2"},
+	{0, 2, 213}: {Abbreviation: "CNVV", Name: "Convective meridional momentum mixing acceleration", Unit: "m s-2"},
+	{0, 2, 214}: {Abbreviation: "WTEND", Name: "Tendency of vertical velocity", Unit: "m s-2"},
+	{0, 2, 215}: {Abbreviation: "OMGALF", Name: "Omega (Dp/Dt) divide by density", Unit: "K"},
+	{0, 2, 216}: {Abbreviation: "CNGWDU", Name: "Convective gravity wave drag zonal acceleration", Unit: "m s-2"},
+	{0, 2, 217}: {Abbreviation: "CNGWDV", Name: "Convective gravity wave drag meridional acceleration", Unit: "m s-2"},
+	{0, 2, 220}: {Abbreviation: "MAXUVV", Name: "Hourly maximum of upward vertical velocity", Unit: "m s-1"},
+	{0, 2, 221}: {Abbreviation: "MAXDVV", Name: "Hourly maximum of downward vertical velocity", Unit: "m s-1"},
+	{0, 2, 222}: {Abbreviation: "MAXUW", Name: "U component of hourly maximum 10m wind speed", Unit: "m s-1"},
+	{0, 2, 223}: {Abbreviation: "MAXVW", Name: "V component of hourly maximum 10m wind speed", Unit: "m s-1"},
+	{0, 2, 224}: {Abbreviation: "VRATE", Name: "Ventilation rate", Unit: "m2 s-1"},
+
+	// Discipline 0, category 3: mass
+	{0, 3, 192}: {Abbreviation: "MSLET", Name: "MSLP (Eta model reduction)", Unit: "Pa", StandardName: "air_pressure_at_mean_sea_level"},
+	{0, 3, 193}: {Abbreviation: "5WAVH", Name: "5-wave geopotential height", Unit: "gpm"},
+	{0, 3, 194}: {Abbreviation: "U-GWD", Name: "Zonal flux of gravity wave stress", Unit: "N m-2"},
+	{0, 3, 195}: {Abbreviation: "V-GWD", Name: "Meridional flux of gravity wave stress", Unit: "N m-2"},
+	{0, 3, 196}: {Abbreviation: "HPBL", Name: "Planetary boundary layer height", Unit: "m", StandardName: "atmosphere_boundary_layer_thickness"},
+	{0, 3, 197}: {Abbreviation: "5WAVA", Name: "5-wave geopotential height anomaly", Unit: "gpm"},
+	{0, 3, 198}: {Abbreviation: "MSLMA", Name: "MSLP (MAPS system reduction)", Unit: "Pa", StandardName: "air_pressure_at_mean_sea_level"},
+	{0, 3, 199}: {Abbreviation: "TSLSA", Name: "3-hr pressure tendency (Std. Atmos. Reduction)", Unit: "Pa s-1"},
+	{0, 3, 200}: {Abbreviation: "PLPL", Name: "Pressure of level from which parcel was lifted", Unit: "Pa"},
+	{0, 3, 201}: {Abbreviation: "LPSX", Name: "X-gradient of log pressure", Unit: "m-1"},
+	{0, 3, 202}: {Abbreviation: "LPSY", Name: "Y-gradient of log pressure", Unit: "m-1"},
+	{0, 3, 203}: {Abbreviation: "HGTX", Name: "X-gradient of height", Unit: "m-1"},
+	{0, 3, 204}: {Abbreviation: "HGTY", Name: "Y-gradient of height", Unit: "m-1"},
+	{0, 3, 205}: {Abbreviation: "LAYTH", Name: "Layer thickness", Unit: "m"},
+	{0, 3, 206}: {Abbreviation: "NLGSP", Name: "Natural log of surface pressure", Unit: "ln(kPa)"},
+	{0, 3, 207}: {Abbreviation: "CNVUMF", Name: "Convective updraft mass flux", Unit: "kg m-2 s-1"},
+	{0, 3, 208}: {Abbreviation: "CNVDMF", Name: "Convective downdraft mass flux", Unit: "kg m-2 s-1"},
+	{0, 3, 209}: {Abbreviation: "CNVDEMF", Name: "Convective detrainment mass flux", Unit: "kg m-2 s-1"},
+	{0, 3, 211}: {Abbreviation: "HGTN", Name: "Geopotential height (nearest grid point)", Unit: "gpm"},
+	{0, 3, 212}: {Abbreviation: "PRESN", Name: "Pressure (nearest grid point)", Unit: "Pa"},
+
+	// Discipline 0, category 4: short-wave radiation
+	{0, 4, 192}: {Abbreviation: "DSWRF", Name: "Downward short-wave radiation flux", Unit: "W m-2"},
+	{0, 4, 193}: {Abbreviation: "USWRF", Name: "Upward short-wave radiation flux", Unit: "W m-2"},
+	{0, 4, 194}: {Abbreviation: "DUVB", Name: "UV-B downward solar flux", Unit: "W m-2"},
+	{0, 4, 195}: {Abbreviation: "CDUVB", Name: "Clear sky UV-B downward solar flux", Unit: "W m-2"},
+	{0, 4, 196}: {Abbreviation: "CSDSF", Name: "Clear sky downward solar flux", Unit: "W m-2"},
+	{0, 4, 197}: {Abbreviation: "SWHR", Name: "Solar radiative heating rate", Unit: "K s-1"},
+	{0, 4, 198}: {Abbreviation: "CSUSF", Name: "Clear sky upward solar flux", Unit: "W m-2"},
+	{0, 4, 199}: {Abbreviation: "CFNSF", Name: "Cloud forcing net solar flux", Unit: "W m-2"},
+	{0, 4, 200}: {Abbreviation: "VBDSF", Name: "Visible beam downward solar flux", Unit: "W m-2"},
+	{0, 4, 201}: {Abbreviation: "VDDSF", Name: "Visible diffuse downward solar flux", Unit: "W m-2"},
+	{0, 4, 202}: {Abbreviation: "NBDSF", Name: "Near IR beam downward solar flux", Unit: "W m-2"},
+	{0, 4, 203}: {Abbreviation: "NDDSF", Name: "Near IR diffuse downward solar flux", Unit: "W m-2"},
+	{0, 4, 204}: {Abbreviation: "DTRF", Name: "Downward total radiation flux", Unit: "W m-2"},
+	{0, 4, 205}: {Abbreviation: "UTRF", Name: "Upward total radiation flux", Unit: "W m-2"},
+
+	// Discipline 0, category 5: long-wave radiation
+	{0, 5, 192}: {Abbreviation: "DLWRF", Name: "Downward long-wave radiation flux", Unit: "W m-2"},
+	{0, 5, 193}: {Abbreviation: "ULWRF", Name: "Upward long-wave radiation flux", Unit: "W m-2"},
+	{0, 5, 194}: {Abbreviation: "LWHR", Name: "Long-wave radiative heating rate", Unit: "K s-1"},
+	{0, 5, 195}: {Abbreviation: "CSULF", Name: "Clear sky upward long wave flux", Unit: "W m-2"},
+	{0, 5, 196}: {Abbreviation: "CSDLF", Name: "Clear sky downward long wave flux", Unit: "W m-2"},
+	{0, 5, 197}: {Abbreviation: "CFNLF", Name: "Cloud forcing net long wave flux", Unit: "W m-2"},
+
+	// Discipline 0, category 6: cloud
+	{0, 6, 192}: {Abbreviation: "CDLYR", Name: "Non-convective cloud cover", Unit: "%"},
+	{0, 6, 193}: {Abbreviation: "CWORK", Name: "Cloud work function", Unit: "J kg-1"},
+	{0, 6, 194}: {Abbreviation: "CUEFI", Name: "Convective cloud efficiency", Unit: "Numeric"},
+	{0, 6, 195}: {Abbreviation: "TCOND", Name: "Total condensate", Unit: "kg kg-1"},
+	{0, 6, 196}: {Abbreviation: "TCOLW", Name: "Total column-integrated cloud water", Unit: "kg m-2", StandardName: "atmosphere_mass_content_of_cloud_liquid_water"},
+	{0, 6, 197}: {Abbreviation: "TCOLI", Name: "Total column-integrated cloud ice", Unit: "kg m-2", StandardName: "atmosphere_mass_content_of_cloud_ice"},
+	{0, 6, 198}: {Abbreviation: "TCOLC", Name: "Total column-integrated condensate", Unit: "kg m-2"},
+	{0, 6, 199}: {Abbreviation: "FICE", Name: "Ice fraction of total condensate", Unit: "Numeric"},
+	{0, 6, 200}: {Abbreviation: "MFLUX", Name: "Convective cloud mass flux", Unit: "Pa s-1"},
+	{0, 6, 201}: {Abbreviation: "SUNSD", Name: "Sunshine duration", Unit: "s", StandardName: "duration_of_sunshine"},
+
+	// Discipline 0, category 7: thermodynamic stability indices
+	{0, 7, 192}: {Abbreviation: "LFTX", Name: "Surface lifted index", Unit: "K"},
+	{0, 7, 193}: {Abbreviation: "4LFTX", Name: "Best (4 layer) lifted index", Unit: "K"},
+	{0, 7, 194}: {Abbreviation: "RI", Name: "Richardson number", Unit: "Numeric"},
+	{0, 7, 195}: {Abbreviation: "CWDI", Name: "Convective weather detection index", Unit: "Numeric"},
+	{0, 7, 196}: {Abbreviation: "UVI", Name: "Ultra violet index", Unit: "W m-2"},
+	{0, 7, 197}: {Abbreviation: "UPHL", Name: "Updraft helicity", Unit: "m2 s-2"},
+	{0, 7, 198}: {Abbreviation: "LAI", Name: "Leaf area index", Unit: "Numeric", StandardName: "leaf_area_index"},
+	{0, 7, 199}: {Abbreviation: "MXUPHL", Name: "Hourly maximum of updraft helicity", Unit: "m2 s-2"},
+	{0, 7, 200}: {Abbreviation: "MNUPHL", Name: "Hourly minimum of updraft helicity", Unit: "m2 s-2"},
+	{0, 7, 203}: {Abbreviation: "DCAPE", Name: "Downdraft CAPE", Unit: "J kg-1"},
+	{0, 7, 204}: {Abbreviation: "EFHL", Name: "Effective storm relative helicity", Unit: "m2 s-2"},
+	{0, 7, 205}: {Abbreviation: "ESP", Name: "Enhanced stretching potential", Unit: "Numeric"},
+	{0, 7, 206}: {Abbreviation: "CANGLE", Name: "Critical angle", Unit: "°"},
+
+	// Discipline 0, category 14: trace gases
+	{0, 14, 192}: {Abbreviation: "O3MR", Name: "Ozone mixing ratio", Unit: "kg kg-1", StandardName: "mass_fraction_of_ozone_in_air"},
+	{0, 14, 193}: {Abbreviation: "OZCON", Name: "Ozone concentration", Unit: "ppb"},
+	{0, 14, 194}: {Abbreviation: "OZCAT", Name: "Categorical ozone concentration", Unit: "Numeric"},
+	{0, 14, 195}: {Abbreviation: "VDFOZ", Name: "Ozone vertical diffusion", Unit: "kg kg-1 s-1"},
+	{0, 14, 196}: {Abbreviation: "POZ", Name: "Ozone production", Unit: "kg kg-1 s-1"},
+	{0, 14, 197}: {Abbreviation: "TOZ", Name: "Ozone tendency", Unit: "kg kg-1 s-1"},
+	{0, 14, 198}: {Abbreviation: "POZT", Name: "Ozone production from temperature term", Unit: "kg kg-1 s-1"},
+	{0, 14, 199}: {Abbreviation: "POZO", Name: "Ozone production from column ozone term", Unit: "kg kg-1 s-1"},
+
+	// Discipline 0, category 16: forecast radar imagery
+	{0, 16, 192}: {Abbreviation: "REFZR", Name: "Derived radar reflectivity backscatter from rain", Unit: "mm6 m-3"},
+	{0, 16, 193}: {Abbreviation: "REFZI", Name: "Derived radar reflectivity backscatter from ice", Unit: "mm6 m-3"},
+	{0, 16, 194}: {Abbreviation: "REFZC", Name: "Derived radar reflectivity backscatter from parameterized convection", Unit: "mm6 m-3"},
+	{0, 16, 195}: {Abbreviation: "REFD", Name: "Reflectivity", Unit: "dB"},
+	{0, 16, 196}: {Abbreviation: "REFC", Name: "Composite reflectivity", Unit: "dB"},
+	{0, 16, 197}: {Abbreviation: "RETOP", Name: "Echo top", Unit: "m"},
+	{0, 16, 198}: {Abbreviation: "MAXREF", Name: "Hourly maximum of simulated reflectivity at 1 km AGL", Unit: "dB"},
+
+	// Discipline 0, category 17: electrodynamics
+	{0, 17, 192}: {Abbreviation: "LTNG", Name: "Lightning", Unit: "Numeric"},
+
+	// Discipline 0, category 19: physical atmospheric properties
+	{0, 19, 192}: {Abbreviation: "MXSALB", Name: "Maximum snow albedo", Unit: "%"},
+	{0, 19, 193}: {Abbreviation: "SNFALB", Name: "Snow-free albedo", Unit: "%"},
+	{0, 19, 194}: {Abbreviation: "SRCONO", Name: "Slight risk convective outlook", Unit: "Numeric"},
+	{0, 19, 195}: {Abbreviation: "MRCONO", Name: "Moderate risk convective outlook", Unit: "Numeric"},
+	{0, 19, 196}: {Abbreviation: "HRCONO", Name: "High risk convective outlook", Unit: "Numeric"},
+	{0, 19, 197}: {Abbreviation: "TORPROB", Name: "Tornado probability", Unit: "%"},
+	{0, 19, 198}: {Abbreviation: "HAILPROB", Name: "Hail probability", Unit: "%"},
+	{0, 19, 199}: {Abbreviation: "WINDPROB", Name: "Wind probability", Unit: "%"},
+	{0, 19, 200}: {Abbreviation: "STORPROB", Name: "Significant tornado probability", Unit: "%"},
+	{0, 19, 201}: {Abbreviation: "SHAILPRO", Name: "Significant hail probability", Unit: "%"},
+	{0, 19, 202}: {Abbreviation: "SWINDPRO", Name: "Significant wind probability", Unit: "%"},
+	{0, 19, 203}: {Abbreviation: "TSTMC", Name: "Categorical thunderstorm (1-yes, 0-no)", Unit: "Numeric"},
+	{0, 19, 204}: {Abbreviation: "MIXLY", Name: "Number of mixed layers next to surface", Unit: "Numeric"},
+	{0, 19, 205}: {Abbreviation: "FLGHT", Name: "Flight category", Unit: "Numeric"},
+	{0, 19, 206}: {Abbreviation: "CICEL", Name: "Confidence - ceiling", Unit: "Numeric"},
+	{0, 19, 207}: {Abbreviation: "CIVIS", Name: "Confidence - visibility", Unit: "Numeric"},
+	{0, 19, 208}: {Abbreviation: "CIFLT", Name: "Confidence - flight category", Unit: "Numeric"},
+	{0, 19, 209}: {Abbreviation: "LAVNI", Name: "Low-level aviation interest", Unit: "Numeric"},
+	{0, 19, 210}: {Abbreviation: "HAVNI", Name: "High-level aviation interest", Unit: "Numeric"},
+	{0, 19, 211}: {Abbreviation: "SBSALB", Name: "Visible, black sky albedo", Unit: "%"},
+	{0, 19, 212}: {Abbreviation: "SWSALB", Name: "Visible, white sky albedo", Unit: "%"},
+	{0, 19, 213}: {Abbreviation: "NBSALB", Name: "Near IR, black sky albedo", Unit: "%"},
+	{0, 19, 214}: {Abbreviation: "NWSALB", Name: "Near IR, white sky albedo", Unit: "%"},
+	{0, 19, 215}: {Abbreviation: "PRSVR", Name: "Total probability of severe thunderstorms (Days 2,3)", Unit: "%"},
+	{0, 19, 216}: {Abbreviation: "PRSIGSVR", Name: "Total probability of extreme severe thunderstorms (Days 2,3)", Unit: "%"},
+	{0, 19, 232}: {Abbreviation: "VAFTD", Name: "Volcanic ash forecast transport and dispersion", Unit: "log10(kg m-3)"},
+	{0, 19, 233}: {Abbreviation: "ICPRB", Name: "Icing probability", Unit: "Numeric"},
+	{0, 19, 234}: {Abbreviation: "ICSEV", Name: "Icing severity", Unit: "Numeric"},
+	{0, 19, 235}: {Abbreviation: "JFWPRB", Name: "Joint fire weather probability", Unit: "%"},
+	{0, 19, 236}: {Abbreviation: "SNOWLVL", Name: "Snow level", Unit: "m"},
+	{0, 19, 237}: {Abbreviation: "DRYTPROB", Name: "Dry thunderstorm probability", Unit: "%"},
+
+	// Discipline 0, category 191: miscellaneous
+	{0, 191, 192}: {Abbreviation: "NLAT", Name: "Latitude (-90 to +90)", Unit: "°", StandardName: "latitude"},
+	{0, 191, 193}: {Abbreviation: "ELON", Name: "East longitude (0 - 360)", Unit: "°", StandardName: "longitude"},
+	{0, 191, 194}: {Abbreviation: "TSEC", Name: "Seconds prior to initial reference time", Unit: "s"},
+	{0, 191, 195}: {Abbreviation: "MLYNO", Name: "Model layer number (from bottom up)", Unit: "Numeric"},
+	{0, 191, 196}: {Abbreviation: "NLATN", Name: "Latitude (nearest neighbor) (-90 to +90)", Unit: "°", StandardName: "latitude"},
+	{0, 191, 197}: {Abbreviation: "ELONN", Name: "East longitude (nearest neighbor) (0 - 360)", Unit: "°", StandardName: "longitude"},
+
+	// Discipline 1, category 0: hydrology basic products
+	{1, 0, 192}: {Abbreviation: "BGRUN", Name: "Baseflow-groundwater runoff", Unit: "kg m-2"},
+	{1, 0, 193}: {Abbreviation: "SSRUN", Name: "Storm surface runoff", Unit: "kg m-2"},
+
+	// Discipline 2, category 0: vegetation/biomass
+	{2, 0, 192}: {Abbreviation: "SOILW", Name: "Volumetric soil moisture content", Unit: "Proportion"},
+	{2, 0, 193}: {Abbreviation: "GFLUX", Name: "Ground heat flux", Unit: "W m-2"},
+	{2, 0, 194}: {Abbreviation: "MSTAV", Name: "Moisture availability", Unit: "%"},
+	{2, 0, 195}: {Abbreviation: "SFEXC", Name: "Exchange coefficient", Unit: "kg m-2 s-1"},
+	{2, 0, 196}: {Abbreviation: "CNWAT", Name: "Plant canopy surface water", Unit: "kg m-2", StandardName: "canopy_water_amount"},
+	{2, 0, 197}: {Abbreviation: "BMIXL", Name: "Blackadar's mixing length scale", Unit: "m"},
+	{2, 0, 198}: {Abbreviation: "VGTYP", Name: "Vegetation type", Unit: "Numeric"},
+	{2, 0, 199}: {Abbreviation: "CCOND", Name: "Canopy conductance", Unit: "m s-1"},
+	{2, 0, 200}: {Abbreviation: "RSMIN", Name: "Minimal stomatal resistance", Unit: "s m-1"},
+	{2, 0, 201}: {Abbreviation: "WILT", Name: "Wilting point", Unit: "Proportion"},
+	{2, 0, 202}: {Abbreviation: "RCS", Name: "Solar parameter in canopy conductance", Unit: "Proportion"},
+	{2, 0, 203}: {Abbreviation: "RCT", Name: "Temperature parameter in canopy conductance", Unit: "Proportion"},
+	{2, 0, 204}: {Abbreviation: "RCQ", Name: "Humidity parameter in canopy conductance", Unit: "Proportion"},
+	{2, 0, 205}: {Abbreviation: "RCSOL", Name: "Soil moisture parameter in canopy conductance", Unit: "Proportion"},
+	{2, 0, 206}: {Abbreviation: "RDRIP", Name: "Rate of water dropping from canopy to ground", Unit: "kg m-2 s-1"},
+	{2, 0, 207}: {Abbreviation: "ICWAT", Name: "Ice-free water surface", Unit: "%"},
+	{2, 0, 208}: {Abbreviation: "AKHS", Name: "Surface exchange coefficients for T and Q divided by delta z", Unit: "m s-1"},
+	{2, 0, 209}: {Abbreviation: "AKMS", Name: "Surface exchange coefficients for U and V divided by delta z", Unit: "m s-1"},
+	{2, 0, 210}: {Abbreviation: "VEGT", Name: "Vegetation canopy temperature", Unit: "K"},
+	{2, 0, 211}: {Abbreviation: "SSTOR", Name: "Surface water storage", Unit: "kg m-2"},
+	{2, 0, 212}: {Abbreviation: "LSOIL", Name: "Liquid soil moisture content (non-frozen)", Unit: "kg m-2"},
+	{2, 0, 213}: {Abbreviation: "EWATR", Name: "Open water evaporation (standing water)", Unit: "W m-2"},
+	{2, 0, 214}: {Abbreviation: "GWREC", Name: "Groundwater recharge", Unit: "kg m-2"},
+	{2, 0, 215}: {Abbreviation: "QREC", Name: "Flood plain recharge", Unit: "kg m-2"},
+	{2, 0, 216}: {Abbreviation: "SFCRH", Name: "Roughness length for heat", Unit: "m"},
+	{2, 0, 217}: {Abbreviation: "NDVI", Name: "Normalized difference vegetation index", Unit: "Numeric", StandardName: "normalized_difference_vegetation_index"},
+	{2, 0, 218}: {Abbreviation: "LANDN", Name: "Land-sea coverage (nearest neighbor) (land = 1, sea = 0)", Unit: "Proportion"},
+	{2, 0, 219}: {Abbreviation: "AMIXL", Name: "Asymptotic mixing length scale", Unit: "m"},
+
+	// Discipline 2, category 3: soil products
+	{2, 3, 192}: {Abbreviation: "SOILL", Name: "Liquid volumetric soil moisture (non-frozen)", Unit: "Proportion"},
+	{2, 3, 193}: {Abbreviation: "RLYRS", Name: "Number of soil layers in root zone", Unit: "Numeric"},
+	{2, 3, 194}: {Abbreviation: "SLTYP", Name: "Surface slope type", Unit: "Numeric"},
+	{2, 3, 195}: {Abbreviation: "SMREF", Name: "Transpiration stress-onset (soil moisture)", Unit: "Proportion"},
+	{2, 3, 196}: {Abbreviation: "SMDRY", Name: "Direct evaporation cease (soil moisture)", Unit: "Proportion"},
+	{2, 3, 197}: {Abbreviation: "POROS", Name: "Soil porosity", Unit: "Proportion"},
+	{2, 3, 198}: {Abbreviation: "EVBS", Name: "Direct evaporation from bare soil", Unit: "W m-2"},
+	{2, 3, 199}: {Abbreviation: "LSPA", Name: "Land surface precipitation accumulation", Unit: "kg m-2"},
+	{2, 3, 200}: {Abbreviation: "BARET", Name: "Bare soil surface skin temperature", Unit: "K"},
+	{2, 3, 201}: {Abbreviation: "AVSFT", Name: "Average surface skin temperature", Unit: "K"},
+	{2, 3, 202}: {Abbreviation: "RADT", Name: "Effective radiative skin temperature", Unit: "K"},
+	{2, 3, 203}: {Abbreviation: "FLDCP", Name: "Field capacity", Unit: "Proportion"},
+
+	// Discipline 10, category 0: waves
+	{10, 0, 192}: {Abbreviation: "WSTP", Name: "Wave steepness", Unit: "Proportion"},
+
+	// Discipline 10, category 1: currents
+	{10, 1, 192}: {Abbreviation: "OMLU", Name: "Ocean mixed layer U velocity", Unit: "m s-1"},
+	{10, 1, 193}: {Abbreviation: "OMLV", Name: "Ocean mixed layer V velocity", Unit: "m s-1"},
+	{10, 1, 194}: {Abbreviation: "UBARO", Name: "Barotropic U velocity", Unit: "m s-1"},
+	{10, 1, 195}: {Abbreviation: "VBARO", Name: "Barotropic V velocity", Unit: "m s-1"},
+
+	// Discipline 10, category 3: surface properties
+	{10, 3, 192}: {Abbreviation: "SURGE", Name: "Storm surge", Unit: "m"},
+	{10, 3, 193}: {Abbreviation: "ETSRG", Name: "Extra tropical storm surge", Unit: "m"},
+	{10, 3, 194}: {Abbreviation: "ELEV", Name: "Ocean surface elevation relative to geoid", Unit: "m"},
+	{10, 3, 195}: {Abbreviation: "SSHG", Name: "Sea surface height relative to geoid", Unit: "m"},
+	{10, 3, 196}: {Abbreviation: "P2OMLT", Name: "Ocean mixed layer potential density (reference 2000m)", Unit: "kg m-3"},
+	{10, 3, 197}: {Abbreviation: "AOHFLX", Name: "Net air-ocean heat flux", Unit: "W m-2"},
+	{10, 3, 198}: {Abbreviation: "ASHFL", Name: "Assimilative heat flux", Unit: "W m-2"},
+	{10, 3, 199}: {Abbreviation: "SSTT", Name: "Surface temperature trend", Unit: "K day-1"},
+	{10, 3, 200}: {Abbreviation: "SSST", Name: "Surface salinity trend", Unit: "psu day-1"},
+	{10, 3, 201}: {Abbreviation: "KENG", Name: "Kinetic energy", Unit: "J kg-1"},
+	{10, 3, 202}: {Abbreviation: "SLTFL", Name: "Salt flux", Unit: "kg m-2 s-1"},
+
+	// Discipline 10, category 4: sub-surface properties
+	{10, 4, 192}: {Abbreviation: "WTMPC", Name: "3-D temperature", Unit: "°C"},
+	{10, 4, 193}: {Abbreviation: "SALIN", Name: "3-D salinity", Unit: "psu"},
+	{10, 4, 194}: {Abbreviation: "BKENG", Name: "Barotropic kinetic energy", Unit: "J kg-1"},
+	{10, 4, 195}: {Abbreviation: "DBSS", Name: "Geometric depth below sea surface", Unit: "m"},
+	{10, 4, 196}: {Abbreviation: "INTFD", Name: "Interface depths", Unit: "m"},
+	{10, 4, 197}: {Abbreviation: "OHC", Name: "Ocean heat content", Unit: "J m-2"},
+}
+
+// ncepGeneratingProcesses holds commonly used entries of the NCEP generating process
+// identifiers (ON388 Table A)
+var ncepGeneratingProcesses = map[uint8]string{
+	10:  "Global Wind-Wave Forecast Model",
+	11:  "Global Multi-Grid Wave Model",
+	44:  "Sea Surface Temperature Analysis",
+	70:  "Quasi-Lagrangian Hurricane Model (QLM)",
+	81:  "Analysis from GFS (Global Forecast System)",
+	82:  "Analysis from GDAS (Global Data Assimilation System)",
+	83:  "High Resolution Rapid Refresh (HRRR)",
+	84:  "MESO NAM Model",
+	85:  "Real Time Ocean Forecast System (RTOFS)",
+	88:  "NOAA Wave Watch III (NWW3) Ocean Wave Model",
+	96:  "Global Forecast System Model (GFS)",
+	98:  "Climate Forecast System Model (CFS)",
+	104: "National Blend of Models (NBM)",
+	105: "Rapid Refresh (RAP)",
+	107: "Global Ensemble Forecast System (GEFS)",
+	108: "Localized Aviation MOS Program (LAMP)",
+	109: "Real Time Mesoscale Analysis (RTMA)",
+	110: "NAM Model - 15km version",
+	111: "NAM model, generic resolution",
+	113: "Products from NCEP SREF processing",
+	114: "NAEFS Products from joined NCEP, CMC global ensembles",
+	118: "UnRestricted Mesoscale Analysis (URMA)",
+	132: "High Resolution Ensemble Forecast (HREF)",
+	134: "Rapid Refresh Forecast System (RRFS)",
+	135: "Hurricane Analysis and Forecast System (HAFS)",
+	140: "North American Regional Reanalysis (NARR)",
+	199: "Climate Forecast System Reanalysis (CFSR)",
+}
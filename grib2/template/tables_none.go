@@ -0,0 +1,12 @@
+//go:build nointernaltables
+
+package template
+
+// builtinParameters is empty in builds with the nointernaltables tag
+var builtinParameters = map[parameterKey]ParameterInfo{}
+
+// ncepLocalParameters is empty in builds with the nointernaltables tag
+var ncepLocalParameters = map[parameterKey]ParameterInfo{}
+
+// ncepGeneratingProcesses is empty in builds with the nointernaltables tag
+var ncepGeneratingProcesses = map[uint8]string{}
@@ -0,0 +1,28 @@
+//go:build nointernaltables
+
+package template_test
+
+import (
+	"testing"
+
+	"github.com/scorix/grib/grib2/template"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNoInternalTables(t *testing.T) {
+	_, ok := template.LookupParameter(0, 0, 0)
+	assert.False(t, ok)
+	assert.Equal(t, "discipline 0 category 0 parameter 0", template.ParameterName(0, 0, 0))
+
+	_, ok = template.LookupCentreParameter(7, 1, 0, 1, 192)
+	assert.False(t, ok)
+
+	_, ok = template.LookupGeneratingProcess(7, 96)
+	assert.False(t, ok)
+
+	template.RegisterParameters(template.ParameterInfo{Abbreviation: "TMP", Name: "Temperature", Unit: "K"})
+	info, ok := template.LookupParameter(0, 0, 0)
+	require.True(t, ok)
+	assert.Equal(t, "TMP", info.Abbreviation)
+}
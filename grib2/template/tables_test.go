@@ -0,0 +1,120 @@
+//go:build !nointernaltables
+
+package template_test
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/scorix/grib/grib2/template"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParameterName(t *testing.T) {
+	assert.Equal(t, "Temperature", template.ParameterName(0, 0, 0))
+	assert.Equal(t, "discipline 10 category 3 parameter 200", template.ParameterName(10, 3, 200))
+}
+
+func TestLookupParameter(t *testing.T) {
+	tests := []struct {
+		discipline, category, number uint8
+		want                         template.ParameterInfo
+	}{
+		{0, 3, 1, template.ParameterInfo{Abbreviation: "PRMSL", Name: "Pressure reduced to MSL", Unit: "Pa", StandardName: "air_pressure_at_mean_sea_level"}},
+		{0, 1, 19, template.ParameterInfo{Abbreviation: "PTYPE", Name: "Precipitation type", Unit: "Code table 4.201"}},
+		{0, 7, 7, template.ParameterInfo{Abbreviation: "CIN", Name: "Convective inhibition", Unit: "J kg-1", StandardName: "atmosphere_convective_inhibition"}},
+		{2, 0, 2, template.ParameterInfo{Abbreviation: "TSOIL", Name: "Soil temperature", Unit: "K", StandardName: "soil_temperature"}},
+		{10, 0, 3, template.ParameterInfo{Abbreviation: "HTSGW", Name: "Significant height of combined wind waves and swell", Unit: "m", StandardName: "sea_surface_wave_significant_height"}},
+	}
+	for _, tt := range tests {
+		info, ok := template.LookupParameter(tt.discipline, tt.category, tt.number)
+		require.True(t, ok, "%d %d %d", tt.discipline, tt.category, tt.number)
+		tt.want.Discipline, tt.want.Category, tt.want.Number = tt.discipline, tt.category, tt.number
+		assert.Equal(t, tt.want, info)
+		assert.False(t, info.IsLocal())
+	}
+
+	// Local parameters are not in the WMO table
+	_, ok := template.LookupParameter(0, 1, 192)
+	assert.False(t, ok)
+	_, ok = template.LookupParameter(0, 250, 0)
+	assert.False(t, ok)
+}
+
+func TestIsLocalParameter(t *testing.T) {
+	assert.False(t, template.IsLocalParameter(0, 191, 191))
+	assert.True(t, template.IsLocalParameter(0, 1, 192))
+	assert.True(t, template.IsLocalParameter(0, 192, 0))
+	assert.True(t, template.IsLocalParameter(192, 0, 0))
+	assert.True(t, template.ParameterInfo{Category: 16, Number: 196}.IsLocal())
+}
+
+func TestLookupCentreParameter(t *testing.T) {
+	// NCEP categorical rain, in the local range of the moisture category
+	info, ok := template.LookupCentreParameter(7, 1, 0, 1, 192)
+	require.True(t, ok)
+	assert.Equal(t, template.ParameterInfo{Discipline: 0, Category: 1, Number: 192, Abbreviation: "CRAIN", Name: "Categorical rain", Unit: "Code table 4.222"}, info)
+	assert.True(t, info.IsLocal())
+
+	// The master table comes first
+	info, ok = template.LookupCentreParameter(7, 1, 0, 0, 0)
+	require.True(t, ok)
+	assert.Equal(t, "TMP", info.Abbreviation)
+
+	// Not for messages without local tables, nor from other centres
+	_, ok = template.LookupCentreParameter(7, 0, 0, 1, 192)
+	assert.False(t, ok)
+	_, ok = template.LookupCentreParameter(7, 255, 0, 1, 192)
+	assert.False(t, ok)
+	_, ok = template.LookupCentreParameter(98, 1, 0, 1, 192)
+	assert.False(t, ok)
+}
+
+func TestRegisterLocalParameters(t *testing.T) {
+	template.RegisterLocalParameters(78, template.ParameterInfo{Discipline: 0, Category: 1, Number: 192, Abbreviation: "LOCAL78", Name: "DWD local parameter", Unit: "1"})
+
+	info, ok := template.LookupCentreParameter(78, 1, 0, 1, 192)
+	require.True(t, ok)
+	assert.Equal(t, "LOCAL78", info.Abbreviation)
+
+	// The other centres keep their own tables
+	info, ok = template.LookupCentreParameter(7, 1, 0, 1, 192)
+	require.True(t, ok)
+	assert.Equal(t, "CRAIN", info.Abbreviation)
+	_, ok = template.LookupParameter(0, 1, 192)
+	assert.False(t, ok)
+}
+
+func TestRegisterParameters(t *testing.T) {
+	template.RegisterParameters(template.ParameterInfo{Discipline: 0, Category: 191, Number: 7, Abbreviation: "LOCAL", Name: "Local parameter", Unit: "1"})
+
+	info, ok := template.LookupParameter(0, 191, 7)
+	require.True(t, ok)
+	assert.Equal(t, "LOCAL", info.Abbreviation)
+	assert.Equal(t, "Local parameter", template.ParameterName(0, 191, 7))
+}
+
+// TestWithoutInternalTables builds the module with the nointernaltables tag and runs the
+// tests of this package and of the reader in that build
+func TestWithoutInternalTables(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds the module again")
+	}
+	gobin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go command not found")
+	}
+
+	for _, args := range [][]string{
+		{"build", "-tags", "nointernaltables", "./..."},
+		{"test", "-count=1", "-tags", "nointernaltables", "./template", "./reader"},
+	} {
+		cmd := exec.Command(gobin, args...)
+		cmd.Dir = ".."
+		cmd.Env = os.Environ()
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, "go %v:\n%s", args, out)
+	}
+}
@@ -0,0 +1,67 @@
+package template
+
+import "time"
+
+// Indicator of unit of time range (Code Table 4.4)
+const (
+	TimeUnitMinute  uint8 = 0   // Minute
+	TimeUnitHour    uint8 = 1   // Hour
+	TimeUnitDay     uint8 = 2   // Day
+	TimeUnitMonth   uint8 = 3   // Month
+	TimeUnitYear    uint8 = 4   // Year
+	TimeUnitDecade  uint8 = 5   // Decade (10 years)
+	TimeUnitNormal  uint8 = 6   // Normal (30 years)
+	TimeUnitCentury uint8 = 7   // Century (100 years)
+	TimeUnit3Hours  uint8 = 10  // 3 hours
+	TimeUnit6Hours  uint8 = 11  // 6 hours
+	TimeUnit12Hours uint8 = 12  // 12 hours
+	TimeUnitSecond  uint8 = 13  // Second
+	TimeUnitMissing uint8 = 255 // Missing
+)
+
+// TimeUnitDuration returns the fixed length of one unit of Code Table 4.4.
+// Calendar based units (month, year, decade, normal, century) have no fixed
+// length and report false, as do reserved and missing values.
+func TimeUnitDuration(unit uint8) (time.Duration, bool) {
+	switch unit {
+	case TimeUnitMinute:
+		return time.Minute, true
+	case TimeUnitHour:
+		return time.Hour, true
+	case TimeUnitDay:
+		return 24 * time.Hour, true
+	case TimeUnit3Hours:
+		return 3 * time.Hour, true
+	case TimeUnit6Hours:
+		return 6 * time.Hour, true
+	case TimeUnit12Hours:
+		return 12 * time.Hour, true
+	case TimeUnitSecond:
+		return time.Second, true
+	default:
+		return 0, false
+	}
+}
+
+// AddTimeUnits adds n units of Code Table 4.4 to t. Calendar based units are added as
+// months and years with time.AddDate, so one month after January 31 is March 2 or 3, as
+// with AddDate. Reserved and missing units report false.
+func AddTimeUnits(t time.Time, unit uint8, n uint32) (time.Time, bool) {
+	if d, ok := TimeUnitDuration(unit); ok {
+		return t.Add(time.Duration(n) * d), true
+	}
+	switch unit {
+	case TimeUnitMonth:
+		return t.AddDate(0, int(n), 0), true
+	case TimeUnitYear:
+		return t.AddDate(int(n), 0, 0), true
+	case TimeUnitDecade:
+		return t.AddDate(10*int(n), 0, 0), true
+	case TimeUnitNormal:
+		return t.AddDate(30*int(n), 0, 0), true
+	case TimeUnitCentury:
+		return t.AddDate(100*int(n), 0, 0), true
+	default:
+		return time.Time{}, false
+	}
+}
@@ -0,0 +1,43 @@
+package template_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/scorix/grib/grib2/template"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddTimeUnits(t *testing.T) {
+	start := time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		unit uint8
+		n    uint32
+		want time.Time
+	}{
+		{template.TimeUnitMinute, 90, time.Date(2024, 1, 31, 13, 30, 0, 0, time.UTC)},
+		{template.TimeUnitHour, 36, time.Date(2024, 2, 2, 0, 0, 0, 0, time.UTC)},
+		{template.TimeUnitDay, 30, time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)},
+		{template.TimeUnit3Hours, 4, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{template.TimeUnit6Hours, 2, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{template.TimeUnit12Hours, 3, time.Date(2024, 2, 2, 0, 0, 0, 0, time.UTC)},
+		{template.TimeUnitSecond, 45, time.Date(2024, 1, 31, 12, 0, 45, 0, time.UTC)},
+		// January 31 plus a month overflows February, as with time.AddDate
+		{template.TimeUnitMonth, 1, time.Date(2024, 3, 2, 12, 0, 0, 0, time.UTC)},
+		{template.TimeUnitMonth, 2, time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC)},
+		{template.TimeUnitYear, 1, time.Date(2025, 1, 31, 12, 0, 0, 0, time.UTC)},
+		{template.TimeUnitDecade, 2, time.Date(2044, 1, 31, 12, 0, 0, 0, time.UTC)},
+		{template.TimeUnitNormal, 1, time.Date(2054, 1, 31, 12, 0, 0, 0, time.UTC)},
+		{template.TimeUnitCentury, 1, time.Date(2124, 1, 31, 12, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, ok := template.AddTimeUnits(start, tt.unit, tt.n)
+		assert.True(t, ok, "unit %d", tt.unit)
+		assert.Equal(t, tt.want, got, "unit %d", tt.unit)
+	}
+
+	for _, unit := range []uint8{8, 9, 14, 191, template.TimeUnitMissing} {
+		_, ok := template.AddTimeUnits(start, unit, 1)
+		assert.False(t, ok, "unit %d", unit)
+	}
+}
@@ -0,0 +1,57 @@
+package units
+
+// GRIB2 codes signed integers with the most significant bit as the sign and the
+// remaining bits as the magnitude, not in two's complement. A negative zero decodes
+// as 0, and the most negative two's complement value of each width, which has no
+// sign-and-magnitude form, encodes as the largest representable magnitude.
+
+// SignMagnitudeInt8 decodes a 1 octet sign-and-magnitude integer
+func SignMagnitudeInt8(v uint8) int8 {
+	magnitude := int8(v & 0x7f)
+	if v&0x80 != 0 {
+		return -magnitude
+	}
+	return magnitude
+}
+
+// SignMagnitudeInt16 decodes a 2 octet sign-and-magnitude integer
+func SignMagnitudeInt16(v uint16) int16 {
+	magnitude := int16(v & 0x7fff)
+	if v&0x8000 != 0 {
+		return -magnitude
+	}
+	return magnitude
+}
+
+// SignMagnitudeInt32 decodes a 4 octet sign-and-magnitude integer
+func SignMagnitudeInt32(v uint32) int32 {
+	magnitude := int32(v & 0x7fffffff)
+	if v&0x80000000 != 0 {
+		return -magnitude
+	}
+	return magnitude
+}
+
+// EncodeSignMagnitudeInt8 encodes v as a 1 octet sign-and-magnitude integer
+func EncodeSignMagnitudeInt8(v int8) uint8 {
+	if v < 0 {
+		return 0x80 | uint8(min(-int16(v), 0x7f))
+	}
+	return uint8(v)
+}
+
+// EncodeSignMagnitudeInt16 encodes v as a 2 octet sign-and-magnitude integer
+func EncodeSignMagnitudeInt16(v int16) uint16 {
+	if v < 0 {
+		return 0x8000 | uint16(min(-int32(v), 0x7fff))
+	}
+	return uint16(v)
+}
+
+// EncodeSignMagnitudeInt32 encodes v as a 4 octet sign-and-magnitude integer
+func EncodeSignMagnitudeInt32(v int32) uint32 {
+	if v < 0 {
+		return 0x80000000 | uint32(min(-int64(v), 0x7fffffff))
+	}
+	return uint32(v)
+}
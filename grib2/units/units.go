@@ -0,0 +1,68 @@
+// Package units converts between the coded integers of GRIB2 templates and the
+// physical values they represent: scaled values, microdegree angles and longitudes, and
+// sign-and-magnitude integers.
+package units
+
+import "math"
+
+// missingScaledValue is the coded scaled value meaning "missing"
+const missingScaledValue = 0xffffffff
+
+// ScaledValue computes scaledValue × 10^-scaleFactor, the value of a GRIB2 scale factor
+// and scaled value pair. The scale factor must already be decoded from its
+// sign-and-magnitude octet, see SignMagnitudeInt8. A missing (all ones) scaled value
+// yields NaN.
+func ScaledValue(scaleFactor int8, scaledValue uint32) float64 {
+	if scaledValue == missingScaledValue {
+		return math.NaN()
+	}
+
+	// Dividing by an exact power of ten keeps values such as 0.1 exact where multiplying
+	// by its inexact inverse would not
+	if scaleFactor >= 0 {
+		return float64(scaledValue) / math.Pow10(int(scaleFactor))
+	}
+	return float64(scaledValue) * math.Pow10(-int(scaleFactor))
+}
+
+// Microdegrees converts an angle in the 10^-6 degree units of grid templates into degrees
+func Microdegrees(v int32) float64 {
+	return float64(v) / 1e6
+}
+
+// DegreesToMicro converts an angle in degrees into 10^-6 degree units, rounding to the
+// nearest unit and saturating at the limits of int32
+func DegreesToMicro(degrees float64) int32 {
+	micro := math.Round(degrees * 1e6)
+	switch {
+	case micro >= math.MaxInt32:
+		return math.MaxInt32
+	case micro <= math.MinInt32:
+		return math.MinInt32
+	default:
+		return int32(micro)
+	}
+}
+
+// NormalizeLongitude maps a longitude in degrees into [0, 360)
+func NormalizeLongitude(lon float64) float64 {
+	lon = math.Mod(lon, 360)
+	if lon < 0 {
+		lon += 360
+	}
+	if lon == 360 {
+		// math.Mod of a tiny negative value can round back up to 360
+		lon = 0
+	}
+	return lon
+}
+
+// LongitudeToMicro converts a longitude in degrees into 10^-6 degree units in [0, 360e6),
+// as grid templates code longitudes. Longitudes that round to 360 degrees wrap to 0.
+func LongitudeToMicro(lon float64) uint32 {
+	micro := math.Round(NormalizeLongitude(lon) * 1e6)
+	if micro >= 360e6 {
+		micro -= 360e6
+	}
+	return uint32(micro)
+}
@@ -0,0 +1,160 @@
+package units_test
+
+import (
+	"math"
+	"testing"
+	"testing/quick"
+
+	"github.com/scorix/grib/grib2/units"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScaledValue(t *testing.T) {
+	tests := []struct {
+		name        string
+		scaleFactor int8
+		scaledValue uint32
+		want        float64
+	}{
+		{name: "unscaled", scaleFactor: 0, scaledValue: 500, want: 500},
+		{name: "positive scale factor", scaleFactor: 1, scaledValue: 1, want: 0.1},
+		{name: "two decimals", scaleFactor: 2, scaledValue: 15, want: 0.15},
+		{name: "scale factor -1", scaleFactor: -1, scaledValue: 5, want: 50},
+		{name: "scale factor -2", scaleFactor: -2, scaledValue: 850, want: 85000},
+		{name: "zero", scaleFactor: 3, scaledValue: 0, want: 0},
+		{name: "largest value", scaleFactor: 0, scaledValue: 0xfffffffe, want: 4294967294},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, units.ScaledValue(tt.scaleFactor, tt.scaledValue))
+		})
+	}
+
+	t.Run("missing value", func(t *testing.T) {
+		assert.True(t, math.IsNaN(units.ScaledValue(0, 0xffffffff)))
+		assert.True(t, math.IsNaN(units.ScaledValue(-1, 0xffffffff)))
+	})
+}
+
+func TestMicrodegrees(t *testing.T) {
+	tests := []struct {
+		micro   int32
+		degrees float64
+	}{
+		{micro: 0, degrees: 0},
+		{micro: 90000000, degrees: 90},
+		{micro: -90000000, degrees: -90},
+		{micro: 359750000, degrees: 359.75},
+		{micro: 250000, degrees: 0.25},
+		{micro: -1, degrees: -0.000001},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.degrees, units.Microdegrees(tt.micro), "Microdegrees(%d)", tt.micro)
+		assert.Equal(t, tt.micro, units.DegreesToMicro(tt.degrees), "DegreesToMicro(%g)", tt.degrees)
+	}
+
+	assert.Equal(t, int32(1), units.DegreesToMicro(0.0000005), "rounds half away from zero")
+	assert.Equal(t, int32(math.MaxInt32), units.DegreesToMicro(1e6))
+	assert.Equal(t, int32(math.MinInt32), units.DegreesToMicro(-1e6))
+}
+
+func TestMicrodegreesRoundTrip(t *testing.T) {
+	require.NoError(t, quick.Check(func(v int32) bool {
+		return units.DegreesToMicro(units.Microdegrees(v)) == v
+	}, nil))
+}
+
+func TestLongitudeToMicro(t *testing.T) {
+	tests := []struct {
+		lon   float64
+		micro uint32
+	}{
+		{lon: 0, micro: 0},
+		{lon: -10, micro: 350000000},
+		{lon: 359.75, micro: 359750000},
+		{lon: 360, micro: 0},
+		{lon: 725.5, micro: 5500000},
+		{lon: -1e-10, micro: 0},      // math.Mod rounds back up to 360
+		{lon: 359.9999996, micro: 0}, // rounds up to 360 microdegrees
+		{lon: -0.0000004, micro: 0},  // rounds up to 360 microdegrees after the wrap
+		{lon: -0.0000006, micro: 359999999},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.micro, units.LongitudeToMicro(tt.lon), "LongitudeToMicro(%g)", tt.lon)
+		lon := units.NormalizeLongitude(tt.lon)
+		assert.True(t, lon >= 0 && lon < 360, "NormalizeLongitude(%g) = %g", tt.lon, lon)
+	}
+}
+
+func TestSignMagnitude(t *testing.T) {
+	tests := []struct {
+		name string
+		v    int32
+		raw8 uint8
+		raw  uint32
+	}{
+		{name: "zero", v: 0, raw8: 0x00, raw: 0x00000000},
+		{name: "one", v: 1, raw8: 0x01, raw: 0x00000001},
+		{name: "minus one", v: -1, raw8: 0x81, raw: 0x80000001},
+		{name: "minus two", v: -2, raw8: 0x82, raw: 0x80000002},
+		{name: "largest", v: 127, raw8: 0x7f, raw: 0x0000007f},
+		{name: "smallest", v: -127, raw8: 0xff, raw: 0x8000007f},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, int8(tt.v), units.SignMagnitudeInt8(tt.raw8))
+			assert.Equal(t, tt.raw8, units.EncodeSignMagnitudeInt8(int8(tt.v)))
+
+			raw16 := uint16(tt.raw&0x7fff) | uint16(tt.raw>>16&0x8000)
+			assert.Equal(t, int16(tt.v), units.SignMagnitudeInt16(raw16))
+			assert.Equal(t, raw16, units.EncodeSignMagnitudeInt16(int16(tt.v)))
+
+			assert.Equal(t, tt.v, units.SignMagnitudeInt32(tt.raw))
+			assert.Equal(t, tt.raw, units.EncodeSignMagnitudeInt32(tt.v))
+		})
+	}
+
+	t.Run("decimal scale factor -2 is not two's complement", func(t *testing.T) {
+		assert.Equal(t, int16(-2), units.SignMagnitudeInt16(0x8002))
+	})
+
+	t.Run("negative zero", func(t *testing.T) {
+		assert.Equal(t, int8(0), units.SignMagnitudeInt8(0x80))
+		assert.Equal(t, int16(0), units.SignMagnitudeInt16(0x8000))
+		assert.Equal(t, int32(0), units.SignMagnitudeInt32(0x80000000))
+	})
+
+	t.Run("all ones", func(t *testing.T) {
+		// The missing value of a signed octet field decodes as the smallest value
+		assert.Equal(t, int8(-127), units.SignMagnitudeInt8(0xff))
+		assert.Equal(t, int16(-32767), units.SignMagnitudeInt16(0xffff))
+		assert.Equal(t, int32(-math.MaxInt32), units.SignMagnitudeInt32(0xffffffff))
+	})
+
+	t.Run("most negative value saturates", func(t *testing.T) {
+		assert.Equal(t, uint8(0xff), units.EncodeSignMagnitudeInt8(math.MinInt8))
+		assert.Equal(t, uint16(0xffff), units.EncodeSignMagnitudeInt16(math.MinInt16))
+		assert.Equal(t, uint32(0xffffffff), units.EncodeSignMagnitudeInt32(math.MinInt32))
+	})
+}
+
+func TestSignMagnitudeRoundTrip(t *testing.T) {
+	require.NoError(t, quick.Check(func(v int8) bool {
+		return v == math.MinInt8 || units.SignMagnitudeInt8(units.EncodeSignMagnitudeInt8(v)) == v
+	}, nil))
+	require.NoError(t, quick.Check(func(v int16) bool {
+		return v == math.MinInt16 || units.SignMagnitudeInt16(units.EncodeSignMagnitudeInt16(v)) == v
+	}, nil))
+	require.NoError(t, quick.Check(func(v int32) bool {
+		return v == math.MinInt32 || units.SignMagnitudeInt32(units.EncodeSignMagnitudeInt32(v)) == v
+	}, nil))
+	require.NoError(t, quick.Check(func(raw uint32) bool {
+		// Every coding except negative zero survives decoding and re-encoding
+		return raw == 0x80000000 || units.EncodeSignMagnitudeInt32(units.SignMagnitudeInt32(raw)) == raw
+	}, nil))
+}
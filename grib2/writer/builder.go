@@ -0,0 +1,402 @@
+package writer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/scorix/grib/grib2/packing"
+	"github.com/scorix/grib/grib2/section"
+	"github.com/scorix/grib/grib2/spec"
+	"github.com/scorix/grib/grib2/template"
+	"github.com/scorix/grib/grib2/units"
+)
+
+// LatLonGrid configures the regular latitude/longitude grid (template 3.0) of a MessageBuilder
+type LatLonGrid struct {
+	Ni, Nj       int     // Number of points along a parallel and along a meridian
+	LatFirst     float64 // Latitude of the first grid point in degrees
+	LonFirst     float64 // Longitude of the first grid point in degrees
+	DLat, DLon   float64 // Increments between rows and columns in degrees, positive; the scanning mode gives their direction
+	ScanningMode uint8   // Scanning mode flags (Flag Table 3.4); 0 scans eastwards, then southwards
+	ShapeOfEarth uint8   // Shape of the Earth (Code Table 3.2), one with predefined axes
+}
+
+// Product configures the analysis or forecast at a horizontal level (template 4.0) of a
+// MessageBuilder
+type Product struct {
+	Category          uint8         // Parameter category (Code Table 4.1)
+	Number            uint8         // Parameter number (Code Table 4.2)
+	GeneratingProcess uint8         // Type of generating process (Code Table 4.3)
+	ProcessIdentifier uint8         // Analysis or forecast generating process identifier, defined by the centre
+	ForecastTime      time.Duration // Forecast time after the reference time, in whole seconds
+
+	// FirstSurface is the first fixed surface, whose type must be set; SecondSurface is
+	// missing when its type is 0. Surface values must be positive or zero.
+	FirstSurface, SecondSurface template.FixedSurface
+}
+
+// MessageBuilder assembles a single field message on a regular latitude/longitude grid,
+// packed with simple packing, e.g. to write a field derived from decoded ones:
+//
+//	msg, err := writer.NewMessageBuilder().
+//		Discipline(0).Centre(7).ReferenceTime(t).
+//		LatLonGrid(g).Product(p).Values(values).
+//		Build()
+//
+// The setters record their arguments; Build checks them for consistency.
+type MessageBuilder struct {
+	discipline     uint8
+	centre         uint16
+	subCentre      uint16
+	tablesVersion  [2]uint8
+	referenceTime  time.Time
+	status         uint8
+	grid           *LatLonGrid
+	product        *Product
+	values         []float64
+	packing        func(values []float64) (packing.PackingParams, error)
+	packingChoices int
+}
+
+// NewMessageBuilder returns a builder with master tables version 2, no local tables and
+// operational products, whose values are packed at the finest step that fits 32 bits unless
+// Precision or Packing is set
+func NewMessageBuilder() *MessageBuilder {
+	return &MessageBuilder{
+		tablesVersion: [2]uint8{2, 0},
+		packing: func(values []float64) (packing.PackingParams, error) {
+			return packing.ChoosePacking(values, 0), nil
+		},
+	}
+}
+
+// Discipline sets the discipline of the message (Code Table 0.0)
+func (b *MessageBuilder) Discipline(discipline uint8) *MessageBuilder {
+	b.discipline = discipline
+	return b
+}
+
+// Centre sets the originating centre (Common Code Table C-11)
+func (b *MessageBuilder) Centre(centre uint16) *MessageBuilder {
+	b.centre = centre
+	return b
+}
+
+// SubCentre sets the originating sub-centre
+func (b *MessageBuilder) SubCentre(subCentre uint16) *MessageBuilder {
+	b.subCentre = subCentre
+	return b
+}
+
+// TablesVersion sets the versions of the GRIB master tables and local tables
+func (b *MessageBuilder) TablesVersion(master, local uint8) *MessageBuilder {
+	b.tablesVersion = [2]uint8{master, local}
+	return b
+}
+
+// ReferenceTime sets the reference time, the start of the forecast, written in UTC to the second
+func (b *MessageBuilder) ReferenceTime(t time.Time) *MessageBuilder {
+	b.referenceTime = t
+	return b
+}
+
+// ProductionStatus sets the production status of the data (Code Table 1.3)
+func (b *MessageBuilder) ProductionStatus(status uint8) *MessageBuilder {
+	b.status = status
+	return b
+}
+
+// LatLonGrid sets the grid of the field
+func (b *MessageBuilder) LatLonGrid(g LatLonGrid) *MessageBuilder {
+	b.grid = &g
+	return b
+}
+
+// Product sets the product of the field
+func (b *MessageBuilder) Product(p Product) *MessageBuilder {
+	b.product = &p
+	return b
+}
+
+// Values sets the values of the field, one per grid point in scanning order; NaN values
+// are missing and written with a bit-map
+func (b *MessageBuilder) Values(values []float64) *MessageBuilder {
+	b.values = values
+	return b
+}
+
+// Precision packs the values within precision of their decoded values, see packing.ChoosePacking
+func (b *MessageBuilder) Precision(precision float64) *MessageBuilder {
+	b.packingChoices++
+	b.packing = func(values []float64) (packing.PackingParams, error) {
+		return packing.ChoosePacking(values, precision), nil
+	}
+	return b
+}
+
+// Packing packs the values with the decimal scale factor and bits per value given, or the
+// fewest bits for their range when bitsPerValue is 0, see packing.ChoosePackingBits
+func (b *MessageBuilder) Packing(decimalScale int16, bitsPerValue uint8) *MessageBuilder {
+	b.packingChoices++
+	b.packing = func(values []float64) (packing.PackingParams, error) {
+		return packing.ChoosePackingBits(values, decimalScale, bitsPerValue)
+	}
+	return b
+}
+
+// Build checks the settings for consistency and encodes the message, to be written with
+// WriteMessage. All the problems found are reported together.
+func (b *MessageBuilder) Build() (*spec.Message, error) {
+	var errs []error
+	if b.packingChoices > 1 {
+		errs = append(errs, errors.New("both Precision and Packing are set"))
+	}
+	identification, err := b.identification()
+	errs = append(errs, err)
+	gridDef, err := b.gridDefinition()
+	errs = append(errs, err)
+	productDef, err := b.productDefinition()
+	errs = append(errs, err)
+	if b.grid != nil && len(b.values) != b.grid.Ni*b.grid.Nj {
+		errs = append(errs, fmt.Errorf("%d values for a %dx%d grid", len(b.values), b.grid.Ni, b.grid.Nj))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("writer: build: %w", err)
+	}
+
+	params, err := b.packing(b.values)
+	if err != nil {
+		return nil, fmt.Errorf("writer: build: %w", err)
+	}
+	field, err := packing.EncodeSimpleParams(b.values, params)
+	if err != nil {
+		return nil, fmt.Errorf("writer: build: %w", err)
+	}
+
+	payloads := [][]byte{
+		identification,
+		gridDef,
+		productDef,
+		field.DataRepresentationPayload(),
+		field.BitmapPayload(),
+	}
+	totalLength := 16 + 4 + sectionHeaderLength + len(field.Data)
+	for _, payload := range payloads {
+		totalLength += sectionHeaderLength + len(payload)
+	}
+
+	msg := &spec.Message{}
+	indicator := []byte{'G', 'R', 'I', 'B', 0, 0, b.discipline, 2}
+	if msg.Indicator, err = section.NewSection0FromBytes(binary.BigEndian.AppendUint64(indicator, uint64(totalLength))); err != nil {
+		return nil, fmt.Errorf("writer: build: %w", err)
+	}
+	sections := make([]section.Section, len(payloads))
+	for k, payload := range payloads {
+		data := append(binary.BigEndian.AppendUint32(nil, uint32(sectionHeaderLength+len(payload))), uint8(k+1))
+		if k > 0 {
+			data[4]++ // No Section 2
+		}
+		data = append(data, payload...)
+
+		var sec section.Section
+		switch data[4] {
+		case 1:
+			sec, err = section.NewSection1FromBytes(data, false)
+		case 3:
+			sec, err = section.NewSection3FromBytes(data)
+		case 4:
+			sec, err = section.NewSection4FromBytes(data)
+		case 5:
+			sec, err = section.NewSection5FromBytes(data)
+		case 6:
+			sec, err = section.NewSection6FromBytes(data)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("writer: build: %w", err)
+		}
+		sections[k] = sec
+	}
+	msg.Identification = sections[0].(section.Section1)
+	msg.Blocks = []spec.LocalBlock{{
+		Grids: []spec.GridBlock{{
+			GridDef: sections[1].(section.Section3),
+			Fields: []spec.DataField{{
+				ProductDef: sections[2].(section.Section4),
+				DataRep:    sections[3].(section.Section5),
+				Bitmap:     sections[4].(section.Section6),
+				Data:       section.NewSection7FromDataReaderAt(uint32(sectionHeaderLength+len(field.Data)), bytes.NewReader(field.Data)),
+			}},
+		}},
+	}}
+	if msg.End, err = section.NewSection8FromBytes([]byte{'7', '7', '7', '7'}); err != nil {
+		return nil, fmt.Errorf("writer: build: %w", err)
+	}
+
+	if err := msg.Validate(); err != nil {
+		return nil, fmt.Errorf("writer: build: %w", err)
+	}
+	return msg, nil
+}
+
+// identification returns the Section 1 payload
+func (b *MessageBuilder) identification() ([]byte, error) {
+	if b.referenceTime.IsZero() {
+		return nil, errors.New("reference time is not set")
+	}
+	t := b.referenceTime.UTC()
+	if t.Year() < 0 || t.Year() > math.MaxUint16 {
+		return nil, fmt.Errorf("reference time %s is out of range", t)
+	}
+
+	// Analysis or forecast, from the type of generating process
+	dataType := uint8(1)
+	if b.product != nil && b.product.GeneratingProcess == 0 {
+		dataType = 0
+	}
+
+	payload := binary.BigEndian.AppendUint16(nil, b.centre)
+	payload = binary.BigEndian.AppendUint16(payload, b.subCentre)
+	payload = append(payload, b.tablesVersion[0], b.tablesVersion[1], 1) // Significance: start of forecast
+	payload = binary.BigEndian.AppendUint16(payload, uint16(t.Year()))
+	payload = append(payload, uint8(t.Month()), uint8(t.Day()), uint8(t.Hour()), uint8(t.Minute()), uint8(t.Second()))
+	return append(payload, b.status, dataType), nil
+}
+
+// gridDefinition returns the Section 3 payload of the lat/lon grid
+func (b *MessageBuilder) gridDefinition() ([]byte, error) {
+	g := b.grid
+	if g == nil {
+		return nil, errors.New("grid is not set")
+	}
+	var errs []error
+	if g.Ni <= 0 || g.Nj <= 0 || uint64(g.Ni)*uint64(g.Nj) > math.MaxUint32 {
+		errs = append(errs, fmt.Errorf("invalid grid dimensions %dx%d", g.Ni, g.Nj))
+	}
+	if !(g.DLat > 0 && g.DLon > 0) {
+		errs = append(errs, fmt.Errorf("grid increments %g and %g are not positive", g.DLat, g.DLon))
+	}
+	switch g.ShapeOfEarth {
+	case 1, 3, 7:
+		errs = append(errs, fmt.Errorf("shape of the earth %d needs axes, which are not supported", g.ShapeOfEarth))
+	}
+
+	latLast := g.LatFirst - float64(g.Nj-1)*g.DLat
+	if g.ScanningMode&0x40 != 0 {
+		latLast = g.LatFirst + float64(g.Nj-1)*g.DLat
+	}
+	lonLast := g.LonFirst + float64(g.Ni-1)*g.DLon
+	if g.ScanningMode&0x80 != 0 {
+		lonLast = g.LonFirst - float64(g.Ni-1)*g.DLon
+	}
+	for _, lat := range []float64{g.LatFirst, latLast} {
+		if !(lat >= -90 && lat <= 90) {
+			errs = append(errs, fmt.Errorf("grid latitude %g is out of range", lat))
+			break
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	payload := []byte{0} // Source: specified in Code Table 3.1
+	payload = binary.BigEndian.AppendUint32(payload, uint32(g.Ni*g.Nj))
+	payload = append(payload, 0, 0) // No optional list
+	payload = binary.BigEndian.AppendUint16(payload, 0)
+
+	payload = append(payload, g.ShapeOfEarth)
+	for range 3 {
+		// Radius, major and minor axes: missing
+		payload = append(payload, 0xff, 0xff, 0xff, 0xff, 0xff)
+	}
+	payload = binary.BigEndian.AppendUint32(payload, uint32(g.Ni))
+	payload = binary.BigEndian.AppendUint32(payload, uint32(g.Nj))
+	payload = binary.BigEndian.AppendUint32(payload, 0)          // Basic angle: microdegrees
+	payload = binary.BigEndian.AppendUint32(payload, 0xffffffff) // Subdivisions of basic angle: missing
+	payload = binary.BigEndian.AppendUint32(payload, units.EncodeSignMagnitudeInt32(units.DegreesToMicro(g.LatFirst)))
+	payload = binary.BigEndian.AppendUint32(payload, units.LongitudeToMicro(g.LonFirst))
+	payload = append(payload, 0x30) // Resolution and component flags: increments given
+	payload = binary.BigEndian.AppendUint32(payload, units.EncodeSignMagnitudeInt32(units.DegreesToMicro(latLast)))
+	payload = binary.BigEndian.AppendUint32(payload, units.LongitudeToMicro(lonLast))
+	payload = binary.BigEndian.AppendUint32(payload, uint32(units.DegreesToMicro(g.DLon)))
+	payload = binary.BigEndian.AppendUint32(payload, uint32(units.DegreesToMicro(g.DLat)))
+	return append(payload, g.ScanningMode), nil
+}
+
+// productDefinition returns the Section 4 payload of product template 4.0
+func (b *MessageBuilder) productDefinition() ([]byte, error) {
+	p := b.product
+	if p == nil {
+		return nil, errors.New("product is not set")
+	}
+	var errs []error
+	unit, forecast, err := forecastTime(p.ForecastTime)
+	errs = append(errs, err)
+	if p.FirstSurface.Type == 0 {
+		errs = append(errs, errors.New("type of the first fixed surface is not set"))
+	}
+	first, err := encodeSurface(p.FirstSurface)
+	errs = append(errs, err)
+	second := []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+	if p.SecondSurface.Type != 0 {
+		second, err = encodeSurface(p.SecondSurface)
+		errs = append(errs, err)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	payload := binary.BigEndian.AppendUint16(nil, 0) // No coordinate values
+	payload = binary.BigEndian.AppendUint16(payload, 0)
+	payload = append(payload, p.Category, p.Number, p.GeneratingProcess, 0xff, p.ProcessIdentifier)
+	payload = append(payload, 0xff, 0xff, 0xff) // Data cutoff: missing
+	payload = append(payload, unit)
+	payload = binary.BigEndian.AppendUint32(payload, forecast)
+	payload = append(payload, first...)
+	return append(payload, second...), nil
+}
+
+// forecastTime returns the forecast time in the coarsest unit of Code Table 4.4 among hours,
+// minutes and seconds that represents it exactly
+func forecastTime(d time.Duration) (unit uint8, n uint32, err error) {
+	if d < 0 || d%time.Second != 0 {
+		return 0, 0, fmt.Errorf("forecast time %s is not a positive whole number of seconds", d)
+	}
+	unit, step := uint8(13), time.Second
+	switch {
+	case d%time.Hour == 0:
+		unit, step = 1, time.Hour
+	case d%time.Minute == 0:
+		unit, step = 0, time.Minute
+	}
+	if d/step > math.MaxUint32 {
+		return 0, 0, fmt.Errorf("forecast time %s is out of range", d)
+	}
+	return unit, uint32(d / step), nil
+}
+
+// encodeSurface returns the type, scale factor and scaled value of a fixed surface, with the
+// smallest scale factor that represents the value exactly
+func encodeSurface(s template.FixedSurface) ([]byte, error) {
+	if !s.HasValue {
+		return []byte{s.Type, 0xff, 0xff, 0xff, 0xff, 0xff}, nil
+	}
+	if !(s.Value >= 0) {
+		return nil, fmt.Errorf("value %g of fixed surface type %d cannot be coded", s.Value, s.Type)
+	}
+	for scale := range 10 {
+		scaled := s.Value * math.Pow(10, float64(scale))
+		rounded := math.Round(scaled)
+		if rounded >= math.MaxUint32 {
+			break
+		}
+		if math.Abs(scaled-rounded) <= 1e-9*max(1, scaled) {
+			surface := []byte{s.Type, units.EncodeSignMagnitudeInt8(int8(scale))}
+			return binary.BigEndian.AppendUint32(surface, uint32(rounded)), nil
+		}
+	}
+	return nil, fmt.Errorf("value %g of fixed surface type %d cannot be coded", s.Value, s.Type)
+}
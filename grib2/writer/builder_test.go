@@ -0,0 +1,166 @@
+package writer_test
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/scorix/grib/grib2/reader"
+	"github.com/scorix/grib/grib2/template"
+	"github.com/scorix/grib/grib2/writer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessageBuilder(t *testing.T) {
+	reference := time.Date(2024, 3, 1, 6, 0, 0, 0, time.UTC)
+	nan := math.NaN()
+	values := []float64{
+		271.25, 272.5, 273.75, 275,
+		270, nan, 274.5, 276.125,
+		269.5, 271, 273, 277.25,
+	}
+
+	msg, err := writer.NewMessageBuilder().
+		Discipline(0).Centre(7).SubCentre(2).ReferenceTime(reference).
+		LatLonGrid(writer.LatLonGrid{Ni: 4, Nj: 3, LatFirst: 50, LonFirst: -10, DLat: 0.5, DLon: 0.25, ShapeOfEarth: 6}).
+		Product(writer.Product{
+			Category: 0, Number: 0, GeneratingProcess: 2, ProcessIdentifier: 96,
+			ForecastTime: 90 * time.Minute,
+			FirstSurface: template.FixedSurface{Type: 103, Value: 2, HasValue: true},
+		}).
+		Values(values).
+		Precision(0.01).
+		Build()
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	require.NoError(t, writer.WriteMessage(&out, msg, writer.WithoutProvenance()))
+	assert.Equal(t, uint64(out.Len()), msg.Indicator.TotalLength())
+
+	var fields []reader.FlatMessage
+	require.NoError(t, reader.NewReaderAt(bytes.NewReader(out.Bytes())).EachFlatMessage(func(_ int, f reader.FlatMessage) bool {
+		fields = append(fields, f)
+		return true
+	}))
+	require.Len(t, fields, 1)
+	f := fields[0]
+
+	assert.Equal(t, 7, f.Centre)
+	assert.Equal(t, 2, f.SubCentre)
+	assert.True(t, f.IsForecast())
+	ref, err := f.ReferenceTime()
+	require.NoError(t, err)
+	assert.Equal(t, reference, ref)
+	forecast, err := f.ForecastDuration()
+	require.NoError(t, err)
+	assert.Equal(t, 90*time.Minute, forecast)
+	assert.Equal(t, uint8(96), f.Product.GeneratingProcessIdentifier)
+	assert.Equal(t, "2 m above ground", f.LevelString())
+
+	lats, lons, err := f.Coordinates()
+	require.NoError(t, err)
+	assert.Equal(t, []float64{50, 50, 50, 50, 49.5, 49.5, 49.5, 49.5, 49, 49, 49, 49}, lats)
+	assert.InDeltaSlice(t, []float64{350, 350.25, 350.5, 350.75}, lons[:4], 1e-9)
+
+	decoded, err := f.Values()
+	require.NoError(t, err)
+	require.Len(t, decoded, len(values))
+	for k, want := range values {
+		if math.IsNaN(want) {
+			assert.True(t, math.IsNaN(decoded[k]), "value %d", k)
+		} else {
+			assert.InDelta(t, want, decoded[k], 0.01, "value %d", k)
+		}
+	}
+}
+
+func TestMessageBuilder_Packing(t *testing.T) {
+	msg, err := writer.NewMessageBuilder().
+		ReferenceTime(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)).
+		LatLonGrid(writer.LatLonGrid{Ni: 2, Nj: 2, LatFirst: -1, DLat: 1, DLon: 1, ScanningMode: 0x40, ShapeOfEarth: 6}).
+		Product(writer.Product{
+			Category: 3, Number: 5,
+			FirstSurface: template.FixedSurface{Type: 100, Value: 85000, HasValue: true},
+		}).
+		Values([]float64{1500.4, 1510.6, 1490.2, 1480}).
+		Packing(0, 0).
+		Build()
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	require.NoError(t, writer.WriteMessage(&out, msg))
+	assert.Equal(t, [][]float64{{1500, 1511, 1490, 1480}}, decodeAll(t, out.Bytes()))
+
+	// An analysis from the type of generating process, on a grid scanning northwards
+	require.NoError(t, reader.NewReaderAt(bytes.NewReader(out.Bytes())).EachFlatMessage(func(_ int, f reader.FlatMessage) bool {
+		assert.True(t, f.IsAnalysis())
+		assert.Equal(t, "850 mb", f.LevelString())
+		lats, _, err := f.Coordinates()
+		require.NoError(t, err)
+		assert.Equal(t, []float64{-1, -1, 0, 0}, lats)
+		return true
+	}))
+}
+
+func TestMessageBuilder_TinyNegativeLongitude(t *testing.T) {
+	// math.Mod(-1e-10, 360) + 360 rounds to 360, which must not be coded as 360000000
+	msg, err := writer.NewMessageBuilder().
+		ReferenceTime(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)).
+		LatLonGrid(writer.LatLonGrid{Ni: 4, Nj: 2, LatFirst: 1, LonFirst: -1e-10, DLat: 1, DLon: 90, ShapeOfEarth: 6}).
+		Product(writer.Product{FirstSurface: template.FixedSurface{Type: 1}}).
+		Values(make([]float64, 8)).
+		Build()
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	require.NoError(t, writer.WriteMessage(&out, msg))
+	messages := 0
+	require.NoError(t, reader.NewReaderAt(bytes.NewReader(out.Bytes())).EachFlatMessage(func(_ int, f reader.FlatMessage) bool {
+		messages++
+		require.NotNil(t, f.Grid.LatLon)
+		assert.Equal(t, uint32(0), f.Grid.LatLon.LongitudeOfFirstGridPoint)
+		assert.Equal(t, uint32(270000000), f.Grid.LatLon.LongitudeOfLastGridPoint)
+		return true
+	}))
+	assert.Equal(t, 1, messages)
+}
+
+func TestMessageBuilder_Errors(t *testing.T) {
+	grid := writer.LatLonGrid{Ni: 2, Nj: 2, LatFirst: 10, DLat: 1, DLon: 1, ShapeOfEarth: 6}
+	product := writer.Product{FirstSurface: template.FixedSurface{Type: 1}}
+	reference := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	_, err := writer.NewMessageBuilder().Build()
+	assert.ErrorContains(t, err, "reference time is not set")
+	assert.ErrorContains(t, err, "grid is not set")
+	assert.ErrorContains(t, err, "product is not set")
+
+	_, err = writer.NewMessageBuilder().ReferenceTime(reference).LatLonGrid(grid).Product(product).Values(make([]float64, 3)).Build()
+	assert.ErrorContains(t, err, "writer: build: 3 values for a 2x2 grid")
+
+	for name, tc := range map[string]struct {
+		grid    writer.LatLonGrid
+		product writer.Product
+		want    string
+	}{
+		"dimensions":    {grid: writer.LatLonGrid{Ni: 0, Nj: 2, DLat: 1, DLon: 1}, product: product, want: "invalid grid dimensions 0x2"},
+		"increments":    {grid: writer.LatLonGrid{Ni: 2, Nj: 2, DLat: -1, DLon: 1}, product: product, want: "grid increments -1 and 1 are not positive"},
+		"latitude":      {grid: writer.LatLonGrid{Ni: 2, Nj: 2, LatFirst: 90, DLat: 1, DLon: 1, ScanningMode: 0x40}, product: product, want: "grid latitude 91 is out of range"},
+		"earth":         {grid: writer.LatLonGrid{Ni: 2, Nj: 2, DLat: 1, DLon: 1, ShapeOfEarth: 1}, product: product, want: "shape of the earth 1 needs axes"},
+		"surface":       {grid: grid, product: writer.Product{}, want: "type of the first fixed surface is not set"},
+		"surface value": {grid: grid, product: writer.Product{FirstSurface: template.FixedSurface{Type: 103, Value: -2, HasValue: true}}, want: "value -2 of fixed surface type 103 cannot be coded"},
+		"forecast time": {grid: grid, product: writer.Product{ForecastTime: 1500 * time.Millisecond, FirstSurface: product.FirstSurface}, want: "forecast time 1.5s is not a positive whole number of seconds"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := writer.NewMessageBuilder().ReferenceTime(reference).LatLonGrid(tc.grid).Product(tc.product).Values(make([]float64, 4)).Build()
+			assert.ErrorContains(t, err, tc.want)
+		})
+	}
+
+	_, err = writer.NewMessageBuilder().ReferenceTime(reference).LatLonGrid(grid).Product(product).Values(make([]float64, 4)).Packing(0, 40).Build()
+	assert.ErrorContains(t, err, "writer: build: packing:")
+	_, err = writer.NewMessageBuilder().ReferenceTime(reference).LatLonGrid(grid).Product(product).Values(make([]float64, 4)).Precision(1).Packing(0, 8).Build()
+	assert.ErrorContains(t, err, "both Precision and Packing are set")
+}
@@ -0,0 +1,159 @@
+package writer
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/scorix/grib/grib2/section"
+	"github.com/scorix/grib/grib2/spec"
+)
+
+// WriteMessage writes msg to w as a single message with a MessageWriter created with opts.
+// See MessageWriter.WriteMessage.
+func WriteMessage(w io.Writer, msg *spec.Message, opts ...Option) error {
+	mw := NewMessageWriter(w, opts...)
+	_, err := mw.WriteMessage(msg)
+	return err
+}
+
+// WriteMessage writes msg, serializing each of its sections from the values it reports,
+// and returns the total length of the message. Section 0 carries the discipline of msg
+// and the total length of the message written; Section 8 ends it.
+//
+// The local, grid and field blocks are written in order. A grid block that shares the
+// Section 3 of the previous one, as does a local block without a grid definition of its
+// own, does not repeat it. Template octets are written as they were read, so a message
+// read from a file is written back octet for octet, except for octets the sections do not
+// keep, such as the reserved octets of a Section 1 read without them. The MessageWriter
+// still adds its provenance Section 2 after the Section 1 of a message without a local
+// use section, growing the message by that section; create it with WithoutProvenance to
+// copy messages unchanged.
+func (mw *MessageWriter) WriteMessage(msg *spec.Message) (uint64, error) {
+	if msg.Indicator == nil || msg.Identification == nil {
+		return 0, fmt.Errorf("writer: message without Section 0 or Section 1")
+	}
+	if err := mw.Begin(msg.Indicator.Discipline()); err != nil {
+		return 0, err
+	}
+	if err := mw.writeSection(msg.Identification); err != nil {
+		return 0, err
+	}
+
+	var lastGrid section.Section3
+	for _, local := range msg.Blocks {
+		if local.LocalUse != nil {
+			if err := mw.writeSection(local.LocalUse); err != nil {
+				return 0, err
+			}
+		}
+		for _, grid := range local.Grids {
+			if grid.GridDef != lastGrid {
+				if err := mw.writeSection(grid.GridDef); err != nil {
+					return 0, err
+				}
+				lastGrid = grid.GridDef
+			}
+			for _, field := range grid.Fields {
+				if err := mw.writeField(field); err != nil {
+					return 0, err
+				}
+			}
+		}
+	}
+
+	return mw.End()
+}
+
+// writeField writes the Sections 4-7 of a data field
+func (mw *MessageWriter) writeField(field spec.DataField) error {
+	if field.ProductDef == nil || field.DataRep == nil || field.Data == nil {
+		return fmt.Errorf("writer: data field without Section 4, 5 or 7")
+	}
+	for _, sec := range []section.Section{field.ProductDef, field.DataRep} {
+		if err := mw.writeSection(sec); err != nil {
+			return err
+		}
+	}
+	if field.Bitmap != nil {
+		if err := mw.writeSection(field.Bitmap); err != nil {
+			return err
+		}
+	}
+
+	// The data is streamed rather than held in memory
+	if err := field.Data.LoadError(); err != nil {
+		return fmt.Errorf("writer: Section 7: %w", err)
+	}
+	return mw.WriteSectionFrom(7, field.Data.DataReader(), int64(field.Data.DataSize()))
+}
+
+// writeSection writes a Section 1-6 serialized with Payload
+func (mw *MessageWriter) writeSection(sec section.Section) error {
+	payload, err := Payload(sec)
+	if err != nil {
+		return err
+	}
+	return mw.WriteSection(sec.SectionNumber(), payload)
+}
+
+// Payload serializes a Section 1-7 into its payload, the octets following the section
+// number, from the values the section reports. Sections shorter than their defined octets,
+// such as a truncated Section 1, keep their length.
+func Payload(sec section.Section) ([]byte, error) {
+	var payload []byte
+	switch s := sec.(type) {
+	case section.Section1:
+		payload = binary.BigEndian.AppendUint16(payload, s.OriginatingCenter())
+		payload = binary.BigEndian.AppendUint16(payload, s.OriginatingSubcenter())
+		payload = append(payload, s.MasterTablesVersion(), s.LocalTablesVersion(), s.ReferenceTimeSignificance())
+		payload = binary.BigEndian.AppendUint16(payload, s.Year())
+		payload = append(payload, s.Month(), s.Day(), s.Hour(), s.Minute(), s.Second())
+		payload = append(payload, s.ProductionStatus(), s.DataType())
+		payload = append(payload, s.Reserved()...)
+		if s.Truncated() {
+			payload = payload[:max(int(s.Length())-sectionHeaderLength, 0)]
+		}
+	case section.Section2:
+		payload = append(payload, s.LocalUseData()...)
+	case section.Section3:
+		width := s.OptionalListOctets()
+		if width > 4 {
+			return nil, fmt.Errorf("writer: Section 3 optional list entries of %d octets", width)
+		}
+		payload = append(payload, s.GridDefinitionSource())
+		payload = binary.BigEndian.AppendUint32(payload, s.NumberOfDataPoints())
+		payload = append(payload, uint8(width), s.OptionalListInterpretation())
+		payload = binary.BigEndian.AppendUint16(payload, s.GridDefinitionTemplateNumber())
+		payload = append(payload, s.GridDefinitionTemplate()...)
+		for _, n := range s.OptionalList() {
+			payload = append(payload, binary.BigEndian.AppendUint32(nil, n)[4-width:]...)
+		}
+	case section.Section4:
+		coordinates := s.CoordinateValues()
+		payload = binary.BigEndian.AppendUint16(payload, uint16(len(coordinates)))
+		payload = binary.BigEndian.AppendUint16(payload, s.ProductDefinitionTemplateNumber())
+		payload = append(payload, s.ProductDefinitionTemplate()...)
+		for _, v := range coordinates {
+			payload = binary.BigEndian.AppendUint32(payload, math.Float32bits(v))
+		}
+	case section.Section5:
+		payload = binary.BigEndian.AppendUint32(payload, s.NumberOfDataPoints())
+		payload = binary.BigEndian.AppendUint16(payload, s.DataRepresentationTemplateNumber())
+		payload = append(payload, s.DataRepresentationTemplate()...)
+	case section.Section6:
+		payload = append(payload, s.BitMapIndicator())
+		if s.HasBitMap() {
+			payload = append(payload, s.BitMap()...)
+		}
+	case section.Section7:
+		if err := s.LoadError(); err != nil {
+			return nil, fmt.Errorf("writer: Section 7: %w", err)
+		}
+		payload = append(payload, s.Data()...)
+	default:
+		return nil, fmt.Errorf("writer: cannot serialize Section %d", sec.SectionNumber())
+	}
+	return payload, nil
+}
@@ -0,0 +1,195 @@
+package writer_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"os"
+	"testing"
+
+	"github.com/scorix/grib/grib2/internal/testgrib"
+	"github.com/scorix/grib/grib2/packing"
+	"github.com/scorix/grib/grib2/reader"
+	"github.com/scorix/grib/grib2/section"
+	"github.com/scorix/grib/grib2/units"
+	"github.com/scorix/grib/grib2/writer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readMessages reads every message of a file into the nested specification structure
+func readMessages(t *testing.T, data []byte) []*reader.Message {
+	r := reader.NewReaderAt(bytes.NewReader(data))
+	var infos []reader.MessageInfo
+	require.NoError(t, r.EachMessage(func(_ int, info reader.MessageInfo) bool {
+		infos = append(infos, info)
+		return true
+	}))
+
+	messages := make([]*reader.Message, len(infos))
+	for k, info := range infos {
+		msg, err := r.ReadMessage(info)
+		require.NoError(t, err)
+		messages[k] = msg
+	}
+	return messages
+}
+
+// decodeAll decodes the fields of a file
+func decodeAll(t *testing.T, data []byte) [][]float64 {
+	var fields [][]float64
+	require.NoError(t, reader.NewReaderAt(bytes.NewReader(data)).EachFlatMessage(func(_ int, f reader.FlatMessage) bool {
+		values, err := f.Values()
+		require.NoError(t, err)
+		fields = append(fields, values)
+		return true
+	}))
+	return fields
+}
+
+func TestWriteMessage_RoundTrip(t *testing.T) {
+	gfs, err := os.ReadFile("../reader/testdata/gfs.t00z.pgrb2.0p25.f000")
+	require.NoError(t, err)
+	bitmapped := testgrib.MustEncode(testgrib.Spec{
+		Ni: 3, Nj: 2,
+		Values: []float64{1, 2, 3, 4, 5, 6},
+		Bitmap: []bool{true, false, true, true, true, false},
+	})
+
+	for name, data := range map[string][]byte{"gfs": gfs, "bit-map": bitmapped} {
+		t.Run(name, func(t *testing.T) {
+			messages := readMessages(t, data)
+			require.NotEmpty(t, messages)
+
+			var out bytes.Buffer
+			for _, msg := range messages {
+				var single bytes.Buffer
+				require.NoError(t, writer.WriteMessage(&single, &msg.Message, writer.WithoutProvenance()))
+
+				// Every section is written back octet for octet
+				original := data[msg.Info.Offset : msg.Info.Offset+int64(msg.Info.Length)]
+				require.Equal(t, len(original), single.Len())
+				assert.True(t, bytes.Equal(original, single.Bytes()), "message %d", msg.Info.Index)
+				out.Write(single.Bytes())
+			}
+
+			want := decodeAll(t, data)
+			got := decodeAll(t, out.Bytes())
+			require.Len(t, got, len(want))
+			for k := range want {
+				assert.Equal(t, len(want[k]), len(got[k]))
+				for i := range want[k] {
+					if math.IsNaN(want[k][i]) {
+						assert.True(t, math.IsNaN(got[k][i]))
+					} else {
+						assert.Equal(t, want[k][i], got[k][i])
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestWriteMessage_RoundTripProvenance(t *testing.T) {
+	gfs, err := os.ReadFile("../reader/testdata/gfs.t00z.pgrb2.0p25.f000")
+	require.NoError(t, err)
+
+	for _, msg := range readMessages(t, gfs) {
+		original := gfs[msg.Info.Offset : msg.Info.Offset+int64(msg.Info.Length)]
+		var single bytes.Buffer
+		require.NoError(t, writer.WriteMessage(&single, &msg.Message))
+		written := single.Bytes()
+
+		// With the default options, the only change is the provenance Section 2 following
+		// Section 1 in a message without one, and the total length that counts it
+		sec1End := 16 + int(binary.BigEndian.Uint32(written[16:]))
+		if len(msg.Blocks) > 0 && msg.Blocks[0].LocalUse == nil {
+			require.Equal(t, uint8(2), written[sec1End+4], "message %d", msg.Info.Index)
+			localUse := written[sec1End : sec1End+int(binary.BigEndian.Uint32(written[sec1End:]))]
+			p, ok, err := section.ParseProvenance(localUse[5:])
+			require.NoError(t, err)
+			require.True(t, ok)
+			assert.Equal(t, writer.DefaultProvenance(), p)
+
+			written = append(bytes.Clone(written[:sec1End]), written[sec1End+len(localUse):]...)
+			binary.BigEndian.PutUint64(written[8:], uint64(len(written)))
+		}
+		assert.True(t, bytes.Equal(original, written), "message %d", msg.Info.Index)
+	}
+}
+
+func TestWriteMessage_Repacked(t *testing.T) {
+	gfs, err := os.ReadFile("../reader/testdata/gfs.t00z.pgrb2.0p25.f000")
+	require.NoError(t, err)
+	original := decodeAll(t, gfs)
+
+	// Decode each field, pack it again with the decimal scale factor and bits of the file,
+	// write it with the other sections unchanged and decode it back
+	var out bytes.Buffer
+	mw := writer.NewMessageWriter(&out, writer.WithoutProvenance())
+	var steps []float64
+	for k, msg := range readMessages(t, gfs) {
+		require.Len(t, msg.Blocks, 1)
+		require.Len(t, msg.Blocks[0].Grids, 1)
+		require.Len(t, msg.Blocks[0].Grids[0].Fields, 1)
+		grid := msg.Blocks[0].Grids[0]
+		dataRep := grid.Fields[0].DataRep.DataRepresentationTemplate()
+
+		params, err := packing.ChoosePackingBits(original[k], units.SignMagnitudeInt16(binary.BigEndian.Uint16(dataRep[6:8])), dataRep[8])
+		require.NoError(t, err)
+		field, err := packing.EncodeSimpleParams(original[k], params)
+		require.NoError(t, err)
+		steps = append(steps, params.Step())
+
+		require.NoError(t, mw.Begin(msg.Indicator.Discipline()))
+		for _, sec := range []section.Section{msg.Identification, grid.GridDef, grid.Fields[0].ProductDef} {
+			payload, err := writer.Payload(sec)
+			require.NoError(t, err)
+			require.NoError(t, mw.WriteSection(sec.SectionNumber(), payload))
+		}
+		require.NoError(t, mw.WriteSection(5, field.DataRepresentationPayload()))
+		require.NoError(t, mw.WriteSection(6, field.BitmapPayload()))
+		require.NoError(t, mw.WriteSection(7, field.Data))
+		_, err = mw.End()
+		require.NoError(t, err)
+	}
+
+	repacked := decodeAll(t, out.Bytes())
+	require.Len(t, repacked, len(original))
+	for k := range original {
+		require.Len(t, repacked[k], len(original[k]))
+		for i, want := range original[k] {
+			if math.Abs(repacked[k][i]-want) > steps[k]/2+1e-9*math.Abs(want) {
+				t.Fatalf("field %d value %d: %v repacked as %v with step %v", k, i, want, repacked[k][i], steps[k])
+			}
+		}
+	}
+}
+
+func TestWriteMessage_Provenance(t *testing.T) {
+	msg := readMessages(t, testgrib.MustEncode(testgrib.Spec{}))[0]
+
+	var out bytes.Buffer
+	mw := writer.NewMessageWriter(&out)
+	length, err := mw.WriteMessage(&msg.Message)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(out.Len()), length)
+
+	// The provenance Section 2 is added to a message without one
+	written := readMessages(t, out.Bytes())
+	require.Len(t, written, 1)
+	require.Len(t, written[0].Blocks, 1)
+	require.NotNil(t, written[0].Blocks[0].LocalUse)
+	assert.Equal(t, msg.Info.Length+uint64(written[0].Blocks[0].LocalUse.Length()), length)
+}
+
+func TestWriteMessage_Errors(t *testing.T) {
+	msg := readMessages(t, testgrib.MustEncode(testgrib.Spec{}))[0]
+
+	incomplete := msg.Message
+	incomplete.Identification = nil
+	assert.ErrorContains(t, writer.WriteMessage(&bytes.Buffer{}, &incomplete), "without Section 0 or Section 1")
+
+	_, err := writer.Payload(msg.End)
+	assert.ErrorContains(t, err, "cannot serialize Section 8")
+}
@@ -0,0 +1,71 @@
+package writer
+
+import (
+	"runtime/debug"
+
+	"github.com/scorix/grib/grib2/section"
+)
+
+// modulePath is the module path of this package, the software named in the default provenance
+const modulePath = "github.com/scorix/grib/grib2"
+
+// Option configures a MessageWriter
+type Option func(*options)
+
+type options struct {
+	tablesVersion *[2]uint8           // Master and local tables versions set in Section 1, if any
+	provenance    *section.Provenance // Provenance recorded in Section 2, if any
+}
+
+// WithTablesVersion sets the version numbers of the GRIB master tables and local tables,
+// octets 10 and 11 of Section 1, in every message written, replacing those in the Section 1
+// payloads given to WriteSection
+func WithTablesVersion(master, local uint8) Option {
+	return func(o *options) {
+		o.tablesVersion = &[2]uint8{master, local}
+	}
+}
+
+// WithProvenance records p in a Section 2 of every message that does not write one after
+// its Section 1, instead of the provenance of this package
+func WithProvenance(p section.Provenance) Option {
+	return func(o *options) {
+		o.provenance = &p
+	}
+}
+
+// WithoutProvenance disables the Section 2 recording provenance, so that the sections of a
+// message are written exactly as given
+func WithoutProvenance() Option {
+	return func(o *options) {
+		o.provenance = nil
+	}
+}
+
+// DefaultProvenance returns the provenance recorded by default: this package's module path
+// and the version it was built at, "(devel)" when the version is unknown
+func DefaultProvenance() section.Provenance {
+	p := section.Provenance{Software: modulePath, Version: "(devel)"}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return p
+	}
+	if info.Main.Path == modulePath && info.Main.Version != "" {
+		p.Version = info.Main.Version
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == modulePath && dep.Version != "" {
+			p.Version = dep.Version
+		}
+	}
+	return p
+}
+
+func newOptions(opts []Option) options {
+	p := DefaultProvenance()
+	o := options{provenance: &p}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
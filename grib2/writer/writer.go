@@ -0,0 +1,229 @@
+// Package writer writes GRIB2 messages.
+package writer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// sectionHeaderLength is the length of the header of Sections 1-7: a 4 octet length and the section number
+const sectionHeaderLength = 5
+
+// MessageWriter writes GRIB2 messages one section at a time, without holding a whole
+// message in memory. Section payloads, including large Section 7 data, are copied to the
+// sink as they are produced, and the total length in Section 0 is back-patched once the
+// message ends.
+//
+// Back-patching needs a sink that implements io.Seeker or io.WriterAt. A sink that only
+// implements io.Writer cannot be patched; messages written to it are buffered in memory
+// until End, which is the documented fallback for pipes and network connections.
+// A sink implementing io.WriterAt but not io.Seeker must be empty when the MessageWriter
+// is created, since message offsets are then counted from its first byte.
+//
+// By default each message records the provenance of this package in a Section 2 written
+// after its Section 1, unless the message writes its own Section 2 there; see
+// WithProvenance and WithoutProvenance.
+type MessageWriter struct {
+	sink   io.Writer
+	seeker io.Seeker   // Sink used to back-patch by seeking, when it can seek
+	at     io.WriterAt // Sink used to back-patch by offset, when it cannot seek
+
+	written int64         // Bytes written to the sink, for io.WriterAt sinks
+	start   int64         // Offset of the current message in the sink
+	length  uint64        // Bytes of the current message written so far
+	buffer  *bytes.Buffer // Current message, when the sink cannot be back-patched
+	open    bool          // A message was begun and not ended
+	last    uint8         // Number of the last section written in the current message
+
+	opts options
+}
+
+// NewMessageWriter creates a MessageWriter writing to w
+func NewMessageWriter(w io.Writer, opts ...Option) *MessageWriter {
+	mw := &MessageWriter{sink: w, opts: newOptions(opts)}
+	if seeker, ok := w.(io.Seeker); ok {
+		mw.seeker = seeker
+	} else if at, ok := w.(io.WriterAt); ok {
+		mw.at = at
+	}
+	return mw
+}
+
+// Streaming reports whether messages go straight to the sink; when false each message is
+// buffered until End because the sink cannot be back-patched
+func (mw *MessageWriter) Streaming() bool {
+	return mw.seeker != nil || mw.at != nil
+}
+
+// Begin starts a message of the given discipline by writing its Section 0. The total
+// length is written as zero and back-patched by End.
+func (mw *MessageWriter) Begin(discipline uint8) error {
+	if mw.open {
+		return errors.New("writer: previous message was not ended")
+	}
+
+	switch {
+	case mw.seeker != nil:
+		start, err := mw.seeker.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return fmt.Errorf("writer: failed to locate the start of the message: %w", err)
+		}
+		mw.start = start
+	case mw.at != nil:
+		mw.start = mw.written
+	default:
+		mw.buffer = &bytes.Buffer{}
+	}
+
+	mw.open = true
+	mw.length = 0
+	mw.last = 0
+	return mw.write([]byte{'G', 'R', 'I', 'B', 0x00, 0x00, discipline, 0x02, 0, 0, 0, 0, 0, 0, 0, 0})
+}
+
+// WriteSection writes a Section 1-7 with the given payload, the octets following the
+// section number
+func (mw *MessageWriter) WriteSection(number uint8, payload []byte) error {
+	return mw.WriteSectionFrom(number, bytes.NewReader(payload), int64(len(payload)))
+}
+
+// WriteSectionFrom writes a Section 1-7 whose payload of the given length is read from r as
+// it is copied to the sink. It fails when r holds fewer than length octets.
+func (mw *MessageWriter) WriteSectionFrom(number uint8, r io.Reader, length int64) error {
+	if !mw.open {
+		return errors.New("writer: no message was begun")
+	}
+	if number < 1 || number > 7 {
+		return fmt.Errorf("writer: invalid section number %d", number)
+	}
+	if length < 0 || length > math.MaxUint32-sectionHeaderLength {
+		return fmt.Errorf("writer: invalid Section %d payload length %d", number, length)
+	}
+
+	if mw.last == 1 && number != 2 && mw.opts.provenance != nil {
+		if err := mw.writeProvenance(); err != nil {
+			return err
+		}
+	}
+	if number == 1 && mw.opts.tablesVersion != nil {
+		payload, err := mw.identification(r, length)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(payload)
+	}
+
+	header := binary.BigEndian.AppendUint32(nil, uint32(length+sectionHeaderLength))
+	if err := mw.write(append(header, number)); err != nil {
+		return err
+	}
+	mw.last = number
+
+	copied, err := io.CopyN(mw.out(), r, length)
+	mw.advance(copied)
+	if err != nil {
+		return fmt.Errorf("writer: Section %d payload: %w", number, err)
+	}
+	return nil
+}
+
+// writeProvenance writes the Section 2 recording the provenance
+func (mw *MessageWriter) writeProvenance() error {
+	localUse, err := mw.opts.provenance.MarshalLocalUse()
+	if err != nil {
+		return fmt.Errorf("writer: %w", err)
+	}
+	return mw.WriteSection(2, localUse)
+}
+
+// identification reads a Section 1 payload of the given length and sets the tables versions
+// given with WithTablesVersion
+func (mw *MessageWriter) identification(r io.Reader, length int64) ([]byte, error) {
+	// The versions are octets 10 and 11, following the centre and sub-centre
+	if length < 6 {
+		return nil, fmt.Errorf("writer: Section 1 payload of %d octets has no tables versions", length)
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, fmt.Errorf("writer: Section 1 payload: %w", err)
+	}
+	payload[4], payload[5] = mw.opts.tablesVersion[0], mw.opts.tablesVersion[1]
+	return payload, nil
+}
+
+// End writes Section 8, back-patches the total length in Section 0 and returns it
+func (mw *MessageWriter) End() (uint64, error) {
+	if !mw.open {
+		return 0, errors.New("writer: no message was begun")
+	}
+	if err := mw.write([]byte{'7', '7', '7', '7'}); err != nil {
+		return 0, err
+	}
+	mw.open = false
+
+	totalLength := binary.BigEndian.AppendUint64(nil, mw.length)
+	switch {
+	case mw.seeker != nil:
+		if err := mw.patchBySeeking(totalLength); err != nil {
+			return 0, err
+		}
+	case mw.at != nil:
+		if _, err := mw.at.WriteAt(totalLength, mw.start+8); err != nil {
+			return 0, fmt.Errorf("writer: failed to write the total length: %w", err)
+		}
+	default:
+		message := mw.buffer.Bytes()
+		copy(message[8:16], totalLength)
+		mw.buffer = nil
+		if _, err := mw.sink.Write(message); err != nil {
+			return 0, fmt.Errorf("writer: %w", err)
+		}
+	}
+
+	return mw.length, nil
+}
+
+// patchBySeeking writes the total length by seeking back to Section 0, then returns to the
+// end of the message
+func (mw *MessageWriter) patchBySeeking(totalLength []byte) error {
+	if _, err := mw.seeker.Seek(mw.start+8, io.SeekStart); err != nil {
+		return fmt.Errorf("writer: failed to seek to Section 0: %w", err)
+	}
+	if _, err := mw.sink.Write(totalLength); err != nil {
+		return fmt.Errorf("writer: failed to write the total length: %w", err)
+	}
+	if _, err := mw.seeker.Seek(mw.start+int64(mw.length), io.SeekStart); err != nil {
+		return fmt.Errorf("writer: failed to seek to the end of the message: %w", err)
+	}
+	return nil
+}
+
+// out returns where the octets of the current message go
+func (mw *MessageWriter) out() io.Writer {
+	if mw.buffer != nil {
+		return mw.buffer
+	}
+	return mw.sink
+}
+
+// write writes p as part of the current message
+func (mw *MessageWriter) write(p []byte) error {
+	n, err := mw.out().Write(p)
+	mw.advance(int64(n))
+	if err != nil {
+		return fmt.Errorf("writer: %w", err)
+	}
+	return nil
+}
+
+// advance accounts for n octets written to the current message
+func (mw *MessageWriter) advance(n int64) {
+	mw.length += uint64(n)
+	if mw.buffer == nil {
+		mw.written += n
+	}
+}
@@ -0,0 +1,261 @@
+package writer_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/scorix/grib/grib2/internal/testgrib"
+	"github.com/scorix/grib/grib2/reader"
+	"github.com/scorix/grib/grib2/section"
+	"github.com/scorix/grib/grib2/writer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sectionPayloads returns the payloads of Sections 1-7 of a single field message, keyed by number
+func sectionPayloads(t *testing.T, message []byte) map[uint8][]byte {
+	payloads := make(map[uint8][]byte)
+	require.NoError(t, reader.NewReaderAt(bytes.NewReader(message)).EachMessage(func(_ int, info reader.MessageInfo) bool {
+		for _, sec := range info.Sections {
+			if sec.Number >= 1 && sec.Number <= 7 {
+				payloads[sec.Number] = message[sec.Offset+5 : sec.Offset+int64(sec.Length)]
+			}
+		}
+		return true
+	}))
+	return payloads
+}
+
+// writeMessage writes the sections of a single field message through mw
+func writeMessage(t *testing.T, mw *writer.MessageWriter, message []byte) uint64 {
+	require.NoError(t, mw.Begin(message[6]))
+	payloads := sectionPayloads(t, message)
+	for number := uint8(1); number <= 7; number++ {
+		if payload, ok := payloads[number]; ok {
+			require.NoError(t, mw.WriteSection(number, payload))
+		}
+	}
+	length, err := mw.End()
+	require.NoError(t, err)
+	return length
+}
+
+// writerAtBuffer is an in-memory sink implementing io.WriterAt but not io.Seeker
+type writerAtBuffer struct {
+	data []byte
+}
+
+func (b *writerAtBuffer) Write(p []byte) (int, error) {
+	b.data = append(b.data, p...)
+	return len(p), nil
+}
+
+func (b *writerAtBuffer) WriteAt(p []byte, off int64) (int, error) {
+	return copy(b.data[off:], p), nil
+}
+
+func TestMessageWriterSinks(t *testing.T) {
+	first := testgrib.MustEncode(testgrib.Spec{})
+	second := testgrib.MustEncode(testgrib.Spec{Discipline: 10, Ni: 3, Nj: 2})
+	want := append(append([]byte(nil), first...), second...)
+
+	t.Run("io.Writer", func(t *testing.T) {
+		var sink bytes.Buffer
+		mw := writer.NewMessageWriter(&sink, writer.WithoutProvenance())
+		assert.False(t, mw.Streaming())
+
+		assert.Equal(t, uint64(len(first)), writeMessage(t, mw, first))
+		assert.Equal(t, uint64(len(second)), writeMessage(t, mw, second))
+		assert.Equal(t, want, sink.Bytes())
+	})
+
+	t.Run("io.WriterAt", func(t *testing.T) {
+		sink := &writerAtBuffer{}
+		mw := writer.NewMessageWriter(sink, writer.WithoutProvenance())
+		assert.True(t, mw.Streaming())
+
+		writeMessage(t, mw, first)
+		writeMessage(t, mw, second)
+		assert.Equal(t, want, sink.data)
+	})
+
+	t.Run("io.Seeker", func(t *testing.T) {
+		f, err := os.Create(filepath.Join(t.TempDir(), "out.grib2"))
+		require.NoError(t, err)
+		defer f.Close()
+
+		// Messages are written from the current position of the sink
+		_, err = f.Write([]byte("header"))
+		require.NoError(t, err)
+
+		mw := writer.NewMessageWriter(f, writer.WithoutProvenance())
+		assert.True(t, mw.Streaming())
+		writeMessage(t, mw, first)
+		writeMessage(t, mw, second)
+
+		got, err := os.ReadFile(f.Name())
+		require.NoError(t, err)
+		assert.Equal(t, append([]byte("header"), want...), got)
+	})
+}
+
+func TestMessageWriterProvenance(t *testing.T) {
+	message := testgrib.MustEncode(testgrib.Spec{})
+	fields := func(data []byte) []reader.FlatMessage {
+		var fields []reader.FlatMessage
+		require.NoError(t, reader.NewReaderAt(bytes.NewReader(data)).EachFlatMessage(func(_ int, f reader.FlatMessage) bool {
+			fields = append(fields, f)
+			return true
+		}))
+		require.Len(t, fields, 1)
+		return fields
+	}
+
+	t.Run("default", func(t *testing.T) {
+		var sink bytes.Buffer
+		mw := writer.NewMessageWriter(&sink, writer.WithTablesVersion(33, 1))
+		length := writeMessage(t, mw, message)
+		assert.Equal(t, uint64(sink.Len()), length)
+
+		field := fields(sink.Bytes())[0]
+		assert.Equal(t, 33, field.MasterTablesVersion)
+		assert.Equal(t, 1, field.LocalTablesVersion)
+		p, ok, err := field.Provenance()
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.Equal(t, writer.DefaultProvenance(), p)
+		assert.Equal(t, "github.com/scorix/grib/grib2", p.Software)
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		want := section.Provenance{Software: "example.com/pipeline", Version: "v1.2.3"}
+		var sink bytes.Buffer
+		writeMessage(t, writer.NewMessageWriter(&sink, writer.WithProvenance(want)), message)
+
+		p, ok, err := fields(sink.Bytes())[0].Provenance()
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.Equal(t, want, p)
+	})
+
+	t.Run("own local use", func(t *testing.T) {
+		var sink bytes.Buffer
+		mw := writer.NewMessageWriter(&sink)
+		require.NoError(t, mw.Begin(0))
+		payloads := sectionPayloads(t, message)
+		require.NoError(t, mw.WriteSection(1, payloads[1]))
+		require.NoError(t, mw.WriteSection(2, []byte("centre data")))
+		for number := uint8(3); number <= 7; number++ {
+			require.NoError(t, mw.WriteSection(number, payloads[number]))
+		}
+		_, err := mw.End()
+		require.NoError(t, err)
+
+		field := fields(sink.Bytes())[0]
+		assert.Equal(t, []byte("centre data"), field.LocalUse.LocalUseData())
+		_, ok, err := field.Provenance()
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("suppressed", func(t *testing.T) {
+		var sink bytes.Buffer
+		writeMessage(t, writer.NewMessageWriter(&sink, writer.WithoutProvenance()), message)
+		assert.Equal(t, message, sink.Bytes())
+	})
+}
+
+func TestMessageWriterErrors(t *testing.T) {
+	mw := writer.NewMessageWriter(io.Discard)
+
+	assert.ErrorContains(t, mw.WriteSection(1, nil), "no message was begun")
+	_, err := mw.End()
+	assert.ErrorContains(t, err, "no message was begun")
+
+	require.NoError(t, mw.Begin(0))
+	assert.ErrorContains(t, mw.Begin(0), "previous message was not ended")
+	assert.ErrorContains(t, mw.WriteSection(8, nil), "invalid section number 8")
+	assert.ErrorContains(t, mw.WriteSectionFrom(7, bytes.NewReader([]byte{1, 2}), 3), "Section 7 payload: EOF")
+}
+
+func TestMessageWriterStreamsLargeField(t *testing.T) {
+	if testing.Short() {
+		t.Skip("writes a 100 MB field")
+	}
+
+	const (
+		ni, nj = 10000, 10000 // One octet per value at 8 bits
+		points = ni * nj
+	)
+
+	// Headers of a 4x4 field at 8 bits per value, resized to the large grid
+	payloads := sectionPayloads(t, testgrib.MustEncode(testgrib.Spec{BitsPerValue: 8}))
+	binary.BigEndian.PutUint32(payloads[3][1:5], points) // number of data points
+	binary.BigEndian.PutUint32(payloads[3][25:29], ni)   // Ni
+	binary.BigEndian.PutUint32(payloads[3][29:33], nj)   // Nj
+	binary.BigEndian.PutUint32(payloads[5][0:4], points) // number of packed values
+
+	f, err := os.Create(filepath.Join(t.TempDir(), "large.grib2"))
+	require.NoError(t, err)
+	defer f.Close()
+
+	// The packed data is produced on the other end of a pipe while it is written
+	data, producer := io.Pipe()
+	go func() {
+		chunk := make([]byte, 64*1024)
+		for i := range chunk {
+			chunk[i] = byte(i)
+		}
+		for remaining := points; remaining > 0; remaining -= len(chunk) {
+			if _, err := producer.Write(chunk[:min(len(chunk), remaining)]); err != nil {
+				return
+			}
+		}
+		producer.Close()
+	}()
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+
+	mw := writer.NewMessageWriter(f)
+	require.NoError(t, mw.Begin(0))
+	for _, number := range []uint8{1, 3, 4, 5, 6} {
+		require.NoError(t, mw.WriteSection(number, payloads[number]))
+	}
+	require.NoError(t, mw.WriteSectionFrom(7, data, points))
+	length, err := mw.End()
+	require.NoError(t, err)
+
+	runtime.ReadMemStats(&after)
+	assert.Less(t, after.TotalAlloc-before.TotalAlloc, uint64(16<<20), "the field must not be buffered")
+
+	var infos []reader.MessageInfo
+	require.NoError(t, reader.NewReaderAt(f).EachMessage(func(_ int, info reader.MessageInfo) bool {
+		infos = append(infos, info)
+		return true
+	}))
+	require.Len(t, infos, 1)
+	assert.Equal(t, length, infos[0].Length)
+	assert.Empty(t, infos[0].Anomalies)
+
+	message, err := reader.NewReaderAt(f).ReadMessage(infos[0])
+	require.NoError(t, err)
+	require.NoError(t, message.Validate())
+
+	fields := message.FlattenToFlatMessages()
+	require.Len(t, fields, 1)
+	sections := fields[0].SectionRanges()
+	assert.Equal(t, int64(points+5), sections[7].Length)
+
+	// The data continues the 64 KiB pattern across chunks
+	tail := make([]byte, 4)
+	_, err = f.ReadAt(tail, sections[7].End()-4)
+	require.NoError(t, err)
+	last := points - 1
+	assert.Equal(t, []byte{byte(last - 3), byte(last - 2), byte(last - 1), byte(last)}, tail)
+}